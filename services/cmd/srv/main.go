@@ -1,50 +1,212 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	corewebhook "github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
 	data "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/email"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/eventbus"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/metrics"
 	"github.com/benjohns1/scheduled-tasks/services/internal/infra/scheduler"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/stream"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/tracing"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/webhook"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/ratelimit"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	l := log.New(os.Stderr, "main ", log.LstdFlags)
-
 	loadEnv()
 
+	lc, err := newLogConfig()
+	l := logging.New(os.Stderr, "main", lc)
+	if err != nil {
+		l.Warn("invalid log configuration, using defaults", "error", err)
+	}
+	scLog := logging.New(os.Stderr, "sched", lc)
+	acLog := logging.New(os.Stderr, "api", lc)
+	whLog := logging.New(os.Stderr, "webhook", lc)
+	evLog := logging.New(os.Stderr, "events", lc)
+	ntLog := logging.New(os.Stderr, "notify", lc)
+	chLog := logging.New(os.Stderr, "chat", lc)
+	atLog := logging.New(os.Stderr, "action", lc)
+	cmLog := logging.New(os.Stderr, "command", lc)
+	m := metrics.New()
+
+	tc, err := newTraceConfig()
+	if err != nil {
+		l.Warn("invalid trace configuration, tracing disabled", "error", err)
+	}
+	shutdownTracing, err := tracing.Start(tc)
+	if err != nil {
+		l.Panic(err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			l.Warn("error flushing traces", "error", err)
+		}
+	}()
+
 	// Scheduler DB connection
-	scConn := data.NewDBConn(l, "scheduler")
+	scConn := data.NewDBConn(scLog, "scheduler")
 	if err := scConn.Connect(); err != nil {
 		l.Panic(err)
 	}
 	defer scConn.Close()
+	m.RegisterDB("scheduler", scConn.DB)
 
 	// API DB connection
-	acConn := data.NewDBConn(l, "api")
+	acConn := data.NewDBConn(acLog, "api")
 	if err := acConn.Connect(); err != nil {
 		l.Panic(err)
 	}
 	defer acConn.Close()
+	m.RegisterDB("api", acConn.DB)
+
+	// Webhook DB connection
+	whConn := data.NewDBConn(whLog, "webhook")
+	if err := whConn.Connect(); err != nil {
+		l.Panic(err)
+	}
+	defer whConn.Close()
+	m.RegisterDB("webhook", whConn.DB)
+
+	// Event bus DB connection
+	evConn := data.NewDBConn(evLog, "events")
+	if err := evConn.Connect(); err != nil {
+		l.Panic(err)
+	}
+	defer evConn.Close()
+	m.RegisterDB("events", evConn.DB)
+
+	// Chat notifier DB connection
+	chConn := data.NewDBConn(chLog, "chat")
+	if err := chConn.Connect(); err != nil {
+		l.Panic(err)
+	}
+	defer chConn.Close()
+	m.RegisterDB("chat", chConn.DB)
+
+	dbs := map[string]health.DB{"api": &acConn, "scheduler": &scConn, "webhook": &whConn, "events": &evConn, "chat": &chConn}
+
+	l.Info("starting webhook dispatcher, chat notifier, event bus, scheduler and API server")
+	webhooks, whClose, whChan := startWebhooks(whLog, whConn)
+	chats, chClose, chChan := startChat(chLog, chConn)
+	hub := newStream(evLog, evConn)
+	subscribers := []usecase.EventSubscriber{webhooks, chats, hub}
+
+	// Recurring task HTTP actions are only made if they're enabled
+	var atClose chan<- bool
+	var atChan <-chan bool
+	if os.Getenv("ACTION_ENABLED") == "true" {
+		atConn := data.NewDBConn(atLog, "action")
+		if err := atConn.Connect(); err != nil {
+			l.Panic(err)
+		}
+		defer atConn.Close()
+		m.RegisterDB("action", atConn.DB)
+		dbs["action"] = &atConn
+
+		ac := newActionConfig()
+		l.Info("starting action executor", "allow_private", ac.AllowPrivate)
+		var actions *action.Executor
+		actions, atClose, atChan = startActions(atLog, atConn, ac)
+		subscribers = append(subscribers, actions)
+	}
+
+	// Email notifications are only sent if an SMTP server is configured
+	var ntClose chan<- bool
+	var ntChan <-chan bool
+	if sc := newSMTPConfig(); sc.Host != "" {
+		ntConn := data.NewDBConn(ntLog, "notification")
+		if err := ntConn.Connect(); err != nil {
+			l.Panic(err)
+		}
+		defer ntConn.Close()
+		m.RegisterDB("notification", ntConn.DB)
+		dbs["notification"] = &ntConn
+
+		l.Info("starting email notifier", "smtp_host", sc.Host)
+		var notifier *email.Notifier
+		notifier, ntClose, ntChan = startNotifications(ntLog, ntConn, sc)
+		subscribers = append(subscribers, notifier)
+	}
+
+	// Recurring task commands are only run if a directory to run them in is configured
+	var cmClose chan<- bool
+	var cmChan <-chan bool
+	if cc := newCommandConfig(); cc.Dir != "" {
+		cmConn := data.NewDBConn(cmLog, "command")
+		if err := cmConn.Connect(); err != nil {
+			l.Panic(err)
+		}
+		defer cmConn.Close()
+		m.RegisterDB("command", cmConn.DB)
+		dbs["command"] = &cmConn
+
+		l.Info("starting command executor", "dir", cc.Dir, "allowed", strings.Join(cc.Allowed, ","))
+		if len(cc.Allowed) == 0 {
+			l.Warn("no programs are allowed, every command run will fail", "env", "COMMAND_ALLOWLIST")
+		}
+		var commands *command.Executor
+		commands, cmClose, cmChan = startCommands(cmLog, cmConn, cc)
+		subscribers = append(subscribers, commands)
+	}
 
-	l.Print("starting scheduler and API server")
-	checkC, scChan := startScheduler(scConn)
-	acChan := startAPIServer(acConn, checkC)
+	evClose, evChan := startEventBus(evLog, evConn, subscribers...)
+	scStatus := scheduler.NewStatus()
+	checkC, scChan := startScheduler(scLog, m, scStatus, scConn)
+	hc := health.Config{
+		DBs:                 dbs,
+		Schema:              &acConn,
+		LatestSchemaVersion: data.LatestSchemaVersion(),
+		Scheduler:           scStatus,
+	}
+	acChan := startAPIServer(acLog, m, acConn, checkC, hub, hc)
 
 	sc := false
 	ac := false
 	for {
 		select {
 		case sc = <-scChan:
-			l.Print("scheduler closed")
+			l.Info("scheduler closed")
 		case ac = <-acChan:
-			l.Print("api server closed")
+			l.Info("api server closed")
 		}
 		if sc && ac {
-			l.Print("all processes closed, exiting")
+			evClose <- true
+			<-evChan
+			whClose <- true
+			<-whChan
+			chClose <- true
+			<-chChan
+			if atClose != nil {
+				atClose <- true
+				<-atChan
+			}
+			if ntClose != nil {
+				ntClose <- true
+				<-ntChan
+			}
+			if cmClose != nil {
+				cmClose <- true
+				<-cmChan
+			}
+			l.Info("all processes closed, exiting")
 			return
 		}
 	}
@@ -57,15 +219,13 @@ func loadEnv() {
 	}
 }
 
-func startAPIServer(dbconn data.DBConn, check chan<- bool) (closed <-chan bool) {
-	l := log.New(os.Stderr, "api ", log.LstdFlags)
-
+func startAPIServer(l *logging.Logger, m *metrics.Metrics, dbconn data.DBConn, check chan<- bool, events *stream.Hub, hc health.Config) (closed <-chan bool) {
 	didSetup, err := dbconn.Setup()
 	if err != nil {
 		l.Panic(err)
 	}
 	if didSetup {
-		l.Print("first-time DB setup complete")
+		l.Info("first-time DB setup complete")
 	}
 
 	// Instantiate repositories
@@ -81,28 +241,242 @@ func startAPIServer(dbconn data.DBConn, check chan<- bool) (closed <-chan bool)
 	if err != nil {
 		l.Panic(err)
 	}
+	activityRepo, err := data.NewActivityRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	workspaceRepo, err := data.NewWorkspaceRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	roleRepo, err := data.NewRoleRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	tokenRepo, err := data.NewTokenRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	credRepo, err := data.NewCredentialRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	webhookRepo, err := data.NewWebhookRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	notificationRepo, err := data.NewNotificationRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	actionRepo, err := data.NewActionRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	commandRepo, err := data.NewCommandRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	scheduleRunRepo, err := data.NewScheduleRunRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Instantiate authorization handler, personal access tokens are accepted alongside the identity provider's tokens
+	// local username and password authentication replaces the external identity provider if LOCAL_AUTH_SECRET is set
+	var local *auth.Local
+	var provider auth.Authenticator
+	if secret := os.Getenv("LOCAL_AUTH_SECRET"); secret != "" {
+		if local, err = auth.NewLocal(l, credRepo, auth.LocalConfig{Secret: []byte(secret)}, nil); err != nil {
+			l.Panic(err)
+		}
+		provider = local
+	} else if provider, err = newAuthenticator(l); err != nil {
+		l.Panic(err)
+	}
+	a := auth.NewPersonalToken(l, tokenRepo, provider)
+
+	// Serve REST API
+	api := restapi.New(l, a, check, userRepo, taskRepo, scheduleRepo, activityRepo, workspaceRepo, roleRepo, tokenRepo, credRepo, webhookRepo, notificationRepo, actionRepo, commandRepo, scheduleRunRepo, events, local, newAdmins(), newLimits(), m, hc)
+	return restapi.Serve(l, api)
+}
 
-	// Instantiate authorization handler
-	a := auth.NewAuth0(l, auth.Auth0Config{
+// newAdmins returns the external user IDs granted the admin role when they sign in from the environment
+func newAdmins() auth.Admins {
+	var admins auth.Admins
+	for _, id := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			admins = append(admins, id)
+		}
+	}
+	return admins
+}
+
+// newAuthenticator returns a generic OpenID Connect authenticator if OIDC_ISSUER_URL is set, otherwise an Auth0 authenticator
+// OIDC_AUDIENCE is required with OIDC_ISSUER_URL, so tokens issued for other applications aren't accepted
+func newAuthenticator(l auth.Logger) (auth.Authenticator, error) {
+	if issuer := os.Getenv("OIDC_ISSUER_URL"); issuer != "" {
+		audience := []string{}
+		for _, aud := range strings.Split(os.Getenv("OIDC_AUDIENCE"), ",") {
+			if aud = strings.TrimSpace(aud); aud != "" {
+				audience = append(audience, aud)
+			}
+		}
+		a, err := auth.NewOIDC(l, auth.OIDCConfig{
+			IssuerURL:    issuer,
+			Audience:     audience,
+			SubjectClaim: os.Getenv("OIDC_SUBJECT_CLAIM"),
+			IssuerClaim:  os.Getenv("OIDC_ISSUER_CLAIM"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating OIDC authenticator, check OIDC_AUDIENCE is set: %v", err)
+		}
+		return a, nil
+	}
+	return auth.NewAuth0(l, auth.Auth0Config{
 		Secret:   []byte(os.Getenv("AUTH0_API_SECRET")),
 		Audience: []string{os.Getenv("AUTH0_API_IDENTIFIER")},
 		Domain:   os.Getenv("AUTH0_DOMAIN"),
-	})
+	}), nil
+}
 
-	// Serve REST API
-	api := restapi.New(l, a, check, userRepo, taskRepo, scheduleRepo)
-	return restapi.Serve(l, api)
+// newLimits returns rate limits and per-user quotas from the environment, unset values are unlimited
+func newLimits() restapi.Limits {
+	return restapi.Limits{
+		RateLimit: ratelimit.Config{
+			UserRate:   envFloat("RATE_LIMIT_USER_PER_MINUTE"),
+			UserBurst:  envInt("RATE_LIMIT_USER_BURST"),
+			IPRate:     envFloat("RATE_LIMIT_IP_PER_MINUTE"),
+			IPBurst:    envInt("RATE_LIMIT_IP_BURST"),
+			TrustProxy: os.Getenv("RATE_LIMIT_TRUST_PROXY") == "true",
+		},
+		Quota: usecase.Quota{
+			MaxSchedules:      envInt("QUOTA_MAX_SCHEDULES"),
+			MaxRecurringTasks: envInt("QUOTA_MAX_RECURRING_TASKS"),
+		},
+	}
+}
+
+// newLogConfig returns the log level and format from the environment, defaulting to info level text output
+func newLogConfig() (logging.Config, error) {
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	return logging.Config{Level: level, JSON: os.Getenv("LOG_FORMAT") == "json"}, err
+}
+
+// newTraceConfig returns the trace exporter from the environment, tracing is disabled unless OTEL_TRACES_EXPORTER is set
+func newTraceConfig() (tracing.Config, error) {
+	exporter, err := tracing.ParseExporter(os.Getenv("OTEL_TRACES_EXPORTER"))
+	return tracing.Config{Exporter: exporter, ServiceName: "scheduled-tasks"}, err
+}
+
+// newWebhookConfig returns webhook delivery settings from the environment, unset values use the dispatcher's defaults
+func newWebhookConfig() webhook.Config {
+	return webhook.Config{
+		Timeout: time.Duration(envInt("WEBHOOK_TIMEOUT_SECONDS")) * time.Second,
+		Retry: corewebhook.RetryPolicy{
+			MaxAttempts: envInt("WEBHOOK_MAX_ATTEMPTS"),
+			Delay:       time.Duration(envInt("WEBHOOK_RETRY_SECONDS")) * time.Second,
+		},
+		AllowPrivate: os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true",
+	}
+}
+
+// newChatConfig returns chat notification settings from the environment, unset values use the notifier's defaults
+func newChatConfig() chat.Config {
+	return chat.Config{
+		Timeout: time.Duration(envInt("CHAT_TIMEOUT_SECONDS")) * time.Second,
+		Retry: corewebhook.RetryPolicy{
+			MaxAttempts: envInt("CHAT_MAX_ATTEMPTS"),
+			Delay:       time.Duration(envInt("CHAT_RETRY_SECONDS")) * time.Second,
+		},
+		AppURL:       os.Getenv("APP_URL"),
+		AllowPrivate: os.Getenv("CHAT_ALLOW_PRIVATE") == "true",
+	}
 }
 
-func startScheduler(dbconn data.DBConn) (check chan<- bool, closed <-chan bool) {
-	l := log.New(os.Stderr, "sched ", log.LstdFlags)
+// newActionConfig returns HTTP action settings from the environment, unset values use the executor's defaults
+func newActionConfig() action.Config {
+	return action.Config{
+		Timeout: time.Duration(envInt("ACTION_TIMEOUT_SECONDS")) * time.Second,
+		Retry: corewebhook.RetryPolicy{
+			MaxAttempts: envInt("ACTION_MAX_ATTEMPTS"),
+			Delay:       time.Duration(envInt("ACTION_RETRY_SECONDS")) * time.Second,
+		},
+		AllowPrivate: os.Getenv("ACTION_ALLOW_PRIVATE") == "true",
+	}
+}
 
+// newEventConfig returns event publishing settings from the environment, unset values use the event bus's defaults
+func newEventConfig() eventbus.Config {
+	return eventbus.Config{
+		Interval:    time.Duration(envInt("EVENT_POLL_MILLISECONDS")) * time.Millisecond,
+		MaxAttempts: envInt("EVENT_MAX_ATTEMPTS"),
+		RetryDelay:  time.Duration(envInt("EVENT_RETRY_MILLISECONDS")) * time.Millisecond,
+	}
+}
+
+// newStreamConfig returns event stream settings from the environment, unset values use the hub's defaults
+func newStreamConfig() stream.Config {
+	return stream.Config{
+		History:   envInt("STREAM_HISTORY"),
+		Heartbeat: time.Duration(envInt("STREAM_HEARTBEAT_SECONDS")) * time.Second,
+	}
+}
+
+// newCommandConfig returns recurring task command settings from the environment, commands aren't run if COMMAND_DIR is unset
+func newCommandConfig() command.Config {
+	var allowed []string
+	for _, program := range strings.Split(os.Getenv("COMMAND_ALLOWLIST"), ",") {
+		if program = strings.TrimSpace(program); program != "" {
+			allowed = append(allowed, program)
+		}
+	}
+	return command.Config{
+		Dir:     os.Getenv("COMMAND_DIR"),
+		Allowed: allowed,
+		Timeout: time.Duration(envInt("COMMAND_TIMEOUT_SECONDS")) * time.Second,
+	}
+}
+
+// newEmailConfig returns email notification settings from the environment, unset values use the notifier's defaults
+func newEmailConfig() email.Config {
+	return email.Config{
+		Retry: corewebhook.RetryPolicy{
+			MaxAttempts: envInt("EMAIL_MAX_ATTEMPTS"),
+			Delay:       time.Duration(envInt("EMAIL_RETRY_SECONDS")) * time.Second,
+		},
+		AppURL: os.Getenv("APP_URL"),
+	}
+}
+
+// newSMTPConfig returns the SMTP server used for email notifications from the environment, unset values use the sender's defaults
+func newSMTPConfig() email.SMTPConfig {
+	return email.SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     envInt("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func envInt(key string) int {
+	val, _ := strconv.Atoi(os.Getenv(key))
+	return val
+}
+
+func envFloat(key string) float64 {
+	val, _ := strconv.ParseFloat(os.Getenv(key), 64)
+	return val
+}
+
+func startScheduler(l *logging.Logger, m *metrics.Metrics, status *scheduler.Status, dbconn data.DBConn) (check chan<- bool, closed <-chan bool) {
 	didSetup, err := dbconn.Setup()
 	if err != nil {
 		l.Panic(err)
 	}
 	if didSetup {
-		l.Print("first-time DB setup complete")
+		l.Info("first-time DB setup complete")
 	}
 
 	// Instantiate repositories
@@ -114,8 +488,199 @@ func startScheduler(dbconn data.DBConn) (check chan<- bool, closed <-chan bool)
 	if err != nil {
 		l.Panic(err)
 	}
+	runRepo, err := data.NewScheduleRunRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	activityRepo, err := data.NewActivityRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
 
-	// Start scheduler process
-	_, check, closed = scheduler.Run(l, taskRepo, scheduleRepo, nil)
+	// Start scheduler process, recording a run history for each schedule
+	_, check, closed = scheduler.Run(l, m, status, taskRepo, activityRepo, scheduleRepo, runRepo, nil)
 	return check, closed
 }
+
+func startWebhooks(l *logging.Logger, dbconn data.DBConn) (d *webhook.Dispatcher, close chan<- bool, closed <-chan bool) {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	webhookRepo, err := data.NewWebhookRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	workspaceRepo, err := data.NewWorkspaceRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	taskRepo, err := data.NewTaskRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	scheduleRepo, err := data.NewScheduleRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Start delivering webhooks in the background
+	d = webhook.NewDispatcher(l, webhookRepo, workspaceRepo, taskRepo, scheduleRepo, newWebhookConfig())
+	close, closed = d.Run()
+	return d, close, closed
+}
+
+func startChat(l *logging.Logger, dbconn data.DBConn) (n *chat.Notifier, close chan<- bool, closed <-chan bool) {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	chatRepo, err := data.NewChatRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	taskRepo, err := data.NewTaskRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	scheduleRepo, err := data.NewScheduleRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Start posting overdue tasks and delivering chat messages in the background, generated tasks are queued as events are published
+	n = chat.NewNotifier(l, chatRepo, taskRepo, scheduleRepo, newChatConfig())
+	close, closed = n.Run()
+	return n, close, closed
+}
+
+func startActions(l *logging.Logger, dbconn data.DBConn, ac action.Config) (e *action.Executor, close chan<- bool, closed <-chan bool) {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	actionRepo, err := data.NewActionRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	taskRepo, err := data.NewTaskRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Start making due HTTP action calls in the background, the executor is woken as generated task events are published
+	e = action.NewExecutor(l, actionRepo, taskRepo, ac)
+	close, closed = e.Run()
+	return e, close, closed
+}
+
+func startCommands(l *logging.Logger, dbconn data.DBConn, cc command.Config) (e *command.Executor, close chan<- bool, closed <-chan bool) {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	commandRepo, err := data.NewCommandRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	taskRepo, err := data.NewTaskRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Start running pending commands in the background, the executor is woken as generated task events are published
+	e = command.NewExecutor(l, commandRepo, taskRepo, cc)
+	close, closed = e.Run()
+	return e, close, closed
+}
+
+func startEventBus(l *logging.Logger, dbconn data.DBConn, subscribers ...usecase.EventSubscriber) (close chan<- bool, closed <-chan bool) {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	outboxRepo, err := data.NewOutboxRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Start publishing events from the outbox in the background
+	return eventbus.New(l, outboxRepo, newEventConfig(), subscribers...).Run()
+}
+
+func newStream(l *logging.Logger, dbconn data.DBConn) *stream.Hub {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	taskRepo, err := data.NewTaskRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	scheduleRepo, err := data.NewScheduleRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	workspaceRepo, err := data.NewWorkspaceRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Events are streamed to API clients as they're published, recent events are kept in memory so clients can resume after reconnecting
+	return stream.NewHub(l, taskRepo, scheduleRepo, workspaceRepo, newStreamConfig())
+}
+
+func startNotifications(l *logging.Logger, dbconn data.DBConn, sc email.SMTPConfig) (n *email.Notifier, close chan<- bool, closed <-chan bool) {
+	didSetup, err := dbconn.Setup()
+	if err != nil {
+		l.Panic(err)
+	}
+	if didSetup {
+		l.Info("first-time DB setup complete")
+	}
+
+	// Instantiate repositories
+	notificationRepo, err := data.NewNotificationRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+	taskRepo, err := data.NewTaskRepo(dbconn)
+	if err != nil {
+		l.Panic(err)
+	}
+
+	// Start sending queued emails and daily digests in the background, generated task emails are queued as events are published
+	n = email.NewNotifier(l, notificationRepo, taskRepo, email.NewSMTPSender(sc), newEmailConfig())
+	close, closed = n.Run()
+	return n, close, closed
+}
@@ -0,0 +1,239 @@
+package action
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// MaxErrorLength is the maximum length of a call's recorded error, in characters, longer errors are truncated
+const MaxErrorLength = 1000
+
+// MaxResponseLength is the maximum length of a call's recorded response body, in characters, longer responses are truncated
+const MaxResponseLength = 2000
+
+// Status is the state of a call
+type Status uint8
+
+// Call statuses
+const (
+	StatusPending Status = iota
+	StatusSucceeded
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Request is an HTTP request, with its body already rendered
+type Request struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    string
+}
+
+// NewRequest instantiates a new HTTP request
+func NewRequest(method string, url string, headers map[string]string, body string) Request {
+	return Request{method: method, url: url, headers: copyHeaders(headers), body: body}
+}
+
+// Method returns the request's HTTP method
+func (r Request) Method() string {
+	return r.method
+}
+
+// URL returns the URL the request is sent to
+func (r Request) URL() string {
+	return r.url
+}
+
+// Headers returns the request's headers, by name
+func (r Request) Headers() map[string]string {
+	return copyHeaders(r.headers)
+}
+
+// Body returns the request body
+func (r Request) Body() string {
+	return r.body
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	c := make(map[string]string, len(headers))
+	for name, value := range headers {
+		c[name] = value
+	}
+	return c
+}
+
+// Call is an HTTP request made for a generated task, along with the outcome of its latest attempt
+// failed attempts are retried the same way as webhook deliveries, a call's own timeout and maximum attempts override the defaults if set
+type Call struct {
+	id              ID
+	request         Request
+	timeout         time.Duration
+	maxAttempts     int
+	completeTask    bool
+	status          Status
+	attempts        int
+	statusCode      int
+	response        string
+	latency         time.Duration
+	lastError       string
+	createdTime     time.Time
+	lastAttemptTime time.Time
+	nextAttemptTime time.Time
+}
+
+// NewCall instantiates a new pending call, due immediately
+func NewCall(req Request, timeout time.Duration, maxAttempts int, completeTask bool) *Call {
+	now := clock.Now()
+	return &Call{
+		id:              NewID(),
+		request:         req,
+		timeout:         timeout,
+		maxAttempts:     maxAttempts,
+		completeTask:    completeTask,
+		status:          StatusPending,
+		createdTime:     now,
+		nextAttemptTime: now,
+	}
+}
+
+// NewRawCall instantiates a call entity with all available fields
+func NewRawCall(id ID, req Request, timeout time.Duration, maxAttempts int, completeTask bool, status Status, attempts int, statusCode int, response string, latency time.Duration, lastError string, created time.Time, lastAttempt time.Time, nextAttempt time.Time) *Call {
+	return &Call{
+		id:              id,
+		request:         req,
+		timeout:         timeout,
+		maxAttempts:     maxAttempts,
+		completeTask:    completeTask,
+		status:          status,
+		attempts:        attempts,
+		statusCode:      statusCode,
+		response:        response,
+		latency:         latency,
+		lastError:       lastError,
+		createdTime:     created,
+		lastAttemptTime: lastAttempt,
+		nextAttemptTime: nextAttempt,
+	}
+}
+
+// ID returns the call's unique ID
+func (c *Call) ID() ID {
+	return c.id
+}
+
+// Request returns the HTTP request made
+func (c *Call) Request() Request {
+	return c.request
+}
+
+// Timeout returns how long to wait for a response, zero to use the default
+func (c *Call) Timeout() time.Duration {
+	return c.timeout
+}
+
+// MaxAttempts returns the number of times the call is attempted before it fails, zero to use the default
+func (c *Call) MaxAttempts() int {
+	return c.maxAttempts
+}
+
+// CompleteTask returns whether the generated task is completed once the call succeeds
+func (c *Call) CompleteTask() bool {
+	return c.completeTask
+}
+
+// Status returns the call's status
+func (c *Call) Status() Status {
+	return c.status
+}
+
+// Attempts returns the number of times the call has been attempted
+func (c *Call) Attempts() int {
+	return c.attempts
+}
+
+// StatusCode returns the HTTP status code of the latest attempt, zero if no response was received
+func (c *Call) StatusCode() int {
+	return c.statusCode
+}
+
+// Response returns the response body of the latest attempt, truncated to MaxResponseLength characters
+func (c *Call) Response() string {
+	return c.response
+}
+
+// Latency returns how long the latest attempt took
+func (c *Call) Latency() time.Duration {
+	return c.latency
+}
+
+// LastError returns the reason the latest attempt failed, empty if it succeeded
+func (c *Call) LastError() string {
+	return c.lastError
+}
+
+// CreatedTime returns the time the call was queued
+func (c *Call) CreatedTime() time.Time {
+	return c.createdTime
+}
+
+// LastAttemptTime returns the time of the latest attempt, zero if it hasn't been attempted
+func (c *Call) LastAttemptTime() time.Time {
+	return c.lastAttemptTime
+}
+
+// NextAttemptTime returns when the call is due to be attempted, zero once it has succeeded or failed
+func (c *Call) NextAttemptTime() time.Time {
+	return c.nextAttemptTime
+}
+
+// Succeeded records a successful attempt
+func (c *Call) Succeeded(statusCode int, response string, latency time.Duration) {
+	c.attempted(statusCode, response, latency, "")
+	c.status = StatusSucceeded
+	c.nextAttemptTime = time.Time{}
+}
+
+// Failed records a failed attempt, and schedules a retry with backoff until the maximum attempts are reached
+func (c *Call) Failed(statusCode int, response string, latency time.Duration, reason string, p webhook.RetryPolicy) {
+	c.attempted(statusCode, response, latency, reason)
+	if c.maxAttempts > 0 {
+		p.MaxAttempts = c.maxAttempts
+	}
+	if c.attempts >= p.MaxAttempts {
+		c.status = StatusFailed
+		c.nextAttemptTime = time.Time{}
+		return
+	}
+	c.nextAttemptTime = c.lastAttemptTime.Add(p.Backoff(c.attempts))
+}
+
+func (c *Call) attempted(statusCode int, response string, latency time.Duration, reason string) {
+	c.attempts++
+	c.statusCode = statusCode
+	c.response = truncate(response, MaxResponseLength)
+	c.latency = latency
+	c.lastError = truncate(reason, MaxErrorLength)
+	c.lastAttemptTime = clock.Now()
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,66 @@
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+func TestCall_Failed(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	p := webhook.RetryPolicy{MaxAttempts: 8, Delay: time.Minute}
+
+	c := NewCall(NewRequest("POST", "https://example.com/warm", nil, "{}"), 0, 3, false)
+	if !c.NextAttemptTime().Equal(now) {
+		t.Fatalf("NewCall() nextAttemptTime = %v, want %v", c.NextAttemptTime(), now)
+	}
+
+	wantNext := []time.Time{now.Add(time.Minute), now.Add(2 * time.Minute), {}}
+	wantStatus := []Status{StatusPending, StatusPending, StatusFailed}
+	for i := range wantNext {
+		c.Failed(503, "busy", 20*time.Millisecond, "Service Unavailable", p)
+		if c.Attempts() != i+1 {
+			t.Errorf("attempt %v: attempts = %v, want %v", i+1, c.Attempts(), i+1)
+		}
+		if c.Status() != wantStatus[i] {
+			t.Errorf("attempt %v: status = %v, want %v, the call's own maximum attempts should override the policy's", i+1, c.Status(), wantStatus[i])
+		}
+		if !c.NextAttemptTime().Equal(wantNext[i]) {
+			t.Errorf("attempt %v: nextAttemptTime = %v, want %v", i+1, c.NextAttemptTime(), wantNext[i])
+		}
+		if c.StatusCode() != 503 || c.Response() != "busy" || c.Latency() != 20*time.Millisecond || c.LastError() != "Service Unavailable" || !c.LastAttemptTime().Equal(now) {
+			t.Errorf("attempt %v: outcome = %v %v %v %v %v, want 503 busy 20ms Service Unavailable %v", i+1, c.StatusCode(), c.Response(), c.Latency(), c.LastError(), c.LastAttemptTime(), now)
+		}
+	}
+}
+
+func TestCall_Succeeded(t *testing.T) {
+	c := NewCall(NewRequest("GET", "https://example.com/warm", nil, ""), time.Second, 0, true)
+	c.Failed(0, "", time.Second, strings.Repeat("x", MaxErrorLength+1), webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute})
+	if len(c.LastError()) != MaxErrorLength {
+		t.Errorf("Failed() lastError length = %v, want it truncated to %v", len(c.LastError()), MaxErrorLength)
+	}
+	c.Succeeded(200, strings.Repeat("y", MaxResponseLength+1), 5*time.Millisecond)
+	if c.Status() != StatusSucceeded || c.Attempts() != 2 || c.StatusCode() != 200 || c.LastError() != "" || !c.NextAttemptTime().IsZero() {
+		t.Errorf("Succeeded() = %v %v %v %v %v, want succeeded after 2 attempts with status 200", c.Status(), c.Attempts(), c.StatusCode(), c.LastError(), c.NextAttemptTime())
+	}
+	if len(c.Response()) != MaxResponseLength || c.Latency() != 5*time.Millisecond {
+		t.Errorf("Succeeded() response length = %v, latency = %v, want the response truncated to %v and 5ms", len(c.Response()), c.Latency(), MaxResponseLength)
+	}
+}
+
+func TestRequest_Headers(t *testing.T) {
+	headers := map[string]string{"X-Token": "a"}
+	r := NewRequest("GET", "https://example.com/warm", headers, "")
+	headers["X-Token"] = "b"
+	r.Headers()["X-Token"] = "c"
+	if got := r.Headers()["X-Token"]; got != "a" {
+		t.Errorf("Headers() X-Token = %v, want the request's headers to be unaffected by changes to the given or returned maps", got)
+	}
+}
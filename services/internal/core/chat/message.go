@@ -0,0 +1,177 @@
+package chat
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// MaxErrorLength is the maximum length of a message's recorded error, in characters, longer errors are truncated
+const MaxErrorLength = 1000
+
+// Kind is the reason a task is posted to a chat channel
+type Kind string
+
+// Message kinds, a task is posted at most once for each
+const (
+	KindGenerated Kind = "generated"
+	KindOverdue   Kind = "overdue"
+)
+
+// Status is the state of a message
+type Status uint8
+
+// Message statuses
+const (
+	StatusPending Status = iota
+	StatusSucceeded
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Message is a task posted to a chat channel's incoming webhook, along with the outcome of its latest attempt
+// failed attempts are retried the same way as webhook deliveries
+type Message struct {
+	id              ID
+	url             string
+	kind            Kind
+	payload         []byte
+	status          Status
+	attempts        int
+	statusCode      int
+	lastError       string
+	createdTime     time.Time
+	lastAttemptTime time.Time
+	nextAttemptTime time.Time
+}
+
+// NewMessage instantiates a new pending message of a JSON payload to an incoming webhook URL, due immediately
+func NewMessage(url string, kind Kind, payload []byte) *Message {
+	now := clock.Now()
+	return &Message{
+		id:              NewID(),
+		url:             url,
+		kind:            kind,
+		payload:         payload,
+		status:          StatusPending,
+		createdTime:     now,
+		nextAttemptTime: now,
+	}
+}
+
+// NewRawMessage instantiates a message entity with all available fields
+func NewRawMessage(id ID, url string, kind Kind, payload []byte, status Status, attempts int, statusCode int, lastError string, created time.Time, lastAttempt time.Time, nextAttempt time.Time) *Message {
+	return &Message{
+		id:              id,
+		url:             url,
+		kind:            kind,
+		payload:         payload,
+		status:          status,
+		attempts:        attempts,
+		statusCode:      statusCode,
+		lastError:       lastError,
+		createdTime:     created,
+		lastAttemptTime: lastAttempt,
+		nextAttemptTime: nextAttempt,
+	}
+}
+
+// ID returns the message's unique ID
+func (m *Message) ID() ID {
+	return m.id
+}
+
+// URL returns the incoming webhook URL the message is posted to
+func (m *Message) URL() string {
+	return m.url
+}
+
+// Kind returns the reason the task is posted
+func (m *Message) Kind() Kind {
+	return m.kind
+}
+
+// Payload returns the JSON payload posted to the incoming webhook
+func (m *Message) Payload() []byte {
+	return m.payload
+}
+
+// Status returns the message's status
+func (m *Message) Status() Status {
+	return m.status
+}
+
+// Attempts returns the number of times the message has been attempted
+func (m *Message) Attempts() int {
+	return m.attempts
+}
+
+// StatusCode returns the HTTP status code of the latest attempt, zero if no response was received
+func (m *Message) StatusCode() int {
+	return m.statusCode
+}
+
+// LastError returns the reason the latest attempt failed, empty if it succeeded
+func (m *Message) LastError() string {
+	return m.lastError
+}
+
+// CreatedTime returns the time the message was queued
+func (m *Message) CreatedTime() time.Time {
+	return m.createdTime
+}
+
+// LastAttemptTime returns the time of the latest attempt, zero if it hasn't been attempted
+func (m *Message) LastAttemptTime() time.Time {
+	return m.lastAttemptTime
+}
+
+// NextAttemptTime returns when the message is due to be attempted, zero once it has succeeded or failed
+func (m *Message) NextAttemptTime() time.Time {
+	return m.nextAttemptTime
+}
+
+// Succeeded records a successful attempt
+func (m *Message) Succeeded(statusCode int) {
+	m.attempted(statusCode, "")
+	m.status = StatusSucceeded
+	m.nextAttemptTime = time.Time{}
+}
+
+// Failed records a failed attempt, and schedules a retry with backoff until the policy's maximum attempts are reached
+func (m *Message) Failed(statusCode int, reason string, p webhook.RetryPolicy) {
+	m.attempted(statusCode, reason)
+	if m.attempts >= p.MaxAttempts {
+		m.status = StatusFailed
+		m.nextAttemptTime = time.Time{}
+		return
+	}
+	m.nextAttemptTime = m.lastAttemptTime.Add(p.Backoff(m.attempts))
+}
+
+func (m *Message) attempted(statusCode int, reason string) {
+	m.attempts++
+	m.statusCode = statusCode
+	m.lastError = truncate(reason)
+	m.lastAttemptTime = clock.Now()
+}
+
+func truncate(reason string) string {
+	if utf8.RuneCountInString(reason) <= MaxErrorLength {
+		return reason
+	}
+	return string([]rune(reason)[:MaxErrorLength])
+}
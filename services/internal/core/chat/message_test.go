@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+func TestMessage_Failed(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	p := webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute}
+
+	m := NewMessage("https://example.com/hook", KindGenerated, []byte("{}"))
+	if !m.NextAttemptTime().Equal(now) {
+		t.Fatalf("NewMessage() nextAttemptTime = %v, want %v", m.NextAttemptTime(), now)
+	}
+
+	wantNext := []time.Time{now.Add(time.Minute), now.Add(2 * time.Minute), {}}
+	wantStatus := []Status{StatusPending, StatusPending, StatusFailed}
+	for i := range wantNext {
+		m.Failed(500, "server error", p)
+		if m.Attempts() != i+1 {
+			t.Errorf("attempt %v: attempts = %v, want %v", i+1, m.Attempts(), i+1)
+		}
+		if m.Status() != wantStatus[i] {
+			t.Errorf("attempt %v: status = %v, want %v", i+1, m.Status(), wantStatus[i])
+		}
+		if !m.NextAttemptTime().Equal(wantNext[i]) {
+			t.Errorf("attempt %v: nextAttemptTime = %v, want %v", i+1, m.NextAttemptTime(), wantNext[i])
+		}
+		if m.StatusCode() != 500 || m.LastError() != "server error" || !m.LastAttemptTime().Equal(now) {
+			t.Errorf("attempt %v: outcome = %v %v %v, want 500 server error %v", i+1, m.StatusCode(), m.LastError(), m.LastAttemptTime(), now)
+		}
+	}
+}
+
+func TestMessage_Succeeded(t *testing.T) {
+	m := NewMessage("https://example.com/hook", KindOverdue, []byte("{}"))
+	m.Failed(0, strings.Repeat("x", MaxErrorLength+1), webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute})
+	if len(m.LastError()) != MaxErrorLength {
+		t.Errorf("Failed() lastError length = %v, want it truncated to %v", len(m.LastError()), MaxErrorLength)
+	}
+	m.Succeeded(200)
+	if m.Status() != StatusSucceeded || m.Attempts() != 2 || m.StatusCode() != 200 || m.LastError() != "" || !m.NextAttemptTime().IsZero() {
+		t.Errorf("Succeeded() = %v %v %v %v %v, want succeeded after 2 attempts with status 200", m.Status(), m.Attempts(), m.StatusCode(), m.LastError(), m.NextAttemptTime())
+	}
+}
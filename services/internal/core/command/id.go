@@ -0,0 +1,42 @@
+package command
+
+import (
+	"github.com/google/uuid"
+)
+
+// ID unique command run identifier
+type ID struct {
+	id uuid.UUID
+}
+
+// NewID generates a new ID
+func NewID() ID {
+	return ID{id: uuid.New()}
+}
+
+// ParseID creates an ID from a preexisting string value
+func ParseID(val string) (ID, error) {
+	id, err := uuid.Parse(val)
+	if err != nil {
+		return ID{}, err
+	}
+	return ID{id: id}, nil
+}
+
+// Equals determines if two command run IDs are equal
+func (val ID) Equals(other interface{}) bool {
+	if otherVal, ok := other.(ID); ok {
+		return val.id == otherVal.id
+	}
+	return false
+}
+
+// IsEmpty determines if this ID is empty or zero-value
+func (val ID) IsEmpty() bool {
+	return val.id == uuid.UUID{}
+}
+
+// String returns the string representation of the ID
+func (val ID) String() string {
+	return val.id.String()
+}
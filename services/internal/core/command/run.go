@@ -0,0 +1,188 @@
+package command
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// MaxOutputLength is the maximum length of a run's recorded stdout and stderr, in characters, longer output is truncated
+const MaxOutputLength = 4000
+
+// MaxErrorLength is the maximum length of a run's recorded error, in characters, longer errors are truncated
+const MaxErrorLength = 1000
+
+// Status is the state of a run
+type Status uint8
+
+// Run statuses
+const (
+	StatusPending Status = iota
+	StatusRunning
+	StatusSucceeded
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusRunning:
+		return "running"
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Run is a command run for a generated task, along with its outcome
+// a run is only started once, if the executor stops while it's running it's left running rather than being run again
+type Run struct {
+	id           ID
+	program      string
+	args         []string
+	timeout      time.Duration
+	status       Status
+	exitCode     int
+	stdout       string
+	stderr       string
+	lastError    string
+	createdTime  time.Time
+	startedTime  time.Time
+	finishedTime time.Time
+}
+
+// NewRun instantiates a new pending run
+func NewRun(program string, args []string, timeout time.Duration) *Run {
+	return &Run{
+		id:          NewID(),
+		program:     program,
+		args:        copyArgs(args),
+		timeout:     timeout,
+		status:      StatusPending,
+		createdTime: clock.Now(),
+	}
+}
+
+// NewRawRun instantiates a run entity with all available fields
+func NewRawRun(id ID, program string, args []string, timeout time.Duration, status Status, exitCode int, stdout string, stderr string, lastError string, created time.Time, started time.Time, finished time.Time) *Run {
+	return &Run{
+		id:           id,
+		program:      program,
+		args:         copyArgs(args),
+		timeout:      timeout,
+		status:       status,
+		exitCode:     exitCode,
+		stdout:       stdout,
+		stderr:       stderr,
+		lastError:    lastError,
+		createdTime:  created,
+		startedTime:  started,
+		finishedTime: finished,
+	}
+}
+
+func copyArgs(args []string) []string {
+	return append([]string{}, args...)
+}
+
+// ID returns the run's unique ID
+func (r *Run) ID() ID {
+	return r.id
+}
+
+// Program returns the name of the program run
+func (r *Run) Program() string {
+	return r.program
+}
+
+// Args returns the arguments the program is run with
+func (r *Run) Args() []string {
+	return copyArgs(r.args)
+}
+
+// Timeout returns how long the command can run before it's killed, zero to use the default
+func (r *Run) Timeout() time.Duration {
+	return r.timeout
+}
+
+// Status returns the run's status
+func (r *Run) Status() Status {
+	return r.status
+}
+
+// ExitCode returns the command's exit code, -1 if it didn't exit normally
+func (r *Run) ExitCode() int {
+	return r.exitCode
+}
+
+// Stdout returns the command's standard output, truncated to MaxOutputLength characters
+func (r *Run) Stdout() string {
+	return r.stdout
+}
+
+// Stderr returns the command's standard error, truncated to MaxOutputLength characters
+func (r *Run) Stderr() string {
+	return r.stderr
+}
+
+// LastError returns the reason the run failed, empty if it succeeded or the command exited with a non-zero code
+func (r *Run) LastError() string {
+	return r.lastError
+}
+
+// CreatedTime returns the time the run was queued
+func (r *Run) CreatedTime() time.Time {
+	return r.createdTime
+}
+
+// StartedTime returns the time the command was started, zero if it hasn't been
+func (r *Run) StartedTime() time.Time {
+	return r.startedTime
+}
+
+// FinishedTime returns the time the command finished, zero if it hasn't
+func (r *Run) FinishedTime() time.Time {
+	return r.finishedTime
+}
+
+// Duration returns how long the command ran for, zero if it hasn't finished
+func (r *Run) Duration() time.Duration {
+	if r.finishedTime.IsZero() || r.startedTime.IsZero() {
+		return 0
+	}
+	return r.finishedTime.Sub(r.startedTime)
+}
+
+// Start records that the command has started
+func (r *Run) Start() {
+	r.status = StatusRunning
+	r.startedTime = clock.Now()
+}
+
+// Finish records the command's outcome, it succeeded if it exited with code 0 and there was no other error, such as a timeout
+func (r *Run) Finish(exitCode int, stdout string, stderr string, reason string) {
+	r.exitCode = exitCode
+	r.stdout = truncate(stdout, MaxOutputLength)
+	r.stderr = truncate(stderr, MaxOutputLength)
+	r.lastError = truncate(reason, MaxErrorLength)
+	r.finishedTime = clock.Now()
+	if r.startedTime.IsZero() {
+		r.startedTime = r.finishedTime
+	}
+	if exitCode == 0 && reason == "" {
+		r.status = StatusSucceeded
+		return
+	}
+	r.status = StatusFailed
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+func TestRun_Finish(t *testing.T) {
+	start := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+
+	tests := []struct {
+		name       string
+		exitCode   int
+		reason     string
+		wantStatus Status
+	}{
+		{name: "zero exit code should succeed", exitCode: 0, wantStatus: StatusSucceeded},
+		{name: "non-zero exit code should fail", exitCode: 2, wantStatus: StatusFailed},
+		{name: "timeout should fail even with a zero exit code", exitCode: 0, reason: "timed out after 1s", wantStatus: StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Set(clock.NewStaticMock(start))
+			r := NewRun("backup", []string{"--all"}, time.Second)
+			if r.Status() != StatusPending {
+				t.Fatalf("NewRun() status = %v, want %v", r.Status(), StatusPending)
+			}
+			r.Start()
+			if r.Status() != StatusRunning || !r.StartedTime().Equal(start) {
+				t.Errorf("Start() = %v %v, want %v %v", r.Status(), r.StartedTime(), StatusRunning, start)
+			}
+			clock.Set(clock.NewStaticMock(start.Add(3 * time.Second)))
+			r.Finish(tt.exitCode, "out", "err", tt.reason)
+			if r.Status() != tt.wantStatus {
+				t.Errorf("Finish() status = %v, want %v", r.Status(), tt.wantStatus)
+			}
+			if r.ExitCode() != tt.exitCode || r.Stdout() != "out" || r.Stderr() != "err" || r.LastError() != tt.reason || r.Duration() != 3*time.Second {
+				t.Errorf("Finish() outcome = %v %v %v %v %v, want %v out err %v 3s", r.ExitCode(), r.Stdout(), r.Stderr(), r.LastError(), r.Duration(), tt.exitCode, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRun_Finish_truncate(t *testing.T) {
+	r := NewRun("backup", nil, 0)
+	r.Finish(1, strings.Repeat("o", MaxOutputLength+1), strings.Repeat("e", MaxOutputLength+1), strings.Repeat("x", MaxErrorLength+1))
+	if len(r.Stdout()) != MaxOutputLength || len(r.Stderr()) != MaxOutputLength || len(r.LastError()) != MaxErrorLength {
+		t.Errorf("Finish() lengths = %v %v %v, want output truncated to %v and the error to %v", len(r.Stdout()), len(r.Stderr()), len(r.LastError()), MaxOutputLength, MaxErrorLength)
+	}
+	if r.StartedTime().IsZero() {
+		t.Errorf("Finish() without Start() should set the started time")
+	}
+}
+
+func TestRun_Args(t *testing.T) {
+	args := []string{"a"}
+	r := NewRun("backup", args, 0)
+	args[0] = "b"
+	r.Args()[0] = "c"
+	if got := r.Args()[0]; got != "a" {
+		t.Errorf("Args()[0] = %v, want the run's args to be unaffected by changes to the given or returned slices", got)
+	}
+}
@@ -0,0 +1,189 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ProviderID is the user_external provider ID that local users are mapped to, their external ID is their username
+const ProviderID = "local"
+
+// Username and password length limits
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 64
+	MinPasswordLength = 8
+	// MaxPasswordLength is bcrypt's input limit, in bytes
+	MaxPasswordLength = 72
+)
+
+// Credential is a local user's username and password
+// Only a bcrypt hash of the password is kept, along with a hash of any outstanding password reset token
+type Credential struct {
+	username     string
+	userID       user.ID
+	passwordHash string
+	version      int
+	resetHash    string
+	resetExpires time.Time
+}
+
+// New instantiates a new credential entity for a user, with a hashed password
+func New(username string, uid user.ID, password string) (*Credential, error) {
+	username, err := ParseUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if uid.IsEmpty() {
+		return nil, errors.New("credential must belong to a user")
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{username: username, userID: uid, passwordHash: hash, version: 1}, nil
+}
+
+// NewRaw instantiates a credential entity with all available fields
+func NewRaw(username string, uid user.ID, passwordHash string, version int, resetHash string, resetExpires time.Time) *Credential {
+	return &Credential{
+		username:     username,
+		userID:       uid,
+		passwordHash: passwordHash,
+		version:      version,
+		resetHash:    resetHash,
+		resetExpires: resetExpires,
+	}
+}
+
+// ParseUsername normalizes a username and validates its length and characters
+func ParseUsername(username string) (string, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if l := utf8.RuneCountInString(username); l < MinUsernameLength || l > MaxUsernameLength {
+		return "", fmt.Errorf("username must be between %d and %d characters", MinUsernameLength, MaxUsernameLength)
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && !strings.ContainsRune("._-@", r) {
+			return "", fmt.Errorf("username cannot contain '%c', only letters, numbers and . _ - @ are allowed", r)
+		}
+	}
+	return username, nil
+}
+
+// ValidatePassword validates a new password's length
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password cannot be longer than %d bytes", MaxPasswordLength)
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	if err := ValidatePassword(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %v", err)
+	}
+	return string(hash), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Username returns the credential's unique username
+func (c *Credential) Username() string {
+	return c.username
+}
+
+// UserID returns the ID of the user the credential belongs to
+func (c *Credential) UserID() user.ID {
+	return c.userID
+}
+
+// PasswordHash returns the bcrypt hash of the password
+func (c *Credential) PasswordHash() string {
+	return c.passwordHash
+}
+
+// Version returns the credential's version, which increases every time the password changes
+// Sessions issued for an older version are no longer valid
+func (c *Credential) Version() int {
+	return c.version
+}
+
+// ResetHash returns the hash of the outstanding password reset token, empty if there is none
+func (c *Credential) ResetHash() string {
+	return c.resetHash
+}
+
+// ResetExpires returns the time the outstanding password reset token expires
+func (c *Credential) ResetExpires() time.Time {
+	return c.resetExpires
+}
+
+// CheckPassword returns whether the password matches
+func (c *Credential) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(c.passwordHash), []byte(password)) == nil
+}
+
+// ChangePassword sets a new password if the current password matches
+func (c *Credential) ChangePassword(current string, password string) error {
+	if !c.CheckPassword(current) {
+		return errors.New("current password does not match")
+	}
+	return c.setPassword(password)
+}
+
+func (c *Credential) setPassword(password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	c.passwordHash = hash
+	c.version++
+	c.resetHash = ""
+	c.resetExpires = time.Time{}
+	return nil
+}
+
+// NewResetToken creates a single-use password reset token that expires after the given duration, replacing any outstanding one
+func (c *Credential) NewResetToken(ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating reset token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	c.resetHash = hashResetToken(token)
+	c.resetExpires = clock.Now().Add(ttl)
+	return token, nil
+}
+
+// ResetPassword sets a new password if the reset token matches the outstanding one and hasn't expired
+func (c *Credential) ResetPassword(token string, password string) error {
+	if c.resetHash == "" || subtle.ConstantTimeCompare([]byte(hashResetToken(token)), []byte(c.resetHash)) != 1 {
+		return errors.New("invalid password reset token")
+	}
+	if !clock.Now().Before(c.resetExpires) {
+		return errors.New("password reset token has expired")
+	}
+	return c.setPassword(password)
+}
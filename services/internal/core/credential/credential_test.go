@@ -0,0 +1,134 @@
+package credential
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNew(t *testing.T) {
+	uid := user.NewID()
+
+	type args struct {
+		username string
+		uid      user.ID
+		password string
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantUsername string
+		wantErr      bool
+	}{
+		{
+			name:         "should create credential with normalized username",
+			args:         args{username: "  Jane.Doe@Example.com ", uid: uid, password: "password1"},
+			wantUsername: "jane.doe@example.com",
+			wantErr:      false,
+		},
+		{
+			name:    "should return error for short username",
+			args:    args{username: "jd", uid: uid, password: "password1"},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for username with invalid characters",
+			args:    args{username: "jane doe", uid: uid, password: "password1"},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for short password",
+			args:    args{username: "jane", uid: uid, password: "short"},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for password that is too long",
+			args:    args{username: "jane", uid: uid, password: strings.Repeat("a", MaxPasswordLength+1)},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for empty user",
+			args:    args{username: "jane", uid: user.ID{}, password: "password1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.args.username, tt.args.uid, tt.args.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Username() != tt.wantUsername {
+				t.Errorf("New() username = %v, want %v", got.Username(), tt.wantUsername)
+			}
+			if strings.Contains(got.PasswordHash(), tt.args.password) || !got.CheckPassword(tt.args.password) {
+				t.Errorf("New() password hash = %v, should be a hash of the password", got.PasswordHash())
+			}
+		})
+	}
+}
+
+func TestCredential_ChangePassword(t *testing.T) {
+	c, _ := New("jane", user.NewID(), "password1")
+
+	if err := c.ChangePassword("wrong password", "password2"); err == nil {
+		t.Errorf("Credential.ChangePassword() with wrong current password should return an error")
+	}
+	if err := c.ChangePassword("password1", "password2"); err != nil {
+		t.Errorf("Credential.ChangePassword() error = %v", err)
+	}
+	if c.CheckPassword("password1") || !c.CheckPassword("password2") {
+		t.Errorf("Credential.ChangePassword() should replace the password")
+	}
+	if c.Version() != 2 {
+		t.Errorf("Credential.ChangePassword() version = %v, want 2", c.Version())
+	}
+}
+
+func TestCredential_ResetPassword(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewMock(func() time.Time { return now }))
+	defer clock.Set(prevClock)
+	c, _ := New("jane", user.NewID(), "password1")
+	token, _ := c.NewResetToken(time.Hour)
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		token    string
+		password string
+		wantErr  bool
+	}{
+		{name: "wrong reset token should return error", token: "wrong", password: "password2", wantErr: true},
+		{name: "invalid new password should return error", token: token, password: "short", wantErr: true},
+		{name: "valid reset token should reset password", token: token, password: "password2", wantErr: false},
+		{name: "reset token should only be usable once", token: token, password: "password3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			err := c.ResetPassword(tt.token, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Credential.ResetPassword() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !c.CheckPassword(tt.password) {
+				t.Errorf("Credential.ResetPassword() should set the new password")
+			}
+		})
+	}
+
+	expired, _ := c.NewResetToken(time.Hour)
+	now = now.Add(time.Hour)
+	if err := c.ResetPassword(expired, "password4"); err == nil {
+		t.Errorf("Credential.ResetPassword() with expired reset token should return an error")
+	}
+}
@@ -0,0 +1,174 @@
+package event
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// MaxErrorLength is the maximum length of an event's recorded error, in characters, longer errors are truncated
+const MaxErrorLength = 1000
+
+// Type is the kind of change an event records
+type Type string
+
+// Event types emitted by tasks and schedules
+const (
+	TaskCreated      Type = "task.created"
+	TaskGenerated    Type = "task.generated"
+	TaskCompleted    Type = "task.completed"
+	TaskUncompleted  Type = "task.uncompleted"
+	TaskCleared      Type = "task.cleared"
+	ScheduleCreated  Type = "schedule.created"
+	SchedulePaused   Type = "schedule.paused"
+	ScheduleUnpaused Type = "schedule.unpaused"
+	ScheduleRemoved  Type = "schedule.removed"
+)
+
+// Status is the publishing state of an event
+type Status uint8
+
+// Event statuses
+const (
+	StatusPending Status = iota
+	StatusPublished
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusPublished:
+		return "published"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Event is a domain event, recording a change to an entity that subscribers are told about once the change is persisted
+// the subscribers that have handled it are recorded, so a failed attempt is only retried for the others
+type Event struct {
+	id              ID
+	typ             Type
+	status          Status
+	attempts        int
+	lastError       string
+	deliveredTo     []string
+	occurredTime    time.Time
+	publishedTime   time.Time
+	nextAttemptTime time.Time
+}
+
+// New instantiates a new pending event that occurred now, due to be published immediately
+func New(t Type) *Event {
+	now := clock.Now()
+	return &Event{id: NewID(), typ: t, status: StatusPending, occurredTime: now, nextAttemptTime: now}
+}
+
+// NewRaw instantiates an event entity with all available fields
+func NewRaw(id ID, t Type, status Status, attempts int, lastError string, deliveredTo []string, occurred time.Time, published time.Time, nextAttempt time.Time) *Event {
+	return &Event{
+		id:              id,
+		typ:             t,
+		status:          status,
+		attempts:        attempts,
+		lastError:       lastError,
+		deliveredTo:     append([]string{}, deliveredTo...),
+		occurredTime:    occurred,
+		publishedTime:   published,
+		nextAttemptTime: nextAttempt,
+	}
+}
+
+// ID returns the event's unique ID
+func (e *Event) ID() ID {
+	return e.id
+}
+
+// Type returns the kind of change the event records
+func (e *Event) Type() Type {
+	return e.typ
+}
+
+// Status returns the event's publishing status
+func (e *Event) Status() Status {
+	return e.status
+}
+
+// Attempts returns the number of times publishing the event has failed
+func (e *Event) Attempts() int {
+	return e.attempts
+}
+
+// LastError returns the reason publishing the event last failed, empty if it hasn't
+func (e *Event) LastError() string {
+	return e.lastError
+}
+
+// DeliveredTo returns the names of the subscribers that have handled the event
+func (e *Event) DeliveredTo() []string {
+	return append([]string{}, e.deliveredTo...)
+}
+
+// IsDeliveredTo returns whether a subscriber has handled the event
+func (e *Event) IsDeliveredTo(subscriber string) bool {
+	for _, s := range e.deliveredTo {
+		if s == subscriber {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivered records a subscriber as having handled the event, so it isn't given the event again if another subscriber fails
+func (e *Event) Delivered(subscriber string) {
+	if !e.IsDeliveredTo(subscriber) {
+		e.deliveredTo = append(e.deliveredTo, subscriber)
+	}
+}
+
+// OccurredTime returns the time the change was made
+func (e *Event) OccurredTime() time.Time {
+	return e.occurredTime
+}
+
+// PublishedTime returns the time the event was published to all subscribers, or given up on, zero if it's pending
+func (e *Event) PublishedTime() time.Time {
+	return e.publishedTime
+}
+
+// NextAttemptTime returns when publishing the event is next due, zero once it's no longer pending
+func (e *Event) NextAttemptTime() time.Time {
+	return e.nextAttemptTime
+}
+
+// Published records the event as published to all subscribers
+func (e *Event) Published() {
+	e.status = StatusPublished
+	e.publishedTime = clock.Now()
+	e.nextAttemptTime = time.Time{}
+}
+
+// Failed records a failed attempt to publish the event, retrying it after the backoff, or giving up once maxAttempts is reached
+func (e *Event) Failed(reason string, maxAttempts int, backoff time.Duration) {
+	e.attempts++
+	e.lastError = truncate(reason)
+	now := clock.Now()
+	if e.attempts >= maxAttempts {
+		e.status = StatusFailed
+		e.publishedTime = now
+		e.nextAttemptTime = time.Time{}
+		return
+	}
+	e.nextAttemptTime = now.Add(backoff)
+}
+
+func truncate(reason string) string {
+	if utf8.RuneCountInString(reason) <= MaxErrorLength {
+		return reason
+	}
+	return string([]rune(reason)[:MaxErrorLength])
+}
@@ -0,0 +1,83 @@
+package event
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	e := New(TaskCreated)
+	if e.ID().IsEmpty() || e.Type() != TaskCreated || e.Status() != StatusPending || !e.OccurredTime().Equal(now) || !e.PublishedTime().IsZero() || !e.NextAttemptTime().Equal(now) {
+		t.Errorf("New() = %+v, want pending %v event that occurred at %v, due immediately", e, TaskCreated, now)
+	}
+}
+
+func TestEvent_Failed(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	e := New(TaskCompleted)
+	wantStatus := []Status{StatusPending, StatusPending, StatusFailed}
+	wantPublished := []time.Time{{}, {}, now}
+	wantNext := []time.Time{now.Add(time.Second), now.Add(time.Second), {}}
+	for i := range wantStatus {
+		e.Failed("subscriber unavailable", 3, time.Second)
+		if e.Attempts() != i+1 {
+			t.Errorf("attempt %v: attempts = %v, want %v", i+1, e.Attempts(), i+1)
+		}
+		if e.Status() != wantStatus[i] {
+			t.Errorf("attempt %v: status = %v, want %v", i+1, e.Status(), wantStatus[i])
+		}
+		if !e.PublishedTime().Equal(wantPublished[i]) {
+			t.Errorf("attempt %v: publishedTime = %v, want %v", i+1, e.PublishedTime(), wantPublished[i])
+		}
+		if !e.NextAttemptTime().Equal(wantNext[i]) {
+			t.Errorf("attempt %v: nextAttemptTime = %v, want %v", i+1, e.NextAttemptTime(), wantNext[i])
+		}
+		if e.LastError() != "subscriber unavailable" {
+			t.Errorf("attempt %v: lastError = %v, want subscriber unavailable", i+1, e.LastError())
+		}
+	}
+
+	e.Failed(strings.Repeat("x", MaxErrorLength+1), 3, time.Second)
+	if len(e.LastError()) != MaxErrorLength {
+		t.Errorf("lastError length = %v, want truncated to %v", len(e.LastError()), MaxErrorLength)
+	}
+}
+
+func TestEvent_Published(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	e := New(ScheduleRemoved)
+	e.Failed("subscriber unavailable", 3, time.Second)
+	e.Published()
+	if e.Status() != StatusPublished || !e.PublishedTime().Equal(now) || e.Attempts() != 1 {
+		t.Errorf("Published() = %v at %v after %v attempts, want %v at %v after 1 attempt", e.Status(), e.PublishedTime(), e.Attempts(), StatusPublished, now)
+	}
+}
+
+func TestEvent_Delivered(t *testing.T) {
+	e := New(TaskCreated)
+	e.Delivered("webhook")
+	e.Delivered("webhook")
+	e.Delivered("chat")
+	if got := e.DeliveredTo(); len(got) != 2 || got[0] != "webhook" || got[1] != "chat" {
+		t.Errorf("DeliveredTo() = %v, want each subscriber recorded once", got)
+	}
+	if !e.IsDeliveredTo("chat") || e.IsDeliveredTo("email") {
+		t.Errorf("IsDeliveredTo() should only be true for recorded subscribers")
+	}
+}
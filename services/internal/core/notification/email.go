@@ -0,0 +1,156 @@
+package notification
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// MaxErrorLength is the maximum length of an email's recorded error, in characters, longer errors are truncated
+const MaxErrorLength = 1000
+
+// Status is the state of a queued email
+type Status uint8
+
+// Email statuses
+const (
+	StatusPending Status = iota
+	StatusSent
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusSent:
+		return "sent"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Email is a rendered notification email queued to be sent, along with the outcome of its latest attempt
+// failed attempts are retried the same way as webhook deliveries
+type Email struct {
+	id              ID
+	to              string
+	subject         string
+	html            []byte
+	status          Status
+	attempts        int
+	lastError       string
+	createdTime     time.Time
+	lastAttemptTime time.Time
+	nextAttemptTime time.Time
+}
+
+// NewEmail instantiates a new pending HTML email to a single recipient, due immediately
+func NewEmail(to string, subject string, html []byte) *Email {
+	now := clock.Now()
+	return &Email{
+		id:              NewID(),
+		to:              to,
+		subject:         subject,
+		html:            html,
+		status:          StatusPending,
+		createdTime:     now,
+		nextAttemptTime: now,
+	}
+}
+
+// NewRawEmail instantiates an email entity with all available fields
+func NewRawEmail(id ID, to string, subject string, html []byte, status Status, attempts int, lastError string, created time.Time, lastAttempt time.Time, nextAttempt time.Time) *Email {
+	return &Email{
+		id:              id,
+		to:              to,
+		subject:         subject,
+		html:            html,
+		status:          status,
+		attempts:        attempts,
+		lastError:       lastError,
+		createdTime:     created,
+		lastAttemptTime: lastAttempt,
+		nextAttemptTime: nextAttempt,
+	}
+}
+
+// ID returns the email's unique ID
+func (e *Email) ID() ID {
+	return e.id
+}
+
+// To returns the recipient's address
+func (e *Email) To() string {
+	return e.to
+}
+
+// Subject returns the email's subject line
+func (e *Email) Subject() string {
+	return e.subject
+}
+
+// HTML returns the email's rendered HTML body
+func (e *Email) HTML() []byte {
+	return e.html
+}
+
+// Status returns the email's status
+func (e *Email) Status() Status {
+	return e.status
+}
+
+// Attempts returns the number of times sending the email has been attempted
+func (e *Email) Attempts() int {
+	return e.attempts
+}
+
+// LastError returns the reason the latest attempt failed, empty if it succeeded
+func (e *Email) LastError() string {
+	return e.lastError
+}
+
+// CreatedTime returns the time the email was queued
+func (e *Email) CreatedTime() time.Time {
+	return e.createdTime
+}
+
+// LastAttemptTime returns the time of the latest attempt, zero if it hasn't been attempted
+func (e *Email) LastAttemptTime() time.Time {
+	return e.lastAttemptTime
+}
+
+// NextAttemptTime returns when the email is due to be attempted, zero once it has been sent or failed
+func (e *Email) NextAttemptTime() time.Time {
+	return e.nextAttemptTime
+}
+
+// Sent records a successful attempt
+func (e *Email) Sent() {
+	e.attempted("")
+	e.status = StatusSent
+	e.nextAttemptTime = time.Time{}
+}
+
+// Failed records a failed attempt, and schedules a retry with backoff until the policy's maximum attempts are reached
+func (e *Email) Failed(reason string, p webhook.RetryPolicy) {
+	e.attempted(reason)
+	if e.attempts >= p.MaxAttempts {
+		e.status = StatusFailed
+		e.nextAttemptTime = time.Time{}
+		return
+	}
+	e.nextAttemptTime = e.lastAttemptTime.Add(p.Backoff(e.attempts))
+}
+
+func (e *Email) attempted(reason string) {
+	e.attempts++
+	if utf8.RuneCountInString(reason) > MaxErrorLength {
+		reason = string([]rune(reason)[:MaxErrorLength])
+	}
+	e.lastError = reason
+	e.lastAttemptTime = clock.Now()
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+func TestEmail_Failed(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	p := webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute}
+
+	e := NewEmail("user@example.com", "New task", []byte("<p>task</p>"))
+	if !e.NextAttemptTime().Equal(now) {
+		t.Fatalf("NewEmail() nextAttemptTime = %v, want %v", e.NextAttemptTime(), now)
+	}
+
+	wantNext := []time.Time{now.Add(time.Minute), now.Add(2 * time.Minute), {}}
+	wantStatus := []Status{StatusPending, StatusPending, StatusFailed}
+	for i := range wantNext {
+		e.Failed("smtp unavailable", p)
+		if e.Attempts() != i+1 {
+			t.Errorf("attempt %v: attempts = %v, want %v", i+1, e.Attempts(), i+1)
+		}
+		if e.Status() != wantStatus[i] {
+			t.Errorf("attempt %v: status = %v, want %v", i+1, e.Status(), wantStatus[i])
+		}
+		if !e.NextAttemptTime().Equal(wantNext[i]) {
+			t.Errorf("attempt %v: nextAttemptTime = %v, want %v", i+1, e.NextAttemptTime(), wantNext[i])
+		}
+		if e.LastError() != "smtp unavailable" || !e.LastAttemptTime().Equal(now) {
+			t.Errorf("attempt %v: outcome = %v %v, want smtp unavailable %v", i+1, e.LastError(), e.LastAttemptTime(), now)
+		}
+	}
+}
+
+func TestEmail_Sent(t *testing.T) {
+	e := NewEmail("user@example.com", "New task", []byte("<p>task</p>"))
+	e.Failed(strings.Repeat("x", MaxErrorLength+1), webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute})
+	if len(e.LastError()) != MaxErrorLength {
+		t.Errorf("Failed() lastError length = %v, want it truncated to %v", len(e.LastError()), MaxErrorLength)
+	}
+	e.Sent()
+	if e.Status() != StatusSent || e.Attempts() != 2 || e.LastError() != "" || !e.NextAttemptTime().IsZero() {
+		t.Errorf("Sent() = %v %v %v %v, want sent after 2 attempts", e.Status(), e.Attempts(), e.LastError(), e.NextAttemptTime())
+	}
+}
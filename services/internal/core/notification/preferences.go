@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// MaxEmailLength is the maximum length of a notification email address, in characters
+const MaxEmailLength = 254
+
+// Preferences are a user's choices of which emails they're sent, and where
+type Preferences struct {
+	userID         user.ID
+	email          string
+	taskGenerated  bool
+	digest         bool
+	digestHour     int
+	lastDigestTime time.Time
+}
+
+// New instantiates a user's notification preferences, with the daily digest sent at the given hour of the day in UTC
+// an empty email address disables all notifications
+func New(uid user.ID, email string, taskGenerated bool, digest bool, digestHour int) (*Preferences, error) {
+	if uid.IsEmpty() {
+		return nil, errors.New("notification preferences must belong to a user")
+	}
+	email = strings.TrimSpace(email)
+	if l := utf8.RuneCountInString(email); l > MaxEmailLength {
+		return nil, fmt.Errorf("email address is %d characters, cannot be longer than %d", l, MaxEmailLength)
+	}
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return nil, fmt.Errorf("invalid email address '%v'", email)
+		}
+	}
+	if (taskGenerated || digest) && email == "" {
+		return nil, errors.New("an email address is required to enable notifications")
+	}
+	if digestHour < 0 || digestHour > 23 {
+		return nil, fmt.Errorf("digest hour %d must be between 0 and 23", digestHour)
+	}
+	return &Preferences{userID: uid, email: email, taskGenerated: taskGenerated, digest: digest, digestHour: digestHour}, nil
+}
+
+// NewRaw instantiates notification preferences with all available fields
+func NewRaw(uid user.ID, email string, taskGenerated bool, digest bool, digestHour int, lastDigest time.Time) *Preferences {
+	return &Preferences{
+		userID:         uid,
+		email:          email,
+		taskGenerated:  taskGenerated,
+		digest:         digest,
+		digestHour:     digestHour,
+		lastDigestTime: lastDigest,
+	}
+}
+
+// UserID returns the ID of the user the preferences belong to
+func (p *Preferences) UserID() user.ID {
+	return p.userID
+}
+
+// Email returns the address notifications are sent to
+func (p *Preferences) Email() string {
+	return p.email
+}
+
+// TaskGenerated returns whether the user is emailed when a schedule generates a task for them
+func (p *Preferences) TaskGenerated() bool {
+	return p.taskGenerated
+}
+
+// Digest returns whether the user is sent a daily digest of their open tasks
+func (p *Preferences) Digest() bool {
+	return p.digest
+}
+
+// DigestHour returns the hour of the day the digest is sent at, in UTC
+func (p *Preferences) DigestHour() int {
+	return p.digestHour
+}
+
+// LastDigestTime returns the time the last digest was sent, zero if none has been
+func (p *Preferences) LastDigestTime() time.Time {
+	return p.lastDigestTime
+}
+
+// SetLastDigestTime keeps the time the last digest was sent when preferences are replaced, so the new ones don't send it again today
+func (p *Preferences) SetLastDigestTime(t time.Time) {
+	p.lastDigestTime = t
+}
+
+// DigestDue returns whether the digest is enabled and hasn't been sent since its most recent hour of the day, as of now
+func (p *Preferences) DigestDue(now time.Time) bool {
+	if !p.digest {
+		return false
+	}
+	now = now.UTC()
+	due := time.Date(now.Year(), now.Month(), now.Day(), p.digestHour, 0, 0, 0, time.UTC)
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return p.lastDigestTime.Before(due)
+}
+
+// DigestSent records the digest as sent at the given time
+func (p *Preferences) DigestSent(t time.Time) {
+	p.lastDigestTime = t
+}
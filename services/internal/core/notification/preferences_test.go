@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNew(t *testing.T) {
+	uid := user.NewID()
+
+	type args struct {
+		uid           user.ID
+		email         string
+		taskGenerated bool
+		digest        bool
+		digestHour    int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "valid preferences should be created",
+			args: args{uid, " jane@example.com ", true, true, 7},
+		},
+		{
+			name: "disabled notifications should not need an email address",
+			args: args{uid, "", false, false, 0},
+		},
+		{
+			name:    "enabled notifications should need an email address",
+			args:    args{uid, "", false, true, 0},
+			wantErr: true,
+		},
+		{
+			name:    "invalid email address should return an error",
+			args:    args{uid, "Jane <jane@example.com>", true, false, 0},
+			wantErr: true,
+		},
+		{
+			name:    "long email address should return an error",
+			args:    args{uid, strings.Repeat("a", MaxEmailLength) + "@example.com", true, false, 0},
+			wantErr: true,
+		},
+		{
+			name:    "digest hour after 23 should return an error",
+			args:    args{uid, "jane@example.com", false, true, 24},
+			wantErr: true,
+		},
+		{
+			name:    "empty user should return an error",
+			args:    args{user.ID{}, "jane@example.com", true, false, 0},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.args.uid, tt.args.email, tt.args.taskGenerated, tt.args.digest, tt.args.digestHour)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Email() != strings.TrimSpace(tt.args.email) {
+				t.Errorf("New() email = %v, want %v", got.Email(), strings.TrimSpace(tt.args.email))
+			}
+		})
+	}
+}
+
+func TestPreferences_DigestDue(t *testing.T) {
+	uid := user.NewID()
+	now := time.Date(2000, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		p    *Preferences
+		want bool
+	}{
+		{
+			name: "disabled digest should not be due",
+			p:    NewRaw(uid, "jane@example.com", false, false, 9, time.Time{}),
+			want: false,
+		},
+		{
+			name: "digest never sent should be due after its hour",
+			p:    NewRaw(uid, "jane@example.com", false, true, 9, time.Time{}),
+			want: true,
+		},
+		{
+			name: "digest sent yesterday should be due after its hour",
+			p:    NewRaw(uid, "jane@example.com", false, true, 9, time.Date(2000, 1, 1, 9, 0, 0, 0, time.UTC)),
+			want: true,
+		},
+		{
+			name: "digest sent today should not be due",
+			p:    NewRaw(uid, "jane@example.com", false, true, 9, time.Date(2000, 1, 2, 9, 1, 0, 0, time.UTC)),
+			want: false,
+		},
+		{
+			name: "digest sent yesterday should not be due before its hour",
+			p:    NewRaw(uid, "jane@example.com", false, true, 10, time.Date(2000, 1, 1, 10, 0, 0, 0, time.UTC)),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.DigestDue(now); got != tt.want {
+				t.Errorf("Preferences.DigestDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package schedule
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+)
+
+// MaxActionURLLength is the maximum length of an HTTP action's URL, in characters
+const MaxActionURLLength = 2000
+
+// MaxActionBodyLength is the maximum length of an HTTP action's body template, in characters
+const MaxActionBodyLength = 10000
+
+// MaxActionTimeout is the longest an HTTP action can wait for a response
+const MaxActionTimeout = 5 * time.Minute
+
+// MaxActionAttempts is the most times an HTTP action can be attempted
+const MaxActionAttempts = 20
+
+// actionMethods are the HTTP methods an action can use
+var actionMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+// ActionData is the data an HTTP action's body template is rendered with, for each occurrence
+// times are formatted as RFC 3339, a task without a due time has an empty DueTime
+type ActionData struct {
+	Name        string
+	Description string
+	Occurrence  string
+	DueTime     string
+}
+
+// HTTPAction is an HTTP request made each time a recurring task occurs, in addition to generating its task
+// if completeTask is set, the generated task is completed once the request succeeds, so it's a record of the request rather than a task for someone to do
+type HTTPAction struct {
+	method       string
+	url          string
+	headers      map[string]string
+	body         string
+	tmpl         *template.Template
+	timeout      time.Duration
+	maxAttempts  int
+	completeTask bool
+}
+
+// NewHTTPAction instantiates a new HTTP action, with a text/template body rendered with ActionData for each occurrence
+// zero timeout and maximum attempts use the executor's defaults
+func NewHTTPAction(method string, rawURL string, headers map[string]string, body string, timeout time.Duration, maxAttempts int, completeTask bool) (HTTPAction, error) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		method = "POST"
+	}
+	if !validMethod(method) {
+		return HTTPAction{}, fmt.Errorf("unknown HTTP method '%v', should be one of %v", method, strings.Join(actionMethods, ", "))
+	}
+	rawURL = strings.TrimSpace(rawURL)
+	if l := utf8.RuneCountInString(rawURL); l > MaxActionURLLength {
+		return HTTPAction{}, fmt.Errorf("action URL is %d characters, cannot be longer than %d", l, MaxActionURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return HTTPAction{}, fmt.Errorf("invalid action URL: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return HTTPAction{}, fmt.Errorf("action URL '%v' must be an absolute http or https URL", rawURL)
+	}
+	for name, value := range headers {
+		if !validHeaderName(name) {
+			return HTTPAction{}, fmt.Errorf("invalid header name '%v'", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return HTTPAction{}, fmt.Errorf("header '%v' value cannot contain line breaks", name)
+		}
+	}
+	if l := utf8.RuneCountInString(body); l > MaxActionBodyLength {
+		return HTTPAction{}, fmt.Errorf("action body is %d characters, cannot be longer than %d", l, MaxActionBodyLength)
+	}
+	tmpl, err := template.New("body").Parse(body)
+	if err != nil {
+		return HTTPAction{}, fmt.Errorf("invalid action body template: %v", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, ActionData{}); err != nil {
+		return HTTPAction{}, fmt.Errorf("invalid action body template: %v", err)
+	}
+	if timeout < 0 || timeout > MaxActionTimeout {
+		return HTTPAction{}, fmt.Errorf("action timeout %v must be between 0 and %v", timeout, MaxActionTimeout)
+	}
+	if maxAttempts < 0 || maxAttempts > MaxActionAttempts {
+		return HTTPAction{}, fmt.Errorf("action max attempts %d must be between 0 and %d", maxAttempts, MaxActionAttempts)
+	}
+	return HTTPAction{
+		method:       method,
+		url:          rawURL,
+		headers:      copyHeaders(headers),
+		body:         body,
+		tmpl:         tmpl,
+		timeout:      timeout,
+		maxAttempts:  maxAttempts,
+		completeTask: completeTask,
+	}, nil
+}
+
+func validMethod(method string) bool {
+	for _, m := range actionMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// validHeaderName returns whether a header name is a non-empty HTTP token
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`()<>@,;:\"/[]?={}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	c := make(map[string]string, len(headers))
+	for name, value := range headers {
+		c[name] = value
+	}
+	return c
+}
+
+// Method returns the action's HTTP method
+func (a HTTPAction) Method() string {
+	return a.method
+}
+
+// URL returns the URL the action's requests are sent to
+func (a HTTPAction) URL() string {
+	return a.url
+}
+
+// Headers returns the action's request headers, by name
+func (a HTTPAction) Headers() map[string]string {
+	return copyHeaders(a.headers)
+}
+
+// Body returns the action's body template
+func (a HTTPAction) Body() string {
+	return a.body
+}
+
+// Timeout returns how long the action waits for a response, zero to use the default
+func (a HTTPAction) Timeout() time.Duration {
+	return a.timeout
+}
+
+// MaxAttempts returns the number of times the action is attempted before it fails, zero to use the default
+func (a HTTPAction) MaxAttempts() int {
+	return a.maxAttempts
+}
+
+// CompleteTask returns whether generated tasks are completed once their request succeeds
+func (a HTTPAction) CompleteTask() bool {
+	return a.completeTask
+}
+
+// NewCall renders the action's request for an occurrence, and the task generated for it
+func (a HTTPAction) NewCall(occurrence time.Time, t *task.Task) (*action.Call, error) {
+	data := ActionData{Name: t.Name(), Description: t.Description(), Occurrence: occurrence.UTC().Format(time.RFC3339)}
+	if !t.DueTime().IsZero() {
+		data.DueTime = t.DueTime().UTC().Format(time.RFC3339)
+	}
+	if a.tmpl == nil {
+		return nil, errors.New("action body template is not parsed")
+	}
+	var body bytes.Buffer
+	if err := a.tmpl.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("error rendering action body: %v", err)
+	}
+	return action.NewCall(action.NewRequest(a.method, a.url, a.headers, body.String()), a.timeout, a.maxAttempts, a.completeTask), nil
+}
+
+// Equal returns whether 2 HTTP actions are equal
+func (a HTTPAction) Equal(other HTTPAction) bool {
+	if a.method != other.method || a.url != other.url || a.body != other.body || a.timeout != other.timeout || a.maxAttempts != other.maxAttempts || a.completeTask != other.completeTask || len(a.headers) != len(other.headers) {
+		return false
+	}
+	for name, value := range a.headers {
+		if ov, ok := other.headers[name]; !ok || ov != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNewHTTPAction(t *testing.T) {
+	type args struct {
+		method      string
+		url         string
+		headers     map[string]string
+		body        string
+		timeout     time.Duration
+		maxAttempts int
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantMethod string
+		wantErr    string
+	}{
+		{
+			name:       "valid action should be created",
+			args:       args{method: "put", url: "https://example.com/warm", headers: map[string]string{"X-Token": "s3cret"}, body: `{"at":"{{.Occurrence}}"}`, timeout: time.Minute, maxAttempts: 3},
+			wantMethod: "PUT",
+		},
+		{
+			name:       "action without a method should POST",
+			args:       args{url: "http://localhost:8080/warm"},
+			wantMethod: "POST",
+		},
+		{
+			name:    "unknown method should return an error",
+			args:    args{method: "CONNECT", url: "https://example.com/warm"},
+			wantErr: "unknown HTTP method 'CONNECT'",
+		},
+		{
+			name:    "relative URL should return an error",
+			args:    args{url: "/warm"},
+			wantErr: "must be an absolute http or https URL",
+		},
+		{
+			name:    "non-http URL should return an error",
+			args:    args{url: "file:///etc/passwd"},
+			wantErr: "must be an absolute http or https URL",
+		},
+		{
+			name:    "invalid header name should return an error",
+			args:    args{url: "https://example.com/warm", headers: map[string]string{"X Token": "a"}},
+			wantErr: "invalid header name 'X Token'",
+		},
+		{
+			name:    "header value with a line break should return an error",
+			args:    args{url: "https://example.com/warm", headers: map[string]string{"X-Token": "a\r\nX-Other: b"}},
+			wantErr: "cannot contain line breaks",
+		},
+		{
+			name:    "body template that doesn't parse should return an error",
+			args:    args{url: "https://example.com/warm", body: "{{.Name"},
+			wantErr: "invalid action body template",
+		},
+		{
+			name:    "body template with an unknown field should return an error",
+			args:    args{url: "https://example.com/warm", body: "{{.Assignee}}"},
+			wantErr: "invalid action body template",
+		},
+		{
+			name:    "body that's too long should return an error",
+			args:    args{url: "https://example.com/warm", body: strings.Repeat("x", MaxActionBodyLength+1)},
+			wantErr: "cannot be longer than",
+		},
+		{
+			name:    "timeout that's too long should return an error",
+			args:    args{url: "https://example.com/warm", timeout: MaxActionTimeout + time.Second},
+			wantErr: "action timeout",
+		},
+		{
+			name:    "too many attempts should return an error",
+			args:    args{url: "https://example.com/warm", maxAttempts: MaxActionAttempts + 1},
+			wantErr: "action max attempts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewHTTPAction(tt.args.method, tt.args.url, tt.args.headers, tt.args.body, tt.args.timeout, tt.args.maxAttempts, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewHTTPAction() error = %v, want error containing %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewHTTPAction() error = %v", err)
+			}
+			if got.Method() != tt.wantMethod || got.URL() != tt.args.url || got.Body() != tt.args.body || got.Timeout() != tt.args.timeout || got.MaxAttempts() != tt.args.maxAttempts {
+				t.Errorf("NewHTTPAction() = %v %v %v %v %v, want %v %v %v %v %v", got.Method(), got.URL(), got.Body(), got.Timeout(), got.MaxAttempts(), tt.wantMethod, tt.args.url, tt.args.body, tt.args.timeout, tt.args.maxAttempts)
+			}
+		})
+	}
+}
+
+func TestSchedule_NewTask_action(t *testing.T) {
+	occurrence := time.Date(2000, 1, 1, 9, 0, 0, 0, time.UTC)
+	a, err := NewHTTPAction("POST", "https://example.com/warm", map[string]string{"X-Token": "s3cret"}, `{"task":"{{.Name}}","at":"{{.Occurrence}}","due":"{{.DueTime}}"}`, 10*time.Second, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, _ := NewRecurringTask("warm cache", "").WithDueOffset(time.Hour)
+	f, _ := NewHourFrequency([]int{0})
+	s := New(f, user.NewID())
+	s.AddTask(rt.WithAction(a))
+	s.AddTask(NewRecurringTask("manual task", ""))
+
+	got, err := s.NewTask(0, occurrence)
+	if err != nil {
+		t.Fatalf("Schedule.NewTask() error = %v", err)
+	}
+	c := got.Action()
+	if c == nil {
+		t.Fatalf("Schedule.NewTask() action = nil, want a queued call")
+	}
+	req := c.Request()
+	wantBody := `{"task":"warm cache","at":"2000-01-01T09:00:00Z","due":"2000-01-01T10:00:00Z"}`
+	if req.Method() != "POST" || req.URL() != "https://example.com/warm" || req.Headers()["X-Token"] != "s3cret" || req.Body() != wantBody {
+		t.Errorf("Schedule.NewTask() request = %v %v %v %v, want POST https://example.com/warm with the rendered body %v", req.Method(), req.URL(), req.Headers(), req.Body(), wantBody)
+	}
+	if c.Status() != action.StatusPending || c.Timeout() != 10*time.Second || c.MaxAttempts() != 2 || !c.CompleteTask() {
+		t.Errorf("Schedule.NewTask() call = %v %v %v %v, want a pending call with the action's settings", c.Status(), c.Timeout(), c.MaxAttempts(), c.CompleteTask())
+	}
+
+	got, err = s.NewTask(1, occurrence)
+	if err != nil {
+		t.Fatalf("Schedule.NewTask() error = %v", err)
+	}
+	if got.Action() != nil {
+		t.Errorf("Schedule.NewTask() action = %v, want nil for a recurring task without an action", got.Action())
+	}
+}
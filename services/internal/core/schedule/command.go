@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+)
+
+// MaxCommandProgramLength is the maximum length of a command's program name, in characters
+const MaxCommandProgramLength = 200
+
+// MaxCommandArgs is the maximum number of arguments a command can have
+const MaxCommandArgs = 50
+
+// MaxCommandArgLength is the maximum length of each of a command's arguments, in characters
+const MaxCommandArgLength = 1000
+
+// MaxCommandTimeout is the longest a command can run before it's killed
+const MaxCommandTimeout = time.Hour
+
+// Command is a local program run each time a recurring task occurs, once its task is generated
+// the generated task is completed once the command succeeds, commands are only run if the executor is enabled and allows the program
+type Command struct {
+	program string
+	args    []string
+	timeout time.Duration
+}
+
+// NewCommand instantiates a new command, arguments are passed to the program as is, without a shell
+// zero timeout uses the executor's default
+func NewCommand(program string, args []string, timeout time.Duration) (Command, error) {
+	program = strings.TrimSpace(program)
+	if program == "" {
+		return Command{}, fmt.Errorf("command program is required")
+	}
+	if l := utf8.RuneCountInString(program); l > MaxCommandProgramLength {
+		return Command{}, fmt.Errorf("command program is %d characters, cannot be longer than %d", l, MaxCommandProgramLength)
+	}
+	if strings.ContainsAny(program, "\x00 \t\r\n") {
+		return Command{}, fmt.Errorf("command program '%v' cannot contain whitespace", program)
+	}
+	if len(args) > MaxCommandArgs {
+		return Command{}, fmt.Errorf("command has %d arguments, cannot have more than %d", len(args), MaxCommandArgs)
+	}
+	for i, arg := range args {
+		if l := utf8.RuneCountInString(arg); l > MaxCommandArgLength {
+			return Command{}, fmt.Errorf("command argument %d is %d characters, cannot be longer than %d", i+1, l, MaxCommandArgLength)
+		}
+		if strings.ContainsRune(arg, 0) {
+			return Command{}, fmt.Errorf("command argument %d cannot contain null characters", i+1)
+		}
+	}
+	if timeout < 0 || timeout > MaxCommandTimeout {
+		return Command{}, fmt.Errorf("command timeout %v must be between 0 and %v", timeout, MaxCommandTimeout)
+	}
+	return Command{program: program, args: append([]string{}, args...), timeout: timeout}, nil
+}
+
+// Program returns the name of the program run
+func (c Command) Program() string {
+	return c.program
+}
+
+// Args returns the arguments the program is run with
+func (c Command) Args() []string {
+	return append([]string{}, c.args...)
+}
+
+// Timeout returns how long the command can run before it's killed, zero to use the default
+func (c Command) Timeout() time.Duration {
+	return c.timeout
+}
+
+// NewRun queues a run of the command for an occurrence
+func (c Command) NewRun() *command.Run {
+	return command.NewRun(c.program, c.args, c.timeout)
+}
+
+// Equal returns whether 2 commands are equal
+func (c Command) Equal(other Command) bool {
+	if c.program != other.program || c.timeout != other.timeout || len(c.args) != len(other.args) {
+		return false
+	}
+	for i, arg := range c.args {
+		if other.args[i] != arg {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNewCommand(t *testing.T) {
+	type args struct {
+		program string
+		args    []string
+		timeout time.Duration
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantProgram string
+		wantErr     string
+	}{
+		{
+			name:        "valid command should be created",
+			args:        args{program: " backup ", args: []string{"--dest", "/var/backups/my files"}, timeout: time.Minute},
+			wantProgram: "backup",
+		},
+		{
+			name:    "empty program should return an error",
+			args:    args{program: " "},
+			wantErr: "command program is required",
+		},
+		{
+			name:    "program with whitespace should return an error",
+			args:    args{program: "backup --all"},
+			wantErr: "cannot contain whitespace",
+		},
+		{
+			name:    "too many arguments should return an error",
+			args:    args{program: "backup", args: make([]string, MaxCommandArgs+1)},
+			wantErr: "cannot have more than",
+		},
+		{
+			name:    "argument that's too long should return an error",
+			args:    args{program: "backup", args: []string{strings.Repeat("x", MaxCommandArgLength+1)}},
+			wantErr: "command argument 1 is",
+		},
+		{
+			name:    "argument with a null character should return an error",
+			args:    args{program: "backup", args: []string{"a\x00b"}},
+			wantErr: "cannot contain null characters",
+		},
+		{
+			name:    "timeout that's too long should return an error",
+			args:    args{program: "backup", timeout: MaxCommandTimeout + time.Second},
+			wantErr: "command timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCommand(tt.args.program, tt.args.args, tt.args.timeout)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewCommand() error = %v, want error containing %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewCommand() error = %v", err)
+			}
+			if got.Program() != tt.wantProgram || strings.Join(got.Args(), "|") != strings.Join(tt.args.args, "|") || got.Timeout() != tt.args.timeout {
+				t.Errorf("NewCommand() = %v %v %v, want %v %v %v", got.Program(), got.Args(), got.Timeout(), tt.wantProgram, tt.args.args, tt.args.timeout)
+			}
+		})
+	}
+}
+
+func TestSchedule_NewTask_command(t *testing.T) {
+	occurrence := time.Date(2000, 1, 1, 9, 0, 0, 0, time.UTC)
+	c, err := NewCommand("backup", []string{"--all"}, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := NewHourFrequency([]int{0})
+	s := New(f, user.NewID())
+	s.AddTask(NewRecurringTask("back up", "").WithCommand(c))
+	s.AddTask(NewRecurringTask("manual task", ""))
+
+	got, err := s.NewTask(0, occurrence)
+	if err != nil {
+		t.Fatalf("Schedule.NewTask() error = %v", err)
+	}
+	r := got.Command()
+	if r == nil {
+		t.Fatalf("Schedule.NewTask() command = nil, want a queued run")
+	}
+	if r.Program() != "backup" || strings.Join(r.Args(), " ") != "--all" || r.Timeout() != 10*time.Second || r.Status() != command.StatusPending {
+		t.Errorf("Schedule.NewTask() run = %v %v %v %v, want a pending run with the command's settings", r.Program(), r.Args(), r.Timeout(), r.Status())
+	}
+
+	got, err = s.NewTask(1, occurrence)
+	if err != nil {
+		t.Fatalf("Schedule.NewTask() error = %v", err)
+	}
+	if got.Command() != nil {
+		t.Errorf("Schedule.NewTask() command = %v, want nil for a recurring task without a command", got.Command())
+	}
+}
@@ -1,14 +1,35 @@
 package schedule
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
 // RecurringTask represents a task that recurs on a schedule
 type RecurringTask struct {
-	name        string
-	description string
+	name             string
+	description      string
+	priority         task.Priority
+	dueOffset        time.Duration
+	hasDueOffset     bool
+	tags             []task.Tag
+	checklist        []string
+	autoComplete     bool
+	rotation         []user.ID
+	rotationStrategy RotationStrategy
+	rotationState    []int64
+	action           HTTPAction
+	hasAction        bool
+	command          Command
+	hasCommand       bool
 }
 
 // NewRecurringTask instantiates a new recurring task entity
 func NewRecurringTask(name string, description string) RecurringTask {
-	return RecurringTask{name, description}
+	return RecurringTask{name: name, description: description}
 }
 
 // Name returns the task namee
@@ -21,7 +42,141 @@ func (rt *RecurringTask) Description() string {
 	return rt.description
 }
 
+// Priority returns the priority given to generated tasks
+func (rt *RecurringTask) Priority() task.Priority {
+	return rt.priority
+}
+
+// DueOffset returns how long after each occurrence generated tasks are due, and whether a due offset is set
+func (rt *RecurringTask) DueOffset() (time.Duration, bool) {
+	return rt.dueOffset, rt.hasDueOffset
+}
+
+// WithPriority returns a copy of the recurring task with the given priority
+func (rt RecurringTask) WithPriority(p task.Priority) (RecurringTask, error) {
+	if !p.IsValid() {
+		return rt, fmt.Errorf("invalid priority %d", p)
+	}
+	rt.priority = p
+	return rt, nil
+}
+
+// WithDueOffset returns a copy of the recurring task whose generated tasks are due the given duration after each occurrence
+func (rt RecurringTask) WithDueOffset(offset time.Duration) (RecurringTask, error) {
+	if offset < 0 {
+		return rt, fmt.Errorf("due offset %v cannot be negative", offset)
+	}
+	rt.dueOffset = offset
+	rt.hasDueOffset = true
+	return rt, nil
+}
+
+// Tags returns the tags given to generated tasks
+func (rt *RecurringTask) Tags() []task.Tag {
+	return append([]task.Tag{}, rt.tags...)
+}
+
+// HasTags returns whether the recurring task has all of the given tags
+func (rt *RecurringTask) HasTags(tags ...task.Tag) bool {
+	return task.HasTags(rt.tags, tags)
+}
+
+// WithTags returns a copy of the recurring task with the given tags
+func (rt RecurringTask) WithTags(tags []task.Tag) RecurringTask {
+	rt.tags = task.NormalizeTags(tags)
+	return rt
+}
+
+// Checklist returns the names of the checklist items copied into generated tasks
+func (rt *RecurringTask) Checklist() []string {
+	return append([]string{}, rt.checklist...)
+}
+
+// AutoComplete returns whether generated tasks are completed automatically when all of their checklist items are checked
+func (rt *RecurringTask) AutoComplete() bool {
+	return rt.autoComplete
+}
+
+// WithChecklist returns a copy of the recurring task with the given checklist items and auto-complete rule
+func (rt RecurringTask) WithChecklist(items []string, autoComplete bool) (RecurringTask, error) {
+	parsed, err := task.ParseChecklist(items)
+	if err != nil {
+		return rt, err
+	}
+	rt.checklist = nil
+	for _, item := range parsed {
+		rt.checklist = append(rt.checklist, item.Name())
+	}
+	rt.autoComplete = autoComplete
+	return rt, nil
+}
+
+// Action returns the HTTP request made each time the recurring task occurs, and whether it has one
+func (rt *RecurringTask) Action() (HTTPAction, bool) {
+	return rt.action, rt.hasAction
+}
+
+// WithAction returns a copy of the recurring task that makes an HTTP request each time it occurs
+func (rt RecurringTask) WithAction(a HTTPAction) RecurringTask {
+	rt.action = a
+	rt.hasAction = true
+	return rt
+}
+
+// Command returns the local program run each time the recurring task occurs, and whether it has one
+func (rt *RecurringTask) Command() (Command, bool) {
+	return rt.command, rt.hasCommand
+}
+
+// WithCommand returns a copy of the recurring task that runs a local program each time it occurs
+func (rt RecurringTask) WithCommand(c Command) RecurringTask {
+	rt.command = c
+	rt.hasCommand = true
+	return rt
+}
+
+// NewTask creates a new task for an occurrence of this recurring task at the given time
+func (rt *RecurringTask) NewTask(occurrence time.Time, createdBy user.ID) *task.Task {
+	t := task.New(rt.name, rt.description, createdBy)
+	t.SetPriority(rt.priority)
+	t.SetTags(rt.tags)
+	items := make([]task.ChecklistItem, len(rt.checklist))
+	for i, name := range rt.checklist {
+		items[i] = task.NewRawChecklistItem(name, time.Time{})
+	}
+	t.SetChecklist(items)
+	t.SetAutoComplete(rt.autoComplete)
+	if rt.hasDueOffset {
+		t.SetDueTime(occurrence.Add(rt.dueOffset))
+	}
+	return t
+}
+
 // Equal returns whether 2 recurring tasks are equal
 func (rt *RecurringTask) Equal(rtc RecurringTask) bool {
-	return rt.name == rtc.name && rt.description == rtc.description
+	return rt.name == rtc.name && rt.description == rtc.description && rt.priority == rtc.priority && rt.dueOffset == rtc.dueOffset && rt.hasDueOffset == rtc.hasDueOffset && equalTags(rt.tags, rtc.tags) && equalChecklist(rt.checklist, rtc.checklist) && rt.autoComplete == rtc.autoComplete && equalRotation(rt.rotation, rtc.rotation) && rt.rotationStrategy == rtc.rotationStrategy && rt.hasAction == rtc.hasAction && rt.action.Equal(rtc.action) && rt.hasCommand == rtc.hasCommand && rt.command.Equal(rtc.command)
+}
+
+func equalTags(as []task.Tag, bs []task.Tag) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalChecklist(as []string, bs []string) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
 }
@@ -1,7 +1,12 @@
 package schedule
 
 import (
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
 
 func TestRecurringTask_Equal(t *testing.T) {
@@ -9,6 +14,17 @@ func TestRecurringTask_Equal(t *testing.T) {
 	rt1dupe := NewRecurringTask("task 1", "desc")
 	rt2 := NewRecurringTask("task 2", "desc")
 	rt2b := NewRecurringTask("task 2", "different description")
+	rt2c, _ := rt2.WithPriority(task.PriorityLow)
+	rt2d := rt2.WithTags([]task.Tag{"ops"})
+	rt2e, _ := rt2.WithChecklist([]string{"step 1"}, false)
+	a1, _ := NewHTTPAction("POST", "https://example.com/warm", map[string]string{"X-Token": "a"}, "", 0, 0, false)
+	a2, _ := NewHTTPAction("POST", "https://example.com/warm", map[string]string{"X-Token": "b"}, "", 0, 0, false)
+	rt2f := rt2.WithAction(a1)
+	rt2g := rt2.WithAction(a2)
+	c1, _ := NewCommand("backup", []string{"--all"}, 0)
+	c2, _ := NewCommand("backup", []string{"--incremental"}, 0)
+	rt2h := rt2.WithCommand(c1)
+	rt2i := rt2.WithCommand(c2)
 
 	type args struct {
 		rtc RecurringTask
@@ -43,6 +59,48 @@ func TestRecurringTask_Equal(t *testing.T) {
 			args: args{rtc: rt2b},
 			want: false,
 		},
+		{
+			name: "recurring tasks with different priority should be different",
+			rt:   &rt2,
+			args: args{rtc: rt2c},
+			want: false,
+		},
+		{
+			name: "recurring tasks with different tags should be different",
+			rt:   &rt2,
+			args: args{rtc: rt2d},
+			want: false,
+		},
+		{
+			name: "recurring tasks with different checklists should be different",
+			rt:   &rt2,
+			args: args{rtc: rt2e},
+			want: false,
+		},
+		{
+			name: "recurring tasks with and without an action should be different",
+			rt:   &rt2,
+			args: args{rtc: rt2f},
+			want: false,
+		},
+		{
+			name: "recurring tasks with different action headers should be different",
+			rt:   &rt2f,
+			args: args{rtc: rt2g},
+			want: false,
+		},
+		{
+			name: "recurring tasks with and without a command should be different",
+			rt:   &rt2,
+			args: args{rtc: rt2h},
+			want: false,
+		},
+		{
+			name: "recurring tasks with different command args should be different",
+			rt:   &rt2h,
+			args: args{rtc: rt2i},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -52,3 +110,96 @@ func TestRecurringTask_Equal(t *testing.T) {
 		})
 	}
 }
+
+func TestRecurringTask_NewTask(t *testing.T) {
+	occurrence := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	uid := user.New("test user RecurringTask.NewTask").ID()
+	plain := NewRecurringTask("task", "desc")
+	withDue, _ := plain.WithDueOffset(90 * time.Minute)
+	withDue, _ = withDue.WithTags([]task.Tag{"ops"}).WithPriority(task.PriorityHigh)
+	withDue, _ = withDue.WithChecklist([]string{"step 1", "step 2"}, true)
+
+	tests := []struct {
+		name         string
+		rt           *RecurringTask
+		wantPriority task.Priority
+		wantDue      time.Time
+		wantTags     []task.Tag
+		wantItems    int
+		wantAuto     bool
+	}{
+		{
+			name:         "recurring task without due offset should create task with no due time",
+			rt:           &plain,
+			wantPriority: task.PriorityNone,
+			wantDue:      time.Time{},
+			wantTags:     []task.Tag{},
+		},
+		{
+			name:         "recurring task with due offset should create task due after the occurrence",
+			rt:           &withDue,
+			wantPriority: task.PriorityHigh,
+			wantDue:      occurrence.Add(90 * time.Minute),
+			wantTags:     []task.Tag{"ops"},
+			wantItems:    2,
+			wantAuto:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rt.NewTask(occurrence, uid)
+			if got.Name() != tt.rt.Name() || got.Description() != tt.rt.Description() || got.CreatedBy() != uid {
+				t.Errorf("RecurringTask.NewTask() = %v, want name %v, description %v, createdBy %v", got, tt.rt.Name(), tt.rt.Description(), uid)
+			}
+			if got.Priority() != tt.wantPriority {
+				t.Errorf("RecurringTask.NewTask() priority = %v, want %v", got.Priority(), tt.wantPriority)
+			}
+			if !got.DueTime().Equal(tt.wantDue) {
+				t.Errorf("RecurringTask.NewTask() due time = %v, want %v", got.DueTime(), tt.wantDue)
+			}
+			if !reflect.DeepEqual(got.Tags(), tt.wantTags) {
+				t.Errorf("RecurringTask.NewTask() tags = %v, want %v", got.Tags(), tt.wantTags)
+			}
+			if len(got.Checklist()) != tt.wantItems || got.AutoComplete() != tt.wantAuto {
+				t.Errorf("RecurringTask.NewTask() checklist = %v, autoComplete = %v, want %d items, autoComplete %v", got.Checklist(), got.AutoComplete(), tt.wantItems, tt.wantAuto)
+			}
+			for _, item := range got.Checklist() {
+				if item.IsChecked() {
+					t.Errorf("RecurringTask.NewTask() checklist item %v should not be checked", item.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringTask_WithDueOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "zero offset should be valid",
+			offset:  0,
+			wantErr: false,
+		},
+		{
+			name:    "negative offset should return error",
+			offset:  -time.Minute,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := NewRecurringTask("task", "desc")
+			got, err := rt.WithDueOffset(tt.offset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecurringTask.WithDueOffset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if _, ok := got.DueOffset(); ok == tt.wantErr {
+				t.Errorf("RecurringTask.WithDueOffset() has offset = %v, want %v", ok, !tt.wantErr)
+			}
+		})
+	}
+}
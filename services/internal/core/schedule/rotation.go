@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// RotationStrategy determines which user in a recurring task's rotation is assigned each generated task
+type RotationStrategy uint8
+
+// RotationStrategy constants
+const (
+	RotationRoundRobin RotationStrategy = iota + 1
+	RotationLeastRecent
+)
+
+func (rs RotationStrategy) String() string {
+	switch rs {
+	case RotationRoundRobin:
+		return "round-robin"
+	case RotationLeastRecent:
+		return "least-recent"
+	}
+	return "[Invalid rotation strategy]"
+}
+
+// IsValid returns whether the rotation strategy is one of the defined rotation strategy constants
+func (rs RotationStrategy) IsValid() bool {
+	return rs >= RotationRoundRobin && rs <= RotationLeastRecent
+}
+
+// ParseRotationStrategy parses a rotation strategy from its string representation, an empty string is RotationRoundRobin
+func ParseRotationStrategy(val string) (RotationStrategy, error) {
+	switch strings.ToLower(val) {
+	case "", "round-robin":
+		return RotationRoundRobin, nil
+	case "least-recent":
+		return RotationLeastRecent, nil
+	}
+	return 0, fmt.Errorf("unknown rotation strategy '%v', should be 'round-robin' or 'least-recent'", val)
+}
+
+// Rotation returns the ordered IDs of the users generated tasks are assigned to, empty if generated tasks are unassigned
+func (rt *RecurringTask) Rotation() []user.ID {
+	return append([]user.ID{}, rt.rotation...)
+}
+
+// RotationStrategy returns how users in the rotation are chosen
+func (rt *RecurringTask) RotationStrategy() RotationStrategy {
+	return rt.rotationStrategy
+}
+
+// RotationState returns the sequence number of the last task assigned to each user in the rotation, 0 for users that haven't been assigned one yet
+func (rt *RecurringTask) RotationState() []int64 {
+	return append([]int64{}, rt.rotationState...)
+}
+
+// WithRotation returns a copy of the recurring task whose generated tasks are assigned to the given users in turn
+// Users that were already in the rotation keep their assignment history, an empty rotation leaves generated tasks unassigned
+func (rt RecurringTask) WithRotation(uids []user.ID, strategy RotationStrategy) (RecurringTask, error) {
+	if len(uids) == 0 {
+		rt.rotation = nil
+		rt.rotationStrategy = 0
+		rt.rotationState = nil
+		return rt, nil
+	}
+	if !strategy.IsValid() {
+		return rt, fmt.Errorf("invalid rotation strategy %d", strategy)
+	}
+	state := make([]int64, len(uids))
+	for i, uid := range uids {
+		if uid.IsEmpty() {
+			return rt, errors.New("rotation user ID cannot be empty")
+		}
+		for _, prev := range uids[:i] {
+			if prev.Equals(uid) {
+				return rt, fmt.Errorf("user %v is in the rotation more than once", uid)
+			}
+		}
+		for j, prev := range rt.rotation {
+			if prev.Equals(uid) {
+				state[i] = rt.rotationState[j]
+			}
+		}
+	}
+	rt.rotation = append([]user.ID{}, uids...)
+	rt.rotationStrategy = strategy
+	rt.rotationState = state
+	return rt, nil
+}
+
+// WithRotationState returns a copy of the recurring task with the given assignment history, as returned by RotationState
+func (rt RecurringTask) WithRotationState(state []int64) (RecurringTask, error) {
+	if len(state) != len(rt.rotation) {
+		return rt, fmt.Errorf("rotation state has %d entries, rotation has %d users", len(state), len(rt.rotation))
+	}
+	rt.rotationState = append([]int64{}, state...)
+	return rt, nil
+}
+
+// NextAssignee returns the user the next generated task will be assigned to, false if the recurring task has no rotation
+func (rt *RecurringTask) NextAssignee() (user.ID, bool) {
+	i := rt.nextInRotation()
+	if i < 0 {
+		return user.ID{}, false
+	}
+	return rt.rotation[i], true
+}
+
+// assignNext records a task assignment to the next user in the rotation, returning their ID
+func (rt *RecurringTask) assignNext() (user.ID, bool) {
+	i := rt.nextInRotation()
+	if i < 0 {
+		return user.ID{}, false
+	}
+	var last int64
+	for _, seq := range rt.rotationState {
+		if seq > last {
+			last = seq
+		}
+	}
+	state := append([]int64{}, rt.rotationState...)
+	state[i] = last + 1
+	rt.rotationState = state
+	return rt.rotation[i], true
+}
+
+// nextInRotation returns the index of the next user to assign a task to, -1 if the rotation is empty
+// Round-robin picks the user after the one assigned most recently, least-recent picks the user that has gone longest without an assignment
+func (rt *RecurringTask) nextInRotation() int {
+	if len(rt.rotation) == 0 {
+		return -1
+	}
+	if rt.rotationStrategy == RotationLeastRecent {
+		next := 0
+		for i, seq := range rt.rotationState {
+			if seq < rt.rotationState[next] {
+				next = i
+			}
+		}
+		return next
+	}
+	last := -1
+	for i, seq := range rt.rotationState {
+		if seq > 0 && (last < 0 || seq > rt.rotationState[last]) {
+			last = i
+		}
+	}
+	return (last + 1) % len(rt.rotation)
+}
+
+func equalRotation(as []user.ID, bs []user.ID) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if !as[i].Equals(bs[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestParseRotationStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    RotationStrategy
+		wantErr bool
+	}{
+		{name: "empty string should be round-robin", val: "", want: RotationRoundRobin},
+		{name: "should parse round-robin", val: "round-robin", want: RotationRoundRobin},
+		{name: "should parse least-recent", val: "least-recent", want: RotationLeastRecent},
+		{name: "should return error for unknown strategy", val: "random", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRotationStrategy(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRotationStrategy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRotationStrategy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringTask_WithRotation(t *testing.T) {
+	u1, u2 := user.NewID(), user.NewID()
+
+	type args struct {
+		uids     []user.ID
+		strategy RotationStrategy
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "should set rotation",
+			args:    args{uids: []user.ID{u1, u2}, strategy: RotationRoundRobin},
+			wantErr: false,
+		},
+		{
+			name:    "should clear rotation with no users",
+			args:    args{uids: nil, strategy: 0},
+			wantErr: false,
+		},
+		{
+			name:    "should return error for duplicate users",
+			args:    args{uids: []user.ID{u1, u2, u1}, strategy: RotationRoundRobin},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for empty user ID",
+			args:    args{uids: []user.ID{u1, {}}, strategy: RotationRoundRobin},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for invalid strategy",
+			args:    args{uids: []user.ID{u1}, strategy: RotationStrategy(99)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRecurringTask("rt", "").WithRotation(tt.args.uids, tt.args.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecurringTask.WithRotation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !equalRotation(got.Rotation(), tt.args.uids) {
+				t.Errorf("RecurringTask.WithRotation() rotation = %v, want %v", got.Rotation(), tt.args.uids)
+			}
+		})
+	}
+}
+
+func TestSchedule_NewTask(t *testing.T) {
+	u1, u2, u3 := user.NewID(), user.NewID(), user.NewID()
+	occurrence := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Assign 3 tasks from a rotation of u1 and u2 (u1, u2, u1), then add u3 to the end of the rotation
+	f, _ := NewHourFrequency([]int{0})
+	newSchedule := func(strategy RotationStrategy) *Schedule {
+		s := New(f, u1)
+		rt, _ := NewRecurringTask("rt", "").WithRotation([]user.ID{u1, u2}, strategy)
+		s.AddTask(rt)
+		for i := 0; i < 3; i++ {
+			s.NewTask(0, occurrence)
+		}
+		s.tasks[0], _ = s.tasks[0].WithRotation([]user.ID{u1, u2, u3}, strategy)
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		s        *Schedule
+		wantNext []user.ID
+	}{
+		{
+			name:     "round-robin should continue after the most recently assigned user",
+			s:        newSchedule(RotationRoundRobin),
+			wantNext: []user.ID{u2, u3, u1, u2},
+		},
+		{
+			name:     "least-recent should assign new users first",
+			s:        newSchedule(RotationLeastRecent),
+			wantNext: []user.ID{u3, u2, u1, u3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.wantNext {
+				got, err := tt.s.NewTask(0, occurrence)
+				if err != nil {
+					t.Errorf("Schedule.NewTask() error = %v", err)
+					return
+				}
+				if !got.Assignee().Equals(want) {
+					t.Errorf("Schedule.NewTask() #%d assignee = %v, want %v", i, got.Assignee(), want)
+				}
+			}
+		})
+	}
+
+	if _, err := New(f, u1).NewTask(0, occurrence); err == nil {
+		t.Errorf("Schedule.NewTask() should return error for unknown recurring task index")
+	}
+}
@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// MaxRunErrorLength is the maximum length of a run's recorded error, in characters, longer errors are truncated
+const MaxRunErrorLength = 1000
+
+// RunType is what a run records, a check of the whole schedule or one of the occurrences found by a check
+type RunType uint8
+
+// Run types
+const (
+	RunTypeCheck RunType = iota
+	RunTypeOccurrence
+)
+
+func (rt RunType) String() string {
+	switch rt {
+	case RunTypeCheck:
+		return "check"
+	case RunTypeOccurrence:
+		return "occurrence"
+	}
+	return "unknown"
+}
+
+// Run records what the scheduler did when it checked a schedule, or processed one of its occurrences
+// a check's scheduled time is the time it checked for occurrences up to, an occurrence's is the time it recurred at
+type Run struct {
+	runType       RunType
+	scheduledTime time.Time
+	processedTime time.Time
+	tasksCreated  int
+	err           string
+}
+
+// NewCheckRun instantiates a run recording a check of a schedule for occurrences up to the given time
+func NewCheckRun(checked time.Time, tasksCreated int, err error) *Run {
+	return newRun(RunTypeCheck, checked, tasksCreated, err)
+}
+
+// NewOccurrenceRun instantiates a run recording the tasks created for an occurrence of a schedule
+func NewOccurrenceRun(occurrence time.Time, tasksCreated int, err error) *Run {
+	return newRun(RunTypeOccurrence, occurrence, tasksCreated, err)
+}
+
+func newRun(runType RunType, scheduled time.Time, tasksCreated int, err error) *Run {
+	r := &Run{
+		runType:       runType,
+		scheduledTime: scheduled,
+		processedTime: clock.Now(),
+		tasksCreated:  tasksCreated,
+	}
+	if err != nil {
+		r.err = truncateRunError(err.Error())
+	}
+	return r
+}
+
+// NewRawRun instantiates a run entity with all available fields
+func NewRawRun(runType RunType, scheduled time.Time, processed time.Time, tasksCreated int, err string) *Run {
+	return &Run{
+		runType:       runType,
+		scheduledTime: scheduled,
+		processedTime: processed,
+		tasksCreated:  tasksCreated,
+		err:           err,
+	}
+}
+
+func truncateRunError(s string) string {
+	if utf8.RuneCountInString(s) <= MaxRunErrorLength {
+		return s
+	}
+	return string([]rune(s)[:MaxRunErrorLength])
+}
+
+// Type returns whether the run records a check or an occurrence
+func (r *Run) Type() RunType {
+	return r.runType
+}
+
+// ScheduledTime returns the time the run was scheduled for
+func (r *Run) ScheduledTime() time.Time {
+	return r.scheduledTime
+}
+
+// ProcessedTime returns the time the scheduler finished processing the run
+func (r *Run) ProcessedTime() time.Time {
+	return r.processedTime
+}
+
+// Lag returns how long after its scheduled time the run was processed
+func (r *Run) Lag() time.Duration {
+	return r.processedTime.Sub(r.scheduledTime)
+}
+
+// TasksCreated returns the number of tasks created by the run
+func (r *Run) TasksCreated() int {
+	return r.tasksCreated
+}
+
+// Err returns the reason the run failed, empty if it succeeded
+func (r *Run) Err() string {
+	return r.err
+}
+
+// Failed returns whether the run failed
+func (r *Run) Failed() bool {
+	return r.err != ""
+}
@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+func TestNewOccurrenceRun(t *testing.T) {
+	occurrence := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(occurrence.Add(3 * time.Second)))
+
+	tests := []struct {
+		name       string
+		err        error
+		wantErr    string
+		wantFailed bool
+	}{
+		{name: "run without an error should succeed"},
+		{name: "run with an error should fail", err: errors.New("error adding task"), wantErr: "error adding task", wantFailed: true},
+		{name: "long errors should be truncated", err: errors.New(strings.Repeat("x", MaxRunErrorLength+1)), wantErr: strings.Repeat("x", MaxRunErrorLength), wantFailed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewOccurrenceRun(occurrence, 2, tt.err)
+			if r.Type() != RunTypeOccurrence || !r.ScheduledTime().Equal(occurrence) || r.TasksCreated() != 2 {
+				t.Errorf("NewOccurrenceRun() = %v %v %v, want %v %v 2", r.Type(), r.ScheduledTime(), r.TasksCreated(), RunTypeOccurrence, occurrence)
+			}
+			if r.Lag() != 3*time.Second {
+				t.Errorf("Lag() = %v, want the time between the occurrence and processing it", r.Lag())
+			}
+			if r.Err() != tt.wantErr || r.Failed() != tt.wantFailed {
+				t.Errorf("Err() = %v, Failed() = %v, want %v, %v", r.Err(), r.Failed(), tt.wantErr, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -2,10 +2,16 @@ package schedule
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
 )
 
 // Schedule represents a collection of tasks that recur at some frequency
@@ -16,21 +22,40 @@ type Schedule struct {
 	tasks       []RecurringTask
 	removedTime time.Time
 	createdBy   user.ID
+	workspace   workspace.ID
+	chatURL     string
+	events      []*event.Event
 }
 
+// MaxChatURLLength is the maximum length of a schedule's chat webhook URL, in characters
+const MaxChatURLLength = 2000
+
 // New instantiates a new schedule entity
 func New(f Frequency, createdBy user.ID) *Schedule {
-	return &Schedule{frequency: f, paused: false, tasks: []RecurringTask{}, createdBy: createdBy}
+	return &Schedule{frequency: f, paused: false, tasks: []RecurringTask{}, createdBy: createdBy, events: []*event.Event{event.New(event.ScheduleCreated)}}
 }
 
 // NewRaw creates a new schedule entity from raw data
 func NewRaw(frequency Frequency, paused bool, lastChecked time.Time, tasks []RecurringTask, removedTime time.Time, createdBy user.ID) *Schedule {
-	return &Schedule{frequency, paused, lastChecked, tasks, removedTime, createdBy}
+	return &Schedule{frequency: frequency, paused: paused, lastChecked: lastChecked, tasks: tasks, removedTime: removedTime, createdBy: createdBy}
+}
+
+// Events returns the events emitted by changes to the schedule since it was created or loaded, which haven't been persisted yet
+func (s *Schedule) Events() []*event.Event {
+	return s.events
+}
+
+// ClearEvents discards the schedule's emitted events, once they've been persisted
+func (s *Schedule) ClearEvents() {
+	s.events = nil
 }
 
 // Pause pauses a schedule
 func (s *Schedule) Pause() {
-	s.paused = true
+	if !s.paused {
+		s.paused = true
+		s.events = append(s.events, event.New(event.SchedulePaused))
+	}
 }
 
 // Unpause unpauses a schedule
@@ -38,6 +63,7 @@ func (s *Schedule) Unpause() {
 	if s.paused {
 		s.paused = false
 		s.Check(clock.Now())
+		s.events = append(s.events, event.New(event.ScheduleUnpaused))
 	}
 }
 
@@ -61,6 +87,68 @@ func (s *Schedule) CreatedBy() user.ID {
 	return s.createdBy
 }
 
+// Workspace returns the ID of the workspace the schedule is shared with, empty if the schedule is only visible to its creator
+func (s *Schedule) Workspace() workspace.ID {
+	return s.workspace
+}
+
+// SetWorkspace shares the schedule and the tasks it creates with all members of a workspace, an empty ID makes them only visible to its creator
+func (s *Schedule) SetWorkspace(id workspace.ID) {
+	s.workspace = id
+}
+
+// ChatURL returns the incoming webhook URL of the chat channel the schedule's generated and overdue tasks are posted to, empty if they aren't posted
+func (s *Schedule) ChatURL() string {
+	return s.chatURL
+}
+
+// SetChatURL sets the Slack or Mattermost compatible incoming webhook URL the schedule's tasks are posted to, an empty URL stops posting them
+func (s *Schedule) SetChatURL(rawURL string) error {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		s.chatURL = ""
+		return nil
+	}
+	if l := utf8.RuneCountInString(rawURL); l > MaxChatURLLength {
+		return fmt.Errorf("chat URL is %d characters, cannot be longer than %d", l, MaxChatURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid chat URL: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("chat URL '%v' must be an absolute http or https URL", rawURL)
+	}
+	s.chatURL = rawURL
+	return nil
+}
+
+// NewTask creates a task for an occurrence of the recurring task at the given index, shared with the schedule's workspace
+// and assigned to the next user in the recurring task's rotation
+func (s *Schedule) NewTask(index int, occurrence time.Time) (*task.Task, error) {
+	if index < 0 || index >= len(s.tasks) {
+		return nil, fmt.Errorf("no recurring task at index %d", index)
+	}
+	rt := &s.tasks[index]
+	t := rt.NewTask(occurrence, s.createdBy)
+	t.SetWorkspace(s.workspace)
+	if assignee, ok := rt.assignNext(); ok {
+		t.SetAssignee(assignee)
+	}
+	if a, ok := rt.Action(); ok {
+		c, err := a.NewCall(occurrence, t)
+		if err != nil {
+			return nil, err
+		}
+		t.SetAction(c)
+	}
+	if c, ok := rt.Command(); ok {
+		t.SetCommand(c.NewRun())
+	}
+	t.MarkGenerated()
+	return t, nil
+}
+
 // Check sets the lastChecked time
 func (s *Schedule) Check(time time.Time) error {
 	if time.After(s.LastChecked()) {
@@ -108,6 +196,38 @@ func (s *Schedule) RemoveTask(rt RecurringTask) error {
 	return nil
 }
 
+// HasTaskWithTags returns whether any recurring task in the schedule has all of the given tags
+func (s *Schedule) HasTaskWithTags(tags ...task.Tag) bool {
+	for _, rt := range s.tasks {
+		if rt.HasTags(tags...) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceTag replaces a tag on all recurring tasks that have it, returning the number of recurring tasks changed; an empty replacement removes the tag
+func (s *Schedule) ReplaceTag(from task.Tag, to task.Tag) int {
+	count := 0
+	for i, rt := range s.tasks {
+		if !rt.HasTags(from) {
+			continue
+		}
+		tags := []task.Tag{}
+		for _, tag := range rt.tags {
+			if tag != from {
+				tags = append(tags, tag)
+			}
+		}
+		if to != "" {
+			tags = append(tags, to)
+		}
+		s.tasks[i] = rt.WithTags(tags)
+		count++
+	}
+	return count
+}
+
 // Times gets a list of scheduled times between the start and end times
 func (s *Schedule) Times(start time.Time, end time.Time) ([]time.Time, error) {
 	return s.frequency.times(start, end)
@@ -122,6 +242,7 @@ func (s *Schedule) NextTime(after time.Time) (time.Time, error) {
 func (s *Schedule) Remove() error {
 	if s.removedTime.IsZero() {
 		s.removedTime = clock.Now()
+		s.events = append(s.events, event.New(event.ScheduleRemoved))
 	}
 	return nil
 }
@@ -2,9 +2,12 @@ package schedule
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
 
@@ -849,3 +852,152 @@ func TestSchedule_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestSchedule_SetChatURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{name: "https URL should be set", url: " https://hooks.slack.com/services/T0/B0/x ", want: "https://hooks.slack.com/services/T0/B0/x"},
+		{name: "http URL should be set", url: "http://localhost:8065/hooks/abc", want: "http://localhost:8065/hooks/abc"},
+		{name: "empty URL should clear it", url: "", want: ""},
+		{name: "relative URL should fail", url: "/hooks/abc", wantErr: true},
+		{name: "non-http URL should fail", url: "ftp://example.com/hook", wantErr: true},
+		{name: "URL that's too long should fail", url: "https://example.com/" + strings.Repeat("a", MaxChatURLLength), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Schedule{chatURL: "https://example.com/previous"}
+			err := s.SetChatURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Schedule.SetChatURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && s.ChatURL() != tt.want {
+				t.Errorf("Schedule.ChatURL() = %v, want %v", s.ChatURL(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Events(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      *Schedule
+		change func(s *Schedule)
+		want   []event.Type
+	}{
+		{
+			name:   "new schedule should emit a created event",
+			s:      New(Frequency{}, user.ID{}),
+			change: func(s *Schedule) {},
+			want:   []event.Type{event.ScheduleCreated},
+		},
+		{
+			name:   "pausing a schedule should emit a paused event",
+			s:      &Schedule{},
+			change: func(s *Schedule) { s.Pause() },
+			want:   []event.Type{event.SchedulePaused},
+		},
+		{
+			name:   "pausing a paused schedule should not emit an event",
+			s:      &Schedule{paused: true},
+			change: func(s *Schedule) { s.Pause() },
+			want:   []event.Type{},
+		},
+		{
+			name:   "unpausing a paused schedule should emit an unpaused event",
+			s:      &Schedule{paused: true},
+			change: func(s *Schedule) { s.Unpause() },
+			want:   []event.Type{event.ScheduleUnpaused},
+		},
+		{
+			name:   "removing a schedule should emit a removed event",
+			s:      &Schedule{},
+			change: func(s *Schedule) { s.Remove() },
+			want:   []event.Type{event.ScheduleRemoved},
+		},
+		{
+			name:   "removing a removed schedule should not emit an event",
+			s:      &Schedule{removedTime: time.Now()},
+			change: func(s *Schedule) { s.Remove() },
+			want:   []event.Type{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change(tt.s)
+			got := []event.Type{}
+			for _, e := range tt.s.Events() {
+				got = append(got, e.Type())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schedule.Events() = %v, want %v", got, tt.want)
+			}
+			tt.s.ClearEvents()
+			if len(tt.s.Events()) != 0 {
+				t.Errorf("Schedule.ClearEvents() left %v events", len(tt.s.Events()))
+			}
+		})
+	}
+}
+
+func TestSchedule_ReplaceTag(t *testing.T) {
+	f, _ := NewHourFrequency([]int{0})
+	newSchedule := func() *Schedule {
+		s := New(f, user.ID{})
+		s.AddTask(NewRecurringTask("task 1", "").WithTags([]task.Tag{"ops"}))
+		s.AddTask(NewRecurringTask("task 2", "").WithTags([]task.Tag{"billing", "ops"}))
+		s.AddTask(NewRecurringTask("task 3", ""))
+		return s
+	}
+
+	type args struct {
+		from task.Tag
+		to   task.Tag
+	}
+	tests := []struct {
+		name     string
+		s        *Schedule
+		args     args
+		want     int
+		wantTags [][]task.Tag
+	}{
+		{
+			name:     "should rename tag on 2 recurring tasks",
+			s:        newSchedule(),
+			args:     args{from: "ops", to: "infra"},
+			want:     2,
+			wantTags: [][]task.Tag{{"infra"}, {"billing", "infra"}, {}},
+		},
+		{
+			name:     "should remove tag from 2 recurring tasks",
+			s:        newSchedule(),
+			args:     args{from: "ops", to: ""},
+			want:     2,
+			wantTags: [][]task.Tag{{}, {"billing"}, {}},
+		},
+		{
+			name:     "should not change recurring tasks without the tag",
+			s:        newSchedule(),
+			args:     args{from: "unknown", to: "infra"},
+			want:     0,
+			wantTags: [][]task.Tag{{"ops"}, {"billing", "ops"}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.ReplaceTag(tt.args.from, tt.args.to); got != tt.want {
+				t.Errorf("Schedule.ReplaceTag() = %v, want %v", got, tt.want)
+			}
+			gotTags := [][]task.Tag{}
+			for _, rt := range tt.s.Tasks() {
+				gotTags = append(gotTags, rt.Tags())
+			}
+			if !reflect.DeepEqual(gotTags, tt.wantTags) {
+				t.Errorf("Schedule.ReplaceTag() tags = %v, want %v", gotTags, tt.wantTags)
+			}
+		})
+	}
+}
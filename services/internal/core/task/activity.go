@@ -0,0 +1,118 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// MaxCommentLength is the maximum length of a task comment, in characters
+const MaxCommentLength = 2000
+
+// ActivityType is the kind of change recorded in a task's activity history
+type ActivityType uint8
+
+// ActivityType constants
+const (
+	ActivityCreated ActivityType = iota + 1
+	ActivityEdited
+	ActivityCompleted
+	ActivityUncompleted
+	ActivityCleared
+	ActivityComment
+)
+
+func (at ActivityType) String() string {
+	switch at {
+	case ActivityCreated:
+		return "created"
+	case ActivityEdited:
+		return "edited"
+	case ActivityCompleted:
+		return "completed"
+	case ActivityUncompleted:
+		return "uncompleted"
+	case ActivityCleared:
+		return "cleared"
+	case ActivityComment:
+		return "comment"
+	}
+	return "[Invalid activity type]"
+}
+
+// IsValid returns whether the activity type is one of the defined activity type constants
+func (at ActivityType) IsValid() bool {
+	return at >= ActivityCreated && at <= ActivityComment
+}
+
+// Activity is a single append-only entry in a task's activity history
+type Activity struct {
+	activityType ActivityType
+	comment      string
+	createdTime  time.Time
+	createdBy    user.ID
+}
+
+// NewActivity instantiates a new activity entry recording a change to a task
+func NewActivity(activityType ActivityType, createdBy user.ID) (*Activity, error) {
+	if !activityType.IsValid() || activityType == ActivityComment {
+		return nil, fmt.Errorf("invalid activity type %d", activityType)
+	}
+	return &Activity{
+		activityType: activityType,
+		createdTime:  clock.Now(),
+		createdBy:    createdBy,
+	}, nil
+}
+
+// NewComment instantiates a new activity entry containing a user comment
+func NewComment(comment string, createdBy user.ID) (*Activity, error) {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return nil, errors.New("comment cannot be empty")
+	}
+	if l := utf8.RuneCountInString(comment); l > MaxCommentLength {
+		return nil, fmt.Errorf("comment is %d characters, cannot be longer than %d", l, MaxCommentLength)
+	}
+	return &Activity{
+		activityType: ActivityComment,
+		comment:      comment,
+		createdTime:  clock.Now(),
+		createdBy:    createdBy,
+	}, nil
+}
+
+// NewRawActivity instantiates an activity entry with all available fields
+func NewRawActivity(activityType ActivityType, comment string, created time.Time, createdBy user.ID) *Activity {
+	return &Activity{
+		activityType: activityType,
+		comment:      comment,
+		createdTime:  created,
+		createdBy:    createdBy,
+	}
+}
+
+// Type returns the kind of change the activity records
+func (a *Activity) Type() ActivityType {
+	return a.activityType
+}
+
+// Comment returns the activity comment, empty unless the activity is a comment
+func (a *Activity) Comment() string {
+	return a.comment
+}
+
+// CreatedTime returns the time the activity occurred
+func (a *Activity) CreatedTime() time.Time {
+	return a.createdTime
+}
+
+// CreatedBy returns the ID of the user that performed the activity
+func (a *Activity) CreatedBy() user.ID {
+	return a.createdBy
+}
@@ -0,0 +1,82 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNewActivity(t *testing.T) {
+	tests := []struct {
+		name         string
+		activityType ActivityType
+		wantErr      bool
+	}{
+		{
+			name:         "completed activity should be created",
+			activityType: ActivityCompleted,
+			wantErr:      false,
+		},
+		{
+			name:         "comment activity should return error",
+			activityType: ActivityComment,
+			wantErr:      true,
+		},
+		{
+			name:         "unknown activity type should return error",
+			activityType: ActivityType(0),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewActivity(tt.activityType, user.NewID())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewActivity() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.Type() != tt.activityType {
+				t.Errorf("NewActivity() type = %v, want %v", got.Type(), tt.activityType)
+			}
+		})
+	}
+}
+
+func TestNewComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "comment should be trimmed",
+			comment: "  left a note \n",
+			want:    "left a note",
+			wantErr: false,
+		},
+		{
+			name:    "empty comment should return error",
+			comment: " ",
+			wantErr: true,
+		},
+		{
+			name:    "comment that is too long should return error",
+			comment: strings.Repeat("c", MaxCommentLength+1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewComment(tt.comment, user.NewID())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewComment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Comment() != tt.want || got.Type() != ActivityComment) {
+				t.Errorf("NewComment() = %v, %v, want %v, %v", got.Type(), got.Comment(), ActivityComment, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// Checklist limits
+const (
+	MaxChecklistItems          = 50
+	MaxChecklistItemNameLength = 200
+)
+
+// ChecklistItem is a single step of a task's checklist
+type ChecklistItem struct {
+	name        string
+	checkedTime time.Time
+}
+
+// NewChecklistItem instantiates a new unchecked checklist item
+func NewChecklistItem(name string) (ChecklistItem, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ChecklistItem{}, errors.New("checklist item name cannot be empty")
+	}
+	if l := utf8.RuneCountInString(name); l > MaxChecklistItemNameLength {
+		return ChecklistItem{}, fmt.Errorf("checklist item name is %d characters, cannot be longer than %d", l, MaxChecklistItemNameLength)
+	}
+	return ChecklistItem{name: name}, nil
+}
+
+// NewRawChecklistItem instantiates a checklist item with all available fields
+func NewRawChecklistItem(name string, checked time.Time) ChecklistItem {
+	return ChecklistItem{name: name, checkedTime: checked}
+}
+
+// ParseChecklist creates a list of unchecked checklist items from their names
+func ParseChecklist(names []string) ([]ChecklistItem, error) {
+	if len(names) > MaxChecklistItems {
+		return nil, fmt.Errorf("checklist has %d items, cannot have more than %d", len(names), MaxChecklistItems)
+	}
+	items := make([]ChecklistItem, len(names))
+	for i, name := range names {
+		item, err := NewChecklistItem(name)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// Name returns the checklist item name
+func (ci ChecklistItem) Name() string {
+	return ci.name
+}
+
+// CheckedTime returns the time the item was checked, zero value if not checked
+func (ci ChecklistItem) CheckedTime() time.Time {
+	return ci.checkedTime
+}
+
+// IsChecked returns whether the item has been checked
+func (ci ChecklistItem) IsChecked() bool {
+	return !ci.checkedTime.IsZero()
+}
+
+// Checklist returns the task's ordered checklist items
+func (t *Task) Checklist() []ChecklistItem {
+	return append([]ChecklistItem{}, t.checklist...)
+}
+
+// SetChecklist replaces the task's checklist items
+func (t *Task) SetChecklist(items []ChecklistItem) error {
+	if len(items) > MaxChecklistItems {
+		return fmt.Errorf("checklist has %d items, cannot have more than %d", len(items), MaxChecklistItems)
+	}
+	if len(items) == 0 {
+		t.checklist = nil
+		return nil
+	}
+	t.checklist = append([]ChecklistItem{}, items...)
+	return nil
+}
+
+// AutoComplete returns whether the task is completed automatically when all of its checklist items are checked
+func (t *Task) AutoComplete() bool {
+	return t.autoComplete
+}
+
+// SetAutoComplete sets whether the task is completed automatically when all of its checklist items are checked
+func (t *Task) SetAutoComplete(autoComplete bool) {
+	t.autoComplete = autoComplete
+}
+
+// AllItemsChecked returns whether the task has checklist items and all of them are checked
+func (t *Task) AllItemsChecked() bool {
+	if len(t.checklist) == 0 {
+		return false
+	}
+	for _, item := range t.checklist {
+		if !item.IsChecked() {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckItem checks the checklist item at the given index, completing the task if auto-complete is set and all items are checked
+// Returns false if the item was already checked
+func (t *Task) CheckItem(index int) (bool, error) {
+	if !t.IsValid() {
+		return false, errors.New("Task is invalid, cannot check checklist item")
+	}
+	if index < 0 || index >= len(t.checklist) {
+		return false, fmt.Errorf("checklist item %d not found", index)
+	}
+	if t.checklist[index].IsChecked() {
+		return false, nil
+	}
+	t.checklist[index].checkedTime = clock.Now()
+	if t.autoComplete && t.AllItemsChecked() {
+		if _, err := t.CompleteNow(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// UncheckItem unchecks the checklist item at the given index, reopening the task if auto-complete is set and it was completed
+// Returns false if the item was not checked
+func (t *Task) UncheckItem(index int) (bool, error) {
+	if !t.IsValid() {
+		return false, errors.New("Task is invalid, cannot uncheck checklist item")
+	}
+	if index < 0 || index >= len(t.checklist) {
+		return false, fmt.Errorf("checklist item %d not found", index)
+	}
+	if !t.checklist[index].IsChecked() {
+		return false, nil
+	}
+	t.checklist[index].checkedTime = time.Time{}
+	if t.autoComplete {
+		if _, err := t.Uncomplete(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
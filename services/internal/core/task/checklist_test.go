@@ -0,0 +1,190 @@
+package task
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChecklist(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "valid item names should return unchecked items",
+			names:   []string{"tag", "build", "publish"},
+			wantLen: 3,
+			wantErr: false,
+		},
+		{
+			name:    "empty item name should return error",
+			names:   []string{"tag", " "},
+			wantErr: true,
+		},
+		{
+			name:    "item name that is too long should return error",
+			names:   []string{strings.Repeat("i", MaxChecklistItemNameLength+1)},
+			wantErr: true,
+		},
+		{
+			name:    "too many items should return error",
+			names:   make([]string, MaxChecklistItems+1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecklist(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseChecklist() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ParseChecklist() = %v, want %d items", got, tt.wantLen)
+			}
+			for _, item := range got {
+				if item.IsChecked() {
+					t.Errorf("ParseChecklist() item %v should not be checked", item)
+				}
+			}
+		})
+	}
+}
+
+func TestTask_CheckItem(t *testing.T) {
+	newTask := func(autoComplete bool, checked ...bool) *Task {
+		tk := &Task{autoComplete: autoComplete}
+		for _, c := range checked {
+			item := ChecklistItem{name: "item"}
+			if c {
+				item.checkedTime = time.Now()
+			}
+			tk.checklist = append(tk.checklist, item)
+		}
+		return tk
+	}
+
+	tests := []struct {
+		name          string
+		t             *Task
+		index         int
+		want          bool
+		wantErr       bool
+		wantCompleted bool
+	}{
+		{
+			name:          "unchecked item should be checked",
+			t:             newTask(false, false, false),
+			index:         0,
+			want:          true,
+			wantErr:       false,
+			wantCompleted: false,
+		},
+		{
+			name:          "checked item should not be checked again",
+			t:             newTask(false, true, false),
+			index:         0,
+			want:          false,
+			wantErr:       false,
+			wantCompleted: false,
+		},
+		{
+			name:          "unknown item should return error",
+			t:             newTask(false, false),
+			index:         1,
+			want:          false,
+			wantErr:       true,
+			wantCompleted: false,
+		},
+		{
+			name:          "checking last item should complete auto-complete task",
+			t:             newTask(true, true, false),
+			index:         1,
+			want:          true,
+			wantErr:       false,
+			wantCompleted: true,
+		},
+		{
+			name:          "checking last item should not complete task without auto-complete",
+			t:             newTask(false, true, false),
+			index:         1,
+			want:          true,
+			wantErr:       false,
+			wantCompleted: false,
+		},
+		{
+			name:          "cleared task should return error",
+			t:             &Task{clearedTime: time.Now(), checklist: []ChecklistItem{{name: "item"}}},
+			index:         0,
+			want:          false,
+			wantErr:       true,
+			wantCompleted: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.t.CheckItem(tt.index)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Task.CheckItem() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Task.CheckItem() = %v, want %v", got, tt.want)
+			}
+			if completed := !tt.t.CompletedTime().IsZero(); completed != tt.wantCompleted {
+				t.Errorf("Task.CheckItem() completed = %v, want %v", completed, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestTask_UncheckItem(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		t             *Task
+		index         int
+		want          bool
+		wantCompleted bool
+	}{
+		{
+			name:          "unchecking item should reopen completed auto-complete task",
+			t:             &Task{autoComplete: true, completedTime: now, checklist: []ChecklistItem{{name: "item", checkedTime: now}}},
+			index:         0,
+			want:          true,
+			wantCompleted: false,
+		},
+		{
+			name:          "unchecking item should not reopen completed task without auto-complete",
+			t:             &Task{completedTime: now, checklist: []ChecklistItem{{name: "item", checkedTime: now}}},
+			index:         0,
+			want:          true,
+			wantCompleted: true,
+		},
+		{
+			name:          "unchecked item should not be unchecked again",
+			t:             &Task{checklist: []ChecklistItem{{name: "item"}}},
+			index:         0,
+			want:          false,
+			wantCompleted: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.t.UncheckItem(tt.index)
+			if err != nil {
+				t.Errorf("Task.UncheckItem() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Task.UncheckItem() = %v, want %v", got, tt.want)
+			}
+			if completed := !tt.t.CompletedTime().IsZero(); completed != tt.wantCompleted {
+				t.Errorf("Task.UncheckItem() completed = %v, want %v", completed, tt.wantCompleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority indicates how important a task is relative to other tasks
+type Priority uint8
+
+// Priority constants, ordered from least to most important
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityNone:
+		return "none"
+	case PriorityLow:
+		return "low"
+	case PriorityMedium:
+		return "medium"
+	case PriorityHigh:
+		return "high"
+	}
+	return "[Invalid priority]"
+}
+
+// IsValid returns whether the priority is one of the defined priority constants
+func (p Priority) IsValid() bool {
+	return p <= PriorityHigh
+}
+
+// ParsePriority parses a priority from its string representation, an empty string is PriorityNone
+func ParsePriority(val string) (Priority, error) {
+	switch strings.ToLower(val) {
+	case "", "none":
+		return PriorityNone, nil
+	case "low":
+		return PriorityLow, nil
+	case "medium":
+		return PriorityMedium, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNone, fmt.Errorf("unknown priority '%v', should be 'none', 'low', 'medium', or 'high'", val)
+}
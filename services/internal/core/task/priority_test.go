@@ -0,0 +1,43 @@
+package task
+
+import "testing"
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    Priority
+		wantErr bool
+	}{
+		{
+			name:    "empty string should be no priority",
+			val:     "",
+			want:    PriorityNone,
+			wantErr: false,
+		},
+		{
+			name:    "priority names should be case-insensitive",
+			val:     "High",
+			want:    PriorityHigh,
+			wantErr: false,
+		},
+		{
+			name:    "unknown priority should return error",
+			val:     "urgent",
+			want:    PriorityNone,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePriority(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePriority() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParsePriority() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
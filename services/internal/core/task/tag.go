@@ -0,0 +1,130 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the maximum length of a tag, in characters
+const MaxTagLength = 50
+
+// Tag is a normalized (lowercase) label used to organize tasks
+type Tag string
+
+// NewTag normalizes and validates a tag name
+func NewTag(name string) (Tag, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if n == "" {
+		return "", fmt.Errorf("tag cannot be empty")
+	}
+	if l := utf8.RuneCountInString(n); l > MaxTagLength {
+		return "", fmt.Errorf("tag is %d characters, cannot be longer than %d", l, MaxTagLength)
+	}
+	for _, r := range n {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.:", r) {
+			return "", fmt.Errorf("tag '%v' can only contain letters, digits, '-', '_', '.', or ':'", name)
+		}
+	}
+	return Tag(n), nil
+}
+
+// ParseTags normalizes and validates a list of tag names, returning them sorted and without duplicates
+func ParseTags(names []string) ([]Tag, error) {
+	tags := make([]Tag, 0, len(names))
+	for _, name := range names {
+		tag, err := NewTag(name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return NormalizeTags(tags), nil
+}
+
+// NormalizeTags returns a sorted copy of the tags without duplicates, or nil if there are no tags
+func NormalizeTags(tags []Tag) []Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	set := make(map[Tag]bool, len(tags))
+	norm := make([]Tag, 0, len(tags))
+	for _, tag := range tags {
+		if set[tag] {
+			continue
+		}
+		set[tag] = true
+		norm = append(norm, tag)
+	}
+	sort.Slice(norm, func(i, j int) bool { return norm[i] < norm[j] })
+	return norm
+}
+
+// HasTags returns whether the list of tags contains all of the given tags
+func HasTags(tags []Tag, want []Tag) bool {
+	for _, w := range want {
+		found := false
+		for _, tag := range tags {
+			if tag == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Tags returns the task's tags, sorted
+func (t *Task) Tags() []Tag {
+	return append([]Tag{}, t.tags...)
+}
+
+// SetTags replaces all of the task's tags
+func (t *Task) SetTags(tags []Tag) {
+	t.tags = NormalizeTags(tags)
+}
+
+// HasTags returns whether the task has all of the given tags
+func (t *Task) HasTags(tags ...Tag) bool {
+	return HasTags(t.tags, tags)
+}
+
+// AddTag adds a tag to the task, returning false if the task already has the tag
+func (t *Task) AddTag(tag Tag) bool {
+	if t.HasTags(tag) {
+		return false
+	}
+	t.SetTags(append(t.Tags(), tag))
+	return true
+}
+
+// RemoveTag removes a tag from the task, returning false if the task didn't have the tag
+func (t *Task) RemoveTag(tag Tag) bool {
+	var tags []Tag
+	for _, existing := range t.tags {
+		if existing != tag {
+			tags = append(tags, existing)
+		}
+	}
+	if len(tags) == len(t.tags) {
+		return false
+	}
+	t.tags = tags
+	return true
+}
+
+// ReplaceTag replaces a tag on the task, returning false if the task didn't have the tag; an empty replacement removes the tag
+func (t *Task) ReplaceTag(from Tag, to Tag) bool {
+	if !t.RemoveTag(from) {
+		return false
+	}
+	if to != "" {
+		t.AddTag(to)
+	}
+	return true
+}
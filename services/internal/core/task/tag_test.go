@@ -0,0 +1,174 @@
+package task
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    Tag
+		wantErr bool
+	}{
+		{
+			name:    "tag should be trimmed and lowercased",
+			val:     " On-Call ",
+			want:    "on-call",
+			wantErr: false,
+		},
+		{
+			name:    "empty tag should return error",
+			val:     "  ",
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "tag containing whitespace should return error",
+			val:     "on call",
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "tag that is too long should return error",
+			val:     strings.Repeat("t", MaxTagLength+1),
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTag(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NewTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		vals    []string
+		want    []Tag
+		wantErr bool
+	}{
+		{
+			name:    "no tags should return nil",
+			vals:    []string{},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "tags should be sorted without duplicates",
+			vals:    []string{"infra", "Billing", "INFRA"},
+			want:    []Tag{"billing", "infra"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid tag should return error",
+			vals:    []string{"infra", "bad/tag"},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTags(tt.vals)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTags() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTask_AddTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        *Task
+		tag      Tag
+		want     bool
+		wantTags []Tag
+	}{
+		{
+			name:     "new tag should be added in order",
+			t:        &Task{tags: []Tag{"infra"}},
+			tag:      "billing",
+			want:     true,
+			wantTags: []Tag{"billing", "infra"},
+		},
+		{
+			name:     "existing tag should not be added again",
+			t:        &Task{tags: []Tag{"infra"}},
+			tag:      "infra",
+			want:     false,
+			wantTags: []Tag{"infra"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.AddTag(tt.tag); got != tt.want {
+				t.Errorf("Task.AddTag() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.t.Tags(), tt.wantTags) {
+				t.Errorf("Task.Tags() = %v, want %v", tt.t.Tags(), tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestTask_ReplaceTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        *Task
+		from     Tag
+		to       Tag
+		want     bool
+		wantTags []Tag
+	}{
+		{
+			name:     "tag should be replaced",
+			t:        &Task{tags: []Tag{"billing", "ops"}},
+			from:     "ops",
+			to:       "infra",
+			want:     true,
+			wantTags: []Tag{"billing", "infra"},
+		},
+		{
+			name:     "empty replacement should remove the tag",
+			t:        &Task{tags: []Tag{"billing", "ops"}},
+			from:     "ops",
+			to:       "",
+			want:     true,
+			wantTags: []Tag{"billing"},
+		},
+		{
+			name:     "missing tag should not be replaced",
+			t:        &Task{tags: []Tag{"billing"}},
+			from:     "ops",
+			to:       "infra",
+			want:     false,
+			wantTags: []Tag{"billing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.ReplaceTag(tt.from, tt.to); got != tt.want {
+				t.Errorf("Task.ReplaceTag() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.t.Tags(), tt.wantTags) {
+				t.Errorf("Task.Tags() = %v, want %v", tt.t.Tags(), tt.wantTags)
+			}
+		})
+	}
+}
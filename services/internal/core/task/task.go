@@ -4,9 +4,20 @@ import (
 	"errors"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+)
+
+// Maximum field lengths, in characters
+const (
+	MaxNameLength        = 100
+	MaxDescriptionLength = 500
 )
 
 // Task is a single task struct
@@ -17,6 +28,17 @@ type Task struct {
 	clearedTime   time.Time
 	createdTime   time.Time
 	createdBy     user.ID
+	workspace     workspace.ID
+	assignee      user.ID
+	schedule      int64
+	dueTime       time.Time
+	priority      Priority
+	tags          []Tag
+	checklist     []ChecklistItem
+	autoComplete  bool
+	action        *action.Call
+	command       *command.Run
+	events        []*event.Event
 }
 
 // New instantiates a new task entity
@@ -26,6 +48,7 @@ func New(name string, description string, createdBy user.ID) *Task {
 		description: description,
 		createdTime: clock.Now(),
 		createdBy:   createdBy,
+		events:      []*event.Event{event.New(event.TaskCreated)},
 	}
 }
 
@@ -41,6 +64,16 @@ func NewRaw(name string, description string, complete time.Time, cleared time.Ti
 	}
 }
 
+// Events returns the events emitted by changes to the task since it was created or loaded, which haven't been persisted yet
+func (t *Task) Events() []*event.Event {
+	return t.events
+}
+
+// ClearEvents discards the task's emitted events, once they've been persisted
+func (t *Task) ClearEvents() {
+	t.events = nil
+}
+
 // IsValid returns whether a task is valid and can be operated upon
 func (t *Task) IsValid() bool {
 	return t.clearedTime.IsZero()
@@ -76,6 +109,136 @@ func (t *Task) CreatedBy() user.ID {
 	return t.createdBy
 }
 
+// Workspace returns the ID of the workspace the task is shared with, empty if the task is only visible to its creator
+func (t *Task) Workspace() workspace.ID {
+	return t.workspace
+}
+
+// SetWorkspace shares the task with all members of a workspace, an empty ID makes it only visible to its creator
+func (t *Task) SetWorkspace(id workspace.ID) {
+	t.workspace = id
+}
+
+// Assignee returns the ID of the user responsible for the task, empty if the task is unassigned
+func (t *Task) Assignee() user.ID {
+	return t.assignee
+}
+
+// SetAssignee assigns the task to a user, an empty ID unassigns it
+func (t *Task) SetAssignee(uid user.ID) {
+	t.assignee = uid
+}
+
+// Schedule returns the ID of the schedule that generated the task, zero if it was added by a user
+func (t *Task) Schedule() int64 {
+	return t.schedule
+}
+
+// SetSchedule records the ID of the schedule that generated the task
+func (t *Task) SetSchedule(id int64) {
+	t.schedule = id
+}
+
+// Action returns the HTTP action call queued when the task was generated, nil if there isn't one
+// it's only set on newly generated tasks, and is saved along with the task when it's added
+func (t *Task) Action() *action.Call {
+	return t.action
+}
+
+// SetAction queues an HTTP action call for the task
+func (t *Task) SetAction(c *action.Call) {
+	t.action = c
+}
+
+// Command returns the command run queued when the task was generated, nil if there isn't one
+// it's only set on newly generated tasks, and is saved along with the task when it's added
+func (t *Task) Command() *command.Run {
+	return t.command
+}
+
+// SetCommand queues a command run for the task
+func (t *Task) SetCommand(r *command.Run) {
+	t.command = r
+}
+
+// Validate returns an error if any task fields are invalid
+func (t *Task) Validate() error {
+	if err := validateName(t.name); err != nil {
+		return err
+	}
+	return validateDescription(t.description)
+}
+
+func validateName(name string) error {
+	if l := utf8.RuneCountInString(name); l > MaxNameLength {
+		return fmt.Errorf("name is %d characters, cannot be longer than %d", l, MaxNameLength)
+	}
+	return nil
+}
+
+func validateDescription(description string) error {
+	if l := utf8.RuneCountInString(description); l > MaxDescriptionLength {
+		return fmt.Errorf("description is %d characters, cannot be longer than %d", l, MaxDescriptionLength)
+	}
+	return nil
+}
+
+// Rename changes the task name
+func (t *Task) Rename(name string) error {
+	if !t.IsValid() {
+		return errors.New("Task is invalid, cannot be renamed")
+	}
+	if err := validateName(name); err != nil {
+		return err
+	}
+	t.name = name
+	return nil
+}
+
+// Redescribe changes the task description
+func (t *Task) Redescribe(description string) error {
+	if !t.IsValid() {
+		return errors.New("Task is invalid, cannot be redescribed")
+	}
+	if err := validateDescription(description); err != nil {
+		return err
+	}
+	t.description = description
+	return nil
+}
+
+// DueTime returns the time the task must be completed by, zero value if not set
+func (t *Task) DueTime() time.Time {
+	return t.dueTime
+}
+
+// SetDueTime sets the time the task must be completed by, a zero value removes the due time
+func (t *Task) SetDueTime(due time.Time) {
+	t.dueTime = due
+}
+
+// Priority returns the task priority
+func (t *Task) Priority() Priority {
+	return t.priority
+}
+
+// SetPriority sets the task priority
+func (t *Task) SetPriority(p Priority) error {
+	if !p.IsValid() {
+		return fmt.Errorf("invalid priority %d", p)
+	}
+	t.priority = p
+	return nil
+}
+
+// IsOverdue returns whether the task is incomplete and past its due time
+func (t *Task) IsOverdue(now time.Time) bool {
+	if t.dueTime.IsZero() || !t.completedTime.IsZero() {
+		return false
+	}
+	return now.After(t.dueTime)
+}
+
 // CompleteNow completes a task now
 func (t *Task) CompleteNow() (bool, error) {
 	if !t.IsValid() {
@@ -85,6 +248,25 @@ func (t *Task) CompleteNow() (bool, error) {
 		return false, nil
 	}
 	t.completedTime = clock.Now()
+	t.events = append(t.events, event.New(event.TaskCompleted))
+	return true, nil
+}
+
+// MarkGenerated records that the task was generated by a schedule, rather than added by a user
+func (t *Task) MarkGenerated() {
+	t.events = append(t.events, event.New(event.TaskGenerated))
+}
+
+// Uncomplete reopens a completed task, returns false if it wasn't completed
+func (t *Task) Uncomplete() (bool, error) {
+	if !t.IsValid() {
+		return false, errors.New("Task is invalid, cannot be uncompleted")
+	}
+	if t.completedTime.IsZero() {
+		return false, nil
+	}
+	t.completedTime = time.Time{}
+	t.events = append(t.events, event.New(event.TaskUncompleted))
 	return true, nil
 }
 
@@ -100,6 +282,7 @@ func (t *Task) ClearCompleted() error {
 func (t *Task) Clear() error {
 	if t.clearedTime.IsZero() {
 		t.clearedTime = clock.Now()
+		t.events = append(t.events, event.New(event.TaskCleared))
 	}
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
 
@@ -41,7 +42,12 @@ func TestNew(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := New(tt.args.name, tt.args.description, tt.args.createdBy); !reflect.DeepEqual(got, tt.want) {
+			got := New(tt.args.name, tt.args.description, tt.args.createdBy)
+			if es := got.Events(); len(es) != 1 || es[0].Type() != event.TaskCreated {
+				t.Errorf("New() events = %v, want a single %v event", es, event.TaskCreated)
+			}
+			got.ClearEvents()
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("New() = %v, want %v", got, tt.want)
 			}
 		})
@@ -175,3 +181,136 @@ func TestTask_ClearCompleted(t *testing.T) {
 		})
 	}
 }
+
+func TestTask_SetPriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       *Task
+		p       Priority
+		wantErr bool
+	}{
+		{
+			name:    "valid priority should be set",
+			t:       &Task{},
+			p:       PriorityMedium,
+			wantErr: false,
+		},
+		{
+			name:    "invalid priority should return error",
+			t:       &Task{},
+			p:       PriorityHigh + 1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.t.SetPriority(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Task.SetPriority() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && tt.t.Priority() != tt.p {
+				t.Errorf("Task.Priority() = %v, want %v", tt.t.Priority(), tt.p)
+			}
+		})
+	}
+}
+
+func TestTask_IsOverdue(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		t    *Task
+		want bool
+	}{
+		{
+			name: "task without a due time should not be overdue",
+			t:    &Task{},
+			want: false,
+		},
+		{
+			name: "incomplete task past its due time should be overdue",
+			t:    &Task{dueTime: now.Add(-time.Minute)},
+			want: true,
+		},
+		{
+			name: "incomplete task due in the future should not be overdue",
+			t:    &Task{dueTime: now.Add(time.Minute)},
+			want: false,
+		},
+		{
+			name: "completed task past its due time should not be overdue",
+			t:    &Task{dueTime: now.Add(-time.Minute), completedTime: now},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.IsOverdue(now); got != tt.want {
+				t.Errorf("Task.IsOverdue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTask_Events(t *testing.T) {
+	completed := NewRaw("completed", "", time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), time.Time{}, time.Time{}, user.ID{})
+	cleared := NewRaw("cleared", "", time.Time{}, time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), time.Time{}, user.ID{})
+
+	tests := []struct {
+		name   string
+		task   *Task
+		change func(t *Task)
+		want   []event.Type
+	}{
+		{
+			name:   "completing a task should emit a completed event",
+			task:   NewRaw("incomplete", "", time.Time{}, time.Time{}, time.Time{}, user.ID{}),
+			change: func(t *Task) { t.CompleteNow() },
+			want:   []event.Type{event.TaskCompleted},
+		},
+		{
+			name:   "completing a completed task should not emit an event",
+			task:   completed,
+			change: func(t *Task) { t.CompleteNow() },
+			want:   []event.Type{},
+		},
+		{
+			name:   "uncompleting a completed task should emit an uncompleted event",
+			task:   completed,
+			change: func(t *Task) { t.Uncomplete() },
+			want:   []event.Type{event.TaskUncompleted},
+		},
+		{
+			name:   "marking a task generated should emit a generated event",
+			task:   NewRaw("generated", "", time.Time{}, time.Time{}, time.Time{}, user.ID{}),
+			change: func(t *Task) { t.MarkGenerated() },
+			want:   []event.Type{event.TaskGenerated},
+		},
+		{
+			name:   "clearing a task should emit a cleared event",
+			task:   NewRaw("to clear", "", time.Time{}, time.Time{}, time.Time{}, user.ID{}),
+			change: func(t *Task) { t.Clear() },
+			want:   []event.Type{event.TaskCleared},
+		},
+		{
+			name:   "clearing a cleared task should not emit an event",
+			task:   cleared,
+			change: func(t *Task) { t.Clear() },
+			want:   []event.Type{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.ClearEvents()
+			tt.change(tt.task)
+			got := []event.Type{}
+			for _, e := range tt.task.Events() {
+				got = append(got, e.Type())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Events() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
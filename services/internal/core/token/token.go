@@ -0,0 +1,151 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// MaxNameLength is the maximum length of a token name, in characters
+const MaxNameLength = 100
+
+// ManageScope is the scope that allows managing personal access tokens, tokens can't use it to create other tokens with it
+const ManageScope = "manage:tokens"
+
+// SecretPrefix prefixes every token secret, so personal access tokens can be told apart from other bearer tokens
+const SecretPrefix = "stpat_"
+
+// Token is a personal access token a user creates to access the API from scripts and automation
+// Only a hash of the token's secret is kept, the secret itself is only available when the token is created
+type Token struct {
+	id          ID
+	name        string
+	userID      user.ID
+	scopes      []string
+	hash        string
+	createdTime time.Time
+	expiresTime time.Time
+	revokedTime time.Time
+}
+
+// New instantiates a new token entity and returns it with its secret
+// A zero expiry time creates a token that never expires
+func New(name string, uid user.ID, scopes []string, expires time.Time) (*Token, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("token name cannot be empty")
+	}
+	if l := utf8.RuneCountInString(name); l > MaxNameLength {
+		return nil, "", fmt.Errorf("token name is %d characters, cannot be longer than %d", l, MaxNameLength)
+	}
+	if uid.IsEmpty() {
+		return nil, "", errors.New("token must belong to a user")
+	}
+	now := clock.Now()
+	if !expires.IsZero() && !expires.After(now) {
+		return nil, "", fmt.Errorf("token expiry %v must be in the future", expires)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("error generating token secret: %v", err)
+	}
+	secret := SecretPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return &Token{
+		id:          NewID(),
+		name:        name,
+		userID:      uid,
+		scopes:      append([]string{}, scopes...),
+		hash:        Hash(secret),
+		createdTime: now,
+		expiresTime: expires,
+	}, secret, nil
+}
+
+// NewRaw instantiates a token entity with all available fields
+func NewRaw(id ID, name string, uid user.ID, scopes []string, hash string, created time.Time, expires time.Time, revoked time.Time) *Token {
+	return &Token{
+		id:          id,
+		name:        name,
+		userID:      uid,
+		scopes:      scopes,
+		hash:        hash,
+		createdTime: created,
+		expiresTime: expires,
+		revokedTime: revoked,
+	}
+}
+
+// Hash returns the hash a token secret is stored and looked up by
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsSecret returns whether a bearer token looks like a personal access token secret
+func IsSecret(val string) bool {
+	return strings.HasPrefix(val, SecretPrefix)
+}
+
+// ID returns the token's unique ID
+func (t *Token) ID() ID {
+	return t.id
+}
+
+// Name returns the token name
+func (t *Token) Name() string {
+	return t.name
+}
+
+// UserID returns the ID of the user the token belongs to
+func (t *Token) UserID() user.ID {
+	return t.userID
+}
+
+// Scopes returns the permission scopes granted to the token
+func (t *Token) Scopes() []string {
+	return t.scopes
+}
+
+// Hash returns the hash of the token's secret
+func (t *Token) Hash() string {
+	return t.hash
+}
+
+// CreatedTime returns the time the token was created
+func (t *Token) CreatedTime() time.Time {
+	return t.createdTime
+}
+
+// ExpiresTime returns the time the token expires, zero if it never expires
+func (t *Token) ExpiresTime() time.Time {
+	return t.expiresTime
+}
+
+// RevokedTime returns the time the token was revoked, zero if it hasn't been revoked
+func (t *Token) RevokedTime() time.Time {
+	return t.revokedTime
+}
+
+// Revoke revokes the token, revoking an already revoked token has no effect
+func (t *Token) Revoke() {
+	if t.revokedTime.IsZero() {
+		t.revokedTime = clock.Now()
+	}
+}
+
+// IsActive returns whether the token can currently be used: it is neither revoked nor expired
+func (t *Token) IsActive() bool {
+	if !t.revokedTime.IsZero() {
+		return false
+	}
+	return t.expiresTime.IsZero() || clock.Now().Before(t.expiresTime)
+}
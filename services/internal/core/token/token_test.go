@@ -0,0 +1,131 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	uid := user.NewID()
+
+	type args struct {
+		name    string
+		uid     user.ID
+		expires time.Time
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "should create token with trimmed name",
+			args:     args{name: "  ci  ", uid: uid, expires: now.Add(time.Hour)},
+			wantName: "ci",
+			wantErr:  false,
+		},
+		{
+			name:     "should create token that never expires",
+			args:     args{name: "ci", uid: uid},
+			wantName: "ci",
+			wantErr:  false,
+		},
+		{
+			name:    "should return error for empty name",
+			args:    args{name: "   ", uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for name that is too long",
+			args:    args{name: strings.Repeat("a", MaxNameLength+1), uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for empty user",
+			args:    args{name: "ci", uid: user.ID{}},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for expiry in the past",
+			args:    args{name: "ci", uid: uid, expires: now},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, secret, err := New(tt.args.name, tt.args.uid, []string{"read:task"}, tt.args.expires)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Name() != tt.wantName {
+				t.Errorf("New() name = %v, want %v", got.Name(), tt.wantName)
+			}
+			if !IsSecret(secret) {
+				t.Errorf("New() secret = %v, want prefix %v", secret, SecretPrefix)
+			}
+			if got.Hash() != Hash(secret) || strings.Contains(got.Hash(), secret) {
+				t.Errorf("New() hash = %v, want hash of secret", got.Hash())
+			}
+			if !got.IsActive() {
+				t.Errorf("New() token should be active")
+			}
+		})
+	}
+}
+
+func TestToken_IsActive(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	uid := user.NewID()
+	revoked := NewRaw(NewID(), "ci", uid, nil, "", now, time.Time{}, time.Time{})
+	revoked.Revoke()
+
+	tests := []struct {
+		name string
+		t    *Token
+		want bool
+	}{
+		{
+			name: "token without expiry should be active",
+			t:    NewRaw(NewID(), "ci", uid, nil, "", now, time.Time{}, time.Time{}),
+			want: true,
+		},
+		{
+			name: "token before expiry should be active",
+			t:    NewRaw(NewID(), "ci", uid, nil, "", now, now.Add(time.Second), time.Time{}),
+			want: true,
+		},
+		{
+			name: "expired token should not be active",
+			t:    NewRaw(NewID(), "ci", uid, nil, "", now, now, time.Time{}),
+			want: false,
+		},
+		{
+			name: "revoked token should not be active",
+			t:    revoked,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.IsActive(); got != tt.want {
+				t.Errorf("Token.IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role determines which permissions a user is granted in the app
+type Role uint8
+
+// Role constants, ordered from least to most privileged
+const (
+	RoleViewer Role = iota + 1
+	RoleMember
+	RoleAdmin
+)
+
+// Roles returns all roles, from most to least privileged
+func Roles() []Role {
+	return []Role{RoleAdmin, RoleMember, RoleViewer}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleMember:
+		return "member"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "[Invalid role]"
+}
+
+// IsValid returns whether the role is one of the defined role constants
+func (r Role) IsValid() bool {
+	return r >= RoleViewer && r <= RoleAdmin
+}
+
+// IsReadOnly returns whether users with this role are only allowed to view data
+func (r Role) IsReadOnly() bool {
+	return r == RoleViewer
+}
+
+// ParseRole parses a role from its string representation
+func ParseRole(val string) (Role, error) {
+	switch strings.ToLower(val) {
+	case "viewer":
+		return RoleViewer, nil
+	case "member":
+		return RoleMember, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+	return 0, fmt.Errorf("unknown role '%v', should be 'admin', 'member', or 'viewer'", val)
+}
@@ -0,0 +1,55 @@
+package user
+
+import "testing"
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    Role
+		wantErr bool
+	}{
+		{name: "should parse viewer", val: "viewer", want: RoleViewer},
+		{name: "should parse member", val: "member", want: RoleMember},
+		{name: "should parse admin case-insensitively", val: "Admin", want: RoleAdmin},
+		{name: "should return error for empty role", val: "", wantErr: true},
+		{name: "should return error for unknown role", val: "owner", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRole(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUser_SetRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		want    Role
+		wantErr bool
+	}{
+		{name: "should set valid role", role: RoleViewer, want: RoleViewer},
+		{name: "should return error for invalid role", role: Role(0), want: RoleMember, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := New("user")
+			err := u.SetRole(tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("User.SetRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if u.Role() != tt.want {
+				t.Errorf("User.Role() = %v, want %v", u.Role(), tt.want)
+			}
+		})
+	}
+}
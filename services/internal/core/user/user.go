@@ -1,15 +1,18 @@
 package user
 
+import "fmt"
+
 // User a user of the scheduled task system
 type User struct {
 	id          ID
 	displayname string
+	role        Role
 }
 
-// New creates a new user entity
+// New creates a new user entity, with the member role
 func New(displayname string) *User {
 	id := NewID()
-	return &User{id, displayname}
+	return &User{id, displayname, RoleMember}
 }
 
 // NewRaw instantiates a user entity with all available fields
@@ -18,7 +21,7 @@ func NewRaw(idStr string, displayname string) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &User{id, displayname}, nil
+	return &User{id, displayname, RoleMember}, nil
 }
 
 // ID returns the user's unique ID
@@ -35,3 +38,17 @@ func (u *User) DisplayName() string {
 func (u *User) UpdateDisplayName(displayname string) {
 	u.displayname = displayname
 }
+
+// Role returns the user's role
+func (u *User) Role() Role {
+	return u.role
+}
+
+// SetRole changes the user's role
+func (u *User) SetRole(role Role) error {
+	if !role.IsValid() {
+		return fmt.Errorf("invalid role %d", role)
+	}
+	u.role = role
+	return nil
+}
@@ -19,7 +19,7 @@ func TestNew(t *testing.T) {
 			name:        "should create a valid user",
 			args:        args{"Hi, I'm a Valid [{'#`\"]}User Display Name"},
 			wantValidID: true,
-			want:        &User{displayname: "Hi, I'm a Valid [{'#`\"]}User Display Name"},
+			want:        &User{displayname: "Hi, I'm a Valid [{'#`\"]}User Display Name", role: RoleMember},
 		},
 	}
 	for _, tt := range tests {
@@ -51,7 +51,7 @@ func TestNewRaw(t *testing.T) {
 			args: args{"123e4567-e89b-12d3-a456-426655440000", "user display name"},
 			want: func() *User {
 				id, _ := ParseID("123e4567-e89b-12d3-a456-426655440000")
-				return &User{id, "user display name"}
+				return &User{id, "user display name", RoleMember}
 			}(),
 			wantErr: false,
 		},
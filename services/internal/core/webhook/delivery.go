@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// MaxErrorLength is the maximum length of a delivery's recorded error, in characters, longer errors are truncated
+const MaxErrorLength = 1000
+
+// DeliveryStatus is the state of a delivery
+type DeliveryStatus uint8
+
+// Delivery statuses
+const (
+	DeliveryPending DeliveryStatus = iota
+	DeliverySucceeded
+	DeliveryFailed
+)
+
+func (s DeliveryStatus) String() string {
+	switch s {
+	case DeliveryPending:
+		return "pending"
+	case DeliverySucceeded:
+		return "succeeded"
+	case DeliveryFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// RetryPolicy determines how many times a delivery is attempted, and how long to wait between attempts
+// the wait doubles after each failed attempt, starting at Delay, up to MaxDelay
+type RetryPolicy struct {
+	MaxAttempts int
+	Delay       time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy attempts a delivery 8 times over about an hour
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 8, Delay: 30 * time.Second, MaxDelay: time.Hour}
+
+// Backoff returns how long to wait before the next attempt, after the given number of failed attempts
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	d := p.Delay
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return d
+}
+
+// Delivery is a single event payload sent to a webhook, along with the outcome of its latest attempt
+type Delivery struct {
+	id              ID
+	webhookID       ID
+	event           EventType
+	payload         []byte
+	status          DeliveryStatus
+	attempts        int
+	statusCode      int
+	lastError       string
+	createdTime     time.Time
+	lastAttemptTime time.Time
+	nextAttemptTime time.Time
+}
+
+// NewDelivery instantiates a new pending delivery of an event payload to a webhook, due immediately
+func NewDelivery(webhookID ID, event EventType, payload []byte) *Delivery {
+	now := clock.Now()
+	return &Delivery{
+		id:              NewID(),
+		webhookID:       webhookID,
+		event:           event,
+		payload:         payload,
+		status:          DeliveryPending,
+		createdTime:     now,
+		nextAttemptTime: now,
+	}
+}
+
+// NewRawDelivery instantiates a delivery entity with all available fields
+func NewRawDelivery(id ID, webhookID ID, event EventType, payload []byte, status DeliveryStatus, attempts int, statusCode int, lastError string, created time.Time, lastAttempt time.Time, nextAttempt time.Time) *Delivery {
+	return &Delivery{
+		id:              id,
+		webhookID:       webhookID,
+		event:           event,
+		payload:         payload,
+		status:          status,
+		attempts:        attempts,
+		statusCode:      statusCode,
+		lastError:       lastError,
+		createdTime:     created,
+		lastAttemptTime: lastAttempt,
+		nextAttemptTime: nextAttempt,
+	}
+}
+
+// ID returns the delivery's unique ID
+func (d *Delivery) ID() ID {
+	return d.id
+}
+
+// WebhookID returns the ID of the webhook the payload is sent to
+func (d *Delivery) WebhookID() ID {
+	return d.webhookID
+}
+
+// Event returns the type of event the payload describes
+func (d *Delivery) Event() EventType {
+	return d.event
+}
+
+// Payload returns the JSON payload sent to the webhook
+func (d *Delivery) Payload() []byte {
+	return d.payload
+}
+
+// Status returns the delivery's status
+func (d *Delivery) Status() DeliveryStatus {
+	return d.status
+}
+
+// Attempts returns the number of times the delivery has been attempted
+func (d *Delivery) Attempts() int {
+	return d.attempts
+}
+
+// StatusCode returns the HTTP status code of the latest attempt, zero if no response was received
+func (d *Delivery) StatusCode() int {
+	return d.statusCode
+}
+
+// LastError returns the reason the latest attempt failed, empty if it succeeded
+func (d *Delivery) LastError() string {
+	return d.lastError
+}
+
+// CreatedTime returns the time the delivery was queued
+func (d *Delivery) CreatedTime() time.Time {
+	return d.createdTime
+}
+
+// LastAttemptTime returns the time of the latest attempt, zero if it hasn't been attempted
+func (d *Delivery) LastAttemptTime() time.Time {
+	return d.lastAttemptTime
+}
+
+// NextAttemptTime returns when the delivery is due to be attempted, zero once it has succeeded or failed
+func (d *Delivery) NextAttemptTime() time.Time {
+	return d.nextAttemptTime
+}
+
+// Succeeded records a successful attempt
+func (d *Delivery) Succeeded(statusCode int) {
+	d.attempted(statusCode, "")
+	d.status = DeliverySucceeded
+	d.nextAttemptTime = time.Time{}
+}
+
+// Failed records a failed attempt, and schedules a retry with backoff until the policy's maximum attempts are reached
+func (d *Delivery) Failed(statusCode int, reason string, p RetryPolicy) {
+	d.attempted(statusCode, reason)
+	if d.attempts >= p.MaxAttempts {
+		d.status = DeliveryFailed
+		d.nextAttemptTime = time.Time{}
+		return
+	}
+	d.nextAttemptTime = d.lastAttemptTime.Add(p.Backoff(d.attempts))
+}
+
+// Cancel fails a pending delivery without attempting it, e.g. because its webhook was removed
+func (d *Delivery) Cancel(reason string) {
+	d.status = DeliveryFailed
+	d.lastError = truncate(reason)
+	d.nextAttemptTime = time.Time{}
+}
+
+func (d *Delivery) attempted(statusCode int, reason string) {
+	d.attempts++
+	d.statusCode = statusCode
+	d.lastError = truncate(reason)
+	d.lastAttemptTime = clock.Now()
+}
+
+func truncate(reason string) string {
+	if utf8.RuneCountInString(reason) <= MaxErrorLength {
+		return reason
+	}
+	return string([]rune(reason)[:MaxErrorLength])
+}
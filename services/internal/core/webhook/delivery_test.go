@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, Delay: time.Second, MaxDelay: 10 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 50, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%v) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDelivery_Failed(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	p := RetryPolicy{MaxAttempts: 3, Delay: time.Minute}
+
+	d := NewDelivery(NewID(), EventTaskCreated, []byte("{}"))
+	if !d.NextAttemptTime().Equal(now) {
+		t.Fatalf("NewDelivery() nextAttemptTime = %v, want %v", d.NextAttemptTime(), now)
+	}
+
+	wantNext := []time.Time{now.Add(time.Minute), now.Add(2 * time.Minute), {}}
+	wantStatus := []DeliveryStatus{DeliveryPending, DeliveryPending, DeliveryFailed}
+	for i := range wantNext {
+		d.Failed(500, "server error", p)
+		if d.Attempts() != i+1 {
+			t.Errorf("attempt %v: attempts = %v, want %v", i+1, d.Attempts(), i+1)
+		}
+		if d.Status() != wantStatus[i] {
+			t.Errorf("attempt %v: status = %v, want %v", i+1, d.Status(), wantStatus[i])
+		}
+		if !d.NextAttemptTime().Equal(wantNext[i]) {
+			t.Errorf("attempt %v: nextAttemptTime = %v, want %v", i+1, d.NextAttemptTime(), wantNext[i])
+		}
+		if d.StatusCode() != 500 || d.LastError() != "server error" || !d.LastAttemptTime().Equal(now) {
+			t.Errorf("attempt %v: outcome = %v %v %v, want 500 server error %v", i+1, d.StatusCode(), d.LastError(), d.LastAttemptTime(), now)
+		}
+	}
+}
+
+func TestDelivery_Succeeded(t *testing.T) {
+	d := NewDelivery(NewID(), EventTaskCreated, []byte("{}"))
+	d.Failed(0, "connection refused", DefaultRetryPolicy)
+	d.Succeeded(204)
+	if d.Status() != DeliverySucceeded {
+		t.Errorf("status = %v, want %v", d.Status(), DeliverySucceeded)
+	}
+	if d.Attempts() != 2 || d.StatusCode() != 204 || d.LastError() != "" || !d.NextAttemptTime().IsZero() {
+		t.Errorf("outcome = %v %v %v %v, want 2 204, no error and no next attempt", d.Attempts(), d.StatusCode(), d.LastError(), d.NextAttemptTime())
+	}
+}
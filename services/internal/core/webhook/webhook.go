@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// MaxURLLength is the maximum length of a webhook URL, in characters
+const MaxURLLength = 2000
+
+// MaxSecretLength is the maximum length of a webhook secret, in characters
+const MaxSecretLength = 200
+
+// EventType is a kind of change webhooks can subscribe to
+type EventType string
+
+// Event types, named after the domain events they're delivered for
+const (
+	EventTaskCreated     = EventType(event.TaskCreated)
+	EventTaskCompleted   = EventType(event.TaskCompleted)
+	EventTaskCleared     = EventType(event.TaskCleared)
+	EventScheduleCreated = EventType(event.ScheduleCreated)
+	EventSchedulePaused  = EventType(event.SchedulePaused)
+	EventScheduleRemoved = EventType(event.ScheduleRemoved)
+)
+
+// EventTypes returns every event type webhooks can subscribe to
+func EventTypes() []EventType {
+	return []EventType{EventTaskCreated, EventTaskCompleted, EventTaskCleared, EventScheduleCreated, EventSchedulePaused, EventScheduleRemoved}
+}
+
+// ParseEventType parses an event type name
+func ParseEventType(name string) (EventType, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, e := range EventTypes() {
+		if string(e) == name {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// Webhook is a user's subscription to have events POSTed to a URL
+// The secret is used to sign each payload, so the receiver can verify it was sent by this app
+type Webhook struct {
+	id          ID
+	userID      user.ID
+	url         string
+	events      []EventType
+	secret      string
+	createdTime time.Time
+	removedTime time.Time
+}
+
+// New instantiates a new webhook entity, subscribed to the given event types
+// An empty secret generates a random one
+func New(rawURL string, events []EventType, secret string, uid user.ID) (*Webhook, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if l := utf8.RuneCountInString(rawURL); l > MaxURLLength {
+		return nil, fmt.Errorf("webhook URL is %d characters, cannot be longer than %d", l, MaxURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook URL '%v' must be an absolute http or https URL", rawURL)
+	}
+	if len(events) == 0 {
+		return nil, errors.New("webhook must subscribe to at least one event type")
+	}
+	subscribed := []EventType{}
+	for _, e := range events {
+		if _, ok := ParseEventType(string(e)); !ok {
+			return nil, fmt.Errorf("unknown event type '%v'", e)
+		}
+		if !containsEvent(subscribed, e) {
+			subscribed = append(subscribed, e)
+		}
+	}
+	if uid.IsEmpty() {
+		return nil, errors.New("webhook must belong to a user")
+	}
+	if l := utf8.RuneCountInString(secret); l > MaxSecretLength {
+		return nil, fmt.Errorf("webhook secret is %d characters, cannot be longer than %d", l, MaxSecretLength)
+	}
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("error generating webhook secret: %v", err)
+		}
+		secret = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return &Webhook{
+		id:          NewID(),
+		userID:      uid,
+		url:         rawURL,
+		events:      subscribed,
+		secret:      secret,
+		createdTime: clock.Now(),
+	}, nil
+}
+
+// NewRaw instantiates a webhook entity with all available fields
+func NewRaw(id ID, uid user.ID, rawURL string, events []EventType, secret string, created time.Time, removed time.Time) *Webhook {
+	return &Webhook{
+		id:          id,
+		userID:      uid,
+		url:         rawURL,
+		events:      events,
+		secret:      secret,
+		createdTime: created,
+		removedTime: removed,
+	}
+}
+
+func containsEvent(es []EventType, e EventType) bool {
+	for _, val := range es {
+		if val == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ID returns the webhook's unique ID
+func (w *Webhook) ID() ID {
+	return w.id
+}
+
+// UserID returns the ID of the user the webhook belongs to
+func (w *Webhook) UserID() user.ID {
+	return w.userID
+}
+
+// URL returns the URL events are POSTed to
+func (w *Webhook) URL() string {
+	return w.url
+}
+
+// Events returns the event types the webhook is subscribed to
+func (w *Webhook) Events() []EventType {
+	return w.events
+}
+
+// Secret returns the secret payloads are signed with
+func (w *Webhook) Secret() string {
+	return w.secret
+}
+
+// CreatedTime returns the time the webhook was created
+func (w *Webhook) CreatedTime() time.Time {
+	return w.createdTime
+}
+
+// RemovedTime returns the time the webhook was removed, zero if it hasn't been removed
+func (w *Webhook) RemovedTime() time.Time {
+	return w.removedTime
+}
+
+// Remove removes the webhook, so no further events are delivered to it
+// removing an already removed webhook has no effect
+func (w *Webhook) Remove() {
+	if w.removedTime.IsZero() {
+		w.removedTime = clock.Now()
+	}
+}
+
+// IsActive returns whether events are still delivered to the webhook
+func (w *Webhook) IsActive() bool {
+	return w.removedTime.IsZero()
+}
+
+// Subscribes returns whether the webhook is active and subscribed to an event type
+func (w *Webhook) Subscribes(e EventType) bool {
+	return w.IsActive() && containsEvent(w.events, e)
+}
+
+// Sign returns the signature of a payload, the hex-encoded HMAC-SHA256 of the payload keyed with the webhook's secret, prefixed with "sha256="
+func (w *Webhook) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+	uid := user.NewID()
+
+	type args struct {
+		url    string
+		events []EventType
+		secret string
+		uid    user.ID
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantURL    string
+		wantEvents []EventType
+		wantSecret string
+		wantErr    bool
+	}{
+		{
+			name:       "should create webhook with trimmed URL and de-duplicated events",
+			args:       args{url: " https://example.com/hook ", events: []EventType{EventTaskCreated, EventTaskCompleted, EventTaskCreated}, secret: "s3cret", uid: uid},
+			wantURL:    "https://example.com/hook",
+			wantEvents: []EventType{EventTaskCreated, EventTaskCompleted},
+			wantSecret: "s3cret",
+		},
+		{
+			name:       "should generate a secret if none is given",
+			args:       args{url: "http://localhost:9000", events: []EventType{EventSchedulePaused}, uid: uid},
+			wantURL:    "http://localhost:9000",
+			wantEvents: []EventType{EventSchedulePaused},
+		},
+		{
+			name:    "should return error for relative URL",
+			args:    args{url: "/hook", events: []EventType{EventTaskCreated}, uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for non-http URL",
+			args:    args{url: "ftp://example.com/hook", events: []EventType{EventTaskCreated}, uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for URL that is too long",
+			args:    args{url: "https://example.com/" + strings.Repeat("a", MaxURLLength), events: []EventType{EventTaskCreated}, uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for no events",
+			args:    args{url: "https://example.com/hook", uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for unknown event",
+			args:    args{url: "https://example.com/hook", events: []EventType{"task.deleted"}, uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for secret that is too long",
+			args:    args{url: "https://example.com/hook", events: []EventType{EventTaskCreated}, secret: strings.Repeat("a", MaxSecretLength+1), uid: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for empty user",
+			args:    args{url: "https://example.com/hook", events: []EventType{EventTaskCreated}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.args.url, tt.args.events, tt.args.secret, tt.args.uid)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.URL() != tt.wantURL {
+				t.Errorf("New() url = %v, want %v", got.URL(), tt.wantURL)
+			}
+			if !reflect.DeepEqual(got.Events(), tt.wantEvents) {
+				t.Errorf("New() events = %v, want %v", got.Events(), tt.wantEvents)
+			}
+			if tt.wantSecret != "" && got.Secret() != tt.wantSecret {
+				t.Errorf("New() secret = %v, want %v", got.Secret(), tt.wantSecret)
+			}
+			if got.Secret() == "" {
+				t.Errorf("New() secret should not be empty")
+			}
+			if !got.CreatedTime().Equal(now) {
+				t.Errorf("New() createdTime = %v, want %v", got.CreatedTime(), now)
+			}
+			if !got.IsActive() {
+				t.Errorf("New() webhook should be active")
+			}
+		})
+	}
+}
+
+func TestParseEventType(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   EventType
+		wantOk bool
+	}{
+		{name: "task.created", want: EventTaskCreated, wantOk: true},
+		{name: " Schedule.Removed ", want: EventScheduleRemoved, wantOk: true},
+		{name: "task.deleted", wantOk: false},
+		{name: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseEventType(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ParseEventType() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWebhook_Subscribes(t *testing.T) {
+	w := NewRaw(NewID(), user.NewID(), "https://example.com/hook", []EventType{EventTaskCreated}, "s3cret", time.Time{}, time.Time{})
+	if !w.Subscribes(EventTaskCreated) {
+		t.Errorf("Subscribes(%v) = false, want true", EventTaskCreated)
+	}
+	if w.Subscribes(EventTaskCompleted) {
+		t.Errorf("Subscribes(%v) = true, want false", EventTaskCompleted)
+	}
+	w.Remove()
+	if w.Subscribes(EventTaskCreated) {
+		t.Errorf("removed webhook Subscribes(%v) = true, want false", EventTaskCreated)
+	}
+}
+
+func TestWebhook_Sign(t *testing.T) {
+	w := NewRaw(NewID(), user.NewID(), "https://example.com/hook", []EventType{EventTaskCreated}, "It's a Secret to Everybody", time.Time{}, time.Time{})
+	got := w.Sign([]byte("Hello, World!"))
+	want := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	if got != want {
+		t.Errorf("Sign() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role determines what a member is allowed to do in a workspace
+type Role uint8
+
+// Role constants, ordered from least to most privileged
+const (
+	RoleMember Role = iota + 1
+	RoleAdmin
+	RoleOwner
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleMember:
+		return "member"
+	case RoleAdmin:
+		return "admin"
+	case RoleOwner:
+		return "owner"
+	}
+	return "[Invalid role]"
+}
+
+// IsValid returns whether the role is one of the defined role constants
+func (r Role) IsValid() bool {
+	return r >= RoleMember && r <= RoleOwner
+}
+
+// CanManageMembers returns whether members with this role can invite other members
+func (r Role) CanManageMembers() bool {
+	return r >= RoleAdmin
+}
+
+// ParseRole parses a role from its string representation, an empty string is RoleMember
+func ParseRole(val string) (Role, error) {
+	switch strings.ToLower(val) {
+	case "", "member":
+		return RoleMember, nil
+	case "admin":
+		return RoleAdmin, nil
+	case "owner":
+		return RoleOwner, nil
+	}
+	return 0, fmt.Errorf("unknown role '%v', should be 'member', 'admin', or 'owner'", val)
+}
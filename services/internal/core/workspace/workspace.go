@@ -0,0 +1,141 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// MaxNameLength is the maximum length of a workspace name, in characters
+const MaxNameLength = 100
+
+// Workspace is a team of users that share tasks and schedules
+type Workspace struct {
+	id          ID
+	name        string
+	createdTime time.Time
+	createdBy   user.ID
+	members     []Member
+}
+
+// Member is a user's membership in a workspace
+type Member struct {
+	userID user.ID
+	role   Role
+}
+
+// New instantiates a new workspace entity, owned by the user creating it
+func New(name string, createdBy user.ID) (*Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("workspace name cannot be empty")
+	}
+	if l := utf8.RuneCountInString(name); l > MaxNameLength {
+		return nil, fmt.Errorf("workspace name is %d characters, cannot be longer than %d", l, MaxNameLength)
+	}
+	if createdBy.IsEmpty() {
+		return nil, errors.New("workspace must be created by a user")
+	}
+	return &Workspace{
+		id:          NewID(),
+		name:        name,
+		createdTime: clock.Now(),
+		createdBy:   createdBy,
+		members:     []Member{{userID: createdBy, role: RoleOwner}},
+	}, nil
+}
+
+// NewRaw instantiates a workspace entity with all available fields
+func NewRaw(id ID, name string, created time.Time, createdBy user.ID, members []Member) *Workspace {
+	return &Workspace{
+		id:          id,
+		name:        name,
+		createdTime: created,
+		createdBy:   createdBy,
+		members:     members,
+	}
+}
+
+// NewMember instantiates a workspace membership
+func NewMember(uid user.ID, role Role) Member {
+	return Member{userID: uid, role: role}
+}
+
+// ID returns the workspace's unique ID
+func (w *Workspace) ID() ID {
+	return w.id
+}
+
+// Name returns the workspace name
+func (w *Workspace) Name() string {
+	return w.name
+}
+
+// CreatedTime returns the time the workspace was created
+func (w *Workspace) CreatedTime() time.Time {
+	return w.createdTime
+}
+
+// CreatedBy returns the ID of the user that created the workspace
+func (w *Workspace) CreatedBy() user.ID {
+	return w.createdBy
+}
+
+// Members returns the workspace's memberships
+func (w *Workspace) Members() []Member {
+	return append([]Member{}, w.members...)
+}
+
+// Role returns a user's role in the workspace, false if the user is not a member
+func (w *Workspace) Role(uid user.ID) (Role, bool) {
+	for _, m := range w.members {
+		if m.userID.Equals(uid) {
+			return m.role, true
+		}
+	}
+	return 0, false
+}
+
+// IsMember returns whether the user is a member of the workspace
+func (w *Workspace) IsMember(uid user.ID) bool {
+	_, ok := w.Role(uid)
+	return ok
+}
+
+// AddMember adds a user to the workspace, invited by an existing member allowed to manage members
+// Only owners can add other owners
+func (w *Workspace) AddMember(invitedBy user.ID, uid user.ID, role Role) error {
+	inviterRole, ok := w.Role(invitedBy)
+	if !ok || !inviterRole.CanManageMembers() {
+		return errors.New("only workspace owners and admins can invite members")
+	}
+	if !role.IsValid() {
+		return fmt.Errorf("invalid role %d", role)
+	}
+	if role > inviterRole {
+		return fmt.Errorf("a workspace %v cannot invite an %v", inviterRole, role)
+	}
+	if uid.IsEmpty() {
+		return errors.New("member user ID cannot be empty")
+	}
+	if w.IsMember(uid) {
+		return fmt.Errorf("user %v is already a member", uid)
+	}
+	w.members = append(w.members, Member{userID: uid, role: role})
+	return nil
+}
+
+// UserID returns the ID of the member's user
+func (m Member) UserID() user.ID {
+	return m.userID
+}
+
+// Role returns the member's role
+func (m Member) Role() Role {
+	return m.role
+}
@@ -0,0 +1,165 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestNew(t *testing.T) {
+	uid := user.NewID()
+
+	type args struct {
+		name      string
+		createdBy user.ID
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "should create workspace with trimmed name",
+			args:     args{name: "  team  ", createdBy: uid},
+			wantName: "team",
+			wantErr:  false,
+		},
+		{
+			name:    "should return error for empty name",
+			args:    args{name: "   ", createdBy: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for name that is too long",
+			args:    args{name: strings.Repeat("a", MaxNameLength+1), createdBy: uid},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for empty creator",
+			args:    args{name: "team", createdBy: user.ID{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.args.name, tt.args.createdBy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Name() != tt.wantName {
+				t.Errorf("New() name = %v, want %v", got.Name(), tt.wantName)
+			}
+			if role, ok := got.Role(tt.args.createdBy); !ok || role != RoleOwner {
+				t.Errorf("New() creator role = %v, %v, want %v, true", role, ok, RoleOwner)
+			}
+		})
+	}
+}
+
+func TestWorkspace_AddMember(t *testing.T) {
+	owner := user.NewID()
+	admin := user.NewID()
+	member := user.NewID()
+	newWorkspace := func() *Workspace {
+		return NewRaw(NewID(), "team", time.Time{}, owner, []Member{
+			NewMember(owner, RoleOwner),
+			NewMember(admin, RoleAdmin),
+			NewMember(member, RoleMember),
+		})
+	}
+
+	type args struct {
+		invitedBy user.ID
+		uid       user.ID
+		role      Role
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "owner should be able to add an owner",
+			args:    args{invitedBy: owner, uid: user.NewID(), role: RoleOwner},
+			wantErr: false,
+		},
+		{
+			name:    "admin should be able to add a member",
+			args:    args{invitedBy: admin, uid: user.NewID(), role: RoleMember},
+			wantErr: false,
+		},
+		{
+			name:    "admin should not be able to add an owner",
+			args:    args{invitedBy: admin, uid: user.NewID(), role: RoleOwner},
+			wantErr: true,
+		},
+		{
+			name:    "member should not be able to add a member",
+			args:    args{invitedBy: member, uid: user.NewID(), role: RoleMember},
+			wantErr: true,
+		},
+		{
+			name:    "non-member should not be able to add a member",
+			args:    args{invitedBy: user.NewID(), uid: user.NewID(), role: RoleMember},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for existing member",
+			args:    args{invitedBy: owner, uid: member, role: RoleMember},
+			wantErr: true,
+		},
+		{
+			name:    "should return error for invalid role",
+			args:    args{invitedBy: owner, uid: user.NewID(), role: Role(0)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWorkspace()
+			err := w.AddMember(tt.args.invitedBy, tt.args.uid, tt.args.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Workspace.AddMember() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if role, ok := w.Role(tt.args.uid); !ok || role != tt.args.role {
+					t.Errorf("Workspace.AddMember() role = %v, %v, want %v, true", role, ok, tt.args.role)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    Role
+		wantErr bool
+	}{
+		{name: "empty string should be member", val: "", want: RoleMember},
+		{name: "should parse admin", val: "admin", want: RoleAdmin},
+		{name: "should parse owner case-insensitively", val: "Owner", want: RoleOwner},
+		{name: "should return error for unknown role", val: "guest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRole(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ActionRepo handles persisting HTTP action calls queued for generated tasks
+type ActionRepo struct {
+	db *tracedDB
+}
+
+// NewActionRepo instantiates a new ActionRepo
+func NewActionRepo(conn DBConn) (repo *ActionRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &ActionRepo{db: newTracedDB(conn)}, nil
+}
+
+const actionCallSelectClause = "SELECT id, task_id, method, url, headers, body, timeout_ms, max_attempts, complete_task, status, attempts, status_code, response, latency_ms, last_error, created_time, last_attempt_time, next_attempt_time FROM action_call"
+
+// addActionCall queues an HTTP action call for a new task, a nil call isn't stored
+// it should be run in the same transaction as the task is added in, so either both or neither are persisted
+func addActionCall(ctx context.Context, db dbtx, taskID usecase.TaskID, c *action.Call) error {
+	if c == nil {
+		return nil
+	}
+	req := c.Request()
+	headers, err := json.Marshal(req.Headers())
+	if err != nil {
+		return err
+	}
+	q := "INSERT INTO action_call (id, task_id, method, url, headers, body, timeout_ms, max_attempts, complete_task, status, attempts, status_code, response, latency_ms, last_error, created_time, last_attempt_time, next_attempt_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)"
+	_, err = db.ExecContext(ctx, q, c.ID().String(), taskID, req.Method(), req.URL(), string(headers), req.Body(), int64(c.Timeout()/time.Millisecond), c.MaxAttempts(), c.CompleteTask(), c.Status(), c.Attempts(), c.StatusCode(), c.Response(), int64(c.Latency()/time.Millisecond), c.LastError(), c.CreatedTime(), c.LastAttemptTime(), c.NextAttemptTime())
+	return err
+}
+
+// GetForTask retrieves the call queued for a task
+func (r *ActionRepo) GetForTask(ctx context.Context, taskID usecase.TaskID) (*action.Call, usecase.Error) {
+	cd, err := parseActionCallRow(r.db.QueryRowContext(ctx, actionCallSelectClause+" WHERE task_id = $1", taskID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, usecase.NewError(usecase.ErrRecordNotFound, "no action call found for task id = %v", taskID)
+		}
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving action call for task id %v: %v", taskID, err)
+	}
+	return cd.Call, nil
+}
+
+// GetDue retrieves pending calls due to be attempted at or before the given time, oldest first
+func (r *ActionRepo) GetDue(ctx context.Context, before time.Time, limit int) ([]usecase.ActionCallData, usecase.Error) {
+	q := actionCallSelectClause + " WHERE status = $1 AND next_attempt_time <= $2 ORDER BY next_attempt_time, id LIMIT $3"
+	rows, err := r.db.QueryContext(ctx, q, action.StatusPending, before, limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due action calls: %v", err)
+	}
+	defer rows.Close()
+
+	cs := []usecase.ActionCallData{}
+	for rows.Next() {
+		cd, err := parseActionCallRow(rows)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing action call row: %v", err)
+		}
+		cs = append(cs, cd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due action calls: %v", err)
+	}
+	return cs, nil
+}
+
+func parseActionCallRow(r scannable) (cd usecase.ActionCallData, err error) {
+	var row struct {
+		id              string
+		taskID          usecase.TaskID
+		method          string
+		url             string
+		headers         []byte
+		body            string
+		timeoutMs       int64
+		maxAttempts     int
+		completeTask    bool
+		status          action.Status
+		attempts        int
+		statusCode      int
+		response        string
+		latencyMs       int64
+		lastError       string
+		createdTime     *string
+		lastAttemptTime *string
+		nextAttemptTime *string
+	}
+	err = r.Scan(&row.id, &row.taskID, &row.method, &row.url, &row.headers, &row.body, &row.timeoutMs, &row.maxAttempts, &row.completeTask, &row.status, &row.attempts, &row.statusCode, &row.response, &row.latencyMs, &row.lastError, &row.createdTime, &row.lastAttemptTime, &row.nextAttemptTime)
+	if err != nil {
+		return
+	}
+	id, err := action.ParseID(row.id)
+	if err != nil {
+		return cd, fmt.Errorf("error parsing action call id %v: %v", row.id, err)
+	}
+	headers := map[string]string{}
+	if err = json.Unmarshal(row.headers, &headers); err != nil {
+		return cd, fmt.Errorf("error parsing action call id %v headers: %v", row.id, err)
+	}
+	req := action.NewRequest(row.method, row.url, headers, row.body)
+	c := action.NewRawCall(id, req, time.Duration(row.timeoutMs)*time.Millisecond, row.maxAttempts, row.completeTask, row.status, row.attempts, row.statusCode, row.response, time.Duration(row.latencyMs)*time.Millisecond, row.lastError, parseNullTime(row.createdTime), parseNullTime(row.lastAttemptTime), parseNullTime(row.nextAttemptTime))
+	return usecase.ActionCallData{TaskID: row.taskID, Call: c}, nil
+}
+
+// NextAttemptTime retrieves the time the next pending call is due, zero if there are none
+func (r *ActionRepo) NextAttemptTime(ctx context.Context) (time.Time, usecase.Error) {
+	var next *string
+	if err := r.db.QueryRowContext(ctx, "SELECT MIN(next_attempt_time) FROM action_call WHERE status = $1", action.StatusPending).Scan(&next); err != nil {
+		return time.Time{}, usecase.NewError(usecase.ErrUnknown, "error retrieving next action call time: %v", err)
+	}
+	return parseNullTime(next), nil
+}
+
+// Update updates a call's persistent data to the given entity values
+func (r *ActionRepo) Update(ctx context.Context, c *action.Call) usecase.Error {
+	q := "UPDATE action_call SET status = $2, attempts = $3, status_code = $4, response = $5, latency_ms = $6, last_error = $7, last_attempt_time = $8, next_attempt_time = $9 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, c.ID().String(), c.Status(), c.Attempts(), c.StatusCode(), c.Response(), int64(c.Latency()/time.Millisecond), c.LastError(), c.LastAttemptTime(), c.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating action call id %v: %v", c.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no action call found for id = %v", c.ID())
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestActionRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewActionRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	scheduleRepo, _ := NewScheduleRepo(conn)
+
+	a, err := schedule.NewHTTPAction("PUT", "https://example.com/warm", map[string]string{"X-Token": "secret"}, `{"task":"{{.Name}}"}`, 10*time.Second, 3, true)
+	if err != nil {
+		t.Fatalf("NewHTTPAction() error = %v", err)
+	}
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, user.ID{})
+	s.AddTask(schedule.NewRecurringTask("warm cache", "").WithAction(a))
+	sid, ucerr := scheduleRepo.Add(ctx, s)
+	if ucerr != nil {
+		t.Fatalf("ScheduleRepo.Add() error = %v", ucerr)
+	}
+	got, _ := scheduleRepo.Get(ctx, sid)
+	if len(got.Tasks()) != 1 {
+		t.Fatalf("ScheduleRepo.Get() tasks = %v, want 1", got.Tasks())
+	}
+	if ga, ok := got.Tasks()[0].Action(); !ok || !ga.Equal(a) {
+		t.Errorf("ScheduleRepo.Get() action = %v, %v, want %v", ga, ok, a)
+	}
+
+	tsk := task.New("warm cache", "", user.ID{})
+	c, _ := a.NewCall(time.Now(), tsk)
+	tsk.SetAction(c)
+	tid, ucerr := taskRepo.Add(ctx, tsk)
+	if ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+	if tsk.Action() != nil {
+		t.Errorf("TaskRepo.Add() should clear the task's action once it's queued")
+	}
+	manualID, _ := taskRepo.Add(ctx, task.New("manual", "", user.ID{}))
+
+	gc, ucerr := r.GetForTask(ctx, tid)
+	if ucerr != nil {
+		t.Fatalf("ActionRepo.GetForTask() error = %v", ucerr)
+	}
+	if !gc.ID().Equals(c.ID()) || gc.Request().Method() != "PUT" || gc.Request().Body() != `{"task":"warm cache"}` || gc.Request().Headers()["X-Token"] != "secret" || gc.Timeout() != 10*time.Second || gc.MaxAttempts() != 3 || !gc.CompleteTask() || gc.Status() != action.StatusPending {
+		t.Errorf("ActionRepo.GetForTask() = %v, want call %v", gc, c)
+	}
+	if _, ucerr := r.GetForTask(ctx, manualID); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ActionRepo.GetForTask() for a task without an action error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+
+	due, ucerr := r.GetDue(ctx, c.NextAttemptTime(), 10)
+	if ucerr != nil {
+		t.Fatalf("ActionRepo.GetDue() error = %v", ucerr)
+	}
+	if len(due) != 1 || due[0].TaskID != tid || !due[0].Call.ID().Equals(c.ID()) {
+		t.Fatalf("ActionRepo.GetDue() = %v, want call %v for task %v", due, c.ID(), tid)
+	}
+
+	c.Failed(503, "busy", 20*time.Millisecond, "Service Unavailable", webhook.RetryPolicy{MaxAttempts: 8, Delay: time.Hour})
+	if ucerr := r.Update(ctx, c); ucerr != nil {
+		t.Fatalf("ActionRepo.Update() error = %v", ucerr)
+	}
+	if due, _ := r.GetDue(ctx, c.LastAttemptTime(), 10); len(due) != 0 {
+		t.Errorf("ActionRepo.GetDue() = %v, want no calls until the retry is due", due)
+	}
+	if next, _ := r.NextAttemptTime(ctx); !next.Equal(c.NextAttemptTime()) {
+		t.Errorf("ActionRepo.NextAttemptTime() = %v, want %v", next, c.NextAttemptTime())
+	}
+	gc, _ = r.GetForTask(ctx, tid)
+	if gc.Attempts() != 1 || gc.StatusCode() != 503 || gc.Response() != "busy" || gc.Latency() != 20*time.Millisecond || gc.LastError() != "Service Unavailable" {
+		t.Errorf("ActionRepo.GetForTask() after Update() = %v, want the failed attempt recorded", gc)
+	}
+
+	if ucerr := r.Update(ctx, action.NewCall(action.Request{}, 0, 0, false)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ActionRepo.Update() with an unknown call error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
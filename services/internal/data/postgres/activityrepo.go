@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/pqerr"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ActivityRepo handles persisting task activity data
+type ActivityRepo struct {
+	db *tracedDB
+}
+
+// NewActivityRepo instantiates a new ActivityRepo
+func NewActivityRepo(conn DBConn) (repo *ActivityRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &ActivityRepo{db: newTracedDB(conn)}, nil
+}
+
+// GetAllForTask retrieves a task's activity entries, oldest first
+func (r *ActivityRepo) GetAllForTask(ctx context.Context, id usecase.TaskID) ([]usecase.ActivityData, usecase.Error) {
+	q := "SELECT id, task_id, activity_type, comment, created_time, created_by FROM task_activity WHERE task_id = $1 ORDER BY created_time, id"
+	rows, err := r.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving activity for task id %d: %v", id, err)
+	}
+	defer rows.Close()
+
+	as := []usecase.ActivityData{}
+	for rows.Next() {
+		ad, err := parseActivityRow(rows)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing activity row: %v", err)
+		}
+		as = append(as, ad)
+	}
+
+	return as, nil
+}
+
+func parseActivityRow(r scannable) (ad usecase.ActivityData, err error) {
+	var row struct {
+		id           int64
+		taskID       int64
+		activityType task.ActivityType
+		comment      string
+		createdTime  *string
+		createdBy    *string
+	}
+	err = r.Scan(&row.id, &row.taskID, &row.activityType, &row.comment, &row.createdTime, &row.createdBy)
+	if err != nil {
+		return
+	}
+
+	createdBy := user.ID{}
+	if row.createdBy != nil {
+		createdBy, _ = user.ParseID(*row.createdBy)
+	}
+
+	ad.ActivityID = usecase.ActivityID(row.id)
+	ad.TaskID = usecase.TaskID(row.taskID)
+	ad.Activity = task.NewRawActivity(row.activityType, row.comment, parseNullTime(row.createdTime), createdBy)
+	return
+}
+
+// Add appends an activity entry to a task's activity history
+func (r *ActivityRepo) Add(ctx context.Context, id usecase.TaskID, a *task.Activity) (usecase.ActivityID, usecase.Error) {
+	q := "INSERT INTO task_activity (task_id, activity_type, comment, created_time, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	var aid usecase.ActivityID
+	err := r.db.QueryRowContext(ctx, q, id, a.Type(), a.Comment(), a.CreatedTime(), a.CreatedBy().StringPtr()).Scan(&aid)
+	if err != nil {
+		if pqerr.Eq(err, pqerr.ForeignKeyViolation) {
+			return 0, usecase.NewError(usecase.ErrRecordNotFound, "error inserting activity for task id %d: %v", id, err)
+		}
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting activity for task id %d: %v", id, err)
+	}
+
+	return aid, nil
+}
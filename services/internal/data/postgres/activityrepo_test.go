@@ -0,0 +1,86 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestActivityRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewActivityRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u := user.New("test user for activity Add")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
+	taskID, _ := taskRepo.Add(ctx, task.New("t1", "", u.ID()))
+	created := task.NewRawActivity(task.ActivityCreated, "", time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), u.ID())
+	comment := task.NewRawActivity(task.ActivityComment, "a comment", time.Date(2000, 1, 1, 13, 0, 0, 0, time.UTC), u.ID())
+
+	type args struct {
+		id usecase.TaskID
+		a  *task.Activity
+	}
+	tests := []struct {
+		name    string
+		r       *ActivityRepo
+		args    args
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name:    "should add activity entry",
+			r:       r,
+			args:    args{id: taskID, a: created},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should add comment entry",
+			r:       r,
+			args:    args{id: taskID, a: comment},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should return ErrRecordNotFound for unknown task",
+			r:       r,
+			args:    args{id: 9999, a: comment},
+			wantErr: usecase.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.r.Add(ctx, tt.args.id, tt.args.a)
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ActivityRepo.Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, err := r.GetAllForTask(ctx, taskID)
+	if err != nil {
+		t.Fatalf("ActivityRepo.GetAllForTask() error = %v", err)
+	}
+	want := []*task.Activity{created, comment}
+	if len(got) != len(want) {
+		t.Fatalf("ActivityRepo.GetAllForTask() = %v, want %d entries", got, len(want))
+	}
+	for i, ad := range got {
+		a := ad.Activity
+		if ad.TaskID != taskID || a.Type() != want[i].Type() || a.Comment() != want[i].Comment() || !a.CreatedTime().Equal(want[i].CreatedTime()) || !a.CreatedBy().Equals(want[i].CreatedBy()) {
+			t.Errorf("ActivityRepo.GetAllForTask() entry %d = %v, want %v", i, a, want[i])
+		}
+	}
+}
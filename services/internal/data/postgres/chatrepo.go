@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ChatRepo handles persisting chat messages queued for schedules' chat channels
+type ChatRepo struct {
+	db *tracedDB
+}
+
+// NewChatRepo instantiates a new ChatRepo
+func NewChatRepo(conn DBConn) (repo *ChatRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &ChatRepo{db: newTracedDB(conn)}, nil
+}
+
+// Add queues a chat message about a task, returning an ErrDuplicateRecord error if a message of the same kind was already queued for it
+func (r *ChatRepo) Add(ctx context.Context, m *chat.Message, taskID usecase.TaskID, scheduleID usecase.ScheduleID) usecase.Error {
+	q := `INSERT INTO chat_message (id, task_id, schedule_id, kind, url, payload, status, attempts, status_code, last_error, created_time, last_attempt_time, next_attempt_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (task_id, kind) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, q, m.ID().String(), taskID, scheduleID, string(m.Kind()), m.URL(), string(m.Payload()), m.Status(), m.Attempts(), m.StatusCode(), m.LastError(), m.CreatedTime(), m.LastAttemptTime(), m.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting new %v chat message for task id %v: %v", m.Kind(), taskID, err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "%v chat message for task id %v already exists", m.Kind(), taskID)
+	}
+	return nil
+}
+
+// GetDue retrieves pending messages due to be attempted at or before the given time, oldest first
+func (r *ChatRepo) GetDue(ctx context.Context, before time.Time, limit int) ([]*chat.Message, usecase.Error) {
+	q := "SELECT id, kind, url, payload, status, attempts, status_code, last_error, created_time, last_attempt_time, next_attempt_time FROM chat_message WHERE status = $1 AND next_attempt_time <= $2 ORDER BY next_attempt_time, id LIMIT $3"
+	rows, err := r.db.QueryContext(ctx, q, chat.StatusPending, before, limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due chat messages: %v", err)
+	}
+	defer rows.Close()
+
+	ms := []*chat.Message{}
+	for rows.Next() {
+		var row struct {
+			id              string
+			kind            string
+			url             string
+			payload         []byte
+			status          chat.Status
+			attempts        int
+			statusCode      int
+			lastError       string
+			createdTime     *string
+			lastAttemptTime *string
+			nextAttemptTime *string
+		}
+		if err := rows.Scan(&row.id, &row.kind, &row.url, &row.payload, &row.status, &row.attempts, &row.statusCode, &row.lastError, &row.createdTime, &row.lastAttemptTime, &row.nextAttemptTime); err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing chat message row: %v", err)
+		}
+		id, err := chat.ParseID(row.id)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing chat message id %v: %v", row.id, err)
+		}
+		ms = append(ms, chat.NewRawMessage(id, row.url, chat.Kind(row.kind), row.payload, row.status, row.attempts, row.statusCode, row.lastError, parseNullTime(row.createdTime), parseNullTime(row.lastAttemptTime), parseNullTime(row.nextAttemptTime)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due chat messages: %v", err)
+	}
+	return ms, nil
+}
+
+// NextAttemptTime retrieves the time the next pending message is due, zero if there are none
+func (r *ChatRepo) NextAttemptTime(ctx context.Context) (time.Time, usecase.Error) {
+	var next *string
+	if err := r.db.QueryRowContext(ctx, "SELECT MIN(next_attempt_time) FROM chat_message WHERE status = $1", chat.StatusPending).Scan(&next); err != nil {
+		return time.Time{}, usecase.NewError(usecase.ErrUnknown, "error retrieving next chat message time: %v", err)
+	}
+	return parseNullTime(next), nil
+}
+
+// Update updates a chat message's persistent data to the given entity values
+func (r *ChatRepo) Update(ctx context.Context, m *chat.Message) usecase.Error {
+	q := "UPDATE chat_message SET status = $2, attempts = $3, status_code = $4, last_error = $5, last_attempt_time = $6, next_attempt_time = $7 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, m.ID().String(), m.Status(), m.Attempts(), m.StatusCode(), m.LastError(), m.LastAttemptTime(), m.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating chat message id %v: %v", m.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no chat message found for id = %v", m.ID())
+	}
+	return nil
+}
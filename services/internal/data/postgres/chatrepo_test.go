@@ -0,0 +1,96 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestChatRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewChatRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	scheduleRepo, _ := NewScheduleRepo(conn)
+
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, user.ID{})
+	s.SetChatURL("https://chat.example.com/hooks/1")
+	sid, ucerr := scheduleRepo.Add(ctx, s)
+	if ucerr != nil {
+		t.Fatalf("ScheduleRepo.Add() error = %v", ucerr)
+	}
+	if got, _ := scheduleRepo.Get(ctx, sid); got.ChatURL() != s.ChatURL() {
+		t.Errorf("ScheduleRepo.Get() chat URL = %v, want %v", got.ChatURL(), s.ChatURL())
+	}
+
+	due := time.Date(1990, 1, 1, 12, 0, 0, 0, time.UTC)
+	tsk := task.New("generated", "", user.ID{})
+	tsk.SetSchedule(int64(sid))
+	tsk.SetDueTime(due)
+	tid, ucerr := taskRepo.Add(ctx, tsk)
+	if ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+	manual := task.New("manual", "", user.ID{})
+	manual.SetDueTime(due.Add(time.Hour))
+	manualID, _ := taskRepo.Add(ctx, manual)
+	taskRepo.Add(ctx, task.New("not due", "", user.ID{}))
+
+	ts, ucerr := taskRepo.GetAllDue(ctx, due.Add(-time.Minute), due.Add(time.Hour))
+	if ucerr != nil {
+		t.Fatalf("TaskRepo.GetAllDue() error = %v", ucerr)
+	}
+	if len(ts) != 2 || ts[tid] == nil || ts[manualID] == nil {
+		t.Fatalf("TaskRepo.GetAllDue() = %v, want tasks %v and %v", ts, tid, manualID)
+	}
+	if ts[tid].Schedule() != int64(sid) || ts[manualID].Schedule() != 0 {
+		t.Errorf("TaskRepo.GetAllDue() schedules = %v and %v, want %v and 0", ts[tid].Schedule(), ts[manualID].Schedule(), sid)
+	}
+
+	m := chat.NewMessage(s.ChatURL(), chat.KindOverdue, []byte(`{"text":"generated"}`))
+	if ucerr := r.Add(ctx, m, tid, sid); ucerr != nil {
+		t.Fatalf("ChatRepo.Add() error = %v", ucerr)
+	}
+	if ucerr := r.Add(ctx, chat.NewMessage(s.ChatURL(), chat.KindOverdue, []byte(`{}`)), tid, sid); ucerr == nil || ucerr.Code() != usecase.ErrDuplicateRecord {
+		t.Errorf("ChatRepo.Add() of the same kind for a task error = %v, want %v", ucerr, usecase.ErrDuplicateRecord)
+	}
+
+	ms, ucerr := r.GetDue(ctx, m.NextAttemptTime(), 10)
+	if ucerr != nil {
+		t.Fatalf("ChatRepo.GetDue() error = %v", ucerr)
+	}
+	if len(ms) != 1 || !ms[0].ID().Equals(m.ID()) || ms[0].Kind() != chat.KindOverdue || ms[0].URL() != m.URL() || string(ms[0].Payload()) != string(m.Payload()) {
+		t.Fatalf("ChatRepo.GetDue() = %v, want message %v", ms, m.ID())
+	}
+
+	ms[0].Failed(500, "Internal Server Error", webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Hour})
+	if ucerr := r.Update(ctx, ms[0]); ucerr != nil {
+		t.Fatalf("ChatRepo.Update() error = %v", ucerr)
+	}
+	if ms, _ := r.GetDue(ctx, m.NextAttemptTime(), 10); len(ms) != 0 {
+		t.Errorf("ChatRepo.GetDue() = %v, want no messages until the retry is due", ms)
+	}
+	if next, _ := r.NextAttemptTime(ctx); next.IsZero() {
+		t.Errorf("ChatRepo.NextAttemptTime() = %v, want the retry time", next)
+	}
+	if ucerr := r.Update(ctx, chat.NewMessage("", chat.KindOverdue, nil)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ChatRepo.Update() with an unknown message error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+
+	"github.com/lib/pq"
+)
+
+// CommandRepo handles persisting command runs queued for generated tasks
+type CommandRepo struct {
+	db *tracedDB
+}
+
+// NewCommandRepo instantiates a new CommandRepo
+func NewCommandRepo(conn DBConn) (repo *CommandRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &CommandRepo{db: newTracedDB(conn)}, nil
+}
+
+const commandRunSelectClause = "SELECT id, task_id, program, args, timeout_ms, status, exit_code, stdout, stderr, last_error, created_time, started_time, finished_time FROM command_run"
+
+// addCommandRun queues a command run for a new task, a nil run isn't stored
+// it should be run in the same transaction as the task is added in, so either both or neither are persisted
+func addCommandRun(ctx context.Context, db dbtx, taskID usecase.TaskID, r *command.Run) error {
+	if r == nil {
+		return nil
+	}
+	q := "INSERT INTO command_run (id, task_id, program, args, timeout_ms, status, exit_code, stdout, stderr, last_error, created_time, started_time, finished_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)"
+	_, err := db.ExecContext(ctx, q, r.ID().String(), taskID, r.Program(), pq.Array(r.Args()), int64(r.Timeout()/time.Millisecond), r.Status(), r.ExitCode(), r.Stdout(), r.Stderr(), r.LastError(), r.CreatedTime(), r.StartedTime(), r.FinishedTime())
+	return err
+}
+
+// GetForTask retrieves the run queued for a task
+func (r *CommandRepo) GetForTask(ctx context.Context, taskID usecase.TaskID) (*command.Run, usecase.Error) {
+	rd, err := parseCommandRunRow(r.db.QueryRowContext(ctx, commandRunSelectClause+" WHERE task_id = $1", taskID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, usecase.NewError(usecase.ErrRecordNotFound, "no command run found for task id = %v", taskID)
+		}
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving command run for task id %v: %v", taskID, err)
+	}
+	return rd.Run, nil
+}
+
+// GetPending retrieves runs that haven't been started, oldest first
+func (r *CommandRepo) GetPending(ctx context.Context, limit int) ([]usecase.CommandRunData, usecase.Error) {
+	q := commandRunSelectClause + " WHERE status = $1 ORDER BY created_time, task_id LIMIT $2"
+	rows, err := r.db.QueryContext(ctx, q, command.StatusPending, limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving pending command runs: %v", err)
+	}
+	defer rows.Close()
+
+	rs := []usecase.CommandRunData{}
+	for rows.Next() {
+		rd, err := parseCommandRunRow(rows)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing command run row: %v", err)
+		}
+		rs = append(rs, rd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving pending command runs: %v", err)
+	}
+	return rs, nil
+}
+
+func parseCommandRunRow(r scannable) (rd usecase.CommandRunData, err error) {
+	var row struct {
+		id           string
+		taskID       usecase.TaskID
+		program      string
+		args         []string
+		timeoutMs    int64
+		status       command.Status
+		exitCode     int
+		stdout       string
+		stderr       string
+		lastError    string
+		createdTime  *string
+		startedTime  *string
+		finishedTime *string
+	}
+	err = r.Scan(&row.id, &row.taskID, &row.program, pq.Array(&row.args), &row.timeoutMs, &row.status, &row.exitCode, &row.stdout, &row.stderr, &row.lastError, &row.createdTime, &row.startedTime, &row.finishedTime)
+	if err != nil {
+		return
+	}
+	id, err := command.ParseID(row.id)
+	if err != nil {
+		return rd, fmt.Errorf("error parsing command run id %v: %v", row.id, err)
+	}
+	run := command.NewRawRun(id, row.program, row.args, time.Duration(row.timeoutMs)*time.Millisecond, row.status, row.exitCode, row.stdout, row.stderr, row.lastError, parseNullTime(row.createdTime), parseNullTime(row.startedTime), parseNullTime(row.finishedTime))
+	return usecase.CommandRunData{TaskID: row.taskID, Run: run}, nil
+}
+
+// Start saves a started run's status and start time, only if it's still pending
+// returns false if the run isn't pending, because another instance already started it
+func (r *CommandRepo) Start(ctx context.Context, run *command.Run) (bool, usecase.Error) {
+	q := "UPDATE command_run SET status = $2, started_time = $3 WHERE id = $1 AND status = $4"
+	res, err := r.db.ExecContext(ctx, q, run.ID().String(), run.Status(), run.StartedTime(), command.StatusPending)
+	if err != nil {
+		return false, usecase.NewError(usecase.ErrUnknown, "error starting command run id %v: %v", run.ID(), err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, usecase.NewError(usecase.ErrUnknown, "error starting command run id %v: %v", run.ID(), err)
+	}
+	return count == 1, nil
+}
+
+// Update updates a run's persistent data to the given entity values
+func (r *CommandRepo) Update(ctx context.Context, run *command.Run) usecase.Error {
+	q := "UPDATE command_run SET status = $2, exit_code = $3, stdout = $4, stderr = $5, last_error = $6, started_time = $7, finished_time = $8 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, run.ID().String(), run.Status(), run.ExitCode(), run.Stdout(), run.Stderr(), run.LastError(), run.StartedTime(), run.FinishedTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating command run id %v: %v", run.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no command run found for id = %v", run.ID())
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestCommandRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewCommandRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	scheduleRepo, _ := NewScheduleRepo(conn)
+
+	c, err := schedule.NewCommand("backup", []string{"--dest", "/var/backups/my files"}, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, user.ID{})
+	s.AddTask(schedule.NewRecurringTask("back up", "").WithCommand(c))
+	sid, ucerr := scheduleRepo.Add(ctx, s)
+	if ucerr != nil {
+		t.Fatalf("ScheduleRepo.Add() error = %v", ucerr)
+	}
+	got, _ := scheduleRepo.Get(ctx, sid)
+	if len(got.Tasks()) != 1 {
+		t.Fatalf("ScheduleRepo.Get() tasks = %v, want 1", got.Tasks())
+	}
+	if gc, ok := got.Tasks()[0].Command(); !ok || !gc.Equal(c) {
+		t.Errorf("ScheduleRepo.Get() command = %v, %v, want %v", gc, ok, c)
+	}
+
+	tsk := task.New("back up", "", user.ID{})
+	run := c.NewRun()
+	tsk.SetCommand(run)
+	tid, ucerr := taskRepo.Add(ctx, tsk)
+	if ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+	if tsk.Command() != nil {
+		t.Errorf("TaskRepo.Add() should clear the task's command once it's queued")
+	}
+	manualID, _ := taskRepo.Add(ctx, task.New("manual", "", user.ID{}))
+
+	gr, ucerr := r.GetForTask(ctx, tid)
+	if ucerr != nil {
+		t.Fatalf("CommandRepo.GetForTask() error = %v", ucerr)
+	}
+	if !gr.ID().Equals(run.ID()) || gr.Program() != "backup" || strings.Join(gr.Args(), "|") != "--dest|/var/backups/my files" || gr.Timeout() != time.Minute || gr.Status() != command.StatusPending {
+		t.Errorf("CommandRepo.GetForTask() = %v, want run %v", gr, run)
+	}
+	if _, ucerr := r.GetForTask(ctx, manualID); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("CommandRepo.GetForTask() for a task without a command error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+
+	pending, ucerr := r.GetPending(ctx, 10)
+	if ucerr != nil {
+		t.Fatalf("CommandRepo.GetPending() error = %v", ucerr)
+	}
+	if len(pending) != 1 || pending[0].TaskID != tid || !pending[0].Run.ID().Equals(run.ID()) {
+		t.Fatalf("CommandRepo.GetPending() = %v, want run %v for task %v", pending, run.ID(), tid)
+	}
+
+	second, _ := r.GetPending(ctx, 10)
+	run.Start()
+	if claimed, ucerr := r.Start(ctx, run); ucerr != nil || !claimed {
+		t.Fatalf("CommandRepo.Start() = %v, %v, want true", claimed, ucerr)
+	}
+	second[0].Run.Start()
+	if claimed, ucerr := r.Start(ctx, second[0].Run); ucerr != nil || claimed {
+		t.Errorf("CommandRepo.Start() for a run already started = %v, %v, want false", claimed, ucerr)
+	}
+	if pending, _ := r.GetPending(ctx, 10); len(pending) != 0 {
+		t.Errorf("CommandRepo.GetPending() = %v, want no runs once it's started", pending)
+	}
+	run.Finish(3, "copied 2 files", "disk full", "")
+	if ucerr := r.Update(ctx, run); ucerr != nil {
+		t.Fatalf("CommandRepo.Update() error = %v", ucerr)
+	}
+	if pending, _ := r.GetPending(ctx, 10); len(pending) != 0 {
+		t.Errorf("CommandRepo.GetPending() = %v, want no runs once it's finished", pending)
+	}
+	gr, _ = r.GetForTask(ctx, tid)
+	if gr.Status() != command.StatusFailed || gr.ExitCode() != 3 || gr.Stdout() != "copied 2 files" || gr.Stderr() != "disk full" || gr.StartedTime().IsZero() || gr.FinishedTime().IsZero() {
+		t.Errorf("CommandRepo.GetForTask() after Update() = %v, want the failed run recorded", gr)
+	}
+
+	if ucerr := r.Update(ctx, command.NewRun("backup", nil, 0)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("CommandRepo.Update() with an unknown run error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// CredentialRepo handles persisting local user credential data
+type CredentialRepo struct {
+	db *tracedDB
+}
+
+// NewCredentialRepo instantiates a new CredentialRepo
+func NewCredentialRepo(conn DBConn) (repo *CredentialRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &CredentialRepo{db: newTracedDB(conn)}, nil
+}
+
+// Get retrieves a credential, given its username
+func (r *CredentialRepo) Get(ctx context.Context, username string) (*credential.Credential, usecase.Error) {
+	q := "SELECT user_id, password_hash, version, reset_hash, reset_expires_time FROM local_credential WHERE username = $1"
+	var row struct {
+		userID       string
+		passwordHash string
+		version      int
+		resetHash    sql.NullString
+		resetExpires *string
+	}
+	err := r.db.QueryRowContext(ctx, q, username).Scan(&row.userID, &row.passwordHash, &row.version, &row.resetHash, &row.resetExpires)
+	if err == sql.ErrNoRows {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no credential found with username = %v", username)
+	}
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving credential for username %v: %v", username, err)
+	}
+	uid, err := user.ParseID(row.userID)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing user ID for username %v: %v", username, err)
+	}
+	return credential.NewRaw(username, uid, row.passwordHash, row.version, row.resetHash.String, parseNullTime(row.resetExpires)), nil
+}
+
+// Add adds a credential to the persistence layer
+func (r *CredentialRepo) Add(ctx context.Context, c *credential.Credential) usecase.Error {
+	q := "INSERT INTO local_credential (username, user_id, password_hash, version, reset_hash, reset_expires_time) VALUES ($1, $2, $3, $4, $5, $6)"
+	if _, err := r.db.ExecContext(ctx, q, c.Username(), c.UserID().String(), c.PasswordHash(), c.Version(), nullString(c.ResetHash()), c.ResetExpires()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting new credential: %v", err)
+	}
+	return nil
+}
+
+// Update updates a credential's persistent data to the given entity values
+func (r *CredentialRepo) Update(ctx context.Context, c *credential.Credential) usecase.Error {
+	q := "UPDATE local_credential SET password_hash = $2, version = $3, reset_hash = $4, reset_expires_time = $5 WHERE username = $1"
+	res, err := r.db.ExecContext(ctx, q, c.Username(), c.PasswordHash(), c.Version(), nullString(c.ResetHash()), c.ResetExpires())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating credential for username %v: %v", c.Username(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no credential found for username = %v", c.Username())
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
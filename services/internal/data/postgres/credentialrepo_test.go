@@ -0,0 +1,53 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestCredentialRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewCredentialRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u := user.New("local user")
+	userRepo.AddExternal(ctx, u, credential.ProviderID, "jane")
+	c, _ := credential.New("jane", u.ID(), "password1")
+
+	if ucerr := r.Add(ctx, c); ucerr != nil {
+		t.Fatalf("CredentialRepo.Add() error = %v", ucerr)
+	}
+	got, ucerr := r.Get(ctx, "jane")
+	if ucerr != nil {
+		t.Fatalf("CredentialRepo.Get() error = %v", ucerr)
+	}
+	if !got.UserID().Equals(u.ID()) || !got.CheckPassword("password1") || got.Version() != 1 || got.ResetHash() != "" || !got.ResetExpires().IsZero() {
+		t.Errorf("CredentialRepo.Get() = %v, want %v", got, c)
+	}
+
+	resetToken, _ := c.NewResetToken(time.Hour)
+	if ucerr := r.Update(ctx, c); ucerr != nil {
+		t.Fatalf("CredentialRepo.Update() error = %v", ucerr)
+	}
+	got, _ = r.Get(ctx, "jane")
+	if err := got.ResetPassword(resetToken, "password2"); err != nil {
+		t.Errorf("CredentialRepo.Get() reset token not persisted: %v", err)
+	}
+	if _, ucerr := r.Get(ctx, "unknown"); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("CredentialRepo.Get() error = %v, wantErr %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
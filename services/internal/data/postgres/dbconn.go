@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -8,13 +9,14 @@ import (
 	"time"
 
 	_ "github.com/lib/pq" // add postgres DB driver
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
 
-// Logger interface needed for postgres log messages
+// Logger interface needed for structured postgres log messages, with key/value pairs after the message
 type Logger interface {
-	Print(v ...interface{})
-	Printf(format string, v ...interface{})
-	Println(v ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
 }
 
 type scannable interface {
@@ -23,6 +25,30 @@ type scannable interface {
 
 const dbTimeFormat = time.RFC3339Nano
 
+// parseNullTime parses a nullable DB time value, returning the zero value if it is null or invalid
+func parseNullTime(val *string) time.Time {
+	if val == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(dbTimeFormat, *val)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseNullUserID parses a nullable DB user ID value, returning the empty ID if it is null or invalid
+func parseNullUserID(val *string) user.ID {
+	if val == nil {
+		return user.ID{}
+	}
+	id, err := user.ParseID(*val)
+	if err != nil {
+		return user.ID{}
+	}
+	return id
+}
+
 // DBConn contains DB connection data
 type DBConn struct {
 	Host              string
@@ -45,6 +71,19 @@ func (conn *DBConn) Close() error {
 	return conn.DB.Close()
 }
 
+// pingTimeout limits how long Ping waits for the DB to respond
+const pingTimeout = 2 * time.Second
+
+// Ping checks the DB connection is alive, it returns an error if the DB hasn't been connected
+func (conn *DBConn) Ping() error {
+	if conn.DB == nil {
+		return fmt.Errorf("db %s is not connected", conn.Name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return conn.DB.PingContext(ctx)
+}
+
 // NewDBConn creates struct with default DB connection info, and overrides with environment variables if set
 func NewDBConn(l Logger, appName string) DBConn {
 
@@ -90,7 +129,7 @@ func NewDBConn(l Logger, appName string) DBConn {
 // Connect opens and ping-checks a DB connection
 func (conn *DBConn) Connect() (err error) {
 	if conn.DB == nil {
-		conn.l.Printf("connecting to db %s as %s...", conn.Name, conn.User)
+		conn.l.Info("connecting to db", "db", conn.Name, "user", conn.User)
 		db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s application_name=%s sslmode=disable", conn.Host, conn.Port, conn.User, conn.Password, conn.Name, conn.AppName))
 		if err != nil {
 			err = fmt.Errorf("error opening db: %v", err)
@@ -98,7 +137,7 @@ func (conn *DBConn) Connect() (err error) {
 		}
 		conn.DB = db
 	} else {
-		conn.l.Printf("already connected to db %s", conn.Name)
+		conn.l.Info("already connected to db", "db", conn.Name)
 	}
 
 	// Ping & retry if needed
@@ -107,23 +146,29 @@ func (conn *DBConn) Connect() (err error) {
 		if err == nil {
 			break
 		}
-		conn.l.Printf("attempt %d/%d couldn't ping db: %v", attempts+1, conn.MaxRetryAttempts, err)
+		conn.l.Warn("couldn't ping db", "db", conn.Name, "attempt", attempts+1, "max_attempts", conn.MaxRetryAttempts, "error", err)
 		time.Sleep(time.Duration(conn.RetrySleepSeconds) * time.Second)
 	}
 
 	return err
 }
 
-// Setup sets up initial DB schema
+// Setup sets up initial DB schema if needed, then applies any pending migrations
 func (conn *DBConn) Setup() (setup bool, err error) {
 
 	_, err = conn.DB.Exec(`SELECT 1 FROM task LIMIT 1; SELECT 1 FROM schedule LIMIT 1; SELECT 1 FROM recurring_task;`)
-	if err == nil {
-		setup = false
-		return // no error, table was already setup
+	if err != nil {
+		setup = true
+		if err = conn.create(); err != nil {
+			return
+		}
 	}
 
-	setup = true
+	err = conn.Migrate()
+	return
+}
+
+func (conn *DBConn) create() (err error) {
 	_, err = conn.DB.Exec(`
 		CREATE TABLE user_account (
 			id uuid PRIMARY KEY,
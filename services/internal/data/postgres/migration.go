@@ -0,0 +1,400 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// migration is a single versioned change to the DB schema, applied after the initial setup
+type migration struct {
+	version     int
+	description string
+	command     string
+}
+
+// migrations lists all schema changes in the order they must be applied
+var migrations = []migration{
+	{
+		version:     1,
+		description: "full-text search vectors for tasks and recurring tasks",
+		command: `
+			ALTER TABLE task ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B')
+				) STORED;
+			CREATE INDEX task_search_vector_idx ON task USING GIN (search_vector);
+			ALTER TABLE recurring_task ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B')
+				) STORED;
+			CREATE INDEX recurring_task_search_vector_idx ON recurring_task USING GIN (search_vector);`,
+	},
+	{
+		version:     2,
+		description: "task due times and priorities",
+		command: `
+			ALTER TABLE task ADD COLUMN due_time TIMESTAMPTZ;
+			ALTER TABLE task ADD COLUMN priority smallint NOT NULL DEFAULT 0;
+			ALTER TABLE recurring_task ADD COLUMN due_offset_seconds bigint;
+			ALTER TABLE recurring_task ADD COLUMN priority smallint NOT NULL DEFAULT 0;`,
+	},
+	{
+		version:     3,
+		description: "normalized task and recurring task tags",
+		command: `
+			CREATE TABLE tag (
+				id SERIAL PRIMARY KEY,
+				created_by uuid REFERENCES user_account(id) ON DELETE CASCADE,
+				name character varying(50) NOT NULL,
+				UNIQUE (created_by, name)
+				);
+			CREATE TABLE task_tag (
+				task_id integer REFERENCES task(id) ON DELETE CASCADE,
+				tag_id integer REFERENCES tag(id) ON DELETE CASCADE,
+				PRIMARY KEY (task_id, tag_id)
+				);
+			CREATE TABLE recurring_task_tag (
+				recurring_task_id integer REFERENCES recurring_task(id) ON DELETE CASCADE,
+				tag_id integer REFERENCES tag(id) ON DELETE CASCADE,
+				PRIMARY KEY (recurring_task_id, tag_id)
+				);
+			CREATE INDEX task_tag_tag_id_idx ON task_tag (tag_id);
+			CREATE INDEX recurring_task_tag_tag_id_idx ON recurring_task_tag (tag_id);`,
+	},
+	{
+		version:     4,
+		description: "task and recurring task checklists",
+		command: `
+			CREATE TABLE task_checklist_item (
+				task_id integer REFERENCES task(id) ON DELETE CASCADE,
+				position smallint NOT NULL,
+				name character varying(200) NOT NULL,
+				checked_time TIMESTAMPTZ,
+				PRIMARY KEY (task_id, position)
+				);
+			ALTER TABLE task ADD COLUMN auto_complete boolean NOT NULL DEFAULT FALSE;
+			ALTER TABLE recurring_task ADD COLUMN checklist character varying(200)[];
+			ALTER TABLE recurring_task ADD COLUMN auto_complete boolean NOT NULL DEFAULT FALSE;`,
+	},
+	{
+		version:     5,
+		description: "append-only task activity history",
+		command: `
+			CREATE TABLE task_activity (
+				id SERIAL PRIMARY KEY,
+				task_id integer REFERENCES task(id) ON DELETE CASCADE,
+				activity_type smallint NOT NULL,
+				comment character varying(2000) NOT NULL DEFAULT '',
+				created_time TIMESTAMPTZ NOT NULL,
+				created_by uuid REFERENCES user_account(id)
+				);
+			CREATE INDEX task_activity_task_id_idx ON task_activity (task_id);`,
+	},
+	{
+		version:     6,
+		description: "shared team workspaces",
+		command: `
+			CREATE TABLE workspace (
+				id uuid PRIMARY KEY,
+				name character varying(100) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				created_by uuid REFERENCES user_account(id)
+				);
+			CREATE TABLE workspace_member (
+				workspace_id uuid REFERENCES workspace(id) ON DELETE CASCADE,
+				user_id uuid REFERENCES user_account(id) ON DELETE CASCADE,
+				role smallint NOT NULL,
+				PRIMARY KEY (workspace_id, user_id)
+				);
+			CREATE INDEX workspace_member_user_id_idx ON workspace_member (user_id);
+			ALTER TABLE task ADD COLUMN workspace_id uuid REFERENCES workspace(id);
+			ALTER TABLE schedule ADD COLUMN workspace_id uuid REFERENCES workspace(id);
+			CREATE INDEX task_workspace_id_idx ON task (workspace_id);
+			CREATE INDEX schedule_workspace_id_idx ON schedule (workspace_id);`,
+	},
+	{
+		version:     7,
+		description: "task assignees and recurring task rotations",
+		command: `
+			ALTER TABLE task ADD COLUMN assignee_id uuid REFERENCES user_account(id);
+			CREATE INDEX task_assignee_id_idx ON task (assignee_id);
+			ALTER TABLE recurring_task ADD COLUMN rotation uuid[];
+			ALTER TABLE recurring_task ADD COLUMN rotation_strategy smallint NOT NULL DEFAULT 0;
+			ALTER TABLE recurring_task ADD COLUMN rotation_state bigint[];`,
+	},
+	{
+		version:     8,
+		description: "user roles and role permissions",
+		command: `
+			ALTER TABLE user_account ADD COLUMN role smallint NOT NULL DEFAULT 2;
+			CREATE TABLE role_permission (
+				role smallint PRIMARY KEY,
+				permissions bigint NOT NULL
+			);`,
+	},
+	{
+		version:     9,
+		description: "personal access tokens",
+		command: `
+			CREATE TABLE access_token (
+				id uuid PRIMARY KEY,
+				user_id uuid NOT NULL REFERENCES user_account(id),
+				name varchar(100) NOT NULL,
+				token_hash char(64) NOT NULL UNIQUE,
+				scopes text[] NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				expires_time TIMESTAMPTZ,
+				revoked_time TIMESTAMPTZ
+			);
+			CREATE INDEX access_token_user_id_idx ON access_token (user_id);`,
+	},
+	{
+		version:     10,
+		description: "local user credentials",
+		command: `
+			CREATE TABLE local_credential (
+				username varchar(64) PRIMARY KEY,
+				user_id uuid NOT NULL UNIQUE REFERENCES user_account(id),
+				password_hash varchar(60) NOT NULL,
+				version integer NOT NULL,
+				reset_hash char(64),
+				reset_expires_time TIMESTAMPTZ
+			);`,
+	},
+	{
+		version:     11,
+		description: "webhooks",
+		command: `
+			CREATE TABLE webhook (
+				id uuid PRIMARY KEY,
+				user_id uuid NOT NULL REFERENCES user_account(id),
+				url varchar(2000) NOT NULL,
+				events text[] NOT NULL,
+				secret varchar(200) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				removed_time TIMESTAMPTZ
+			);
+			CREATE INDEX webhook_user_id_idx ON webhook (user_id);
+			CREATE TABLE webhook_delivery (
+				id uuid PRIMARY KEY,
+				webhook_id uuid NOT NULL REFERENCES webhook(id),
+				event varchar(50) NOT NULL,
+				payload json NOT NULL,
+				status smallint NOT NULL,
+				attempts integer NOT NULL,
+				status_code integer NOT NULL,
+				last_error varchar(1000) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				last_attempt_time TIMESTAMPTZ,
+				next_attempt_time TIMESTAMPTZ
+			);
+			CREATE INDEX webhook_delivery_webhook_id_idx ON webhook_delivery (webhook_id, created_time);
+			CREATE INDEX webhook_delivery_due_idx ON webhook_delivery (next_attempt_time) WHERE status = 0;`,
+	},
+	{
+		version:     12,
+		description: "event outbox",
+		command: `
+			CREATE TABLE event_outbox (
+				id uuid PRIMARY KEY,
+				type varchar(50) NOT NULL,
+				task_id integer REFERENCES task(id),
+				schedule_id integer REFERENCES schedule(id),
+				status smallint NOT NULL,
+				attempts integer NOT NULL,
+				last_error varchar(1000) NOT NULL,
+				occurred_time TIMESTAMPTZ NOT NULL,
+				published_time TIMESTAMPTZ
+			);
+			CREATE INDEX event_outbox_pending_idx ON event_outbox (occurred_time) WHERE status = 0;`,
+	},
+	{
+		version:     13,
+		description: "notification preferences",
+		command: `
+			CREATE TABLE notification_preference (
+				user_id uuid PRIMARY KEY REFERENCES user_account(id),
+				email varchar(254) NOT NULL,
+				task_generated boolean NOT NULL,
+				digest boolean NOT NULL,
+				digest_hour smallint NOT NULL,
+				last_digest_time TIMESTAMPTZ
+			);`,
+	},
+	{
+		version:     14,
+		description: "chat notifications",
+		command: `
+			ALTER TABLE task ADD COLUMN schedule_id integer REFERENCES schedule(id);
+			ALTER TABLE schedule ADD COLUMN chat_url varchar(2000) NOT NULL DEFAULT '';
+			CREATE INDEX task_due_time_idx ON task (due_time);
+			CREATE TABLE chat_message (
+				id uuid PRIMARY KEY,
+				task_id integer NOT NULL REFERENCES task(id),
+				schedule_id integer NOT NULL REFERENCES schedule(id),
+				kind varchar(20) NOT NULL,
+				url varchar(2000) NOT NULL,
+				payload json NOT NULL,
+				status smallint NOT NULL,
+				attempts integer NOT NULL,
+				status_code integer NOT NULL,
+				last_error varchar(1000) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				last_attempt_time TIMESTAMPTZ,
+				next_attempt_time TIMESTAMPTZ,
+				UNIQUE (task_id, kind)
+			);
+			CREATE INDEX chat_message_due_idx ON chat_message (next_attempt_time) WHERE status = 0;`,
+	},
+	{
+		version:     15,
+		description: "scheduled HTTP actions",
+		command: `
+			ALTER TABLE recurring_task ADD COLUMN action json;
+			CREATE TABLE action_call (
+				id uuid PRIMARY KEY,
+				task_id integer NOT NULL UNIQUE REFERENCES task(id),
+				method varchar(10) NOT NULL,
+				url varchar(2000) NOT NULL,
+				headers json NOT NULL,
+				body text NOT NULL,
+				timeout_ms bigint NOT NULL,
+				max_attempts integer NOT NULL,
+				complete_task boolean NOT NULL,
+				status smallint NOT NULL,
+				attempts integer NOT NULL,
+				status_code integer NOT NULL,
+				response varchar(2000) NOT NULL,
+				latency_ms bigint NOT NULL,
+				last_error varchar(1000) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				last_attempt_time TIMESTAMPTZ,
+				next_attempt_time TIMESTAMPTZ
+			);
+			CREATE INDEX action_call_due_idx ON action_call (next_attempt_time) WHERE status = 0;`,
+	},
+	{
+		version:     16,
+		description: "scheduled commands",
+		command: `
+			ALTER TABLE recurring_task ADD COLUMN command json;
+			CREATE TABLE command_run (
+				id uuid PRIMARY KEY,
+				task_id integer NOT NULL UNIQUE REFERENCES task(id),
+				program varchar(200) NOT NULL,
+				args text[] NOT NULL,
+				timeout_ms bigint NOT NULL,
+				status smallint NOT NULL,
+				exit_code integer NOT NULL,
+				stdout text NOT NULL,
+				stderr text NOT NULL,
+				last_error varchar(1000) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				started_time TIMESTAMPTZ,
+				finished_time TIMESTAMPTZ
+			);
+			CREATE INDEX command_run_pending_idx ON command_run (created_time) WHERE status = 0;`,
+	},
+	{
+		version:     17,
+		description: "append-only schedule run history",
+		command: `
+			CREATE TABLE schedule_run (
+				id BIGSERIAL PRIMARY KEY,
+				schedule_id integer NOT NULL REFERENCES schedule(id) ON DELETE CASCADE,
+				run_type smallint NOT NULL,
+				scheduled_time TIMESTAMPTZ NOT NULL,
+				processed_time TIMESTAMPTZ NOT NULL,
+				tasks_created integer NOT NULL,
+				error varchar(1000) NOT NULL
+			);
+			CREATE INDEX schedule_run_schedule_id_idx ON schedule_run (schedule_id, id);`,
+	},
+	{
+		version:     18,
+		description: "per-subscriber event delivery and retry backoff",
+		command: `
+			ALTER TABLE event_outbox ADD COLUMN delivered_to text[] NOT NULL DEFAULT '{}';
+			ALTER TABLE event_outbox ADD COLUMN next_attempt_time TIMESTAMPTZ;
+			UPDATE event_outbox SET next_attempt_time = occurred_time WHERE status = 0;`,
+	},
+	{
+		version:     19,
+		description: "queued notification emails",
+		command: `
+			CREATE TABLE notification_email (
+				id uuid PRIMARY KEY,
+				task_id integer NOT NULL UNIQUE REFERENCES task(id),
+				recipient varchar(254) NOT NULL,
+				subject text NOT NULL,
+				html text NOT NULL,
+				status smallint NOT NULL,
+				attempts integer NOT NULL,
+				last_error varchar(1000) NOT NULL,
+				created_time TIMESTAMPTZ NOT NULL,
+				last_attempt_time TIMESTAMPTZ,
+				next_attempt_time TIMESTAMPTZ
+			);
+			CREATE INDEX notification_email_due_idx ON notification_email (next_attempt_time) WHERE status = 0;`,
+	},
+}
+
+// LatestSchemaVersion returns the schema version the application code expects
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the version of the latest migration applied to the DB
+func (conn *DBConn) SchemaVersion() (version int, err error) {
+	err = conn.DB.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migration").Scan(&version)
+	return
+}
+
+// Migrate applies all schema migrations that have not yet been applied, in order
+func (conn *DBConn) Migrate() error {
+	_, err := conn.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migration (
+			version integer PRIMARY KEY,
+			description character varying(200) NOT NULL,
+			applied_time TIMESTAMPTZ NOT NULL
+			);`)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migration table: %v", err)
+	}
+
+	current, err := conn.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("error retrieving current schema version: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := conn.apply(m); err != nil {
+			return fmt.Errorf("error applying migration %d (%s): %v", m.version, m.description, err)
+		}
+		conn.l.Info("applied DB migration", "db", conn.Name, "version", m.version, "description", m.description)
+	}
+	return nil
+}
+
+func (conn *DBConn) apply(m migration) error {
+	txn, err := conn.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting DB transaction: %v", err)
+	}
+	defer txn.Rollback()
+
+	if _, err := txn.Exec(m.command); err != nil {
+		return err
+	}
+	q := "INSERT INTO schema_migration (version, description, applied_time) VALUES ($1, $2, $3)"
+	if _, err := txn.Exec(q, m.version, m.description, clock.Now()); err != nil {
+		return err
+	}
+
+	return txn.Commit()
+}
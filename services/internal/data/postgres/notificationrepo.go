@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// NotificationRepo handles persisting users' notification preferences, and queued emails
+type NotificationRepo struct {
+	db *tracedDB
+}
+
+// NewNotificationRepo instantiates a new NotificationRepo
+func NewNotificationRepo(conn DBConn) (repo *NotificationRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &NotificationRepo{db: newTracedDB(conn)}, nil
+}
+
+// Get retrieves a user's notification preferences
+func (r *NotificationRepo) Get(ctx context.Context, uid user.ID) (*notification.Preferences, usecase.Error) {
+	ps, err := r.getAllWhere(ctx, "user_id = $1", uid.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving notification preferences for user %v: %v", uid, err)
+	}
+	if len(ps) == 0 {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no notification preferences found for user %v", uid)
+	}
+	return ps[0], nil
+}
+
+// GetAllDigests retrieves the preferences of all users with the daily digest enabled
+func (r *NotificationRepo) GetAllDigests(ctx context.Context) ([]*notification.Preferences, usecase.Error) {
+	ps, err := r.getAllWhere(ctx, "digest = true")
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving digest preferences: %v", err)
+	}
+	return ps, nil
+}
+
+func (r *NotificationRepo) getAllWhere(ctx context.Context, whereClause string, params ...interface{}) ([]*notification.Preferences, error) {
+	q := fmt.Sprintf("SELECT user_id, email, task_generated, digest, digest_hour, last_digest_time FROM notification_preference WHERE %v ORDER BY user_id", whereClause)
+	rows, err := r.db.QueryContext(ctx, q, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps := []*notification.Preferences{}
+	for rows.Next() {
+		var row struct {
+			userID         string
+			email          string
+			taskGenerated  bool
+			digest         bool
+			digestHour     int
+			lastDigestTime *string
+		}
+		if err := rows.Scan(&row.userID, &row.email, &row.taskGenerated, &row.digest, &row.digestHour, &row.lastDigestTime); err != nil {
+			return nil, err
+		}
+		uid, err := user.ParseID(row.userID)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, notification.NewRaw(uid, row.email, row.taskGenerated, row.digest, row.digestHour, parseNullTime(row.lastDigestTime)))
+	}
+	return ps, rows.Err()
+}
+
+// Upsert adds or replaces a user's notification preferences
+func (r *NotificationRepo) Upsert(ctx context.Context, p *notification.Preferences) usecase.Error {
+	q := `INSERT INTO notification_preference (user_id, email, task_generated, digest, digest_hour, last_digest_time) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, task_generated = EXCLUDED.task_generated, digest = EXCLUDED.digest, digest_hour = EXCLUDED.digest_hour, last_digest_time = EXCLUDED.last_digest_time`
+	if _, err := r.db.ExecContext(ctx, q, p.UserID().String(), p.Email(), p.TaskGenerated(), p.Digest(), p.DigestHour(), p.LastDigestTime()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error saving notification preferences for user %v: %v", p.UserID(), err)
+	}
+	return nil
+}
+
+// AddEmail queues an email about a task, returning an ErrDuplicateRecord error if an email was already queued for it
+func (r *NotificationRepo) AddEmail(ctx context.Context, e *notification.Email, taskID usecase.TaskID) usecase.Error {
+	q := `INSERT INTO notification_email (id, task_id, recipient, subject, html, status, attempts, last_error, created_time, last_attempt_time, next_attempt_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (task_id) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, q, e.ID().String(), taskID, e.To(), e.Subject(), string(e.HTML()), e.Status(), e.Attempts(), e.LastError(), e.CreatedTime(), e.LastAttemptTime(), e.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting new email for task id %v: %v", taskID, err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "email for task id %v already exists", taskID)
+	}
+	return nil
+}
+
+// GetDueEmails retrieves pending emails due to be attempted at or before the given time, oldest first
+func (r *NotificationRepo) GetDueEmails(ctx context.Context, before time.Time, limit int) ([]*notification.Email, usecase.Error) {
+	q := "SELECT id, recipient, subject, html, status, attempts, last_error, created_time, last_attempt_time, next_attempt_time FROM notification_email WHERE status = $1 AND next_attempt_time <= $2 ORDER BY next_attempt_time, id LIMIT $3"
+	rows, err := r.db.QueryContext(ctx, q, notification.StatusPending, before, limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due emails: %v", err)
+	}
+	defer rows.Close()
+
+	es := []*notification.Email{}
+	for rows.Next() {
+		var row struct {
+			id              string
+			recipient       string
+			subject         string
+			html            []byte
+			status          notification.Status
+			attempts        int
+			lastError       string
+			createdTime     *string
+			lastAttemptTime *string
+			nextAttemptTime *string
+		}
+		if err := rows.Scan(&row.id, &row.recipient, &row.subject, &row.html, &row.status, &row.attempts, &row.lastError, &row.createdTime, &row.lastAttemptTime, &row.nextAttemptTime); err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing email row: %v", err)
+		}
+		id, err := notification.ParseID(row.id)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing email id %v: %v", row.id, err)
+		}
+		es = append(es, notification.NewRawEmail(id, row.recipient, row.subject, row.html, row.status, row.attempts, row.lastError, parseNullTime(row.createdTime), parseNullTime(row.lastAttemptTime), parseNullTime(row.nextAttemptTime)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due emails: %v", err)
+	}
+	return es, nil
+}
+
+// NextEmailAttemptTime retrieves the time the next pending email is due, zero if there are none
+func (r *NotificationRepo) NextEmailAttemptTime(ctx context.Context) (time.Time, usecase.Error) {
+	var next *string
+	if err := r.db.QueryRowContext(ctx, "SELECT MIN(next_attempt_time) FROM notification_email WHERE status = $1", notification.StatusPending).Scan(&next); err != nil {
+		return time.Time{}, usecase.NewError(usecase.ErrUnknown, "error retrieving next email time: %v", err)
+	}
+	return parseNullTime(next), nil
+}
+
+// UpdateEmail updates a queued email's persistent data to the given entity values
+func (r *NotificationRepo) UpdateEmail(ctx context.Context, e *notification.Email) usecase.Error {
+	q := "UPDATE notification_email SET status = $2, attempts = $3, last_error = $4, last_attempt_time = $5, next_attempt_time = $6 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, e.ID().String(), e.Status(), e.Attempts(), e.LastError(), e.LastAttemptTime(), e.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating email id %v: %v", e.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no email found for id = %v", e.ID())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestNotificationRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewNotificationRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u1, u2 := user.New("user 1 for notifications"), user.New("user 2 for notifications")
+	userRepo.AddExternal(ctx, u1, "p1", "e1")
+	userRepo.AddExternal(ctx, u2, "p1", "e2")
+
+	if _, ucerr := r.Get(ctx, u1.ID()); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("NotificationRepo.Get() without preferences error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+	p1 := notification.NewRaw(u1.ID(), "one@example.com", true, true, 7, time.Time{})
+	p2 := notification.NewRaw(u2.ID(), "two@example.com", true, false, 0, time.Time{})
+	for _, p := range []*notification.Preferences{p1, p2} {
+		if ucerr := r.Upsert(ctx, p); ucerr != nil {
+			t.Fatalf("NotificationRepo.Upsert() error = %v", ucerr)
+		}
+	}
+	sent := time.Date(2000, 1, 1, 7, 0, 0, 0, time.UTC)
+	p1.DigestSent(sent)
+	if ucerr := r.Upsert(ctx, p1); ucerr != nil {
+		t.Fatalf("NotificationRepo.Upsert() of existing preferences error = %v", ucerr)
+	}
+
+	got, ucerr := r.Get(ctx, u1.ID())
+	if ucerr != nil {
+		t.Fatalf("NotificationRepo.Get() error = %v", ucerr)
+	}
+	if got.Email() != p1.Email() || !got.TaskGenerated() || !got.Digest() || got.DigestHour() != 7 || !got.LastDigestTime().Equal(sent) {
+		t.Errorf("NotificationRepo.Get() = %+v, want %+v", got, p1)
+	}
+	if got, _ := r.Get(ctx, u2.ID()); !got.LastDigestTime().IsZero() {
+		t.Errorf("NotificationRepo.Get() last digest = %v, want zero", got.LastDigestTime())
+	}
+	ps, ucerr := r.GetAllDigests(ctx)
+	if ucerr != nil || len(ps) != 1 || !ps[0].UserID().Equals(u1.ID()) {
+		t.Errorf("NotificationRepo.GetAllDigests() = %v, %v, want only user 1's preferences", ps, ucerr)
+	}
+}
+
+func TestNotificationRepo_emails(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewNotificationRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	t1, ucerr := taskRepo.Add(ctx, task.New("emailed 1", "", user.ID{}))
+	if ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+	t2, _ := taskRepo.Add(ctx, task.New("emailed 2", "", user.ID{}))
+
+	e1 := notification.NewEmail("one@example.com", "New task: emailed 1", []byte("<p>emailed 1</p>"))
+	if ucerr := r.AddEmail(ctx, e1, t1); ucerr != nil {
+		t.Fatalf("NotificationRepo.AddEmail() error = %v", ucerr)
+	}
+	if ucerr := r.AddEmail(ctx, notification.NewEmail("one@example.com", "again", nil), t1); ucerr == nil || ucerr.Code() != usecase.ErrDuplicateRecord {
+		t.Errorf("NotificationRepo.AddEmail() for the same task error = %v, want %v", ucerr, usecase.ErrDuplicateRecord)
+	}
+	if ucerr := r.AddEmail(ctx, notification.NewEmail("two@example.com", "New task: emailed 2", []byte("<p>emailed 2</p>")), t2); ucerr != nil {
+		t.Fatalf("NotificationRepo.AddEmail() for another task error = %v", ucerr)
+	}
+
+	due, ucerr := r.GetDueEmails(ctx, clock.Now(), 10)
+	if ucerr != nil || len(due) < 2 {
+		t.Fatalf("NotificationRepo.GetDueEmails() = %v, %v, want both emails", due, ucerr)
+	}
+	var got *notification.Email
+	for _, e := range due {
+		if e.ID() == e1.ID() {
+			got = e
+		}
+	}
+	if got == nil || got.To() != e1.To() || got.Subject() != e1.Subject() || string(got.HTML()) != string(e1.HTML()) || got.Status() != notification.StatusPending {
+		t.Fatalf("NotificationRepo.GetDueEmails() = %+v, want %+v", got, e1)
+	}
+	got.Failed("smtp unavailable", webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Hour})
+	if ucerr := r.UpdateEmail(ctx, got); ucerr != nil {
+		t.Fatalf("NotificationRepo.UpdateEmail() error = %v", ucerr)
+	}
+	due, _ = r.GetDueEmails(ctx, clock.Now(), 10)
+	for _, e := range due {
+		if e.ID() == e1.ID() {
+			t.Errorf("NotificationRepo.GetDueEmails() = %v, want the failed email to wait for its retry", due)
+		}
+	}
+	if next, ucerr := r.NextEmailAttemptTime(ctx); ucerr != nil || next.IsZero() {
+		t.Errorf("NotificationRepo.NextEmailAttemptTime() = %v, %v, want a pending email", next, ucerr)
+	}
+	if ucerr := r.UpdateEmail(ctx, notification.NewEmail("", "", nil)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("NotificationRepo.UpdateEmail() with an unknown email error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+
+	"github.com/lib/pq"
+)
+
+// OutboxRepo handles retrieving domain events from the outbox and persisting their publishing status
+// events are added to the outbox by the task and schedule repos, in the same transaction as the change that emitted them
+type OutboxRepo struct {
+	db *tracedDB
+}
+
+// NewOutboxRepo instantiates a new OutboxRepo
+func NewOutboxRepo(conn DBConn) (repo *OutboxRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &OutboxRepo{db: newTracedDB(conn)}, nil
+}
+
+// GetPending retrieves the oldest events that haven't been published yet, and are due to be attempted before the given time
+func (r *OutboxRepo) GetPending(ctx context.Context, before time.Time, limit int) ([]usecase.EventData, usecase.Error) {
+	q := "SELECT id, type, task_id, schedule_id, status, attempts, last_error, delivered_to, occurred_time, published_time, next_attempt_time FROM event_outbox WHERE status = $1 AND next_attempt_time <= $2 ORDER BY occurred_time, id LIMIT $3"
+	rows, err := r.db.QueryContext(ctx, q, event.StatusPending, before, limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving pending events: %v", err)
+	}
+	defer rows.Close()
+
+	es := []usecase.EventData{}
+	for rows.Next() {
+		var row struct {
+			id              string
+			typ             string
+			taskID          sql.NullInt64
+			scheduleID      sql.NullInt64
+			status          event.Status
+			attempts        int
+			lastError       string
+			deliveredTo     []string
+			occurredTime    *string
+			publishedTime   *string
+			nextAttemptTime *string
+		}
+		if err := rows.Scan(&row.id, &row.typ, &row.taskID, &row.scheduleID, &row.status, &row.attempts, &row.lastError, pq.Array(&row.deliveredTo), &row.occurredTime, &row.publishedTime, &row.nextAttemptTime); err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing event row: %v", err)
+		}
+		id, err := event.ParseID(row.id)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing event id %v: %v", row.id, err)
+		}
+		es = append(es, usecase.EventData{
+			Event:      event.NewRaw(id, event.Type(row.typ), row.status, row.attempts, row.lastError, row.deliveredTo, parseNullTime(row.occurredTime), parseNullTime(row.publishedTime), parseNullTime(row.nextAttemptTime)),
+			TaskID:     usecase.TaskID(row.taskID.Int64),
+			ScheduleID: usecase.ScheduleID(row.scheduleID.Int64),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving pending events: %v", err)
+	}
+	return es, nil
+}
+
+// Update updates an event's publishing status
+func (r *OutboxRepo) Update(ctx context.Context, e *event.Event) usecase.Error {
+	q := "UPDATE event_outbox SET status = $2, attempts = $3, last_error = $4, delivered_to = $5, published_time = $6, next_attempt_time = $7 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, e.ID().String(), e.Status(), e.Attempts(), e.LastError(), pq.Array(e.DeliveredTo()), e.PublishedTime(), e.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating event id %v: %v", e.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no event found for id = %v", e.ID())
+	}
+	return nil
+}
+
+// addEvents adds the events emitted by a task or schedule to the outbox, a zero ID isn't stored
+// it should be run in the same transaction as the change that emitted them, so either both or neither are persisted
+func addEvents(ctx context.Context, db dbtx, taskID usecase.TaskID, scheduleID usecase.ScheduleID, es []*event.Event) error {
+	q := "INSERT INTO event_outbox (id, type, task_id, schedule_id, status, attempts, last_error, delivered_to, occurred_time, published_time, next_attempt_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"
+	for _, e := range es {
+		tid := sql.NullInt64{Int64: int64(taskID), Valid: taskID != 0}
+		sid := sql.NullInt64{Int64: int64(scheduleID), Valid: scheduleID != 0}
+		if _, err := db.ExecContext(ctx, q, e.ID().String(), string(e.Type()), tid, sid, e.Status(), e.Attempts(), e.LastError(), pq.Array(e.DeliveredTo()), e.OccurredTime(), e.PublishedTime(), e.NextAttemptTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
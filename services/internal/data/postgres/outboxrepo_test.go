@@ -0,0 +1,95 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+)
+
+func TestOutboxRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewOutboxRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	scheduleRepo, _ := NewScheduleRepo(conn)
+
+	tsk := task.New("t1", "", user.ID{})
+	tid, ucerr := taskRepo.Add(ctx, tsk)
+	if ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+	tsk.CompleteNow()
+	if ucerr := taskRepo.Update(ctx, tid, tsk); ucerr != nil {
+		t.Fatalf("TaskRepo.Update() error = %v", ucerr)
+	}
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, user.ID{})
+	sid, ucerr := scheduleRepo.Add(ctx, s)
+	if ucerr != nil {
+		t.Fatalf("ScheduleRepo.Add() error = %v", ucerr)
+	}
+	if len(tsk.Events()) != 0 || len(s.Events()) != 0 {
+		t.Errorf("events should be cleared once persisted, got %v task and %v schedule events", len(tsk.Events()), len(s.Events()))
+	}
+
+	pending, ucerr := r.GetPending(ctx, time.Now(), 10)
+	if ucerr != nil {
+		t.Fatalf("OutboxRepo.GetPending() error = %v", ucerr)
+	}
+	want := []struct {
+		typ event.Type
+		tid int64
+		sid int64
+	}{{event.TaskCreated, int64(tid), 0}, {event.TaskCompleted, int64(tid), 0}, {event.ScheduleCreated, 0, int64(sid)}}
+	if len(pending) != len(want) {
+		t.Fatalf("OutboxRepo.GetPending() = %v, want %v events", pending, len(want))
+	}
+	for i, w := range want {
+		if pending[i].Event.Type() != w.typ || int64(pending[i].TaskID) != w.tid || int64(pending[i].ScheduleID) != w.sid {
+			t.Errorf("OutboxRepo.GetPending()[%v] = %v, want %v task %v schedule %v", i, pending[i], w.typ, w.tid, w.sid)
+		}
+	}
+
+	pending[0].Event.Published()
+	pending[1].Event.Delivered("webhook")
+	pending[1].Event.Failed("chat: subscriber unavailable", 3, time.Hour)
+	for _, ed := range pending[:2] {
+		if ucerr := r.Update(ctx, ed.Event); ucerr != nil {
+			t.Fatalf("OutboxRepo.Update() error = %v", ucerr)
+		}
+	}
+	pending, _ = r.GetPending(ctx, time.Now(), 10)
+	if len(pending) != 1 || pending[0].Event.Type() != event.ScheduleCreated {
+		t.Errorf("OutboxRepo.GetPending() = %v, want only the schedule created event until the failed event is due", pending)
+	}
+	pending, _ = r.GetPending(ctx, time.Now().Add(2*time.Hour), 10)
+	if len(pending) != 2 || pending[0].Event.Type() != event.TaskCompleted || !reflect.DeepEqual(pending[0].Event.DeliveredTo(), []string{"webhook"}) {
+		t.Errorf("OutboxRepo.GetPending() = %v, want the failed event once due, with its delivery recorded", pending)
+	}
+	if ucerr := r.Update(ctx, event.New(event.TaskCleared)); ucerr == nil {
+		t.Errorf("OutboxRepo.Update() with an unknown event should return an error")
+	}
+
+	tsk.Clear()
+	if ucerr := taskRepo.Update(ctx, tid+1000, tsk); ucerr == nil {
+		t.Errorf("TaskRepo.Update() of an unknown task should return an error")
+	}
+	if pending, _ := r.GetPending(ctx, time.Now(), 10); len(pending) != 1 {
+		t.Errorf("events of a failed update should not be persisted, got %v pending events", len(pending))
+	}
+}
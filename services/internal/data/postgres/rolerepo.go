@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// RoleRepo handles persisting role permission data
+type RoleRepo struct {
+	db *tracedDB
+}
+
+// NewRoleRepo instantiates a new RoleRepo
+func NewRoleRepo(conn DBConn) (repo *RoleRepo, err error) {
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+	return &RoleRepo{db: newTracedDB(conn)}, nil
+}
+
+// GetPermissions retrieves a role's permission bitmask
+func (r *RoleRepo) GetPermissions(ctx context.Context, role user.Role) (int64, usecase.Error) {
+
+	q := "SELECT permissions FROM role_permission WHERE role = $1"
+	var perms int64
+	err := r.db.QueryRowContext(ctx, q, role).Scan(&perms)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, usecase.NewError(usecase.ErrRecordNotFound, "no permissions set for role %v", role)
+		}
+		return 0, usecase.NewError(usecase.ErrUnknown, "error getting permissions for role %v: %v", role, err)
+	}
+
+	return perms, nil
+}
+
+// SetPermissions sets a role's permission bitmask
+func (r *RoleRepo) SetPermissions(ctx context.Context, role user.Role, perms int64) usecase.Error {
+
+	q := "INSERT INTO role_permission (role, permissions) VALUES ($1, $2) ON CONFLICT (role) DO UPDATE SET permissions = EXCLUDED.permissions"
+	if _, err := r.db.ExecContext(ctx, q, role, perms); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error setting permissions for role %v: %v", role, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestRoleRepo_GetPermissions(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewRoleRepo(conn)
+	r.SetPermissions(ctx, user.RoleViewer, 6)
+	r.SetPermissions(ctx, user.RoleViewer, 10)
+
+	type args struct {
+		role user.Role
+	}
+	tests := []struct {
+		name    string
+		r       *RoleRepo
+		args    args
+		want    int64
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name:    "should get the last permissions set for a role",
+			r:       r,
+			args:    args{role: user.RoleViewer},
+			want:    10,
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should return ErrRecordNotFound for role without permissions",
+			r:       r,
+			args:    args{role: user.RoleAdmin},
+			want:    0,
+			wantErr: usecase.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.GetPermissions(ctx, tt.args.role)
+			if got != tt.want {
+				t.Errorf("RoleRepo.GetPermissions() got = %v, want %v", got, tt.want)
+			}
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("RoleRepo.GetPermissions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 
@@ -16,7 +19,7 @@ import (
 
 // ScheduleRepo persists schedule data in a PostgreSQL DB
 type ScheduleRepo struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 // NewScheduleRepo instantiates a new ScheduleRepo
@@ -26,15 +29,15 @@ func NewScheduleRepo(conn DBConn) (repo *ScheduleRepo, err error) {
 		return nil, fmt.Errorf("DB connection is nil")
 	}
 
-	return &ScheduleRepo{db: conn.DB}, nil
+	return &ScheduleRepo{db: newTracedDB(conn)}, nil
 }
 
 // Get retrieves a schedule aggregate, given its persistent ID
-func (r *ScheduleRepo) Get(id usecase.ScheduleID) (*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) Get(ctx context.Context, id usecase.ScheduleID) (*schedule.Schedule, usecase.Error) {
 
 	// Retrieve from DB
 	query := fmt.Sprintf("%s WHERE id = $1", scheduleSelectClause())
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 	sd, err := parseScheduleRow(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -44,7 +47,7 @@ func (r *ScheduleRepo) Get(id usecase.ScheduleID) (*schedule.Schedule, usecase.E
 	}
 
 	// Get recurring tasks from DB
-	rts, err := r.getRecurringTasks([]usecase.ScheduleID{id})
+	rts, err := r.getRecurringTasks(ctx, []usecase.ScheduleID{id})
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving recurring tasks for schedule id %v", id)
 	}
@@ -56,11 +59,11 @@ func (r *ScheduleRepo) Get(id usecase.ScheduleID) (*schedule.Schedule, usecase.E
 }
 
 // GetForUser retrieves a schedule entity for a user, given its persistent ID
-func (r *ScheduleRepo) GetForUser(id usecase.ScheduleID, uid user.ID) (*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) GetForUser(ctx context.Context, id usecase.ScheduleID, uid user.ID) (*schedule.Schedule, usecase.Error) {
 
 	// Retrieve from DB
-	query := fmt.Sprintf("%s WHERE id = $1 AND created_by = $2", scheduleSelectClause())
-	row := r.db.QueryRow(query, id, uid.StringPtr())
+	query := fmt.Sprintf("%s WHERE id = $1 AND %s", scheduleSelectClause(), visibleToUser("schedule", "$2"))
+	row := r.db.QueryRowContext(ctx, query, id, uid.StringPtr())
 	sd, err := parseScheduleRow(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -70,7 +73,7 @@ func (r *ScheduleRepo) GetForUser(id usecase.ScheduleID, uid user.ID) (*schedule
 	}
 
 	// Get recurring tasks from DB
-	rts, err := r.getRecurringTasks([]usecase.ScheduleID{id})
+	rts, err := r.getRecurringTasks(ctx, []usecase.ScheduleID{id})
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving recurring tasks for schedule id %v", id)
 	}
@@ -82,21 +85,21 @@ func (r *ScheduleRepo) GetForUser(id usecase.ScheduleID, uid user.ID) (*schedule
 }
 
 // GetAll retrieves all schedules
-func (r *ScheduleRepo) GetAll() (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
-	return r.getAllWhere("")
+func (r *ScheduleRepo) GetAll(ctx context.Context) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+	return r.getAllWhere(ctx, "")
 }
 
 // GetAllScheduled retrieves all unpaused schedules that haven't been removed
-func (r *ScheduleRepo) GetAllScheduled() (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
-	return r.getAllWhere("paused = FALSE AND removed_time = $1", time.Time{})
+func (r *ScheduleRepo) GetAllScheduled(ctx context.Context) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+	return r.getAllWhere(ctx, "paused = FALSE AND removed_time = $1", time.Time{})
 }
 
-// GetAllForUser retrieves all schedules created by the given user
-func (r *ScheduleRepo) GetAllForUser(uid user.ID) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
-	return r.getAllWhere("removed_time = $1 AND created_by = $2", time.Time{}, uid.StringPtr())
+// GetAllForUser retrieves all valid schedules the given user can access
+func (r *ScheduleRepo) GetAllForUser(ctx context.Context, uid user.ID) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+	return r.getAllWhere(ctx, "removed_time = $1 AND "+visibleToUser("schedule", "$2"), time.Time{}, uid.StringPtr())
 }
 
-func (r *ScheduleRepo) getAllWhere(whereClause string, params ...interface{}) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) getAllWhere(ctx context.Context, whereClause string, params ...interface{}) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
 
 	q := scheduleSelectClause()
 	if whereClause != "" {
@@ -104,7 +107,7 @@ func (r *ScheduleRepo) getAllWhere(whereClause string, params ...interface{}) (m
 	}
 
 	// Retrieve from DB
-	rows, err := r.db.Query(q, params...)
+	rows, err := r.db.QueryContext(ctx, q, params...)
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving all schedules: %v", err)
 	}
@@ -123,7 +126,7 @@ func (r *ScheduleRepo) getAllWhere(whereClause string, params ...interface{}) (m
 	}
 
 	// Get recurring tasks from DB
-	allTasks, err := r.getRecurringTasks(sids)
+	allTasks, err := r.getRecurringTasks(ctx, sids)
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving recurring tasks for all schedules: %v", err)
 	}
@@ -137,7 +140,7 @@ func (r *ScheduleRepo) getAllWhere(whereClause string, params ...interface{}) (m
 }
 
 func scheduleSelectClause() (selectClause string) {
-	return "SELECT id, paused, last_checked, removed_time, created_by, frequency_offset, frequency_interval, frequency_time_period, frequency_at_minutes, frequency_at_hours, frequency_on_days_of_week, frequency_on_days_of_month FROM schedule"
+	return "SELECT id, paused, last_checked, removed_time, created_by, workspace_id, frequency_offset, frequency_interval, frequency_time_period, frequency_at_minutes, frequency_at_hours, frequency_on_days_of_week, frequency_on_days_of_month, chat_url FROM schedule"
 }
 
 func parseScheduleRow(r scannable) (sd usecase.ScheduleData, err error) {
@@ -151,6 +154,7 @@ func parseScheduleRow(r scannable) (sd usecase.ScheduleData, err error) {
 		lastChecked    *string
 		removed        *string
 		createdBy      *string
+		workspaceID    *string
 		fOffset        int
 		fInterval      int
 		fTimePeriod    schedule.TimePeriod
@@ -158,8 +162,9 @@ func parseScheduleRow(r scannable) (sd usecase.ScheduleData, err error) {
 		fAtHours       []sql.NullInt64
 		fOnDaysOfWeek  []sql.NullInt64
 		fOnDaysOfMonth []sql.NullInt64
+		chatURL        string
 	}
-	err = r.Scan(&row.id, &row.paused, &row.lastChecked, &row.removed, &row.createdBy, &row.fOffset, &row.fInterval, &row.fTimePeriod, pq.Array(&row.fAtMinutes), pq.Array(&row.fAtHours), pq.Array(&row.fOnDaysOfWeek), pq.Array(&row.fOnDaysOfMonth))
+	err = r.Scan(&row.id, &row.paused, &row.lastChecked, &row.removed, &row.createdBy, &row.workspaceID, &row.fOffset, &row.fInterval, &row.fTimePeriod, pq.Array(&row.fAtMinutes), pq.Array(&row.fAtHours), pq.Array(&row.fOnDaysOfWeek), pq.Array(&row.fOnDaysOfMonth), &row.chatURL)
 	if err != nil {
 		return
 	}
@@ -185,6 +190,10 @@ func parseScheduleRow(r scannable) (sd usecase.ScheduleData, err error) {
 
 	// Construct schedule entity
 	sd.Schedule = schedule.NewRaw(f, row.paused, lastChecked, []schedule.RecurringTask{}, removed, createdBy)
+	sd.Schedule.SetWorkspace(parseWorkspaceID(row.workspaceID))
+	if err = sd.Schedule.SetChatURL(row.chatURL); err != nil {
+		return
+	}
 	sd.ScheduleID = usecase.ScheduleID(row.id)
 
 	return
@@ -218,22 +227,36 @@ func toWeekdaySlice(sqlSlice []sql.NullInt64) []time.Weekday {
 	return days
 }
 
-// Add adds a schedule to the persisence layer
-func (r *ScheduleRepo) Add(s *schedule.Schedule) (usecase.ScheduleID, usecase.Error) {
-	q := "INSERT INTO schedule (paused, last_checked, removed_time, created_by, frequency_offset, frequency_interval, frequency_time_period, frequency_at_minutes, frequency_at_hours, frequency_on_days_of_week, frequency_on_days_of_month) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id"
+// Add adds a schedule to the persisence layer, along with the events it emitted
+func (r *ScheduleRepo) Add(ctx context.Context, s *schedule.Schedule) (usecase.ScheduleID, usecase.Error) {
+	txn, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error starting DB transaction: %v", err)
+	}
+	defer txn.Rollback()
+
+	q := "INSERT INTO schedule (paused, last_checked, removed_time, created_by, frequency_offset, frequency_interval, frequency_time_period, frequency_at_minutes, frequency_at_hours, frequency_on_days_of_week, frequency_on_days_of_month, workspace_id, chat_url) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id"
 	var id usecase.ScheduleID
 	f := s.Frequency()
-	err := r.db.QueryRow(q, s.Paused(), s.LastChecked(), s.RemovedTime(), s.CreatedBy().StringPtr(), f.Offset(), f.Interval(), f.TimePeriod(), pq.Array(f.AtMinutes()), pq.Array(f.AtHours()), pq.Array(f.OnDaysOfWeek()), pq.Array(f.OnDaysOfMonth())).Scan(&id)
+	err = txn.QueryRowContext(ctx, q, s.Paused(), s.LastChecked(), s.RemovedTime(), s.CreatedBy().StringPtr(), f.Offset(), f.Interval(), f.TimePeriod(), pq.Array(f.AtMinutes()), pq.Array(f.AtHours()), pq.Array(f.OnDaysOfWeek()), pq.Array(f.OnDaysOfMonth()), s.Workspace().StringPtr(), s.ChatURL()).Scan(&id)
 	if err != nil {
 		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting new schedule: %v", err)
 	}
 	rts := s.Tasks()
 	if len(rts) > 0 {
-		err := r.insertTasks(id, rts)
+		err := insertTasks(ctx, txn, id, s.CreatedBy(), rts)
 		if err != nil {
 			return 0, usecase.NewError(usecase.ErrUnknown, "error inserting recurring tasks to schedule: %v", err)
 		}
 	}
+	if err := addEvents(ctx, txn, 0, id, s.Events()); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting events for new schedule: %v", err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error committing new schedule: %v", err)
+	}
+	s.ClearEvents()
 
 	return id, nil
 }
@@ -244,20 +267,159 @@ func parseRecurringTaskRow(r scannable) (id int64, sid usecase.ScheduleID, rt sc
 
 	// Scan into row data structure
 	var row struct {
-		name        string
-		description string
-	}
-	err = r.Scan(&id, &sid, &row.name, &row.description)
+		name             string
+		description      string
+		priority         task.Priority
+		dueOffsetSeconds *int64
+		checklist        []string
+		autoComplete     bool
+		rotation         []string
+		rotationStrategy schedule.RotationStrategy
+		rotationState    []int64
+		action           []byte
+		command          []byte
+		tags             []string
+	}
+	err = r.Scan(&id, &sid, &row.name, &row.description, &row.priority, &row.dueOffsetSeconds, pq.Array(&row.checklist), &row.autoComplete, pq.Array(&row.rotation), &row.rotationStrategy, pq.Array(&row.rotationState), &row.action, &row.command, pq.Array(&row.tags))
 	if err != nil {
 		return
 	}
 
 	// Construct recurring task value object
-	rt = schedule.NewRecurringTask(row.name, row.description)
+	rt, err = schedule.NewRecurringTask(row.name, row.description).WithTags(toTags(row.tags)).WithPriority(row.priority)
+	if err != nil {
+		return
+	}
+	if row.dueOffsetSeconds != nil {
+		if rt, err = rt.WithDueOffset(time.Duration(*row.dueOffsetSeconds) * time.Second); err != nil {
+			return
+		}
+	}
+	if rt, err = rt.WithChecklist(row.checklist, row.autoComplete); err != nil {
+		return
+	}
+	rotation := make([]user.ID, len(row.rotation))
+	for i, uid := range row.rotation {
+		if rotation[i], err = user.ParseID(uid); err != nil {
+			return
+		}
+	}
+	if rt, err = rt.WithRotation(rotation, row.rotationStrategy); err != nil {
+		return
+	}
+	if len(rotation) > 0 {
+		if rt, err = rt.WithRotationState(row.rotationState); err != nil {
+			return
+		}
+	}
+	if row.action != nil {
+		var a schedule.HTTPAction
+		if a, err = parseAction(row.action); err != nil {
+			return
+		}
+		rt = rt.WithAction(a)
+	}
+	if row.command != nil {
+		var c schedule.Command
+		if c, err = parseCommand(row.command); err != nil {
+			return
+		}
+		rt = rt.WithCommand(c)
+	}
 	return
 }
 
-func (r *ScheduleRepo) getRecurringTasks(sids []usecase.ScheduleID) (map[usecase.ScheduleID]map[int64]schedule.RecurringTask, error) {
+// actionRow is how a recurring task's HTTP action is stored in its action column
+type actionRow struct {
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers"`
+	Body         string            `json:"body"`
+	TimeoutMs    int64             `json:"timeoutMs"`
+	MaxAttempts  int               `json:"maxAttempts"`
+	CompleteTask bool              `json:"completeTask"`
+}
+
+func parseAction(data []byte) (schedule.HTTPAction, error) {
+	var row actionRow
+	if err := json.Unmarshal(data, &row); err != nil {
+		return schedule.HTTPAction{}, fmt.Errorf("error parsing action: %v", err)
+	}
+	return schedule.NewHTTPAction(row.Method, row.URL, row.Headers, row.Body, time.Duration(row.TimeoutMs)*time.Millisecond, row.MaxAttempts, row.CompleteTask)
+}
+
+// commandRow is how a recurring task's command is stored in its command column
+type commandRow struct {
+	Program   string   `json:"program"`
+	Args      []string `json:"args"`
+	TimeoutMs int64    `json:"timeoutMs"`
+}
+
+func parseCommand(data []byte) (schedule.Command, error) {
+	var row commandRow
+	if err := json.Unmarshal(data, &row); err != nil {
+		return schedule.Command{}, fmt.Errorf("error parsing command: %v", err)
+	}
+	return schedule.NewCommand(row.Program, row.Args, time.Duration(row.TimeoutMs)*time.Millisecond)
+}
+
+// commandJSON returns a recurring task's command to store in its command column, or nil if it has none
+func commandJSON(rt schedule.RecurringTask) (*string, error) {
+	c, ok := rt.Command()
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(commandRow{Program: c.Program(), Args: c.Args(), TimeoutMs: int64(c.Timeout() / time.Millisecond)})
+	if err != nil {
+		return nil, err
+	}
+	str := string(data)
+	return &str, nil
+}
+
+// actionJSON returns a recurring task's HTTP action to store in its action column, or nil if it has none
+func actionJSON(rt schedule.RecurringTask) (*string, error) {
+	a, ok := rt.Action()
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(actionRow{
+		Method:       a.Method(),
+		URL:          a.URL(),
+		Headers:      a.Headers(),
+		Body:         a.Body(),
+		TimeoutMs:    int64(a.Timeout() / time.Millisecond),
+		MaxAttempts:  a.MaxAttempts(),
+		CompleteTask: a.CompleteTask(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	str := string(data)
+	return &str, nil
+}
+
+// rotationStrings returns the string representations of a recurring task's rotation user IDs
+func rotationStrings(rt schedule.RecurringTask) []string {
+	uids := rt.Rotation()
+	strs := make([]string, len(uids))
+	for i, uid := range uids {
+		strs[i] = uid.String()
+	}
+	return strs
+}
+
+// dueOffsetSeconds returns a recurring task's due offset in seconds, or nil if it has none
+func dueOffsetSeconds(rt schedule.RecurringTask) *int64 {
+	offset, ok := rt.DueOffset()
+	if !ok {
+		return nil
+	}
+	seconds := int64(offset / time.Second)
+	return &seconds
+}
+
+func (r *ScheduleRepo) getRecurringTasks(ctx context.Context, sids []usecase.ScheduleID) (map[usecase.ScheduleID]map[int64]schedule.RecurringTask, error) {
 	ts := map[usecase.ScheduleID]map[int64]schedule.RecurringTask{}
 	if len(sids) <= 0 {
 		return ts, nil
@@ -267,8 +429,8 @@ func (r *ScheduleRepo) getRecurringTasks(sids []usecase.ScheduleID) (map[usecase
 	for i, sid := range sids {
 		sidsString[i] = strconv.Itoa(int(sid))
 	}
-	q := fmt.Sprintf("SELECT id, schedule_id, name, description FROM recurring_task WHERE schedule_id IN (%s)", strings.Join(sidsString, ","))
-	rows, err := r.db.Query(q)
+	q := fmt.Sprintf("SELECT id, schedule_id, name, description, priority, due_offset_seconds, checklist, auto_complete, rotation, rotation_strategy, rotation_state, action, command, %s FROM recurring_task WHERE schedule_id IN (%s)", tagNamesColumn("recurring_task_tag", "recurring_task_id", "recurring_task.id"), strings.Join(sidsString, ","))
+	rows, err := r.db.QueryContext(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving tasks: %v", err)
 	}
@@ -286,65 +448,101 @@ func (r *ScheduleRepo) getRecurringTasks(sids []usecase.ScheduleID) (map[usecase
 	return ts, nil
 }
 
-func (r *ScheduleRepo) insertTasks(sid usecase.ScheduleID, rts []schedule.RecurringTask) error {
-	q := "INSERT INTO recurring_task (schedule_id, name, description) VALUES ($1, $2, $3) RETURNING id"
+func insertTasks(ctx context.Context, db dbtx, sid usecase.ScheduleID, createdBy user.ID, rts []schedule.RecurringTask) error {
+	q := "INSERT INTO recurring_task (schedule_id, name, description, priority, due_offset_seconds, checklist, auto_complete, rotation, rotation_strategy, rotation_state, action, command) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id"
 	var rtid int64
 	for _, rt := range rts {
-		err := r.db.QueryRow(q, sid, rt.Name(), rt.Description()).Scan(&rtid)
+		a, err := actionJSON(rt)
 		if err != nil {
 			return err
 		}
+		c, err := commandJSON(rt)
+		if err != nil {
+			return err
+		}
+		err = db.QueryRowContext(ctx, q, sid, rt.Name(), rt.Description(), rt.Priority(), dueOffsetSeconds(rt), pq.Array(rt.Checklist()), rt.AutoComplete(), pq.Array(rotationStrings(rt)), rt.RotationStrategy(), pq.Array(rt.RotationState()), a, c).Scan(&rtid)
+		if err != nil {
+			return err
+		}
+		if err := linkTags(ctx, db, "recurring_task_tag", "recurring_task_id", rtid, createdBy, rt.Tags()); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (r *ScheduleRepo) clearTasks(sid usecase.ScheduleID) error {
+func clearTasks(ctx context.Context, db dbtx, sid usecase.ScheduleID) error {
 	q := "DELETE FROM recurring_task WHERE schedule_id = $1"
-	_, err := r.db.Exec(q, sid)
+	_, err := db.ExecContext(ctx, q, sid)
 	if err != nil {
 		return fmt.Errorf("error clearing all tasks from recurring_task table: %v", err)
 	}
 	return nil
 }
 
-// Update updates a schedule's persistent data to the given aggregate values
-func (r *ScheduleRepo) Update(id usecase.ScheduleID, s *schedule.Schedule) usecase.Error {
+// Update updates a schedule's persistent data to the given aggregate values, along with the events it emitted
+func (r *ScheduleRepo) Update(ctx context.Context, id usecase.ScheduleID, s *schedule.Schedule) usecase.Error {
 
-	// Update schedule row
-	q := "UPDATE schedule SET paused = $2, last_checked = $3, removed_time = $4, created_by = $5, frequency_offset = $6, frequency_interval = $7, frequency_time_period = $8, frequency_at_minutes = $9, frequency_at_hours = $10, frequency_on_days_of_week = $11, frequency_on_days_of_month = $12 WHERE id = $1 RETURNING id"
-	f := s.Frequency()
-	rows, err := r.db.Query(q, id, s.Paused(), s.LastChecked(), s.RemovedTime(), s.CreatedBy().StringPtr(), f.Offset(), f.Interval(), f.TimePeriod(), pq.Array(f.AtMinutes()), pq.Array(f.AtHours()), pq.Array(f.OnDaysOfWeek()), pq.Array(f.OnDaysOfMonth()))
+	// Check if any tasks need to be modified
+	rts, err := r.getRecurringTasks(ctx, []usecase.ScheduleID{id})
 	if err != nil {
-		return usecase.NewError(usecase.ErrUnknown, "error updating schedule id %d: %v", id, err)
+		return usecase.NewError(usecase.ErrUnknown, "error retrieving recurring tasks for schedule id %v: %v", id, err)
 	}
-	defer rows.Close()
-	if !rows.Next() {
-		return usecase.NewError(usecase.ErrRecordNotFound, "no schedule found for id = %v", id)
+
+	txn, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error starting DB transaction: %v", err)
 	}
+	defer txn.Rollback()
 
-	// Check if any tasks need to be modified
-	rts, err := r.getRecurringTasks([]usecase.ScheduleID{id})
+	// Update schedule row
+	q := "UPDATE schedule SET paused = $2, last_checked = $3, removed_time = $4, created_by = $5, frequency_offset = $6, frequency_interval = $7, frequency_time_period = $8, frequency_at_minutes = $9, frequency_at_hours = $10, frequency_on_days_of_week = $11, frequency_on_days_of_month = $12, workspace_id = $13, chat_url = $14 WHERE id = $1 RETURNING id"
+	f := s.Frequency()
+	err = txn.QueryRowContext(ctx, q, id, s.Paused(), s.LastChecked(), s.RemovedTime(), s.CreatedBy().StringPtr(), f.Offset(), f.Interval(), f.TimePeriod(), pq.Array(f.AtMinutes()), pq.Array(f.AtHours()), pq.Array(f.OnDaysOfWeek()), pq.Array(f.OnDaysOfMonth()), s.Workspace().StringPtr(), s.ChatURL()).Scan(&id)
 	if err != nil {
-		return usecase.NewError(usecase.ErrUnknown, "error retrieving recurring tasks for schedule id %v: %v", id, err)
+		if err == sql.ErrNoRows {
+			return usecase.NewError(usecase.ErrRecordNotFound, "no schedule found for id = %v", id)
+		}
+		return usecase.NewError(usecase.ErrUnknown, "error updating schedule id %d: %v", id, err)
 	}
+
+	// Rotation state isn't part of a recurring task's data, so it's saved even if the tasks weren't otherwise modified
 	newRts := s.Tasks()
-	if AnyTasksModified(rts[id], newRts) {
-		err := r.replaceTasks(id, newRts)
+	if matched, ok := matchTasks(rts[id], newRts); !ok {
+		err := replaceTasks(ctx, txn, id, s.CreatedBy(), newRts)
 		if err != nil {
 			return usecase.NewError(usecase.ErrUnknown, "error updating recurring tasks for schedule id %v: %v", id, err)
 		}
+	} else if err := updateRotationStates(ctx, txn, newRts, matched); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating recurring task rotations for schedule id %v: %v", id, err)
+	}
+	if err := addEvents(ctx, txn, 0, id, s.Events()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting events for schedule id %v: %v", id, err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error committing schedule id %v: %v", id, err)
 	}
+	s.ClearEvents()
 
 	return nil
 }
 
 // AnyTasksModified returns whether the map of recurring tasks contains all entries in the slice of recurring tasks
 func AnyTasksModified(as map[int64]schedule.RecurringTask, bs []schedule.RecurringTask) bool {
+	_, ok := matchTasks(as, bs)
+	return !ok
+}
+
+// matchTasks matches each recurring task in the map to an equal one in the slice, returning the slice index for each task ID,
+// and false if they don't all match
+func matchTasks(as map[int64]schedule.RecurringTask, bs []schedule.RecurringTask) (map[int64]int, bool) {
 	if len(as) != len(bs) {
-		return true
+		return nil, false
 	}
+	matched := make(map[int64]int, len(as))
 	usedIndices := make(map[int]bool)
-	for _, at := range as {
+	for id, at := range as {
 		match := false
 		for i, bt := range bs {
 			if _, used := usedIndices[i]; used {
@@ -353,28 +551,69 @@ func AnyTasksModified(as map[int64]schedule.RecurringTask, bs []schedule.Recurri
 			if at.Equal(bt) {
 				match = true
 				usedIndices[i] = true
+				matched[id] = i
 				break
 			}
 		}
 		if !match {
-			return true
+			return nil, false
 		}
 	}
-	return false
+	return matched, true
 }
 
-func (r *ScheduleRepo) replaceTasks(id usecase.ScheduleID, rts []schedule.RecurringTask) error {
+// updateRotationStates saves the rotation state of each matched recurring task
+func updateRotationStates(ctx context.Context, db dbtx, rts []schedule.RecurringTask, matched map[int64]int) error {
+	q := "UPDATE recurring_task SET rotation_state = $2 WHERE id = $1"
+	for id, i := range matched {
+		if len(rts[i].Rotation()) == 0 {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, q, id, pq.Array(rts[i].RotationState())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceTasks(ctx context.Context, db dbtx, id usecase.ScheduleID, createdBy user.ID, rts []schedule.RecurringTask) error {
 	// Modify tasks by clearing and reinserting all
 	// @TODO: determine which specific tasks need updating and only update those
-	err := r.clearTasks(id)
+	err := clearTasks(ctx, db, id)
 	if err != nil {
 		return fmt.Errorf("error clearing recurring tasks: %v", err)
 	}
 	if len(rts) > 0 {
-		err := r.insertTasks(id, rts)
+		err := insertTasks(ctx, db, id, createdBy, rts)
 		if err != nil {
 			return fmt.Errorf("error inserting recurring tasks: %v", err)
 		}
 	}
 	return nil
 }
+
+// SearchRecurringTasks performs a full-text search over recurring tasks in the valid schedules a user can access, returning ranked matches
+func (r *ScheduleRepo) SearchRecurringTasks(ctx context.Context, query string, uid user.ID) ([]usecase.SearchResult, usecase.Error) {
+	q := `SELECT rt.schedule_id, rt.name, rt.description, ts_headline($1::regconfig, rt.name, query, $4), ts_headline($1::regconfig, rt.description, query, $4), ts_rank(rt.search_vector, query) AS rank
+		FROM recurring_task rt JOIN schedule s ON s.id = rt.schedule_id, plainto_tsquery($1::regconfig, $2) query
+		WHERE ` + visibleToUser("s", "$3") + ` AND s.removed_time = $5 AND rt.search_vector @@ query
+		ORDER BY rank DESC, rt.schedule_id LIMIT $6`
+	rows, err := r.db.QueryContext(ctx, q, searchConfig, query, uid.StringPtr(), headlineOptions(), time.Time{}, usecase.MaxSearchResults)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error searching recurring tasks: %v", err)
+	}
+	defer rows.Close()
+
+	rs := []usecase.SearchResult{}
+	for rows.Next() {
+		sid, res, err := parseSearchRow(rows)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing recurring task search row: %v", err)
+		}
+		res.Type = usecase.SearchResultRecurringTask
+		res.ScheduleID = usecase.ScheduleID(sid)
+		rs = append(rs, res)
+	}
+
+	return rs, nil
+}
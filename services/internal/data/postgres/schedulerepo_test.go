@@ -3,11 +3,14 @@
 package postgres_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
 	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
@@ -15,6 +18,8 @@ import (
 )
 
 func TestNewScheduleRepo(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -44,7 +49,7 @@ func TestNewScheduleRepo(t *testing.T) {
 				t.Errorf("NewScheduleRepo() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			gotSchedules, err := gotRepo.GetAll()
+			gotSchedules, err := gotRepo.GetAll(ctx)
 			if err != nil {
 				t.Errorf("NewScheduleRepo() error retrieving schedules: %v", err)
 			}
@@ -96,8 +101,10 @@ func addMonthSchedule(t *testing.T, r *ScheduleRepo, atMinutes []int, atHours []
 }
 
 func addSchedule(t *testing.T, r *ScheduleRepo, f schedule.Frequency, createdBy user.ID) (s *schedule.Schedule, id usecase.ScheduleID) {
+	ctx := context.Background()
+
 	s = schedule.New(f, createdBy)
-	id, err := r.Add(s)
+	id, err := r.Add(ctx, s)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -105,6 +112,8 @@ func addSchedule(t *testing.T, r *ScheduleRepo, f schedule.Frequency, createdBy
 }
 
 func TestScheduleRepo_Get(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -116,7 +125,7 @@ func TestScheduleRepo_Get(t *testing.T) {
 	}
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for schedule Get")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 	uid := u.ID()
 
 	_, hs, hsID := addHourSchedule(t, r, []int{0}, uid)
@@ -165,7 +174,7 @@ func TestScheduleRepo_Get(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Get(tt.args.id)
+			got, err := tt.r.Get(ctx, tt.args.id)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ScheduleRepo.Get() got = %v, want %v", got, tt.want)
 			}
@@ -178,6 +187,8 @@ func TestScheduleRepo_Get(t *testing.T) {
 }
 
 func TestScheduleRepo_GetAll(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -189,7 +200,7 @@ func TestScheduleRepo_GetAll(t *testing.T) {
 	}
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for schedule GetAll")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 	uid := u.ID()
 
 	f1 := schedule.Frequency{}
@@ -224,7 +235,7 @@ func TestScheduleRepo_GetAll(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetAll()
+			got, err := tt.r.GetAll(ctx)
 			if len(got) != len(tt.wantMap) {
 				t.Errorf("ScheduleRepo.GetAll() got = %v, want %v", got, tt.wantMap)
 			}
@@ -242,6 +253,8 @@ func TestScheduleRepo_GetAll(t *testing.T) {
 }
 
 func TestScheduleRepo_GetAllScheduled(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -257,7 +270,7 @@ func TestScheduleRepo_GetAllScheduled(t *testing.T) {
 	}
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for schedule GetAllScheduled")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 	uid := u.ID()
 
 	sPause := schedule.New(f, uid)
@@ -265,15 +278,15 @@ func TestScheduleRepo_GetAllScheduled(t *testing.T) {
 	sRemove := schedule.New(f, uid)
 	sRemove.Remove()
 	sValid := schedule.New(f, uid)
-	_, err = r.Add(sPause)
+	_, err = r.Add(ctx, sPause)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = r.Add(sRemove)
+	_, err = r.Add(ctx, sRemove)
 	if err != nil {
 		t.Fatal(err)
 	}
-	validID, err := r.Add(sValid)
+	validID, err := r.Add(ctx, sValid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -293,7 +306,7 @@ func TestScheduleRepo_GetAllScheduled(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetAllScheduled()
+			got, err := tt.r.GetAllScheduled(ctx)
 			if len(got) != len(tt.wantMap) {
 				t.Errorf("ScheduleRepo.GetAllScheduled() got = %v, want %v", got, tt.wantMap)
 			}
@@ -311,6 +324,8 @@ func TestScheduleRepo_GetAllScheduled(t *testing.T) {
 }
 
 func TestScheduleRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -322,7 +337,7 @@ func TestScheduleRepo_Add(t *testing.T) {
 	}
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for schedule Add")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 	uid := u.ID()
 
 	hf, err := schedule.NewHourFrequency([]int{0})
@@ -390,7 +405,7 @@ func TestScheduleRepo_Add(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Add(tt.args.s)
+			got, err := tt.r.Add(ctx, tt.args.s)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ScheduleRepo.Add() got = %v, want %v", got, tt.want)
 			}
@@ -403,6 +418,8 @@ func TestScheduleRepo_Add(t *testing.T) {
 }
 
 func TestScheduleRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -414,28 +431,32 @@ func TestScheduleRepo_Update(t *testing.T) {
 	}
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for schedule Update")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 	uid := u.ID()
 
 	hf, _ := schedule.NewHourFrequency([]int{0})
 	hs := schedule.New(hf, uid)
-	hsID, err := r.Add(hs)
+	hsID, err := r.Add(ctx, hs)
 	if err != nil {
 		t.Fatal(err)
 	}
 	hs.Pause()
+	hs.AddTask(schedule.NewRecurringTask("tagged task", "").WithTags([]task.Tag{"infra", "ops"}))
 
 	df, _ := schedule.NewDayFrequency([]int{0}, []int{0})
 	ds := schedule.New(df, uid)
-	dsID, err := r.Add(ds)
+	dsID, err := r.Add(ctx, ds)
 	if err != nil {
 		t.Fatal(err)
 	}
 	ds.Pause()
+	rt, _ := schedule.NewRecurringTask("rotating task", "").WithRotation([]user.ID{uid}, schedule.RotationLeastRecent)
+	ds.AddTask(rt)
+	ds.NewTask(0, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
 
 	wf, _ := schedule.NewWeekFrequency([]int{0}, []int{0}, []time.Weekday{time.Sunday})
 	ws := schedule.New(wf, user.ID{})
-	wsID, err := r.Add(ws)
+	wsID, err := r.Add(ctx, ws)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -452,13 +473,13 @@ func TestScheduleRepo_Update(t *testing.T) {
 		wantErr usecase.ErrorCode
 	}{
 		{
-			name:    "should successfully update hour schedule",
+			name:    "should successfully update hour schedule with tagged recurring task",
 			r:       r,
 			args:    args{id: hsID, s: hs},
 			wantErr: usecase.ErrNone,
 		},
 		{
-			name:    "should successfully update day schedule",
+			name:    "should successfully update day schedule with rotating recurring task",
 			r:       r,
 			args:    args{id: dsID, s: ds},
 			wantErr: usecase.ErrNone,
@@ -472,15 +493,92 @@ func TestScheduleRepo_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Update(tt.args.id, tt.args.s)
+			err := tt.r.Update(ctx, tt.args.id, tt.args.s)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("ScheduleRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			got, _ := tt.r.Get(ctx, tt.args.id)
+			if AnyTasksModified(toTaskMap(got.Tasks()), tt.args.s.Tasks()) {
+				t.Errorf("ScheduleRepo.Update() saved recurring tasks = %v, want %v", got.Tasks(), tt.args.s.Tasks())
+			}
+			for i, rt := range got.Tasks() {
+				if !reflect.DeepEqual(rt.RotationState(), tt.args.s.Tasks()[i].RotationState()) {
+					t.Errorf("ScheduleRepo.Update() saved rotation state = %v, want %v", rt.RotationState(), tt.args.s.Tasks()[i].RotationState())
+				}
+			}
 		})
 	}
 }
 
+func toTaskMap(rts []schedule.RecurringTask) map[int64]schedule.RecurringTask {
+	m := map[int64]schedule.RecurringTask{}
+	for i, rt := range rts {
+		m[int64(i)] = rt
+	}
+	return m
+}
+
+func TestScheduleRepo_Update_rotationState(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2000, 1, 1, 11, 30, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewMock(func() time.Time { return now }))
+	defer clock.Set(prevClock)
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewScheduleRepo(conn)
+	taskRepo, _ := NewTaskRepo(conn)
+	runRepo, _ := NewScheduleRunRepo(conn)
+	activityRepo, _ := NewActivityRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u1 := user.New("first user in rotation")
+	userRepo.AddExternal(ctx, u1, "p1", "e1")
+	u2 := user.New("second user in rotation")
+	userRepo.AddExternal(ctx, u2, "p1", "e2")
+
+	rt, err := schedule.NewRecurringTask("rotating task", "").WithRotation([]user.ID{u1.ID(), u2.ID()}, schedule.RotationRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := schedule.NewHourFrequency([]int{0})
+	id, err := r.Add(ctx, schedule.NewRaw(f, false, time.Date(2000, 1, 1, 10, 30, 0, 0, time.UTC), []schedule.RecurringTask{rt}, time.Time{}, u1.ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each check reloads the schedule, so the second occurrence is only assigned to the next user if the first check saved the rotation
+	var assignees []user.ID
+	seen := map[usecase.TaskID]bool{}
+	for i := 0; i < 2; i++ {
+		if _, _, err := usecase.CheckSchedules(ctx, taskRepo, activityRepo, r, runRepo); err != nil {
+			t.Fatalf("CheckSchedules() error = %v", err)
+		}
+		s, err := r.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state := s.Tasks()[0].RotationState(); state[i] == 0 {
+			t.Errorf("check %v: rotation state = %v, want the assignment saved", i+1, state)
+		}
+		ts, _ := taskRepo.GetAll(ctx)
+		for tid, tsk := range ts {
+			if tsk.Name() == "rotating task" && !seen[tid] {
+				seen[tid] = true
+				assignees = append(assignees, tsk.Assignee())
+			}
+		}
+		now = now.Add(time.Hour)
+	}
+	if want := []user.ID{u1.ID(), u2.ID()}; !reflect.DeepEqual(assignees, want) {
+		t.Errorf("assignees = %v, want %v", assignees, want)
+	}
+}
+
 func TestScheduleRepo_AnyTasksModified(t *testing.T) {
 	type args struct {
 		as map[int64]schedule.RecurringTask
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/pqerr"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ScheduleRunRepo handles persisting schedule run history
+type ScheduleRunRepo struct {
+	db *tracedDB
+}
+
+// NewScheduleRunRepo instantiates a new ScheduleRunRepo
+func NewScheduleRunRepo(conn DBConn) (repo *ScheduleRunRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &ScheduleRunRepo{db: newTracedDB(conn)}, nil
+}
+
+// GetForSchedule retrieves a page of a schedule's runs, newest first, along with the total number of runs
+func (r *ScheduleRunRepo) GetForSchedule(ctx context.Context, id usecase.ScheduleID, p usecase.Page) ([]usecase.ScheduleRunData, int, usecase.Error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schedule_run WHERE schedule_id = $1", id).Scan(&total); err != nil {
+		return nil, 0, usecase.NewError(usecase.ErrUnknown, "error counting runs for schedule id %d: %v", id, err)
+	}
+
+	q := "SELECT id, schedule_id, run_type, scheduled_time, processed_time, tasks_created, error FROM schedule_run WHERE schedule_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3"
+	rows, err := r.db.QueryContext(ctx, q, id, p.Limit, p.Offset)
+	if err != nil {
+		return nil, 0, usecase.NewError(usecase.ErrUnknown, "error retrieving runs for schedule id %d: %v", id, err)
+	}
+	defer rows.Close()
+
+	rs := []usecase.ScheduleRunData{}
+	for rows.Next() {
+		rd, err := parseScheduleRunRow(rows)
+		if err != nil {
+			return nil, 0, usecase.NewError(usecase.ErrUnknown, "error parsing schedule run row: %v", err)
+		}
+		rs = append(rs, rd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, usecase.NewError(usecase.ErrUnknown, "error retrieving runs for schedule id %d: %v", id, err)
+	}
+
+	return rs, total, nil
+}
+
+func parseScheduleRunRow(r scannable) (rd usecase.ScheduleRunData, err error) {
+	var row struct {
+		id            int64
+		scheduleID    int64
+		runType       schedule.RunType
+		scheduledTime *string
+		processedTime *string
+		tasksCreated  int
+		err           string
+	}
+	err = r.Scan(&row.id, &row.scheduleID, &row.runType, &row.scheduledTime, &row.processedTime, &row.tasksCreated, &row.err)
+	if err != nil {
+		return
+	}
+
+	rd.ScheduleRunID = usecase.ScheduleRunID(row.id)
+	rd.ScheduleID = usecase.ScheduleID(row.scheduleID)
+	rd.Run = schedule.NewRawRun(row.runType, parseNullTime(row.scheduledTime), parseNullTime(row.processedTime), row.tasksCreated, row.err)
+	return
+}
+
+// Add appends a run to a schedule's run history
+func (r *ScheduleRunRepo) Add(ctx context.Context, id usecase.ScheduleID, run *schedule.Run) (usecase.ScheduleRunID, usecase.Error) {
+	q := "INSERT INTO schedule_run (schedule_id, run_type, scheduled_time, processed_time, tasks_created, error) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	var rid usecase.ScheduleRunID
+	err := r.db.QueryRowContext(ctx, q, id, run.Type(), run.ScheduledTime(), run.ProcessedTime(), run.TasksCreated(), run.Err()).Scan(&rid)
+	if err != nil {
+		if pqerr.Eq(err, pqerr.ForeignKeyViolation) {
+			return 0, usecase.NewError(usecase.ErrRecordNotFound, "error inserting run for schedule id %d: %v", id, err)
+		}
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting run for schedule id %d: %v", id, err)
+	}
+
+	return rid, nil
+}
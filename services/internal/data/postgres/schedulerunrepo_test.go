@@ -0,0 +1,65 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestScheduleRunRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewScheduleRunRepo(conn)
+	scheduleRepo, _ := NewScheduleRepo(conn)
+	f, _ := schedule.NewHourFrequency([]int{0})
+	sid, _ := scheduleRepo.Add(ctx, schedule.New(f, user.ID{}))
+
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	occurrence := schedule.NewRawRun(schedule.RunTypeOccurrence, now, now.Add(3*time.Second), 2, "")
+	check := schedule.NewRawRun(schedule.RunTypeCheck, now.Add(time.Minute), now.Add(time.Minute+time.Second), 2, "")
+	failed := schedule.NewCheckRun(now.Add(time.Hour), 0, errors.New("error adding task to repo"))
+	var ids []usecase.ScheduleRunID
+	for _, run := range []*schedule.Run{occurrence, check, failed} {
+		id, ucerr := r.Add(ctx, sid, run)
+		if ucerr != nil {
+			t.Fatalf("ScheduleRunRepo.Add() error = %v", ucerr)
+		}
+		ids = append(ids, id)
+	}
+	if _, ucerr := r.Add(ctx, 9999, check); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ScheduleRunRepo.Add() for an unknown schedule error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+
+	got, total, ucerr := r.GetForSchedule(ctx, sid, usecase.Page{Limit: 2})
+	if ucerr != nil {
+		t.Fatalf("ScheduleRunRepo.GetForSchedule() error = %v", ucerr)
+	}
+	if total != 3 || len(got) != 2 || got[0].ScheduleRunID != ids[2] || got[1].ScheduleRunID != ids[1] {
+		t.Fatalf("ScheduleRunRepo.GetForSchedule() = %v, %v, want runs %v and %v of 3", got, total, ids[2], ids[1])
+	}
+	if g := got[0].Run; g.Type() != schedule.RunTypeCheck || !g.ScheduledTime().Equal(failed.ScheduledTime()) || g.Err() != "error adding task to repo" {
+		t.Errorf("ScheduleRunRepo.GetForSchedule() run = %v %v %q, want the failed check", g.Type(), g.ScheduledTime(), g.Err())
+	}
+
+	got, _, _ = r.GetForSchedule(ctx, sid, usecase.Page{Limit: 2, Offset: 2})
+	if len(got) != 1 || got[0].ScheduleID != sid {
+		t.Fatalf("ScheduleRunRepo.GetForSchedule() with offset = %v, want the oldest run", got)
+	}
+	if g := got[0].Run; g.Type() != schedule.RunTypeOccurrence || !g.ScheduledTime().Equal(now) || !g.ProcessedTime().Equal(now.Add(3*time.Second)) || g.TasksCreated() != 2 || g.Failed() {
+		t.Errorf("ScheduleRunRepo.GetForSchedule() run = %v %v %v %v, want %v", g.Type(), g.ScheduledTime(), g.ProcessedTime(), g.TasksCreated(), occurrence)
+	}
+}
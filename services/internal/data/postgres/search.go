@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// searchConfig is the text search configuration used to build and query search vectors
+const searchConfig = "english"
+
+// headlineOptions returns the ts_headline options used to mark matching terms, the usecases turn the marks into HTML highlights
+func headlineOptions() string {
+	return fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, usecase.MatchStart, usecase.MatchStop)
+}
+
+// parseSearchRow scans a search result row, returning the ID of the matched task or schedule separately
+func parseSearchRow(r scannable) (id int64, res usecase.SearchResult, err error) {
+	err = r.Scan(&id, &res.Name, &res.Description, &res.NameHighlight, &res.DescriptionHighlight, &res.Rank)
+	return
+}
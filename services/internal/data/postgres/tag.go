@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// tagNamesColumn returns a select column with the sorted array of tag names linked to a row through the given join table
+func tagNamesColumn(joinTable string, joinColumn string, idColumn string) string {
+	return "ARRAY(SELECT tg.name FROM " + joinTable + " jt JOIN tag tg ON tg.id = jt.tag_id WHERE jt." + joinColumn + " = " + idColumn + " ORDER BY tg.name)"
+}
+
+// toTags converts tag names retrieved from the DB into tags
+func toTags(names []string) []task.Tag {
+	tags := make([]task.Tag, len(names))
+	for i, name := range names {
+		tags[i] = task.Tag(name)
+	}
+	return task.NormalizeTags(tags)
+}
+
+// upsertTags inserts any of the user's tags that don't exist yet and returns the IDs of all of them
+func upsertTags(ctx context.Context, db dbtx, uid user.ID, tags []task.Tag) ([]int64, error) {
+	q := "INSERT INTO tag (created_by, name) VALUES ($1, $2) ON CONFLICT (created_by, name) DO UPDATE SET name = EXCLUDED.name RETURNING id"
+	ids := make([]int64, len(tags))
+	for i, tag := range tags {
+		if err := db.QueryRowContext(ctx, q, uid.StringPtr(), string(tag)).Scan(&ids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// linkTags replaces the tags linked to a row through the given join table
+func linkTags(ctx context.Context, db dbtx, joinTable string, joinColumn string, id int64, uid user.ID, tags []task.Tag) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM "+joinTable+" WHERE "+joinColumn+" = $1", id); err != nil {
+		return err
+	}
+	tagIDs, err := upsertTags(ctx, db, uid, tags)
+	if err != nil {
+		return err
+	}
+	q := "INSERT INTO " + joinTable + " (" + joinColumn + ", tag_id) VALUES ($1, $2)"
+	for _, tagID := range tagIDs {
+		if _, err := db.ExecContext(ctx, q, id, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -10,11 +11,13 @@ import (
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+
+	"github.com/lib/pq"
 )
 
 // TaskRepo handles persisting task data and maintaining an in-memory cache
 type TaskRepo struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 // NewTaskRepo instantiates a new TaskRepo
@@ -24,15 +27,15 @@ func NewTaskRepo(conn DBConn) (repo *TaskRepo, err error) {
 		return nil, fmt.Errorf("DB connection is nil")
 	}
 
-	return &TaskRepo{db: conn.DB}, nil
+	return &TaskRepo{db: newTracedDB(conn)}, nil
 }
 
 // Get retrieves a task entity, given its persistent ID
-func (r *TaskRepo) Get(id usecase.TaskID) (*task.Task, usecase.Error) {
+func (r *TaskRepo) Get(ctx context.Context, id usecase.TaskID) (*task.Task, usecase.Error) {
 
 	// Retrieve from DB
 	query := fmt.Sprintf("%s WHERE id = $1", taskSelectClause())
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 	td, err := parseTaskRow(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -40,16 +43,19 @@ func (r *TaskRepo) Get(id usecase.TaskID) (*task.Task, usecase.Error) {
 		}
 		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing task id %d: %v", id, err)
 	}
+	if err := r.loadChecklists(ctx, map[usecase.TaskID]*task.Task{td.TaskID: td.Task}); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving checklist for task id %d: %v", id, err)
+	}
 
 	return td.Task, nil
 }
 
 // GetForUser retrieves a task entity, given its persistent ID and user ID
-func (r *TaskRepo) GetForUser(id usecase.TaskID, uid user.ID) (*task.Task, usecase.Error) {
+func (r *TaskRepo) GetForUser(ctx context.Context, id usecase.TaskID, uid user.ID) (*task.Task, usecase.Error) {
 
 	// Retrieve from DB
-	query := fmt.Sprintf("%s WHERE id = $1 AND created_by = $2", taskSelectClause())
-	row := r.db.QueryRow(query, id, uid.String())
+	query := fmt.Sprintf("%s WHERE id = $1 AND %s", taskSelectClause(), visibleToUser("task", "$2"))
+	row := r.db.QueryRowContext(ctx, query, id, uid.String())
 	td, err := parseTaskRow(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -57,14 +63,17 @@ func (r *TaskRepo) GetForUser(id usecase.TaskID, uid user.ID) (*task.Task, useca
 		}
 		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing task id %d: %v", id, err)
 	}
+	if err := r.loadChecklists(ctx, map[usecase.TaskID]*task.Task{td.TaskID: td.Task}); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving checklist for task id %d: %v", id, err)
+	}
 
 	return td.Task, nil
 }
 
 // GetAll retrieves all tasks
-func (r *TaskRepo) GetAll() (map[usecase.TaskID]*task.Task, usecase.Error) {
+func (r *TaskRepo) GetAll(ctx context.Context) (map[usecase.TaskID]*task.Task, usecase.Error) {
 	// Retrieve from DB
-	rows, err := r.db.Query(taskSelectClause())
+	rows, err := r.db.QueryContext(ctx, taskSelectClause())
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving all tasks: %v", err)
 	}
@@ -78,16 +87,19 @@ func (r *TaskRepo) GetAll() (map[usecase.TaskID]*task.Task, usecase.Error) {
 		}
 		tasks[td.TaskID] = td.Task
 	}
+	if err := r.loadChecklists(ctx, tasks); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving task checklists: %v", err)
+	}
 
 	return tasks, nil
 }
 
-// GetAllForUser retrieves all tasks for a user
-func (r *TaskRepo) GetAllForUser(uid user.ID) (map[usecase.TaskID]*task.Task, usecase.Error) {
-	q := fmt.Sprintf("%v WHERE created_by = $1", taskSelectClause())
+// GetAllForUser retrieves all tasks a user can access
+func (r *TaskRepo) GetAllForUser(ctx context.Context, uid user.ID) (map[usecase.TaskID]*task.Task, usecase.Error) {
+	q := fmt.Sprintf("%v WHERE %v", taskSelectClause(), visibleToUser("task", "$1"))
 
 	// Retrieve from DB
-	rows, err := r.db.Query(q, uid.String())
+	rows, err := r.db.QueryContext(ctx, q, uid.String())
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving all tasks: %v", err)
 	}
@@ -101,12 +113,41 @@ func (r *TaskRepo) GetAllForUser(uid user.ID) (map[usecase.TaskID]*task.Task, us
 		}
 		tasks[td.TaskID] = td.Task
 	}
+	if err := r.loadChecklists(ctx, tasks); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving task checklists: %v", err)
+	}
+
+	return tasks, nil
+}
+
+// GetAllDue retrieves open tasks, not completed or cleared, due after one time, up to and including another
+func (r *TaskRepo) GetAllDue(ctx context.Context, after time.Time, before time.Time) (map[usecase.TaskID]*task.Task, usecase.Error) {
+	q := fmt.Sprintf("%v WHERE completed_time = $1 AND cleared_time = $1 AND due_time > $2 AND due_time <= $3", taskSelectClause())
+
+	// Retrieve from DB
+	rows, err := r.db.QueryContext(ctx, q, time.Time{}, after, before)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due tasks: %v", err)
+	}
+	defer rows.Close()
+
+	tasks := map[usecase.TaskID]*task.Task{}
+	for rows.Next() {
+		td, err := parseTaskRow(rows)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing task row: %v", err)
+		}
+		tasks[td.TaskID] = td.Task
+	}
+	if err := r.loadChecklists(ctx, tasks); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving task checklists: %v", err)
+	}
 
 	return tasks, nil
 }
 
 func taskSelectClause() (selectClause string) {
-	return "SELECT id, name, description, completed_time, cleared_time, created_time, created_by FROM task"
+	return fmt.Sprintf("SELECT id, name, description, completed_time, cleared_time, created_time, created_by, workspace_id, assignee_id, due_time, priority, auto_complete, schedule_id, %s FROM task", tagNamesColumn("task_tag", "task_id", "task.id"))
 }
 
 func parseTaskRow(r scannable) (td usecase.TaskData, err error) {
@@ -122,8 +163,15 @@ func parseTaskRow(r scannable) (td usecase.TaskData, err error) {
 		clearedTime   *string
 		createdTime   *string
 		createdBy     *string
+		workspaceID   *string
+		assigneeID    *string
+		dueTime       *string
+		priority      task.Priority
+		autoComplete  bool
+		scheduleID    *int64
+		tags          []string
 	}
-	err = r.Scan(&row.id, &row.name, &row.description, &row.completedTime, &row.clearedTime, &row.createdTime, &row.createdBy)
+	err = r.Scan(&row.id, &row.name, &row.description, &row.completedTime, &row.clearedTime, &row.createdTime, &row.createdBy, &row.workspaceID, &row.assigneeID, &row.dueTime, &row.priority, &row.autoComplete, &row.scheduleID, pq.Array(&row.tags))
 	if err != nil {
 		return
 	}
@@ -147,40 +195,182 @@ func parseTaskRow(r scannable) (td usecase.TaskData, err error) {
 	}
 
 	td.Task = task.NewRaw(row.name, row.description, completedTime, clearedTime, createdTime, createdBy)
+	td.Task.SetWorkspace(parseWorkspaceID(row.workspaceID))
+	td.Task.SetAssignee(parseNullUserID(row.assigneeID))
+	td.Task.SetDueTime(parseNullTime(row.dueTime))
+	td.Task.SetTags(toTags(row.tags))
+	td.Task.SetAutoComplete(row.autoComplete)
+	if row.scheduleID != nil {
+		td.Task.SetSchedule(*row.scheduleID)
+	}
+	if err = td.Task.SetPriority(row.priority); err != nil {
+		return
+	}
 	td.TaskID = usecase.TaskID(row.id)
 
 	return
 }
 
-// Add adds a task to the persisence layer
-func (r *TaskRepo) Add(t *task.Task) (usecase.TaskID, usecase.Error) {
-	q := "INSERT INTO task (name, description, completed_time, cleared_time, created_time, created_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+// scheduleIDPtr returns the ID of the schedule that generated a task, or nil if it was added by a user
+func scheduleIDPtr(t *task.Task) *int64 {
+	if t.Schedule() == 0 {
+		return nil
+	}
+	id := t.Schedule()
+	return &id
+}
+
+// loadChecklists retrieves the checklist items for all given tasks
+func (r *TaskRepo) loadChecklists(ctx context.Context, ts map[usecase.TaskID]*task.Task) error {
+	if len(ts) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(ts))
+	for id := range ts {
+		ids = append(ids, int64(id))
+	}
+
+	q := "SELECT task_id, name, checked_time FROM task_checklist_item WHERE task_id = ANY($1) ORDER BY task_id, position"
+	rows, err := r.db.QueryContext(ctx, q, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	items := map[usecase.TaskID][]task.ChecklistItem{}
+	for rows.Next() {
+		var id usecase.TaskID
+		var name string
+		var checkedTime *string
+		if err := rows.Scan(&id, &name, &checkedTime); err != nil {
+			return err
+		}
+		items[id] = append(items[id], task.NewRawChecklistItem(name, parseNullTime(checkedTime)))
+	}
+	for id, t := range ts {
+		if err := t.SetChecklist(items[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveChecklist replaces all checklist items of a task
+func saveChecklist(ctx context.Context, db dbtx, id usecase.TaskID, items []task.ChecklistItem) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM task_checklist_item WHERE task_id = $1", id); err != nil {
+		return err
+	}
+	q := "INSERT INTO task_checklist_item (task_id, position, name, checked_time) VALUES ($1, $2, $3, $4)"
+	for i, item := range items {
+		if _, err := db.ExecContext(ctx, q, id, i, item.Name(), item.CheckedTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add adds a task to the persisence layer, along with the events it emitted
+func (r *TaskRepo) Add(ctx context.Context, t *task.Task) (usecase.TaskID, usecase.Error) {
+	txn, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error starting DB transaction: %v", err)
+	}
+	defer txn.Rollback()
+
+	q := "INSERT INTO task (name, description, completed_time, cleared_time, created_time, created_by, due_time, priority, auto_complete, workspace_id, assignee_id, schedule_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id"
 	var id usecase.TaskID
-	err := r.db.QueryRow(q, t.Name(), t.Description(), t.CompletedTime(), t.ClearedTime(), t.CreatedTime(), t.CreatedBy().StringPtr()).Scan(&id)
+	err = txn.QueryRowContext(ctx, q, t.Name(), t.Description(), t.CompletedTime(), t.ClearedTime(), t.CreatedTime(), t.CreatedBy().StringPtr(), t.DueTime(), t.Priority(), t.AutoComplete(), t.Workspace().StringPtr(), t.Assignee().StringPtr(), scheduleIDPtr(t)).Scan(&id)
 	if err != nil {
 		if pqerr.Eq(err, pqerr.ForeignKeyViolation) {
 			return 0, usecase.NewError(usecase.ErrRecordNotFound, "error inserting new task: %v", err)
 		}
 		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting new task: %v", err)
 	}
+	if err := linkTags(ctx, txn, "task_tag", "task_id", int64(id), t.CreatedBy(), t.Tags()); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting tags for new task: %v", err)
+	}
+	if err := saveChecklist(ctx, txn, id, t.Checklist()); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting checklist for new task: %v", err)
+	}
+	if err := addEvents(ctx, txn, id, 0, t.Events()); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting events for new task: %v", err)
+	}
+	if err := addActionCall(ctx, txn, id, t.Action()); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting action call for new task: %v", err)
+	}
+	if err := addCommandRun(ctx, txn, id, t.Command()); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error inserting command run for new task: %v", err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, usecase.NewError(usecase.ErrUnknown, "error committing new task: %v", err)
+	}
+	t.ClearEvents()
+	t.SetAction(nil)
+	t.SetCommand(nil)
 
 	return id, nil
 }
 
-// Update updates a task's persistent data to the given entity values
-func (r *TaskRepo) Update(id usecase.TaskID, t *task.Task) usecase.Error {
-	q := "UPDATE task SET name = $2, description = $3, completed_time = $4, cleared_time = $5, created_time = $6, created_by = $7 WHERE id = $1 RETURNING id"
-	rows, err := r.db.Query(q, id, t.Name(), t.Description(), t.CompletedTime(), t.ClearedTime(), t.CreatedTime(), t.CreatedBy().StringPtr())
+// Update updates a task's persistent data to the given entity values, along with the events it emitted
+func (r *TaskRepo) Update(ctx context.Context, id usecase.TaskID, t *task.Task) usecase.Error {
+	txn, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error starting DB transaction: %v", err)
+	}
+	defer txn.Rollback()
+
+	q := "UPDATE task SET name = $2, description = $3, completed_time = $4, cleared_time = $5, created_time = $6, created_by = $7, due_time = $8, priority = $9, auto_complete = $10, workspace_id = $11, assignee_id = $12, schedule_id = $13 WHERE id = $1 RETURNING id"
+	err = txn.QueryRowContext(ctx, q, id, t.Name(), t.Description(), t.CompletedTime(), t.ClearedTime(), t.CreatedTime(), t.CreatedBy().StringPtr(), t.DueTime(), t.Priority(), t.AutoComplete(), t.Workspace().StringPtr(), t.Assignee().StringPtr(), scheduleIDPtr(t)).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return usecase.NewError(usecase.ErrRecordNotFound, "no task found for id = %v", id)
+		}
 		if pqerr.Eq(err, pqerr.ForeignKeyViolation) {
 			return usecase.NewError(usecase.ErrRecordNotFound, "error inserting new task: %v", err)
 		}
 		return usecase.NewError(usecase.ErrUnknown, "error updating task id %d: %v", id, err)
 	}
-	defer rows.Close()
-	if !rows.Next() {
-		return usecase.NewError(usecase.ErrRecordNotFound, "no task found for id = %v", id)
+	if err := linkTags(ctx, txn, "task_tag", "task_id", int64(id), t.CreatedBy(), t.Tags()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating tags for task id %d: %v", id, err)
+	}
+	if err := saveChecklist(ctx, txn, id, t.Checklist()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating checklist for task id %d: %v", id, err)
+	}
+	if err := addEvents(ctx, txn, id, 0, t.Events()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting events for task id %d: %v", id, err)
 	}
 
+	if err := txn.Commit(); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error committing task id %d: %v", id, err)
+	}
+	t.ClearEvents()
+
 	return nil
 }
+
+// Search performs a full-text search over the valid tasks a user can access, returning ranked matches
+func (r *TaskRepo) Search(ctx context.Context, query string, uid user.ID) ([]usecase.SearchResult, usecase.Error) {
+	q := `SELECT id, name, description, ts_headline($1::regconfig, name, query, $4), ts_headline($1::regconfig, description, query, $4), ts_rank(search_vector, query) AS rank
+		FROM task, plainto_tsquery($1::regconfig, $2) query
+		WHERE ` + visibleToUser("task", "$3") + ` AND cleared_time = $5 AND search_vector @@ query
+		ORDER BY rank DESC, id LIMIT $6`
+	rows, err := r.db.QueryContext(ctx, q, searchConfig, query, uid.StringPtr(), headlineOptions(), time.Time{}, usecase.MaxSearchResults)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error searching tasks: %v", err)
+	}
+	defer rows.Close()
+
+	rs := []usecase.SearchResult{}
+	for rows.Next() {
+		id, res, err := parseSearchRow(rows)
+		if err != nil {
+			return nil, usecase.NewError(usecase.ErrUnknown, "error parsing task search row: %v", err)
+		}
+		res.Type = usecase.SearchResultTask
+		res.TaskID = usecase.TaskID(id)
+		rs = append(rs, res)
+	}
+
+	return rs, nil
+}
@@ -3,7 +3,9 @@
 package postgres_test
 
 import (
+	"context"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +19,8 @@ import (
 )
 
 func TestNewTaskRepo(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -45,7 +49,7 @@ func TestNewTaskRepo(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewTaskRepo() error = %v, wantErr %v", err, tt.wantErr)
 			}
-			gotTasks, err := gotRepo.GetAll()
+			gotTasks, err := gotRepo.GetAll(ctx)
 			if !reflect.DeepEqual(gotTasks, tt.wantTasks) {
 				t.Errorf("NewTaskRepo() tasks = %v, want %v", gotTasks, tt.wantTasks)
 			}
@@ -54,6 +58,8 @@ func TestNewTaskRepo(t *testing.T) {
 }
 
 func TestTaskRepo_Get(t *testing.T) {
+	ctx := context.Background()
+
 	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
 	prevClock := clock.Get()
 	clockMock := clock.NewStaticMock(now)
@@ -68,10 +74,10 @@ func TestTaskRepo_Get(t *testing.T) {
 	r, _ := NewTaskRepo(conn)
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for task Get")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 
 	newTask := task.New("t1", "t1desc", u.ID())
-	id, err := r.Add(newTask)
+	id, err := r.Add(ctx, newTask)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -96,7 +102,7 @@ func TestTaskRepo_Get(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Get(tt.args.id)
+			got, err := tt.r.Get(ctx, tt.args.id)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("TaskRepo.Get() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -108,6 +114,8 @@ func TestTaskRepo_Get(t *testing.T) {
 }
 
 func TestTaskRepo_GetAll(t *testing.T) {
+	ctx := context.Background()
+
 	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
 	prevClock := clock.Get()
 	clockMock := clock.NewStaticMock(now)
@@ -122,13 +130,13 @@ func TestTaskRepo_GetAll(t *testing.T) {
 	r, _ := NewTaskRepo(conn)
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for task GetAll")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 	uid := u.ID()
 
 	newTask1 := task.New("", "", uid)
 	newTask2 := task.New("", "", uid)
-	id1, _ := r.Add(newTask1)
-	id2, _ := r.Add(newTask2)
+	id1, _ := r.Add(ctx, newTask1)
+	id2, _ := r.Add(ctx, newTask2)
 
 	tests := []struct {
 		name    string
@@ -145,7 +153,7 @@ func TestTaskRepo_GetAll(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetAll()
+			got, err := tt.r.GetAll(ctx)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("TaskRepo.GetAll() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -157,6 +165,8 @@ func TestTaskRepo_GetAll(t *testing.T) {
 }
 
 func TestTaskRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -165,7 +175,7 @@ func TestTaskRepo_Add(t *testing.T) {
 	r, _ := NewTaskRepo(conn)
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for task Add")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 
 	t1 := task.New("", "", user.ID{})
 	t2 := task.New("", "", user.New("unknown db user").ID())
@@ -205,7 +215,7 @@ func TestTaskRepo_Add(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Add(tt.args.t)
+			got, err := tt.r.Add(ctx, tt.args.t)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("TaskRepo.Add() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -217,6 +227,8 @@ func TestTaskRepo_Add(t *testing.T) {
 }
 
 func TestTaskRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -225,11 +237,21 @@ func TestTaskRepo_Update(t *testing.T) {
 	r, _ := NewTaskRepo(conn)
 	userRepo, _ := NewUserRepo(conn)
 	u := user.New("test user for task Update")
-	userRepo.AddExternal(u, "p1", "e1")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
 
 	t1 := task.New("t1", "", u.ID())
-	id1, _ := r.Add(t1)
+	id1, _ := r.Add(ctx, t1)
 	t1.CompleteNow()
+	t2 := task.New("t2", "", u.ID())
+	id2, _ := r.Add(ctx, t2)
+	t2.SetDueTime(time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC))
+	t2.SetPriority(task.PriorityHigh)
+	t2.SetTags([]task.Tag{"infra", "ops"})
+	items, _ := task.ParseChecklist([]string{"step 1", "step 2"})
+	t2.SetChecklist(items)
+	t2.SetAutoComplete(true)
+	t2.CheckItem(1)
+	t2.SetAssignee(u.ID())
 
 	type args struct {
 		id usecase.TaskID
@@ -247,12 +269,108 @@ func TestTaskRepo_Update(t *testing.T) {
 			args:    args{id: id1, t: t1},
 			wantErr: usecase.ErrNone,
 		},
+		{
+			name:    "should successfully update task due time, priority, tags, checklist and assignee",
+			r:       r,
+			args:    args{id: id2, t: t2},
+			wantErr: usecase.ErrNone,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Update(tt.args.id, tt.args.t)
+			err := tt.r.Update(ctx, tt.args.id, tt.args.t)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("TaskRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got, _ := tt.r.Get(ctx, tt.args.id)
+			if !got.DueTime().Equal(tt.args.t.DueTime()) || got.Priority() != tt.args.t.Priority() {
+				t.Errorf("TaskRepo.Update() saved due time = %v, priority = %v, want %v, %v", got.DueTime(), got.Priority(), tt.args.t.DueTime(), tt.args.t.Priority())
+			}
+			if !got.Assignee().Equals(tt.args.t.Assignee()) {
+				t.Errorf("TaskRepo.Update() saved assignee = %v, want %v", got.Assignee(), tt.args.t.Assignee())
+			}
+			if !reflect.DeepEqual(got.Tags(), tt.args.t.Tags()) {
+				t.Errorf("TaskRepo.Update() saved tags = %v, want %v", got.Tags(), tt.args.t.Tags())
+			}
+			if got.AutoComplete() != tt.args.t.AutoComplete() || len(got.Checklist()) != len(tt.args.t.Checklist()) {
+				t.Errorf("TaskRepo.Update() saved checklist = %v, autoComplete = %v, want %v, %v", got.Checklist(), got.AutoComplete(), tt.args.t.Checklist(), tt.args.t.AutoComplete())
+				return
+			}
+			for i, item := range got.Checklist() {
+				want := tt.args.t.Checklist()[i]
+				if item.Name() != want.Name() || item.IsChecked() != want.IsChecked() {
+					t.Errorf("TaskRepo.Update() saved checklist item %d = %v, want %v", i, item, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskRepo_Search(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewTaskRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u := user.New("test user for task Search")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
+	u2 := user.New("test user 2 for task Search")
+	userRepo.AddExternal(ctx, u2, "p1", "e2")
+
+	id1, _ := r.Add(ctx, task.New("Renew certificates", "", u.ID()))
+	id2, _ := r.Add(ctx, task.New("Water plants", "check the certificate", u.ID()))
+	cleared := task.New("certificate cleared", "", u.ID())
+	cleared.Clear()
+	r.Add(ctx, cleared)
+	r.Add(ctx, task.New("certificate for user 2", "", u2.ID()))
+
+	type args struct {
+		query string
+		uid   user.ID
+	}
+	tests := []struct {
+		name    string
+		r       *TaskRepo
+		args    args
+		wantIDs []usecase.TaskID
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name:    "should return stemmed matches ranked by name over description",
+			r:       r,
+			args:    args{query: "certificate", uid: u.ID()},
+			wantIDs: []usecase.TaskID{id1, id2},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should return no matches",
+			r:       r,
+			args:    args{query: "zebra", uid: u.ID()},
+			wantIDs: []usecase.TaskID{},
+			wantErr: usecase.ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Search(ctx, tt.args.query, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("TaskRepo.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			gotIDs := []usecase.TaskID{}
+			for _, res := range got {
+				gotIDs = append(gotIDs, res.TaskID)
+				if !strings.Contains(res.NameHighlight+res.DescriptionHighlight, usecase.MatchStart) {
+					t.Errorf("TaskRepo.Search() result %v should contain a highlighted term", res)
+				}
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("TaskRepo.Search() got IDs = %v, want %v", gotIDs, tt.wantIDs)
 			}
 		})
 	}
@@ -12,19 +12,14 @@ import (
 
 type loggerStub struct{}
 
-func (l *loggerStub) Print(v ...interface{}) {
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
 	if testing.Verbose() {
-		l.Printf("%v", v...)
+		fmt.Printf("    LOG: %v %v\n", msg, kv)
 	}
 }
-func (l *loggerStub) Printf(format string, v ...interface{}) {
+func (l *loggerStub) Warn(msg string, kv ...interface{}) {
 	if testing.Verbose() {
-		fmt.Printf(fmt.Sprintf("    LOG: %v\n", format), v...)
-	}
-}
-func (l *loggerStub) Println(v ...interface{}) {
-	if testing.Verbose() {
-		l.Printf("%v", v...)
+		fmt.Printf("    WARN: %v %v\n", msg, kv)
 	}
 }
 
@@ -90,6 +85,6 @@ func NewTestDBConn(test testType) (postgres.DBConn, error) {
 
 // destroy !!!WARNING!!! completely destroys all data in the DB
 func destroy(conn *postgres.DBConn) error {
-	_, err := conn.DB.Exec("DROP TABLE task; DROP TABLE recurring_task; DROP TABLE schedule; DROP TABLE user_external; DROP TABLE user_account;")
+	_, err := conn.DB.Exec("DROP TABLE IF EXISTS schema_migration; DROP TABLE IF EXISTS schedule_run; DROP TABLE IF EXISTS command_run; DROP TABLE IF EXISTS action_call; DROP TABLE IF EXISTS chat_message; DROP TABLE IF EXISTS event_outbox; DROP TABLE IF EXISTS notification_preference; DROP TABLE IF EXISTS notification_email; DROP TABLE IF EXISTS task_tag; DROP TABLE IF EXISTS task_checklist_item; DROP TABLE IF EXISTS task_activity; DROP TABLE IF EXISTS recurring_task_tag; DROP TABLE IF EXISTS tag; DROP TABLE task; DROP TABLE recurring_task; DROP TABLE schedule; DROP TABLE IF EXISTS workspace_member; DROP TABLE IF EXISTS workspace; DROP TABLE IF EXISTS role_permission; DROP TABLE IF EXISTS access_token; DROP TABLE IF EXISTS local_credential; DROP TABLE IF EXISTS webhook_delivery; DROP TABLE IF EXISTS webhook; DROP TABLE user_external; DROP TABLE user_account;")
 	return err
 }
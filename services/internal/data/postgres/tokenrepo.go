@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+	"github.com/lib/pq"
+)
+
+// TokenRepo handles persisting personal access token data
+type TokenRepo struct {
+	db *tracedDB
+}
+
+// NewTokenRepo instantiates a new TokenRepo
+func NewTokenRepo(conn DBConn) (repo *TokenRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &TokenRepo{db: newTracedDB(conn)}, nil
+}
+
+// Get retrieves a token, given its ID
+func (r *TokenRepo) Get(ctx context.Context, id token.ID) (*token.Token, usecase.Error) {
+	ts, err := r.getAllWhere(ctx, "id = $1", id.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving token id %v: %v", id, err)
+	}
+	if len(ts) == 0 {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no token found with id = %v", id)
+	}
+	return ts[0], nil
+}
+
+// GetByHash retrieves a token, given the hash of its secret
+func (r *TokenRepo) GetByHash(ctx context.Context, hash string) (*token.Token, usecase.Error) {
+	ts, err := r.getAllWhere(ctx, "token_hash = $1", hash)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving token by hash: %v", err)
+	}
+	if len(ts) == 0 {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no token found with matching hash")
+	}
+	return ts[0], nil
+}
+
+// GetAllForUser retrieves all of a user's tokens
+func (r *TokenRepo) GetAllForUser(ctx context.Context, uid user.ID) ([]*token.Token, usecase.Error) {
+	ts, err := r.getAllWhere(ctx, "user_id = $1", uid.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving tokens for user %v: %v", uid, err)
+	}
+	return ts, nil
+}
+
+func (r *TokenRepo) getAllWhere(ctx context.Context, whereClause string, params ...interface{}) ([]*token.Token, error) {
+	q := fmt.Sprintf("SELECT id, user_id, name, token_hash, scopes, created_time, expires_time, revoked_time FROM access_token WHERE %v ORDER BY created_time, id", whereClause)
+	rows, err := r.db.QueryContext(ctx, q, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := []*token.Token{}
+	for rows.Next() {
+		var row struct {
+			id          string
+			userID      string
+			name        string
+			hash        string
+			scopes      []string
+			createdTime *string
+			expiresTime *string
+			revokedTime *string
+		}
+		if err := rows.Scan(&row.id, &row.userID, &row.name, &row.hash, pq.Array(&row.scopes), &row.createdTime, &row.expiresTime, &row.revokedTime); err != nil {
+			return nil, err
+		}
+		id, err := token.ParseID(row.id)
+		if err != nil {
+			return nil, err
+		}
+		uid, err := user.ParseID(row.userID)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, token.NewRaw(id, row.name, uid, row.scopes, row.hash, parseNullTime(row.createdTime), parseNullTime(row.expiresTime), parseNullTime(row.revokedTime)))
+	}
+	return ts, rows.Err()
+}
+
+// Add adds a token to the persistence layer
+func (r *TokenRepo) Add(ctx context.Context, t *token.Token) usecase.Error {
+	q := "INSERT INTO access_token (id, user_id, name, token_hash, scopes, created_time, expires_time, revoked_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	if _, err := r.db.ExecContext(ctx, q, t.ID().String(), t.UserID().String(), t.Name(), t.Hash(), pq.Array(t.Scopes()), t.CreatedTime(), t.ExpiresTime(), t.RevokedTime()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting new token: %v", err)
+	}
+	return nil
+}
+
+// Update updates a token's persistent data to the given entity values
+func (r *TokenRepo) Update(ctx context.Context, t *token.Token) usecase.Error {
+	q := "UPDATE access_token SET name = $2, scopes = $3, expires_time = $4, revoked_time = $5 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, t.ID().String(), t.Name(), pq.Array(t.Scopes()), t.ExpiresTime(), t.RevokedTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating token id %v: %v", t.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no token found for id = %v", t.ID())
+	}
+	return nil
+}
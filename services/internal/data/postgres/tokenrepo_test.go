@@ -0,0 +1,60 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestTokenRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewTokenRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u := user.New("user for tokens")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
+	created := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	expires := time.Date(2000, 2, 1, 12, 0, 0, 0, time.UTC)
+	tok := token.NewRaw(token.NewID(), "ci", u.ID(), []string{"read:task", "upsert:task"}, token.Hash("secret"), created, expires, time.Time{})
+
+	if ucerr := r.Add(ctx, tok); ucerr != nil {
+		t.Fatalf("TokenRepo.Add() error = %v", ucerr)
+	}
+	got, ucerr := r.GetByHash(ctx, token.Hash("secret"))
+	if ucerr != nil {
+		t.Fatalf("TokenRepo.GetByHash() error = %v", ucerr)
+	}
+	if !got.ID().Equals(tok.ID()) || !reflect.DeepEqual(got.Scopes(), tok.Scopes()) || !got.ExpiresTime().Equal(expires) || !got.RevokedTime().IsZero() {
+		t.Errorf("TokenRepo.GetByHash() = %v, want %v", got, tok)
+	}
+
+	tok.Revoke()
+	if ucerr := r.Update(ctx, tok); ucerr != nil {
+		t.Fatalf("TokenRepo.Update() error = %v", ucerr)
+	}
+	got, ucerr = r.Get(ctx, tok.ID())
+	if ucerr != nil || got.RevokedTime().IsZero() {
+		t.Errorf("TokenRepo.Get() = %v, %v, want revoked token", got, ucerr)
+	}
+	ts, ucerr := r.GetAllForUser(ctx, u.ID())
+	if ucerr != nil || len(ts) != 1 {
+		t.Errorf("TokenRepo.GetAllForUser() = %v, %v, want 1 token", ts, ucerr)
+	}
+	if _, ucerr := r.GetByHash(ctx, token.Hash("unknown")); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("TokenRepo.GetByHash() error = %v, wantErr %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
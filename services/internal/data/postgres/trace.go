@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/data/postgres")
+
+// dbtx runs statements either directly on a DB connection or in a transaction
+type dbtx interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// tracedDB wraps a DB connection, recording a span for each statement run through it as a child of the context's span
+type tracedDB struct {
+	db   *sql.DB
+	name string
+}
+
+func newTracedDB(conn DBConn) *tracedDB {
+	return &tracedDB{db: conn.DB, name: conn.Name}
+}
+
+// QueryContext runs a query that returns rows
+func (db *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, db.name, query)
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	endStatement(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs a query that returns at most one row
+func (db *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, db.name, query)
+	row := db.db.QueryRowContext(ctx, query, args...)
+	endStatement(span, row.Err())
+	return row
+}
+
+// ExecContext runs a statement that doesn't return rows
+func (db *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, db.name, query)
+	res, err := db.db.ExecContext(ctx, query, args...)
+	endStatement(span, err)
+	return res, err
+}
+
+// BeginTx starts a transaction, its statements are traced the same as the DB's
+func (db *tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*tracedTx, error) {
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{tx: tx, name: db.name}, nil
+}
+
+// tracedTx wraps a DB transaction, recording a span for each statement run through it
+type tracedTx struct {
+	tx   *sql.Tx
+	name string
+}
+
+// QueryContext runs a query in the transaction that returns rows
+func (tx *tracedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, tx.name, query)
+	rows, err := tx.tx.QueryContext(ctx, query, args...)
+	endStatement(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs a query in the transaction that returns at most one row
+func (tx *tracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, tx.name, query)
+	row := tx.tx.QueryRowContext(ctx, query, args...)
+	endStatement(span, row.Err())
+	return row
+}
+
+// ExecContext runs a statement in the transaction that doesn't return rows
+func (tx *tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, tx.name, query)
+	res, err := tx.tx.ExecContext(ctx, query, args...)
+	endStatement(span, err)
+	return res, err
+}
+
+// Commit commits the transaction
+func (tx *tracedTx) Commit() error {
+	return tx.tx.Commit()
+}
+
+// Rollback aborts the transaction
+func (tx *tracedTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// startStatement starts a client span for a SQL statement, named by its operation and DB, e.g. "SELECT taskapp"
+func startStatement(ctx context.Context, dbName string, query string) (context.Context, trace.Span) {
+	op := statementOperation(query)
+	return tracer.Start(ctx, op+" "+dbName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBNameKey.String(dbName),
+			semconv.DBOperationKey.String(op),
+			semconv.DBStatementKey.String(query),
+		),
+	)
+}
+
+// endStatement ends a statement's span, marking it as failed if the statement returned an error
+// no rows is an expected result for lookups, not a failure
+func endStatement(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statementOperation returns the SQL keyword a statement starts with, e.g. SELECT
+func statementOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
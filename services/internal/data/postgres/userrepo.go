@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -11,7 +12,7 @@ import (
 
 // UserRepo handles persisting user data
 type UserRepo struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 // NewUserRepo instantiates a new UserRepo
@@ -19,13 +20,13 @@ func NewUserRepo(conn DBConn) (repo *UserRepo, err error) {
 	if conn.DB == nil {
 		return nil, fmt.Errorf("DB connection is nil")
 	}
-	return &UserRepo{db: conn.DB}, nil
+	return &UserRepo{db: newTracedDB(conn)}, nil
 }
 
 // AddExternal adds a user and associates it to a provider and external ID
-func (r *UserRepo) AddExternal(u *user.User, providerID string, externalID string) usecase.Error {
+func (r *UserRepo) AddExternal(ctx context.Context, u *user.User, providerID string, externalID string) usecase.Error {
 
-	txn, err := r.db.Begin()
+	txn, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return usecase.NewError(usecase.ErrUnknown, "error starting DB transaction: %v", err)
 	}
@@ -34,8 +35,8 @@ func (r *UserRepo) AddExternal(u *user.User, providerID string, externalID strin
 	id := u.ID().String()
 
 	// Insert into user_account table
-	addUserCommand := "INSERT INTO user_account (id, displayname) VALUES ($1, $2);"
-	_, err = txn.Exec(addUserCommand, id, u.DisplayName())
+	addUserCommand := "INSERT INTO user_account (id, displayname, role) VALUES ($1, $2, $3);"
+	_, err = txn.ExecContext(ctx, addUserCommand, id, u.DisplayName(), u.Role())
 	if err != nil {
 		if pqerr.Eq(err, pqerr.UniqueViolation) {
 			return usecase.NewError(usecase.ErrDuplicateRecord, "user with id %v already exists", id)
@@ -45,7 +46,7 @@ func (r *UserRepo) AddExternal(u *user.User, providerID string, externalID strin
 
 	// Insert into user_external table
 	addExternalCommand := "INSERT INTO user_external (user_id, provider, external_id) VALUES ($1, $2, $3);"
-	_, err = txn.Exec(addExternalCommand, id, providerID, externalID)
+	_, err = txn.ExecContext(ctx, addExternalCommand, id, providerID, externalID)
 	if err != nil {
 		if pqerr.Eq(err, pqerr.UniqueViolation) {
 			return usecase.NewError(usecase.ErrDuplicateRecord, "external id %v for provider %v already exists", externalID, providerID)
@@ -59,11 +60,11 @@ func (r *UserRepo) AddExternal(u *user.User, providerID string, externalID strin
 }
 
 // Update updates a user
-func (r *UserRepo) Update(u *user.User) usecase.Error {
+func (r *UserRepo) Update(ctx context.Context, u *user.User) usecase.Error {
 
 	id := u.ID().String()
-	q := "UPDATE user_account SET displayname = $1 WHERE id = $2"
-	res, err := r.db.Exec(q, u.DisplayName(), id)
+	q := "UPDATE user_account SET displayname = $1, role = $2 WHERE id = $3"
+	res, err := r.db.ExecContext(ctx, q, u.DisplayName(), u.Role(), id)
 	if err != nil {
 		return usecase.NewError(usecase.ErrUnknown, "error updating user '%v': %v", id, err)
 	}
@@ -74,25 +75,56 @@ func (r *UserRepo) Update(u *user.User) usecase.Error {
 	return nil
 }
 
+// Get gets a user given its ID
+func (r *UserRepo) Get(ctx context.Context, id user.ID) (*user.User, usecase.Error) {
+
+	q := "SELECT id, displayname, role FROM user_account WHERE id = $1"
+	var d struct {
+		id          string
+		displayname string
+		role        user.Role
+	}
+	err := r.db.QueryRowContext(ctx, q, id.String()).Scan(&d.id, &d.displayname, &d.role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, usecase.NewError(usecase.ErrRecordNotFound, "user id '%v' not found", id)
+		}
+		return nil, usecase.NewError(usecase.ErrUnknown, "error getting user: %v", err)
+	}
+	u, err := user.NewRaw(d.id, d.displayname)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing user data: %v", err)
+	}
+	if err := u.SetRole(d.role); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing user role: %v", err)
+	}
+
+	return u, nil
+}
+
 // GetExternal gets a user given its provider and external ID
-func (r *UserRepo) GetExternal(providerID string, externalID string) (*user.User, usecase.Error) {
+func (r *UserRepo) GetExternal(ctx context.Context, providerID string, externalID string) (*user.User, usecase.Error) {
 
-	q := "SELECT user_account.* FROM user_account JOIN user_external ON user_account.id = user_external.user_id WHERE user_external.provider = $1 AND user_external.external_id = $2 LIMIT 1;"
+	q := "SELECT user_account.id, user_account.displayname, user_account.role FROM user_account JOIN user_external ON user_account.id = user_external.user_id WHERE user_external.provider = $1 AND user_external.external_id = $2 LIMIT 1;"
 	var d struct {
 		id          string
 		displayname string
+		role        user.Role
 	}
-	err := r.db.QueryRow(q, providerID, externalID).Scan(&d.id, &d.displayname)
+	err := r.db.QueryRowContext(ctx, q, providerID, externalID).Scan(&d.id, &d.displayname, &d.role)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, usecase.NewError(usecase.ErrRecordNotFound, "user not found by provider %v and external ID %v", providerID, externalID)
 		}
 		return nil, usecase.NewError(usecase.ErrUnknown, "error getting user: %v", err)
 	}
-	user, err := user.NewRaw(d.id, d.displayname)
+	u, err := user.NewRaw(d.id, d.displayname)
 	if err != nil {
 		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing user data: %v", err)
 	}
+	if err := u.SetRole(d.role); err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error parsing user role: %v", err)
+	}
 
-	return user, nil
+	return u, nil
 }
@@ -3,6 +3,7 @@
 package postgres_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -49,6 +50,8 @@ func TestNewUserRepo(t *testing.T) {
 }
 
 func TestUserRepo_AddExternal(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -101,7 +104,7 @@ func TestUserRepo_AddExternal(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.AddExternal(tt.args.u, tt.args.providerID, tt.args.externalID)
+			err := tt.r.AddExternal(ctx, tt.args.u, tt.args.providerID, tt.args.externalID)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("UserRepo.Add() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -110,6 +113,8 @@ func TestUserRepo_AddExternal(t *testing.T) {
 }
 
 func TestUserRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -122,7 +127,7 @@ func TestUserRepo_Update(t *testing.T) {
 	}
 
 	u1 := user.New("new user display name")
-	r.AddExternal(u1, "p1", "e1")
+	r.AddExternal(ctx, u1, "p1", "e1")
 	u1.UpdateDisplayName("updated display name")
 
 	type args struct {
@@ -149,7 +154,7 @@ func TestUserRepo_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Update(tt.args.u)
+			err := tt.r.Update(ctx, tt.args.u)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("UserRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -158,6 +163,8 @@ func TestUserRepo_Update(t *testing.T) {
 }
 
 func TestUserRepo_GetExternal(t *testing.T) {
+	ctx := context.Background()
+
 	conn, err := NewTestDBConn(DBTest)
 	if err != nil {
 		t.Fatal(err)
@@ -170,7 +177,7 @@ func TestUserRepo_GetExternal(t *testing.T) {
 	}
 
 	u1 := user.New("new user display name")
-	r.AddExternal(u1, "p1", "e1")
+	r.AddExternal(ctx, u1, "p1", "e1")
 
 	type args struct {
 		providerID string
@@ -200,7 +207,7 @@ func TestUserRepo_GetExternal(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetExternal(tt.args.providerID, tt.args.externalID)
+			got, err := tt.r.GetExternal(ctx, tt.args.providerID, tt.args.externalID)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("UserRepo.GetExternal() error = %v, wantErr %v", err, tt.wantErr)
 			}
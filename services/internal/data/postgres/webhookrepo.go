@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+	"github.com/lib/pq"
+)
+
+// WebhookRepo handles persisting webhook and webhook delivery data
+type WebhookRepo struct {
+	db *tracedDB
+}
+
+// NewWebhookRepo instantiates a new WebhookRepo
+func NewWebhookRepo(conn DBConn) (repo *WebhookRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &WebhookRepo{db: newTracedDB(conn)}, nil
+}
+
+// Get retrieves a webhook, given its ID
+func (r *WebhookRepo) Get(ctx context.Context, id webhook.ID) (*webhook.Webhook, usecase.Error) {
+	ws, err := r.getAllWhere(ctx, "id = $1", id.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving webhook id %v: %v", id, err)
+	}
+	if len(ws) == 0 {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no webhook found with id = %v", id)
+	}
+	return ws[0], nil
+}
+
+// GetAllForUser retrieves all of a user's webhooks
+func (r *WebhookRepo) GetAllForUser(ctx context.Context, uid user.ID) ([]*webhook.Webhook, usecase.Error) {
+	ws, err := r.getAllWhere(ctx, "user_id = $1", uid.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving webhooks for user %v: %v", uid, err)
+	}
+	return ws, nil
+}
+
+// GetSubscribed retrieves the active webhooks of the given users that are subscribed to an event type
+func (r *WebhookRepo) GetSubscribed(ctx context.Context, e webhook.EventType, uids []user.ID) ([]*webhook.Webhook, usecase.Error) {
+	ids := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		ids = append(ids, uid.String())
+	}
+	ws, err := r.getAllWhere(ctx, "user_id = ANY($1) AND $2 = ANY(events)", pq.Array(ids), string(e))
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving webhooks subscribed to %v: %v", e, err)
+	}
+	active := []*webhook.Webhook{}
+	for _, w := range ws {
+		if w.IsActive() {
+			active = append(active, w)
+		}
+	}
+	return active, nil
+}
+
+func (r *WebhookRepo) getAllWhere(ctx context.Context, whereClause string, params ...interface{}) ([]*webhook.Webhook, error) {
+	q := fmt.Sprintf("SELECT id, user_id, url, events, secret, created_time, removed_time FROM webhook WHERE %v ORDER BY created_time, id", whereClause)
+	rows, err := r.db.QueryContext(ctx, q, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ws := []*webhook.Webhook{}
+	for rows.Next() {
+		var row struct {
+			id          string
+			userID      string
+			url         string
+			events      []string
+			secret      string
+			createdTime *string
+			removedTime *string
+		}
+		if err := rows.Scan(&row.id, &row.userID, &row.url, pq.Array(&row.events), &row.secret, &row.createdTime, &row.removedTime); err != nil {
+			return nil, err
+		}
+		id, err := webhook.ParseID(row.id)
+		if err != nil {
+			return nil, err
+		}
+		uid, err := user.ParseID(row.userID)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]webhook.EventType, 0, len(row.events))
+		for _, e := range row.events {
+			events = append(events, webhook.EventType(e))
+		}
+		ws = append(ws, webhook.NewRaw(id, uid, row.url, events, row.secret, parseNullTime(row.createdTime), parseNullTime(row.removedTime)))
+	}
+	return ws, rows.Err()
+}
+
+// Add adds a webhook to the persistence layer
+func (r *WebhookRepo) Add(ctx context.Context, w *webhook.Webhook) usecase.Error {
+	q := "INSERT INTO webhook (id, user_id, url, events, secret, created_time, removed_time) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	if _, err := r.db.ExecContext(ctx, q, w.ID().String(), w.UserID().String(), w.URL(), pq.Array(eventNames(w.Events())), w.Secret(), w.CreatedTime(), w.RemovedTime()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting new webhook: %v", err)
+	}
+	return nil
+}
+
+// Update updates a webhook's persistent data to the given entity values
+func (r *WebhookRepo) Update(ctx context.Context, w *webhook.Webhook) usecase.Error {
+	q := "UPDATE webhook SET url = $2, events = $3, secret = $4, removed_time = $5 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, w.ID().String(), w.URL(), pq.Array(eventNames(w.Events())), w.Secret(), w.RemovedTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating webhook id %v: %v", w.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no webhook found for id = %v", w.ID())
+	}
+	return nil
+}
+
+func eventNames(events []webhook.EventType) []string {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, string(e))
+	}
+	return names
+}
+
+// GetDeliveries retrieves a webhook's most recent deliveries, newest first
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, id webhook.ID, limit int) ([]*webhook.Delivery, usecase.Error) {
+	ds, err := r.getDeliveriesWhere(ctx, "webhook_id = $1 ORDER BY created_time DESC, id LIMIT $2", id.String(), limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving deliveries for webhook id %v: %v", id, err)
+	}
+	return ds, nil
+}
+
+// GetDueDeliveries retrieves pending deliveries due to be attempted at or before the given time, oldest first
+func (r *WebhookRepo) GetDueDeliveries(ctx context.Context, before time.Time, limit int) ([]*webhook.Delivery, usecase.Error) {
+	ds, err := r.getDeliveriesWhere(ctx, "status = $1 AND next_attempt_time <= $2 ORDER BY next_attempt_time, id LIMIT $3", webhook.DeliveryPending, before, limit)
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving due deliveries: %v", err)
+	}
+	return ds, nil
+}
+
+func (r *WebhookRepo) getDeliveriesWhere(ctx context.Context, whereClause string, params ...interface{}) ([]*webhook.Delivery, error) {
+	q := fmt.Sprintf("SELECT id, webhook_id, event, payload, status, attempts, status_code, last_error, created_time, last_attempt_time, next_attempt_time FROM webhook_delivery WHERE %v", whereClause)
+	rows, err := r.db.QueryContext(ctx, q, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []*webhook.Delivery{}
+	for rows.Next() {
+		var row struct {
+			id              string
+			webhookID       string
+			event           string
+			payload         []byte
+			status          webhook.DeliveryStatus
+			attempts        int
+			statusCode      int
+			lastError       string
+			createdTime     *string
+			lastAttemptTime *string
+			nextAttemptTime *string
+		}
+		if err := rows.Scan(&row.id, &row.webhookID, &row.event, &row.payload, &row.status, &row.attempts, &row.statusCode, &row.lastError, &row.createdTime, &row.lastAttemptTime, &row.nextAttemptTime); err != nil {
+			return nil, err
+		}
+		id, err := webhook.ParseID(row.id)
+		if err != nil {
+			return nil, err
+		}
+		wid, err := webhook.ParseID(row.webhookID)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, webhook.NewRawDelivery(id, wid, webhook.EventType(row.event), row.payload, row.status, row.attempts, row.statusCode, row.lastError, parseNullTime(row.createdTime), parseNullTime(row.lastAttemptTime), parseNullTime(row.nextAttemptTime)))
+	}
+	return ds, rows.Err()
+}
+
+// NextDeliveryTime retrieves the time the next pending delivery is due, zero if there are none
+func (r *WebhookRepo) NextDeliveryTime(ctx context.Context) (time.Time, usecase.Error) {
+	var next *string
+	if err := r.db.QueryRowContext(ctx, "SELECT MIN(next_attempt_time) FROM webhook_delivery WHERE status = $1", webhook.DeliveryPending).Scan(&next); err != nil {
+		return time.Time{}, usecase.NewError(usecase.ErrUnknown, "error retrieving next delivery time: %v", err)
+	}
+	return parseNullTime(next), nil
+}
+
+// AddDeliveries adds webhook deliveries to the persistence layer in a single transaction
+func (r *WebhookRepo) AddDeliveries(ctx context.Context, ds []*webhook.Delivery) usecase.Error {
+	txn, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error starting DB transaction: %v", err)
+	}
+	defer txn.Rollback()
+
+	q := "INSERT INTO webhook_delivery (id, webhook_id, event, payload, status, attempts, status_code, last_error, created_time, last_attempt_time, next_attempt_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"
+	for _, d := range ds {
+		if _, err := txn.ExecContext(ctx, q, d.ID().String(), d.WebhookID().String(), string(d.Event()), string(d.Payload()), d.Status(), d.Attempts(), d.StatusCode(), d.LastError(), d.CreatedTime(), d.LastAttemptTime(), d.NextAttemptTime()); err != nil {
+			return usecase.NewError(usecase.ErrUnknown, "error inserting new delivery for webhook id %v: %v", d.WebhookID(), err)
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error committing DB transaction: %v", err)
+	}
+	return nil
+}
+
+// UpdateDelivery updates a webhook delivery's persistent data to the given entity values
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, d *webhook.Delivery) usecase.Error {
+	q := "UPDATE webhook_delivery SET status = $2, attempts = $3, status_code = $4, last_error = $5, last_attempt_time = $6, next_attempt_time = $7 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, d.ID().String(), d.Status(), d.Attempts(), d.StatusCode(), d.LastError(), d.LastAttemptTime(), d.NextAttemptTime())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating delivery id %v: %v", d.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no delivery found for id = %v", d.ID())
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestWebhookRepo(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewWebhookRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	u := user.New("user for webhooks")
+	userRepo.AddExternal(ctx, u, "p1", "e1")
+	created := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	events := []webhook.EventType{webhook.EventTaskCreated, webhook.EventScheduleRemoved}
+	w := webhook.NewRaw(webhook.NewID(), u.ID(), "https://example.com/hook", events, "s3cret", created, time.Time{})
+
+	if ucerr := r.Add(ctx, w); ucerr != nil {
+		t.Fatalf("WebhookRepo.Add() error = %v", ucerr)
+	}
+	got, ucerr := r.Get(ctx, w.ID())
+	if ucerr != nil {
+		t.Fatalf("WebhookRepo.Get() error = %v", ucerr)
+	}
+	if got.URL() != w.URL() || !reflect.DeepEqual(got.Events(), events) || got.Secret() != w.Secret() || !got.CreatedTime().Equal(created) || !got.IsActive() {
+		t.Errorf("WebhookRepo.Get() = %v, want %v", got, w)
+	}
+	if ws, ucerr := r.GetSubscribed(ctx, webhook.EventScheduleRemoved, []user.ID{user.NewID(), u.ID()}); ucerr != nil || len(ws) != 1 {
+		t.Errorf("WebhookRepo.GetSubscribed() = %v, %v, want 1 webhook", ws, ucerr)
+	}
+	if ws, ucerr := r.GetSubscribed(ctx, webhook.EventTaskCleared, []user.ID{u.ID()}); ucerr != nil || len(ws) != 0 {
+		t.Errorf("WebhookRepo.GetSubscribed() = %v, %v, want no webhooks", ws, ucerr)
+	}
+
+	pending := webhook.NewRawDelivery(webhook.NewID(), w.ID(), webhook.EventTaskCreated, []byte(`{"type":"task.created"}`), webhook.DeliveryPending, 0, 0, "", created, time.Time{}, created)
+	retry := webhook.NewRawDelivery(webhook.NewID(), w.ID(), webhook.EventTaskCreated, []byte(`{}`), webhook.DeliveryPending, 1, 500, "Internal Server Error", created, created, created.Add(time.Minute))
+	if ucerr := r.AddDeliveries(ctx, []*webhook.Delivery{pending, retry}); ucerr != nil {
+		t.Fatalf("WebhookRepo.AddDeliveries() error = %v", ucerr)
+	}
+	due, ucerr := r.GetDueDeliveries(ctx, created, 10)
+	if ucerr != nil || len(due) != 1 || !due[0].ID().Equals(pending.ID()) || string(due[0].Payload()) != string(pending.Payload()) {
+		t.Errorf("WebhookRepo.GetDueDeliveries() = %v, %v, want only the pending delivery", due, ucerr)
+	}
+
+	pending.Succeeded(204)
+	if ucerr := r.UpdateDelivery(ctx, pending); ucerr != nil {
+		t.Fatalf("WebhookRepo.UpdateDelivery() error = %v", ucerr)
+	}
+	next, ucerr := r.NextDeliveryTime(ctx)
+	if want := created.Add(time.Minute); ucerr != nil || !next.Equal(want) {
+		t.Errorf("WebhookRepo.NextDeliveryTime() = %v, %v, want %v", next, ucerr, want)
+	}
+	ds, ucerr := r.GetDeliveries(ctx, w.ID(), 10)
+	if ucerr != nil || len(ds) != 2 {
+		t.Errorf("WebhookRepo.GetDeliveries() = %v, %v, want 2 deliveries", ds, ucerr)
+	}
+
+	w.Remove()
+	if ucerr := r.Update(ctx, w); ucerr != nil {
+		t.Fatalf("WebhookRepo.Update() error = %v", ucerr)
+	}
+	if ws, ucerr := r.GetSubscribed(ctx, webhook.EventTaskCreated, []user.ID{u.ID()}); ucerr != nil || len(ws) != 0 {
+		t.Errorf("WebhookRepo.GetSubscribed() = %v, %v, want no webhooks after removal", ws, ucerr)
+	}
+	if _, ucerr := r.Get(ctx, webhook.NewID()); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("WebhookRepo.Get() error = %v, wantErr %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// WorkspaceRepo handles persisting workspace data
+type WorkspaceRepo struct {
+	db *tracedDB
+}
+
+// NewWorkspaceRepo instantiates a new WorkspaceRepo
+func NewWorkspaceRepo(conn DBConn) (repo *WorkspaceRepo, err error) {
+
+	if conn.DB == nil {
+		return nil, fmt.Errorf("DB connection is nil")
+	}
+
+	return &WorkspaceRepo{db: newTracedDB(conn)}, nil
+}
+
+// visibleToUser returns a where condition matching rows the user can access: rows shared with a workspace the user is a member of,
+// or rows without a workspace that the user created
+func visibleToUser(alias string, userParam string) string {
+	return fmt.Sprintf("((%[1]s.workspace_id IS NULL AND %[1]s.created_by = %[2]s) OR %[1]s.workspace_id IN (SELECT workspace_id FROM workspace_member WHERE user_id = %[2]s))", alias, userParam)
+}
+
+// parseWorkspaceID converts a nullable workspace ID retrieved from the DB, NULL is an empty ID
+func parseWorkspaceID(val *string) workspace.ID {
+	if val == nil {
+		return workspace.ID{}
+	}
+	id, err := workspace.ParseID(*val)
+	if err != nil {
+		return workspace.ID{}
+	}
+	return id
+}
+
+// Get retrieves a workspace, given its ID
+func (r *WorkspaceRepo) Get(ctx context.Context, id workspace.ID) (*workspace.Workspace, usecase.Error) {
+	ws, err := r.getAllWhere(ctx, "id = $1", id.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving workspace id %v: %v", id, err)
+	}
+	if len(ws) == 0 {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no workspace found with id = %v", id)
+	}
+	return ws[0], nil
+}
+
+// GetAllForUser retrieves all workspaces a user is a member of
+func (r *WorkspaceRepo) GetAllForUser(ctx context.Context, uid user.ID) ([]*workspace.Workspace, usecase.Error) {
+	ws, err := r.getAllWhere(ctx, "id IN (SELECT workspace_id FROM workspace_member WHERE user_id = $1)", uid.String())
+	if err != nil {
+		return nil, usecase.NewError(usecase.ErrUnknown, "error retrieving workspaces for user %v: %v", uid, err)
+	}
+	return ws, nil
+}
+
+func (r *WorkspaceRepo) getAllWhere(ctx context.Context, whereClause string, params ...interface{}) ([]*workspace.Workspace, error) {
+	q := fmt.Sprintf("SELECT id, name, created_time, created_by FROM workspace WHERE %v ORDER BY name, id", whereClause)
+	rows, err := r.db.QueryContext(ctx, q, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type workspaceRow struct {
+		id          string
+		name        string
+		createdTime *string
+		createdBy   *string
+	}
+	wrs := []workspaceRow{}
+	for rows.Next() {
+		var row workspaceRow
+		if err := rows.Scan(&row.id, &row.name, &row.createdTime, &row.createdBy); err != nil {
+			return nil, err
+		}
+		wrs = append(wrs, row)
+	}
+	rows.Close()
+
+	ws := make([]*workspace.Workspace, len(wrs))
+	for i, row := range wrs {
+		id, err := workspace.ParseID(row.id)
+		if err != nil {
+			return nil, err
+		}
+		createdBy := user.ID{}
+		if row.createdBy != nil {
+			createdBy, _ = user.ParseID(*row.createdBy)
+		}
+		members, err := r.getMembers(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		ws[i] = workspace.NewRaw(id, row.name, parseNullTime(row.createdTime), createdBy, members)
+	}
+	return ws, nil
+}
+
+func (r *WorkspaceRepo) getMembers(ctx context.Context, id workspace.ID) ([]workspace.Member, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT user_id, role FROM workspace_member WHERE workspace_id = $1 ORDER BY role DESC, user_id", id.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []workspace.Member{}
+	for rows.Next() {
+		var uidStr string
+		var role workspace.Role
+		if err := rows.Scan(&uidStr, &role); err != nil {
+			return nil, err
+		}
+		uid, err := user.ParseID(uidStr)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, workspace.NewMember(uid, role))
+	}
+	return members, nil
+}
+
+// Add adds a workspace and its members to the persistence layer
+func (r *WorkspaceRepo) Add(ctx context.Context, w *workspace.Workspace) usecase.Error {
+	q := "INSERT INTO workspace (id, name, created_time, created_by) VALUES ($1, $2, $3, $4)"
+	if _, err := r.db.ExecContext(ctx, q, w.ID().String(), w.Name(), w.CreatedTime(), w.CreatedBy().StringPtr()); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting new workspace: %v", err)
+	}
+	if err := r.saveMembers(ctx, w); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error inserting members for new workspace: %v", err)
+	}
+	return nil
+}
+
+// Update updates a workspace's persistent data to the given aggregate values
+func (r *WorkspaceRepo) Update(ctx context.Context, w *workspace.Workspace) usecase.Error {
+	q := "UPDATE workspace SET name = $2 WHERE id = $1"
+	res, err := r.db.ExecContext(ctx, q, w.ID().String(), w.Name())
+	if err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating workspace id %v: %v", w.ID(), err)
+	}
+	if count, _ := res.RowsAffected(); count != 1 {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no workspace found for id = %v", w.ID())
+	}
+	if err := r.saveMembers(ctx, w); err != nil {
+		return usecase.NewError(usecase.ErrUnknown, "error updating members for workspace id %v: %v", w.ID(), err)
+	}
+	return nil
+}
+
+// saveMembers upserts all of a workspace's memberships
+func (r *WorkspaceRepo) saveMembers(ctx context.Context, w *workspace.Workspace) error {
+	q := "INSERT INTO workspace_member (workspace_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role"
+	for _, m := range w.Members() {
+		if _, err := r.db.ExecContext(ctx, q, w.ID().String(), m.UserID().String(), m.Role()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestWorkspaceRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewWorkspaceRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	owner := user.New("owner for workspace Update")
+	member := user.New("member for workspace Update")
+	userRepo.AddExternal(ctx, owner, "p1", "e1")
+	userRepo.AddExternal(ctx, member, "p1", "e2")
+	w, _ := workspace.New("team", owner.ID())
+	if err := r.Add(ctx, w); err != nil {
+		t.Fatalf("WorkspaceRepo.Add() error = %v", err)
+	}
+
+	w.AddMember(owner.ID(), member.ID(), workspace.RoleAdmin)
+	if err := r.Update(ctx, w); err != nil {
+		t.Fatalf("WorkspaceRepo.Update() error = %v", err)
+	}
+
+	got, ucerr := r.Get(ctx, w.ID())
+	if ucerr != nil {
+		t.Fatalf("WorkspaceRepo.Get() error = %v", ucerr)
+	}
+	if got.Name() != "team" || len(got.Members()) != 2 {
+		t.Errorf("WorkspaceRepo.Get() = %v, want workspace 'team' with 2 members", got)
+	}
+	if role, ok := got.Role(member.ID()); !ok || role != workspace.RoleAdmin {
+		t.Errorf("WorkspaceRepo.Get() member role = %v, %v, want %v, true", role, ok, workspace.RoleAdmin)
+	}
+
+	ws, ucerr := r.GetAllForUser(ctx, member.ID())
+	if ucerr != nil || len(ws) != 1 {
+		t.Errorf("WorkspaceRepo.GetAllForUser() = %v, %v, want 1 workspace", ws, ucerr)
+	}
+	if _, ucerr := r.Get(ctx, workspace.NewID()); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("WorkspaceRepo.Get() error = %v, wantErr %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
+
+func TestTaskRepo_GetForUser_workspace(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := NewTestDBConn(DBTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := NewTaskRepo(conn)
+	workspaceRepo, _ := NewWorkspaceRepo(conn)
+	userRepo, _ := NewUserRepo(conn)
+	owner := user.New("owner for shared task")
+	member := user.New("member for shared task")
+	outsider := user.New("outsider for shared task")
+	userRepo.AddExternal(ctx, owner, "p1", "e1")
+	userRepo.AddExternal(ctx, member, "p1", "e2")
+	userRepo.AddExternal(ctx, outsider, "p1", "e3")
+	w, _ := workspace.New("team", owner.ID())
+	w.AddMember(owner.ID(), member.ID(), workspace.RoleMember)
+	workspaceRepo.Add(ctx, w)
+	shared := task.New("shared", "", owner.ID())
+	shared.SetWorkspace(w.ID())
+	sharedID, _ := r.Add(ctx, shared)
+	personalID, _ := r.Add(ctx, task.New("personal", "", owner.ID()))
+
+	type args struct {
+		id  usecase.TaskID
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name:    "member should get shared task",
+			args:    args{sharedID, member.ID()},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "non-member should not get shared task",
+			args:    args{sharedID, outsider.ID()},
+			wantErr: usecase.ErrRecordNotFound,
+		},
+		{
+			name:    "member should not get personal task",
+			args:    args{personalID, member.ID()},
+			wantErr: usecase.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetForUser(ctx, tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("TaskRepo.GetForUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !got.Workspace().Equals(w.ID()) {
+				t.Errorf("TaskRepo.GetForUser() workspace = %v, want %v", got.Workspace(), w.ID())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package transient
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ActionRepo maintains an in-memory cache of HTTP action calls
+// calls are attempted in the background, so access is guarded by a mutex and entities are copied in and out
+type ActionRepo struct {
+	mu    sync.RWMutex
+	calls map[action.ID]usecase.ActionCallData
+}
+
+// NewActionRepo instantiates a new ActionRepo
+func NewActionRepo() *ActionRepo {
+	return &ActionRepo{calls: make(map[action.ID]usecase.ActionCallData)}
+}
+
+// add queues a call for a task, called by the task repo when the task is added
+func (r *ActionRepo) add(taskID usecase.TaskID, c *action.Call) {
+	if r == nil || c == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[c.ID()] = usecase.ActionCallData{TaskID: taskID, Call: copyCall(c)}
+}
+
+// GetForTask retrieves the call queued for a task
+func (r *ActionRepo) GetForTask(ctx context.Context, taskID usecase.TaskID) (*action.Call, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, cd := range r.calls {
+		if cd.TaskID == taskID {
+			return copyCall(cd.Call), nil
+		}
+	}
+	return nil, usecase.NewError(usecase.ErrRecordNotFound, "no action call for task ID %v", taskID)
+}
+
+// GetDue retrieves pending calls due to be attempted at or before the given time, oldest first
+func (r *ActionRepo) GetDue(ctx context.Context, before time.Time, limit int) ([]usecase.ActionCallData, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cs := []usecase.ActionCallData{}
+	for _, cd := range r.calls {
+		if cd.Call.Status() == action.StatusPending && !cd.Call.NextAttemptTime().After(before) {
+			cs = append(cs, usecase.ActionCallData{TaskID: cd.TaskID, Call: copyCall(cd.Call)})
+		}
+	}
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Call.NextAttemptTime().Before(cs[j].Call.NextAttemptTime()) })
+	if len(cs) > limit {
+		cs = cs[:limit]
+	}
+	return cs, nil
+}
+
+// NextAttemptTime retrieves the time the next pending call is due, zero if there are none
+func (r *ActionRepo) NextAttemptTime(ctx context.Context) (time.Time, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var next time.Time
+	for _, cd := range r.calls {
+		if cd.Call.Status() == action.StatusPending && (next.IsZero() || cd.Call.NextAttemptTime().Before(next)) {
+			next = cd.Call.NextAttemptTime()
+		}
+	}
+	return next, nil
+}
+
+// Update updates a call
+func (r *ActionRepo) Update(ctx context.Context, c *action.Call) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cd, ok := r.calls[c.ID()]
+	if !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no action call with ID %v", c.ID())
+	}
+	r.calls[c.ID()] = usecase.ActionCallData{TaskID: cd.TaskID, Call: copyCall(c)}
+	return nil
+}
+
+func copyCall(c *action.Call) *action.Call {
+	cc := *c
+	return &cc
+}
@@ -0,0 +1,65 @@
+package transient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestActionRepo(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewActionRepo()
+	taskRepo := NewTaskRepo()
+	taskRepo.SetActionRepo(r)
+	var ids []usecase.TaskID
+	for _, name := range []string{"warm cache", "rebuild index"} {
+		tsk := task.New(name, "", user.NewID())
+		tsk.SetAction(action.NewCall(action.NewRequest("POST", "https://example.com/"+name, nil, ""), 0, 0, false))
+		id, ucerr := taskRepo.Add(ctx, tsk)
+		if ucerr != nil {
+			t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+		}
+		if tsk.Action() != nil {
+			t.Errorf("TaskRepo.Add() should clear the task's action once it's queued")
+		}
+		ids = append(ids, id)
+	}
+	if _, ucerr := taskRepo.Add(ctx, task.New("manual task", "", user.NewID())); ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+
+	c, ucerr := r.GetForTask(ctx, ids[0])
+	if ucerr != nil || c.Request().URL() != "https://example.com/warm cache" {
+		t.Fatalf("ActionRepo.GetForTask() = %v, %v, want the call queued for the task", c, ucerr)
+	}
+	if _, ucerr := r.GetForTask(ctx, 3); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ActionRepo.GetForTask() for a task without an action error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+
+	due, _ := r.GetDue(ctx, clock.Now(), 10)
+	if len(due) != 2 {
+		t.Fatalf("ActionRepo.GetDue() = %v, want both calls", due)
+	}
+	due[0].Call.Failed(500, "", time.Millisecond, "Internal Server Error", webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Hour})
+	if ucerr := r.Update(ctx, due[0].Call); ucerr != nil {
+		t.Fatalf("ActionRepo.Update() error = %v", ucerr)
+	}
+	due, _ = r.GetDue(ctx, clock.Now(), 10)
+	if len(due) != 1 {
+		t.Errorf("ActionRepo.GetDue() = %v, want only the call that hasn't been attempted", due)
+	}
+	if next, _ := r.NextAttemptTime(ctx); !next.Equal(due[0].Call.NextAttemptTime()) {
+		t.Errorf("ActionRepo.NextAttemptTime() = %v, want %v", next, due[0].Call.NextAttemptTime())
+	}
+	if ucerr := r.Update(ctx, action.NewCall(action.Request{}, 0, 0, false)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ActionRepo.Update() with an unknown call error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,33 @@
+package transient
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ActivityRepo maintains an in-memory cache of task activity
+type ActivityRepo struct {
+	lastID     int
+	activities map[usecase.TaskID][]usecase.ActivityData
+}
+
+// NewActivityRepo instantiates a new ActivityRepo
+func NewActivityRepo() *ActivityRepo {
+	return &ActivityRepo{activities: make(map[usecase.TaskID][]usecase.ActivityData)}
+}
+
+// GetAllForTask retrieves a task's activity entries, oldest first
+func (r *ActivityRepo) GetAllForTask(ctx context.Context, id usecase.TaskID) ([]usecase.ActivityData, usecase.Error) {
+	return append([]usecase.ActivityData{}, r.activities[id]...), nil
+}
+
+// Add appends an activity entry to a task's activity history
+func (r *ActivityRepo) Add(ctx context.Context, id usecase.TaskID, a *task.Activity) (usecase.ActivityID, usecase.Error) {
+	r.lastID++
+	aid := usecase.ActivityID(r.lastID)
+	r.activities[id] = append(r.activities[id], usecase.ActivityData{ActivityID: aid, TaskID: id, Activity: a})
+
+	return aid, nil
+}
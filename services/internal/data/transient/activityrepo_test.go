@@ -0,0 +1,65 @@
+package transient
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestActivityRepo_GetAllForTask(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewActivityRepo()
+	uid := user.NewID()
+	created, _ := task.NewActivity(task.ActivityCreated, uid)
+	comment, _ := task.NewComment("a comment", uid)
+	otherCreated, _ := task.NewActivity(task.ActivityCreated, uid)
+	id1, _ := r.Add(ctx, 1, created)
+	r.Add(ctx, 2, otherCreated)
+	id2, _ := r.Add(ctx, 1, comment)
+
+	type args struct {
+		id usecase.TaskID
+	}
+	tests := []struct {
+		name    string
+		r       *ActivityRepo
+		args    args
+		want    []usecase.ActivityData
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name: "should get task activity in the order it was added",
+			r:    r,
+			args: args{id: 1},
+			want: []usecase.ActivityData{
+				{ActivityID: id1, TaskID: 1, Activity: created},
+				{ActivityID: id2, TaskID: 1, Activity: comment},
+			},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should get empty list for task without activity",
+			r:       r,
+			args:    args{id: 3},
+			want:    []usecase.ActivityData{},
+			wantErr: usecase.ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.GetAllForTask(ctx, tt.args.id)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ActivityRepo.GetAllForTask() got = %v, want %v", got, tt.want)
+			}
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ActivityRepo.GetAllForTask() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package transient
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ChatRepo maintains an in-memory cache of chat messages
+// messages are attempted in the background, so access is guarded by a mutex and entities are copied in and out
+type ChatRepo struct {
+	mu       sync.RWMutex
+	messages map[chat.ID]*chat.Message
+	posted   map[chatKey]bool
+}
+
+// chatKey identifies a task's message of a single kind, each task is only posted once for each kind
+type chatKey struct {
+	taskID usecase.TaskID
+	kind   chat.Kind
+}
+
+// NewChatRepo instantiates a new ChatRepo
+func NewChatRepo() *ChatRepo {
+	return &ChatRepo{messages: make(map[chat.ID]*chat.Message), posted: make(map[chatKey]bool)}
+}
+
+// Add queues a chat message about a task
+func (r *ChatRepo) Add(ctx context.Context, m *chat.Message, taskID usecase.TaskID, scheduleID usecase.ScheduleID) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := chatKey{taskID: taskID, kind: m.Kind()}
+	if r.posted[key] {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "%v chat message for task id %v already exists", m.Kind(), taskID)
+	}
+	if _, ok := r.messages[m.ID()]; ok {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "chat message with ID %v already exists", m.ID())
+	}
+	r.posted[key] = true
+	r.messages[m.ID()] = copyMessage(m)
+	return nil
+}
+
+// GetDue retrieves pending messages due to be attempted at or before the given time, oldest first
+func (r *ChatRepo) GetDue(ctx context.Context, before time.Time, limit int) ([]*chat.Message, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ms := []*chat.Message{}
+	for _, m := range r.messages {
+		if m.Status() == chat.StatusPending && !m.NextAttemptTime().After(before) {
+			ms = append(ms, copyMessage(m))
+		}
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].NextAttemptTime().Before(ms[j].NextAttemptTime()) })
+	if len(ms) > limit {
+		ms = ms[:limit]
+	}
+	return ms, nil
+}
+
+// GetAll retrieves all messages, oldest first
+func (r *ChatRepo) GetAll(ctx context.Context) ([]*chat.Message, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ms := []*chat.Message{}
+	for _, m := range r.messages {
+		ms = append(ms, copyMessage(m))
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].CreatedTime().Before(ms[j].CreatedTime()) })
+	return ms, nil
+}
+
+// NextAttemptTime retrieves the time the next pending message is due, zero if there are none
+func (r *ChatRepo) NextAttemptTime(ctx context.Context) (time.Time, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var next time.Time
+	for _, m := range r.messages {
+		if m.Status() == chat.StatusPending && (next.IsZero() || m.NextAttemptTime().Before(next)) {
+			next = m.NextAttemptTime()
+		}
+	}
+	return next, nil
+}
+
+// Update updates a chat message
+func (r *ChatRepo) Update(ctx context.Context, m *chat.Message) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.messages[m.ID()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no chat message with ID %v", m.ID())
+	}
+	r.messages[m.ID()] = copyMessage(m)
+	return nil
+}
+
+func copyMessage(m *chat.Message) *chat.Message {
+	c := *m
+	return &c
+}
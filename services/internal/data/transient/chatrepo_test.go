@@ -0,0 +1,48 @@
+package transient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestChatRepo(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewChatRepo()
+	generated := chat.NewMessage("https://chat.example.com/hooks/1", chat.KindGenerated, []byte(`{"text":"t1"}`))
+	if ucerr := r.Add(ctx, generated, 1, 1); ucerr != nil {
+		t.Fatalf("ChatRepo.Add() error = %v", ucerr)
+	}
+	if ucerr := r.Add(ctx, chat.NewMessage("https://chat.example.com/hooks/1", chat.KindGenerated, nil), 1, 1); ucerr == nil || ucerr.Code() != usecase.ErrDuplicateRecord {
+		t.Errorf("ChatRepo.Add() of the same kind for a task error = %v, want %v", ucerr, usecase.ErrDuplicateRecord)
+	}
+	overdue := chat.NewMessage("https://chat.example.com/hooks/1", chat.KindOverdue, nil)
+	if ucerr := r.Add(ctx, overdue, 1, 1); ucerr != nil {
+		t.Fatalf("ChatRepo.Add() of another kind error = %v", ucerr)
+	}
+
+	due, _ := r.GetDue(ctx, clock.Now(), 10)
+	if len(due) != 2 {
+		t.Fatalf("ChatRepo.GetDue() = %v, want both messages", due)
+	}
+	due[0].Failed(500, "Internal Server Error", webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Hour})
+	if ucerr := r.Update(ctx, due[0]); ucerr != nil {
+		t.Fatalf("ChatRepo.Update() error = %v", ucerr)
+	}
+	due, _ = r.GetDue(ctx, clock.Now(), 10)
+	if len(due) != 1 {
+		t.Errorf("ChatRepo.GetDue() = %v, want only the message that hasn't been attempted", due)
+	}
+	if next, _ := r.NextAttemptTime(ctx); !next.Equal(due[0].NextAttemptTime()) {
+		t.Errorf("ChatRepo.NextAttemptTime() = %v, want %v", next, due[0].NextAttemptTime())
+	}
+	if ucerr := r.Update(ctx, chat.NewMessage("", chat.KindOverdue, nil)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("ChatRepo.Update() with an unknown message error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,98 @@
+package transient
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// CommandRepo maintains an in-memory cache of command runs
+// commands are run in the background, so access is guarded by a mutex and entities are copied in and out
+type CommandRepo struct {
+	mu   sync.RWMutex
+	runs map[command.ID]usecase.CommandRunData
+}
+
+// NewCommandRepo instantiates a new CommandRepo
+func NewCommandRepo() *CommandRepo {
+	return &CommandRepo{runs: make(map[command.ID]usecase.CommandRunData)}
+}
+
+// add queues a run for a task, called by the task repo when the task is added
+func (r *CommandRepo) add(taskID usecase.TaskID, run *command.Run) {
+	if r == nil || run == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID()] = usecase.CommandRunData{TaskID: taskID, Run: copyRun(run)}
+}
+
+// GetForTask retrieves the run queued for a task
+func (r *CommandRepo) GetForTask(ctx context.Context, taskID usecase.TaskID) (*command.Run, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rd := range r.runs {
+		if rd.TaskID == taskID {
+			return copyRun(rd.Run), nil
+		}
+	}
+	return nil, usecase.NewError(usecase.ErrRecordNotFound, "no command run for task ID %v", taskID)
+}
+
+// GetPending retrieves runs that haven't been started, oldest first
+func (r *CommandRepo) GetPending(ctx context.Context, limit int) ([]usecase.CommandRunData, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rs := []usecase.CommandRunData{}
+	for _, rd := range r.runs {
+		if rd.Run.Status() == command.StatusPending {
+			rs = append(rs, usecase.CommandRunData{TaskID: rd.TaskID, Run: copyRun(rd.Run)})
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if !rs[i].Run.CreatedTime().Equal(rs[j].Run.CreatedTime()) {
+			return rs[i].Run.CreatedTime().Before(rs[j].Run.CreatedTime())
+		}
+		return rs[i].TaskID < rs[j].TaskID
+	})
+	if len(rs) > limit {
+		rs = rs[:limit]
+	}
+	return rs, nil
+}
+
+// Start saves a started run, only if it's still pending
+// returns false if the run isn't pending, because it was already started
+func (r *CommandRepo) Start(ctx context.Context, run *command.Run) (bool, usecase.Error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rd, ok := r.runs[run.ID()]
+	if !ok {
+		return false, usecase.NewError(usecase.ErrRecordNotFound, "no command run with ID %v", run.ID())
+	}
+	if rd.Run.Status() != command.StatusPending {
+		return false, nil
+	}
+	r.runs[run.ID()] = usecase.CommandRunData{TaskID: rd.TaskID, Run: copyRun(run)}
+	return true, nil
+}
+
+// Update updates a run
+func (r *CommandRepo) Update(ctx context.Context, run *command.Run) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rd, ok := r.runs[run.ID()]
+	if !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no command run with ID %v", run.ID())
+	}
+	r.runs[run.ID()] = usecase.CommandRunData{TaskID: rd.TaskID, Run: copyRun(run)}
+	return nil
+}
+
+func copyRun(run *command.Run) *command.Run {
+	return command.NewRawRun(run.ID(), run.Program(), run.Args(), run.Timeout(), run.Status(), run.ExitCode(), run.Stdout(), run.Stderr(), run.LastError(), run.CreatedTime(), run.StartedTime(), run.FinishedTime())
+}
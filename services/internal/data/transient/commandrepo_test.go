@@ -0,0 +1,69 @@
+package transient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestCommandRepo(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewCommandRepo()
+	taskRepo := NewTaskRepo()
+	taskRepo.SetCommandRepo(r)
+	var ids []usecase.TaskID
+	for _, program := range []string{"backup", "rotate-logs"} {
+		tsk := task.New(program, "", user.NewID())
+		tsk.SetCommand(command.NewRun(program, []string{"--all"}, 0))
+		id, ucerr := taskRepo.Add(ctx, tsk)
+		if ucerr != nil {
+			t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+		}
+		if tsk.Command() != nil {
+			t.Errorf("TaskRepo.Add() should clear the task's command once it's queued")
+		}
+		ids = append(ids, id)
+	}
+	if _, ucerr := taskRepo.Add(ctx, task.New("manual task", "", user.NewID())); ucerr != nil {
+		t.Fatalf("TaskRepo.Add() error = %v", ucerr)
+	}
+
+	run, ucerr := r.GetForTask(ctx, ids[0])
+	if ucerr != nil || run.Program() != "backup" {
+		t.Fatalf("CommandRepo.GetForTask() = %v, %v, want the run queued for the task", run, ucerr)
+	}
+	if _, ucerr := r.GetForTask(ctx, 3); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("CommandRepo.GetForTask() for a task without a command error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+
+	pending, _ := r.GetPending(ctx, 10)
+	if len(pending) != 2 || pending[0].TaskID != ids[0] || pending[1].TaskID != ids[1] {
+		t.Fatalf("CommandRepo.GetPending() = %v, want both runs, oldest first", pending)
+	}
+	second, _ := r.GetPending(ctx, 10)
+	pending[0].Run.Start()
+	if claimed, ucerr := r.Start(ctx, pending[0].Run); ucerr != nil || !claimed {
+		t.Fatalf("CommandRepo.Start() = %v, %v, want true", claimed, ucerr)
+	}
+	second[0].Run.Start()
+	if claimed, ucerr := r.Start(ctx, second[0].Run); ucerr != nil || claimed {
+		t.Errorf("CommandRepo.Start() for a run already started = %v, %v, want false", claimed, ucerr)
+	}
+	if pending, _ = r.GetPending(ctx, 10); len(pending) != 1 || pending[0].TaskID != ids[1] {
+		t.Errorf("CommandRepo.GetPending() = %v, want only the run that hasn't started", pending)
+	}
+	if run, _ := r.GetForTask(ctx, ids[0]); run.Status() != command.StatusRunning {
+		t.Errorf("CommandRepo.GetForTask() status = %v, want %v", run.Status(), command.StatusRunning)
+	}
+	if _, ucerr := r.Start(ctx, command.NewRun("backup", nil, 0)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("CommandRepo.Start() with an unknown run error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+	if ucerr := r.Update(ctx, command.NewRun("backup", nil, 0)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("CommandRepo.Update() with an unknown run error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
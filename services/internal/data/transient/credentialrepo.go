@@ -0,0 +1,50 @@
+package transient
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// CredentialRepo maintains an in-memory cache of local user credentials
+type CredentialRepo struct {
+	credentials map[string]*credential.Credential
+}
+
+// NewCredentialRepo instantiates a new CredentialRepo
+func NewCredentialRepo() *CredentialRepo {
+	return &CredentialRepo{credentials: make(map[string]*credential.Credential)}
+}
+
+// Get retrieves a credential, given its username
+func (r *CredentialRepo) Get(ctx context.Context, username string) (*credential.Credential, usecase.Error) {
+	c, ok := r.credentials[username]
+	if !ok {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no credential with username %v", username)
+	}
+	return c, nil
+}
+
+// Add adds a credential
+func (r *CredentialRepo) Add(ctx context.Context, c *credential.Credential) usecase.Error {
+	if _, ok := r.credentials[c.Username()]; ok {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "credential with username %v already exists", c.Username())
+	}
+	for _, existing := range r.credentials {
+		if existing.UserID().Equals(c.UserID()) {
+			return usecase.NewError(usecase.ErrDuplicateRecord, "user ID %v already has a credential", c.UserID())
+		}
+	}
+	r.credentials[c.Username()] = c
+	return nil
+}
+
+// Update updates a credential
+func (r *CredentialRepo) Update(ctx context.Context, c *credential.Credential) usecase.Error {
+	if _, ok := r.credentials[c.Username()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no credential with username %v", c.Username())
+	}
+	r.credentials[c.Username()] = c
+	return nil
+}
@@ -0,0 +1,59 @@
+package transient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestCredentialRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewCredentialRepo()
+	uid := user.NewID()
+	c, _ := credential.New("jane", uid, "password1")
+	r.Add(ctx, c)
+	sameName, _ := credential.New("jane", user.NewID(), "password1")
+	sameUser, _ := credential.New("jane2", uid, "password1")
+	other, _ := credential.New("john", user.NewID(), "password1")
+
+	tests := []struct {
+		name    string
+		c       *credential.Credential
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name:    "should return ErrDuplicateRecord for existing username",
+			c:       sameName,
+			wantErr: usecase.ErrDuplicateRecord,
+		},
+		{
+			name:    "should return ErrDuplicateRecord for user that already has a credential",
+			c:       sameUser,
+			wantErr: usecase.ErrDuplicateRecord,
+		},
+		{
+			name:    "should add credential",
+			c:       other,
+			wantErr: usecase.ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Add(ctx, tt.c)
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("CredentialRepo.Add() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got, _ := r.Get(ctx, tt.c.Username()); got != tt.c {
+				t.Errorf("CredentialRepo.Get() got = %v, want %v", got, tt.c)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package transient
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// NotificationRepo maintains an in-memory cache of users' notification preferences, and queued emails
+// emails and digests are sent in the background, so access is guarded by a mutex and entities are copied in and out
+type NotificationRepo struct {
+	mu      sync.RWMutex
+	prefs   map[user.ID]*notification.Preferences
+	emails  map[notification.ID]*notification.Email
+	emailed map[usecase.TaskID]bool
+}
+
+// NewNotificationRepo instantiates a new NotificationRepo
+func NewNotificationRepo() *NotificationRepo {
+	return &NotificationRepo{
+		prefs:   make(map[user.ID]*notification.Preferences),
+		emails:  make(map[notification.ID]*notification.Email),
+		emailed: make(map[usecase.TaskID]bool),
+	}
+}
+
+// Get retrieves a user's notification preferences
+func (r *NotificationRepo) Get(ctx context.Context, uid user.ID) (*notification.Preferences, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.prefs[uid]
+	if !ok {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no notification preferences for user ID: %v", uid)
+	}
+	c := *p
+	return &c, nil
+}
+
+// GetAllDigests retrieves the preferences of all users with the daily digest enabled
+func (r *NotificationRepo) GetAllDigests(ctx context.Context) ([]*notification.Preferences, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ps := []*notification.Preferences{}
+	for _, p := range r.prefs {
+		if p.Digest() {
+			c := *p
+			ps = append(ps, &c)
+		}
+	}
+	return ps, nil
+}
+
+// Upsert adds or replaces a user's notification preferences
+func (r *NotificationRepo) Upsert(ctx context.Context, p *notification.Preferences) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *p
+	r.prefs[p.UserID()] = &c
+	return nil
+}
+
+// AddEmail queues an email about a task
+func (r *NotificationRepo) AddEmail(ctx context.Context, e *notification.Email, taskID usecase.TaskID) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.emailed[taskID] {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "email for task id %v already exists", taskID)
+	}
+	if _, ok := r.emails[e.ID()]; ok {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "email with ID %v already exists", e.ID())
+	}
+	r.emailed[taskID] = true
+	r.emails[e.ID()] = copyEmail(e)
+	return nil
+}
+
+// GetDueEmails retrieves pending emails due to be attempted at or before the given time, oldest first
+func (r *NotificationRepo) GetDueEmails(ctx context.Context, before time.Time, limit int) ([]*notification.Email, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	es := []*notification.Email{}
+	for _, e := range r.emails {
+		if e.Status() == notification.StatusPending && !e.NextAttemptTime().After(before) {
+			es = append(es, copyEmail(e))
+		}
+	}
+	sort.SliceStable(es, func(i, j int) bool { return es[i].NextAttemptTime().Before(es[j].NextAttemptTime()) })
+	if len(es) > limit {
+		es = es[:limit]
+	}
+	return es, nil
+}
+
+// GetAllEmails retrieves all queued emails, oldest first
+func (r *NotificationRepo) GetAllEmails(ctx context.Context) ([]*notification.Email, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	es := []*notification.Email{}
+	for _, e := range r.emails {
+		es = append(es, copyEmail(e))
+	}
+	sort.SliceStable(es, func(i, j int) bool { return es[i].CreatedTime().Before(es[j].CreatedTime()) })
+	return es, nil
+}
+
+// NextEmailAttemptTime retrieves the time the next pending email is due, zero if there are none
+func (r *NotificationRepo) NextEmailAttemptTime(ctx context.Context) (time.Time, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var next time.Time
+	for _, e := range r.emails {
+		if e.Status() == notification.StatusPending && (next.IsZero() || e.NextAttemptTime().Before(next)) {
+			next = e.NextAttemptTime()
+		}
+	}
+	return next, nil
+}
+
+// UpdateEmail updates a queued email
+func (r *NotificationRepo) UpdateEmail(ctx context.Context, e *notification.Email) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.emails[e.ID()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no email with ID %v", e.ID())
+	}
+	r.emails[e.ID()] = copyEmail(e)
+	return nil
+}
+
+func copyEmail(e *notification.Email) *notification.Email {
+	c := *e
+	return &c
+}
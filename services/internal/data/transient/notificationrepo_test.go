@@ -0,0 +1,76 @@
+package transient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestNotificationRepo(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewNotificationRepo()
+	uid1, uid2 := user.NewID(), user.NewID()
+	p1 := notification.NewRaw(uid1, "one@example.com", true, true, 7, time.Time{})
+	p2 := notification.NewRaw(uid2, "two@example.com", true, false, 0, time.Time{})
+	r.Upsert(ctx, p1)
+	r.Upsert(ctx, p2)
+
+	if _, ucerr := r.Get(ctx, user.NewID()); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("NotificationRepo.Get() of unknown user error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+	got, _ := r.Get(ctx, uid1)
+	got.DigestSent(time.Date(2000, 1, 1, 7, 0, 0, 0, time.UTC))
+	if again, _ := r.Get(ctx, uid1); !again.LastDigestTime().IsZero() {
+		t.Errorf("changing retrieved preferences should not change the stored preferences")
+	}
+	r.Upsert(ctx, got)
+	if again, _ := r.Get(ctx, uid1); !again.LastDigestTime().Equal(got.LastDigestTime()) {
+		t.Errorf("NotificationRepo.Get() last digest = %v, want %v", again.LastDigestTime(), got.LastDigestTime())
+	}
+
+	ps, _ := r.GetAllDigests(ctx)
+	if len(ps) != 1 || !ps[0].UserID().Equals(uid1) {
+		t.Errorf("NotificationRepo.GetAllDigests() = %v, want only user 1's preferences", ps)
+	}
+}
+
+func TestNotificationRepo_emails(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewNotificationRepo()
+	if ucerr := r.AddEmail(ctx, notification.NewEmail("one@example.com", "New task: t1", []byte("<p>t1</p>")), 1); ucerr != nil {
+		t.Fatalf("NotificationRepo.AddEmail() error = %v", ucerr)
+	}
+	if ucerr := r.AddEmail(ctx, notification.NewEmail("one@example.com", "New task: t1", nil), 1); ucerr == nil || ucerr.Code() != usecase.ErrDuplicateRecord {
+		t.Errorf("NotificationRepo.AddEmail() for the same task error = %v, want %v", ucerr, usecase.ErrDuplicateRecord)
+	}
+	if ucerr := r.AddEmail(ctx, notification.NewEmail("two@example.com", "New task: t2", nil), 2); ucerr != nil {
+		t.Fatalf("NotificationRepo.AddEmail() for another task error = %v", ucerr)
+	}
+
+	due, _ := r.GetDueEmails(ctx, clock.Now(), 10)
+	if len(due) != 2 {
+		t.Fatalf("NotificationRepo.GetDueEmails() = %v, want both emails", due)
+	}
+	due[0].Failed("smtp unavailable", webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Hour})
+	if ucerr := r.UpdateEmail(ctx, due[0]); ucerr != nil {
+		t.Fatalf("NotificationRepo.UpdateEmail() error = %v", ucerr)
+	}
+	due, _ = r.GetDueEmails(ctx, clock.Now(), 10)
+	if len(due) != 1 {
+		t.Errorf("NotificationRepo.GetDueEmails() = %v, want only the email that hasn't been attempted", due)
+	}
+	if next, _ := r.NextEmailAttemptTime(ctx); !next.Equal(due[0].NextAttemptTime()) {
+		t.Errorf("NotificationRepo.NextEmailAttemptTime() = %v, want %v", next, due[0].NextAttemptTime())
+	}
+	if ucerr := r.UpdateEmail(ctx, notification.NewEmail("", "", nil)); ucerr == nil || ucerr.Code() != usecase.ErrRecordNotFound {
+		t.Errorf("NotificationRepo.UpdateEmail() with an unknown email error = %v, want %v", ucerr, usecase.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,84 @@
+package transient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// OutboxRepo maintains an in-memory outbox of domain events, in the order they were added
+// events are published in the background, so access is guarded by a mutex and entities are copied in and out
+type OutboxRepo struct {
+	mu     sync.RWMutex
+	events []usecase.EventData
+}
+
+// NewOutboxRepo instantiates a new OutboxRepo
+func NewOutboxRepo() *OutboxRepo {
+	return &OutboxRepo{events: []usecase.EventData{}}
+}
+
+// GetPending retrieves the oldest events that haven't been published yet, and are due to be attempted before the given time
+func (r *OutboxRepo) GetPending(ctx context.Context, before time.Time, limit int) ([]usecase.EventData, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	es := []usecase.EventData{}
+	for _, ed := range r.events {
+		if len(es) >= limit {
+			break
+		}
+		if ed.Event.Status() == event.StatusPending && !ed.Event.NextAttemptTime().After(before) {
+			es = append(es, copyEventData(ed))
+		}
+	}
+	return es, nil
+}
+
+// GetAll retrieves all events, in the order they were added
+func (r *OutboxRepo) GetAll(ctx context.Context) ([]usecase.EventData, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	es := make([]usecase.EventData, len(r.events))
+	for i, ed := range r.events {
+		es[i] = copyEventData(ed)
+	}
+	return es, nil
+}
+
+// Update updates an event's publishing status
+func (r *OutboxRepo) Update(ctx context.Context, e *event.Event) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, ed := range r.events {
+		if ed.Event.ID() == e.ID() {
+			r.events[i].Event = copyEvent(e)
+			return nil
+		}
+	}
+	return usecase.NewError(usecase.ErrRecordNotFound, "no event with ID %v", e.ID())
+}
+
+// add adds the events emitted by a task or schedule to the outbox
+// a nil outbox discards them, for repos that aren't used to publish events
+func (r *OutboxRepo) add(taskID usecase.TaskID, scheduleID usecase.ScheduleID, es []*event.Event) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range es {
+		r.events = append(r.events, copyEventData(usecase.EventData{Event: e, TaskID: taskID, ScheduleID: scheduleID}))
+	}
+}
+
+func copyEventData(ed usecase.EventData) usecase.EventData {
+	ed.Event = copyEvent(ed.Event)
+	return ed
+}
+
+func copyEvent(e *event.Event) *event.Event {
+	return event.NewRaw(e.ID(), e.Type(), e.Status(), e.Attempts(), e.LastError(), e.DeliveredTo(), e.OccurredTime(), e.PublishedTime(), e.NextAttemptTime())
+}
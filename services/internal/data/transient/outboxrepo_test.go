@@ -0,0 +1,55 @@
+package transient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+func TestOutboxRepo(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewOutboxRepo()
+	taskRepo := NewTaskRepo()
+	taskRepo.SetOutboxRepo(r)
+	scheduleRepo := NewScheduleRepo()
+	scheduleRepo.SetOutboxRepo(r)
+
+	tsk := task.New("t1", "", user.NewID())
+	tid, _ := taskRepo.Add(ctx, tsk)
+	s := schedule.New(schedule.Frequency{}, user.NewID())
+	sid, _ := scheduleRepo.Add(ctx, s)
+	s.Pause()
+	scheduleRepo.Update(ctx, sid, s)
+	if len(tsk.Events()) != 0 || len(s.Events()) != 0 {
+		t.Errorf("events should be cleared once added to the outbox, got %v task and %v schedule events", len(tsk.Events()), len(s.Events()))
+	}
+
+	pending, _ := r.GetPending(ctx, time.Now(), 2)
+	if len(pending) != 2 || pending[0].Event.Type() != event.TaskCreated || pending[0].TaskID != tid || pending[1].Event.Type() != event.ScheduleCreated || pending[1].ScheduleID != sid {
+		t.Fatalf("OutboxRepo.GetPending() = %v, want the oldest 2 events in order", pending)
+	}
+
+	pending[0].Event.Published()
+	if ucerr := r.Update(ctx, pending[0].Event); ucerr != nil {
+		t.Fatalf("OutboxRepo.Update() error = %v", ucerr)
+	}
+	pending, _ = r.GetPending(ctx, time.Now(), 10)
+	if len(pending) != 2 || pending[0].Event.Type() != event.ScheduleCreated || pending[1].Event.Type() != event.SchedulePaused {
+		t.Errorf("OutboxRepo.GetPending() = %v, want only the unpublished schedule events", pending)
+	}
+
+	pending[0].Event.Failed("chat: subscriber unavailable", 3, time.Hour)
+	r.Update(ctx, pending[0].Event)
+	if pending, _ = r.GetPending(ctx, time.Now(), 10); len(pending) != 1 || pending[0].Event.Type() != event.SchedulePaused {
+		t.Errorf("OutboxRepo.GetPending() = %v, want failed events left out until they're due", pending)
+	}
+	if ucerr := r.Update(ctx, event.New(event.TaskCleared)); ucerr == nil {
+		t.Errorf("OutboxRepo.Update() with an unknown event should return an error")
+	}
+}
@@ -0,0 +1,33 @@
+package transient
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// RoleRepo maintains an in-memory cache of role permissions
+type RoleRepo struct {
+	perms map[user.Role]int64
+}
+
+// NewRoleRepo instantiates a new RoleRepo
+func NewRoleRepo() *RoleRepo {
+	return &RoleRepo{perms: make(map[user.Role]int64)}
+}
+
+// GetPermissions retrieves a role's permission bitmask
+func (r *RoleRepo) GetPermissions(ctx context.Context, role user.Role) (int64, usecase.Error) {
+	perms, ok := r.perms[role]
+	if !ok {
+		return 0, usecase.NewError(usecase.ErrRecordNotFound, "no permissions set for role %v", role)
+	}
+	return perms, nil
+}
+
+// SetPermissions sets a role's permission bitmask
+func (r *RoleRepo) SetPermissions(ctx context.Context, role user.Role, perms int64) usecase.Error {
+	r.perms[role] = perms
+	return nil
+}
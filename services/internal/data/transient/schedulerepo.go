@@ -1,6 +1,8 @@
 package transient
 
 import (
+	"context"
+
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
@@ -8,8 +10,10 @@ import (
 
 // ScheduleRepo maintains an in-memory cache of tasks
 type ScheduleRepo struct {
-	lastID    int
-	schedules map[usecase.ScheduleID]*schedule.Schedule
+	lastID     int
+	schedules  map[usecase.ScheduleID]*schedule.Schedule
+	workspaces *WorkspaceRepo
+	outbox     *OutboxRepo
 }
 
 // NewScheduleRepo instantiates a new TaskRepo
@@ -17,8 +21,18 @@ func NewScheduleRepo() *ScheduleRepo {
 	return &ScheduleRepo{schedules: make(map[usecase.ScheduleID]*schedule.Schedule)}
 }
 
+// SetWorkspaceRepo sets the workspaces used to determine which users can access shared schedules
+func (r *ScheduleRepo) SetWorkspaceRepo(wr *WorkspaceRepo) {
+	r.workspaces = wr
+}
+
+// SetOutboxRepo sets the outbox events emitted by schedules are added to, they're discarded if it isn't set
+func (r *ScheduleRepo) SetOutboxRepo(o *OutboxRepo) {
+	r.outbox = o
+}
+
 // Get retrieves a schedule entity, given its persistent ID
-func (r *ScheduleRepo) Get(id usecase.ScheduleID) (*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) Get(ctx context.Context, id usecase.ScheduleID) (*schedule.Schedule, usecase.Error) {
 	s, ok := r.schedules[id]
 	if !ok {
 		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no schedule with ID: %v", id)
@@ -27,16 +41,16 @@ func (r *ScheduleRepo) Get(id usecase.ScheduleID) (*schedule.Schedule, usecase.E
 }
 
 // GetForUser retrieves a schedule entity for a user, given its persistent ID
-func (r *ScheduleRepo) GetForUser(id usecase.ScheduleID, uid user.ID) (*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) GetForUser(ctx context.Context, id usecase.ScheduleID, uid user.ID) (*schedule.Schedule, usecase.Error) {
 	s, ok := r.schedules[id]
-	if !ok || !uid.Equals(s.CreatedBy()) {
+	if !ok || !r.workspaces.visibleTo(uid, s.CreatedBy(), s.Workspace()) {
 		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no schedule with ID: %v", id)
 	}
 	return s, nil
 }
 
 // GetAllScheduled retrieves all valid, unpaused schedules
-func (r *ScheduleRepo) GetAllScheduled() (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) GetAllScheduled(ctx context.Context) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
 	scheds := map[usecase.ScheduleID]*schedule.Schedule{}
 	for id, s := range r.schedules {
 		if s.IsValid() && !s.Paused() {
@@ -47,16 +61,16 @@ func (r *ScheduleRepo) GetAllScheduled() (map[usecase.ScheduleID]*schedule.Sched
 }
 
 // GetAll retrieves all schedules
-func (r *ScheduleRepo) GetAll() (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+func (r *ScheduleRepo) GetAll(ctx context.Context) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
 
 	return r.schedules, nil
 }
 
-// GetAllForUser retrieves all schedules created by the given user
-func (r *ScheduleRepo) GetAllForUser(uid user.ID) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
+// GetAllForUser retrieves all valid schedules the given user can access
+func (r *ScheduleRepo) GetAllForUser(ctx context.Context, uid user.ID) (map[usecase.ScheduleID]*schedule.Schedule, usecase.Error) {
 	ss := map[usecase.ScheduleID]*schedule.Schedule{}
 	for id, s := range r.schedules {
-		if s.IsValid() && uid.Equals(s.CreatedBy()) {
+		if s.IsValid() && r.workspaces.visibleTo(uid, s.CreatedBy(), s.Workspace()) {
 			ss[id] = s
 		}
 	}
@@ -64,16 +78,18 @@ func (r *ScheduleRepo) GetAllForUser(uid user.ID) (map[usecase.ScheduleID]*sched
 }
 
 // Add adds a task to the persisence layer
-func (r *ScheduleRepo) Add(s *schedule.Schedule) (usecase.ScheduleID, usecase.Error) {
+func (r *ScheduleRepo) Add(ctx context.Context, s *schedule.Schedule) (usecase.ScheduleID, usecase.Error) {
 	r.lastID++
 	id := usecase.ScheduleID(r.lastID)
 	r.schedules[id] = s
+	r.outbox.add(0, id, s.Events())
+	s.ClearEvents()
 
 	return id, nil
 }
 
 // Update updates a task's persistent data to the given entity values
-func (r *ScheduleRepo) Update(id usecase.ScheduleID, s *schedule.Schedule) usecase.Error {
+func (r *ScheduleRepo) Update(ctx context.Context, id usecase.ScheduleID, s *schedule.Schedule) usecase.Error {
 
 	_, ok := r.schedules[id]
 	if !ok {
@@ -81,6 +97,27 @@ func (r *ScheduleRepo) Update(id usecase.ScheduleID, s *schedule.Schedule) useca
 	}
 
 	r.schedules[id] = s
+	r.outbox.add(0, id, s.Events())
+	s.ClearEvents()
 
 	return nil
 }
+
+// SearchRecurringTasks finds recurring tasks in a user's valid schedules whose name or description contains the query
+func (r *ScheduleRepo) SearchRecurringTasks(ctx context.Context, query string, uid user.ID) ([]usecase.SearchResult, usecase.Error) {
+	m := newMatcher(query)
+	rs := []usecase.SearchResult{}
+	for id, s := range r.schedules {
+		if !s.IsValid() || !r.workspaces.visibleTo(uid, s.CreatedBy(), s.Workspace()) {
+			continue
+		}
+		for _, rt := range s.Tasks() {
+			if res, ok := m.match(rt.Name(), rt.Description()); ok {
+				res.Type = usecase.SearchResultRecurringTask
+				res.ScheduleID = id
+				rs = append(rs, res)
+			}
+		}
+	}
+	return rs, nil
+}
@@ -1,6 +1,7 @@
 package transient
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -29,10 +30,12 @@ func TestNewScheduleRepo(t *testing.T) {
 }
 
 func TestScheduleRepo_Get(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewScheduleRepo()
 	emptyHourlyFreq, _ := schedule.NewHourFrequency([]int{0})
 	emptyHourlySched := schedule.New(emptyHourlyFreq, user.ID{})
-	emptyID, _ := r.Add(emptyHourlySched)
+	emptyID, _ := r.Add(ctx, emptyHourlySched)
 
 	type args struct {
 		id usecase.ScheduleID
@@ -54,7 +57,7 @@ func TestScheduleRepo_Get(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Get(tt.args.id)
+			got, err := tt.r.Get(ctx, tt.args.id)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ScheduleRepo.Get() got = %v, want %v", got, tt.want)
 			}
@@ -67,13 +70,15 @@ func TestScheduleRepo_Get(t *testing.T) {
 }
 
 func TestScheduleRepo_GetAll(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewScheduleRepo()
 	emptyHourlyFreq1, _ := schedule.NewHourFrequency([]int{0})
 	emptyHourlySched1 := schedule.New(emptyHourlyFreq1, user.ID{})
 	emptyHourlyFreq2, _ := schedule.NewHourFrequency([]int{0})
 	emptyHourlySched2 := schedule.New(emptyHourlyFreq2, user.ID{})
-	id1, _ := r.Add(emptyHourlySched1)
-	id2, _ := r.Add(emptyHourlySched2)
+	id1, _ := r.Add(ctx, emptyHourlySched1)
+	id2, _ := r.Add(ctx, emptyHourlySched2)
 
 	tests := []struct {
 		name    string
@@ -90,7 +95,7 @@ func TestScheduleRepo_GetAll(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetAll()
+			got, err := tt.r.GetAll(ctx)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ScheduleRepo.GetAll() got = %v, want %v", got, tt.want)
 			}
@@ -103,6 +108,8 @@ func TestScheduleRepo_GetAll(t *testing.T) {
 }
 
 func TestScheduleRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewScheduleRepo()
 	emptyHourlyFreq1, _ := schedule.NewHourFrequency([]int{0})
 	emptyHourlySched1 := schedule.New(emptyHourlyFreq1, user.ID{})
@@ -127,7 +134,7 @@ func TestScheduleRepo_Add(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Add(tt.args.s)
+			got, err := tt.r.Add(ctx, tt.args.s)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ScheduleRepo.Add() got = %v, want %v", got, tt.want)
 			}
@@ -140,10 +147,12 @@ func TestScheduleRepo_Add(t *testing.T) {
 }
 
 func TestScheduleRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewScheduleRepo()
 	hourlyFreq1, _ := schedule.NewHourFrequency([]int{0})
 	hourlySched1 := schedule.New(hourlyFreq1, user.ID{})
-	id1, _ := r.Add(hourlySched1)
+	id1, _ := r.Add(ctx, hourlySched1)
 	hourlySched1.Pause()
 
 	type args struct {
@@ -165,7 +174,7 @@ func TestScheduleRepo_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Update(tt.args.id, tt.args.s)
+			err := tt.r.Update(ctx, tt.args.id, tt.args.s)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("ScheduleRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -173,3 +182,68 @@ func TestScheduleRepo_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestScheduleRepo_SearchRecurringTasks(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewScheduleRepo()
+	uid := user.New("test user for SearchRecurringTasks").ID()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s1 := schedule.New(f, uid)
+	s1.AddTask(schedule.NewRecurringTask("backup", "nightly database backup"))
+	s1.AddTask(schedule.NewRecurringTask("vacuum", ""))
+	id1, _ := r.Add(ctx, s1)
+	s2 := schedule.New(f, uid)
+	s2.AddTask(schedule.NewRecurringTask("backup removed", ""))
+	s2.Remove()
+	r.Add(ctx, s2)
+
+	type args struct {
+		query string
+		uid   user.ID
+	}
+	tests := []struct {
+		name    string
+		r       *ScheduleRepo
+		args    args
+		want    []usecase.SearchResult
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name: "should find matching recurring tasks in valid schedules",
+			r:    r,
+			args: args{query: "backup", uid: uid},
+			want: []usecase.SearchResult{
+				{
+					Type:                 usecase.SearchResultRecurringTask,
+					ScheduleID:           id1,
+					Name:                 "backup",
+					Description:          "nightly database backup",
+					NameHighlight:        usecase.MatchStart + "backup" + usecase.MatchStop,
+					DescriptionHighlight: "nightly database " + usecase.MatchStart + "backup" + usecase.MatchStop,
+					Rank:                 nameMatchRank + descriptionMatchRank,
+				},
+			},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should not find other users' recurring tasks",
+			r:       r,
+			args:    args{query: "backup", uid: user.ID{}},
+			want:    []usecase.SearchResult{},
+			wantErr: usecase.ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.SearchRecurringTasks(ctx, tt.args.query, tt.args.uid)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScheduleRepo.SearchRecurringTasks() got = %v, want %v", got, tt.want)
+			}
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ScheduleRepo.SearchRecurringTasks() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package transient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// ScheduleRunRepo maintains an in-memory cache of schedule runs
+// runs are recorded by the scheduler in the background, so access is guarded by a mutex, runs can't be changed so aren't copied
+type ScheduleRunRepo struct {
+	mu     sync.RWMutex
+	lastID int
+	runs   map[usecase.ScheduleID][]usecase.ScheduleRunData
+}
+
+// NewScheduleRunRepo instantiates a new ScheduleRunRepo
+func NewScheduleRunRepo() *ScheduleRunRepo {
+	return &ScheduleRunRepo{runs: make(map[usecase.ScheduleID][]usecase.ScheduleRunData)}
+}
+
+// Add appends a run to a schedule's run history
+func (r *ScheduleRunRepo) Add(ctx context.Context, id usecase.ScheduleID, run *schedule.Run) (usecase.ScheduleRunID, usecase.Error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastID++
+	rid := usecase.ScheduleRunID(r.lastID)
+	r.runs[id] = append(r.runs[id], usecase.ScheduleRunData{ScheduleRunID: rid, ScheduleID: id, Run: run})
+
+	return rid, nil
+}
+
+// GetForSchedule retrieves a page of a schedule's runs, newest first, along with the total number of runs
+func (r *ScheduleRunRepo) GetForSchedule(ctx context.Context, id usecase.ScheduleID, p usecase.Page) ([]usecase.ScheduleRunData, int, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := r.runs[id]
+	rs := []usecase.ScheduleRunData{}
+	for i := len(all) - 1 - p.Offset; i >= 0 && len(rs) < p.Limit; i-- {
+		rs = append(rs, all[i])
+	}
+	return rs, len(all), nil
+}
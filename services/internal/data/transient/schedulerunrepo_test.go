@@ -0,0 +1,50 @@
+package transient
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestScheduleRunRepo_GetForSchedule(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewScheduleRunRepo()
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	var want []usecase.ScheduleRunData
+	for i := 0; i < 3; i++ {
+		run := schedule.NewCheckRun(now.Add(time.Duration(i)*time.Hour), i, nil)
+		id, _ := r.Add(ctx, 1, run)
+		want = append([]usecase.ScheduleRunData{{ScheduleRunID: id, ScheduleID: 1, Run: run}}, want...)
+	}
+	r.Add(ctx, 2, schedule.NewCheckRun(now, 0, nil))
+
+	tests := []struct {
+		name      string
+		id        usecase.ScheduleID
+		page      usecase.Page
+		want      []usecase.ScheduleRunData
+		wantTotal int
+	}{
+		{name: "should get runs newest first", id: 1, page: usecase.Page{Limit: 10}, want: want, wantTotal: 3},
+		{name: "should get up to the page limit", id: 1, page: usecase.Page{Limit: 2}, want: want[:2], wantTotal: 3},
+		{name: "should skip the page offset", id: 1, page: usecase.Page{Limit: 2, Offset: 2}, want: want[2:], wantTotal: 3},
+		{name: "offset past the end should get no runs", id: 1, page: usecase.Page{Limit: 2, Offset: 5}, want: []usecase.ScheduleRunData{}, wantTotal: 3},
+		{name: "schedule without runs should get no runs", id: 3, page: usecase.Page{Limit: 2}, want: []usecase.ScheduleRunData{}, wantTotal: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, ucerr := r.GetForSchedule(ctx, tt.id, tt.page)
+			if ucerr != nil {
+				t.Fatalf("ScheduleRunRepo.GetForSchedule() error = %v", ucerr)
+			}
+			if !reflect.DeepEqual(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("ScheduleRunRepo.GetForSchedule() = %v, %v, want %v, %v", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package transient
+
+import (
+	"regexp"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Rank weights for substring matches, mirroring the name/description weighting of the Postgres search vectors
+const (
+	nameMatchRank        = 1.0
+	descriptionMatchRank = 0.5
+)
+
+// matcher performs a case-insensitive substring match, used as a simple fallback for full-text search
+type matcher struct {
+	re *regexp.Regexp
+}
+
+func newMatcher(query string) matcher {
+	return matcher{regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))}
+}
+
+// match returns a search result for the name and description if either contains the query
+func (m matcher) match(name string, description string) (usecase.SearchResult, bool) {
+	r := usecase.SearchResult{
+		Name:                 name,
+		Description:          description,
+		NameHighlight:        m.highlight(name),
+		DescriptionHighlight: m.highlight(description),
+	}
+	if m.re.MatchString(name) {
+		r.Rank += nameMatchRank
+	}
+	if m.re.MatchString(description) {
+		r.Rank += descriptionMatchRank
+	}
+	return r, r.Rank > 0
+}
+
+func (m matcher) highlight(s string) string {
+	return m.re.ReplaceAllString(s, usecase.MatchStart+"$0"+usecase.MatchStop)
+}
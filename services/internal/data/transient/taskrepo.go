@@ -1,6 +1,9 @@
 package transient
 
 import (
+	"context"
+	"time"
+
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
@@ -8,8 +11,12 @@ import (
 
 // TaskRepo maintains an in-memory cache of tasks
 type TaskRepo struct {
-	lastID int
-	tasks  map[usecase.TaskID]*task.Task
+	lastID     int
+	tasks      map[usecase.TaskID]*task.Task
+	workspaces *WorkspaceRepo
+	outbox     *OutboxRepo
+	actions    *ActionRepo
+	commands   *CommandRepo
 }
 
 // NewTaskRepo instantiates a new TaskRepo
@@ -17,8 +24,28 @@ func NewTaskRepo() *TaskRepo {
 	return &TaskRepo{tasks: make(map[usecase.TaskID]*task.Task)}
 }
 
+// SetWorkspaceRepo sets the workspaces used to determine which users can access shared tasks
+func (r *TaskRepo) SetWorkspaceRepo(wr *WorkspaceRepo) {
+	r.workspaces = wr
+}
+
+// SetOutboxRepo sets the outbox events emitted by tasks are added to, they're discarded if it isn't set
+func (r *TaskRepo) SetOutboxRepo(o *OutboxRepo) {
+	r.outbox = o
+}
+
+// SetActionRepo sets the repo HTTP action calls queued for generated tasks are added to, they're discarded if it isn't set
+func (r *TaskRepo) SetActionRepo(a *ActionRepo) {
+	r.actions = a
+}
+
+// SetCommandRepo sets the repo command runs queued for generated tasks are added to, they're discarded if it isn't set
+func (r *TaskRepo) SetCommandRepo(c *CommandRepo) {
+	r.commands = c
+}
+
 // Get retrieves a task entity, given its persistent ID
-func (r *TaskRepo) Get(id usecase.TaskID) (*task.Task, usecase.Error) {
+func (r *TaskRepo) Get(ctx context.Context, id usecase.TaskID) (*task.Task, usecase.Error) {
 
 	// Try to retrieve from cache
 	t, ok := r.tasks[id]
@@ -29,12 +56,12 @@ func (r *TaskRepo) Get(id usecase.TaskID) (*task.Task, usecase.Error) {
 }
 
 // GetForUser retrieves a task entity, given its persistent ID and user ID
-func (r *TaskRepo) GetForUser(id usecase.TaskID, uid user.ID) (*task.Task, usecase.Error) {
+func (r *TaskRepo) GetForUser(ctx context.Context, id usecase.TaskID, uid user.ID) (*task.Task, usecase.Error) {
 
 	// Try to retrieve from cache
 	t, ok := r.tasks[id]
 	if ok {
-		if uid.Equals(t.CreatedBy()) {
+		if r.workspaces.visibleTo(uid, t.CreatedBy(), t.Workspace()) {
 			return t, nil
 		}
 	}
@@ -42,33 +69,51 @@ func (r *TaskRepo) GetForUser(id usecase.TaskID, uid user.ID) (*task.Task, useca
 }
 
 // GetAll retrieves all tasks
-func (r *TaskRepo) GetAll() (map[usecase.TaskID]*task.Task, usecase.Error) {
+func (r *TaskRepo) GetAll(ctx context.Context) (map[usecase.TaskID]*task.Task, usecase.Error) {
 
 	return r.tasks, nil
 }
 
-// GetAllForUser retrieves all tasks for a user
-func (r *TaskRepo) GetAllForUser(uid user.ID) (map[usecase.TaskID]*task.Task, usecase.Error) {
+// GetAllForUser retrieves all tasks a user can access
+func (r *TaskRepo) GetAllForUser(ctx context.Context, uid user.ID) (map[usecase.TaskID]*task.Task, usecase.Error) {
 	tasks := make(map[usecase.TaskID]*task.Task)
 	for tid, task := range r.tasks {
-		if uid.Equals(task.CreatedBy()) {
+		if r.workspaces.visibleTo(uid, task.CreatedBy(), task.Workspace()) {
 			tasks[tid] = task
 		}
 	}
 	return tasks, nil
 }
 
+// GetAllDue retrieves open tasks due after one time, up to and including another
+func (r *TaskRepo) GetAllDue(ctx context.Context, after time.Time, before time.Time) (map[usecase.TaskID]*task.Task, usecase.Error) {
+	tasks := make(map[usecase.TaskID]*task.Task)
+	for tid, t := range r.tasks {
+		due := t.DueTime()
+		if t.IsValid() && t.CompletedTime().IsZero() && !due.IsZero() && due.After(after) && !due.After(before) {
+			tasks[tid] = t
+		}
+	}
+	return tasks, nil
+}
+
 // Add adds a task to the persisence layer
-func (r *TaskRepo) Add(t *task.Task) (usecase.TaskID, usecase.Error) {
+func (r *TaskRepo) Add(ctx context.Context, t *task.Task) (usecase.TaskID, usecase.Error) {
 	r.lastID++
 	id := usecase.TaskID(r.lastID)
 	r.tasks[id] = t
+	r.outbox.add(id, 0, t.Events())
+	t.ClearEvents()
+	r.actions.add(id, t.Action())
+	t.SetAction(nil)
+	r.commands.add(id, t.Command())
+	t.SetCommand(nil)
 
 	return id, nil
 }
 
 // Update updates a task's persistent data to the given entity values
-func (r *TaskRepo) Update(id usecase.TaskID, t *task.Task) usecase.Error {
+func (r *TaskRepo) Update(ctx context.Context, id usecase.TaskID, t *task.Task) usecase.Error {
 
 	_, ok := r.tasks[id]
 	if !ok {
@@ -76,6 +121,25 @@ func (r *TaskRepo) Update(id usecase.TaskID, t *task.Task) usecase.Error {
 	}
 
 	r.tasks[id] = t
+	r.outbox.add(id, 0, t.Events())
+	t.ClearEvents()
 
 	return nil
 }
+
+// Search finds a user's valid tasks whose name or description contains the query
+func (r *TaskRepo) Search(ctx context.Context, query string, uid user.ID) ([]usecase.SearchResult, usecase.Error) {
+	m := newMatcher(query)
+	rs := []usecase.SearchResult{}
+	for id, t := range r.tasks {
+		if !t.IsValid() || !r.workspaces.visibleTo(uid, t.CreatedBy(), t.Workspace()) {
+			continue
+		}
+		if res, ok := m.match(t.Name(), t.Description()); ok {
+			res.Type = usecase.SearchResultTask
+			res.TaskID = id
+			rs = append(rs, res)
+		}
+	}
+	return rs, nil
+}
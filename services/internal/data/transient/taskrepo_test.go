@@ -1,8 +1,10 @@
 package transient
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
@@ -29,9 +31,11 @@ func TestNewTaskRepo(t *testing.T) {
 }
 
 func TestTaskRepo_Get(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewTaskRepo()
 	newTask := task.New("", "", user.ID{})
-	id, _ := r.Add(newTask)
+	id, _ := r.Add(ctx, newTask)
 
 	type args struct {
 		id usecase.TaskID
@@ -53,7 +57,7 @@ func TestTaskRepo_Get(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Get(tt.args.id)
+			got, err := tt.r.Get(ctx, tt.args.id)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("TaskRepo.Get() got = %v, want %v", got, tt.want)
 			}
@@ -66,11 +70,13 @@ func TestTaskRepo_Get(t *testing.T) {
 }
 
 func TestTaskRepo_GetAll(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewTaskRepo()
 	newTask1 := task.New("", "", user.ID{})
 	newTask2 := task.New("", "", user.ID{})
-	id1, _ := r.Add(newTask1)
-	id2, _ := r.Add(newTask2)
+	id1, _ := r.Add(ctx, newTask1)
+	id2, _ := r.Add(ctx, newTask2)
 
 	tests := []struct {
 		name    string
@@ -87,7 +93,7 @@ func TestTaskRepo_GetAll(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetAll()
+			got, err := tt.r.GetAll(ctx)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("TaskRepo.GetAll() got = %v, want %v", got, tt.want)
 			}
@@ -100,6 +106,8 @@ func TestTaskRepo_GetAll(t *testing.T) {
 }
 
 func TestTaskRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewTaskRepo()
 	newTask := task.New("", "", user.ID{})
 
@@ -123,7 +131,7 @@ func TestTaskRepo_Add(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Add(tt.args.t)
+			got, err := tt.r.Add(ctx, tt.args.t)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("TaskRepo.Add() got = %v, want %v", got, tt.want)
 			}
@@ -136,9 +144,11 @@ func TestTaskRepo_Add(t *testing.T) {
 }
 
 func TestTaskRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewTaskRepo()
 	newTask := task.New("", "", user.ID{})
-	id1, _ := r.Add(newTask)
+	id1, _ := r.Add(ctx, newTask)
 	newTask.CompleteNow()
 
 	type args struct {
@@ -160,7 +170,7 @@ func TestTaskRepo_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Update(tt.args.id, tt.args.t)
+			err := tt.r.Update(ctx, tt.args.id, tt.args.t)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("TaskRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -168,3 +178,85 @@ func TestTaskRepo_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskRepo_Search(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewTaskRepo()
+	uid := user.New("test user for task Search").ID()
+	id1, _ := r.Add(ctx, task.New("Pay invoices", "", uid))
+	r.Add(ctx, task.New("Pay rent", "", user.ID{}))
+
+	type args struct {
+		query string
+		uid   user.ID
+	}
+	tests := []struct {
+		name    string
+		r       *TaskRepo
+		args    args
+		want    []usecase.SearchResult
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name: "should find case-insensitive substring matches for the user",
+			r:    r,
+			args: args{query: "INVOICE", uid: uid},
+			want: []usecase.SearchResult{
+				{Type: usecase.SearchResultTask, TaskID: id1, Name: "Pay invoices", NameHighlight: "Pay " + usecase.MatchStart + "invoice" + usecase.MatchStop + "s", Rank: nameMatchRank},
+			},
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should not find other users' tasks",
+			r:       r,
+			args:    args{query: "rent", uid: uid},
+			want:    []usecase.SearchResult{},
+			wantErr: usecase.ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Search(ctx, tt.args.query, tt.args.uid)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TaskRepo.Search() got = %v, want %v", got, tt.want)
+			}
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("TaskRepo.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestTaskRepo_GetAllDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r := NewTaskRepo()
+	uid := user.NewID()
+	newTask := func(name string, due time.Time) *task.Task {
+		tk := task.New(name, "", uid)
+		tk.SetDueTime(due)
+		return tk
+	}
+	dueID, _ := r.Add(ctx, newTask("due", now.Add(-time.Minute)))
+	atID, _ := r.Add(ctx, newTask("due now", now))
+	r.Add(ctx, newTask("due before", now.Add(-time.Hour)))
+	r.Add(ctx, newTask("due later", now.Add(time.Minute)))
+	r.Add(ctx, newTask("no due time", time.Time{}))
+	completed := newTask("completed", now.Add(-time.Minute))
+	completed.CompleteNow()
+	r.Add(ctx, completed)
+	cleared := newTask("cleared", now.Add(-time.Minute))
+	cleared.Clear()
+	r.Add(ctx, cleared)
+
+	got, err := r.GetAllDue(ctx, now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatalf("TaskRepo.GetAllDue() error = %v", err)
+	}
+	if len(got) != 2 || got[dueID] == nil || got[atID] == nil {
+		t.Errorf("TaskRepo.GetAllDue() = %v, want tasks %v and %v", got, dueID, atID)
+	}
+}
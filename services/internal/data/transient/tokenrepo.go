@@ -0,0 +1,67 @@
+package transient
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// TokenRepo maintains an in-memory cache of personal access tokens
+type TokenRepo struct {
+	tokens map[token.ID]*token.Token
+}
+
+// NewTokenRepo instantiates a new TokenRepo
+func NewTokenRepo() *TokenRepo {
+	return &TokenRepo{tokens: make(map[token.ID]*token.Token)}
+}
+
+// Get retrieves a token, given its ID
+func (r *TokenRepo) Get(ctx context.Context, id token.ID) (*token.Token, usecase.Error) {
+	t, ok := r.tokens[id]
+	if !ok {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no token with ID: %v", id)
+	}
+	return t, nil
+}
+
+// GetByHash retrieves a token, given the hash of its secret
+func (r *TokenRepo) GetByHash(ctx context.Context, hash string) (*token.Token, usecase.Error) {
+	for _, t := range r.tokens {
+		if t.Hash() == hash {
+			return t, nil
+		}
+	}
+	return nil, usecase.NewError(usecase.ErrRecordNotFound, "no token with matching hash")
+}
+
+// GetAllForUser retrieves all of a user's tokens
+func (r *TokenRepo) GetAllForUser(ctx context.Context, uid user.ID) ([]*token.Token, usecase.Error) {
+	ts := []*token.Token{}
+	for _, t := range r.tokens {
+		if t.UserID().Equals(uid) {
+			ts = append(ts, t)
+		}
+	}
+	return ts, nil
+}
+
+// Add adds a token
+func (r *TokenRepo) Add(ctx context.Context, t *token.Token) usecase.Error {
+	if _, ok := r.tokens[t.ID()]; ok {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "token with ID %v already exists", t.ID())
+	}
+	r.tokens[t.ID()] = t
+	return nil
+}
+
+// Update updates a token
+func (r *TokenRepo) Update(ctx context.Context, t *token.Token) usecase.Error {
+	if _, ok := r.tokens[t.ID()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no token with ID %v", t.ID())
+	}
+	r.tokens[t.ID()] = t
+	return nil
+}
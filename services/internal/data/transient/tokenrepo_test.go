@@ -0,0 +1,57 @@
+package transient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestTokenRepo_GetByHash(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewTokenRepo()
+	tok, secret, _ := token.New("ci", user.NewID(), []string{"read:task"}, time.Time{})
+	r.Add(ctx, tok)
+
+	type args struct {
+		hash string
+	}
+	tests := []struct {
+		name    string
+		r       *TokenRepo
+		args    args
+		want    *token.Token
+		wantErr usecase.ErrorCode
+	}{
+		{
+			name:    "should get token by the hash of its secret",
+			r:       r,
+			args:    args{hash: token.Hash(secret)},
+			want:    tok,
+			wantErr: usecase.ErrNone,
+		},
+		{
+			name:    "should return ErrRecordNotFound for unknown hash",
+			r:       r,
+			args:    args{hash: token.Hash("unknown")},
+			want:    nil,
+			wantErr: usecase.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.GetByHash(ctx, tt.args.hash)
+			if got != tt.want {
+				t.Errorf("TokenRepo.GetByHash() got = %v, want %v", got, tt.want)
+			}
+			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("TokenRepo.GetByHash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package transient
 
 import (
+	"context"
+
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
@@ -22,7 +24,7 @@ func NewUserRepo() *UserRepo {
 }
 
 // AddExternal adds a user to the memory cache
-func (r *UserRepo) AddExternal(u *user.User, providerID string, externalID string) usecase.Error {
+func (r *UserRepo) AddExternal(ctx context.Context, u *user.User, providerID string, externalID string) usecase.Error {
 	id := u.ID()
 	if (id == user.ID{}) {
 		return usecase.NewError(usecase.ErrInvalidID, "user ID cannot be empty when adding to repo")
@@ -40,7 +42,7 @@ func (r *UserRepo) AddExternal(u *user.User, providerID string, externalID strin
 }
 
 // Update updates a user
-func (r *UserRepo) Update(u *user.User) usecase.Error {
+func (r *UserRepo) Update(ctx context.Context, u *user.User) usecase.Error {
 	id := u.ID()
 	if _, ok := r.users[id]; !ok {
 		return usecase.NewError(usecase.ErrRecordNotFound, "no user with ID %v", id)
@@ -51,8 +53,17 @@ func (r *UserRepo) Update(u *user.User) usecase.Error {
 	return nil
 }
 
+// Get gets a user given its ID
+func (r *UserRepo) Get(ctx context.Context, id user.ID) (*user.User, usecase.Error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no user with ID %v", id)
+	}
+	return u, nil
+}
+
 // GetExternal gets a user given its provider and external ID
-func (r *UserRepo) GetExternal(providerID string, externalID string) (*user.User, usecase.Error) {
+func (r *UserRepo) GetExternal(ctx context.Context, providerID string, externalID string) (*user.User, usecase.Error) {
 
 	provider := providerKey{providerID, externalID}
 	id, ok := r.external[provider]
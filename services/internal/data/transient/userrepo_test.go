@@ -1,6 +1,7 @@
 package transient
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -17,13 +18,15 @@ func newUserRaw(t *testing.T, idStr string, displayname string) *user.User {
 }
 
 func TestUserRepo_AddExternal(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewUserRepo()
 	emptyUser := user.New("")
 	basicUser := user.New("Test Displayname")
 	dupeUser1 := newUserRaw(t, "111e1111-e89b-12d3-a456-426655440000", "displayname1")
 	dupeUser2 := newUserRaw(t, "111e1111-e89b-12d3-a456-426655440000", "displayname2")
 
-	if err := r.AddExternal(dupeUser1, "p1", "e1"); err != nil {
+	if err := r.AddExternal(ctx, dupeUser1, "p1", "e1"); err != nil {
 		t.Fatalf("Error adding user")
 	}
 
@@ -59,7 +62,7 @@ func TestUserRepo_AddExternal(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.AddExternal(tt.args.u, tt.args.providerID, tt.args.externalID)
+			err := tt.r.AddExternal(ctx, tt.args.u, tt.args.providerID, tt.args.externalID)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("UserRepo.Add() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -68,9 +71,11 @@ func TestUserRepo_AddExternal(t *testing.T) {
 }
 
 func TestUserRepo_Update(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewUserRepo()
 	u := user.New("display name")
-	r.AddExternal(u, "", "")
+	r.AddExternal(ctx, u, "", "")
 	u.UpdateDisplayName("new display name")
 
 	type args struct {
@@ -91,7 +96,7 @@ func TestUserRepo_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Update(tt.args.u)
+			err := tt.r.Update(ctx, tt.args.u)
 			if ((err == nil) != (tt.wantErr == usecase.ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("UserRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -100,9 +105,11 @@ func TestUserRepo_Update(t *testing.T) {
 }
 
 func TestUserRepo_GetExternal(t *testing.T) {
+	ctx := context.Background()
+
 	r := NewUserRepo()
 	u := user.New("display name")
-	r.AddExternal(u, "p1", "e1")
+	r.AddExternal(ctx, u, "p1", "e1")
 
 	type args struct {
 		providerID string
@@ -132,7 +139,7 @@ func TestUserRepo_GetExternal(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.GetExternal(tt.args.providerID, tt.args.externalID)
+			got, err := tt.r.GetExternal(ctx, tt.args.providerID, tt.args.externalID)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("UserRepo.GetExternal() got = %v, want %v", got, tt.want)
 			}
@@ -0,0 +1,173 @@
+package transient
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// WebhookRepo maintains an in-memory cache of webhooks and their deliveries
+// deliveries are attempted in the background, so access is guarded by a mutex and entities are copied in and out
+type WebhookRepo struct {
+	mu         sync.RWMutex
+	webhooks   map[webhook.ID]*webhook.Webhook
+	deliveries map[webhook.ID]*webhook.Delivery
+}
+
+// NewWebhookRepo instantiates a new WebhookRepo
+func NewWebhookRepo() *WebhookRepo {
+	return &WebhookRepo{webhooks: make(map[webhook.ID]*webhook.Webhook), deliveries: make(map[webhook.ID]*webhook.Delivery)}
+}
+
+// Get retrieves a webhook, given its ID
+func (r *WebhookRepo) Get(ctx context.Context, id webhook.ID) (*webhook.Webhook, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	w, ok := r.webhooks[id]
+	if !ok {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no webhook with ID: %v", id)
+	}
+	return copyWebhook(w), nil
+}
+
+// GetAllForUser retrieves all of a user's webhooks
+func (r *WebhookRepo) GetAllForUser(ctx context.Context, uid user.ID) ([]*webhook.Webhook, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ws := []*webhook.Webhook{}
+	for _, w := range r.webhooks {
+		if w.UserID().Equals(uid) {
+			ws = append(ws, copyWebhook(w))
+		}
+	}
+	return ws, nil
+}
+
+// GetSubscribed retrieves the active webhooks of the given users that are subscribed to an event type
+func (r *WebhookRepo) GetSubscribed(ctx context.Context, e webhook.EventType, uids []user.ID) ([]*webhook.Webhook, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ws := []*webhook.Webhook{}
+	for _, w := range r.webhooks {
+		if !w.Subscribes(e) {
+			continue
+		}
+		for _, uid := range uids {
+			if w.UserID().Equals(uid) {
+				ws = append(ws, copyWebhook(w))
+				break
+			}
+		}
+	}
+	return ws, nil
+}
+
+// Add adds a webhook
+func (r *WebhookRepo) Add(ctx context.Context, w *webhook.Webhook) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[w.ID()]; ok {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "webhook with ID %v already exists", w.ID())
+	}
+	r.webhooks[w.ID()] = copyWebhook(w)
+	return nil
+}
+
+// Update updates a webhook
+func (r *WebhookRepo) Update(ctx context.Context, w *webhook.Webhook) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[w.ID()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no webhook with ID %v", w.ID())
+	}
+	r.webhooks[w.ID()] = copyWebhook(w)
+	return nil
+}
+
+// GetDeliveries retrieves a webhook's most recent deliveries, newest first
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, id webhook.ID, limit int) ([]*webhook.Delivery, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ds := []*webhook.Delivery{}
+	for _, d := range r.deliveries {
+		if d.WebhookID() == id {
+			ds = append(ds, copyDelivery(d))
+		}
+	}
+	sort.SliceStable(ds, func(i, j int) bool { return ds[i].CreatedTime().After(ds[j].CreatedTime()) })
+	if len(ds) > limit {
+		ds = ds[:limit]
+	}
+	return ds, nil
+}
+
+// GetDueDeliveries retrieves pending deliveries due to be attempted at or before the given time, oldest first
+func (r *WebhookRepo) GetDueDeliveries(ctx context.Context, before time.Time, limit int) ([]*webhook.Delivery, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ds := []*webhook.Delivery{}
+	for _, d := range r.deliveries {
+		if d.Status() == webhook.DeliveryPending && !d.NextAttemptTime().After(before) {
+			ds = append(ds, copyDelivery(d))
+		}
+	}
+	sort.SliceStable(ds, func(i, j int) bool { return ds[i].NextAttemptTime().Before(ds[j].NextAttemptTime()) })
+	if len(ds) > limit {
+		ds = ds[:limit]
+	}
+	return ds, nil
+}
+
+// NextDeliveryTime retrieves the time the next pending delivery is due, zero if there are none
+func (r *WebhookRepo) NextDeliveryTime(ctx context.Context) (time.Time, usecase.Error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var next time.Time
+	for _, d := range r.deliveries {
+		if d.Status() == webhook.DeliveryPending && (next.IsZero() || d.NextAttemptTime().Before(next)) {
+			next = d.NextAttemptTime()
+		}
+	}
+	return next, nil
+}
+
+// AddDeliveries adds webhook deliveries
+func (r *WebhookRepo) AddDeliveries(ctx context.Context, ds []*webhook.Delivery) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range ds {
+		if _, ok := r.deliveries[d.ID()]; ok {
+			return usecase.NewError(usecase.ErrDuplicateRecord, "delivery with ID %v already exists", d.ID())
+		}
+	}
+	for _, d := range ds {
+		r.deliveries[d.ID()] = copyDelivery(d)
+	}
+	return nil
+}
+
+// UpdateDelivery updates a webhook delivery
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, d *webhook.Delivery) usecase.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.deliveries[d.ID()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no delivery with ID %v", d.ID())
+	}
+	r.deliveries[d.ID()] = copyDelivery(d)
+	return nil
+}
+
+func copyWebhook(w *webhook.Webhook) *webhook.Webhook {
+	c := *w
+	return &c
+}
+
+func copyDelivery(d *webhook.Delivery) *webhook.Delivery {
+	c := *d
+	return &c
+}
@@ -0,0 +1,103 @@
+package transient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+func TestWebhookRepo_GetSubscribed(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewWebhookRepo()
+	uid1, uid2, uid3 := user.NewID(), user.NewID(), user.NewID()
+	w1, _ := webhook.New("https://example.com/1", []webhook.EventType{webhook.EventTaskCreated}, "", uid1)
+	w2, _ := webhook.New("https://example.com/2", []webhook.EventType{webhook.EventTaskCreated, webhook.EventTaskCleared}, "", uid2)
+	w3, _ := webhook.New("https://example.com/3", []webhook.EventType{webhook.EventTaskCreated}, "", uid3)
+	removed, _ := webhook.New("https://example.com/removed", []webhook.EventType{webhook.EventTaskCreated}, "", uid1)
+	removed.Remove()
+	for _, w := range []*webhook.Webhook{w1, w2, w3, removed} {
+		r.Add(ctx, w)
+	}
+
+	tests := []struct {
+		name  string
+		event webhook.EventType
+		uids  []user.ID
+		want  []*webhook.Webhook
+	}{
+		{
+			name:  "should only get active webhooks of the given users",
+			event: webhook.EventTaskCreated,
+			uids:  []user.ID{uid1, uid2},
+			want:  []*webhook.Webhook{w1, w2},
+		},
+		{
+			name:  "should only get webhooks subscribed to the event",
+			event: webhook.EventTaskCleared,
+			uids:  []user.ID{uid1, uid2, uid3},
+			want:  []*webhook.Webhook{w2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetSubscribed(ctx, tt.event, tt.uids)
+			if err != nil {
+				t.Fatalf("WebhookRepo.GetSubscribed() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("WebhookRepo.GetSubscribed() got %v webhooks, want %v", len(got), len(tt.want))
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, w := range got {
+					found = found || w.ID() == want.ID()
+				}
+				if !found {
+					t.Errorf("WebhookRepo.GetSubscribed() missing webhook %v", want.URL())
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookRepo_GetDueDeliveries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	r := NewWebhookRepo()
+	wid := webhook.NewID()
+	due := webhook.NewDelivery(wid, webhook.EventTaskCreated, []byte("{}"))
+	retry := webhook.NewDelivery(wid, webhook.EventTaskCreated, []byte("{}"))
+	retry.Failed(500, "server error", webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute})
+	done := webhook.NewDelivery(wid, webhook.EventTaskCreated, []byte("{}"))
+	done.Succeeded(200)
+	r.AddDeliveries(ctx, []*webhook.Delivery{due, retry, done})
+
+	got, err := r.GetDueDeliveries(ctx, now, 10)
+	if err != nil || len(got) != 1 || got[0].ID() != due.ID() {
+		t.Errorf("WebhookRepo.GetDueDeliveries() = %v, %v, want only the due delivery", got, err)
+	}
+	next, err := r.NextDeliveryTime(ctx)
+	if err != nil || !next.Equal(now) {
+		t.Errorf("WebhookRepo.NextDeliveryTime() = %v, %v, want %v", next, err, now)
+	}
+
+	due.Succeeded(204)
+	r.UpdateDelivery(ctx, due)
+	next, err = r.NextDeliveryTime(ctx)
+	if want := now.Add(time.Minute); err != nil || !next.Equal(want) {
+		t.Errorf("WebhookRepo.NextDeliveryTime() = %v, %v, want %v", next, err, want)
+	}
+	all, _ := r.GetDeliveries(ctx, wid, 2)
+	if len(all) != 2 {
+		t.Errorf("WebhookRepo.GetDeliveries() got %v deliveries, want limit of 2", len(all))
+	}
+}
@@ -0,0 +1,69 @@
+package transient
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// WorkspaceRepo maintains an in-memory cache of workspaces
+type WorkspaceRepo struct {
+	workspaces map[workspace.ID]*workspace.Workspace
+}
+
+// NewWorkspaceRepo instantiates a new WorkspaceRepo
+func NewWorkspaceRepo() *WorkspaceRepo {
+	return &WorkspaceRepo{workspaces: make(map[workspace.ID]*workspace.Workspace)}
+}
+
+// Get retrieves a workspace, given its ID
+func (r *WorkspaceRepo) Get(ctx context.Context, id workspace.ID) (*workspace.Workspace, usecase.Error) {
+	w, ok := r.workspaces[id]
+	if !ok {
+		return nil, usecase.NewError(usecase.ErrRecordNotFound, "no workspace with ID %v", id)
+	}
+	return w, nil
+}
+
+// GetAllForUser retrieves all workspaces a user is a member of
+func (r *WorkspaceRepo) GetAllForUser(ctx context.Context, uid user.ID) ([]*workspace.Workspace, usecase.Error) {
+	ws := []*workspace.Workspace{}
+	for _, w := range r.workspaces {
+		if w.IsMember(uid) {
+			ws = append(ws, w)
+		}
+	}
+	return ws, nil
+}
+
+// Add adds a workspace to the memory cache
+func (r *WorkspaceRepo) Add(ctx context.Context, w *workspace.Workspace) usecase.Error {
+	if _, exists := r.workspaces[w.ID()]; exists {
+		return usecase.NewError(usecase.ErrDuplicateRecord, "workspace ID %v already exists in repo", w.ID())
+	}
+	r.workspaces[w.ID()] = w
+	return nil
+}
+
+// Update updates a workspace
+func (r *WorkspaceRepo) Update(ctx context.Context, w *workspace.Workspace) usecase.Error {
+	if _, ok := r.workspaces[w.ID()]; !ok {
+		return usecase.NewError(usecase.ErrRecordNotFound, "no workspace with ID %v", w.ID())
+	}
+	r.workspaces[w.ID()] = w
+	return nil
+}
+
+// visibleTo returns whether a user can access an entity shared with a workspace, or only visible to its creator if the workspace ID is empty
+func (r *WorkspaceRepo) visibleTo(uid user.ID, createdBy user.ID, wid workspace.ID) bool {
+	if wid.IsEmpty() {
+		return uid.Equals(createdBy)
+	}
+	if r == nil {
+		return false
+	}
+	w, ok := r.workspaces[wid]
+	return ok && w.IsMember(uid)
+}
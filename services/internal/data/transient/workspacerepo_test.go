@@ -0,0 +1,105 @@
+package transient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestWorkspaceRepo_GetAllForUser(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewWorkspaceRepo()
+	uid1 := user.NewID()
+	uid2 := user.NewID()
+	w1, _ := workspace.New("w1", uid1)
+	w2, _ := workspace.New("w2", uid2)
+	w2.AddMember(uid2, uid1, workspace.RoleMember)
+	r.Add(ctx, w1)
+	r.Add(ctx, w2)
+
+	tests := []struct {
+		name    string
+		uid     user.ID
+		wantLen int
+	}{
+		{name: "should get workspaces user created or joined", uid: uid1, wantLen: 2},
+		{name: "should get only workspaces user is a member of", uid: uid2, wantLen: 1},
+		{name: "should get no workspaces for other users", uid: user.NewID(), wantLen: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetAllForUser(ctx, tt.uid)
+			if err != nil {
+				t.Errorf("WorkspaceRepo.GetAllForUser() error = %v", err)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("WorkspaceRepo.GetAllForUser() len = %v, want %v", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestWorkspaceRepo_Add(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewWorkspaceRepo()
+	w, _ := workspace.New("w1", user.NewID())
+
+	if err := r.Add(ctx, w); err != nil {
+		t.Errorf("WorkspaceRepo.Add() error = %v", err)
+	}
+	if err := r.Add(ctx, w); err == nil || err.Code() != usecase.ErrDuplicateRecord {
+		t.Errorf("WorkspaceRepo.Add() error = %v, wantErr %v", err, usecase.ErrDuplicateRecord)
+	}
+	if got, err := r.Get(ctx, w.ID()); err != nil || got != w {
+		t.Errorf("WorkspaceRepo.Get() = %v, %v, want %v", got, err, w)
+	}
+}
+
+func TestTaskRepo_GetAllForUser_workspace(t *testing.T) {
+	ctx := context.Background()
+
+	wr := NewWorkspaceRepo()
+	r := NewTaskRepo()
+	r.SetWorkspaceRepo(wr)
+	owner := user.NewID()
+	member := user.NewID()
+	w, _ := workspace.New("w1", owner)
+	w.AddMember(owner, member, workspace.RoleMember)
+	wr.Add(ctx, w)
+	shared := task.New("shared", "", owner)
+	shared.SetWorkspace(w.ID())
+	sharedID, _ := r.Add(ctx, shared)
+	r.Add(ctx, task.New("personal", "", owner))
+
+	tests := []struct {
+		name    string
+		uid     user.ID
+		wantLen int
+	}{
+		{name: "owner should get personal and shared tasks", uid: owner, wantLen: 2},
+		{name: "member should get only shared tasks", uid: member, wantLen: 1},
+		{name: "non-member should get no tasks", uid: user.NewID(), wantLen: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetAllForUser(ctx, tt.uid)
+			if err != nil {
+				t.Errorf("TaskRepo.GetAllForUser() error = %v", err)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("TaskRepo.GetAllForUser() len = %v, want %v", len(got), tt.wantLen)
+			}
+			if tt.uid.Equals(member) && got[sharedID] != shared {
+				t.Errorf("TaskRepo.GetAllForUser() = %v, want shared task %v", got, sharedID)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package action
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/action")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// DefaultTimeout is the default amount of time to wait for an action's response
+const DefaultTimeout = 30 * time.Second
+
+// idleInterval is how often the executor checks for due calls when none are pending, in case one was queued by another instance
+const idleInterval = time.Minute
+
+// Config contains HTTP action settings, a call's own timeout and maximum attempts override these
+// AllowPrivate allows requests to private, loopback and link-local addresses, which are refused by default
+type Config struct {
+	Timeout      time.Duration
+	Retry        webhook.RetryPolicy
+	AllowPrivate bool
+}
+
+// Executor makes the HTTP requests of recurring task actions in the background, retrying failed requests with backoff
+// it subscribes to published domain events, to be told when tasks with actions are generated
+type Executor struct {
+	l        Logger
+	repo     usecase.ActionRepo
+	taskRepo usecase.TaskRepo
+	sender   usecase.ActionSender
+	retry    webhook.RetryPolicy
+	wake     chan bool
+}
+
+// NewExecutor instantiates a new Executor, zero config values are replaced with defaults
+func NewExecutor(l Logger, repo usecase.ActionRepo, taskRepo usecase.TaskRepo, c Config) *Executor {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Retry.MaxAttempts <= 0 {
+		c.Retry.MaxAttempts = webhook.DefaultRetryPolicy.MaxAttempts
+	}
+	if c.Retry.Delay <= 0 {
+		c.Retry.Delay = webhook.DefaultRetryPolicy.Delay
+	}
+	if c.Retry.MaxDelay <= 0 {
+		c.Retry.MaxDelay = webhook.DefaultRetryPolicy.MaxDelay
+	}
+	return &Executor{
+		l:        l,
+		repo:     repo,
+		taskRepo: taskRepo,
+		sender:   NewHTTPSender(c.Timeout, c.AllowPrivate),
+		retry:    c.Retry,
+		wake:     make(chan bool, 1),
+	}
+}
+
+// Name identifies the subscriber in event delivery records
+func (e *Executor) Name() string {
+	return "action"
+}
+
+// HandleEvent wakes the executor when a task is generated, since its action call is due immediately
+func (e *Executor) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	if ed.Event.Type() == event.TaskGenerated {
+		e.notify()
+	}
+	return nil
+}
+
+func (e *Executor) notify() {
+	select {
+	case e.wake <- true:
+	default:
+	}
+}
+
+// Run starts making due action calls in the background, until closed
+func (e *Executor) Run() (close chan<- bool, closed <-chan bool) {
+	e.l.Info("action executor starting")
+
+	closeSignal := make(chan bool)
+	onClosed := make(chan bool)
+
+	go func() {
+		defer func() {
+			select {
+			case onClosed <- true:
+			default:
+			}
+		}()
+		for {
+			wait := idleInterval
+			next, err := e.perform()
+			if err != nil {
+				e.l.Error("error performing actions", "error", err)
+			} else if !next.IsZero() {
+				if until := clock.Until(next); until < wait {
+					wait = until
+				}
+				e.l.Debug("next action call scheduled", "next", next)
+			}
+			if wait <= 0 {
+				wait = 1
+			}
+
+			select {
+			case <-closeSignal:
+				e.l.Info("action executor exiting")
+				return
+			case <-e.wake:
+			case <-clock.After(wait):
+			}
+		}
+	}()
+
+	return closeSignal, onClosed
+}
+
+// perform attempts all due calls in a new trace, so each run's requests and queries are grouped together
+func (e *Executor) perform() (time.Time, error) {
+	ctx, span := tracer.Start(context.Background(), "action.perform")
+	defer span.End()
+
+	next, ucerr := usecase.PerformActions(ctx, e.repo, e.taskRepo, e.sender, e.retry)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return time.Time{}, ucerr
+	}
+	return next, nil
+}
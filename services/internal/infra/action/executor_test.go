@@ -0,0 +1,139 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+type stubRequest struct {
+	method string
+	token  string
+	body   string
+}
+
+// newStub starts a local HTTP server that records every request it receives, responding with the given status codes in turn
+func newStub(statuses ...int) (*httptest.Server, <-chan stubRequest) {
+	reqs := make(chan stubRequest, 10)
+	i := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		reqs <- stubRequest{method: r.Method, token: r.Header.Get("X-Token"), body: string(body)}
+		status := http.StatusOK
+		if i < len(statuses) {
+			status = statuses[i]
+		}
+		i++
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "attempt %d", i)
+	}))
+	return s, reqs
+}
+
+func TestHTTPSender_Send_timeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "default timeout should apply to calls without their own", wantErr: true},
+		{name: "call's own timeout should override the default", timeout: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := action.NewCall(action.NewRequest("GET", s.URL, nil, ""), tt.timeout, 0, false)
+			_, _, err := NewHTTPSender(20*time.Millisecond, true).Send(context.Background(), c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HTTPSender.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecutor(t *testing.T) {
+	ctx := context.Background()
+
+	stub, reqs := newStub(http.StatusServiceUnavailable, http.StatusOK)
+	defer stub.Close()
+	r := transient.NewActionRepo()
+	taskRepo := transient.NewTaskRepo()
+	taskRepo.SetActionRepo(r)
+
+	e := NewExecutor(&loggerStub{}, r, taskRepo, Config{Timeout: time.Second, Retry: webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond}, AllowPrivate: true})
+	closeExecutor, closed := e.Run()
+
+	tsk := task.New("warm cache", "", user.NewID())
+	tsk.SetAction(action.NewCall(action.NewRequest("PUT", stub.URL, map[string]string{"X-Token": "secret"}, `{"task":"warm cache"}`), 0, 0, true))
+	id, _ := taskRepo.Add(ctx, tsk)
+	if err := e.HandleEvent(ctx, usecase.EventData{Event: event.New(event.TaskGenerated), TaskID: id}); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case req := <-reqs:
+			if req.method != "PUT" || req.token != "secret" || req.body != `{"task":"warm cache"}` {
+				t.Errorf("stub request = %+v, want the call's method, headers and body", req)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("stub received %v requests, want 2", i)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	c, _ := r.GetForTask(ctx, id)
+	for c.Status() != action.StatusSucceeded {
+		if time.Now().After(deadline) {
+			t.Fatalf("call status = %v, want %v", c.Status(), action.StatusSucceeded)
+		}
+		time.Sleep(10 * time.Millisecond)
+		c, _ = r.GetForTask(ctx, id)
+	}
+	if c.Attempts() != 2 || c.StatusCode() != http.StatusOK || c.Response() != "attempt 2" {
+		t.Errorf("call = %v attempts, status %v, response %q, want the second attempt to succeed", c.Attempts(), c.StatusCode(), c.Response())
+	}
+
+	// Closing waits for the current run to finish, so the task has been completed
+	closeExecutor <- true
+	<-closed
+	if got, _ := taskRepo.Get(ctx, id); got.CompletedTime().IsZero() {
+		t.Errorf("task completed time is zero, want the task completed once its call succeeds")
+	}
+}
@@ -0,0 +1,63 @@
+package action
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/netguard"
+)
+
+// maxResponseBytes is the maximum amount of a response body read, longer responses are truncated
+const maxResponseBytes = 64 * 1024
+
+// HTTPSender makes the HTTP requests of action calls
+// requests to addresses that aren't publicly routable are refused, including after redirects, unless private addresses are allowed
+type HTTPSender struct {
+	client  *http.Client
+	timeout time.Duration
+}
+
+// NewHTTPSender instantiates a new HTTPSender, requests for calls without their own timeout fail if they take longer than the default
+func NewHTTPSender(timeout time.Duration, allowPrivate bool) *HTTPSender {
+	return &HTTPSender{client: netguard.NewClient(0, allowPrivate), timeout: timeout}
+}
+
+// Send makes a call's HTTP request, returning the response status code and body
+// trace headers aren't propagated, since actions call arbitrary third-party services
+func (s *HTTPSender) Send(ctx context.Context, c *action.Call) (int, string, error) {
+	timeout := c.Timeout()
+	if timeout <= 0 {
+		timeout = s.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r := c.Request()
+	var body io.Reader
+	if r.Body() != "" {
+		body = strings.NewReader(r.Body())
+	}
+	req, err := http.NewRequest(r.Method(), r.URL(), body)
+	if err != nil {
+		return 0, "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", "scheduled-tasks-action")
+	for name, value := range r.Headers() {
+		req.Header.Set(name, value)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	// A response was received, so an error reading its body only loses the rest of the body
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+	return res.StatusCode, string(b), nil
+}
@@ -0,0 +1,257 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/chat")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// DefaultTimeout is the default amount of time to wait for an incoming webhook to respond
+const DefaultTimeout = 10 * time.Second
+
+// scanInterval is how often the notifier looks for tasks that have become overdue
+const scanInterval = time.Minute
+
+// overdueLookback is how far back the first scan after starting looks for overdue tasks, so tasks that became overdue while the app was down are still posted
+const overdueLookback = 24 * time.Hour
+
+// Config contains chat notification settings
+// AllowPrivate allows messages to private, loopback and link-local addresses, which are refused by default
+type Config struct {
+	Timeout      time.Duration
+	Retry        webhook.RetryPolicy
+	AppURL       string
+	AllowPrivate bool
+}
+
+// Notifier posts tasks generated by schedules, and tasks that become overdue, to their schedule's Slack or Mattermost channel in the background
+// it subscribes to published domain events, to be told when tasks are generated
+type Notifier struct {
+	l            Logger
+	repo         usecase.ChatRepo
+	taskRepo     usecase.TaskRepo
+	scheduleRepo usecase.ScheduleRepo
+	sender       usecase.ChatSender
+	retry        webhook.RetryPolicy
+	appURL       string
+	lastScan     time.Time
+	wake         chan bool
+}
+
+// NewNotifier instantiates a new Notifier, zero config values are replaced with defaults
+// if the app URL is set messages link to each task in the web app
+func NewNotifier(l Logger, repo usecase.ChatRepo, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo, c Config) *Notifier {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Retry.MaxAttempts <= 0 {
+		c.Retry.MaxAttempts = webhook.DefaultRetryPolicy.MaxAttempts
+	}
+	if c.Retry.Delay <= 0 {
+		c.Retry.Delay = webhook.DefaultRetryPolicy.Delay
+	}
+	if c.Retry.MaxDelay <= 0 {
+		c.Retry.MaxDelay = webhook.DefaultRetryPolicy.MaxDelay
+	}
+	return &Notifier{
+		l:            l,
+		repo:         repo,
+		taskRepo:     taskRepo,
+		scheduleRepo: scheduleRepo,
+		sender:       NewHTTPSender(c.Timeout, c.AllowPrivate),
+		retry:        c.Retry,
+		appURL:       strings.TrimRight(c.AppURL, "/"),
+		wake:         make(chan bool, 1),
+	}
+}
+
+// outMessage is an incoming webhook payload, using the attachment format supported by both Slack and Mattermost
+type outMessage struct {
+	Text        string          `json:"text"`
+	Attachments []outAttachment `json:"attachments"`
+}
+
+type outAttachment struct {
+	Fallback  string     `json:"fallback"`
+	Color     string     `json:"color"`
+	Title     string     `json:"title"`
+	TitleLink string     `json:"title_link,omitempty"`
+	Text      string     `json:"text,omitempty"`
+	Fields    []outField `json:"fields"`
+}
+
+type outField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// escaper escapes the control characters of Slack and Mattermost message formatting
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Name identifies the subscriber in event delivery records
+func (n *Notifier) Name() string {
+	return "chat"
+}
+
+// HandleEvent queues a generated task to be posted to its schedule's chat channel, if it has one
+func (n *Notifier) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	queued, ucerr := usecase.PostGeneratedTask(ctx, n.repo, n.taskRepo, n.scheduleRepo, n, ed)
+	if ucerr != nil {
+		return ucerr
+	}
+	if queued {
+		n.l.Debug("queued chat message", "kind", chat.KindGenerated, "task_id", ed.TaskID)
+		n.notify()
+	}
+	return nil
+}
+
+// Format formats a task, and the schedule that generated it, into an incoming webhook payload
+func (n *Notifier) Format(kind chat.Kind, td usecase.TaskData, sd usecase.ScheduleData) ([]byte, error) {
+	name := escaper.Replace(td.Task.Name())
+	text, color := "New task: "+name, "#2eb886"
+	if kind == chat.KindOverdue {
+		text, color = "Overdue task: "+name, "#e01e5a"
+	}
+	a := outAttachment{
+		Fallback: text,
+		Color:    color,
+		Title:    name,
+		Text:     escaper.Replace(td.Task.Description()),
+		Fields:   []outField{{Title: "Schedule", Value: describeFrequency(sd.Schedule.Frequency()), Short: true}},
+	}
+	if n.appURL != "" {
+		a.TitleLink = fmt.Sprintf("%v/task/%d", n.appURL, td.TaskID)
+	}
+	if due := td.Task.DueTime(); !due.IsZero() {
+		a.Fields = append(a.Fields, outField{Title: "Due", Value: due.UTC().Format("Jan 2, 2006 15:04 MST"), Short: true})
+	}
+
+	// Formatting characters are already escaped, so the payload is encoded without JSON's own HTML escaping
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(outMessage{Text: text, Attachments: []outAttachment{a}}); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(b.Bytes()), nil
+}
+
+// describeFrequency describes how often a schedule generates tasks, e.g. "Every 2 days"
+func describeFrequency(f schedule.Frequency) string {
+	period := strings.ToLower(f.TimePeriod().String())
+	if f.Interval() <= 1 {
+		return "Every " + period
+	}
+	return fmt.Sprintf("Every %d %vs", f.Interval(), period)
+}
+
+func (n *Notifier) notify() {
+	select {
+	case n.wake <- true:
+	default:
+	}
+}
+
+// Run starts posting newly overdue tasks and delivering queued messages in the background, until closed
+func (n *Notifier) Run() (close chan<- bool, closed <-chan bool) {
+	n.l.Info("chat notifier starting")
+
+	closeSignal := make(chan bool)
+	onClosed := make(chan bool)
+
+	go func() {
+		defer func() {
+			select {
+			case onClosed <- true:
+			default:
+			}
+		}()
+		for {
+			if count, err := n.postOverdue(clock.Now()); err != nil {
+				n.l.Error("error posting overdue tasks", "error", err)
+			} else if count > 0 {
+				n.l.Debug("queued chat messages", "kind", chat.KindOverdue, "count", count)
+			}
+
+			wait := scanInterval
+			next, err := n.deliver()
+			if err != nil {
+				n.l.Error("error delivering chat messages", "error", err)
+			} else if !next.IsZero() {
+				if until := clock.Until(next); until < wait {
+					wait = until
+				}
+				n.l.Debug("next chat message scheduled", "next", next)
+			}
+			if wait <= 0 {
+				wait = 1
+			}
+
+			select {
+			case <-closeSignal:
+				n.l.Info("chat notifier exiting")
+				return
+			case <-n.wake:
+			case <-clock.After(wait):
+			}
+		}
+	}()
+
+	return closeSignal, onClosed
+}
+
+// postOverdue queues tasks that became overdue since the last scan in a new trace, so each scan's queries are grouped together
+func (n *Notifier) postOverdue(now time.Time) (int, error) {
+	ctx, span := tracer.Start(context.Background(), "chat.postOverdue")
+	defer span.End()
+
+	after := n.lastScan
+	if after.IsZero() {
+		after = now.Add(-overdueLookback)
+	}
+	count, ucerr := usecase.PostOverdueTasks(ctx, n.repo, n.taskRepo, n.scheduleRepo, n, after, now)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return count, ucerr
+	}
+	n.lastScan = now
+	return count, nil
+}
+
+// deliver attempts all due messages in a new trace, so each run's requests and queries are grouped together
+func (n *Notifier) deliver() (time.Time, error) {
+	ctx, span := tracer.Start(context.Background(), "chat.deliver")
+	defer span.End()
+
+	next, ucerr := usecase.DeliverChatMessages(ctx, n.repo, n.sender, n.retry)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return time.Time{}, ucerr
+	}
+	return next, nil
+}
@@ -0,0 +1,188 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+// newStub starts a local HTTP server that records every request body it receives, responding with the given status codes in turn
+func newStub(statuses ...int) (*httptest.Server, <-chan []byte) {
+	reqs := make(chan []byte, 10)
+	i := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		reqs <- body
+		w.WriteHeader(statuses[i%len(statuses)])
+		i++
+	}))
+	return s, reqs
+}
+
+func TestNotifier_Format(t *testing.T) {
+	f, _ := schedule.NewDayFrequency([]int{0}, []int{9})
+	f.SetInterval(2)
+	s := schedule.New(f, user.NewID())
+	tsk := task.New("Water <plants>", "Use the blue can & fill it", user.NewID())
+	tsk.SetDueTime(time.Date(2000, 1, 2, 9, 30, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		kind   chat.Kind
+		appURL string
+		want   string
+	}{
+		{
+			name:   "generated task should link back to the app",
+			kind:   chat.KindGenerated,
+			appURL: "https://tasks.example.com/",
+			want:   `{"text":"New task: Water &lt;plants&gt;","attachments":[{"fallback":"New task: Water &lt;plants&gt;","color":"#2eb886","title":"Water &lt;plants&gt;","title_link":"https://tasks.example.com/task/7","text":"Use the blue can &amp; fill it","fields":[{"title":"Schedule","value":"Every 2 days","short":true},{"title":"Due","value":"Jan 2, 2000 09:30 UTC","short":true}]}]}`,
+		},
+		{
+			name: "overdue task without an app URL should not link",
+			kind: chat.KindOverdue,
+			want: `{"text":"Overdue task: Water &lt;plants&gt;","attachments":[{"fallback":"Overdue task: Water &lt;plants&gt;","color":"#e01e5a","title":"Water &lt;plants&gt;","text":"Use the blue can &amp; fill it","fields":[{"title":"Schedule","value":"Every 2 days","short":true},{"title":"Due","value":"Jan 2, 2000 09:30 UTC","short":true}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNotifier(&loggerStub{}, nil, nil, nil, Config{AppURL: tt.appURL})
+			got, err := n.Format(tt.kind, usecase.TaskData{TaskID: 7, Task: tsk}, usecase.ScheduleData{ScheduleID: 3, Schedule: s})
+			if err != nil {
+				t.Fatalf("Notifier.Format() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Notifier.Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	stub, reqs := newStub(http.StatusServiceUnavailable, http.StatusOK, http.StatusOK)
+	defer stub.Close()
+	r := transient.NewChatRepo()
+	taskRepo := transient.NewTaskRepo()
+	scheduleRepo := transient.NewScheduleRepo()
+	uid := user.NewID()
+	s := schedule.New(schedule.Frequency{}, uid)
+	s.SetChatURL(stub.URL)
+	sid, _ := scheduleRepo.Add(ctx, s)
+
+	overdue := task.New("take out trash", "", uid)
+	overdue.SetSchedule(int64(sid))
+	overdue.SetDueTime(clock.Now().Add(-time.Minute))
+	taskRepo.Add(ctx, overdue)
+
+	n := NewNotifier(&loggerStub{}, r, taskRepo, scheduleRepo, Config{Timeout: time.Second, Retry: webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond}, AllowPrivate: true})
+	closeNotifier, closed := n.Run()
+	defer func() {
+		closeNotifier <- true
+		<-closed
+	}()
+
+	generated := task.New("water plants", "", uid)
+	generated.SetSchedule(int64(sid))
+	id, _ := taskRepo.Add(ctx, generated)
+	if err := n.HandleEvent(ctx, usecase.EventData{Event: event.New(event.TaskGenerated), TaskID: id}); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	want := map[string]bool{"Overdue task: take out trash": false, "New task: water plants": false}
+	for i := 0; i < 3; i++ {
+		var body []byte
+		select {
+		case body = <-reqs:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("stub received %v requests, want 3", i)
+		}
+		var m struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatalf("payload = %s, error = %v", body, err)
+		}
+		if _, ok := want[m.Text]; !ok {
+			t.Errorf("payload text = %v, want one of %v", m.Text, want)
+		}
+		want[m.Text] = true
+	}
+	for text, received := range want {
+		if !received {
+			t.Errorf("stub did not receive message %q", text)
+		}
+	}
+	select {
+	case extra := <-reqs:
+		t.Errorf("stub received unexpected request %s", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHTTPSender_Send(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("redirect target received a request, want redirects not followed")
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		wantCode     int
+		wantErr      bool
+	}{
+		{name: "should refuse private addresses", wantErr: true},
+		{name: "should not follow redirects", allowPrivate: true, wantCode: http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := chat.NewMessage(redirect.URL, chat.KindGenerated, []byte(`{}`))
+			code, err := NewHTTPSender(time.Second, tt.allowPrivate).Send(context.Background(), m)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HTTPSender.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if code != tt.wantCode {
+				t.Errorf("HTTPSender.Send() status code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
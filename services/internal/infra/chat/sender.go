@@ -0,0 +1,51 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/netguard"
+)
+
+// maxResponseBytes is the maximum amount of an incoming webhook response body read, so the connection can be reused
+const maxResponseBytes = 64 * 1024
+
+// HTTPSender POSTs message payloads to Slack or Mattermost incoming webhook URLs
+// requests to addresses that aren't publicly routable are refused unless private addresses are allowed, and redirects aren't followed
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender instantiates a new HTTPSender, requests that take longer than the timeout fail
+func NewHTTPSender(timeout time.Duration, allowPrivate bool) *HTTPSender {
+	client := netguard.NewClient(timeout, allowPrivate)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &HTTPSender{client: client}
+}
+
+// Send POSTs a message's payload to its incoming webhook URL
+// trace headers aren't propagated, since incoming webhooks are third-party services
+func (s *HTTPSender) Send(ctx context.Context, m *chat.Message) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, m.URL(), bytes.NewReader(m.Payload()))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "scheduled-tasks-chat")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxResponseBytes))
+	return res.StatusCode, nil
+}
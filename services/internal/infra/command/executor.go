@@ -0,0 +1,137 @@
+package command
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/command")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// DefaultTimeout is the default amount of time a command can run before it's killed
+const DefaultTimeout = 10 * time.Minute
+
+// idleInterval is how often the executor checks for pending runs when none are queued, in case one was queued by another instance
+const idleInterval = time.Minute
+
+// Config contains command settings, a command's own timeout overrides the default
+type Config struct {
+	Dir     string
+	Allowed []string
+	Timeout time.Duration
+}
+
+// Executor runs the commands of recurring tasks in the background
+// it subscribes to published domain events, to be told when tasks with commands are generated
+type Executor struct {
+	l        Logger
+	repo     usecase.CommandRepo
+	taskRepo usecase.TaskRepo
+	runner   usecase.CommandRunner
+	wake     chan bool
+}
+
+// NewExecutor instantiates a new Executor, zero config values are replaced with defaults
+// only programs in the allowed list are run, each in its own working directory created under the config's directory
+func NewExecutor(l Logger, repo usecase.CommandRepo, taskRepo usecase.TaskRepo, c Config) *Executor {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	return &Executor{
+		l:        l,
+		repo:     repo,
+		taskRepo: taskRepo,
+		runner:   NewRunner(c.Dir, c.Allowed, c.Timeout),
+		wake:     make(chan bool, 1),
+	}
+}
+
+// Name identifies the subscriber in event delivery records
+func (e *Executor) Name() string {
+	return "command"
+}
+
+// HandleEvent wakes the executor when a task is generated, since its command is run immediately
+func (e *Executor) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	if ed.Event.Type() == event.TaskGenerated {
+		e.notify()
+	}
+	return nil
+}
+
+func (e *Executor) notify() {
+	select {
+	case e.wake <- true:
+	default:
+	}
+}
+
+// Run starts running pending commands in the background, until closed
+func (e *Executor) Run() (close chan<- bool, closed <-chan bool) {
+	e.l.Info("command executor starting")
+
+	closeSignal := make(chan bool)
+	onClosed := make(chan bool)
+
+	go func() {
+		defer func() {
+			select {
+			case onClosed <- true:
+			default:
+			}
+		}()
+		for {
+			count, err := e.run()
+			if err != nil {
+				e.l.Error("error running commands", "error", err)
+			} else if count > 0 {
+				// More runs may have been queued while these ran, so check again straight away
+				e.l.Debug("commands run", "count", count)
+				select {
+				case <-closeSignal:
+					e.l.Info("command executor exiting")
+					return
+				default:
+					continue
+				}
+			}
+
+			select {
+			case <-closeSignal:
+				e.l.Info("command executor exiting")
+				return
+			case <-e.wake:
+			case <-clock.After(idleInterval):
+			}
+		}
+	}()
+
+	return closeSignal, onClosed
+}
+
+// run runs a batch of pending commands in a new trace, so each batch's commands and queries are grouped together
+func (e *Executor) run() (int, error) {
+	ctx, span := tracer.Start(context.Background(), "command.run")
+	defer span.End()
+
+	count, ucerr := usecase.RunCommands(ctx, e.repo, e.taskRepo, e.runner)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return count, ucerr
+	}
+	return count, nil
+}
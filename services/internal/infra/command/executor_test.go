@@ -0,0 +1,172 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+// newTestDir creates a command directory for a test, skipping tests on platforms without a POSIX shell
+func newTestDir(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("command tests need a POSIX shell")
+	}
+	dir, err := ioutil.TempDir("", "commands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name       string
+		program    string
+		args       []string
+		timeout    time.Duration
+		wantCode   int
+		wantStdout string
+		wantStderr string
+		wantErr    string
+	}{
+		{name: "should capture output and run in an empty working directory", program: "sh", args: []string{"-c", `ls -A; pwd; echo "$HOME"; echo oops >&2`}, wantStdout: "{dir}\n{dir}\n", wantStderr: "oops\n"},
+		{name: "should return a non-zero exit code without an error", program: "sh", args: []string{"-c", "exit 3"}, wantCode: 3},
+		{name: "should not inherit the environment", program: "sh", args: []string{"-c", `echo "$RUNNER_TEST_SECRET"`}, wantStdout: "\n"},
+		{name: "should refuse programs that aren't allowed", program: "ls", wantCode: -1, wantErr: "program 'ls' is not allowed"},
+		{name: "should run a program with the args pinned in its allowlist entry", program: "echo", args: []string{"pinned"}, wantStdout: "pinned\n"},
+		{name: "should refuse a program with args other than those pinned", program: "echo", args: []string{"pinned", "; rm -rf /"}, wantCode: -1, wantErr: `program 'echo' is not allowed with args ["pinned" "; rm -rf /"]`},
+		{name: "should kill the command and its children when the default timeout passes", program: "sh", args: []string{"-c", "sleep 5 & sleep 5"}, wantCode: -1, wantErr: "timed out after 100ms"},
+		{name: "command's own timeout should override the default", program: "sh", args: []string{"-c", "sleep 0.2; echo done"}, timeout: time.Second, wantStdout: "done\n"},
+	}
+	os.Setenv("RUNNER_TEST_SECRET", "secret")
+	defer os.Unsetenv("RUNNER_TEST_SECRET")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRunner(dir, []string{"sh", "echo pinned"}, 100*time.Millisecond)
+			start := time.Now()
+			code, stdout, stderr, err := r.Run(context.Background(), command.NewRun(tt.program, tt.args, tt.timeout))
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Runner.Run() took %v, want it to return once the timeout passes", elapsed)
+			}
+			if code != tt.wantCode {
+				t.Errorf("Runner.Run() exit code = %v, want %v", code, tt.wantCode)
+			}
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("Runner.Run() error = %v, want %v", err, tt.wantErr)
+			}
+			// The working directory is only known once it's created, so its output lines are checked by prefix
+			if strings.Contains(tt.wantStdout, "{dir}") {
+				lines := strings.Split(stdout, "\n")
+				if len(lines) != 3 || !strings.HasPrefix(lines[0], dir) || lines[0] != lines[1] {
+					t.Errorf("Runner.Run() stdout = %q, want the working directory under %v, used as HOME, with no files in it", stdout, dir)
+				}
+			} else if stdout != tt.wantStdout {
+				t.Errorf("Runner.Run() stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("Runner.Run() stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("command directory has %v entries, want working directories removed once their command finishes", len(files))
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 5}
+	for _, s := range []string{"abc", "def", "ghi"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Errorf("Write() = %v, %v, want %v, nil so the command isn't blocked", n, err, len(s))
+		}
+	}
+	if got := b.String(); got != "abcde" {
+		t.Errorf("String() = %q, want the first 5 bytes", got)
+	}
+}
+
+func TestExecutor(t *testing.T) {
+	ctx := context.Background()
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	r := transient.NewCommandRepo()
+	taskRepo := transient.NewTaskRepo()
+	taskRepo.SetCommandRepo(r)
+
+	e := NewExecutor(&loggerStub{}, r, taskRepo, Config{Dir: dir, Allowed: []string{"sh"}, Timeout: time.Second})
+	closeExecutor, closed := e.Run()
+
+	tsk := task.New("backup", "", user.NewID())
+	tsk.SetCommand(command.NewRun("sh", []string{"-c", "echo backed up"}, 0))
+	id, _ := taskRepo.Add(ctx, tsk)
+	failed := task.New("cleanup", "", user.NewID())
+	failed.SetCommand(command.NewRun("rm", []string{"-rf", "/"}, 0))
+	failedID, _ := taskRepo.Add(ctx, failed)
+	if err := e.HandleEvent(ctx, usecase.EventData{Event: event.New(event.TaskGenerated), TaskID: id}); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	run, _ := r.GetForTask(ctx, id)
+	failedRun, _ := r.GetForTask(ctx, failedID)
+	for run.Status() != command.StatusSucceeded || failedRun.Status() != command.StatusFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("run statuses = %v, %v, want %v, %v", run.Status(), failedRun.Status(), command.StatusSucceeded, command.StatusFailed)
+		}
+		time.Sleep(10 * time.Millisecond)
+		run, _ = r.GetForTask(ctx, id)
+		failedRun, _ = r.GetForTask(ctx, failedID)
+	}
+	if run.ExitCode() != 0 || run.Stdout() != "backed up\n" {
+		t.Errorf("run = exit code %v, stdout %q, want the command's outcome recorded", run.ExitCode(), run.Stdout())
+	}
+	if failedRun.LastError() != "program 'rm' is not allowed" {
+		t.Errorf("failed run error = %q, want the program refused", failedRun.LastError())
+	}
+
+	// Closing waits for the current run to finish, so the tasks have been updated
+	closeExecutor <- true
+	<-closed
+	if got, _ := taskRepo.Get(ctx, id); got.CompletedTime().IsZero() {
+		t.Errorf("task completed time is zero, want the task completed once its command succeeds")
+	}
+	if got, _ := taskRepo.Get(ctx, failedID); !got.CompletedTime().IsZero() {
+		t.Errorf("failed task completed time = %v, want the task left open", got.CompletedTime())
+	}
+}
@@ -0,0 +1,16 @@
+// +build windows
+
+package command
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows, where only the command itself is killed
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// killProcessGroup kills a started command
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
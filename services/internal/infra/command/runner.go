@@ -0,0 +1,153 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+)
+
+// maxOutputBytes is the maximum amount of a command's stdout and stderr kept, the rest is discarded
+const maxOutputBytes = 64 * 1024
+
+// Runner runs commands in a sandboxed working directory
+// each run gets its own empty directory, removed once the command finishes, and an environment with only PATH, HOME and TMPDIR set
+// only allowed commands are run, the runner isn't a security boundary beyond that, so the app should run as an unprivileged user
+type Runner struct {
+	dir     string
+	allowed map[string][][]string
+	timeout time.Duration
+}
+
+// NewRunner instantiates a new Runner, creating run directories in dir
+// each allowed entry is a program optionally followed by space-separated args, a program on its own can be run with any args,
+// otherwise it can only be run with exactly the args of one of its entries
+// commands without their own timeout are killed if they take longer than the default
+func NewRunner(dir string, allowed []string, timeout time.Duration) *Runner {
+	r := &Runner{dir: dir, allowed: map[string][][]string{}, timeout: timeout}
+	for _, entry := range allowed {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			r.allowed[fields[0]] = append(r.allowed[fields[0]], nil)
+			continue
+		}
+		r.allowed[fields[0]] = append(r.allowed[fields[0]], fields[1:])
+	}
+	return r
+}
+
+// allows returns whether a program can be run with the given args
+func (r *Runner) allows(program string, args []string) bool {
+	for _, pinned := range r.allowed[program] {
+		if pinned == nil || equalArgs(pinned, args) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalArgs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Run runs a command, returning its exit code and output, the exit code is -1 if it didn't exit normally
+func (r *Runner) Run(ctx context.Context, run *command.Run) (int, string, string, error) {
+	if _, ok := r.allowed[run.Program()]; !ok {
+		return -1, "", "", fmt.Errorf("program '%v' is not allowed", run.Program())
+	}
+	if !r.allows(run.Program(), run.Args()) {
+		return -1, "", "", fmt.Errorf("program '%v' is not allowed with args %q", run.Program(), run.Args())
+	}
+	path, err := exec.LookPath(run.Program())
+	if err != nil {
+		return -1, "", "", err
+	}
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return -1, "", "", fmt.Errorf("error creating command directory: %v", err)
+	}
+	dir, err := ioutil.TempDir(r.dir, "run-")
+	if err != nil {
+		return -1, "", "", fmt.Errorf("error creating working directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	timeout := run.Timeout()
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{max: maxOutputBytes}
+	stderr := &limitedBuffer{max: maxOutputBytes}
+	cmd := exec.Command(path, run.Args()...)
+	cmd.Dir = dir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return -1, "", "", err
+	}
+
+	// The whole process group is killed once the timeout passes, so child processes can't keep the output pipes open
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, stdout.String(), stderr.String(), fmt.Errorf("timed out after %v", timeout)
+		}
+		return -1, stdout.String(), stderr.String(), ctx.Err()
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() >= 0 {
+			return exitErr.ExitCode(), stdout.String(), stderr.String(), nil
+		}
+		return -1, stdout.String(), stderr.String(), err
+	}
+	return 0, stdout.String(), stderr.String(), nil
+}
+
+// limitedBuffer keeps the first max bytes written to it, and discards the rest
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
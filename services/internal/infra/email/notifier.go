@@ -0,0 +1,238 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/email")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// Sender sends an HTML email to a single recipient
+type Sender interface {
+	Send(ctx context.Context, to string, subject string, html []byte) error
+}
+
+// Config contains email notification settings
+type Config struct {
+	Retry  webhook.RetryPolicy
+	AppURL string
+}
+
+// Notifier emails users about tasks generated for them, and sends daily digests of their open tasks in the background
+// it subscribes to published domain events, to be told when tasks are generated, and queues their emails so a slow or unavailable SMTP server doesn't hold up other subscribers
+type Notifier struct {
+	l        Logger
+	repo     usecase.NotificationRepo
+	taskRepo usecase.TaskRepo
+	sender   Sender
+	retry    webhook.RetryPolicy
+	appURL   string
+	wake     chan bool
+}
+
+// NewNotifier instantiates a new Notifier, zero retry policy values are replaced with defaults
+// if the app URL is set emails link to each task in the web app
+func NewNotifier(l Logger, repo usecase.NotificationRepo, taskRepo usecase.TaskRepo, sender Sender, c Config) *Notifier {
+	if c.Retry.MaxAttempts <= 0 {
+		c.Retry.MaxAttempts = webhook.DefaultRetryPolicy.MaxAttempts
+	}
+	if c.Retry.Delay <= 0 {
+		c.Retry.Delay = webhook.DefaultRetryPolicy.Delay
+	}
+	if c.Retry.MaxDelay <= 0 {
+		c.Retry.MaxDelay = webhook.DefaultRetryPolicy.MaxDelay
+	}
+	return &Notifier{
+		l:        l,
+		repo:     repo,
+		taskRepo: taskRepo,
+		sender:   sender,
+		retry:    c.Retry,
+		appURL:   strings.TrimRight(c.AppURL, "/"),
+		wake:     make(chan bool, 1),
+	}
+}
+
+// Name identifies the subscriber in event delivery records
+func (n *Notifier) Name() string {
+	return "email"
+}
+
+// HandleEvent queues an email of a generated task to the user it was generated for, if they've enabled it
+func (n *Notifier) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	queued, ucerr := usecase.NotifyTaskGenerated(ctx, n.repo, n.taskRepo, n, ed)
+	if ucerr != nil {
+		return ucerr
+	}
+	if queued {
+		n.l.Debug("queued task generated email", "task_id", ed.TaskID)
+		n.notify()
+	}
+	return nil
+}
+
+// FormatTaskGenerated renders a generated task's email
+func (n *Notifier) FormatTaskGenerated(td usecase.TaskData) (string, []byte, error) {
+	var b bytes.Buffer
+	if err := taskGeneratedTemplate.Execute(&b, taskGeneratedData{Task: n.templateTask(td)}); err != nil {
+		return "", nil, fmt.Errorf("error rendering task generated email: %v", err)
+	}
+	return "New task: " + td.Task.Name(), b.Bytes(), nil
+}
+
+// SendEmail sends a queued email to its recipient
+func (n *Notifier) SendEmail(ctx context.Context, e *notification.Email) error {
+	if err := n.sender.Send(ctx, e.To(), e.Subject(), e.HTML()); err != nil {
+		return err
+	}
+	n.l.Debug("sent email", "email_id", e.ID())
+	return nil
+}
+
+// SendDigest emails a digest of open tasks to the address in a user's preferences
+func (n *Notifier) SendDigest(ctx context.Context, p *notification.Preferences, d usecase.Digest) error {
+	data := digestData{Date: d.Time, Overdue: n.templateTasks(d.Overdue), Open: n.templateTasks(d.Open)}
+	var b bytes.Buffer
+	if err := digestTemplate.Execute(&b, data); err != nil {
+		return fmt.Errorf("error rendering digest email: %v", err)
+	}
+	subject := fmt.Sprintf("Your tasks: %d open", len(d.Overdue)+len(d.Open))
+	if len(d.Overdue) > 0 {
+		subject += fmt.Sprintf(", %d overdue", len(d.Overdue))
+	}
+	if err := n.sender.Send(ctx, p.Email(), subject, b.Bytes()); err != nil {
+		return err
+	}
+	n.l.Debug("sent digest email", "user_id", p.UserID(), "overdue", len(d.Overdue), "open", len(d.Open))
+	return nil
+}
+
+func (n *Notifier) templateTask(td usecase.TaskData) templateTask {
+	t := templateTask{
+		ID:          td.TaskID,
+		Name:        td.Task.Name(),
+		Description: td.Task.Description(),
+		Priority:    td.Task.Priority().String(),
+		DueTime:     td.Task.DueTime(),
+	}
+	if n.appURL != "" {
+		t.URL = fmt.Sprintf("%v/task/%d", n.appURL, td.TaskID)
+	}
+	return t
+}
+
+func (n *Notifier) templateTasks(tds []usecase.TaskData) []templateTask {
+	ts := make([]templateTask, len(tds))
+	for i, td := range tds {
+		ts[i] = n.templateTask(td)
+	}
+	return ts
+}
+
+func (n *Notifier) notify() {
+	select {
+	case n.wake <- true:
+	default:
+	}
+}
+
+// Run starts sending queued emails, and due digests at the start of every hour, in the background until closed
+func (n *Notifier) Run() (close chan<- bool, closed <-chan bool) {
+	n.l.Info("email notifier starting")
+
+	closeSignal := make(chan bool)
+	onClosed := make(chan bool)
+
+	go func() {
+		defer func() {
+			select {
+			case onClosed <- true:
+			default:
+			}
+		}()
+		var nextDigests time.Time
+		for {
+			now := clock.Now()
+			if !now.Before(nextDigests) {
+				count, err := n.sendDigests(now)
+				if err != nil {
+					n.l.Error("error sending digests", "error", err)
+				}
+				if count > 0 {
+					n.l.Info("sent digests", "count", count)
+				}
+				nextDigests = now.Truncate(time.Hour).Add(time.Hour)
+			}
+
+			wait := clock.Until(nextDigests)
+			next, err := n.deliver()
+			if err != nil {
+				n.l.Error("error sending emails", "error", err)
+			} else if !next.IsZero() {
+				if until := clock.Until(next); until < wait {
+					wait = until
+				}
+				n.l.Debug("next email scheduled", "next", next)
+			}
+			if wait <= 0 {
+				wait = 1
+			}
+
+			select {
+			case <-closeSignal:
+				n.l.Info("email notifier exiting")
+				return
+			case <-n.wake:
+			case <-clock.After(wait):
+			}
+		}
+	}()
+
+	return closeSignal, onClosed
+}
+
+// deliver sends all due emails in a new trace, so each run's emails and queries are grouped together
+func (n *Notifier) deliver() (time.Time, error) {
+	ctx, span := tracer.Start(context.Background(), "email.deliver")
+	defer span.End()
+
+	next, ucerr := usecase.DeliverEmails(ctx, n.repo, n, n.retry)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return time.Time{}, ucerr
+	}
+	return next, nil
+}
+
+// sendDigests sends due digests in a new trace, so each run's emails and queries are grouped together
+func (n *Notifier) sendDigests(now time.Time) (int, error) {
+	ctx, span := tracer.Start(context.Background(), "email.sendDigests")
+	defer span.End()
+
+	count, ucerr := usecase.SendDigests(ctx, n.repo, n.taskRepo, n, now)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return count, ucerr
+	}
+	return count, nil
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+func TestNotifier_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	c, msgs := newSink(t)
+	uid := user.NewID()
+	repo := transient.NewNotificationRepo()
+	repo.Upsert(ctx, notification.NewRaw(uid, "user@example.com", true, false, 0, time.Time{}))
+	taskRepo := transient.NewTaskRepo()
+	td, _ := usecase.AddTask(ctx, taskRepo, transient.NewActivityRepo(), task.New("Deploy <staging>", "tag & build", uid))
+	n := NewNotifier(&loggerStub{}, repo, taskRepo, NewSMTPSender(c), Config{AppURL: "http://localhost:3000/"})
+
+	if err := n.HandleEvent(ctx, usecase.EventData{Event: event.New(event.TaskGenerated), TaskID: td.TaskID}); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	select {
+	case msg := <-msgs:
+		t.Fatalf("HandleEvent() sent %v, want it only queued", msg.to)
+	default:
+	}
+	if err := n.HandleEvent(ctx, usecase.EventData{Event: event.New(event.TaskGenerated), TaskID: td.TaskID}); err != nil {
+		t.Fatalf("HandleEvent() again error = %v", err)
+	}
+
+	if next, err := n.deliver(); err != nil || !next.IsZero() {
+		t.Fatalf("deliver() = %v, %v, want no emails left pending", next, err)
+	}
+	msg := <-msgs
+	if msg.to[0] != "user@example.com" {
+		t.Errorf("deliver() sent to %v, want user@example.com", msg.to)
+	}
+	select {
+	case msg := <-msgs:
+		t.Errorf("deliver() sent %v again, want each task emailed once", msg.to)
+	default:
+	}
+	for _, want := range []string{
+		"Subject: New task: Deploy <staging>",
+		"Deploy &lt;staging&gt;",
+		"tag &amp; build",
+		fmt.Sprintf(`href="http://localhost:3000/task/%d"`, td.TaskID),
+	} {
+		if !strings.Contains(msg.data, want) {
+			t.Errorf("deliver() email = %v, want it to contain %v", msg.data, want)
+		}
+	}
+}
+
+func TestNotifier_sendDigests(t *testing.T) {
+	ctx := context.Background()
+	c, msgs := newSink(t)
+	uid := user.NewID()
+	now := time.Date(2019, 1, 2, 8, 0, 0, 0, time.UTC)
+	repo := transient.NewNotificationRepo()
+	repo.Upsert(ctx, notification.NewRaw(uid, "user@example.com", false, true, 8, time.Time{}))
+	taskRepo := transient.NewTaskRepo()
+	overdue := task.New("Renew certs", "", uid)
+	overdue.SetDueTime(now.Add(-time.Hour))
+	usecase.AddTask(ctx, taskRepo, transient.NewActivityRepo(), overdue)
+	usecase.AddTask(ctx, taskRepo, transient.NewActivityRepo(), task.New("Water plants", "", uid))
+	n := NewNotifier(&loggerStub{}, repo, taskRepo, NewSMTPSender(c), Config{})
+
+	count, err := n.sendDigests(now)
+	if err != nil || count != 1 {
+		t.Fatalf("sendDigests() = %v, %v, want 1 digest sent", count, err)
+	}
+	msg := <-msgs
+	for _, want := range []string{
+		"Subject: Your tasks: 2 open, 1 overdue",
+		"Wednesday, January 2",
+		"<h3>Overdue</h3>",
+		"Renew certs, due Wed Jan 2 07:00 UTC",
+		"Water plants",
+	} {
+		if !strings.Contains(msg.data, want) {
+			t.Errorf("digest email = %v, want it to contain %v", msg.data, want)
+		}
+	}
+	if strings.Contains(msg.data, "href") {
+		t.Errorf("digest email = %v, want no links without an app URL", msg.data)
+	}
+}
@@ -0,0 +1,111 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+)
+
+// DefaultPort is the default SMTP submission port
+const DefaultPort = 587
+
+// DefaultTimeout is the default amount of time to wait for the SMTP server to accept a message
+const DefaultTimeout = 30 * time.Second
+
+// SMTPConfig contains SMTP server settings, credentials are only sent if a username is set
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTPSender sends HTML emails through an SMTP server, upgrading the connection with STARTTLS if the server supports it
+type SMTPSender struct {
+	c SMTPConfig
+}
+
+// NewSMTPSender instantiates a new SMTPSender, zero config values are replaced with defaults
+func NewSMTPSender(c SMTPConfig) *SMTPSender {
+	if c.Port <= 0 {
+		c.Port = DefaultPort
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	return &SMTPSender{c: c}
+}
+
+// Send sends an HTML email to a single recipient
+func (s *SMTPSender) Send(ctx context.Context, to string, subject string, html []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.c.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.c.Host, strconv.Itoa(s.c.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to SMTP server %v: %v", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, s.c.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error starting SMTP session with %v: %v", addr, err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.c.Host}); err != nil {
+			return fmt.Errorf("error starting TLS: %v", err)
+		}
+	}
+	if s.c.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.c.Username, s.c.Password, s.c.Host)); err != nil {
+			return fmt.Errorf("error authenticating: %v", err)
+		}
+	}
+	if err := c.Mail(s.c.From); err != nil {
+		return fmt.Errorf("error setting sender: %v", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("error setting recipient: %v", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("error starting message: %v", err)
+	}
+	if _, err := w.Write(s.message(to, subject, html)); err != nil {
+		return fmt.Errorf("error writing message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending message: %v", err)
+	}
+	return c.Quit()
+}
+
+func (s *SMTPSender) message(to string, subject string, html []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", s.c.From)
+	fmt.Fprintf(&b, "To: %v\r\n", to)
+	fmt.Fprintf(&b, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %v\r\n", clock.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.Write(html)
+	return b.Bytes()
+}
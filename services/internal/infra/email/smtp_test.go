@@ -0,0 +1,122 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// newSink starts a local SMTP server that accepts every message without TLS or authentication, recording what it receives
+func newSink(t *testing.T) (SMTPConfig, <-chan received) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting SMTP sink: %v", err)
+	}
+	msgs := make(chan received, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSink(conn, msgs)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: addr.IP.String(), Port: addr.Port, From: "tasks@example.com"}, msgs
+}
+
+func serveSink(conn net.Conn, msgs chan<- received) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 sink ready")
+	var msg received
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 sink")
+		case "MAIL":
+			msg = received{from: addrArg(line)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, addrArg(line))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			b, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(b)
+			msgs <- msg
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// addrArg returns the address in a MAIL FROM:<...> or RCPT TO:<...> command
+func addrArg(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	c, msgs := newSink(t)
+	s := NewSMTPSender(c)
+
+	if err := s.Send(context.Background(), "user@example.com", "Ünïcode subject", []byte("<p>Hello</p>\n")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	msg := <-msgs
+	if msg.from != "tasks@example.com" || len(msg.to) != 1 || msg.to[0] != "user@example.com" {
+		t.Errorf("Send() from %v to %v, want tasks@example.com to [user@example.com]", msg.from, msg.to)
+	}
+	tr := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data)))
+	h, err := tr.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("error reading message headers: %v", err)
+	}
+	if got := h.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %v, want text/html; charset=utf-8", got)
+	}
+	if got := h.Get("Subject"); got != "=?utf-8?q?=C3=9Cn=C3=AFcode_subject?=" {
+		t.Errorf("Subject = %v, want it Q-encoded", got)
+	}
+	if !strings.Contains(msg.data, "<p>Hello</p>") {
+		t.Errorf("message body = %v, want it to contain the HTML", msg.data)
+	}
+}
+
+func TestSMTPSender_Send_unavailable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	s := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: port})
+	if err := s.Send(context.Background(), "user@example.com", "subject", nil); err == nil || !strings.Contains(err.Error(), strconv.Itoa(port)) {
+		t.Errorf("Send() error = %v, want a connection error", err)
+	}
+}
@@ -0,0 +1,63 @@
+package email
+
+import (
+	"html/template"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// templateTask is a task's details, as shown in emails
+type templateTask struct {
+	ID          usecase.TaskID
+	Name        string
+	Description string
+	Priority    string
+	DueTime     time.Time
+	URL         string
+}
+
+type taskGeneratedData struct {
+	Task templateTask
+}
+
+type digestData struct {
+	Date    time.Time
+	Overdue []templateTask
+	Open    []templateTask
+}
+
+var funcs = template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		return t.UTC().Format("Mon Jan 2 15:04 MST")
+	},
+}
+
+const layout = `{{define "task"}}<li>{{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}{{if ne .Priority "none"}} ({{.Priority}} priority){{end}}{{if not .DueTime.IsZero}}, due {{formatTime .DueTime}}{{end}}{{if .Description}}<br>{{.Description}}{{end}}</li>{{end}}`
+
+var taskGeneratedTemplate = template.Must(template.New("taskGenerated").Funcs(funcs).Parse(layout + `<!DOCTYPE html>
+<html>
+<body>
+<p>A new task was generated for you:</p>
+<ul>
+{{template "task" .Task}}
+</ul>
+</body>
+</html>
+`))
+
+var digestTemplate = template.Must(template.New("digest").Funcs(funcs).Parse(layout + `<!DOCTYPE html>
+<html>
+<body>
+<p>Your open tasks for {{.Date.Format "Monday, January 2"}}:</p>
+{{if .Overdue}}<h3>Overdue</h3>
+<ul>
+{{range .Overdue}}{{template "task" .}}
+{{end}}</ul>
+{{end}}{{if .Open}}<h3>Open</h3>
+<ul>
+{{range .Open}}{{template "task" .}}
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,126 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/eventbus")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// DefaultInterval is the default amount of time to wait between checks of the outbox, when it's empty
+const DefaultInterval = time.Second
+
+// DefaultMaxAttempts is the default number of times publishing an event is attempted before giving up on it
+const DefaultMaxAttempts = 10
+
+// DefaultRetryDelay is the default amount of time to wait before retrying an event, doubling after each failed attempt
+const DefaultRetryDelay = time.Second
+
+// maxRetryDelay is the longest wait between attempts, the default policy retries an event for about 8 minutes
+const maxRetryDelay = 5 * time.Minute
+
+// Config contains event publishing settings
+type Config struct {
+	Interval    time.Duration
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// Bus publishes domain events from the outbox to in-process subscribers in the background
+// events are added to the outbox by other processes' repos, so the outbox is polled for them
+type Bus struct {
+	l           Logger
+	repo        usecase.OutboxRepo
+	subscribers []usecase.EventSubscriber
+	interval    time.Duration
+	retry       webhook.RetryPolicy
+}
+
+// New instantiates a new Bus, zero config values are replaced with defaults
+func New(l Logger, repo usecase.OutboxRepo, c Config, subscribers ...usecase.EventSubscriber) *Bus {
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = DefaultRetryDelay
+	}
+	return &Bus{
+		l:           l,
+		repo:        repo,
+		subscribers: subscribers,
+		interval:    c.Interval,
+		retry:       webhook.RetryPolicy{MaxAttempts: c.MaxAttempts, Delay: c.RetryDelay, MaxDelay: maxRetryDelay},
+	}
+}
+
+// Run starts publishing events in the background, until closed
+func (b *Bus) Run() (close chan<- bool, closed <-chan bool) {
+	b.l.Info("event bus starting", "subscribers", len(b.subscribers))
+
+	closeSignal := make(chan bool)
+	onClosed := make(chan bool)
+
+	go func() {
+		defer func() {
+			select {
+			case onClosed <- true:
+			default:
+			}
+		}()
+		for {
+			wait := b.interval
+			count, err := b.publish()
+			if err != nil {
+				b.l.Error("error publishing events", "error", err)
+			} else if count >= usecase.EventBatchSize {
+				// more events may be pending, check again right away
+				wait = 0
+			}
+			if count > 0 {
+				b.l.Debug("published events", "count", count)
+			}
+
+			select {
+			case <-closeSignal:
+				b.l.Info("event bus exiting")
+				return
+			case <-clock.After(wait):
+			}
+		}
+	}()
+
+	return closeSignal, onClosed
+}
+
+// publish publishes pending events in a new trace, so each run's subscriber calls and queries are grouped together
+func (b *Bus) publish() (int, error) {
+	ctx, span := tracer.Start(context.Background(), "eventbus.publish")
+	defer span.End()
+
+	count, ucerr := usecase.PublishEvents(ctx, b.repo, b.subscribers, b.retry)
+	span.SetAttributes(attribute.Int("eventbus.events_published", count))
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return count, ucerr
+	}
+	return count, nil
+}
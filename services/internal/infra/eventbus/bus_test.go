@@ -0,0 +1,83 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+type subscriberStub struct {
+	received chan usecase.EventData
+}
+
+func (s *subscriberStub) Name() string {
+	return "stub"
+}
+
+func (s *subscriberStub) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	s.received <- ed
+	return nil
+}
+
+func TestBus(t *testing.T) {
+	ctx := context.Background()
+
+	outbox := transient.NewOutboxRepo()
+	taskRepo := transient.NewTaskRepo()
+	taskRepo.SetOutboxRepo(outbox)
+	s := &subscriberStub{received: make(chan usecase.EventData, 10)}
+
+	b := New(&loggerStub{}, outbox, Config{Interval: 10 * time.Millisecond}, s)
+	closeBus, closed := b.Run()
+	defer func() {
+		closeBus <- true
+		<-closed
+	}()
+
+	uid := user.NewID()
+	td, _ := usecase.AddTask(ctx, taskRepo, transient.NewActivityRepo(), task.New("t1", "", uid))
+	usecase.CompleteTask(ctx, taskRepo, transient.NewActivityRepo(), td.TaskID, uid)
+
+	for _, want := range []event.Type{event.TaskCreated, event.TaskCompleted} {
+		select {
+		case ed := <-s.received:
+			if ed.Event.Type() != want || ed.TaskID != td.TaskID {
+				t.Errorf("subscriber received %v, want %v task %v", ed, want, td.TaskID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("subscriber did not receive %v event before timeout", want)
+		}
+	}
+	select {
+	case extra := <-s.received:
+		t.Errorf("subscriber received unexpected event %v", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
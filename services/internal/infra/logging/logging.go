@@ -0,0 +1,265 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry
+type Level int8
+
+// Log levels, entries below the configured level are discarded
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (lv Level) String() string {
+	switch lv {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("[Unknown level %d]", lv)
+}
+
+// ParseLevel parses a level name, an empty name is parsed as LevelInfo
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level '%v'", name)
+}
+
+// Config contains configuration options for a Logger
+type Config struct {
+	// Level is the minimum level logged
+	Level Level
+	// JSON writes each entry as a JSON object instead of a line of text
+	JSON bool
+}
+
+// Logger writes leveled log entries with key/value fields
+type Logger struct {
+	out    *output
+	name   string
+	fields []interface{}
+}
+
+// output is shared by a logger and all loggers derived from it with With
+type output struct {
+	mu  sync.Mutex
+	w   io.Writer
+	c   Config
+	now func() time.Time
+}
+
+// New returns a logger writing to w, name identifies the process in each entry
+func New(w io.Writer, name string, c Config) *Logger {
+	return &Logger{out: &output{w: w, c: c, now: time.Now}, name: name}
+}
+
+// With returns a logger that adds the key/value fields to every entry
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv)+1)
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	if len(kv)%2 != 0 {
+		fields = append(fields, nil)
+	}
+	return &Logger{out: l.out, name: l.name, fields: fields}
+}
+
+// Debug logs a message with key/value fields at debug level
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.log(LevelDebug, msg, kv)
+}
+
+// Info logs a message with key/value fields at info level
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.log(LevelInfo, msg, kv)
+}
+
+// Warn logs a message with key/value fields at warn level
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.log(LevelWarn, msg, kv)
+}
+
+// Error logs a message with key/value fields at error level
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.log(LevelError, msg, kv)
+}
+
+// Printf logs a formatted message at info level
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.log(LevelInfo, fmt.Sprintf(format, v...), nil)
+}
+
+// Warnf logs a formatted message at warn level
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.log(LevelWarn, fmt.Sprintf(format, v...), nil)
+}
+
+// Errorf logs a formatted message at error level
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.log(LevelError, fmt.Sprintf(format, v...), nil)
+}
+
+// Panic logs its arguments at error level, then panics with them
+func (l *Logger) Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	l.log(LevelError, msg, nil)
+	panic(msg)
+}
+
+func (l *Logger) log(lv Level, msg string, kv []interface{}) {
+	if lv < l.out.c.Level {
+		return
+	}
+	fields := l.fields
+	if len(kv) > 0 {
+		fields = append(append([]interface{}{}, l.fields...), kv...)
+		if len(kv)%2 != 0 {
+			fields = append(fields, nil)
+		}
+	}
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	now := l.out.now()
+	var b []byte
+	if l.out.c.JSON {
+		b = formatJSON(now, lv, l.name, msg, fields)
+	} else {
+		b = formatText(now, lv, l.name, msg, fields)
+	}
+	l.out.w.Write(b)
+}
+
+func formatJSON(now time.Time, lv Level, name string, msg string, fields []interface{}) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, now.UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, lv.String())
+	if name != "" {
+		buf.WriteString(`,"logger":`)
+		writeJSON(buf, name)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, msg)
+	for i := 0; i+1 < len(fields); i += 2 {
+		buf.WriteByte(',')
+		writeJSON(buf, key(fields[i]))
+		buf.WriteByte(':')
+		writeJSON(buf, value(fields[i+1]))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
+
+func formatText(now time.Time, lv Level, name string, msg string, fields []interface{}) []byte {
+	buf := &bytes.Buffer{}
+	if name != "" {
+		buf.WriteString(name)
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(now.Format("2006/01/02 15:04:05"))
+	buf.WriteByte(' ')
+	buf.WriteString(strings.ToUpper(lv.String()))
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	for i := 0; i+1 < len(fields); i += 2 {
+		buf.WriteByte(' ')
+		buf.WriteString(key(fields[i]))
+		buf.WriteByte('=')
+		buf.WriteString(quote(fmt.Sprint(value(fields[i+1]))))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func key(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	return fmt.Sprint(k)
+}
+
+// value converts errors and stringers to strings, so they're formatted the same way in text and JSON entries
+func value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	case time.Duration:
+		return val.String()
+	}
+	return v
+}
+
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Printer is the formatted logging interface that request handlers depend on
+type Printer interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context that carries the logger
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by the context, or nil if there is none
+func FromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(contextKey{}).(*Logger)
+	return l
+}
+
+// Request returns the logger attached to a request, with its request-scoped fields, falling back to l if there is none
+func Request(r *http.Request, l Printer) Printer {
+	if rl := FromContext(r.Context()); rl != nil {
+		return rl
+	}
+	return l
+}
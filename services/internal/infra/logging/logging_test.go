@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLogger(c Config) (*Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l := New(buf, "api", c)
+	l.out.now = func() time.Time { return time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return l, buf
+}
+
+func TestLogger_text(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *Logger)
+		want string
+	}{
+		{
+			name: "should write message with level",
+			log:  func(l *Logger) { l.Info("starting") },
+			want: "api 2000/01/02 03:04:05 INFO starting\n",
+		},
+		{
+			name: "should write key/value fields, quoting values with spaces",
+			log:  func(l *Logger) { l.Warn("failed", "id", 5, "error", errors.New("not found")) },
+			want: "api 2000/01/02 03:04:05 WARN failed id=5 error=\"not found\"\n",
+		},
+		{
+			name: "should write fields added with With before entry fields",
+			log:  func(l *Logger) { l.With("request_id", "abc").Error("oops", "status", 500) },
+			want: "api 2000/01/02 03:04:05 ERROR oops request_id=abc status=500\n",
+		},
+		{
+			name: "should write formatted message",
+			log:  func(l *Logger) { l.Errorf("error adding task: %v", "invalid") },
+			want: "api 2000/01/02 03:04:05 ERROR error adding task: invalid\n",
+		},
+		{
+			name: "should give a key without a value a nil value",
+			log:  func(l *Logger) { l.Info("odd", "key") },
+			want: "api 2000/01/02 03:04:05 INFO odd key=<nil>\n",
+		},
+		{
+			name: "should discard entries below the configured level",
+			log:  func(l *Logger) { l.Debug("checking schedules") },
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newTestLogger(Config{Level: LevelInfo})
+			tt.log(l)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Logger output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_json(t *testing.T) {
+	l, buf := newTestLogger(Config{Level: LevelDebug, JSON: true})
+	l.With("request_id", "abc").Debug("checked schedule", "schedule_id", 3, "wait", time.Second)
+
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Logger output %q is not JSON: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"time":        "2000-01-02T03:04:05Z",
+		"level":       "debug",
+		"logger":      "api",
+		"msg":         "checked schedule",
+		"request_id":  "abc",
+		"schedule_id": float64(3),
+		"wait":        "1s",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Logger output %v = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Level
+		wantErr bool
+	}{
+		{name: "", want: LevelInfo},
+		{name: "debug", want: LevelDebug},
+		{name: "WARN", want: LevelWarn},
+		{name: "error", want: LevelError},
+		{name: "verbose", want: LevelInfo, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	base, _ := newTestLogger(Config{})
+	rl := base.With("request_id", "abc")
+	r := httptest.NewRequest("GET", "/", nil)
+
+	if got := Request(r, base); got != base {
+		t.Errorf("Request() without a context logger = %v, want base logger", got)
+	}
+	r = r.WithContext(NewContext(r.Context(), rl))
+	if got := Request(r, base); got != rl {
+		t.Errorf("Request() with a context logger = %v, want context logger", got)
+	}
+}
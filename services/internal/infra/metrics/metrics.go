@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// namespace prefixes every metric name
+const namespace = "scheduled_tasks"
+
+// Metrics collects API, scheduler and DB metrics, and exposes them in the Prometheus text format
+// A nil *Metrics records nothing, so instrumentation can be disabled by passing nil
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	loopDuration     prometheus.Histogram
+	schedulesChecked prometheus.Counter
+	tasksGenerated   prometheus.Counter
+	generationErrors prometheus.Counter
+	nextRunLag       prometheus.Histogram
+}
+
+// New creates a new set of metrics, along with Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		loopDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "loop_duration_seconds",
+			Help:      "Time taken to check all schedules and generate their tasks.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		schedulesChecked: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "schedules_checked_total",
+			Help:      "Number of schedules checked for recurrences.",
+		}),
+		tasksGenerated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "tasks_generated_total",
+			Help:      "Number of tasks generated from schedule recurrences.",
+		}),
+		generationErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "generation_errors_total",
+			Help:      "Number of scheduler runs that failed to check schedules or generate tasks.",
+		}),
+		nextRunLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "next_run_lag_seconds",
+			Help:      "How late the scheduler woke up after its planned next run time.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		}),
+	}
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.loopDuration,
+		m.schedulesChecked,
+		m.tasksGenerated,
+		m.generationErrors,
+		m.nextRunLag,
+	)
+	return m
+}
+
+// Handler returns an HTTP handler that serves all collected metrics
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a handled HTTP request, route is the matched route pattern rather than the request path, so path IDs don't create new series
+func (m *Metrics) ObserveRequest(method string, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// ObserveSchedulerRun records a scheduler run's duration, and the result of each schedule it checked
+func (m *Metrics) ObserveSchedulerRun(d time.Duration, checks []usecase.ScheduleCheck, err error) {
+	if m == nil {
+		return
+	}
+	m.loopDuration.Observe(d.Seconds())
+	m.schedulesChecked.Add(float64(len(checks)))
+	for _, c := range checks {
+		m.tasksGenerated.Add(float64(c.TasksCreated))
+	}
+	if err != nil {
+		m.generationErrors.Inc()
+	}
+}
+
+// ObserveNextRunLag records how late the scheduler woke up after its planned next run time
+func (m *Metrics) ObserveNextRunLag(lag time.Duration) {
+	if m == nil {
+		return
+	}
+	if lag < 0 {
+		lag = 0
+	}
+	m.nextRunLag.Observe(lag.Seconds())
+}
+
+// RegisterDB adds connection pool stats for a DB, labelled with its name
+func (m *Metrics) RegisterDB(name string, db *sql.DB) {
+	if m == nil || db == nil {
+		return
+	}
+	m.registry.MustRegister(newDBCollector(name, db))
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET", "/api/v1/task/:taskID", 200, time.Millisecond)
+	m.ObserveRequest("GET", "/api/v1/task/:taskID", 200, time.Millisecond)
+	m.ObserveRequest("GET", "/api/v1/task/:taskID", 404, time.Millisecond)
+
+	if got := testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/task/:taskID", "200")); got != 2 {
+		t.Errorf("Metrics.ObserveRequest() 200 count = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/task/:taskID", "404")); got != 1 {
+		t.Errorf("Metrics.ObserveRequest() 404 count = %v, want 1", got)
+	}
+}
+
+func TestMetrics_ObserveSchedulerRun(t *testing.T) {
+	m := New()
+	m.ObserveSchedulerRun(time.Second, []usecase.ScheduleCheck{{ScheduleID: 1, TasksCreated: 3}, {ScheduleID: 2}}, nil)
+	m.ObserveSchedulerRun(time.Second, []usecase.ScheduleCheck{{ScheduleID: 1, Err: errors.New("failed")}}, errors.New("failed"))
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "schedules checked", got: testutil.ToFloat64(m.schedulesChecked), want: 3},
+		{name: "tasks generated", got: testutil.ToFloat64(m.tasksGenerated), want: 3},
+		{name: "generation errors", got: testutil.ToFloat64(m.generationErrors), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("Metrics.ObserveSchedulerRun() %v = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET", "/api/v1/task/", 200, time.Millisecond)
+	m.ObserveNextRunLag(2 * time.Second)
+	m.RegisterDB("api", &sql.DB{})
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`scheduled_tasks_http_requests_total{method="GET",route="/api/v1/task/",status="200"} 1`,
+		`scheduled_tasks_scheduler_next_run_lag_seconds_count 1`,
+		`scheduled_tasks_db_open_connections{db="api"} 0`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics.Handler() output missing %q", want)
+		}
+	}
+}
+
+func TestMetrics_nil(t *testing.T) {
+	var m *Metrics
+	m.ObserveRequest("GET", "/", 200, time.Millisecond)
+	m.ObserveSchedulerRun(time.Second, nil, errors.New("failed"))
+	m.ObserveNextRunLag(time.Second)
+	m.RegisterDB("api", &sql.DB{})
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != 404 {
+		t.Errorf("nil Metrics.Handler() status = %v, want 404", rr.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package netguard
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// blockedNets are the address ranges outbound requests to user-supplied URLs can't connect to,
+// so they can't be used to reach the server itself or services on its internal networks
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, including cloud metadata services
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved, including broadcast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+// nat64Net is the well-known IPv4/IPv6 translation prefix, addresses in it embed an IPv4 address in their last 4 bytes
+var nat64Net = parseCIDRs("64:ff9b::/96")[0]
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// IsPublic returns whether an IP address is publicly routable, rather than private, loopback, link-local or otherwise reserved
+func IsPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else if nat64Net.Contains(ip) {
+		ip = ip[12:]
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Control is a net.Dialer Control hook that refuses connections to addresses that aren't publicly routable
+// it's called with the resolved address of every connection, so it also applies to hostnames and redirects
+func Control(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid address '%v'", address)
+	}
+	if !IsPublic(ip) {
+		return fmt.Errorf("connecting to non-public address %v is not allowed", ip)
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client for requests to user-supplied URLs, which fail if they take longer than the timeout
+// connections to addresses that aren't publicly routable are refused unless allowPrivate is set, e.g. for local testing
+// requests aren't sent through environment-configured proxies, since the proxy would connect to the destination instead
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !allowPrivate {
+		dialer.Control = Control
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package netguard
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "64:ff9b::5db8:d822", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "::1", want: false},
+		{ip: "::", want: false},
+		{ip: "::ffff:127.0.0.1", want: false},
+		{ip: "64:ff9b::7f00:1", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "fe80::1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := IsPublic(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("IsPublic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	localhost := strings.Replace(s.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		wantErr      string
+	}{
+		{name: "should refuse a loopback address", url: s.URL, wantErr: "connecting to non-public address 127.0.0.1 is not allowed"},
+		{name: "should refuse a hostname once it resolves to a loopback address", url: localhost, wantErr: "is not allowed"},
+		{name: "should connect to a loopback address if private addresses are allowed", url: s.URL, allowPrivate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewClient(time.Second, tt.allowPrivate).Get(tt.url)
+			if err == nil {
+				res.Body.Close()
+			}
+			if (err == nil && tt.wantErr != "") || (err != nil && (tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr))) {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,15 +1,30 @@
 package scheduler
 
 import (
+	"context"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
-// Logger interface needed for log messages
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/scheduler")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
 type Logger interface {
-	Printf(format string, v ...interface{})
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// Metrics interface needed to record scheduler metrics
+type Metrics interface {
+	ObserveSchedulerRun(d time.Duration, checks []usecase.ScheduleCheck, err error)
+	ObserveNextRunLag(lag time.Duration)
 }
 
 // Offset is the offset added to the next run time
@@ -19,33 +34,63 @@ const Offset = 3 * time.Second
 const DefaultWait = 7 * 24 * time.Hour
 
 // Run starts the scheduler process
-func Run(l Logger, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo, nextRun chan time.Time) (close chan<- bool, check chan<- bool, closed <-chan bool) {
-	l.Printf("scheduler process starting")
+// if m is not nil, the duration and results of each run are recorded to it
+// if status is not nil, it is kept up to date with whether the process is running and when it last checked schedules
+// each occurrence of a schedule, and each check that found occurrences or failed, is recorded as a run in runRepo
+// the creation of each generated task is recorded in its activity history in activityRepo
+func Run(l Logger, m Metrics, status *Status, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo, scheduleRepo usecase.ScheduleRepo, runRepo usecase.ScheduleRunRepo, nextRun chan time.Time) (close chan<- bool, check chan<- bool, closed <-chan bool) {
+	l.Info("scheduler process starting")
 
 	checkSignal := make(chan bool)
 	closeSignal := make(chan bool)
 	onClosed := make(chan bool)
 
+	if status != nil {
+		status.setRunning(true)
+	}
 	go func() {
 		defer func() {
+			if status != nil {
+				status.setRunning(false)
+			}
 			select {
 			case onClosed <- true:
 			default:
 			}
 		}()
 		for {
-			l.Printf("checking schedules")
-			nextRecurrence, err := usecase.CheckSchedules(taskRepo, scheduleRepo)
-			if err != nil {
-				l.Printf("error checking schedules: %v", err)
+			l.Debug("checking schedules")
+			start := clock.Now()
+			nextRecurrence, checks, err := checkSchedules(taskRepo, activityRepo, scheduleRepo, runRepo)
+			if m != nil {
+				m.ObserveSchedulerRun(clock.Now().Sub(start), checks, err)
+			}
+			if status != nil {
+				status.checked(clock.Now(), err)
+			}
+			for _, c := range checks {
+				if c.RecordErr != nil {
+					l.Error("error recording schedule runs or task activity", "schedule_id", c.ScheduleID, "error", c.RecordErr)
+				}
+				if c.Err != nil {
+					l.Error("error checking schedule", "schedule_id", c.ScheduleID, "tasks_created", c.TasksCreated, "error", c.Err)
+					continue
+				}
+				l.Debug("checked schedule", "schedule_id", c.ScheduleID, "tasks_created", c.TasksCreated, "next", c.Next)
+				if c.TasksCreated > 0 {
+					l.Info("created scheduled tasks", "schedule_id", c.ScheduleID, "tasks_created", c.TasksCreated)
+				}
+			}
+			if err != nil && (len(checks) == 0 || checks[len(checks)-1].Err == nil) {
+				l.Error("error checking schedules", "error", err)
 			}
 			if nextRecurrence.IsZero() {
-				l.Printf("no upcoming schedules, setting default wait to check schedule in %v from now", DefaultWait)
+				l.Info("no upcoming schedules, setting default wait", "wait", DefaultWait)
 				nextRecurrence = clock.Now().Add(DefaultWait)
 			}
 
 			// Sleep until next scheduled time + offset
-			l.Printf("next run scheduled for %v + %v offset", nextRecurrence, Offset)
+			l.Info("next run scheduled", "next", nextRecurrence, "offset", Offset, "schedules_checked", len(checks))
 			nextRunTime := nextRecurrence.Add(Offset)
 
 			// Notify receivers of next runtime
@@ -61,13 +106,30 @@ func Run(l Logger, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo,
 			// Listen for exit signal, check signal, or until next recurrence is ready
 			select {
 			case <-closeSignal:
-				l.Printf("scheduler exiting")
+				l.Info("scheduler exiting")
 				return
 			case <-checkSignal:
 			case <-clock.After(wait):
+				if m != nil {
+					m.ObserveNextRunLag(clock.Now().Sub(nextRunTime))
+				}
 			}
 		}
 	}()
 
 	return closeSignal, checkSignal, onClosed
 }
+
+// checkSchedules checks all schedules for recurrences in a new trace, so each run's use cases and queries are grouped together
+func checkSchedules(taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo, scheduleRepo usecase.ScheduleRepo, runRepo usecase.ScheduleRunRepo) (time.Time, []usecase.ScheduleCheck, error) {
+	ctx, span := tracer.Start(context.Background(), "scheduler.run")
+	defer span.End()
+
+	nextRecurrence, checks, err := usecase.CheckSchedules(ctx, taskRepo, activityRepo, scheduleRepo, runRepo)
+	span.SetAttributes(attribute.Int("scheduler.schedules_checked", len(checks)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return nextRecurrence, checks, err
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -14,9 +15,21 @@ import (
 
 type loggerStub struct{}
 
-func (l *loggerStub) Printf(format string, v ...interface{}) {
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
 	if testing.Verbose() {
-		fmt.Printf(fmt.Sprintf("    LOG: %v\n", format), v...)
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
 	}
 }
 
@@ -28,6 +41,7 @@ func closeNonBlocking(ch chan<- bool) {
 }
 
 func TestRun(t *testing.T) {
+	ctx := context.Background()
 
 	timeout := 10 * time.Millisecond
 
@@ -89,7 +103,7 @@ func TestRun(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 				return args{
 					l:            &loggerStub{},
 					taskRepo:     transient.NewTaskRepo(),
@@ -125,7 +139,7 @@ func TestRun(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				clock.Set(clock.NewStaticMock(testNow))
@@ -154,7 +168,7 @@ func TestRun(t *testing.T) {
 					return
 				}
 
-				tasks, err := a.taskRepo.GetAll()
+				tasks, err := a.taskRepo.GetAll(ctx)
 				if err != nil {
 					t.Errorf("scheduler.Run() error retrieving tasks: %v", err)
 					return
@@ -176,7 +190,7 @@ func TestRun(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				clock.Set(clock.NewStaticMock(testNow))
@@ -205,7 +219,7 @@ func TestRun(t *testing.T) {
 					return
 				}
 
-				schedules, err := a.scheduleRepo.GetAll()
+				schedules, err := a.scheduleRepo.GetAll(ctx)
 				if err != nil {
 					t.Errorf("scheduler.Run() error retrieving schedules: %v", err)
 					return
@@ -236,7 +250,7 @@ func TestRun(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				firstCheckTime := time.Date(2000, time.January, 1, 12, 1, 0, 0, time.UTC)
 				s.Check(firstCheckTime)
@@ -275,7 +289,7 @@ func TestRun(t *testing.T) {
 					return
 				}
 
-				tasks, err := a.taskRepo.GetAll()
+				tasks, err := a.taskRepo.GetAll(ctx)
 				if err != nil {
 					t.Errorf("scheduler.Run() there was an error retrieving tasks: %v", err)
 					return
@@ -302,7 +316,7 @@ func TestRun(t *testing.T) {
 			if args.prevClock != nil {
 				defer clock.Set(args.prevClock)
 			}
-			close, check, closed := Run(args.l, args.taskRepo, args.scheduleRepo, args.nextRun)
+			close, check, closed := Run(args.l, nil, nil, args.taskRepo, transient.NewActivityRepo(), args.scheduleRepo, transient.NewScheduleRunRepo(), args.nextRun)
 			defer closeNonBlocking(close)
 			tt.assert(t, args, resp{close, check, closed})
 		})
@@ -310,6 +324,7 @@ func TestRun(t *testing.T) {
 }
 
 func TestHourFrequencyIntervalOffsets(t *testing.T) {
+	ctx := context.Background()
 
 	timeout := 10 * time.Millisecond
 
@@ -345,7 +360,7 @@ func TestHourFrequencyIntervalOffsets(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				return args{
 					l:            &loggerStub{},
@@ -381,7 +396,7 @@ func TestHourFrequencyIntervalOffsets(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				return args{
 					l:            &loggerStub{},
@@ -417,7 +432,7 @@ func TestHourFrequencyIntervalOffsets(t *testing.T) {
 				f.SetInterval(2)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 1, 0, 10, 0, 0, time.UTC)
@@ -459,7 +474,7 @@ func TestHourFrequencyIntervalOffsets(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 1, 1, 10, 0, 0, time.UTC)
@@ -496,7 +511,7 @@ func TestHourFrequencyIntervalOffsets(t *testing.T) {
 			if args.prevClock != nil {
 				defer clock.Set(args.prevClock)
 			}
-			close, check, closed := Run(args.l, args.taskRepo, args.scheduleRepo, args.nextRun)
+			close, check, closed := Run(args.l, nil, nil, args.taskRepo, transient.NewActivityRepo(), args.scheduleRepo, transient.NewScheduleRunRepo(), args.nextRun)
 			defer closeNonBlocking(close)
 			tt.assert(t, args, resp{close, check, closed})
 		})
@@ -504,6 +519,7 @@ func TestHourFrequencyIntervalOffsets(t *testing.T) {
 }
 
 func TestDayFrequency(t *testing.T) {
+	ctx := context.Background()
 
 	timeout := 10 * time.Millisecond
 
@@ -539,7 +555,7 @@ func TestDayFrequency(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				return args{
 					l:            &loggerStub{},
@@ -576,7 +592,7 @@ func TestDayFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 2, 1, 10, 0, 0, time.UTC)
@@ -613,7 +629,7 @@ func TestDayFrequency(t *testing.T) {
 			if args.prevClock != nil {
 				defer clock.Set(args.prevClock)
 			}
-			close, check, closed := Run(args.l, args.taskRepo, args.scheduleRepo, args.nextRun)
+			close, check, closed := Run(args.l, nil, nil, args.taskRepo, transient.NewActivityRepo(), args.scheduleRepo, transient.NewScheduleRunRepo(), args.nextRun)
 			defer closeNonBlocking(close)
 			tt.assert(t, args, resp{close, check, closed})
 		})
@@ -621,6 +637,7 @@ func TestDayFrequency(t *testing.T) {
 }
 
 func TestWeekFrequency(t *testing.T) {
+	ctx := context.Background()
 
 	timeout := 100 * time.Millisecond
 
@@ -656,7 +673,7 @@ func TestWeekFrequency(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				return args{
 					l:            &loggerStub{},
@@ -693,7 +710,7 @@ func TestWeekFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 3, 1, 10, 0, 0, time.UTC)
@@ -735,7 +752,7 @@ func TestWeekFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 3, 1, 10, 0, 0, time.UTC)
@@ -777,7 +794,7 @@ func TestWeekFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 3, 0, 10, 0, 0, time.UTC)
@@ -819,7 +836,7 @@ func TestWeekFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 3, 1, 10, 0, 0, time.UTC)
@@ -861,7 +878,7 @@ func TestWeekFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.January, 3, 1, 10, 0, 0, time.UTC)
@@ -898,7 +915,7 @@ func TestWeekFrequency(t *testing.T) {
 			if args.prevClock != nil {
 				defer clock.Set(args.prevClock)
 			}
-			close, check, closed := Run(args.l, args.taskRepo, args.scheduleRepo, args.nextRun)
+			close, check, closed := Run(args.l, nil, nil, args.taskRepo, transient.NewActivityRepo(), args.scheduleRepo, transient.NewScheduleRunRepo(), args.nextRun)
 			defer closeNonBlocking(close)
 			tt.assert(t, args, resp{close, check, closed})
 		})
@@ -906,6 +923,7 @@ func TestWeekFrequency(t *testing.T) {
 }
 
 func TestMonthFrequency(t *testing.T) {
+	ctx := context.Background()
 
 	timeout := 10 * time.Millisecond
 
@@ -941,7 +959,7 @@ func TestMonthFrequency(t *testing.T) {
 				}
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				return args{
 					l:            &loggerStub{},
@@ -978,7 +996,7 @@ func TestMonthFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				prevClock := clock.Get()
 				checkTime := time.Date(2000, time.February, 1, 0, 10, 0, 0, time.UTC)
@@ -1020,7 +1038,7 @@ func TestMonthFrequency(t *testing.T) {
 				f.SetOffset(1)
 				s := schedule.New(f, user.ID{})
 				s.AddTask(schedule.NewRecurringTask("t1", "t1desc"))
-				sr.Add(s)
+				sr.Add(ctx, s)
 
 				return args{
 					l:            &loggerStub{},
@@ -1052,9 +1070,90 @@ func TestMonthFrequency(t *testing.T) {
 			if args.prevClock != nil {
 				defer clock.Set(args.prevClock)
 			}
-			close, check, closed := Run(args.l, args.taskRepo, args.scheduleRepo, args.nextRun)
+			close, check, closed := Run(args.l, nil, nil, args.taskRepo, transient.NewActivityRepo(), args.scheduleRepo, transient.NewScheduleRunRepo(), args.nextRun)
 			defer closeNonBlocking(close)
 			tt.assert(t, args, resp{close, check, closed})
 		})
 	}
 }
+
+type schedulerRun struct {
+	checks []usecase.ScheduleCheck
+	err    error
+}
+
+type metricsStub struct {
+	runs chan schedulerRun
+}
+
+func (m *metricsStub) ObserveSchedulerRun(d time.Duration, checks []usecase.ScheduleCheck, err error) {
+	m.runs <- schedulerRun{checks, err}
+}
+
+func (m *metricsStub) ObserveNextRunLag(lag time.Duration) {}
+
+func TestRun_metrics(t *testing.T) {
+	ctx := context.Background()
+
+	timeout := 10 * time.Millisecond
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(time.Date(2000, time.January, 1, 12, 30, 0, 0, time.UTC)))
+	defer clock.Set(prevClock)
+
+	sr := transient.NewScheduleRepo()
+	f, err := schedule.NewHourFrequency([]int{0})
+	if err != nil {
+		t.Fatalf("error creating frequency: %v", err)
+	}
+	sr.Add(ctx, schedule.NewRaw(f, false, time.Date(2000, time.January, 1, 10, 30, 0, 0, time.UTC), []schedule.RecurringTask{schedule.NewRecurringTask("t1", "t1desc")}, time.Time{}, user.ID{}))
+
+	m := &metricsStub{runs: make(chan schedulerRun, 1)}
+	close, _, _ := Run(&loggerStub{}, m, nil, transient.NewTaskRepo(), transient.NewActivityRepo(), sr, transient.NewScheduleRunRepo(), nil)
+	defer closeNonBlocking(close)
+
+	select {
+	case run := <-m.runs:
+		if run.err != nil {
+			t.Errorf("scheduler.Run() recorded error = %v", run.err)
+		}
+		if len(run.checks) != 1 || run.checks[0].TasksCreated != 2 {
+			t.Errorf("scheduler.Run() recorded checks = %+v, want 1 schedule with 2 tasks created", run.checks)
+		}
+	case <-time.After(timeout):
+		t.Errorf("scheduler.Run() should have recorded run metrics before %v timeout", timeout)
+	}
+}
+
+func TestRun_status(t *testing.T) {
+	timeout := 10 * time.Millisecond
+	now := time.Date(2000, time.January, 1, 12, 30, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	status := NewStatus()
+	nextRun := make(chan time.Time)
+	close, _, closed := Run(&loggerStub{}, nil, status, transient.NewTaskRepo(), transient.NewActivityRepo(), transient.NewScheduleRepo(), transient.NewScheduleRunRepo(), nextRun)
+
+	select {
+	case <-nextRun:
+	case <-time.After(timeout):
+		t.Fatalf("scheduler.Run() should have scheduled next run before %v timeout", timeout)
+	}
+	if !status.Running() {
+		t.Errorf("scheduler.Run() status should be running")
+	}
+	if !status.LastChecked().Equal(now) || status.LastError() != nil {
+		t.Errorf("scheduler.Run() status last checked = %v (error %v), want %v", status.LastChecked(), status.LastError(), now)
+	}
+
+	close <- true
+	select {
+	case <-closed:
+	case <-time.After(timeout):
+		t.Fatalf("scheduler.Run() should have closed before %v timeout", timeout)
+	}
+	if status.Running() {
+		t.Errorf("scheduler.Run() status should not be running after closing")
+	}
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// Status reports the state of a scheduler process for health checks, it is safe for concurrent use
+type Status struct {
+	mu          sync.RWMutex
+	running     bool
+	lastChecked time.Time
+	lastErr     error
+}
+
+// NewStatus returns the status of a scheduler process that hasn't started yet
+func NewStatus() *Status {
+	return &Status{}
+}
+
+// Running returns whether the scheduler process is running
+func (s *Status) Running() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.running
+}
+
+// LastChecked returns the time the scheduler last completed checking all schedules, zero if it hasn't yet
+func (s *Status) LastChecked() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastChecked
+}
+
+// LastError returns the error from the scheduler's most recent run, nil if it succeeded
+func (s *Status) LastError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
+
+func (s *Status) setRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = running
+}
+
+func (s *Status) checked(t time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if err == nil {
+		s.lastChecked = t
+	}
+}
@@ -0,0 +1,176 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// DefaultHistory is the default number of recent events kept, so reconnecting clients can resume where they left off
+const DefaultHistory = 1000
+
+// DefaultHeartbeat is the default amount of time between heartbeats sent to idle clients, so proxies don't close their connections
+const DefaultHeartbeat = 15 * time.Second
+
+// subscriptionBuffer is the number of events buffered for each subscriber, a subscriber that falls further behind is disconnected
+const subscriptionBuffer = 100
+
+// Config contains event stream settings
+type Config struct {
+	History   int
+	Heartbeat time.Duration
+}
+
+// Hub fans published task and schedule events out to the clients streaming them, each client only receives events for tasks and schedules its user can see
+// it subscribes to published domain events, keeping the most recent ones so a reconnecting client can resume from the last event it received
+type Hub struct {
+	l             Logger
+	taskRepo      usecase.TaskRepo
+	scheduleRepo  usecase.ScheduleRepo
+	workspaceRepo usecase.WorkspaceRepo
+	history       int
+	heartbeat     time.Duration
+
+	mu     sync.Mutex
+	recent []usecase.StreamEvent
+	subs   map[*Subscription]bool
+}
+
+// NewHub instantiates a new Hub, zero config values are replaced with defaults
+func NewHub(l Logger, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo, workspaceRepo usecase.WorkspaceRepo, c Config) *Hub {
+	if c.History <= 0 {
+		c.History = DefaultHistory
+	}
+	if c.Heartbeat <= 0 {
+		c.Heartbeat = DefaultHeartbeat
+	}
+	return &Hub{
+		l:             l,
+		taskRepo:      taskRepo,
+		scheduleRepo:  scheduleRepo,
+		workspaceRepo: workspaceRepo,
+		history:       c.History,
+		heartbeat:     c.Heartbeat,
+		subs:          make(map[*Subscription]bool),
+	}
+}
+
+// Subscription is a client's stream of events
+type Subscription struct {
+	hub     *Hub
+	uid     user.ID
+	events  chan usecase.StreamEvent
+	replay  []usecase.StreamEvent
+	resumed bool
+	closed  bool
+}
+
+// Events returns the channel events are sent on as they're published, it's closed if the subscriber falls too far behind or is closed
+func (s *Subscription) Events() <-chan usecase.StreamEvent {
+	return s.events
+}
+
+// Replay returns the events published after the last event the client received, to be sent before any others
+func (s *Subscription) Replay() []usecase.StreamEvent {
+	return s.replay
+}
+
+// Resumed returns whether the client resumed without missing any events
+// false if its last event is no longer kept, in which case it should reload everything it's showing
+func (s *Subscription) Resumed() bool {
+	return s.resumed
+}
+
+// Close stops sending events to the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Heartbeat returns the amount of time between heartbeats sent to idle clients
+func (h *Hub) Heartbeat() time.Duration {
+	return h.heartbeat
+}
+
+// Subscribe starts streaming events a user can see, an empty last event ID only streams new events
+func (h *Hub) Subscribe(uid user.ID, lastEventID string) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := &Subscription{hub: h, uid: uid, events: make(chan usecase.StreamEvent, subscriptionBuffer), resumed: lastEventID == ""}
+	if lastEventID != "" {
+		for i, se := range h.recent {
+			if se.Event.ID().String() != lastEventID {
+				continue
+			}
+			s.resumed = true
+			for _, missed := range h.recent[i+1:] {
+				if missed.VisibleTo(uid) {
+					s.replay = append(s.replay, missed)
+				}
+			}
+			break
+		}
+	}
+	h.subs[s] = true
+	return s
+}
+
+// Name identifies the subscriber in event delivery records
+func (h *Hub) Name() string {
+	return "stream"
+}
+
+// HandleEvent sends a published event to every subscriber whose user can see the task or schedule that emitted it
+// an event published again after a retry is only sent once
+func (h *Hub) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	se, ucerr := usecase.NewStreamEvent(ctx, h.taskRepo, h.scheduleRepo, h.workspaceRepo, ed)
+	if ucerr != nil {
+		return ucerr
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, prev := range h.recent {
+		if prev.Event.ID().Equals(ed.Event.ID()) {
+			return nil
+		}
+	}
+	h.recent = append(h.recent, se)
+	if len(h.recent) > h.history {
+		h.recent = h.recent[len(h.recent)-h.history:]
+	}
+	for s := range h.subs {
+		if !se.VisibleTo(s.uid) {
+			continue
+		}
+		select {
+		case s.events <- se:
+		default:
+			h.l.Info("disconnecting event stream subscriber that fell behind", "user_id", s.uid)
+			h.remove(s)
+		}
+	}
+	return nil
+}
+
+// remove stops sending events to a subscription, the hub's lock must be held
+func (h *Hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(h.subs, s)
+	close(s.events)
+}
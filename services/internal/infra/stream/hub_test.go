@@ -0,0 +1,233 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+type fixture struct {
+	hub          *Hub
+	taskRepo     *transient.TaskRepo
+	scheduleRepo *transient.ScheduleRepo
+	owner        user.ID
+	member       user.ID
+	outsider     user.ID
+	ws           workspace.ID
+}
+
+func newFixture(t *testing.T, c Config) fixture {
+	f := fixture{
+		taskRepo:     transient.NewTaskRepo(),
+		scheduleRepo: transient.NewScheduleRepo(),
+		owner:        user.NewID(),
+		member:       user.NewID(),
+		outsider:     user.NewID(),
+	}
+	workspaceRepo := transient.NewWorkspaceRepo()
+	ws, err := workspace.New("Household", f.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.AddMember(f.owner, f.member, workspace.RoleMember); err != nil {
+		t.Fatal(err)
+	}
+	if ucerr := workspaceRepo.Add(context.Background(), ws); ucerr != nil {
+		t.Fatal(ucerr)
+	}
+	f.ws = ws.ID()
+	f.hub = NewHub(&loggerStub{}, f.taskRepo, f.scheduleRepo, workspaceRepo, c)
+	return f
+}
+
+// publishTask adds a task, in a workspace if one is given, and publishes an event for it
+func (f fixture) publishTask(t *testing.T, typ event.Type, ws workspace.ID) usecase.EventData {
+	tsk := task.New("Water plants", "", f.owner)
+	tsk.SetWorkspace(ws)
+	id, ucerr := f.taskRepo.Add(context.Background(), tsk)
+	if ucerr != nil {
+		t.Fatal(ucerr)
+	}
+	ed := usecase.EventData{Event: event.New(typ), TaskID: id}
+	if err := f.hub.HandleEvent(context.Background(), ed); err != nil {
+		t.Fatal(err)
+	}
+	return ed
+}
+
+func received(s *Subscription) []usecase.StreamEvent {
+	var got []usecase.StreamEvent
+	for {
+		select {
+		case se, ok := <-s.Events():
+			if !ok {
+				return got
+			}
+			got = append(got, se)
+		default:
+			return got
+		}
+	}
+}
+
+func TestHub_HandleEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		workspace bool
+		wantOwner int
+		wantMbr   int
+	}{
+		{name: "private task event should only be sent to its creator", wantOwner: 1, wantMbr: 0},
+		{name: "workspace task event should be sent to every member", workspace: true, wantOwner: 1, wantMbr: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, Config{})
+			owner := f.hub.Subscribe(f.owner, "")
+			member := f.hub.Subscribe(f.member, "")
+			outsider := f.hub.Subscribe(f.outsider, "")
+			var ws workspace.ID
+			if tt.workspace {
+				ws = f.ws
+			}
+			ed := f.publishTask(t, event.TaskCreated, ws)
+
+			got := received(owner)
+			if len(got) != tt.wantOwner {
+				t.Fatalf("owner received %d events, want %d", len(got), tt.wantOwner)
+			}
+			if !got[0].Event.ID().Equals(ed.Event.ID()) || got[0].Task == nil || got[0].Task.Name() != "Water plants" {
+				t.Errorf("owner received %+v, want event %v with its task", got[0], ed.Event.ID())
+			}
+			if got := received(member); len(got) != tt.wantMbr {
+				t.Errorf("member received %d events, want %d", len(got), tt.wantMbr)
+			}
+			if got := received(outsider); len(got) != 0 {
+				t.Errorf("outsider received %d events, want 0", len(got))
+			}
+		})
+	}
+}
+
+func TestHub_HandleEvent_schedule(t *testing.T) {
+	f := newFixture(t, Config{})
+	sub := f.hub.Subscribe(f.owner, "")
+	sf, _ := schedule.NewHourFrequency([]int{0})
+	id, ucerr := f.scheduleRepo.Add(context.Background(), schedule.New(sf, f.owner))
+	if ucerr != nil {
+		t.Fatal(ucerr)
+	}
+	if err := f.hub.HandleEvent(context.Background(), usecase.EventData{Event: event.New(event.SchedulePaused), ScheduleID: id}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := received(sub)
+	if len(got) != 1 || got[0].IsTaskEvent() || got[0].Schedule == nil {
+		t.Fatalf("received %+v, want one schedule event with its schedule", got)
+	}
+}
+
+func TestHub_HandleEvent_republished(t *testing.T) {
+	f := newFixture(t, Config{})
+	sub := f.hub.Subscribe(f.owner, "")
+	ed := f.publishTask(t, event.TaskCompleted, workspace.ID{})
+	if err := f.hub.HandleEvent(context.Background(), ed); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := received(sub); len(got) != 1 {
+		t.Errorf("received %d events, want a republished event to only be sent once", len(got))
+	}
+}
+
+func TestHub_HandleEvent_slowSubscriber(t *testing.T) {
+	f := newFixture(t, Config{})
+	sub := f.hub.Subscribe(f.owner, "")
+	for i := 0; i <= subscriptionBuffer; i++ {
+		f.publishTask(t, event.TaskCreated, workspace.ID{})
+	}
+
+	if got := received(sub); len(got) != subscriptionBuffer {
+		t.Errorf("received %d events, want %d", len(got), subscriptionBuffer)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("subscriber that fell behind should be disconnected")
+	}
+	sub.Close()
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	f := newFixture(t, Config{History: 3})
+	var ids []string
+	for i := 0; i < 4; i++ {
+		ids = append(ids, f.publishTask(t, event.TaskCreated, workspace.ID{}).Event.ID().String())
+	}
+	ws := f.publishTask(t, event.TaskCreated, f.ws)
+
+	tests := []struct {
+		name        string
+		uid         user.ID
+		lastEventID string
+		wantResumed bool
+		wantReplay  int
+	}{
+		{name: "new subscriber should only receive new events", uid: f.owner, wantResumed: true},
+		{name: "resuming subscriber should receive the events it missed", uid: f.owner, lastEventID: ids[2], wantResumed: true, wantReplay: 2},
+		{name: "resuming subscriber should only receive missed events it can see", uid: f.member, lastEventID: ids[2], wantResumed: true, wantReplay: 1},
+		{name: "resuming from the latest event should receive nothing", uid: f.owner, lastEventID: ws.Event.ID().String(), wantResumed: true},
+		{name: "resuming from an event that's no longer kept should resync", uid: f.owner, lastEventID: ids[0], wantResumed: false},
+		{name: "resuming from an unknown event should resync", uid: f.owner, lastEventID: event.NewID().String(), wantResumed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := f.hub.Subscribe(tt.uid, tt.lastEventID)
+			defer sub.Close()
+			if sub.Resumed() != tt.wantResumed {
+				t.Errorf("Resumed() = %v, want %v", sub.Resumed(), tt.wantResumed)
+			}
+			if len(sub.Replay()) != tt.wantReplay {
+				t.Errorf("Replay() returned %d events, want %d", len(sub.Replay()), tt.wantReplay)
+			}
+		})
+	}
+}
+
+func TestSubscription_Close(t *testing.T) {
+	f := newFixture(t, Config{})
+	sub := f.hub.Subscribe(f.owner, "")
+	sub.Close()
+	sub.Close()
+	f.publishTask(t, event.TaskCreated, workspace.ID{})
+
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("closed subscription should not receive events")
+	}
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Exporter is where finished spans are sent
+type Exporter string
+
+// Span exporters, spans aren't recorded at all with ExporterNone
+const (
+	ExporterNone   Exporter = "none"
+	ExporterStdout Exporter = "stdout"
+	ExporterOTLP   Exporter = "otlp"
+)
+
+// ParseExporter parses an exporter name, an empty name is parsed as ExporterNone
+func ParseExporter(name string) (Exporter, error) {
+	switch e := Exporter(strings.ToLower(strings.TrimSpace(name))); e {
+	case "", ExporterNone:
+		return ExporterNone, nil
+	case ExporterStdout, ExporterOTLP:
+		return e, nil
+	}
+	return ExporterNone, fmt.Errorf("unknown trace exporter '%v'", name)
+}
+
+// Config contains configuration options for tracing
+type Config struct {
+	// Exporter is where finished spans are sent
+	// the OTLP exporter's endpoint and headers are configured with the standard OTEL_EXPORTER_OTLP_* environment variables
+	Exporter Exporter
+	// ServiceName identifies the process in exported spans, it can be overridden with OTEL_SERVICE_NAME
+	ServiceName string
+	// Writer is where the stdout exporter writes spans, defaults to os.Stdout
+	Writer io.Writer
+}
+
+// Start installs a global tracer provider exporting spans as configured, and the W3C trace context propagator
+// the returned shutdown function flushes any spans that haven't been exported yet
+func Start(c Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exporter sdktrace.SpanExporter
+	switch c.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w := c.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(context.Background())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter '%v'", c.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating %v trace exporter: %v", c.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(c.ServiceName)), resource.Environment())
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %v", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestParseExporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Exporter
+		wantErr bool
+	}{
+		{name: "", want: ExporterNone},
+		{name: "none", want: ExporterNone},
+		{name: "stdout", want: ExporterStdout},
+		{name: " OTLP ", want: ExporterOTLP},
+		{name: "jaeger", want: ExporterNone, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExporter(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseExporter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseExporter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	prevProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prevProvider)
+
+	buf := &bytes.Buffer{}
+	shutdown, err := Start(Config{Exporter: ExporterStdout, ServiceName: "test-service", Writer: buf})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent-span")
+	_, child := otel.Tracer("test").Start(ctx, "child-span")
+	child.End()
+	parent.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"parent-span", "child-span", "test-service", parent.SpanContext().TraceID().String()} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exported spans = %v, want to contain %v", out, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/infra/webhook")
+
+// Logger interface needed for structured log messages, with key/value pairs after the message
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// DefaultTimeout is the default amount of time to wait for a webhook to respond
+const DefaultTimeout = 10 * time.Second
+
+// idleWait is the amount of time the dispatcher waits for new deliveries to be queued, if none are pending
+const idleWait = time.Hour
+
+// Config contains webhook delivery settings
+// AllowPrivate allows deliveries to private, loopback and link-local addresses, which are refused by default
+type Config struct {
+	Timeout      time.Duration
+	Retry        webhook.RetryPolicy
+	AllowPrivate bool
+}
+
+// Dispatcher queues task and schedule events for delivery to subscribed webhooks, and delivers them in the background
+// it subscribes to published domain events, looking up the task or schedule that emitted each one for its payload
+type Dispatcher struct {
+	l             Logger
+	repo          usecase.WebhookRepo
+	workspaceRepo usecase.WorkspaceRepo
+	taskRepo      usecase.TaskRepo
+	scheduleRepo  usecase.ScheduleRepo
+	sender        usecase.WebhookSender
+	retry         webhook.RetryPolicy
+	wake          chan bool
+}
+
+// NewDispatcher instantiates a new Dispatcher, zero config values are replaced with defaults
+func NewDispatcher(l Logger, repo usecase.WebhookRepo, workspaceRepo usecase.WorkspaceRepo, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo, c Config) *Dispatcher {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Retry.MaxAttempts <= 0 {
+		c.Retry.MaxAttempts = webhook.DefaultRetryPolicy.MaxAttempts
+	}
+	if c.Retry.Delay <= 0 {
+		c.Retry.Delay = webhook.DefaultRetryPolicy.Delay
+	}
+	if c.Retry.MaxDelay <= 0 {
+		c.Retry.MaxDelay = webhook.DefaultRetryPolicy.MaxDelay
+	}
+	return &Dispatcher{
+		l:             l,
+		repo:          repo,
+		workspaceRepo: workspaceRepo,
+		taskRepo:      taskRepo,
+		scheduleRepo:  scheduleRepo,
+		sender:        NewHTTPSender(c.Timeout, c.AllowPrivate),
+		retry:         c.Retry,
+		wake:          make(chan bool, 1),
+	}
+}
+
+type outEvent struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Time     time.Time    `json:"time"`
+	Task     *outTask     `json:"task,omitempty"`
+	Schedule *outSchedule `json:"schedule,omitempty"`
+}
+
+type outTask struct {
+	ID            usecase.TaskID `json:"id"`
+	WorkspaceID   string         `json:"workspaceId,omitempty"`
+	Assignee      string         `json:"assignee,omitempty"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	Priority      string         `json:"priority"`
+	Tags          []string       `json:"tags"`
+	CreatedBy     string         `json:"createdBy"`
+	CreatedTime   *time.Time     `json:"createdTime,omitempty"`
+	DueTime       *time.Time     `json:"dueTime,omitempty"`
+	CompletedTime *time.Time     `json:"completedTime,omitempty"`
+	ClearedTime   *time.Time     `json:"clearedTime,omitempty"`
+}
+
+type outSchedule struct {
+	ID          usecase.ScheduleID `json:"id"`
+	WorkspaceID string             `json:"workspaceId,omitempty"`
+	Frequency   string             `json:"frequency"`
+	Interval    int                `json:"interval"`
+	Offset      int                `json:"offset"`
+	Paused      bool               `json:"paused"`
+	Tasks       []string           `json:"tasks"`
+	CreatedBy   string             `json:"createdBy"`
+	RemovedTime *time.Time         `json:"removedTime,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Name identifies the subscriber in event delivery records
+func (d *Dispatcher) Name() string {
+	return "webhook"
+}
+
+// HandleEvent queues a published domain event for delivery to subscribed webhooks, events webhooks can't subscribe to are ignored
+// the event's ID is sent as the payload ID, so receivers can discard an event delivered again after a retried publish
+func (d *Dispatcher) HandleEvent(ctx context.Context, ed usecase.EventData) error {
+	e, ok := webhook.ParseEventType(string(ed.Event.Type()))
+	if !ok {
+		return nil
+	}
+	o := &outEvent{ID: ed.Event.ID().String(), Type: string(e), Time: ed.Event.OccurredTime()}
+	if ed.ScheduleID != 0 {
+		s, ucerr := d.scheduleRepo.Get(ctx, ed.ScheduleID)
+		if ucerr != nil {
+			return ucerr.Prefix("error retrieving schedule id %v", ed.ScheduleID)
+		}
+		o.Schedule = formatSchedule(ed.ScheduleID, s)
+		return d.queue(ctx, o, s.CreatedBy(), s.Workspace())
+	}
+	t, ucerr := d.taskRepo.Get(ctx, ed.TaskID)
+	if ucerr != nil {
+		return ucerr.Prefix("error retrieving task id %v", ed.TaskID)
+	}
+	o.Task = formatTask(ed.TaskID, t)
+	return d.queue(ctx, o, t.CreatedBy(), t.Workspace())
+}
+
+func formatTask(id usecase.TaskID, t *task.Task) *outTask {
+	tags := []string{}
+	for _, tag := range t.Tags() {
+		tags = append(tags, string(tag))
+	}
+	o := &outTask{
+		ID:            id,
+		Name:          t.Name(),
+		Description:   t.Description(),
+		Priority:      t.Priority().String(),
+		Tags:          tags,
+		CreatedBy:     t.CreatedBy().String(),
+		CreatedTime:   optionalTime(t.CreatedTime()),
+		DueTime:       optionalTime(t.DueTime()),
+		CompletedTime: optionalTime(t.CompletedTime()),
+		ClearedTime:   optionalTime(t.ClearedTime()),
+	}
+	if !t.Workspace().IsEmpty() {
+		o.WorkspaceID = t.Workspace().String()
+	}
+	if !t.Assignee().IsEmpty() {
+		o.Assignee = t.Assignee().String()
+	}
+	return o
+}
+
+func formatSchedule(id usecase.ScheduleID, s *schedule.Schedule) *outSchedule {
+	f := s.Frequency()
+	tasks := []string{}
+	for _, rt := range s.Tasks() {
+		tasks = append(tasks, rt.Name())
+	}
+	o := &outSchedule{
+		ID:          id,
+		Frequency:   f.TimePeriod().String(),
+		Interval:    f.Interval(),
+		Offset:      f.Offset(),
+		Paused:      s.Paused(),
+		Tasks:       tasks,
+		CreatedBy:   s.CreatedBy().String(),
+		RemovedTime: optionalTime(s.RemovedTime()),
+	}
+	if !s.Workspace().IsEmpty() {
+		o.WorkspaceID = s.Workspace().String()
+	}
+	return o
+}
+
+func (d *Dispatcher) queue(ctx context.Context, o *outEvent, owner user.ID, ws workspace.ID) error {
+	payload, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("error formatting webhook event: %v", err)
+	}
+	count, ucerr := usecase.QueueWebhookDeliveries(ctx, d.repo, d.workspaceRepo, webhook.EventType(o.Type), payload, owner, ws)
+	if ucerr != nil {
+		return ucerr.Prefix("error queueing webhook deliveries")
+	}
+	if count == 0 {
+		return nil
+	}
+	d.l.Debug("queued webhook deliveries", "event", o.Type, "event_id", o.ID, "deliveries", count)
+	select {
+	case d.wake <- true:
+	default:
+	}
+	return nil
+}
+
+// Run starts delivering queued webhook events in the background, until closed
+func (d *Dispatcher) Run() (close chan<- bool, closed <-chan bool) {
+	d.l.Info("webhook dispatcher starting")
+
+	closeSignal := make(chan bool)
+	onClosed := make(chan bool)
+
+	go func() {
+		defer func() {
+			select {
+			case onClosed <- true:
+			default:
+			}
+		}()
+		for {
+			wait := idleWait
+			next, err := d.deliver()
+			if err != nil {
+				d.l.Error("error delivering webhooks", "error", err)
+			} else if !next.IsZero() {
+				wait = clock.Until(next)
+				d.l.Debug("next webhook delivery scheduled", "next", next)
+			}
+			if wait <= 0 {
+				wait = 1
+			}
+
+			select {
+			case <-closeSignal:
+				d.l.Info("webhook dispatcher exiting")
+				return
+			case <-d.wake:
+			case <-clock.After(wait):
+			}
+		}
+	}()
+
+	return closeSignal, onClosed
+}
+
+// deliver attempts all due deliveries in a new trace, so each run's requests and queries are grouped together
+func (d *Dispatcher) deliver() (time.Time, error) {
+	ctx, span := tracer.Start(context.Background(), "webhook.deliver")
+	defer span.End()
+
+	next, ucerr := usecase.DeliverWebhooks(ctx, d.repo, d.sender, d.retry)
+	if ucerr != nil {
+		span.RecordError(ucerr)
+		span.SetStatus(codes.Error, ucerr.Error())
+		return time.Time{}, ucerr
+	}
+	return next, nil
+}
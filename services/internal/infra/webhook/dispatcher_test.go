@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", msg, kv)
+}
+
+func (l *loggerStub) log(level string, msg string, kv []interface{}) {
+	if testing.Verbose() {
+		fmt.Printf("    %v: %v %v\n", level, msg, kv)
+	}
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newStub starts a local HTTP server that records every request it receives, responding with the given status codes in turn
+func newStub(statuses ...int) (*httptest.Server, <-chan received) {
+	reqs := make(chan received, 10)
+	i := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		reqs <- received{header: r.Header, body: body}
+		w.WriteHeader(statuses[i%len(statuses)])
+		i++
+	}))
+	return s, reqs
+}
+
+func waitForDelivery(t *testing.T, r usecase.WebhookRepo, w *webhook.Webhook, attempts int) *webhook.Delivery {
+	timeout := time.After(2 * time.Second)
+	for {
+		ds, _ := r.GetDeliveries(context.Background(), w.ID(), 1)
+		if len(ds) == 1 && ds[0].Attempts() >= attempts {
+			return ds[0]
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("delivery was not attempted %v times before timeout", attempts)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		retry        webhook.RetryPolicy
+		wantRequests int
+		wantStatus   webhook.DeliveryStatus
+		wantCode     int
+	}{
+		{
+			name:         "should deliver signed event payload",
+			statuses:     []int{http.StatusNoContent},
+			retry:        webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond},
+			wantRequests: 1,
+			wantStatus:   webhook.DeliverySucceeded,
+			wantCode:     http.StatusNoContent,
+		},
+		{
+			name:         "should retry failed deliveries with backoff",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			retry:        webhook.RetryPolicy{MaxAttempts: 3, Delay: 20 * time.Millisecond},
+			wantRequests: 3,
+			wantStatus:   webhook.DeliverySucceeded,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "should stop retrying after max attempts",
+			statuses:     []int{http.StatusServiceUnavailable},
+			retry:        webhook.RetryPolicy{MaxAttempts: 2, Delay: time.Millisecond},
+			wantRequests: 2,
+			wantStatus:   webhook.DeliveryFailed,
+			wantCode:     http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, reqs := newStub(tt.statuses...)
+			defer stub.Close()
+			r := transient.NewWebhookRepo()
+			uid := user.NewID()
+			w, _ := usecase.AddWebhook(ctx, r, stub.URL, []webhook.EventType{webhook.EventTaskCreated}, "s3cret", uid)
+
+			taskRepo := transient.NewTaskRepo()
+			d := NewDispatcher(&loggerStub{}, r, transient.NewWorkspaceRepo(), taskRepo, transient.NewScheduleRepo(), Config{Timeout: time.Second, Retry: tt.retry, AllowPrivate: true})
+			closeDispatcher, closed := d.Run()
+			defer func() {
+				closeDispatcher <- true
+				<-closed
+			}()
+
+			tsk := task.New("deploy", "ship it", uid)
+			created := tsk.Events()[0]
+			id, _ := taskRepo.Add(ctx, tsk)
+			if err := d.HandleEvent(ctx, usecase.EventData{Event: created, TaskID: id}); err != nil {
+				t.Fatalf("HandleEvent() error = %v", err)
+			}
+			tsk.CompleteNow()
+			if err := d.HandleEvent(ctx, usecase.EventData{Event: tsk.Events()[0], TaskID: id}); err != nil {
+				t.Fatalf("HandleEvent() error = %v", err)
+			}
+
+			var req received
+			for i := 0; i < tt.wantRequests; i++ {
+				select {
+				case req = <-reqs:
+				case <-time.After(2 * time.Second):
+					t.Fatalf("stub received %v requests, want %v", i, tt.wantRequests)
+				}
+			}
+			delivery := waitForDelivery(t, r, w, tt.wantRequests)
+
+			if got, want := req.header.Get(HeaderSignature), w.Sign(req.body); got != want {
+				t.Errorf("%v header = %v, want %v", HeaderSignature, got, want)
+			}
+			if got := req.header.Get(HeaderEvent); got != string(webhook.EventTaskCreated) {
+				t.Errorf("%v header = %v, want %v", HeaderEvent, got, webhook.EventTaskCreated)
+			}
+			if got := req.header.Get(HeaderDelivery); got != delivery.ID().String() {
+				t.Errorf("%v header = %v, want %v", HeaderDelivery, got, delivery.ID())
+			}
+			var body struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+				Task struct {
+					ID   usecase.TaskID `json:"id"`
+					Name string         `json:"name"`
+				} `json:"task"`
+			}
+			if err := json.Unmarshal(req.body, &body); err != nil || body.ID != created.ID().String() || body.Type != string(webhook.EventTaskCreated) || body.Task.ID != id || body.Task.Name != "deploy" {
+				t.Errorf("payload = %s, want task.created event %v for task %v", req.body, created.ID(), id)
+			}
+			if delivery.Status() != tt.wantStatus || delivery.StatusCode() != tt.wantCode || delivery.Attempts() != tt.wantRequests {
+				t.Errorf("delivery = %v %v after %v attempts, want %v %v after %v attempts", delivery.Status(), delivery.StatusCode(), delivery.Attempts(), tt.wantStatus, tt.wantCode, tt.wantRequests)
+			}
+			select {
+			case extra := <-reqs:
+				t.Errorf("stub received unexpected request %s", extra.body)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestHTTPSender_Send(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("redirect target received a request, want redirects not followed")
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		wantCode     int
+		wantErr      bool
+	}{
+		{name: "should refuse private addresses", wantErr: true},
+		{name: "should not follow redirects", allowPrivate: true, wantCode: http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := webhook.New(redirect.URL, []webhook.EventType{webhook.EventTaskCreated}, "s3cret", user.NewID())
+			d := webhook.NewDelivery(w.ID(), webhook.EventTaskCreated, []byte(`{}`))
+			code, err := NewHTTPSender(time.Second, tt.allowPrivate).Send(context.Background(), w, d)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HTTPSender.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if code != tt.wantCode {
+				t.Errorf("HTTPSender.Send() status code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/netguard"
+)
+
+// Webhook request headers
+const (
+	HeaderEvent     = "X-Scheduled-Tasks-Event"
+	HeaderDelivery  = "X-Scheduled-Tasks-Delivery"
+	HeaderSignature = "X-Scheduled-Tasks-Signature"
+)
+
+// maxResponseBytes is the maximum amount of a webhook response body read, so the connection can be reused
+const maxResponseBytes = 64 * 1024
+
+// HTTPSender POSTs delivery payloads to webhook URLs
+// requests to addresses that aren't publicly routable are refused unless private addresses are allowed, and redirects aren't followed
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender instantiates a new HTTPSender, requests that take longer than the timeout fail
+func NewHTTPSender(timeout time.Duration, allowPrivate bool) *HTTPSender {
+	client := netguard.NewClient(timeout, allowPrivate)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &HTTPSender{client: client}
+}
+
+// Send POSTs a delivery's payload to its webhook, signed with the webhook's secret
+func (s *HTTPSender) Send(ctx context.Context, w *webhook.Webhook, d *webhook.Delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL(), bytes.NewReader(d.Payload()))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "scheduled-tasks-webhook")
+	req.Header.Set(HeaderEvent, string(d.Event()))
+	req.Header.Set(HeaderDelivery, d.ID().String())
+	req.Header.Set(HeaderSignature, w.Sign(d.Payload()))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxResponseBytes))
+	return res.StatusCode, nil
+}
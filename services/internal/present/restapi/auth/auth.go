@@ -7,6 +7,8 @@ import (
 // Logger interface needed for log messages
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
 }
 
 // Formatter defines the formatter interface for output responses
@@ -33,6 +35,16 @@ func (a *Auth) SetFormatter(f Formatter) {
 	a.f = f
 }
 
+// writeUnauthorized writes an unauthorized response, formatted if a formatter has been set
+func (a *Auth) writeUnauthorized(w http.ResponseWriter) {
+	if a.f != nil {
+		a.f.WriteResponse(w, a.f.Error("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte("Unauthorized"))
+}
+
 // Authenticate stub authentication method
 func (a *Auth) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
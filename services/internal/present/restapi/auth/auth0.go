@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/auth0-community/go-auth0"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
 	"gopkg.in/square/go-jose.v2"
 )
 
@@ -27,14 +28,16 @@ func NewAuth0(l Logger, c Auth0Config) *Auth0 {
 }
 
 type claims struct {
-	Issuer  string `json:"iss"`
-	Subject string `json:"sub"`
-	Scope   string `json:"scope"`
+	Issuer      string   `json:"iss"`
+	Subject     string   `json:"sub"`
+	Scope       string   `json:"scope"`
+	Permissions []string `json:"permissions"`
 }
 
 // Authenticate authenticates a request and calls the next handler
 func (a *Auth0) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, a.l)
 		secretProvider := auth0.NewKeyProvider(a.c.Secret)
 		configuration := auth0.NewConfiguration(secretProvider, a.c.Audience, "https://"+a.c.Domain+"/", jose.HS256)
 		validator := auth0.NewValidator(configuration, nil)
@@ -42,31 +45,27 @@ func (a *Auth0) Authenticate(next http.Handler) http.Handler {
 		token, err := validator.ValidateRequest(r)
 
 		if err != nil {
-			a.l.Printf("Error parsing token: %v", err)
-			a.l.Printf("Token is not valid: %v", token)
-			if a.f != nil {
-				a.f.WriteResponse(w, a.f.Error("Unauthorized"), http.StatusUnauthorized)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte("Unauthorized"))
-			}
+			l.Warnf("Error parsing token: %v", err)
+			l.Warnf("Token is not valid: %v", token)
+			a.writeUnauthorized(w)
 			return
 		}
 		c := claims{}
 		validator.Claims(r, token, &c)
-		ps := getDefaultPerms(&c)
+		ps := getTokenPerms(&c)
 
-		next.ServeHTTP(ResponseContext{w, Context{c.Issuer, c.Subject, ps}}, r)
+		next.ServeHTTP(ResponseContext{w, Context{Issuer: c.Issuer, Subject: c.Subject, Permissions: ps}}, r)
 	})
 }
 
-func getDefaultPerms(c *claims) []Permission {
-	scopes := strings.Split(c.Scope, " ")
+// getTokenPerms parses the permissions granted by an access token's scope and permissions claims
+func getTokenPerms(c *claims) []Permission {
+	scopes := strings.Fields(c.Scope)
 	for _, scope := range scopes {
 		switch scope {
 		case "type:anon":
 			return []Permission{PermNone}
 		}
 	}
-	return GetDefaultUserPerms()
+	return ParsePermissions(append(scopes, c.Permissions...))
 }
@@ -1,6 +1,10 @@
 package auth
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
 
 // ResponseContext wraps http.ResponseWriter in a context that provides authorization details to other handlers
 type ResponseContext struct {
@@ -8,11 +12,20 @@ type ResponseContext struct {
 	Auth Context
 }
 
+// Flush sends any buffered data to the client, if the wrapped http.ResponseWriter supports it
+func (c ResponseContext) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // Context contains relevant auth data from request
 type Context struct {
 	Issuer      string
 	Subject     string
 	Permissions []Permission
+	// UserID identifies the user directly for credentials issued by this application, instead of by issuer and subject
+	UserID user.ID
 }
 
 // HasPerm returns true if the request token has the specified permission
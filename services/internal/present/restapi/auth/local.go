@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Local authenticates requests with session tokens it issues to local users after they log in with a username and password
+type Local struct {
+	Auth
+	c         LocalConfig
+	credRepo  usecase.CredentialRepo
+	fallback  Authenticator
+	signer    jose.Signer
+	signerErr error
+}
+
+// LocalConfig contains configuration options for the Local handler
+type LocalConfig struct {
+	// Secret is the key session tokens are signed with
+	Secret []byte
+	// SessionDuration is how long session tokens are valid for, defaults to 24 hours
+	SessionDuration time.Duration
+}
+
+// MinSecretLength is the minimum length of the secret session tokens are signed with, in bytes
+const MinSecretLength = 32
+
+type sessionClaims struct {
+	jwt.Claims
+	Version int `json:"ver"`
+}
+
+// NewLocal returns a new Local struct, requests without a local session token are authenticated by fallback if it isn't nil
+// Without a fallback, requests without an Authorization header are passed on anonymously
+// The secret is the HS256 signing key, so it must be at least MinSecretLength bytes long
+func NewLocal(l Logger, credRepo usecase.CredentialRepo, c LocalConfig, fallback Authenticator) (*Local, error) {
+	if len(c.Secret) < MinSecretLength {
+		return nil, fmt.Errorf("local auth secret must be at least %v bytes long, got %v", MinSecretLength, len(c.Secret))
+	}
+	if c.SessionDuration == 0 {
+		c.SessionDuration = 24 * time.Hour
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: c.Secret}, (&jose.SignerOptions{}).WithType("JWT"))
+	return &Local{Auth: Auth{l: l}, c: c, credRepo: credRepo, fallback: fallback, signer: signer, signerErr: err}, nil
+}
+
+// SetFormatter sets the formatter on this and the fallback authenticator
+func (a *Local) SetFormatter(f Formatter) {
+	a.Auth.SetFormatter(f)
+	if a.fallback != nil {
+		a.fallback.SetFormatter(f)
+	}
+}
+
+// NewSession issues a signed session token for a local user, returning it with its expiry time
+// Sessions are tied to the credential's version, so changing the password ends all existing sessions
+func (a *Local) NewSession(c *credential.Credential) (string, time.Time, error) {
+	if a.signerErr != nil {
+		return "", time.Time{}, fmt.Errorf("error creating session signer: %v", a.signerErr)
+	}
+	now := clock.Now()
+	expires := now.Add(a.c.SessionDuration)
+	claims := sessionClaims{
+		Claims: jwt.Claims{
+			Issuer:   credential.ProviderID,
+			Subject:  c.Username(),
+			IssuedAt: jwt.NewNumericDate(now),
+			Expiry:   jwt.NewNumericDate(expires),
+		},
+		Version: c.Version(),
+	}
+	tok, err := jwt.Signed(a.signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing session token: %v", err)
+	}
+	return tok, expires, nil
+}
+
+// Authenticate authenticates a request and calls the next handler
+func (a *Local) Authenticate(next http.Handler) http.Handler {
+	var fallback http.Handler
+	if a.fallback != nil {
+		fallback = a.fallback.Authenticate(next)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, a.l)
+		header := r.Header.Get("Authorization")
+		tok, isSession := a.parseSession(header)
+		if !isSession {
+			if fallback != nil {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			if header == "" {
+				next.ServeHTTP(ResponseContext{w, Context{}}, r)
+				return
+			}
+			l.Warnf("Token is not a local session token")
+			a.writeUnauthorized(w)
+			return
+		}
+
+		ac, err := a.validate(r.Context(), tok)
+		if err != nil {
+			l.Warnf("Session token is not valid: %v", err)
+			a.writeUnauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(ResponseContext{w, ac}, r)
+	})
+}
+
+// parseSession parses a bearer token, returning whether it looks like a session token issued by this authenticator
+func (a *Local) parseSession(header string) (*jwt.JSONWebToken, bool) {
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return nil, false
+	}
+	tok, err := jwt.ParseSigned(strings.TrimSpace(header[7:]))
+	if err != nil || len(tok.Headers) != 1 || tok.Headers[0].Algorithm != string(jose.HS256) {
+		return nil, false
+	}
+	c := jwt.Claims{}
+	if err := tok.UnsafeClaimsWithoutVerification(&c); err != nil || c.Issuer != credential.ProviderID {
+		return nil, false
+	}
+	return tok, true
+}
+
+// validate verifies a session token and maps it to an auth context
+// a session grants every permission, which are then restricted to those granted to the user's role
+func (a *Local) validate(ctx context.Context, tok *jwt.JSONWebToken) (Context, error) {
+	c := sessionClaims{}
+	if err := tok.Claims(a.c.Secret, &c); err != nil {
+		return Context{}, fmt.Errorf("error verifying token: %v", err)
+	}
+	if c.Expiry == nil {
+		return Context{}, fmt.Errorf("token has no expiry")
+	}
+	if err := c.Claims.Validate(jwt.Expected{Issuer: credential.ProviderID, Time: clock.Now()}); err != nil {
+		return Context{}, err
+	}
+	cred, err := usecase.GetCredential(ctx, a.credRepo, c.Subject)
+	if err != nil {
+		return Context{}, fmt.Errorf("error retrieving credential for %v: %v", c.Subject, err)
+	}
+	if cred.Version() != c.Version {
+		return Context{}, fmt.Errorf("session for %v has ended, its password has changed", c.Subject)
+	}
+	return Context{Issuer: credential.ProviderID, Subject: cred.Username(), Permissions: GetAllPerms()}, nil
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+)
+
+func TestLocal_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewMock(func() time.Time { return now }))
+	defer clock.Set(prevClock)
+
+	credRepo := transient.NewCredentialRepo()
+	c, _ := credential.New("jane", user.NewID(), "password1")
+	credRepo.Add(ctx, c)
+	a, err := NewLocal(&loggerStub{}, credRepo, LocalConfig{Secret: []byte("a-local-session-secret-of-32-bytes"), SessionDuration: time.Hour}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _, err := a.NewSession(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewLocal(&loggerStub{}, credRepo, LocalConfig{Secret: []byte("another-local-session-secret-32-b")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret, _, _ := other.NewSession(c)
+	unknown, _ := credential.New("john", user.NewID(), "password1")
+	unknownSession, _, _ := a.NewSession(unknown)
+
+	tests := []struct {
+		name        string
+		header      string
+		advance     time.Duration
+		wantStatus  int
+		wantSubject string
+	}{
+		{name: "request without a token should be anonymous", header: "", wantStatus: http.StatusOK},
+		{name: "session should authenticate as the local user", header: "Bearer " + session, wantStatus: http.StatusOK, wantSubject: "jane"},
+		{name: "session signed with another secret should be unauthorized", header: "Bearer " + otherSecret, wantStatus: http.StatusUnauthorized},
+		{name: "session for an unknown user should be unauthorized", header: "Bearer " + unknownSession, wantStatus: http.StatusUnauthorized},
+		{name: "other bearer tokens should be unauthorized without a fallback", header: "Bearer not-a-session", wantStatus: http.StatusUnauthorized},
+		{name: "expired session should be unauthorized", header: "Bearer " + session, advance: time.Hour + 2*time.Minute, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			var got Context
+			h := a.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = w.(ResponseContext).Auth
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Local.Authenticate() status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+			if got.Subject != tt.wantSubject {
+				t.Errorf("Local.Authenticate() subject = %v, want %v", got.Subject, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestNewLocal_secretLength(t *testing.T) {
+	credRepo := transient.NewCredentialRepo()
+	if _, err := NewLocal(&loggerStub{}, credRepo, LocalConfig{Secret: []byte("too short")}, nil); err == nil {
+		t.Errorf("NewLocal() with a short secret should have returned an error")
+	}
+	if _, err := NewLocal(&loggerStub{}, credRepo, LocalConfig{Secret: make([]byte, MinSecretLength)}, nil); err != nil {
+		t.Errorf("NewLocal() error = %v, want nil", err)
+	}
+}
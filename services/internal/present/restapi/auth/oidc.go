@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// OIDC authenticates requests with bearer tokens issued by any OpenID Connect provider
+// cached keys are read concurrently, while a single request at a time refreshes them from the provider
+type OIDC struct {
+	Auth
+	c OIDCConfig
+
+	refreshMu   sync.Mutex
+	mu          sync.RWMutex
+	meta        *oidcMetadata
+	keys        *jose.JSONWebKeySet
+	keysFetched time.Time
+	lastRefresh time.Time
+	refreshErr  error
+}
+
+// OIDCConfig contains configuration options for the OIDC handler
+type OIDCConfig struct {
+	// IssuerURL is the provider's issuer, its metadata is discovered from {IssuerURL}/.well-known/openid-configuration
+	IssuerURL string
+	// Audience lists the accepted token audiences, a token must contain at least one of them, at least one is required
+	Audience []string
+	// SubjectClaim is the token claim mapped to the auth context subject, defaults to "sub"
+	SubjectClaim string
+	// IssuerClaim is the token claim mapped to the auth context issuer, defaults to "iss"
+	IssuerClaim string
+	// ClockSkew is the leeway allowed when validating token expiry and not-before times, defaults to 1 minute
+	ClockSkew time.Duration
+	// KeyCacheDuration is how long signing keys are cached before being refreshed, defaults to 1 hour
+	KeyCacheDuration time.Duration
+	// Client is the HTTP client used to retrieve provider metadata and keys, defaults to a client that times out after DefaultOIDCTimeout
+	Client *http.Client
+}
+
+// DefaultOIDCTimeout is the default amount of time to wait for the provider's metadata and keys
+const DefaultOIDCTimeout = 10 * time.Second
+
+// minKeyRefresh limits how often unknown key IDs, or an unavailable provider, can force the signing keys to be refreshed
+const minKeyRefresh = 1 * time.Minute
+
+type oidcMetadata struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// NewOIDC returns a new OIDC struct, or an error if no audience is configured
+func NewOIDC(l Logger, c OIDCConfig) (*OIDC, error) {
+	if len(c.Audience) == 0 {
+		return nil, fmt.Errorf("at least one OIDC token audience is required")
+	}
+	if c.SubjectClaim == "" {
+		c.SubjectClaim = "sub"
+	}
+	if c.IssuerClaim == "" {
+		c.IssuerClaim = "iss"
+	}
+	if c.ClockSkew == 0 {
+		c.ClockSkew = jwt.DefaultLeeway
+	}
+	if c.KeyCacheDuration == 0 {
+		c.KeyCacheDuration = 1 * time.Hour
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: DefaultOIDCTimeout}
+	}
+	c.IssuerURL = strings.TrimSuffix(c.IssuerURL, "/")
+	return &OIDC{Auth: Auth{l: l}, c: c}, nil
+}
+
+// Authenticate authenticates a request and calls the next handler
+func (a *OIDC) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, a.l)
+		ac, err := a.validate(r)
+		if err != nil {
+			l.Warnf("Token is not valid: %v", err)
+			a.writeUnauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(ResponseContext{w, ac}, r)
+	})
+}
+
+// validate validates a request's bearer token and maps its claims to an auth context
+func (a *OIDC) validate(r *http.Request) (Context, error) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return Context{}, fmt.Errorf("no bearer token in request")
+	}
+	tok, err := jwt.ParseSigned(strings.TrimSpace(header[7:]))
+	if err != nil {
+		return Context{}, fmt.Errorf("error parsing token: %v", err)
+	}
+	if len(tok.Headers) != 1 {
+		return Context{}, fmt.Errorf("token must have exactly one signature")
+	}
+	h := tok.Headers[0]
+	switch jose.SignatureAlgorithm(h.Algorithm) {
+	case jose.RS256, jose.ES256:
+	default:
+		return Context{}, fmt.Errorf("unsupported signing algorithm '%v'", h.Algorithm)
+	}
+
+	meta, key, err := a.key(h.KeyID)
+	if err != nil {
+		return Context{}, err
+	}
+	if key.Algorithm != "" && key.Algorithm != h.Algorithm {
+		return Context{}, fmt.Errorf("key %v cannot be used with signing algorithm '%v'", h.KeyID, h.Algorithm)
+	}
+
+	std := jwt.Claims{}
+	c := claims{}
+	raw := map[string]interface{}{}
+	if err := tok.Claims(key.Key, &std, &c, &raw); err != nil {
+		return Context{}, fmt.Errorf("error verifying token: %v", err)
+	}
+	if std.Expiry == nil {
+		return Context{}, fmt.Errorf("token has no expiry")
+	}
+	if err := std.ValidateWithLeeway(jwt.Expected{Issuer: meta.Issuer, Time: clock.Now()}, a.c.ClockSkew); err != nil {
+		return Context{}, err
+	}
+	if !a.validAudience(std.Audience) {
+		return Context{}, fmt.Errorf("token audience %v not accepted", std.Audience)
+	}
+
+	issuer, ok := raw[a.c.IssuerClaim].(string)
+	if !ok || issuer == "" {
+		return Context{}, fmt.Errorf("token has no '%v' issuer claim", a.c.IssuerClaim)
+	}
+	subject, ok := raw[a.c.SubjectClaim].(string)
+	if !ok || subject == "" {
+		return Context{}, fmt.Errorf("token has no '%v' subject claim", a.c.SubjectClaim)
+	}
+	return Context{Issuer: issuer, Subject: subject, Permissions: getTokenPerms(&c)}, nil
+}
+
+func (a *OIDC) validAudience(aud jwt.Audience) bool {
+	for _, v := range a.c.Audience {
+		if aud.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the provider metadata and the signing key with the given key ID
+// keys are cached, and refreshed when the cache expires or when an unknown key ID is seen, so rotated keys are picked up
+// refreshes are made without blocking requests using cached keys, and at most once every minKeyRefresh
+func (a *OIDC) key(kid string) (*oidcMetadata, *jose.JSONWebKey, error) {
+	if meta, key, done, err := a.cachedKey(kid, clock.Now()); done {
+		return meta, key, err
+	}
+
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	// Another request may have refreshed the keys while this one waited
+	now := clock.Now()
+	if meta, key, done, err := a.cachedKey(kid, now); done {
+		return meta, key, err
+	}
+
+	meta, keys, err := a.refresh()
+	a.mu.Lock()
+	a.lastRefresh = now
+	a.refreshErr = err
+	if err == nil {
+		a.meta = meta
+		a.keys = keys
+		a.keysFetched = now
+	}
+	a.mu.Unlock()
+	if err != nil {
+		// Previously fetched keys keep being used while the provider is unavailable
+		if meta, key, _, _ := a.cachedKey(kid, now); key != nil {
+			a.l.Warnf("Using expired OIDC signing keys: %v", err)
+			return meta, key, nil
+		}
+		return nil, nil, err
+	}
+	if key, ok := findKey(keys, kid); ok {
+		return meta, key, nil
+	}
+	return nil, nil, fmt.Errorf("unknown signing key %v", kid)
+}
+
+// cachedKey looks up a signing key in the cache, done is false if the keys need to be refreshed to find it
+// if they were refreshed too recently to be refreshed again, the cached keys are used even if they've expired
+func (a *OIDC) cachedKey(kid string, now time.Time) (meta *oidcMetadata, key *jose.JSONWebKey, done bool, err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.keys != nil {
+		if key, ok := findKey(a.keys, kid); ok && (now.Sub(a.keysFetched) < a.c.KeyCacheDuration || now.Sub(a.lastRefresh) < minKeyRefresh) {
+			return a.meta, key, true, nil
+		}
+	}
+	if a.lastRefresh.IsZero() || now.Sub(a.lastRefresh) >= minKeyRefresh {
+		return nil, nil, false, nil
+	}
+	if a.keys == nil && a.refreshErr != nil {
+		return nil, nil, true, a.refreshErr
+	}
+	return nil, nil, true, fmt.Errorf("unknown signing key %v", kid)
+}
+
+// refresh retrieves the provider's signing keys, and its metadata if it hasn't been retrieved yet
+// it's only called while holding refreshMu, which guards writes to the metadata
+func (a *OIDC) refresh() (*oidcMetadata, *jose.JSONWebKeySet, error) {
+	meta := a.meta
+	if meta == nil {
+		var err error
+		if meta, err = a.discover(); err != nil {
+			return nil, nil, err
+		}
+	}
+	keys, err := a.fetchKeys(meta.JWKSURI)
+	if err != nil {
+		return nil, nil, err
+	}
+	return meta, keys, nil
+}
+
+func findKey(keys *jose.JSONWebKeySet, kid string) (*jose.JSONWebKey, bool) {
+	for _, k := range keys.Keys {
+		if k.KeyID == kid && (k.Use == "" || k.Use == "sig") {
+			return &k, true
+		}
+	}
+	return nil, false
+}
+
+// discover retrieves the provider's metadata
+func (a *OIDC) discover() (*oidcMetadata, error) {
+	meta := &oidcMetadata{}
+	if err := a.getJSON(a.c.IssuerURL+"/.well-known/openid-configuration", meta); err != nil {
+		return nil, fmt.Errorf("error retrieving OIDC provider metadata: %v", err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != a.c.IssuerURL {
+		return nil, fmt.Errorf("OIDC provider issuer '%v' does not match configured issuer '%v'", meta.Issuer, a.c.IssuerURL)
+	}
+	if meta.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC provider metadata has no jwks_uri")
+	}
+	return meta, nil
+}
+
+// fetchKeys retrieves the provider's signing keys
+func (a *OIDC) fetchKeys(uri string) (*jose.JSONWebKeySet, error) {
+	keys := &jose.JSONWebKeySet{}
+	if err := a.getJSON(uri, keys); err != nil {
+		return nil, fmt.Errorf("error retrieving OIDC provider signing keys: %v", err)
+	}
+	return keys, nil
+}
+
+func (a *OIDC) getJSON(url string, v interface{}) error {
+	res, err := a.c.Client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v from %v", res.StatusCode, url)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,441 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Printf(format string, v ...interface{}) {}
+func (l *loggerStub) Warnf(format string, v ...interface{})  {}
+func (l *loggerStub) Errorf(format string, v ...interface{}) {}
+
+// stubIssuer serves OIDC discovery metadata and a rotatable key set, which can be made slow or unavailable
+type stubIssuer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	keys        jose.JSONWebKeySet
+	keyFetches  int
+	delay       time.Duration
+	unavailable bool
+}
+
+func newStubIssuer() *stubIssuer {
+	s := &stubIssuer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": s.URL, "jwks_uri": s.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.keyFetches++
+		keys, delay, unavailable := s.keys, s.delay, s.unavailable
+		s.mu.Unlock()
+		time.Sleep(delay)
+		if unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(keys)
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *stubIssuer) fetches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keyFetches
+}
+
+func (s *stubIssuer) setUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable = unavailable
+}
+
+func (s *stubIssuer) setKeys(keys ...jose.JSONWebKey) {
+	s.keys = jose.JSONWebKeySet{}
+	for _, k := range keys {
+		s.keys.Keys = append(s.keys.Keys, k.Public())
+	}
+}
+
+func signToken(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, c interface{}) string {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(sig).Claims(c).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func authenticate(a *OIDC, token string) (Context, int) {
+	var got Context
+	h := a.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = w.(ResponseContext).Auth
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return got, rr.Code
+}
+
+func TestNewOIDC(t *testing.T) {
+	if _, err := NewOIDC(&loggerStub{}, OIDCConfig{IssuerURL: "https://issuer.example.com"}); err == nil {
+		t.Errorf("NewOIDC() without an audience error = nil, want an error")
+	}
+	if _, err := NewOIDC(&loggerStub{}, OIDCConfig{IssuerURL: "https://issuer.example.com", Audience: []string{"api"}}); err != nil {
+		t.Errorf("NewOIDC() error = %v, want nil", err)
+	}
+}
+
+func TestOIDC_Authenticate(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaJWK := jose.JSONWebKey{Key: rsaKey, KeyID: "rsa1", Algorithm: string(jose.RS256), Use: "sig"}
+	ecJWK := jose.JSONWebKey{Key: ecKey, KeyID: "ec1", Algorithm: string(jose.ES256), Use: "sig"}
+	unknownJWK := jose.JSONWebKey{Key: rsaKey, KeyID: "unknown"}
+	hmacJWK := jose.JSONWebKey{Key: []byte("0123456789abcdef0123456789abcdef"), KeyID: "rsa1"}
+
+	issuer := newStubIssuer()
+	defer issuer.Close()
+	issuer.setKeys(rsaJWK, ecJWK)
+
+	type tokenClaims struct {
+		jwt.Claims
+		Scope  string `json:"scope,omitempty"`
+		Email  string `json:"email,omitempty"`
+		Tenant string `json:"tenant,omitempty"`
+	}
+	validClaims := func() tokenClaims {
+		return tokenClaims{
+			Claims: jwt.Claims{
+				Issuer:   issuer.URL,
+				Subject:  "user1",
+				Audience: jwt.Audience{"api"},
+				Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+				IssuedAt: jwt.NewNumericDate(now),
+			},
+			Scope:  "read:task upsert:task",
+			Email:  "user1@example.com",
+			Tenant: "tenant1",
+		}
+	}
+	with := func(modify func(c *tokenClaims)) tokenClaims {
+		c := validClaims()
+		modify(&c)
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		c        OIDCConfig
+		alg      jose.SignatureAlgorithm
+		key      interface{}
+		claims   interface{}
+		want     Context
+		wantCode int
+	}{
+		{
+			name:     "valid RS256 token should be authenticated",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   validClaims(),
+			want:     Context{Issuer: issuer.URL, Subject: "user1", Permissions: []Permission{PermReadTask, PermUpsertTask}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "valid ES256 token should be authenticated",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.ES256,
+			key:      ecJWK,
+			claims:   validClaims(),
+			want:     Context{Issuer: issuer.URL, Subject: "user1", Permissions: []Permission{PermReadTask, PermUpsertTask}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "configured claims should be mapped to the subject and issuer",
+			c:        OIDCConfig{IssuerURL: issuer.URL + "/", Audience: []string{"api"}, SubjectClaim: "email", IssuerClaim: "tenant"},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   validClaims(),
+			want:     Context{Issuer: "tenant1", Subject: "user1@example.com", Permissions: []Permission{PermReadTask, PermUpsertTask}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "token expired within the clock skew should be authenticated",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}, ClockSkew: 5 * time.Minute},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   with(func(c *tokenClaims) { c.Expiry = jwt.NewNumericDate(now.Add(-2 * time.Minute)) }),
+			want:     Context{Issuer: issuer.URL, Subject: "user1", Permissions: []Permission{PermReadTask, PermUpsertTask}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "token expired beyond the clock skew should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   with(func(c *tokenClaims) { c.Expiry = jwt.NewNumericDate(now.Add(-2 * time.Minute)) }),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token without expiry should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   with(func(c *tokenClaims) { c.Expiry = nil }),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token for another audience should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"other-api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   validClaims(),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token without an audience should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   with(func(c *tokenClaims) { c.Audience = nil }),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token from another issuer should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   with(func(c *tokenClaims) { c.Issuer = "https://evil.example.com/" }),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token signed with an unknown key should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      unknownJWK,
+			claims:   validClaims(),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "HS256 token should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			alg:      jose.HS256,
+			key:      hmacJWK,
+			claims:   validClaims(),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token missing the configured subject claim should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}, SubjectClaim: "preferred_username"},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   validClaims(),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "request without a token should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "mismatched discovered issuer should be unauthorized",
+			c:        OIDCConfig{IssuerURL: issuer.URL + "/other", Audience: []string{"api"}},
+			alg:      jose.RS256,
+			key:      rsaJWK,
+			claims:   validClaims(),
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewOIDC(&loggerStub{}, tt.c)
+			if err != nil {
+				t.Fatalf("NewOIDC() error = %v", err)
+			}
+			token := ""
+			if tt.claims != nil {
+				token = signToken(t, tt.alg, tt.key, tt.claims)
+			}
+			got, code := authenticate(a, token)
+			if code != tt.wantCode {
+				t.Errorf("OIDC.Authenticate() status = %v, want %v", code, tt.wantCode)
+				return
+			}
+			if code == http.StatusOK && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OIDC.Authenticate() context = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDC_Authenticate_keyRotation(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewMock(func() time.Time { return now }))
+	defer clock.Set(prevClock)
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldJWK := jose.JSONWebKey{Key: oldKey, KeyID: "old"}
+	newJWK := jose.JSONWebKey{Key: newKey, KeyID: "new"}
+
+	issuer := newStubIssuer()
+	defer issuer.Close()
+	issuer.setKeys(oldJWK)
+	a, err := NewOIDC(&loggerStub{}, OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}, KeyCacheDuration: time.Hour})
+	if err != nil {
+		t.Fatalf("NewOIDC() error = %v", err)
+	}
+	claims := jwt.Claims{Issuer: issuer.URL, Subject: "user1", Audience: jwt.Audience{"api"}, Expiry: jwt.NewNumericDate(now.Add(24 * time.Hour))}
+	oldToken := signToken(t, jose.ES256, oldJWK, claims)
+	newToken := signToken(t, jose.ES256, newJWK, claims)
+
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		publish     []jose.JSONWebKey
+		unavailable bool
+		token       string
+		wantCode    int
+		wantFetches int
+	}{
+		{name: "token signed with current key should be authenticated", token: oldToken, wantCode: http.StatusOK, wantFetches: 1},
+		{name: "cached keys should be reused after provider rotates keys", advance: 30 * time.Second, publish: []jose.JSONWebKey{newJWK}, token: oldToken, wantCode: http.StatusOK, wantFetches: 1},
+		{name: "unknown key should not refresh keys again too soon", advance: 10 * time.Second, token: newToken, wantCode: http.StatusUnauthorized, wantFetches: 1},
+		{name: "unknown key should refresh keys and pick up the rotated key", advance: time.Minute, token: newToken, wantCode: http.StatusOK, wantFetches: 2},
+		{name: "removed key should be unauthorized", token: oldToken, wantCode: http.StatusUnauthorized, wantFetches: 2},
+		{name: "expired key cache should be refreshed", advance: 2 * time.Hour, token: newToken, wantCode: http.StatusOK, wantFetches: 3},
+		{name: "expired keys should be used while the provider is unavailable", advance: 2 * time.Hour, unavailable: true, token: newToken, wantCode: http.StatusOK, wantFetches: 4},
+		{name: "unavailable provider should not be retried too soon", advance: 10 * time.Second, unavailable: true, token: newToken, wantCode: http.StatusOK, wantFetches: 4},
+		{name: "provider should be retried once it may be available again", advance: time.Minute, token: newToken, wantCode: http.StatusOK, wantFetches: 5},
+	}
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if tt.publish != nil {
+				issuer.setKeys(tt.publish...)
+			}
+			issuer.setUnavailable(tt.unavailable)
+			_, code := authenticate(a, tt.token)
+			if code != tt.wantCode {
+				t.Errorf("OIDC.Authenticate() status = %v, want %v", code, tt.wantCode)
+			}
+			if issuer.fetches() != tt.wantFetches {
+				t.Errorf("OIDC.Authenticate() key fetches = %v, want %v", issuer.fetches(), tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestOIDC_Authenticate_unavailableProvider(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewMock(func() time.Time { return now }))
+	defer clock.Set(prevClock)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Key: key, KeyID: "k1"}
+	issuer := newStubIssuer()
+	defer issuer.Close()
+	issuer.setKeys(jwk)
+	issuer.setUnavailable(true)
+	a, err := NewOIDC(&loggerStub{}, OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}})
+	if err != nil {
+		t.Fatalf("NewOIDC() error = %v", err)
+	}
+	token := signToken(t, jose.ES256, jwk, jwt.Claims{Issuer: issuer.URL, Subject: "user1", Audience: jwt.Audience{"api"}, Expiry: jwt.NewNumericDate(now.Add(24 * time.Hour))})
+
+	if _, code := authenticate(a, token); code != http.StatusUnauthorized || issuer.fetches() != 1 {
+		t.Errorf("OIDC.Authenticate() = %v after %v key fetches, want %v after 1", code, issuer.fetches(), http.StatusUnauthorized)
+	}
+	now = now.Add(10 * time.Second)
+	if _, code := authenticate(a, token); code != http.StatusUnauthorized || issuer.fetches() != 1 {
+		t.Errorf("OIDC.Authenticate() again = %v after %v key fetches, want %v without retrying too soon", code, issuer.fetches(), http.StatusUnauthorized)
+	}
+	now = now.Add(time.Minute)
+	issuer.setUnavailable(false)
+	if _, code := authenticate(a, token); code != http.StatusOK || issuer.fetches() != 2 {
+		t.Errorf("OIDC.Authenticate() once available = %v after %v key fetches, want %v after 2", code, issuer.fetches(), http.StatusOK)
+	}
+}
+
+func TestOIDC_Authenticate_concurrentRefresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Key: key, KeyID: "k1"}
+	issuer := newStubIssuer()
+	defer issuer.Close()
+	issuer.setKeys(jwk)
+	issuer.delay = 50 * time.Millisecond
+	a, err := NewOIDC(&loggerStub{}, OIDCConfig{IssuerURL: issuer.URL, Audience: []string{"api"}})
+	if err != nil {
+		t.Fatalf("NewOIDC() error = %v", err)
+	}
+	token := signToken(t, jose.ES256, jwk, jwt.Claims{Issuer: issuer.URL, Subject: "user1", Audience: jwt.Audience{"api"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, codes[i] = authenticate(a, token)
+		}(i)
+	}
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %v: OIDC.Authenticate() status = %v, want %v", i, code, http.StatusOK)
+		}
+	}
+	if issuer.fetches() != 1 {
+		t.Errorf("OIDC.Authenticate() key fetches = %v, want concurrent requests to share 1", issuer.fetches())
+	}
+}
@@ -1,22 +1,53 @@
 package auth
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
 
 // Permission type
 type Permission int64
 
 // Application permissions, string must match exactly what is sent in access tokens from auth provider
 const (
-	PermNone           Permission = 0
-	PermUpsertUserSelf Permission = 1 << iota
-	PermUpsertTask     Permission = 1 << iota
-	PermReadTask       Permission = 1 << iota
-	PermDeleteTask     Permission = 1 << iota
-	PermUpsertSchedule Permission = 1 << iota
-	PermReadSchedule   Permission = 1 << iota
-	PermDeleteSchedule Permission = 1 << iota
+	PermNone            Permission = 0
+	PermUpsertUserSelf  Permission = 1 << iota
+	PermUpsertTask      Permission = 1 << iota
+	PermReadTask        Permission = 1 << iota
+	PermDeleteTask      Permission = 1 << iota
+	PermUpsertSchedule  Permission = 1 << iota
+	PermReadSchedule    Permission = 1 << iota
+	PermDeleteSchedule  Permission = 1 << iota
+	PermUpsertWorkspace Permission = 1 << iota
+	PermReadWorkspace   Permission = 1 << iota
+	PermManageRoles     Permission = 1 << iota
+	PermManageTokens    Permission = 1 << iota
+	PermManageWebhooks  Permission = 1 << iota
+	PermManageCommands  Permission = 1 << iota
+	PermManageActions   Permission = 1 << iota
 )
 
+// permission scopes, as sent in the scope or permissions claim of an access token
+var scopes = map[Permission]string{
+	PermUpsertUserSelf:  "upsert:user-self",
+	PermUpsertTask:      "upsert:task",
+	PermReadTask:        "read:task",
+	PermDeleteTask:      "delete:task",
+	PermUpsertSchedule:  "upsert:schedule",
+	PermReadSchedule:    "read:schedule",
+	PermDeleteSchedule:  "delete:schedule",
+	PermUpsertWorkspace: "upsert:workspace",
+	PermReadWorkspace:   "read:workspace",
+	PermManageRoles:     "manage:roles",
+	PermManageTokens:    token.ManageScope,
+	PermManageWebhooks:  "manage:webhooks",
+	PermManageCommands:  "manage:commands",
+	PermManageActions:   "manage:actions",
+}
+
 func (p Permission) String() string {
 	switch p {
 	case PermNone:
@@ -35,6 +66,20 @@ func (p Permission) String() string {
 		return "PermReadSchedule"
 	case PermDeleteSchedule:
 		return "PermDeleteSchedule"
+	case PermUpsertWorkspace:
+		return "PermUpsertWorkspace"
+	case PermReadWorkspace:
+		return "PermReadWorkspace"
+	case PermManageRoles:
+		return "PermManageRoles"
+	case PermManageTokens:
+		return "PermManageTokens"
+	case PermManageWebhooks:
+		return "PermManageWebhooks"
+	case PermManageCommands:
+		return "PermManageCommands"
+	case PermManageActions:
+		return "PermManageActions"
 	}
 	return fmt.Sprintf("[Unknown permission label for %d]", p)
 }
@@ -56,5 +101,89 @@ func GetDefaultUserPerms() []Permission {
 		PermUpsertSchedule,
 		PermReadSchedule,
 		PermDeleteSchedule,
+		PermUpsertWorkspace,
+		PermReadWorkspace,
+		PermManageTokens,
+		PermManageWebhooks,
+	}
+}
+
+// GetAllPerms returns every application permission, for credentials that are only restricted by the user's role
+func GetAllPerms() []Permission {
+	return PermissionsFromMask(^int64(0))
+}
+
+// GetReadOnlyPerms returns the permissions granted to read-only roles
+func GetReadOnlyPerms() []Permission {
+	return []Permission{
+		PermUpsertUserSelf,
+		PermReadTask,
+		PermReadSchedule,
+		PermReadWorkspace,
+	}
+}
+
+// GetDefaultRolePerms returns the permissions granted to a role when none have been persisted
+// recurring task commands and actions run programs and make requests from the server, so only admins can manage them
+func GetDefaultRolePerms(role user.Role) []Permission {
+	switch role {
+	case user.RoleAdmin:
+		return append(GetDefaultUserPerms(), PermManageRoles, PermManageCommands, PermManageActions)
+	case user.RoleMember:
+		return GetDefaultUserPerms()
+	case user.RoleViewer:
+		return GetReadOnlyPerms()
+	}
+	return []Permission{}
+}
+
+// Scope returns the access token scope for a permission
+func (p Permission) Scope() string {
+	return scopes[p]
+}
+
+// ParsePermission parses an access token scope into a permission
+func ParsePermission(scope string) (Permission, bool) {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	for p, s := range scopes {
+		if s == scope {
+			return p, true
+		}
+	}
+	return PermNone, false
+}
+
+// ParsePermissions parses access token scopes into a list of unique permissions, ignoring unknown scopes
+func ParsePermissions(scopes []string) []Permission {
+	ps := []Permission{}
+	var mask int64
+	for _, scope := range scopes {
+		p, ok := ParsePermission(scope)
+		if !ok || mask&int64(p) != 0 {
+			continue
+		}
+		mask |= int64(p)
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// PermissionMask combines a list of permissions into a bitmask
+func PermissionMask(ps []Permission) int64 {
+	var mask int64
+	for _, p := range ps {
+		mask |= int64(p)
+	}
+	return mask
+}
+
+// PermissionsFromMask splits a permission bitmask into a list of permissions, in ascending order
+func PermissionsFromMask(mask int64) []Permission {
+	ps := []Permission{}
+	for p := PermUpsertUserSelf; p <= PermManageActions; p <<= 1 {
+		if mask&int64(p) != 0 {
+			ps = append(ps, p)
+		}
 	}
+	return ps
 }
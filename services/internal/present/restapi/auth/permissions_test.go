@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetTokenPerms(t *testing.T) {
+	tests := []struct {
+		name string
+		c    claims
+		want []Permission
+	}{
+		{
+			name: "anonymous token should only get PermNone",
+			c:    claims{Scope: "read:task type:anon"},
+			want: []Permission{PermNone},
+		},
+		{
+			name: "should parse scope claim",
+			c:    claims{Scope: "read:task  upsert:task"},
+			want: []Permission{PermReadTask, PermUpsertTask},
+		},
+		{
+			name: "should parse permissions claim and ignore duplicates",
+			c:    claims{Scope: "openid read:task", Permissions: []string{"read:task", "manage:roles"}},
+			want: []Permission{PermReadTask, PermManageRoles},
+		},
+		{
+			name: "token without scopes should get no permissions",
+			c:    claims{},
+			want: []Permission{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTokenPerms(&tt.c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getTokenPerms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsFromMask(t *testing.T) {
+	ps := []Permission{PermManageRoles, PermUpsertUserSelf, PermReadWorkspace}
+	want := []Permission{PermUpsertUserSelf, PermReadWorkspace, PermManageRoles}
+	if got := PermissionsFromMask(PermissionMask(ps)); !reflect.DeepEqual(got, want) {
+		t.Errorf("PermissionsFromMask() = %v, want %v", got, want)
+	}
+}
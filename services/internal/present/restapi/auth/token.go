@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// PersonalToken authenticates requests with personal access tokens, and passes all other requests to another authenticator
+type PersonalToken struct {
+	Auth
+	tokenRepo usecase.TokenRepo
+	fallback  Authenticator
+}
+
+// NewPersonalToken returns a new PersonalToken struct, requests without a personal access token are authenticated by fallback
+func NewPersonalToken(l Logger, tokenRepo usecase.TokenRepo, fallback Authenticator) *PersonalToken {
+	return &PersonalToken{Auth: Auth{l: l}, tokenRepo: tokenRepo, fallback: fallback}
+}
+
+// SetFormatter sets the formatter on this and the fallback authenticator
+func (a *PersonalToken) SetFormatter(f Formatter) {
+	a.Auth.SetFormatter(f)
+	a.fallback.SetFormatter(f)
+}
+
+// Authenticate authenticates a request and calls the next handler
+func (a *PersonalToken) Authenticate(next http.Handler) http.Handler {
+	fallback := a.fallback.Authenticate(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, a.l)
+		header := r.Header.Get("Authorization")
+		if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") || !token.IsSecret(strings.TrimSpace(header[7:])) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		t, err := usecase.AuthenticateToken(r.Context(), a.tokenRepo, strings.TrimSpace(header[7:]))
+		if err != nil {
+			if err.Code() != usecase.ErrRecordNotFound {
+				l.Errorf("Error finding personal access token: %v", err)
+				if a.f != nil {
+					a.f.WriteResponse(w, a.f.Error("Error finding token"), 500)
+				} else {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				return
+			}
+			l.Warnf("Personal access token is not valid: %v", err)
+			a.writeUnauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(ResponseContext{w, Context{UserID: t.UserID(), Permissions: ParsePermissions(t.Scopes())}}, r)
+	})
+}
+
+// GetTokenGrant returns the scopes the authorized user in a hydrated userContext may give a new personal access token
+// users can grant the permissions they currently have, already restricted to those of their role
+func GetTokenGrant(w http.ResponseWriter) usecase.TokenGrant {
+	g := usecase.TokenGrant{Known: []string{}, Held: []string{}}
+	for _, p := range GetAllPerms() {
+		g.Known = append(g.Known, p.Scope())
+	}
+	userContext, ok := w.(UserContext)
+	if !ok {
+		return g
+	}
+	for _, p := range userContext.Auth.Permissions {
+		if p != PermNone {
+			g.Held = append(g.Held, p.Scope())
+		}
+	}
+	g.ViaToken = !userContext.Auth.UserID.IsEmpty()
+	return g
+}
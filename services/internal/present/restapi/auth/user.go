@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 	"github.com/julienschmidt/httprouter"
 )
@@ -16,53 +18,124 @@ type UserContext struct {
 	Auth Context
 }
 
+// Flush sends any buffered data to the client, if the wrapped http.ResponseWriter supports it
+func (c UserContext) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Admins lists the external user IDs, i.e. token subjects or local usernames, granted the admin role whenever they sign in
+type Admins []string
+
+// Contains returns whether an external user ID is listed
+func (a Admins) Contains(externalID string) bool {
+	for _, id := range a {
+		if id != "" && id == externalID {
+			return true
+		}
+	}
+	return false
+}
+
 // HydrateUser middleware hydrates a UserContext with a user
 // will respond with a 401 unauthorized response if required is set to true and no user could be found
-func HydrateUser(userRepo usecase.UserRepo, l Logger, f Formatter, required bool, next http.Handler) http.Handler {
+// a found user's token permissions are restricted to those granted to their role, listed admins are granted the admin role first
+func HydrateUser(userRepo usecase.UserRepo, roleRepo usecase.RoleRepo, admins Admins, l Logger, f Formatter, required bool, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if u, a, ok := hydrateUser(w, userRepo, l, f, required); ok {
+		l := logging.Request(r, l)
+		if u, a, ok := hydrateUser(r.Context(), w, userRepo, roleRepo, admins, l, f, required); ok {
 			next.ServeHTTP(UserContext{w, u, a}, r)
 		}
 	})
 }
 
 // HRHydrateUser wraps HydrateUser in httprouter middleware
-func HRHydrateUser(userRepo usecase.UserRepo, l Logger, f Formatter, required bool, next httprouter.Handle) httprouter.Handle {
+func HRHydrateUser(userRepo usecase.UserRepo, roleRepo usecase.RoleRepo, admins Admins, l Logger, f Formatter, required bool, next httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		if u, a, ok := hydrateUser(w, userRepo, l, f, required); ok {
+		l := logging.Request(r, l)
+		if u, a, ok := hydrateUser(r.Context(), w, userRepo, roleRepo, admins, l, f, required); ok {
 			next(UserContext{w, u, a}, r, ps)
 		}
 	}
 }
 
-func hydrateUser(w http.ResponseWriter, userRepo usecase.UserRepo, l Logger, f Formatter, required bool) (*user.User, Context, bool) {
+func hydrateUser(ctx context.Context, w http.ResponseWriter, userRepo usecase.UserRepo, roleRepo usecase.RoleRepo, admins Admins, l Logger, f Formatter, required bool) (*user.User, Context, bool) {
 	a, ok := w.(ResponseContext)
 	if !ok {
-		l.Printf("Invalid auth context, while trying to hydrate user: %v", w)
+		l.Errorf("Invalid auth context, while trying to hydrate user: %v", w)
 		f.WriteResponse(w, f.Error("Error parsing user"), 500)
 		return nil, Context{}, false
 	}
 	c := Context(a.Auth)
 
-	u, err := usecase.GetExternalUser(userRepo, a.Auth.Issuer, a.Auth.Subject)
+	var u *user.User
+	var err usecase.Error
+	if !c.UserID.IsEmpty() {
+		u, err = usecase.GetUser(ctx, userRepo, c.UserID)
+	} else {
+		u, err = usecase.GetExternalUser(ctx, userRepo, a.Auth.Issuer, a.Auth.Subject)
+	}
 
 	if err != nil {
 		if err.Code() != usecase.ErrRecordNotFound {
-			l.Printf("Error finding user: %v", err)
+			l.Errorf("Error finding user: %v", err)
 			f.WriteResponse(w, f.Error("Error finding user"), 500)
 			return nil, c, false
 		}
 		if required {
-			l.Printf("Error finding authorized user from token: %v", err)
+			l.Warnf("Error finding authorized user from token: %v", err)
 			f.ErrUnauthorized(w)
 			return nil, c, false
 		}
 		u = &user.User{}
+		return u, c, true
 	}
 
+	// Only sign-ins are matched against listed admins, tokens issued by this application identify users by ID instead
+	if c.UserID.IsEmpty() && u.Role() != user.RoleAdmin && admins.Contains(c.Subject) {
+		if err := usecase.GrantAdminRole(ctx, userRepo, u); err != nil {
+			l.Errorf("Error granting listed admin user %v the admin role: %v", u.ID(), err)
+			f.WriteResponse(w, f.Error("Error finding user"), 500)
+			return nil, c, false
+		}
+		l.Printf("Granted listed admin user %v the admin role", u.ID())
+	}
+
+	rolePerms, err := GetRolePerms(ctx, roleRepo, u.Role())
+	if err != nil {
+		l.Errorf("Error finding permissions for role %v: %v", u.Role(), err)
+		f.WriteResponse(w, f.Error("Error finding user"), 500)
+		return nil, c, false
+	}
+	c.Permissions = restrictPerms(c.Permissions, rolePerms)
+
 	return u, c, true
 }
 
+// GetRolePerms returns the permissions granted to a role, falling back to the role's defaults if none have been persisted
+func GetRolePerms(ctx context.Context, roleRepo usecase.RoleRepo, role user.Role) ([]Permission, usecase.Error) {
+	mask, err := usecase.GetRolePermissions(ctx, roleRepo, role)
+	if err != nil {
+		if err.Code() == usecase.ErrRecordNotFound {
+			return GetDefaultRolePerms(role), nil
+		}
+		return nil, err
+	}
+	return PermissionsFromMask(mask), nil
+}
+
+func restrictPerms(ps []Permission, allowed []Permission) []Permission {
+	mask := PermissionMask(allowed)
+	restricted := []Permission{}
+	for _, p := range ps {
+		if p == PermNone || mask&int64(p) != 0 {
+			restricted = append(restricted, p)
+		}
+	}
+	return restricted
+}
+
 // FormatProvider formats a provider string from a request to the DB and issuer format
 func FormatProvider(provider string) string {
 	return fmt.Sprintf("https://%v/", provider)
@@ -78,9 +151,19 @@ func GetUser(w http.ResponseWriter) user.User {
 	return *userContext.User
 }
 
+// HasPerm returns whether the authorized user in a hydrated userContext has the specified permission
+func HasPerm(w http.ResponseWriter, perm Permission) bool {
+	userContext, ok := w.(UserContext)
+	if !ok {
+		return false
+	}
+	return userContext.Auth.HasPerm(perm)
+}
+
 // HRAuthorize wraps authorization logic in httprouter middleware
 func HRAuthorize(perm Permission, userRequired bool, l Logger, f Formatter, next httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		if ok := authorize(w, perm, userRequired, l, f); ok {
 			next(w, r, ps)
 		}
@@ -90,12 +173,18 @@ func HRAuthorize(perm Permission, userRequired bool, l Logger, f Formatter, next
 func authorize(w http.ResponseWriter, perm Permission, userRequired bool, l Logger, f Formatter) bool {
 	userContext, ok := w.(UserContext)
 	if !ok {
-		l.Printf("invalid authorization context from http.ResponseWriter: %v", w)
+		l.Errorf("invalid authorization context from http.ResponseWriter: %v", w)
 		f.WriteResponse(w, f.Error("Internal authorization error"), 500)
 		return false
 	}
 	if userRequired && (userContext.User == nil || *userContext.User == user.User{}) {
-		l.Printf("user required but not found from http.ResponseWriter: %v", w)
+		l.Warnf("user required but not found from http.ResponseWriter: %v", w)
+		f.ErrUnauthorized(w)
+		return false
+	}
+
+	if userContext.User != nil && userContext.User.Role().IsReadOnly() && !isReadOnlyPerm(perm) {
+		l.Warnf("user %v has a read-only role, cannot use permission: %v", userContext.User.ID(), perm)
 		f.ErrUnauthorized(w)
 		return false
 	}
@@ -104,7 +193,16 @@ func authorize(w http.ResponseWriter, perm Permission, userRequired bool, l Logg
 		return true
 	}
 
-	l.Printf("user not authorized %v, need permission: %v", userContext.User.ID(), perm)
+	l.Warnf("user not authorized %v, need permission: %v", userContext.User.ID(), perm)
 	f.ErrUnauthorized(w)
 	return false
 }
+
+func isReadOnlyPerm(perm Permission) bool {
+	for _, p := range GetReadOnlyPerms() {
+		if p == perm {
+			return true
+		}
+	}
+	return perm == PermNone
+}
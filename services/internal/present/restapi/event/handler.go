@@ -0,0 +1,114 @@
+package event
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/stream"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/event/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	Event(se usecase.StreamEvent) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Stream defines the interface for subscribing to published events
+type Stream interface {
+	Subscribe(uid user.ID, lastEventID string) *stream.Subscription
+	Heartbeat() time.Duration
+}
+
+// ResyncEvent is sent first when a client can't resume from the last event it received, it should reload everything it's showing
+const ResyncEvent = "resync"
+
+// retryMillis is how long clients wait before reconnecting after their stream is closed
+const retryMillis = 3000
+
+// Handle adds an endpoint streaming task and schedule events to the logged-in user, as server-sent events
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, s Stream) {
+
+	f := mapper.NewFormatter(rf)
+
+	r.GET(prefix+"/events", auth.HRAuthorize(auth.PermReadTask, true, l, f, streamEvents(l, f, s)))
+}
+
+func streamEvents(l Logger, f Formatter, s Stream) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			l.Errorf("response writer doesn't support streaming")
+			f.WriteResponse(w, f.Error("Error: couldn't stream events"), 500)
+			return
+		}
+		u := auth.GetUser(w)
+		schedules := auth.HasPerm(w, auth.PermReadSchedule)
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("lastEventId")
+		}
+
+		sub := s.Subscribe(u.ID(), lastEventID)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(200)
+		fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+		if !sub.Resumed() {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", ResyncEvent)
+		}
+		write := func(se usecase.StreamEvent) {
+			if !se.IsTaskEvent() && !schedules {
+				return
+			}
+			o, err := f.Event(se)
+			if err != nil {
+				l.Errorf("error encoding event %v: %v", se.Event.ID(), err)
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", se.Event.ID(), se.Event.Type(), o)
+		}
+		for _, se := range sub.Replay() {
+			write(se)
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(s.Heartbeat())
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case se, ok := <-sub.Events():
+				if !ok {
+					// dropped for falling behind, the client reconnects and resumes from its last event
+					return
+				}
+				write(se)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package json
+
+import (
+	"encoding/json"
+
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	scheduleMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/schedule/json"
+	taskMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/task/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+	tf *taskMapper.Formatter
+	sf *scheduleMapper.Formatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf, taskMapper.NewFormatter(rf), scheduleMapper.NewFormatter(rf)}
+}
+
+type outEvent struct {
+	ID           string             `json:"id"`
+	Type         string             `json:"type"`
+	OccurredTime format.Time        `json:"occurredTime"`
+	TaskID       usecase.TaskID     `json:"taskId,omitempty"`
+	ScheduleID   usecase.ScheduleID `json:"scheduleId,omitempty"`
+	Task         json.RawMessage    `json:"task,omitempty"`
+	Schedule     json.RawMessage    `json:"schedule,omitempty"`
+}
+
+// Event formats a streamed event, along with the task or schedule that emitted it, to JSON
+// tasks and schedules have the same shape they're given by their own endpoints
+func (f *Formatter) Event(se usecase.StreamEvent) ([]byte, error) {
+	o := &outEvent{
+		ID:           se.Event.ID().String(),
+		Type:         string(se.Event.Type()),
+		OccurredTime: format.Time(se.Event.OccurredTime()),
+	}
+	var err error
+	if se.IsTaskEvent() {
+		o.TaskID = se.TaskID
+		o.Task, err = f.tf.Task(&usecase.TaskData{TaskID: se.TaskID, Task: se.Task})
+	} else {
+		o.ScheduleID = se.ScheduleID
+		o.Schedule, err = f.sf.Schedule(&usecase.ScheduleData{ScheduleID: se.ScheduleID, Schedule: se.Schedule})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(o)
+}
@@ -0,0 +1,175 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	Report(r Report) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// DB interface needed to check a DB connection
+type DB interface {
+	Ping() error
+}
+
+// Schema interface needed to check whether DB migrations are current
+type Schema interface {
+	SchemaVersion() (int, error)
+}
+
+// Scheduler interface needed to check the scheduler process
+type Scheduler interface {
+	Running() bool
+	LastChecked() time.Time
+	LastError() error
+}
+
+// Config lists the dependencies to check, nil dependencies aren't checked
+type Config struct {
+	// DBs are ping-checked, by name
+	DBs map[string]DB
+	// Schema is checked for pending migrations, against LatestSchemaVersion
+	Schema              Schema
+	LatestSchemaVersion int
+	// Scheduler is checked to be running
+	Scheduler Scheduler
+}
+
+// Check names
+const (
+	CheckDB         = "db"
+	CheckMigrations = "migrations"
+	CheckScheduler  = "scheduler"
+)
+
+// Result is the result of a single health check
+type Result struct {
+	OK     bool
+	Error  string
+	Detail map[string]interface{}
+}
+
+// Report is the result of a set of health checks, it is OK only if every check is
+type Report struct {
+	OK     bool
+	Checks map[string]Result
+}
+
+// Checker checks the health of the application's dependencies
+type Checker struct {
+	l Logger
+	f Formatter
+	c Config
+}
+
+// New creates a new Checker
+func New(l Logger, f Formatter, c Config) *Checker {
+	return &Checker{l: l, f: f, c: c}
+}
+
+// Liveness reports whether the process is working, a failed liveness check means it should be restarted
+func (hc *Checker) Liveness() Report {
+	r := Report{OK: true, Checks: map[string]Result{}}
+	if hc.c.Scheduler != nil {
+		r.add(CheckScheduler, hc.checkScheduler())
+	}
+	return r
+}
+
+// Readiness reports whether the process can serve requests: its DBs are reachable, migrations are current and the scheduler is running
+func (hc *Checker) Readiness() Report {
+	r := hc.Liveness()
+	for name, db := range hc.c.DBs {
+		r.add(CheckDB+"."+name, checkDB(db))
+	}
+	if hc.c.Schema != nil {
+		r.add(CheckMigrations, hc.checkMigrations())
+	}
+	return r
+}
+
+func (r *Report) add(name string, res Result) {
+	r.Checks[name] = res
+	r.OK = r.OK && res.OK
+}
+
+func checkDB(db DB) Result {
+	if err := db.Ping(); err != nil {
+		return Result{Error: err.Error()}
+	}
+	return Result{OK: true}
+}
+
+func (hc *Checker) checkMigrations() Result {
+	version, err := hc.c.Schema.SchemaVersion()
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	res := Result{
+		OK:     version >= hc.c.LatestSchemaVersion,
+		Detail: map[string]interface{}{"schemaVersion": version, "latestSchemaVersion": hc.c.LatestSchemaVersion},
+	}
+	if !res.OK {
+		res.Error = "DB migrations are pending"
+	}
+	return res
+}
+
+func (hc *Checker) checkScheduler() Result {
+	s := hc.c.Scheduler
+	res := Result{OK: s.Running(), Detail: map[string]interface{}{"lastCheckedTime": s.LastChecked()}}
+	if err := s.LastError(); err != nil {
+		res.Detail["lastError"] = err.Error()
+	}
+	if !res.OK {
+		res.Error = "scheduler process is not running"
+	}
+	return res
+}
+
+// LivenessHandler serves the liveness report, with a 503 status if it isn't OK
+func (hc *Checker) LivenessHandler() http.Handler {
+	return hc.handler(hc.Liveness)
+}
+
+// ReadinessHandler serves the readiness report, with a 503 status if it isn't OK
+func (hc *Checker) ReadinessHandler() http.Handler {
+	return hc.handler(hc.Readiness)
+}
+
+func (hc *Checker) handler(check func() Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, hc.l)
+		report := check()
+		status := http.StatusOK
+		if !report.OK {
+			status = http.StatusServiceUnavailable
+			for name, res := range report.Checks {
+				if !res.OK {
+					l.Warnf("health check %v failed: %v", name, res.Error)
+				}
+			}
+		}
+		o, err := hc.f.Report(report)
+		if err != nil {
+			l.Errorf("error encoding health report: %v", err)
+			hc.f.WriteResponse(w, hc.f.Error("Error encoding health report"), 500)
+			return
+		}
+		hc.f.WriteResponse(w, o, status)
+	})
+}
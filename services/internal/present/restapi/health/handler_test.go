@@ -0,0 +1,120 @@
+package health_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health"
+	healthMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health/json"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Printf(format string, v ...interface{}) {}
+func (l *loggerStub) Warnf(format string, v ...interface{})  {}
+func (l *loggerStub) Errorf(format string, v ...interface{}) {}
+
+type dbStub struct {
+	err     error
+	version int
+}
+
+func (db *dbStub) Ping() error {
+	return db.err
+}
+
+func (db *dbStub) SchemaVersion() (int, error) {
+	return db.version, db.err
+}
+
+type schedulerStub struct {
+	running bool
+}
+
+func (s *schedulerStub) Running() bool {
+	return s.running
+}
+
+func (s *schedulerStub) LastChecked() time.Time {
+	return time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *schedulerStub) LastError() error {
+	return nil
+}
+
+func TestChecker(t *testing.T) {
+	ok := &dbStub{version: 10}
+	down := &dbStub{err: errors.New("connection refused")}
+
+	tests := []struct {
+		name          string
+		c             health.Config
+		wantLiveness  int
+		wantReadiness int
+		wantBody      string
+	}{
+		{
+			name:          "no dependencies should be healthy and ready",
+			c:             health.Config{},
+			wantLiveness:  200,
+			wantReadiness: 200,
+			wantBody:      `{"status":"ok","checks":{}}`,
+		},
+		{
+			name:          "healthy dependencies should be healthy and ready",
+			c:             health.Config{DBs: map[string]health.DB{"api": ok, "scheduler": ok}, Schema: ok, LatestSchemaVersion: 10, Scheduler: &schedulerStub{running: true}},
+			wantLiveness:  200,
+			wantReadiness: 200,
+			wantBody:      `{"status":"ok","checks":{"db.api":{"status":"ok"},"db.scheduler":{"status":"ok"},"migrations":{"status":"ok","detail":{"latestSchemaVersion":10,"schemaVersion":10}},"scheduler":{"status":"ok","detail":{"lastCheckedTime":"2000-01-01T12:00:00Z"}}}}`,
+		},
+		{
+			name:          "unreachable DB should be healthy but not ready",
+			c:             health.Config{DBs: map[string]health.DB{"api": ok, "scheduler": down}},
+			wantLiveness:  200,
+			wantReadiness: 503,
+			wantBody:      `{"status":"fail","checks":{"db.api":{"status":"ok"},"db.scheduler":{"status":"fail","error":"connection refused"}}}`,
+		},
+		{
+			name:          "pending migrations should be healthy but not ready",
+			c:             health.Config{Schema: ok, LatestSchemaVersion: 11},
+			wantLiveness:  200,
+			wantReadiness: 503,
+			wantBody:      `{"status":"fail","checks":{"migrations":{"status":"fail","error":"DB migrations are pending","detail":{"latestSchemaVersion":11,"schemaVersion":10}}}}`,
+		},
+		{
+			name:          "stopped scheduler should be neither healthy nor ready",
+			c:             health.Config{Scheduler: &schedulerStub{running: false}},
+			wantLiveness:  503,
+			wantReadiness: 503,
+			wantBody:      `{"status":"fail","checks":{"scheduler":{"status":"fail","error":"scheduler process is not running","detail":{"lastCheckedTime":"2000-01-01T12:00:00Z"}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &loggerStub{}
+			hc := health.New(l, healthMapper.NewFormatter(format.NewFormatter(l)), tt.c)
+
+			rr := httptest.NewRecorder()
+			hc.LivenessHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+			if rr.Code != tt.wantLiveness {
+				t.Errorf("Checker.LivenessHandler() status = %v, want %v", rr.Code, tt.wantLiveness)
+			}
+
+			rr = httptest.NewRecorder()
+			hc.ReadinessHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+			if rr.Code != tt.wantReadiness {
+				t.Errorf("Checker.ReadinessHandler() status = %v, want %v", rr.Code, tt.wantReadiness)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("Checker.ReadinessHandler() body = %v, want %v", got, tt.wantBody)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Checker.ReadinessHandler() content type = %v, want application/json", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package json
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outReport struct {
+	Status string                `json:"status"`
+	Checks map[string]*outResult `json:"checks"`
+}
+
+type outResult struct {
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Detail map[string]interface{} `json:"detail,omitempty"`
+}
+
+func status(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "fail"
+}
+
+// Report formats a health report to JSON
+func (f *Formatter) Report(r health.Report) ([]byte, error) {
+	o := &outReport{Status: status(r.OK), Checks: map[string]*outResult{}}
+	for name, res := range r.Checks {
+		o.Checks[name] = &outResult{Status: status(res.OK), Error: res.Error, Detail: detail(res.Detail)}
+	}
+	return json.Marshal(o)
+}
+
+// detail formats times in check details the same way as all other output times
+func detail(d map[string]interface{}) map[string]interface{} {
+	if d == nil {
+		return nil
+	}
+	o := make(map[string]interface{}, len(d))
+	for k, v := range d {
+		if t, ok := v.(time.Time); ok {
+			ft := format.Time(t)
+			v = &ft
+		}
+		o[k] = v
+	}
+	return o
+}
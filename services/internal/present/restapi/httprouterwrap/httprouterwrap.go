@@ -2,6 +2,7 @@ package httprouterwrap
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -39,3 +40,121 @@ func UnwrapFunc(next httprouter.Handle) http.HandlerFunc {
 		}
 	}
 }
+
+// Router wraps a julienschmidt/httprouter router, recording the pattern of each route as it's registered
+// so requests can be matched back to the route pattern they were routed by, e.g. for metrics and tracing
+// httprouter doesn't allow a static segment in the same position as a param, so routes without params are kept in a separate router
+// and take priority, e.g. POST /task/clear is served alongside POST /task/:taskID/comment
+type Router struct {
+	*httprouter.Router
+	static   *httprouter.Router
+	patterns map[string]map[string][]string
+}
+
+// NewRouter instantiates a new Router
+func NewRouter() *Router {
+	return &Router{Router: httprouter.New(), static: httprouter.New(), patterns: map[string]map[string][]string{}}
+}
+
+// Handle registers a new request handle with the given path and method, and records its pattern
+func (r *Router) Handle(method string, path string, handle httprouter.Handle) {
+	keys := paramKeys(path)
+	if keys == "" {
+		r.static.Handle(method, path, handle)
+		return
+	}
+	r.Router.Handle(method, path, handle)
+	if r.patterns[method] == nil {
+		r.patterns[method] = map[string][]string{}
+	}
+	r.patterns[method][keys] = append(r.patterns[method][keys], path)
+}
+
+// ServeHTTP serves a request with the static route matching its path, otherwise with the routes that have params
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h, _, tsr := r.static.Lookup(req.Method, req.URL.Path)
+	if h == nil && tsr {
+		// redirect to the static route with or without a trailing slash, unless a route with params matches the path as it is
+		ph, _, _ := r.Router.Lookup(req.Method, req.URL.Path)
+		tsr = ph == nil
+	}
+	if h != nil || tsr {
+		r.static.ServeHTTP(w, req)
+		return
+	}
+	r.Router.ServeHTTP(w, req)
+}
+
+// GET is a shortcut for Handle(http.MethodGet, path, handle)
+func (r *Router) GET(path string, handle httprouter.Handle) {
+	r.Handle(http.MethodGet, path, handle)
+}
+
+// POST is a shortcut for Handle(http.MethodPost, path, handle)
+func (r *Router) POST(path string, handle httprouter.Handle) {
+	r.Handle(http.MethodPost, path, handle)
+}
+
+// PUT is a shortcut for Handle(http.MethodPut, path, handle)
+func (r *Router) PUT(path string, handle httprouter.Handle) {
+	r.Handle(http.MethodPut, path, handle)
+}
+
+// PATCH is a shortcut for Handle(http.MethodPatch, path, handle)
+func (r *Router) PATCH(path string, handle httprouter.Handle) {
+	r.Handle(http.MethodPatch, path, handle)
+}
+
+// DELETE is a shortcut for Handle(http.MethodDelete, path, handle)
+func (r *Router) DELETE(path string, handle httprouter.Handle) {
+	r.Handle(http.MethodDelete, path, handle)
+}
+
+// Pattern returns the pattern of the route a request path is routed to, e.g. /task/:taskID for /task/5
+// false is returned if the path doesn't match any route
+func (r *Router) Pattern(method string, path string) (string, bool) {
+	if h, _, _ := r.static.Lookup(method, path); h != nil {
+		return path, true
+	}
+	h, ps, _ := r.Router.Lookup(method, path)
+	if h == nil {
+		return "", false
+	}
+	keys := make([]string, len(ps))
+	for i, p := range ps {
+		keys[i] = p.Key
+	}
+	// routes with the same param names only differ by their static segments, so only the one routed to reproduces the path
+	for _, pattern := range r.patterns[method][strings.Join(keys, "/")] {
+		if fillParams(pattern, ps) == path {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// paramKeys returns the names of a pattern's params in order, joined by slashes
+func paramKeys(pattern string) string {
+	keys := []string{}
+	for _, s := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			keys = append(keys, s[1:])
+		}
+	}
+	return strings.Join(keys, "/")
+}
+
+// fillParams replaces a pattern's params with their values
+func fillParams(pattern string, ps httprouter.Params) string {
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			segments[i] = ps.ByName(s[1:])
+		case strings.HasPrefix(s, "*"):
+			// catch-all values include their leading slash
+			segments[i] = strings.TrimPrefix(ps.ByName(s[1:]), "/")
+		}
+	}
+	return strings.Join(segments, "/")
+}
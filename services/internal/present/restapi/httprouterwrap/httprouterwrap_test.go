@@ -0,0 +1,48 @@
+package httprouterwrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestRouter_ServeHTTP(t *testing.T) {
+	r := NewRouter()
+	handle := func(name string) httprouter.Handle {
+		return func(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+			w.Write([]byte(name + ps.ByName("taskID")))
+		}
+	}
+	r.GET("/task/", handle("list"))
+	r.POST("/task/clear", handle("clear"))
+	r.POST("/task/:taskID/activity", handle("activity"))
+	r.DELETE("/task/:taskID", handle("delete"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "static route", method: "POST", path: "/task/clear", wantStatus: 200, wantBody: "clear"},
+		{name: "param route in the same position as a static route", method: "POST", path: "/task/5/activity", wantStatus: 200, wantBody: "activity5"},
+		{name: "param value equal to a static segment", method: "DELETE", path: "/task/clear", wantStatus: 200, wantBody: "deleteclear"},
+		{name: "trailing slash redirect to a static route", method: "GET", path: "/task", wantStatus: 301},
+		{name: "unknown route", method: "POST", path: "/task/5/unknown", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() body = %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ const OutTimeFormat = time.RFC3339Nano
 // Logger interface needed for log messages
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
 }
 
 // ResponseFormatter defines a generic formatter interface
@@ -69,7 +71,7 @@ func (f *Formatter) Error(a interface{}) []byte {
 
 	o, mErr := json.Marshal(outError)
 	if mErr != nil {
-		f.l.Printf("problem marshalling JSON error response: %v (error struct: %v)", mErr, outError)
+		f.l.Errorf("problem marshalling JSON error response: %v (error struct: %v)", mErr, outError)
 	}
 	return o
 }
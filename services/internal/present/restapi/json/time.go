@@ -21,6 +21,24 @@ func (ft *Time) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf("\"%s\"", timeStr)), nil
 }
 
+// UnmarshalJSON parses a time field, null is parsed as the zero value
+func (ft *Time) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*ft = Time(time.Time{})
+		return nil
+	}
+	var timeStr string
+	if err := json.Unmarshal(b, &timeStr); err != nil {
+		return err
+	}
+	t, err := time.Parse(time.RFC3339, timeStr)
+	if err != nil {
+		return fmt.Errorf("invalid time '%v', should be in RFC3339 format", timeStr)
+	}
+	*ft = Time(t)
+	return nil
+}
+
 // Weekday wraps time.Weekday for formatting
 type Weekday time.Weekday
 
@@ -0,0 +1,228 @@
+package local
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/local/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	UserID(id user.ID) ([]byte, error)
+	Session(token string, expires time.Time) ([]byte, error)
+	ResetToken(token string) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	Register(b io.Reader) (mapper.Register, error)
+	Login(b io.Reader) (mapper.Login, error)
+	ChangePassword(b io.Reader) (mapper.ChangePassword, error)
+	RequestReset(b io.Reader) (mapper.RequestReset, error)
+	ConfirmReset(b io.Reader) (mapper.ConfirmReset, error)
+}
+
+// SessionIssuer issues session tokens to local users that have logged in
+type SessionIssuer interface {
+	NewSession(c *credential.Credential) (string, time.Time, error)
+}
+
+// Handle adds local username and password authentication endpoints
+// registering, logging in and confirming a password reset don't require an authenticated user
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, s SessionIssuer, userRepo usecase.UserRepo, credRepo usecase.CredentialRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/auth/local"
+	r.POST(pre+"/register", register(l, f, p, userRepo, credRepo))
+	r.POST(pre+"/login", login(l, f, p, s, credRepo))
+	r.POST(pre+"/password", auth.HRAuthorize(auth.PermUpsertUserSelf, true, l, f, changePassword(l, f, p, credRepo)))
+	r.POST(pre+"/reset", auth.HRAuthorize(auth.PermManageRoles, true, l, f, requestReset(l, f, p, credRepo)))
+	r.POST(pre+"/reset/confirm", confirmReset(l, f, p, credRepo))
+}
+
+func register(l Logger, f Formatter, p Parser, userRepo usecase.UserRepo, credRepo usecase.CredentialRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		reg, err := p.Register(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing register data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse registration data: %v", err), 400)
+			return
+		}
+		u, ucerr := usecase.RegisterLocalUser(r.Context(), userRepo, credRepo, reg.Username, reg.Password, reg.DisplayName)
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: invalid registration data: %v", ucerr), 400)
+				return
+			case usecase.ErrDuplicateRecord:
+				f.WriteResponse(w, f.Error("Error: username is already taken"), 400)
+				return
+			}
+			l.Errorf("error registering local user: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not register user"), 500)
+			return
+		}
+		o, err := f.UserID(u.ID())
+		if err != nil {
+			l.Errorf("error encoding registered user ID: %v", err)
+			f.WriteResponse(w, f.Error("User registered, but there was an error formatting the response"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 201)
+	}
+}
+
+func login(l Logger, f Formatter, p Parser, s SessionIssuer, credRepo usecase.CredentialRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		li, err := p.Login(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing login data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse login data: %v", err), 400)
+			return
+		}
+		c, ucerr := usecase.LoginLocalUser(r.Context(), credRepo, li.Username, li.Password)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrForbidden {
+				l.Warnf("failed login for %v: %v", li.Username, ucerr)
+				f.ErrUnauthorized(w)
+				return
+			}
+			l.Errorf("error logging in local user: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not log in"), 500)
+			return
+		}
+		token, expires, err := s.NewSession(c)
+		if err != nil {
+			l.Errorf("error issuing session: %v", err)
+			f.WriteResponse(w, f.Error("Error: could not log in"), 500)
+			return
+		}
+		o, err := f.Session(token, expires)
+		if err != nil {
+			l.Errorf("error encoding session: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding session data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func changePassword(l Logger, f Formatter, p Parser, credRepo usecase.CredentialRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		userContext, ok := w.(auth.UserContext)
+		if !ok {
+			l.Errorf("invalid authorization context from http.ResponseWriter: %v", w)
+			f.WriteResponse(w, f.Error("Internal authorization error"), 500)
+			return
+		}
+		if userContext.Auth.Issuer != credential.ProviderID {
+			f.WriteResponse(w, f.Error("Error: only local users can change their password"), 400)
+			return
+		}
+		cp, err := p.ChangePassword(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing changePassword data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse password data: %v", err), 400)
+			return
+		}
+		if ucerr := usecase.ChangeLocalPassword(r.Context(), credRepo, userContext.Auth.Subject, cp.CurrentPassword, cp.NewPassword); ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: invalid password: %v", ucerr), 400)
+				return
+			case usecase.ErrForbidden:
+				f.WriteResponse(w, f.Error("Error: current password does not match"), 403)
+				return
+			}
+			l.Errorf("error changing password: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error changing password"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func requestReset(l Logger, f Formatter, p Parser, credRepo usecase.CredentialRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		rr, err := p.RequestReset(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing requestReset data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse reset data: %v", err), 400)
+			return
+		}
+		token, ucerr := usecase.RequestPasswordReset(r.Context(), credRepo, rr.Username)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Username %v not found", rr.Username), 404)
+				return
+			}
+			l.Errorf("error requesting password reset: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error creating reset token"), 500)
+			return
+		}
+		o, err := f.ResetToken(token)
+		if err != nil {
+			l.Errorf("error encoding reset token: %v", err)
+			f.WriteResponse(w, f.Error("Reset token created, but there was an error formatting the response"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 201)
+	}
+}
+
+func confirmReset(l Logger, f Formatter, p Parser, credRepo usecase.CredentialRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		cr, err := p.ConfirmReset(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing confirmReset data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse reset data: %v", err), 400)
+			return
+		}
+		if ucerr := usecase.ResetLocalPassword(r.Context(), credRepo, cr.Username, cr.ResetToken, cr.Password); ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: invalid password: %v", ucerr), 400)
+				return
+			case usecase.ErrForbidden:
+				l.Warnf("failed password reset for %v: %v", cr.Username, ucerr)
+				f.ErrUnauthorized(w)
+				return
+			}
+			l.Errorf("error resetting password: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error resetting password"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
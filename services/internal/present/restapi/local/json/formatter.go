@@ -0,0 +1,47 @@
+package json
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outUserID struct {
+	ID string `json:"id"`
+}
+
+type outSession struct {
+	Token       string      `json:"token"`
+	ExpiresTime format.Time `json:"expiresTime"`
+}
+
+type outResetToken struct {
+	ResetToken string `json:"resetToken"`
+}
+
+// UserID formats a registered user's ID to JSON
+func (f *Formatter) UserID(id user.ID) ([]byte, error) {
+	return json.Marshal(&outUserID{ID: id.String()})
+}
+
+// Session formats a session token and its expiry time to JSON
+func (f *Formatter) Session(token string, expires time.Time) ([]byte, error) {
+	return json.Marshal(&outSession{Token: token, ExpiresTime: format.Time(expires)})
+}
+
+// ResetToken formats a password reset token to JSON
+func (f *Formatter) ResetToken(token string) ([]byte, error) {
+	return json.Marshal(&outResetToken{ResetToken: token})
+}
@@ -0,0 +1,81 @@
+package json
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Register is the parsed data for registering a new local user
+type Register struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DisplayName string `json:"displayname"`
+}
+
+// Register parses register request JSON data
+func (p *Parser) Register(b io.Reader) (Register, error) {
+	var register Register
+	err := json.NewDecoder(b).Decode(&register)
+	return register, err
+}
+
+// Login is the parsed data for logging in a local user
+type Login struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Login parses login request JSON data
+func (p *Parser) Login(b io.Reader) (Login, error) {
+	var login Login
+	err := json.NewDecoder(b).Decode(&login)
+	return login, err
+}
+
+// ChangePassword is the parsed data for changing the logged-in local user's password
+type ChangePassword struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// ChangePassword parses changePassword request JSON data
+func (p *Parser) ChangePassword(b io.Reader) (ChangePassword, error) {
+	var changePassword ChangePassword
+	err := json.NewDecoder(b).Decode(&changePassword)
+	return changePassword, err
+}
+
+// RequestReset is the parsed data for requesting a password reset token
+type RequestReset struct {
+	Username string `json:"username"`
+}
+
+// RequestReset parses requestReset request JSON data
+func (p *Parser) RequestReset(b io.Reader) (RequestReset, error) {
+	var requestReset RequestReset
+	err := json.NewDecoder(b).Decode(&requestReset)
+	return requestReset, err
+}
+
+// ConfirmReset is the parsed data for resetting a password with a reset token
+type ConfirmReset struct {
+	Username   string `json:"username"`
+	ResetToken string `json:"resetToken"`
+	Password   string `json:"password"`
+}
+
+// ConfirmReset parses confirmReset request JSON data
+func (p *Parser) ConfirmReset(b io.Reader) (ConfirmReset, error) {
+	var confirmReset ConfirmReset
+	err := json.NewDecoder(b).Decode(&confirmReset)
+	return confirmReset, err
+}
@@ -0,0 +1,44 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+)
+
+// unmatchedRoute is the route label for requests that don't match any route, so unknown paths don't create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics interface needed to record and serve API metrics
+type Metrics interface {
+	Handler() http.Handler
+	ObserveRequest(method string, route string, status int, d time.Duration)
+}
+
+// observeRequests records the status and latency of every request, by the route pattern it matched
+func observeRequests(m Metrics, router *httprouterwrap.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := clock.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.ObserveRequest(r.Method, routePattern(router, r.Method, r.URL.Path), status, clock.Now().Sub(start))
+	})
+}
+
+// routePattern returns the pattern of the route a request path matches, e.g. /api/v1/task/:taskID for /api/v1/task/5
+func routePattern(router *httprouterwrap.Router, method string, path string) string {
+	if isUnauthenticatedPath(path) {
+		return path
+	}
+	if pattern, ok := router.Pattern(method, path); ok {
+		return pattern
+	}
+	return unmatchedRoute
+}
@@ -0,0 +1,96 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+)
+
+type metricsStub struct {
+	method string
+	route  string
+	status int
+}
+
+func (m *metricsStub) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("metrics"))
+	})
+}
+
+func (m *metricsStub) ObserveRequest(method string, route string, status int, d time.Duration) {
+	m.method, m.route, m.status = method, route, status
+}
+
+func newTestRouter() *httprouterwrap.Router {
+	h := func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	r := httprouterwrap.NewRouter()
+	r.GET("/api/v1/task/", h)
+	r.GET("/api/v1/task/:taskID", h)
+	r.PUT("/api/v1/task/:taskID/tag/:tag", h)
+	r.POST("/api/v1/task/clear", h)
+	r.POST("/api/v1/task/:taskID/activity", h)
+	return r
+}
+
+func TestRoutePattern(t *testing.T) {
+	router := newTestRouter()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "static route", method: "GET", path: "/api/v1/task/", want: "/api/v1/task/"},
+		{name: "route with param", method: "GET", path: "/api/v1/task/5", want: "/api/v1/task/:taskID"},
+		{name: "route with multiple params", method: "PUT", path: "/api/v1/task/5/tag/urgent", want: "/api/v1/task/:taskID/tag/:tag"},
+		{name: "param values equal to static segments", method: "PUT", path: "/api/v1/task/tag/tag/tag", want: "/api/v1/task/:taskID/tag/:tag"},
+		{name: "static route in the same position as a param", method: "POST", path: "/api/v1/task/clear", want: "/api/v1/task/clear"},
+		{name: "param route alongside a static route", method: "POST", path: "/api/v1/task/clear/activity", want: "/api/v1/task/:taskID/activity"},
+		{name: "unknown path", method: "GET", path: "/api/v1/unknown/5", want: unmatchedRoute},
+		{name: "unknown method", method: "DELETE", path: "/api/v1/task/5", want: unmatchedRoute},
+		{name: "metrics path", method: "GET", path: MetricsPath, want: MetricsPath},
+		{name: "health path", method: "GET", path: HealthPath, want: HealthPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routePattern(router, tt.method, tt.path); got != tt.want {
+				t.Errorf("routePattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveRequests(t *testing.T) {
+	router := newTestRouter()
+	tests := []struct {
+		name       string
+		path       string
+		wantRoute  string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "should record API request by route", path: "/api/v1/task/5", wantRoute: "/api/v1/task/:taskID", wantStatus: 204},
+		{name: "should serve and record metrics request", path: MetricsPath, wantRoute: MetricsPath, wantStatus: 200, wantBody: "metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &metricsStub{}
+			rr := httptest.NewRecorder()
+			observeRequests(m, router, serveUnauthenticated(map[string]http.Handler{MetricsPath: m.Handler()}, router)).ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			if m.route != tt.wantRoute || m.status != tt.wantStatus || m.method != "GET" {
+				t.Errorf("observeRequests() recorded %v %v %v, want GET %v %v", m.method, m.route, m.status, tt.wantRoute, tt.wantStatus)
+			}
+			if rr.Body.String() != tt.wantBody {
+				t.Errorf("observeRequests() body = %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
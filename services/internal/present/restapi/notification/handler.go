@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/notification/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	Preferences(p *notification.Preferences) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	UpdatePreferences(b io.Reader) (mapper.Preferences, error)
+}
+
+// Handle adds notification preference handling endpoints for the logged-in user
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, notificationRepo usecase.NotificationRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/notification"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermUpsertUserSelf, true, l, f, getPreferences(l, f, notificationRepo)))
+	r.PUT(pre+"/", auth.HRAuthorize(auth.PermUpsertUserSelf, true, l, f, updatePreferences(l, f, p, notificationRepo)))
+}
+
+func getPreferences(l Logger, f Formatter, notificationRepo usecase.NotificationRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		prefs, ucerr := usecase.GetNotificationPreferences(r.Context(), notificationRepo, u.ID())
+		if ucerr != nil {
+			l.Errorf("error retrieving notification preferences: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't retrieve notification preferences"), 500)
+			return
+		}
+		o, err := f.Preferences(prefs)
+		if err != nil {
+			l.Errorf("error encoding notification preferences: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding notification preferences"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func updatePreferences(l Logger, f Formatter, p Parser, notificationRepo usecase.NotificationRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		up, err := p.UpdatePreferences(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing updatePreferences data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse notification preferences: %v", err), 400)
+			return
+		}
+		prefs, ucerr := usecase.UpdateNotificationPreferences(r.Context(), notificationRepo, u.ID(), up.Email, up.TaskGenerated, up.Digest, up.DigestHour)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrInvalidData {
+				f.WriteResponse(w, f.Errorf("Error: invalid notification preferences: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error updating notification preferences: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not update notification preferences"), 500)
+			return
+		}
+		o, err := f.Preferences(prefs)
+		if err != nil {
+			l.Errorf("error encoding notification preferences: %v", err)
+			f.WriteResponse(w, f.Error("Notification preferences updated, but there was an error formatting the response"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
@@ -0,0 +1,37 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outPreferences struct {
+	Email          string      `json:"email"`
+	TaskGenerated  bool        `json:"taskGenerated"`
+	Digest         bool        `json:"digest"`
+	DigestHour     int         `json:"digestHour"`
+	LastDigestTime format.Time `json:"lastDigestTime"`
+}
+
+// Preferences formats a user's notification preferences to JSON
+func (f *Formatter) Preferences(p *notification.Preferences) ([]byte, error) {
+	return json.Marshal(&outPreferences{
+		Email:          p.Email(),
+		TaskGenerated:  p.TaskGenerated(),
+		Digest:         p.Digest(),
+		DigestHour:     p.DigestHour(),
+		LastDigestTime: format.Time(p.LastDigestTime()),
+	})
+}
@@ -0,0 +1,39 @@
+package json
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Preferences is the parsed data for a user's notification preferences
+type Preferences struct {
+	Email         string
+	TaskGenerated bool
+	Digest        bool
+	DigestHour    int
+}
+
+// UpdatePreferences parses updatePreferences request JSON data
+func (p *Parser) UpdatePreferences(b io.Reader) (Preferences, error) {
+	var prefs preferences
+	if err := json.NewDecoder(b).Decode(&prefs); err != nil {
+		return Preferences{}, err
+	}
+	return Preferences{Email: prefs.Email, TaskGenerated: prefs.TaskGenerated, Digest: prefs.Digest, DigestHour: prefs.DigestHour}, nil
+}
+
+type preferences struct {
+	Email         string `json:"email"`
+	TaskGenerated bool   `json:"taskGenerated"`
+	Digest        bool   `json:"digest"`
+	DigestHour    int    `json:"digestHour"`
+}
@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	WriteResponse(w http.ResponseWriter, res []byte, statusCode int)
+	Error(a interface{}) []byte
+}
+
+// Config configures per-user and per-IP rate limits, a zero rate disables that limit
+type Config struct {
+	// UserRate is the sustained number of requests per minute allowed for each authenticated user
+	UserRate float64
+	// UserBurst is the number of requests an authenticated user can make at once, defaults to UserRate
+	UserBurst int
+	// IPRate is the sustained number of requests per minute allowed for each client IP address
+	IPRate float64
+	// IPBurst is the number of requests a client IP address can make at once, defaults to IPRate
+	IPBurst int
+	// TrustProxy identifies clients by the last X-Forwarded-For address, the one the trusted reverse proxy added,
+	// since a client can send its own header with any earlier addresses, only enable it behind a trusted reverse proxy
+	TrustProxy bool
+}
+
+// sweepInterval is how often idle buckets are removed
+const sweepInterval = 10 * time.Minute
+
+// Limiter is a token bucket rate limiter with a bucket per key
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing a sustained rate of requests per minute and bursts of up to burst requests
+// returns nil if rate isn't positive, a nil limiter allows every request
+func NewLimiter(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &Limiter{rate: rate / 60, burst: float64(burst), buckets: make(map[string]*bucket), swept: clock.Now()}
+}
+
+// Allow takes a token from the key's bucket, if it's empty it returns false and how long until a token is available
+func (lm *Limiter) Allow(key string) (bool, time.Duration) {
+	if lm == nil {
+		return true, 0
+	}
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	now := clock.Now()
+	if now.Sub(lm.swept) >= sweepInterval {
+		lm.sweep(now)
+	}
+	b, ok := lm.buckets[key]
+	if !ok {
+		b = &bucket{tokens: lm.burst, last: now}
+		lm.buckets[key] = b
+	}
+	b.tokens = lm.refill(b, now)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / lm.rate * float64(time.Second))
+	return false, wait
+}
+
+func (lm *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(lm.burst, b.tokens+elapsed*lm.rate)
+}
+
+// sweep removes buckets that have refilled completely, they are equivalent to new buckets
+func (lm *Limiter) sweep(now time.Time) {
+	for key, b := range lm.buckets {
+		if lm.refill(b, now) >= lm.burst {
+			delete(lm.buckets, key)
+		}
+	}
+	lm.swept = now
+}
+
+// ByIP middleware limits requests per client IP address, it should wrap authentication so unauthenticated requests are limited too
+func ByIP(l Logger, f Formatter, lm *Limiter, trustProxy bool, next http.Handler) http.Handler {
+	if lm == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, l)
+		ip := clientIP(r, trustProxy)
+		if ok, wait := lm.Allow(ip); !ok {
+			l.Warnf("rate limit exceeded for IP %v", ip)
+			writeTooManyRequests(w, f, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ByUser middleware limits requests per authenticated user, requests without a hydrated user are passed through
+func ByUser(l Logger, f Formatter, lm *Limiter, next http.Handler) http.Handler {
+	if lm == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		if u.ID().IsEmpty() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := lm.Allow(u.ID().String()); !ok {
+			l.Warnf("rate limit exceeded for user %v", u.ID())
+			writeTooManyRequests(w, f, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeTooManyRequests(w http.ResponseWriter, f Formatter, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	f.WriteResponse(w, f.Error("Too many requests, try again later"), http.StatusTooManyRequests)
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			addrs := strings.Split(fwd, ",")
+			return strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
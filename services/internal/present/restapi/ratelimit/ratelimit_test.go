@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+type loggerStub struct{}
+
+func (l *loggerStub) Printf(format string, v ...interface{}) {}
+func (l *loggerStub) Warnf(format string, v ...interface{})  {}
+func (l *loggerStub) Errorf(format string, v ...interface{}) {}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewMock(func() time.Time { return now }))
+	defer clock.Set(prevClock)
+	lm := NewLimiter(60, 2)
+
+	tests := []struct {
+		name     string
+		key      string
+		advance  time.Duration
+		want     bool
+		wantWait time.Duration
+	}{
+		{name: "1st request in burst should be allowed", key: "a", want: true},
+		{name: "2nd request in burst should be allowed", key: "a", want: true},
+		{name: "request over burst should wait for the next token", key: "a", want: false, wantWait: time.Second},
+		{name: "other keys should have their own bucket", key: "b", want: true},
+		{name: "request should wait for the rest of the refill", key: "a", advance: 500 * time.Millisecond, want: false, wantWait: 500 * time.Millisecond},
+		{name: "refilled token should be allowed", key: "a", advance: 500 * time.Millisecond, want: true},
+		{name: "bucket should not refill past its burst", key: "a", advance: time.Hour, want: true},
+		{name: "2nd request after idling should be allowed", key: "a", want: true},
+		{name: "3rd request after idling should not be allowed", key: "a", want: false, wantWait: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			got, gotWait := lm.Allow(tt.key)
+			if got != tt.want {
+				t.Errorf("Limiter.Allow() got = %v, want %v", got, tt.want)
+			}
+			if gotWait != tt.wantWait {
+				t.Errorf("Limiter.Allow() wait = %v, want %v", gotWait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestNewLimiter(t *testing.T) {
+	if lm := NewLimiter(0, 10); lm != nil {
+		t.Errorf("NewLimiter() with zero rate = %v, want nil", lm)
+	}
+	var lm *Limiter
+	if ok, _ := lm.Allow("a"); !ok {
+		t.Errorf("nil Limiter.Allow() should allow every request")
+	}
+}
+
+func TestByIP(t *testing.T) {
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)))
+	defer clock.Set(prevClock)
+	f := format.NewFormatter(&loggerStub{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name           string
+		trustProxy     bool
+		remoteAddr     string
+		forwarded      string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "1st request should be allowed", remoteAddr: "10.0.0.1:1000", wantStatus: http.StatusOK},
+		{name: "2nd request from the same IP should be limited", remoteAddr: "10.0.0.1:2000", wantStatus: http.StatusTooManyRequests, wantRetryAfter: "30"},
+		{name: "request from another IP should be allowed", remoteAddr: "10.0.0.2:1000", wantStatus: http.StatusOK},
+		{name: "forwarded address should be ignored without a trusted proxy", remoteAddr: "10.0.0.1:1000", forwarded: "10.0.0.3", wantStatus: http.StatusTooManyRequests, wantRetryAfter: "30"},
+		{name: "last forwarded address should identify the client behind a trusted proxy", trustProxy: true, remoteAddr: "10.0.0.2:1000", forwarded: "10.0.0.3, 10.0.0.1", wantStatus: http.StatusTooManyRequests, wantRetryAfter: "30"},
+		{name: "forwarded address from a trusted proxy should be allowed", trustProxy: true, remoteAddr: "10.0.0.1:1000", forwarded: "10.0.0.4", wantStatus: http.StatusOK},
+	}
+	lm := NewLimiter(2, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/task/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rr := httptest.NewRecorder()
+			ByIP(&loggerStub{}, f, lm, tt.trustProxy, ok).ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ByIP() status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("ByIP() Retry-After = %v, want %v", got, tt.wantRetryAfter)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && rr.Body.String() != `{"error":"Too many requests, try again later"}` {
+				t.Errorf("ByIP() body = %v, want JSON error", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestByUser(t *testing.T) {
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)))
+	defer clock.Set(prevClock)
+	f := format.NewFormatter(&loggerStub{})
+	u1 := user.New("user 1")
+	u2 := user.New("user 2")
+	h := ByUser(&loggerStub{}, f, NewLimiter(1, 1), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		u          *user.User
+		wantStatus int
+	}{
+		{name: "1st request should be allowed", u: u1, wantStatus: http.StatusOK},
+		{name: "2nd request from the same user should be limited", u: u1, wantStatus: http.StatusTooManyRequests},
+		{name: "request from another user should be allowed", u: u2, wantStatus: http.StatusOK},
+		{name: "requests without a user should not be limited by user", u: &user.User{}, wantStatus: http.StatusOK},
+		{name: "repeated requests without a user should not be limited by user", u: &user.User{}, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(auth.UserContext{ResponseWriter: rr, User: tt.u}, httptest.NewRequest("GET", "/", nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ByUser() status = %v, want %v", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
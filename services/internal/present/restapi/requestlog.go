@@ -0,0 +1,108 @@
+package restapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+)
+
+// RequestIDHeader is the header an incoming request ID is read from, and the request's ID is returned in
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of request IDs accepted from clients
+const maxRequestIDLength = 64
+
+// statusWriter records the status code written to a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client, so streamed responses aren't held back by the wrapper
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// logRequests assigns every request an ID and logs each completed request
+// if l is a structured logger, a logger with the request ID is attached to the request context, so every log line for the request includes it
+// along with the ID of the request's trace, if it's being traced
+func logRequests(l Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := clock.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		var rl *logging.Logger
+		if sl, ok := l.(*logging.Logger); ok {
+			rl = sl.With("request_id", id)
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				rl = rl.With("trace_id", sc.TraceID().String())
+			}
+			r = r.WithContext(logging.NewContext(r.Context(), rl))
+		}
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		duration := clock.Now().Sub(start)
+		if rl != nil {
+			// health probes and metrics scrapes are frequent, only log them when debugging
+			log := rl.Info
+			if isUnauthenticatedPath(r.URL.Path) {
+				log = rl.Debug
+			}
+			log("request completed", "method", r.Method, "path", r.URL.Path, "status", status, "duration", duration)
+			return
+		}
+		l.Printf("request %v completed: %v %v %d in %v", id, r.Method, r.URL.Path, status, duration)
+	})
+}
+
+// validRequestID returns whether a client-supplied request ID is safe to log and return
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,68 @@
+package restapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+)
+
+func TestLogRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		wantID    func(string) bool
+	}{
+		{
+			name:      "should keep a valid incoming request ID",
+			requestID: "abc-123",
+			wantID:    func(id string) bool { return id == "abc-123" },
+		},
+		{
+			name:      "should generate a request ID if none was sent",
+			requestID: "",
+			wantID:    func(id string) bool { return len(id) == 32 },
+		},
+		{
+			name:      "should replace an invalid incoming request ID",
+			requestID: "bad id\n",
+			wantID:    func(id string) bool { return len(id) == 32 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := logging.New(buf, "api", logging.Config{})
+			h := logRequests(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logging.Request(r, l).Warnf("handler message")
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			req := httptest.NewRequest("GET", "/api/v1/task/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(RequestIDHeader)
+			if !tt.wantID(id) {
+				t.Fatalf("logRequests() request ID = %q", id)
+			}
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("logRequests() logged %d lines, want 2: %v", len(lines), lines)
+			}
+			for _, line := range lines {
+				if !strings.Contains(line, "request_id="+id) {
+					t.Errorf("logRequests() log line %q missing request ID %v", line, id)
+				}
+			}
+			if !strings.Contains(lines[1], "status=418") {
+				t.Errorf("logRequests() completion log %q missing status", lines[1])
+			}
+		})
+	}
+}
@@ -6,37 +6,115 @@ import (
 	"os"
 	"strconv"
 
-	"github.com/julienschmidt/httprouter"
-
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	eventapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health"
+	healthMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
 	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	localapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/local"
+	notificationapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/ratelimit"
+	roleapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/role"
 	scheduleapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/schedule"
+	searchapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/search"
+	tagapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/tag"
 	taskapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/task"
+	tokenapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/token"
 	userapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/user"
+	webhookapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/webhook"
+	workspaceapi "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/workspace"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 // Logger interface needed for log messages
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Limits configures request rate limits and per-user quotas, zero values are unlimited
+type Limits struct {
+	RateLimit ratelimit.Config
+	Quota     usecase.Quota
 }
 
+// Paths served outside of the API's authentication and rate limits, for orchestrators and monitoring
+const (
+	MetricsPath = "/metrics"
+	HealthPath  = "/healthz"
+	ReadyPath   = "/readyz"
+)
+
 // New creates a REST API server
-func New(l Logger, a auth.Authenticator, checkSchedule chan<- bool, userRepo usecase.UserRepo, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) (api http.Handler) {
+// liveness and readiness reports for the dependencies in hc are served on HealthPath and ReadyPath
+// if events is not nil, task and schedule events are streamed to users as they're published
+// users whose external ID is in admins are granted the admin role when they sign in
+// if m is not nil, request metrics are recorded and served on MetricsPath
+// requests are traced with the global OpenTelemetry tracer provider, continuing any W3C trace context they carry
+func New(l Logger, a auth.Authenticator, checkSchedule chan<- bool, userRepo usecase.UserRepo, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo, activityRepo usecase.ActivityRepo, workspaceRepo usecase.WorkspaceRepo, roleRepo usecase.RoleRepo, tokenRepo usecase.TokenRepo, credRepo usecase.CredentialRepo, webhookRepo usecase.WebhookRepo, notificationRepo usecase.NotificationRepo, actionRepo usecase.ActionRepo, commandRepo usecase.CommandRepo, scheduleRunRepo usecase.ScheduleRunRepo, events eventapi.Stream, local *auth.Local, admins auth.Admins, limits Limits, m Metrics, hc health.Config) (api http.Handler) {
 
-	r := httprouter.New()
+	r := httprouterwrap.NewRouter()
 	f := mapper.NewFormatter(l)
 	a.SetFormatter(f)
 	prefix := "/api/v1"
-	taskapi.Handle(r, prefix, l, f, taskRepo)
-	scheduleapi.Handle(r, prefix, l, f, checkSchedule, scheduleRepo)
+	taskapi.Handle(r, prefix, l, f, taskRepo, activityRepo, workspaceRepo, actionRepo, commandRepo)
+	scheduleapi.Handle(r, prefix, l, f, checkSchedule, scheduleRepo, workspaceRepo, scheduleRunRepo, limits.Quota)
 	userapi.Handle(r, prefix, l, f, userRepo)
+	searchapi.Handle(r, prefix, l, f, taskRepo, scheduleRepo)
+	tagapi.Handle(r, prefix, l, f, taskRepo, scheduleRepo)
+	workspaceapi.Handle(r, prefix, l, f, workspaceRepo, userRepo)
+	roleapi.Handle(r, prefix, l, f, roleRepo, userRepo)
+	tokenapi.Handle(r, prefix, l, f, tokenRepo)
+	webhookapi.Handle(r, prefix, l, f, webhookRepo)
+	notificationapi.Handle(r, prefix, l, f, notificationRepo)
+	if events != nil {
+		eventapi.Handle(r, prefix, l, f, events)
+	}
+	if local != nil {
+		localapi.Handle(r, prefix, l, f, local, userRepo, credRepo)
+	}
 
 	r.HandleMethodNotAllowed = false
 	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		f.WriteResponse(w, f.Error("Not found"), 404)
 	})
-	return a.Authenticate(auth.HydrateUser(userRepo, l, f, false, r))
+	rl := limits.RateLimit
+	userLimiter := ratelimit.NewLimiter(rl.UserRate, rl.UserBurst)
+	ipLimiter := ratelimit.NewLimiter(rl.IPRate, rl.IPBurst)
+	h := auth.HydrateUser(userRepo, roleRepo, admins, l, f, false, ratelimit.ByUser(l, f, userLimiter, r))
+	api = ratelimit.ByIP(l, f, ipLimiter, rl.TrustProxy, a.Authenticate(h))
+
+	checker := health.New(l, healthMapper.NewFormatter(f), hc)
+	unauthenticated := map[string]http.Handler{
+		HealthPath: checker.LivenessHandler(),
+		ReadyPath:  checker.ReadinessHandler(),
+	}
+	if m != nil {
+		unauthenticated[MetricsPath] = m.Handler()
+	}
+	api = serveUnauthenticated(unauthenticated, api)
+	if m != nil {
+		api = observeRequests(m, r, api)
+	}
+	return traceRequests(r, logRequests(l, api))
+}
+
+// serveUnauthenticated serves GET requests for the given paths with their handlers, and all other requests with the API handler
+func serveUnauthenticated(handlers map[string]http.Handler, api http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := handlers[r.URL.Path]; ok && r.Method == http.MethodGet {
+			h.ServeHTTP(w, r)
+			return
+		}
+		api.ServeHTTP(w, r)
+	})
+}
+
+// isUnauthenticatedPath returns whether a request path is served outside of the API
+func isUnauthenticatedPath(path string) bool {
+	return path == MetricsPath || path == HealthPath || path == ReadyPath
 }
 
 // Serve starts an API server
@@ -54,7 +132,7 @@ func Serve(l Logger, api http.Handler) (closed <-chan bool) {
 		l.Printf("starting server on port %d", port)
 		err := http.ListenAndServe(fmt.Sprintf(":%d", port), api)
 		if err != nil {
-			l.Printf("http server error: %v", err)
+			l.Errorf("http server error: %v", err)
 		}
 		l.Printf("server exiting")
 		onClosed <- true
@@ -0,0 +1,127 @@
+package role
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/role/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	RoleList(roles []user.Role, perms map[user.Role][]auth.Permission) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	SetPermissions(b io.Reader) ([]auth.Permission, error)
+	Role(val string) (user.Role, error)
+}
+
+// Handle adds role handling endpoints
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, roleRepo usecase.RoleRepo, userRepo usecase.UserRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/role"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermManageRoles, true, l, f, listRoles(l, f, roleRepo)))
+	r.PUT(pre+"/:role", auth.HRAuthorize(auth.PermManageRoles, true, l, f, setPermissions(l, f, p, roleRepo)))
+	r.PUT(pre+"/:role/user/:userID", auth.HRAuthorize(auth.PermManageRoles, true, l, f, setUserRole(l, f, p, userRepo)))
+}
+
+func listRoles(l Logger, f Formatter, roleRepo usecase.RoleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		roles := user.Roles()
+		perms := make(map[user.Role][]auth.Permission, len(roles))
+		for _, role := range roles {
+			ps, ucerr := auth.GetRolePerms(r.Context(), roleRepo, role)
+			if ucerr != nil {
+				l.Errorf("error retrieving permissions for role %v: %v", role, ucerr)
+				f.WriteResponse(w, f.Error("Error: couldn't retrieve roles"), 500)
+				return
+			}
+			perms[role] = ps
+		}
+		o, err := f.RoleList(roles, perms)
+		if err != nil {
+			l.Errorf("error encoding role list: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding role data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func setPermissions(l Logger, f Formatter, p Parser, roleRepo usecase.RoleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		role, err := p.Role(ps.ByName("role"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Role '%v' not found", ps.ByName("role")), 404)
+			return
+		}
+		perms, err := p.SetPermissions(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing setPermissions data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse permission data: %v", err), 400)
+			return
+		}
+		if ucerr := usecase.SetRolePermissions(r.Context(), roleRepo, role, auth.PermissionMask(perms)); ucerr != nil {
+			l.Errorf("error setting role permissions: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error setting role permissions"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func setUserRole(l Logger, f Formatter, p Parser, userRepo usecase.UserRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		role, err := p.Role(ps.ByName("role"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Role '%v' not found", ps.ByName("role")), 404)
+			return
+		}
+		uid, err := user.ParseID(ps.ByName("userID"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid user ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		ucerr := usecase.SetUserRole(r.Context(), userRepo, u.ID(), uid, role)
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrRecordNotFound:
+				f.WriteResponse(w, f.Errorf("User ID %v not found", uid), 404)
+				return
+			case usecase.ErrForbidden:
+				f.WriteResponse(w, f.Error("Error: users cannot change their own role"), 403)
+				return
+			}
+			l.Errorf("error setting user role: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error setting user role"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
@@ -0,0 +1,36 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outRole struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleList formats an ordered list of roles and their permissions to JSON
+func (f *Formatter) RoleList(roles []user.Role, perms map[user.Role][]auth.Permission) ([]byte, error) {
+	o := make([]*outRole, len(roles))
+	for i, role := range roles {
+		o[i] = &outRole{Role: role.String(), Permissions: []string{}}
+		for _, p := range perms[role] {
+			o[i].Permissions = append(o[i].Permissions, p.Scope())
+		}
+	}
+	return json.Marshal(o)
+}
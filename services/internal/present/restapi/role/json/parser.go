@@ -0,0 +1,45 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// SetPermissions parses setPermissions request JSON data into a list of permissions
+func (p *Parser) SetPermissions(b io.Reader) ([]auth.Permission, error) {
+	var setPermissions setPermissions
+	if err := json.NewDecoder(b).Decode(&setPermissions); err != nil {
+		return nil, err
+	}
+	ps := []auth.Permission{}
+	for _, scope := range setPermissions.Permissions {
+		perm, ok := auth.ParsePermission(scope)
+		if !ok {
+			return nil, fmt.Errorf("unknown permission '%v'", scope)
+		}
+		ps = append(ps, perm)
+	}
+	return ps, nil
+}
+
+type setPermissions struct {
+	Permissions []string `json:"permissions"`
+}
+
+// Role parses a role name
+func (p *Parser) Role(val string) (user.Role, error) {
+	return user.ParseRole(val)
+}
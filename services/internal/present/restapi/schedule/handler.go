@@ -8,8 +8,11 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
 	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
 	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/schedule/json"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
@@ -18,6 +21,8 @@ import (
 // Logger interface needed for log messages
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
 }
 
 // Formatter defines the formatter interface for output responses
@@ -29,16 +34,18 @@ type Formatter interface {
 	Schedule(sd *usecase.ScheduleData) ([]byte, error)
 	ScheduleID(id usecase.ScheduleID) ([]byte, error)
 	ScheduleMap(ss map[usecase.ScheduleID]*schedule.Schedule) ([]byte, error)
+	ScheduleRuns(rs []usecase.ScheduleRunData, total int, p usecase.Page) ([]byte, error)
 }
 
 // Parser defines the parser interface for parsing input requests
 type Parser interface {
 	AddSchedule(b io.Reader, uid user.ID) (*schedule.Schedule, error)
+	SetChatURL(b io.Reader) (string, error)
 	AddRecurringTask(b io.Reader) (schedule.RecurringTask, error)
 }
 
 // Handle adds schedule handling endpoints
-func Handle(r *httprouter.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo) {
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo, workspaceRepo usecase.WorkspaceRepo, runRepo usecase.ScheduleRunRepo, quota usecase.Quota) {
 
 	p := mapper.NewParser()
 	f := mapper.NewFormatter(rf)
@@ -47,47 +54,81 @@ func Handle(r *httprouter.Router, prefix string, l Logger, rf responseMapper.Res
 	r.GET(sPre+"/", auth.HRAuthorize(auth.PermReadSchedule, false, l, f, listSchedules(l, f, scheduleRepo)))
 	r.GET(sPre+"/:scheduleID", auth.HRAuthorize(auth.PermReadSchedule, false, l, f, getSchedule(l, f, scheduleRepo)))
 	r.DELETE(sPre+"/:scheduleID", auth.HRAuthorize(auth.PermDeleteSchedule, true, l, f, removeSchedule(l, f, checkSchedule, scheduleRepo)))
-	r.POST(sPre+"/", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, addSchedule(l, f, p, checkSchedule, scheduleRepo)))
+	r.POST(sPre+"/", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, addSchedule(l, f, p, checkSchedule, scheduleRepo, workspaceRepo, quota)))
 	r.PUT(sPre+"/:scheduleID/pause", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, pauseSchedule(l, f, checkSchedule, scheduleRepo)))
 	r.PUT(sPre+"/:scheduleID/unpause", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, unpauseSchedule(l, f, checkSchedule, scheduleRepo)))
+	r.GET(sPre+"/:scheduleID/runs", auth.HRAuthorize(auth.PermReadSchedule, true, l, f, listScheduleRuns(l, f, scheduleRepo, runRepo)))
+	r.PUT(sPre+"/:scheduleID/chat", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, setScheduleChat(l, f, p, scheduleRepo)))
 
 	rtPre := sPre + "/:scheduleID/task"
-	r.POST(rtPre+"/", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, addRecurringTask(l, f, p, scheduleRepo)))
+	r.POST(rtPre+"/", auth.HRAuthorize(auth.PermUpsertSchedule, true, l, f, addRecurringTask(l, f, p, scheduleRepo, quota)))
 }
 
+// listSchedules lists schedules, optionally filtered to those with a recurring task having all 'tag' query parameters
 func listSchedules(l Logger, f Formatter, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		tags, tagErr := task.ParseTags(r.URL.Query()["tag"])
+		if tagErr != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", tagErr), 400)
+			return
+		}
 		u := auth.GetUser(w)
-		ss, err := usecase.ListSchedules(scheduleRepo, u.ID())
+		ss, err := usecase.ListSchedules(r.Context(), scheduleRepo, u.ID())
 		if err != nil {
-			l.Printf("error retrieving schedule list: %v", err)
+			l.Errorf("error retrieving schedule list: %v", err)
 			f.WriteResponse(w, f.Error("Error: couldn't retrieve schedules"), 500)
 			return
 		}
+		if len(tags) > 0 {
+			ss = usecase.FilterSchedulesByTags(ss, tags)
+		}
 
 		o, e := f.ScheduleMap(ss)
 
 		if e != nil {
-			l.Printf("error encoding schedule map: %v", e)
+			l.Errorf("error encoding schedule map: %v", e)
 			f.WriteResponse(w, f.Error("Error encoding schedule data"), 500)
 		}
 		f.WriteResponse(w, o, 200)
 	}
 }
 
-func addSchedule(l Logger, f Formatter, p Parser, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+func addSchedule(l Logger, f Formatter, p Parser, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo, workspaceRepo usecase.WorkspaceRepo, quota usecase.Quota) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
 		u := auth.GetUser(w)
 		s, err := p.AddSchedule(r.Body, u.ID())
 		defer r.Body.Close()
 		if err != nil {
-			l.Printf("error parsing addSchedule data: %v", err)
+			l.Warnf("error parsing addSchedule data: %v", err)
 			f.WriteResponse(w, f.Errorf("Error: could not parse schedule data: %v", err), 400)
 			return
 		}
-		sID, ucerr := usecase.AddSchedule(scheduleRepo, s, checkSchedule)
+		if !authorizeRecurringTasks(w, l, f, s.Tasks()...) {
+			return
+		}
+		if ucerr := usecase.CheckWorkspaceMember(r.Context(), workspaceRepo, s.Workspace(), u.ID()); ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Error: workspace ID %v not found", s.Workspace()), 400)
+				return
+			}
+			l.Errorf("error checking schedule workspace: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not add schedule data"), 500)
+			return
+		}
+		if ucerr := usecase.CheckScheduleQuota(r.Context(), scheduleRepo, quota, u.ID(), len(s.Tasks())); ucerr != nil {
+			if ucerr.Code() == usecase.ErrQuotaExceeded {
+				f.WriteResponse(w, f.Errorf("Error: %v", ucerr), 403)
+				return
+			}
+			l.Errorf("error checking schedule quota: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not add schedule data"), 500)
+			return
+		}
+		sID, ucerr := usecase.AddSchedule(r.Context(), scheduleRepo, s, checkSchedule)
 		if ucerr != nil {
-			l.Printf("error adding schedule: %v", ucerr)
+			l.Errorf("error adding schedule: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error: could not add schedule data"), 500)
 			return
 		}
@@ -104,49 +145,102 @@ func getSchedule(l Logger, f Formatter, scheduleRepo usecase.ScheduleRepo) httpr
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		scheduleIDInt, err := strconv.Atoi(params.ByName("scheduleID"))
 		if err != nil {
-			l.Printf("valid schedule ID required")
+			l.Warnf("valid schedule ID required")
 			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		id := usecase.ScheduleID(scheduleIDInt)
-		sd, ucerr := usecase.GetSchedule(scheduleRepo, id, u.ID())
+		sd, ucerr := usecase.GetSchedule(r.Context(), scheduleRepo, id, u.ID())
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error retrieving schedule ID %d: %v", id, ucerr)
+			l.Errorf("error retrieving schedule ID %d: %v", id, ucerr)
 			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve schedule ID %d", id), 500)
 			return
 		}
 
 		o, err := f.Schedule(sd)
 		if err != nil {
-			l.Printf("error encoding schedule map: %v", err)
+			l.Errorf("error encoding schedule map: %v", err)
 			f.WriteResponse(w, f.Error("Error encoding schedule data"), 500)
 		}
 		f.WriteResponse(w, o, 200)
 	}
 }
 
+// listScheduleRuns lists a page of a schedule's run history, newest first, selected with the 'limit' and 'offset' query parameters
+func listScheduleRuns(l Logger, f Formatter, scheduleRepo usecase.ScheduleRepo, runRepo usecase.ScheduleRunRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		scheduleIDInt, err := strconv.Atoi(ps.ByName("scheduleID"))
+		if err != nil {
+			l.Warnf("valid schedule ID required")
+			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
+			return
+		}
+		var limit, offset int
+		q := r.URL.Query()
+		if v := q.Get("limit"); v != "" {
+			if limit, err = strconv.Atoi(v); err != nil {
+				f.WriteResponse(w, f.Error("Error: limit must be a number"), 400)
+				return
+			}
+		}
+		if v := q.Get("offset"); v != "" {
+			if offset, err = strconv.Atoi(v); err != nil {
+				f.WriteResponse(w, f.Error("Error: offset must be a number"), 400)
+				return
+			}
+		}
+		p, ucerr := usecase.NewPage(limit, offset)
+		if ucerr != nil {
+			f.WriteResponse(w, f.Errorf("Error: invalid page: %v", ucerr), 400)
+			return
+		}
+		u := auth.GetUser(w)
+		id := usecase.ScheduleID(scheduleIDInt)
+		rs, total, ucerr := usecase.ListScheduleRuns(r.Context(), scheduleRepo, runRepo, id, u.ID(), p)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
+				return
+			}
+			l.Errorf("error retrieving runs for schedule ID %d: %v", id, ucerr)
+			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve runs for schedule ID %d", id), 500)
+			return
+		}
+
+		o, err := f.ScheduleRuns(rs, total, p)
+		if err != nil {
+			l.Errorf("error encoding schedule runs: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding schedule run data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
 func removeSchedule(l Logger, f Formatter, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		scheduleIDInt, err := strconv.Atoi(ps.ByName("scheduleID"))
 		if err != nil {
-			l.Printf("valid schedule ID required")
+			l.Warnf("valid schedule ID required")
 			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		id := usecase.ScheduleID(scheduleIDInt)
-		ucerr := usecase.RemoveSchedule(scheduleRepo, id, u.ID(), checkSchedule)
+		ucerr := usecase.RemoveSchedule(r.Context(), scheduleRepo, id, u.ID(), checkSchedule)
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error removing schedule: %v", ucerr)
+			l.Errorf("error removing schedule: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error removing schedule"), 500)
 			return
 		}
@@ -156,21 +250,22 @@ func removeSchedule(l Logger, f Formatter, checkSchedule chan<- bool, scheduleRe
 
 func pauseSchedule(l Logger, f Formatter, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		scheduleIDInt, err := strconv.Atoi(ps.ByName("scheduleID"))
 		if err != nil {
-			l.Printf("valid schedule ID required")
+			l.Warnf("valid schedule ID required")
 			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		id := usecase.ScheduleID(scheduleIDInt)
-		ucerr := usecase.PauseSchedule(scheduleRepo, id, u.ID(), checkSchedule)
+		ucerr := usecase.PauseSchedule(r.Context(), scheduleRepo, id, u.ID(), checkSchedule)
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error pausing schedule: %v", ucerr)
+			l.Errorf("error pausing schedule: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error pausing schedule"), 500)
 			return
 		}
@@ -180,21 +275,22 @@ func pauseSchedule(l Logger, f Formatter, checkSchedule chan<- bool, scheduleRep
 
 func unpauseSchedule(l Logger, f Formatter, checkSchedule chan<- bool, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		scheduleIDInt, err := strconv.Atoi(ps.ByName("scheduleID"))
 		if err != nil {
-			l.Printf("valid schedule ID required")
+			l.Warnf("valid schedule ID required")
 			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		id := usecase.ScheduleID(scheduleIDInt)
-		ucerr := usecase.UnpauseSchedule(scheduleRepo, id, u.ID(), checkSchedule)
+		ucerr := usecase.UnpauseSchedule(r.Context(), scheduleRepo, id, u.ID(), checkSchedule)
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error unpausing schedule: %v", ucerr)
+			l.Errorf("error unpausing schedule: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error unpausing schedule"), 500)
 			return
 		}
@@ -202,13 +298,50 @@ func unpauseSchedule(l Logger, f Formatter, checkSchedule chan<- bool, scheduleR
 	}
 }
 
-func addRecurringTask(l Logger, f Formatter, p Parser, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+func setScheduleChat(l Logger, f Formatter, p Parser, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		scheduleIDInt, err := strconv.Atoi(ps.ByName("scheduleID"))
+		if err != nil {
+			l.Warnf("valid schedule ID required")
+			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
+			return
+		}
+		id := usecase.ScheduleID(scheduleIDInt)
+		url, err := p.SetChatURL(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing setScheduleChat data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse chat data: %v", err), 400)
+			return
+		}
+		u := auth.GetUser(w)
+		ucerr := usecase.SetScheduleChatURL(r.Context(), scheduleRepo, id, url, u.ID())
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrRecordNotFound:
+				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
+				return
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error setting schedule chat URL: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error setting schedule chat URL"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func addRecurringTask(l Logger, f Formatter, p Parser, scheduleRepo usecase.ScheduleRepo, quota usecase.Quota) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 
 		// Get schedule ID
 		scheduleIDInt, err := strconv.Atoi(ps.ByName("scheduleID"))
 		if err != nil {
-			l.Printf("valid schedule ID required")
+			l.Warnf("valid schedule ID required")
 			f.WriteResponse(w, f.Error("Error: valid schedule ID required"), 404)
 			return
 		}
@@ -218,14 +351,33 @@ func addRecurringTask(l Logger, f Formatter, p Parser, scheduleRepo usecase.Sche
 		rt, err := p.AddRecurringTask(r.Body)
 		defer r.Body.Close()
 		if err != nil {
-			l.Printf("error parsing addRecurringTask data: %v", err)
+			l.Warnf("error parsing addRecurringTask data: %v", err)
 			f.WriteResponse(w, f.Errorf("Error: could not parse recurring task data: %v", err), 400)
 			return
 		}
 
-		// Add recurring task
+		if !authorizeRecurringTasks(w, l, f, rt) {
+			return
+		}
+
+		// Check the schedule owner's quota
 		u := auth.GetUser(w)
-		ucerr := usecase.AddRecurringTask(scheduleRepo, id, u.ID(), rt)
+		if ucerr := usecase.CheckRecurringTaskQuota(r.Context(), scheduleRepo, quota, id, u.ID()); ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
+				return
+			}
+			if ucerr.Code() == usecase.ErrQuotaExceeded {
+				f.WriteResponse(w, f.Errorf("Error: %v", ucerr), 403)
+				return
+			}
+			l.Errorf("error checking recurring task quota: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error adding task to schedule"), 500)
+			return
+		}
+
+		// Add recurring task
+		ucerr := usecase.AddRecurringTask(r.Context(), scheduleRepo, id, u.ID(), rt)
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Schedule ID %d not found", id), 404)
@@ -235,10 +387,34 @@ func addRecurringTask(l Logger, f Formatter, p Parser, scheduleRepo usecase.Sche
 				f.WriteResponse(w, f.Errorf("Recurring task already exists for this schedule, can't add duplicate tasks with the same data"), 400)
 				return
 			}
-			l.Printf("error adding task to schedule: %v", ucerr)
+			l.Errorf("error adding task to schedule: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error adding task to schedule"), 500)
 			return
 		}
 		f.WriteEmpty(w, 201)
 	}
 }
+
+// authorizeRecurringTasks checks the user has the extra permissions needed to add the recurring tasks, responding with a 403 if not
+// commands run programs on the server and actions make requests from it, so they need their own permissions
+func authorizeRecurringTasks(w http.ResponseWriter, l Logger, f Formatter, rts ...schedule.RecurringTask) bool {
+	for _, rt := range rts {
+		if _, ok := rt.Command(); ok && !requirePerm(w, l, f, auth.PermManageCommands, "command") {
+			return false
+		}
+		if _, ok := rt.Action(); ok && !requirePerm(w, l, f, auth.PermManageActions, "action") {
+			return false
+		}
+	}
+	return true
+}
+
+func requirePerm(w http.ResponseWriter, l Logger, f Formatter, perm auth.Permission, kind string) bool {
+	if auth.HasPerm(w, perm) {
+		return true
+	}
+	u := auth.GetUser(w)
+	l.Warnf("user %v not authorized to add a recurring task %v, need permission: %v", u.ID(), kind, perm)
+	f.WriteResponse(w, f.Errorf("Error: the %v permission is required to add a task %v", perm.Scope(), kind), 403)
+	return false
+}
@@ -2,8 +2,10 @@ package json
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
@@ -20,6 +22,7 @@ func NewFormatter(rf format.ResponseFormatter) *Formatter {
 
 type outSchedule struct {
 	ID            usecase.ScheduleID `json:"id"`
+	WorkspaceID   *string            `json:"workspaceId,omitempty"`
 	Frequency     string             `json:"frequency"`
 	Interval      int                `json:"interval"`
 	Offset        int                `json:"offset"`
@@ -28,12 +31,56 @@ type outSchedule struct {
 	OnDaysOfWeek  []format.Weekday   `json:"onDaysOfWeek,omitempty"`
 	OnDaysOfMonth []int              `json:"onDaysOfMonth,omitempty"`
 	Paused        bool               `json:"paused"`
+	ChatURL       string             `json:"chatUrl,omitempty"`
 	Tasks         []outRecurringTask `json:"tasks"`
 }
 
 type outRecurringTask struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name             string      `json:"name"`
+	Description      string      `json:"description"`
+	Priority         string      `json:"priority"`
+	DueOffsetMinutes *int        `json:"dueOffsetMinutes,omitempty"`
+	Tags             []task.Tag  `json:"tags"`
+	Checklist        []string    `json:"checklist"`
+	AutoComplete     bool        `json:"autoComplete"`
+	Rotation         []string    `json:"rotation,omitempty"`
+	RotationStrategy string      `json:"rotationStrategy,omitempty"`
+	NextAssignee     *string     `json:"nextAssignee,omitempty"`
+	Action           *outAction  `json:"action,omitempty"`
+	Command          *outCommand `json:"command,omitempty"`
+}
+
+type outAction struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers"`
+	Body           string            `json:"body"`
+	TimeoutSeconds int               `json:"timeoutSeconds"`
+	MaxAttempts    int               `json:"maxAttempts"`
+	CompleteTask   bool              `json:"completeTask"`
+}
+
+type outCommand struct {
+	Program        string   `json:"program"`
+	Args           []string `json:"args"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+}
+
+type outScheduleRuns struct {
+	Runs   []outScheduleRun `json:"runs"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+type outScheduleRun struct {
+	ID            usecase.ScheduleRunID `json:"id"`
+	Type          string                `json:"type"`
+	ScheduledTime format.Time           `json:"scheduledTime"`
+	ProcessedTime format.Time           `json:"processedTime"`
+	LagMs         int64                 `json:"lagMs"`
+	TasksCreated  int                   `json:"tasksCreated"`
+	Error         string                `json:"error"`
 }
 
 type outTaskID struct {
@@ -60,12 +107,14 @@ func (f *Formatter) ScheduleID(id usecase.ScheduleID) ([]byte, error) {
 func scheduleToOut(id usecase.ScheduleID, s *schedule.Schedule) *outSchedule {
 	f := s.Frequency()
 	outS := outSchedule{
-		ID:        id,
-		Frequency: f.TimePeriod().String(),
-		Interval:  f.Interval(),
-		Offset:    f.Offset(),
-		Paused:    s.Paused(),
-		Tasks:     []outRecurringTask{},
+		ID:          id,
+		WorkspaceID: s.Workspace().StringPtr(),
+		Frequency:   f.TimePeriod().String(),
+		Interval:    f.Interval(),
+		Offset:      f.Offset(),
+		Paused:      s.Paused(),
+		ChatURL:     s.ChatURL(),
+		Tasks:       []outRecurringTask{},
 	}
 	switch f.TimePeriod() {
 	case schedule.TimePeriodHour:
@@ -87,7 +136,24 @@ func scheduleToOut(id usecase.ScheduleID, s *schedule.Schedule) *outSchedule {
 		outS.AtMinutes = f.AtMinutes()
 	}
 	for _, rt := range s.Tasks() {
-		oRt := outRecurringTask{Name: rt.Name(), Description: rt.Description()}
+		oRt := outRecurringTask{Name: rt.Name(), Description: rt.Description(), Priority: rt.Priority().String(), Tags: rt.Tags(), Checklist: rt.Checklist(), AutoComplete: rt.AutoComplete()}
+		if offset, ok := rt.DueOffset(); ok {
+			minutes := int(offset / time.Minute)
+			oRt.DueOffsetMinutes = &minutes
+		}
+		if next, ok := rt.NextAssignee(); ok {
+			for _, uid := range rt.Rotation() {
+				oRt.Rotation = append(oRt.Rotation, uid.String())
+			}
+			oRt.RotationStrategy = rt.RotationStrategy().String()
+			oRt.NextAssignee = next.StringPtr()
+		}
+		if a, ok := rt.Action(); ok {
+			oRt.Action = &outAction{Method: a.Method(), URL: a.URL(), Headers: redactHeaders(a.Headers()), Body: a.Body(), TimeoutSeconds: int(a.Timeout() / time.Second), MaxAttempts: a.MaxAttempts(), CompleteTask: a.CompleteTask()}
+		}
+		if c, ok := rt.Command(); ok {
+			oRt.Command = &outCommand{Program: c.Program(), Args: c.Args(), TimeoutSeconds: int(c.Timeout() / time.Second)}
+		}
 		outS.Tasks = append(outS.Tasks, oRt)
 	}
 	return &outS
@@ -107,3 +173,35 @@ func (f *Formatter) ScheduleMap(ss map[usecase.ScheduleID]*schedule.Schedule) ([
 
 	return json.Marshal(o)
 }
+
+// ScheduleRuns formats a page of a schedule's runs to JSON, along with the total number of runs and the page range
+func (f *Formatter) ScheduleRuns(rs []usecase.ScheduleRunData, total int, p usecase.Page) ([]byte, error) {
+	o := outScheduleRuns{Runs: []outScheduleRun{}, Total: total, Limit: p.Limit, Offset: p.Offset}
+	for _, rd := range rs {
+		run := rd.Run
+		o.Runs = append(o.Runs, outScheduleRun{
+			ID:            rd.ScheduleRunID,
+			Type:          run.Type().String(),
+			ScheduledTime: format.Time(run.ScheduledTime()),
+			ProcessedTime: format.Time(run.ProcessedTime()),
+			LagMs:         int64(run.Lag() / time.Millisecond),
+			TasksCreated:  run.TasksCreated(),
+			Error:         run.Err(),
+		})
+	}
+	return json.Marshal(o)
+}
+
+// redactedValue replaces action header values in responses, since they often hold credentials for the called service
+const redactedValue = "[redacted]"
+
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name := range headers {
+		redacted[name] = redactedValue
+	}
+	return redacted
+}
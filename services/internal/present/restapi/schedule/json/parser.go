@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
 	parse "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
 )
 
@@ -39,6 +41,8 @@ type addSchedule struct {
 	OnDaysOfWeek  []parse.Weekday    `json:"onDaysOfWeek"`
 	OnDaysOfMonth []int              `json:"onDaysOfMonth"`
 	Paused        bool               `json:"paused"`
+	WorkspaceID   string             `json:"workspaceId"`
+	ChatURL       string             `json:"chatUrl"`
 	Tasks         []addRecurringTask `json:"tasks"`
 }
 
@@ -82,26 +86,125 @@ func parseAddSchedule(as *addSchedule, uid user.ID) (*schedule.Schedule, error)
 	if as.Paused {
 		s.Pause()
 	}
-	for _, rt := range as.Tasks {
-		s.AddTask(schedule.NewRecurringTask(rt.Name, rt.Description))
+	if as.WorkspaceID != "" {
+		wid, err := workspace.ParseID(as.WorkspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace ID '%v'", as.WorkspaceID)
+		}
+		s.SetWorkspace(wid)
+	}
+	if err := s.SetChatURL(as.ChatURL); err != nil {
+		return nil, err
+	}
+	for _, art := range as.Tasks {
+		rt, err := parseAddRecurringTask(&art)
+		if err != nil {
+			return nil, err
+		}
+		s.AddTask(rt)
 	}
 	return s, nil
 }
 
+// SetChatURL parses setChatURL request JSON data into an incoming webhook URL
+func (p *Parser) SetChatURL(b io.Reader) (string, error) {
+	var setChatURL setChatURL
+	if err := json.NewDecoder(b).Decode(&setChatURL); err != nil {
+		return "", err
+	}
+	return setChatURL.URL, nil
+}
+
+type setChatURL struct {
+	URL string `json:"url"`
+}
+
 // AddRecurringTask parses addRecurringTask request JSON into a core RecurringTask struct
 func (p *Parser) AddRecurringTask(b io.Reader) (schedule.RecurringTask, error) {
 	var addRecurringTask addRecurringTask
 	if err := json.NewDecoder(b).Decode(&addRecurringTask); err != nil {
 		return schedule.RecurringTask{}, err
 	}
-	return parseAddRecurringTask(&addRecurringTask), nil
+	return parseAddRecurringTask(&addRecurringTask)
 }
 
 type addRecurringTask struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name             string      `json:"name"`
+	Description      string      `json:"description"`
+	Priority         string      `json:"priority"`
+	DueOffsetMinutes *int        `json:"dueOffsetMinutes"`
+	Tags             []string    `json:"tags"`
+	Checklist        []string    `json:"checklist"`
+	AutoComplete     bool        `json:"autoComplete"`
+	Rotation         []string    `json:"rotation"`
+	RotationStrategy string      `json:"rotationStrategy"`
+	Action           *addAction  `json:"action"`
+	Command          *addCommand `json:"command"`
+}
+
+type addAction struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers"`
+	Body           string            `json:"body"`
+	TimeoutSeconds int               `json:"timeoutSeconds"`
+	MaxAttempts    int               `json:"maxAttempts"`
+	CompleteTask   bool              `json:"completeTask"`
+}
+
+type addCommand struct {
+	Program        string   `json:"program"`
+	Args           []string `json:"args"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
 }
 
-func parseAddRecurringTask(rt *addRecurringTask) schedule.RecurringTask {
-	return schedule.NewRecurringTask(rt.Name, rt.Description)
+func parseAddRecurringTask(art *addRecurringTask) (schedule.RecurringTask, error) {
+	tags, err := task.ParseTags(art.Tags)
+	if err != nil {
+		return schedule.RecurringTask{}, err
+	}
+	rt := schedule.NewRecurringTask(art.Name, art.Description).WithTags(tags)
+	p, err := task.ParsePriority(art.Priority)
+	if err != nil {
+		return schedule.RecurringTask{}, err
+	}
+	if rt, err = rt.WithPriority(p); err != nil {
+		return schedule.RecurringTask{}, err
+	}
+	if rt, err = rt.WithChecklist(art.Checklist, art.AutoComplete); err != nil {
+		return schedule.RecurringTask{}, err
+	}
+	if art.DueOffsetMinutes != nil {
+		if rt, err = rt.WithDueOffset(time.Duration(*art.DueOffsetMinutes) * time.Minute); err != nil {
+			return schedule.RecurringTask{}, err
+		}
+	}
+	rotation := make([]user.ID, len(art.Rotation))
+	for i, val := range art.Rotation {
+		if rotation[i], err = user.ParseID(val); err != nil {
+			return schedule.RecurringTask{}, fmt.Errorf("invalid rotation user ID '%v'", val)
+		}
+	}
+	strategy, err := schedule.ParseRotationStrategy(art.RotationStrategy)
+	if err != nil {
+		return schedule.RecurringTask{}, err
+	}
+	if rt, err = rt.WithRotation(rotation, strategy); err != nil {
+		return schedule.RecurringTask{}, err
+	}
+	if art.Action != nil {
+		a, err := schedule.NewHTTPAction(art.Action.Method, art.Action.URL, art.Action.Headers, art.Action.Body, time.Duration(art.Action.TimeoutSeconds)*time.Second, art.Action.MaxAttempts, art.Action.CompleteTask)
+		if err != nil {
+			return schedule.RecurringTask{}, err
+		}
+		rt = rt.WithAction(a)
+	}
+	if art.Command != nil {
+		c, err := schedule.NewCommand(art.Command.Program, art.Command.Args, time.Duration(art.Command.TimeoutSeconds)*time.Second)
+		if err != nil {
+			return schedule.RecurringTask{}, err
+		}
+		rt = rt.WithCommand(c)
+	}
+	return rt, nil
 }
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/search/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	SearchResults(query string, rs []usecase.SearchResult) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Handle adds search handling endpoints
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) {
+
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/search"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermReadTask, false, l, f, search(l, f, taskRepo, scheduleRepo)))
+}
+
+func search(l Logger, f Formatter, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			f.WriteResponse(w, f.Error("Error: search query parameter 'q' required"), 400)
+			return
+		}
+
+		// Only include recurring tasks if the user is allowed to read schedules
+		u := auth.GetUser(w)
+		var rs []usecase.SearchResult
+		var ucerr usecase.Error
+		if auth.HasPerm(w, auth.PermReadSchedule) {
+			rs, ucerr = usecase.Search(r.Context(), taskRepo, scheduleRepo, query, u.ID())
+		} else {
+			rs, ucerr = usecase.SearchTasks(r.Context(), taskRepo, query, u.ID())
+		}
+		if ucerr != nil {
+			l.Errorf("error searching for '%v': %v", query, ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't complete search"), 500)
+			return
+		}
+
+		o, err := f.SearchResults(query, rs)
+		if err != nil {
+			l.Errorf("error encoding search results: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding search results"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
@@ -0,0 +1,55 @@
+package json
+
+import (
+	"encoding/json"
+
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outSearchResults struct {
+	Query   string            `json:"query"`
+	Results []outSearchResult `json:"results"`
+}
+
+type outSearchResult struct {
+	Type                 string             `json:"type"`
+	TaskID               usecase.TaskID     `json:"taskId,omitempty"`
+	ScheduleID           usecase.ScheduleID `json:"scheduleId,omitempty"`
+	Name                 string             `json:"name"`
+	Description          string             `json:"description"`
+	NameHighlight        string             `json:"nameHighlight"`
+	DescriptionHighlight string             `json:"descriptionHighlight"`
+	Rank                 float64            `json:"rank"`
+}
+
+// SearchResults formats a ranked list of search results to JSON
+func (f *Formatter) SearchResults(query string, rs []usecase.SearchResult) ([]byte, error) {
+	o := &outSearchResults{
+		Query:   query,
+		Results: make([]outSearchResult, len(rs)),
+	}
+	for i, r := range rs {
+		o.Results[i] = outSearchResult{
+			Type:                 r.Type.String(),
+			TaskID:               r.TaskID,
+			ScheduleID:           r.ScheduleID,
+			Name:                 r.Name,
+			Description:          r.Description,
+			NameHighlight:        r.NameHighlight,
+			DescriptionHighlight: r.DescriptionHighlight,
+			Rank:                 r.Rank,
+		}
+	}
+	return json.Marshal(o)
+}
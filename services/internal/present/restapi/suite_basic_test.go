@@ -3,6 +3,9 @@
 package restapi_test
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,11 +13,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 func TestTransientRESTAPIBasic(t *testing.T) {
@@ -36,6 +45,21 @@ func suiteBasic(t *testing.T, tester test.Tester) {
 	addTask(t, tester.NewAPI())
 	getTask(t, tester.NewAPI())
 	completeTask(t, tester.NewAPI())
+	updateTask(t, tester.NewAPI())
+	uncompleteTask(t, tester.NewAPI())
+	prioritizeTask(t, tester.NewAPI())
+	tagTasks(t, tester.NewAPI())
+	checklistTask(t, tester.NewAPI())
+	taskActivity(t, tester.NewAPI())
+	shareWorkspace(t, tester.NewAPI())
+	assignTasks(t, tester.NewAPI())
+	endpointPermissions(t, tester.NewAPI())
+	manageRoles(t, tester.NewAPI())
+	personalTokens(t, tester.NewAPI())
+	webhooks(t, tester.NewAPI())
+	notificationPreferences(t, tester.NewAPI())
+	streamEvents(t, tester.NewAPI())
+	localAuth(t, tester.NewAPI())
 	clearTask(t, tester.NewAPI())
 	clearCompletedTasks(t, tester.NewAPI())
 	listSchedules(t, tester.NewAPI())
@@ -44,18 +68,25 @@ func suiteBasic(t *testing.T, tester test.Tester) {
 	getSchedule(t, tester.NewAPI())
 	pauseSchedule(t, tester.NewAPI())
 	unpauseSchedule(t, tester.NewAPI())
+	scheduleChat(t, tester.NewAPI())
+	scheduleActions(t, tester.NewAPI())
+	scheduleCommands(t, tester.NewAPI())
+	scheduleRuns(t, tester.NewAPI())
 	removeSchedule(t, tester.NewAPI())
+	search(t, tester.NewAPI())
 }
 
 func addOrUpdateExternalUser(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 	u1 := user.New("test user")
-	apiMock.UserRepo.AddExternal(u1, "https://p1/", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "https://p1/", "e1")
 	u1Perms := []auth.Permission{auth.PermUpsertUserSelf}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "https://p1/", Subject: "e1", Permissions: u1Perms}, api)
 
 	u2 := user.New("test user, no perms")
-	apiMock.UserRepo.AddExternal(u2, "https://p1/", "e2")
+	apiMock.UserRepo.AddExternal(ctx, u2, "https://p1/", "e2")
 	u2Api := test.InjectClaims(test.MockClaims{Issuer: "https://p1/", Subject: "e2"}, api)
 
 	type args struct {
@@ -168,24 +199,26 @@ func errorResponse(t *testing.T, apiMock test.MockAPI) {
 }
 
 func listTasks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
 	defer reset()
 
 	u1 := user.New("test user")
-	apiMock.UserRepo.AddExternal(u1, "p1", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
 	u1Perms := []auth.Permission{auth.PermReadTask}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
 
 	u2 := user.New("test user, no perms")
-	apiMock.UserRepo.AddExternal(u2, "p1", "e2")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
 	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
 
 	u3 := user.New("test user with tasks")
-	apiMock.UserRepo.AddExternal(u3, "p1", "e3")
+	apiMock.UserRepo.AddExternal(ctx, u3, "p1", "e3")
 	u3t1 := task.New("u3 task1", "u3t1 description", u3.ID())
-	apiMock.TaskRepo.Add(u3t1)
+	apiMock.TaskRepo.Add(ctx, u3t1)
 	u3Perms := []auth.Permission{auth.PermReadTask}
 	u3Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e3", Permissions: u3Perms}, api)
 
@@ -227,7 +260,7 @@ func listTasks(t *testing.T, apiMock test.MockAPI) {
 			name:    "u3 should return list with 1 task",
 			h:       u3Api,
 			args:    args{method: "GET", url: "/api/v1/task/"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"u3 task1","description":"u3t1 description","completedTime":null,"createdTime":"%v"}}`, nowStr))},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"u3 task1","description":"u3t1 description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}}`, nowStr))},
 		},
 	}
 	for _, tt := range tests {
@@ -252,15 +285,17 @@ func listTasks(t *testing.T, apiMock test.MockAPI) {
 }
 
 func addTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1 := user.New("test user for addTask")
-	apiMock.UserRepo.AddExternal(u1, "p1", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
 	u1Perms := []auth.Permission{auth.PermUpsertTask}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
 
 	u2 := user.New("test user for addTask, no perms")
-	apiMock.UserRepo.AddExternal(u2, "p1", "e2")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
 	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
 
 	type args struct {
@@ -337,17 +372,19 @@ func addTask(t *testing.T, apiMock test.MockAPI) {
 	}
 }
 func getTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
 	defer reset()
 
 	u1 := user.New("test user for getTask")
-	apiMock.UserRepo.AddExternal(u1, "p1", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
 	u1Perms := []auth.Permission{auth.PermReadTask}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
 	u1t1 := task.New("u1 task1", "u1t1 task description", u1.ID())
-	apiMock.TaskRepo.Add(u1t1)
+	apiMock.TaskRepo.Add(ctx, u1t1)
 
 	type args struct {
 		method string
@@ -381,7 +418,7 @@ func getTask(t *testing.T, apiMock test.MockAPI) {
 			name:    "should return valid task",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/task/1"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"u1 task1","description":"u1t1 task description","completedTime":null,"createdTime":"%v"}`, nowStr))},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"u1 task1","description":"u1t1 task description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}`, nowStr))},
 		},
 	}
 	for _, tt := range tests {
@@ -406,20 +443,22 @@ func getTask(t *testing.T, apiMock test.MockAPI) {
 }
 
 func completeTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1 := user.New("test user for completeTask")
-	apiMock.UserRepo.AddExternal(u1, "p1", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
 	u1Perms := []auth.Permission{auth.PermUpsertTask}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
 	u1t1 := task.New("u1t1 task", "", u1.ID())
-	apiMock.TaskRepo.Add(u1t1)
+	apiMock.TaskRepo.Add(ctx, u1t1)
 
 	u2 := user.New("test user for completeTask, no perms")
-	apiMock.UserRepo.AddExternal(u2, "p1", "e2")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
 	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
 	u2t1 := task.New("u2t1 task", "", u2.ID())
-	apiMock.TaskRepo.Add(u2t1)
+	apiMock.TaskRepo.Add(ctx, u2t1)
 
 	type args struct {
 		method string
@@ -490,20 +529,22 @@ func completeTask(t *testing.T, apiMock test.MockAPI) {
 }
 
 func clearTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1 := user.New("test user for clearTask")
-	apiMock.UserRepo.AddExternal(u1, "p1", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
 	u1Perms := []auth.Permission{auth.PermDeleteTask}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
 	u1t1 := task.New("u1t1 task", "", u1.ID())
-	apiMock.TaskRepo.Add(u1t1)
+	apiMock.TaskRepo.Add(ctx, u1t1)
 
 	u2 := user.New("test user for clearTask, no perms")
-	apiMock.UserRepo.AddExternal(u2, "p1", "e2")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
 	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
 	u2t1 := task.New("u2t1 task", "", u2.ID())
-	apiMock.TaskRepo.Add(u2t1)
+	apiMock.TaskRepo.Add(ctx, u2t1)
 
 	type args struct {
 		method string
@@ -574,28 +615,30 @@ func clearTask(t *testing.T, apiMock test.MockAPI) {
 }
 
 func clearCompletedTasks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1 := user.New("test user 1 for clearTask")
-	apiMock.UserRepo.AddExternal(u1, "p1", "e1")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
 	u1Perms := []auth.Permission{auth.PermDeleteTask}
 	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
 	u1t1 := task.New("u1t1 task", "", u1.ID())
 	u1t1.CompleteNow()
 	u1t2 := task.New("u1t2 task", "", u1.ID())
 	u1t2.CompleteNow()
-	apiMock.TaskRepo.Add(u1t1)
-	apiMock.TaskRepo.Add(u1t2)
+	apiMock.TaskRepo.Add(ctx, u1t1)
+	apiMock.TaskRepo.Add(ctx, u1t2)
 
 	u2 := user.New("test user 2 for clearTask, no perms")
-	apiMock.UserRepo.AddExternal(u2, "p1", "e2")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
 	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
 	u2t1 := task.New("u2t1 task", "", u2.ID())
 	u2t1.CompleteNow()
-	apiMock.TaskRepo.Add(u2t1)
+	apiMock.TaskRepo.Add(ctx, u2t1)
 
 	u3 := user.New("test user 3 for clearTask")
-	apiMock.UserRepo.AddExternal(u3, "p1", "e3")
+	apiMock.UserRepo.AddExternal(ctx, u3, "p1", "e3")
 	u3Perms := []auth.Permission{auth.PermDeleteTask}
 	u3Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e3", Permissions: u3Perms}, api)
 
@@ -662,6 +705,8 @@ func clearCompletedTasks(t *testing.T, apiMock test.MockAPI) {
 }
 
 func listSchedules(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	_, u1Api := apiMock.NewUserWithPerm("test user for listSchedules", "p1", "e1", auth.PermReadSchedule)
@@ -669,12 +714,12 @@ func listSchedules(t *testing.T, apiMock test.MockAPI) {
 	u2, u2Api := apiMock.NewUserWithPerm("test user for listSchedules, no perms", "p1", "e2", auth.PermNone)
 	u2f1, _ := schedule.NewHourFrequency([]int{0})
 	u2s1 := schedule.New(u2f1, u2.ID())
-	apiMock.ScheduleRepo.Add(u2s1)
+	apiMock.ScheduleRepo.Add(ctx, u2s1)
 
 	u3, u3Api := apiMock.NewUserWithPerm("test user for listSchedules, with tasks", "p1", "e3", auth.PermReadSchedule)
 	u3f1, _ := schedule.NewHourFrequency([]int{0, 30})
 	u3s1 := schedule.New(u3f1, u3.ID())
-	apiMock.ScheduleRepo.Add(u3s1)
+	apiMock.ScheduleRepo.Add(ctx, u3s1)
 
 	type args struct {
 		method string
@@ -837,17 +882,19 @@ func addSchedule(t *testing.T, apiMock test.MockAPI) {
 }
 
 func getSchedule(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1, u1Api := apiMock.NewUserWithPerm("test user for getSchedule", "p1", "e1", auth.PermReadSchedule)
 	u1f1, _ := schedule.NewHourFrequency([]int{0})
 	u1s1 := schedule.New(u1f1, u1.ID())
-	apiMock.ScheduleRepo.Add(u1s1)
+	apiMock.ScheduleRepo.Add(ctx, u1s1)
 
 	u2, u2Api := apiMock.NewUserWithPerm("test user for getSchedule, no perms", "p1", "e2", auth.PermNone)
 	u2f1, _ := schedule.NewHourFrequency([]int{0})
 	u2s1 := schedule.New(u2f1, u2.ID())
-	apiMock.ScheduleRepo.Add(u2s1)
+	apiMock.ScheduleRepo.Add(ctx, u2s1)
 
 	type args struct {
 		method string
@@ -918,17 +965,19 @@ func getSchedule(t *testing.T, apiMock test.MockAPI) {
 }
 
 func removeSchedule(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1, u1Api := apiMock.NewUserWithPerm("test user for removeSchedule", "p1", "e1", auth.PermDeleteSchedule)
 	u1f1, _ := schedule.NewHourFrequency([]int{0})
 	u1s1 := schedule.New(u1f1, u1.ID())
-	apiMock.ScheduleRepo.Add(u1s1)
+	apiMock.ScheduleRepo.Add(ctx, u1s1)
 
 	u2, u2Api := apiMock.NewUserWithPerm("test user for removeSchedule, no perms", "p1", "e2", auth.PermNone)
 	u2f1, _ := schedule.NewHourFrequency([]int{0})
 	u2s1 := schedule.New(u2f1, u2.ID())
-	apiMock.ScheduleRepo.Add(u2s1)
+	apiMock.ScheduleRepo.Add(ctx, u2s1)
 
 	type args struct {
 		method string
@@ -999,17 +1048,19 @@ func removeSchedule(t *testing.T, apiMock test.MockAPI) {
 }
 
 func pauseSchedule(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1, u1Api := apiMock.NewUserWithPerm("test user for removeSchedule", "p1", "e1", auth.PermUpsertSchedule)
 	u1f1, _ := schedule.NewHourFrequency([]int{0})
 	u1s1 := schedule.New(u1f1, u1.ID())
-	apiMock.ScheduleRepo.Add(u1s1)
+	apiMock.ScheduleRepo.Add(ctx, u1s1)
 
 	u2, u2Api := apiMock.NewUserWithPerm("test user for removeSchedule, no perms", "p1", "e2", auth.PermNone)
 	u2f1, _ := schedule.NewHourFrequency([]int{0})
 	u2s1 := schedule.New(u2f1, u2.ID())
-	apiMock.ScheduleRepo.Add(u2s1)
+	apiMock.ScheduleRepo.Add(ctx, u2s1)
 
 	type args struct {
 		method string
@@ -1080,17 +1131,19 @@ func pauseSchedule(t *testing.T, apiMock test.MockAPI) {
 }
 
 func unpauseSchedule(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
 	u1, u1Api := apiMock.NewUserWithPerm("test user for removeSchedule", "p1", "e1", auth.PermUpsertSchedule)
 	u1f1, _ := schedule.NewHourFrequency([]int{0})
 	u1s1 := schedule.New(u1f1, u1.ID())
-	apiMock.ScheduleRepo.Add(u1s1)
+	apiMock.ScheduleRepo.Add(ctx, u1s1)
 
 	u2, u2Api := apiMock.NewUserWithPerm("test user for removeSchedule, no perms", "p1", "e2", auth.PermNone)
 	u2f1, _ := schedule.NewHourFrequency([]int{0})
 	u2s1 := schedule.New(u2f1, u2.ID())
-	apiMock.ScheduleRepo.Add(u2s1)
+	apiMock.ScheduleRepo.Add(ctx, u2s1)
 
 	type args struct {
 		method string
@@ -1160,18 +1213,18 @@ func unpauseSchedule(t *testing.T, apiMock test.MockAPI) {
 	}
 }
 
-func addRecurringTask(t *testing.T, apiMock test.MockAPI) {
+func scheduleChat(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	api := apiMock.API
 
-	u1, u1Api := apiMock.NewUserWithPerm("test user for removeSchedule", "p1", "e1", auth.PermUpsertSchedule)
+	u1, u1Api := apiMock.NewUserWithPerms("test user for scheduleChat", "p1", "e1", []auth.Permission{auth.PermUpsertSchedule, auth.PermReadSchedule})
 	u1f1, _ := schedule.NewHourFrequency([]int{0})
-	u1s1 := schedule.New(u1f1, u1.ID())
-	apiMock.ScheduleRepo.Add(u1s1)
+	apiMock.ScheduleRepo.Add(ctx, schedule.New(u1f1, u1.ID()))
 
-	u2, u2Api := apiMock.NewUserWithPerm("test user for removeSchedule, no perms", "p1", "e2", auth.PermNone)
+	u2, _ := apiMock.NewUserWithPerm("test user for scheduleChat, other user", "p1", "e2", auth.PermUpsertSchedule)
 	u2f1, _ := schedule.NewHourFrequency([]int{0})
-	u2s1 := schedule.New(u2f1, u2.ID())
-	apiMock.ScheduleRepo.Add(u2s1)
+	apiMock.ScheduleRepo.Add(ctx, schedule.New(u2f1, u2.ID()))
 
 	type args struct {
 		method string
@@ -1192,44 +1245,316 @@ func addRecurringTask(t *testing.T, apiMock test.MockAPI) {
 		{
 			name:    "no auth should return 401",
 			h:       api,
-			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			args:    args{method: "PUT", url: "/api/v1/schedule/1/chat", body: `{"url":"https://hooks.slack.com/services/T0/B0/x"}`},
 			asserts: asserts{statusEquals: http.StatusUnauthorized},
 		},
 		{
-			name:    "valid schedule ID should return 201",
+			name:    "valid URL should return 204",
 			h:       u1Api,
-			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name": "t1", "description": "t1 desc"}`},
-			asserts: asserts{statusEquals: http.StatusCreated},
+			args:    args{method: "PUT", url: "/api/v1/schedule/1/chat", body: `{"url":"https://hooks.slack.com/services/T0/B0/x"}`},
+			asserts: asserts{statusEquals: http.StatusNoContent},
 		},
 		{
-			name:    "valid schedule ID with empty task body should return 201",
+			name:    "schedule should include its chat URL",
 			h:       u1Api,
-			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{}`},
-			asserts: asserts{statusEquals: http.StatusCreated},
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0],"paused":false,"chatUrl":"https://hooks.slack.com/services/T0/B0/x","tasks":[]}`)},
 		},
 		{
-			name:    "valid schedule ID with null body should return 400",
+			name:    "relative URL should return 400",
 			h:       u1Api,
-			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: ``},
-			asserts: asserts{statusEquals: http.StatusBadRequest},
+			args:    args{method: "PUT", url: "/api/v1/schedule/1/chat", body: `{"url":"/services/T0/B0/x"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`must be an absolute http or https URL`)},
+		},
+		{
+			name:    "invalid JSON should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/schedule/1/chat", body: `{"url":1}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`could not parse chat data`)},
 		},
 		{
 			name:    "other user's schedule should return 404",
 			h:       u1Api,
-			args:    args{method: "POST", url: "/api/v1/schedule/2/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			args:    args{method: "PUT", url: "/api/v1/schedule/2/chat", body: `{"url":"https://hooks.slack.com/services/T0/B0/x"}`},
 			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Schedule ID 2 not found`)},
 		},
 		{
-			name:    "user without permissions should return 401",
-			h:       u2Api,
-			args:    args{method: "POST", url: "/api/v1/schedule/2/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			name:    "empty URL should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/schedule/1/chat", body: `{"url":""}`},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "schedule should no longer include a chat URL",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0],"paused":false,"tasks":[]}`)},
+		},
+		{
+			name:    "adding a schedule with a chat URL should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"chatUrl":"https://mattermost.example.com/hooks/abc"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":3}`)},
+		},
+		{
+			name:    "added schedule should include its chat URL",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/3"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"chatUrl":"https://mattermost.example.com/hooks/abc"`)},
+		},
+		{
+			name:    "adding a schedule with an invalid chat URL should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"chatUrl":"ftp://example.com/hooks/abc"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`could not parse schedule data`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func scheduleActions(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	nowStr, resetClock := test.SetStaticClock(now)
+	defer resetClock()
+
+	api := apiMock.API
+
+	u1, u1Api := apiMock.NewUserWithRole("test user for scheduleActions", "p1", "e1", user.RoleAdmin, []auth.Permission{auth.PermUpsertSchedule, auth.PermReadSchedule, auth.PermReadTask, auth.PermManageActions})
+	u2, _ := apiMock.NewUserWithPerm("test user for scheduleActions, other user", "p1", "e2", auth.PermReadTask)
+	// Members' tokens can't grant them the action permission, since it isn't one of their role's permissions
+	_, memberApi := apiMock.NewUserWithPerms("test user for scheduleActions, member", "p1", "e3", []auth.Permission{auth.PermUpsertSchedule, auth.PermManageActions})
+
+	t1 := task.New("warm cache", "", u1.ID())
+	c := action.NewCall(action.NewRequest("PUT", "https://example.com/warm", nil, `{"task":"warm cache"}`), 0, 0, true)
+	t1.SetAction(c)
+	apiMock.TaskRepo.Add(ctx, t1)
+	apiMock.TaskRepo.Add(ctx, task.New("manual task", "", u1.ID()))
+	apiMock.TaskRepo.Add(ctx, task.New("other user's task", "", u2.ID()))
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "adding a schedule with a task action should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"tasks":[{"name":"warm cache","action":{"method":"put","url":"https://example.com/warm","headers":{"X-Token":"secret"},"body":"{\"task\":\"{{.Name}}\"}","timeoutSeconds":10,"maxAttempts":3,"completeTask":true}}]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "adding a schedule with a task action without the manage:actions permission should return 403",
+			h:       memberApi,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"tasks":[{"name":"metadata","action":{"method":"GET","url":"http://169.254.169.254/"}}]}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`the manage:actions permission is required`)},
+		},
+		{
+			name:    "adding a recurring task action without the manage:actions permission should return 403",
+			h:       memberApi,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name":"metadata","action":{"method":"GET","url":"http://169.254.169.254/"}}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`the manage:actions permission is required`)},
+		},
+		{
+			name:    "schedule should include its task's action, with header values redacted",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"action":{"method":"PUT","url":"https://example.com/warm","headers":{"X-Token":"[redacted]"},"body":"{\"task\":\"{{.Name}}\"}","timeoutSeconds":10,"maxAttempts":3,"completeTask":true}`)},
+		},
+		{
+			name:    "adding a task action without a URL should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name":"warm cache","action":{"method":"POST"}}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest},
+		},
+		{
+			name:    "adding a task action with an invalid body template should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name":"warm cache","action":{"url":"https://example.com/warm","body":"{{.Unknown}}"}}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest},
+		},
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{method: "GET", url: "/api/v1/task/1/action"},
 			asserts: asserts{statusEquals: http.StatusUnauthorized},
 		},
 		{
-			name:    "unknown ID should return 404",
+			name:    "task with an action should return its call",
 			h:       u1Api,
-			args:    args{method: "POST", url: "/api/v1/schedule/9999/task/", body: `{"name": "t1", "description": "t1 desc"}`},
-			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Schedule ID 9999 not found`)},
+			args:    args{method: "GET", url: "/api/v1/task/1/action"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":"%v","method":"PUT","url":"https://example.com/warm","status":"pending","attempts":0,"statusCode":0,"response":"","latencyMs":0,"lastError":"","createdTime":"%v","lastAttemptTime":null,"nextAttemptTime":"%v"}`, c.ID(), nowStr, nowStr))},
+		},
+		{
+			name:    "task without an action should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/2/action"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`No action found for task ID 2`)},
+		},
+		{
+			name:    "other user's task should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/3/action"},
+			asserts: asserts{statusEquals: http.StatusNotFound},
+		},
+		{
+			name:    "invalid task ID should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/abc/action"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`valid task ID required`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func scheduleCommands(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	nowStr, resetClock := test.SetStaticClock(now)
+	defer resetClock()
+
+	api := apiMock.API
+
+	u1, u1Api := apiMock.NewUserWithRole("test user for scheduleCommands", "p1", "e1", user.RoleAdmin, []auth.Permission{auth.PermUpsertSchedule, auth.PermReadSchedule, auth.PermReadTask, auth.PermManageCommands})
+	u2, _ := apiMock.NewUserWithPerm("test user for scheduleCommands, other user", "p1", "e2", auth.PermReadTask)
+	// Members' tokens can't grant them the command permission, since it isn't one of their role's permissions
+	_, memberApi := apiMock.NewUserWithPerms("test user for scheduleCommands, member", "p1", "e3", []auth.Permission{auth.PermUpsertSchedule, auth.PermManageCommands})
+
+	t1 := task.New("backup", "", u1.ID())
+	run := command.NewRun("backup.sh", []string{"--all"}, time.Minute)
+	t1.SetCommand(run)
+	apiMock.TaskRepo.Add(ctx, t1)
+	apiMock.TaskRepo.Add(ctx, task.New("manual task", "", u1.ID()))
+	apiMock.TaskRepo.Add(ctx, task.New("other user's task", "", u2.ID()))
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "adding a schedule with a task command should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"tasks":[{"name":"backup","command":{"program":"backup.sh","args":["--all"],"timeoutSeconds":60}}]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "adding a schedule with a task command without the manage:commands permission should return 403",
+			h:       memberApi,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"tasks":[{"name":"backup","command":{"program":"sh","args":["-c","id"]}}]}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`the manage:commands permission is required`)},
+		},
+		{
+			name:    "adding a recurring task command without the manage:commands permission should return 403",
+			h:       memberApi,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name":"backup","command":{"program":"sh","args":["-c","id"]}}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`the manage:commands permission is required`)},
+		},
+		{
+			name:    "schedule should include its task's command",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"command":{"program":"backup.sh","args":["--all"],"timeoutSeconds":60}`)},
+		},
+		{
+			name:    "adding a task command without a program should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name":"backup","command":{"args":["--all"]}}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest},
+		},
+		{
+			name:    "adding a task command with a shell command line as the program should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name":"backup","command":{"program":"backup.sh --all"}}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest},
+		},
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{method: "GET", url: "/api/v1/task/1/command"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "task with a command should return its run",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1/command"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":"%v","program":"backup.sh","args":["--all"],"status":"pending","exitCode":0,"stdout":"","stderr":"","lastError":"","durationMs":0,"createdTime":"%v","startedTime":null,"finishedTime":null}`, run.ID(), nowStr))},
+		},
+		{
+			name:    "task without a command should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/2/command"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`No command found for task ID 2`)},
+		},
+		{
+			name:    "other user's task should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/3/command"},
+			asserts: asserts{statusEquals: http.StatusNotFound},
+		},
+		{
+			name:    "invalid task ID should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/abc/command"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`valid task ID required`)},
 		},
 	}
 	for _, tt := range tests {
@@ -1252,3 +1577,2264 @@ func addRecurringTask(t *testing.T, apiMock test.MockAPI) {
 		})
 	}
 }
+
+func scheduleRuns(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+	now := time.Date(2000, 1, 1, 12, 30, 0, 0, time.UTC)
+	nowStr, resetClock := test.SetStaticClock(now)
+	defer resetClock()
+
+	api := apiMock.API
+
+	u1, u1Api := apiMock.NewUserWithPerm("test user for scheduleRuns", "p1", "e1", auth.PermReadSchedule)
+	u2, _ := apiMock.NewUserWithPerm("test user for scheduleRuns, other user", "p1", "e2", auth.PermReadSchedule)
+
+	// Checking the schedule finds occurrences at 11:00 and 12:00, recording a run for each and one for the check
+	f, _ := schedule.NewHourFrequency([]int{0})
+	apiMock.ScheduleRepo.Add(ctx, schedule.NewRaw(f, false, time.Date(2000, 1, 1, 10, 30, 0, 0, time.UTC), []schedule.RecurringTask{schedule.NewRecurringTask("t1", "")}, time.Time{}, u1.ID()))
+	if _, _, err := usecase.CheckSchedules(ctx, apiMock.TaskRepo, apiMock.ActivityRepo, apiMock.ScheduleRepo, apiMock.ScheduleRunRepo); err != nil {
+		t.Fatalf("CheckSchedules() error = %v", err)
+	}
+	apiMock.ScheduleRepo.Add(ctx, schedule.New(f, u2.ID()))
+
+	checkRun := fmt.Sprintf(`{"id":3,"type":"check","scheduledTime":"%v","processedTime":"%v","lagMs":0,"tasksCreated":2,"error":""}`, nowStr, nowStr)
+	noonRun := fmt.Sprintf(`{"id":2,"type":"occurrence","scheduledTime":"2000-01-01T12:00:00Z","processedTime":"%v","lagMs":1800000,"tasksCreated":1,"error":""}`, nowStr)
+	elevenRun := fmt.Sprintf(`{"id":1,"type":"occurrence","scheduledTime":"2000-01-01T11:00:00Z","processedTime":"%v","lagMs":5400000,"tasksCreated":1,"error":""}`, nowStr)
+
+	type args struct {
+		url string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{url: "/api/v1/schedule/1/runs"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "schedule runs should be listed newest first",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/1/runs"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"runs":[%v,%v,%v],"total":3,"limit":50,"offset":0}`, checkRun, noonRun, elevenRun))},
+		},
+		{
+			name:    "limit should return the first page",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/1/runs?limit=2"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"runs":[%v,%v],"total":3,"limit":2,"offset":0}`, checkRun, noonRun))},
+		},
+		{
+			name:    "offset should return the next page",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/1/runs?limit=2&offset=2"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"runs":[%v],"total":3,"limit":2,"offset":2}`, elevenRun))},
+		},
+		{
+			name:    "offset past the last run should return an empty page",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/1/runs?offset=10"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"runs":[],"total":3,"limit":50,"offset":10}`)},
+		},
+		{
+			name:    "non-numeric limit should return 400",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/1/runs?limit=all"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`limit must be a number`)},
+		},
+		{
+			name:    "limit over the maximum should return 400",
+			h:       u1Api,
+			args:    args{url: fmt.Sprintf("/api/v1/schedule/1/runs?limit=%d", usecase.MaxPageLimit+1)},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid page`)},
+		},
+		{
+			name:    "negative offset should return 400",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/1/runs?offset=-1"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid page`)},
+		},
+		{
+			name:    "other user's schedule should return 404",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/2/runs"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Schedule ID 2 not found`)},
+		},
+		{
+			name:    "invalid schedule ID should return 404",
+			h:       u1Api,
+			args:    args{url: "/api/v1/schedule/abc/runs"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`valid schedule ID required`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.args.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func addRecurringTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	u1, u1Api := apiMock.NewUserWithPerm("test user for removeSchedule", "p1", "e1", auth.PermUpsertSchedule)
+	u1f1, _ := schedule.NewHourFrequency([]int{0})
+	u1s1 := schedule.New(u1f1, u1.ID())
+	apiMock.ScheduleRepo.Add(ctx, u1s1)
+
+	u2, u2Api := apiMock.NewUserWithPerm("test user for removeSchedule, no perms", "p1", "e2", auth.PermNone)
+	u2f1, _ := schedule.NewHourFrequency([]int{0})
+	u2s1 := schedule.New(u2f1, u2.ID())
+	apiMock.ScheduleRepo.Add(ctx, u2s1)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "valid schedule ID should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			asserts: asserts{statusEquals: http.StatusCreated},
+		},
+		{
+			name:    "valid schedule ID with empty task body should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: `{}`},
+			asserts: asserts{statusEquals: http.StatusCreated},
+		},
+		{
+			name:    "valid schedule ID with null body should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/1/task/", body: ``},
+			asserts: asserts{statusEquals: http.StatusBadRequest},
+		},
+		{
+			name:    "other user's schedule should return 404",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/2/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Schedule ID 2 not found`)},
+		},
+		{
+			name:    "user without permissions should return 401",
+			h:       u2Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/2/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "unknown ID should return 404",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/9999/task/", body: `{"name": "t1", "description": "t1 desc"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Schedule ID 9999 not found`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func search(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	u1 := user.New("test user for search")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermReadTask, auth.PermReadSchedule}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+	apiMock.TaskRepo.Add(ctx, task.New("water the garden", "", u1.ID()))
+	f, _ := schedule.NewDayFrequency([]int{0}, []int{8})
+	s1 := schedule.New(f, u1.ID())
+	s1.AddTask(schedule.NewRecurringTask("daily garden check", "look for weeds"))
+	apiMock.ScheduleRepo.Add(ctx, s1)
+
+	u2 := user.New("test user for search, task perms only")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
+	u2Perms := []auth.Permission{auth.PermReadTask}
+	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2", Permissions: u2Perms}, api)
+	apiMock.TaskRepo.Add(ctx, task.New("garden party", "", u2.ID()))
+	s2 := schedule.New(f, u2.ID())
+	s2.AddTask(schedule.NewRecurringTask("garden rota", ""))
+	apiMock.ScheduleRepo.Add(ctx, s2)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{method: "GET", url: "/api/v1/search/?q=garden"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "missing query should return 400",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/search/"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`search query parameter 'q' required`)},
+		},
+		{
+			name:    "query with no matches should return 200 and an empty result list",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/search/?q=zebra"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"query":"zebra","results":[]}`)},
+		},
+		{
+			name:    "should return matching tasks and recurring tasks",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/search/?q=garden"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"type":"recurringTask","scheduleId":1,"name":"daily garden check"`)},
+		},
+		{
+			name:    "should return only tasks without schedule read permission",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/search/?q=garden"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"results":[{"type":"task","taskId":2,"name":"garden party"`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func updateTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	u1 := user.New("test user for updateTask")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermUpsertTask}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+	apiMock.TaskRepo.Add(ctx, task.New("u1t1 task", "u1t1 description", u1.ID()))
+
+	u2 := user.New("test user for updateTask, no perms")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
+	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
+	apiMock.TaskRepo.Add(ctx, task.New("u2t1 task", "", u2.ID()))
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{method: "PATCH", url: "/api/v1/task/1", body: `{"name":"renamed"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "unknown task ID should return 404",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/9999", body: `{"name":"renamed"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 9999 not found`)},
+		},
+		{
+			name:    "invalid JSON should return 400",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/1", body: `{"name":`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`could not parse task data`)},
+		},
+		{
+			name:    "name that is too long should return 400",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/1", body: fmt.Sprintf(`{"name":"%v"}`, strings.Repeat("n", task.MaxNameLength+1))},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid task data`)},
+		},
+		{
+			name:    "valid name change should return 200 and the updated task",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/1", body: `{"name":"renamed"}`},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"renamed","description":"u1t1 description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}`, nowStr))},
+		},
+		{
+			name:    "valid description change should return 200 and the updated task",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/1", body: `{"description":"new description"}`},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"renamed","description":"new description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}`, nowStr))},
+		},
+		{
+			name:    "valid task ID without proper permissions should return 401",
+			h:       u2Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/2", body: `{"name":"renamed"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "valid task ID owned by another user should return 404",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/2", body: `{"name":"renamed"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 2 not found`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func uncompleteTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	u1 := user.New("test user for uncompleteTask")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermUpsertTask}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+	u1t1 := task.New("u1t1 task", "", u1.ID())
+	u1t1.CompleteNow()
+	apiMock.TaskRepo.Add(ctx, u1t1)
+
+	u2 := user.New("test user for uncompleteTask, no perms")
+	apiMock.UserRepo.AddExternal(ctx, u2, "p1", "e2")
+	u2Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e2"}, api)
+	u2t1 := task.New("u2t1 task", "", u2.ID())
+	u2t1.CompleteNow()
+	apiMock.TaskRepo.Add(ctx, u2t1)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "no auth should return 401",
+			h:       api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/uncomplete"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "unknown task ID should return 404",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/9999/uncomplete"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 9999 not found`)},
+		},
+		{
+			name:    "completed task ID should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/uncomplete"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "incomplete task ID should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/uncomplete"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`Task 1 is not completed`)},
+		},
+		{
+			name:    "valid task ID without proper permissions should return 401",
+			h:       u2Api,
+			args:    args{method: "PUT", url: "/api/v1/task/2/uncomplete"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "valid task ID owned by another user should return 404",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/2/uncomplete"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 2 not found`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func prioritizeTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	u1 := user.New("test user for prioritizeTask")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermReadTask, auth.PermUpsertTask}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "invalid priority should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"bad priority","priority":"urgent"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`unknown priority 'urgent'`)},
+		},
+		{
+			name:    "invalid due time should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"bad due time","dueTime":"tomorrow"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid time 'tomorrow'`)},
+		},
+		{
+			name:    "task with past due time should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"overdue","dueTime":"2000-01-01T11:00:00Z","priority":"low"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "task with future due time should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"upcoming","dueTime":"2000-01-02T00:00:00Z","priority":"high"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":2}`)},
+		},
+		{
+			name:    "overdue filter should return only the overdue task",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?overdue=true"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"overdue","description":"","completedTime":null,"createdTime":"%v","dueTime":"2000-01-01T11:00:00Z","priority":"low","overdue":true,"tags":[],"checklist":[],"autoComplete":false}}`, nowStr))},
+		},
+		{
+			name:    "priority sort should return ordered list with highest priority first",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?sort=priority"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`[{"id":2,"name":"upcoming","description":"","completedTime":null,"createdTime":"%v","dueTime":"2000-01-02T00:00:00Z","priority":"high","overdue":false,"tags":[],"checklist":[],"autoComplete":false},{"id":1,"name":"overdue","description":"","completedTime":null,"createdTime":"%v","dueTime":"2000-01-01T11:00:00Z","priority":"low","overdue":true,"tags":[],"checklist":[],"autoComplete":false}]`, nowStr, nowStr))},
+		},
+		{
+			name:    "due sort should return ordered list with earliest due time first",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?sort=due"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`[{"id":1,"name":"overdue"`)},
+		},
+		{
+			name:    "unknown sort should return 400",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?sort=name"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid sort 'name'`)},
+		},
+		{
+			name:    "null due time and new priority should update the task",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/task/1", body: `{"dueTime":null,"priority":"medium"}`},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"overdue","description":"","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"medium","overdue":false,"tags":[],"checklist":[],"autoComplete":false}`, nowStr))},
+		},
+		{
+			name:    "overdue filter should return no tasks after due time is removed",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?overdue=true"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func tagTasks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	u1 := user.New("test user for tagTasks")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermReadTask, auth.PermUpsertTask, auth.PermReadSchedule, auth.PermUpsertSchedule}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "invalid tag should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"bad tag","tags":["bad tag"]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`can only contain letters, digits`)},
+		},
+		{
+			name:    "task with tags should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"task1","tags":["ops","Infra"]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "second task with tag should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"task2","tags":["infra"]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":2}`)},
+		},
+		{
+			name:    "tagging a task should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/2/tag/billing"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "tagging an unknown task should return 404",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/9999/tag/billing"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 9999 not found`)},
+		},
+		{
+			name:    "tag filter should return tasks with all tags",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?tag=infra&tag=ops"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"task1","description":"","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":["infra","ops"],"checklist":[],"autoComplete":false}}`, nowStr))},
+		},
+		{
+			name:    "new schedule with tagged recurring task should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"tasks":[{"name":"rtask1","tags":["ops"]}]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "schedule tag filter should return schedules with a matching recurring task",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/?tag=ops"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"1":{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0],"paused":false,"tasks":[{"name":"rtask1","description":"","priority":"none","tags":["ops"],"checklist":[],"autoComplete":false}]}}`)},
+		},
+		{
+			name:    "schedule tag filter should not return schedules without a matching recurring task",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/?tag=billing"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{}`)},
+		},
+		{
+			name:    "tag list should return tags with task and recurring task counts",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/tag/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`[{"name":"billing","taskCount":1,"recurringTaskCount":0},{"name":"infra","taskCount":2,"recurringTaskCount":0},{"name":"ops","taskCount":1,"recurringTaskCount":1}]`)},
+		},
+		{
+			name:    "renaming a tag should return 200 and the number of tasks changed",
+			h:       u1Api,
+			args:    args{method: "PATCH", url: "/api/v1/tag/ops", body: `{"name":"On-Call"}`},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"count":2}`)},
+		},
+		{
+			name:    "untagging a task should return 204",
+			h:       u1Api,
+			args:    args{method: "DELETE", url: "/api/v1/task/2/tag/billing"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "untagging a task without the tag should return 404",
+			h:       u1Api,
+			args:    args{method: "DELETE", url: "/api/v1/task/2/tag/billing"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task 2 does not have tag 'billing'`)},
+		},
+		{
+			name:    "deleting a tag should return 200 and the number of tasks changed",
+			h:       u1Api,
+			args:    args{method: "DELETE", url: "/api/v1/tag/infra"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"count":2}`)},
+		},
+		{
+			name:    "deleting an unused tag should return 404",
+			h:       u1Api,
+			args:    args{method: "DELETE", url: "/api/v1/tag/infra"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Tag 'infra' not found`)},
+		},
+		{
+			name:    "tag list should reflect renamed and deleted tags",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/tag/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`[{"name":"on-call","taskCount":1,"recurringTaskCount":1}]`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func checklistTask(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	u1 := user.New("test user for checklistTask")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermReadTask, auth.PermUpsertTask, auth.PermReadSchedule, auth.PermUpsertSchedule}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "empty checklist item should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"bad task","checklist":["step 1"," "]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`checklist item name cannot be empty`)},
+		},
+		{
+			name:    "task with checklist should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"task1","checklist":["step 1","step 2"],"autoComplete":true}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "checking an item should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/checklist/0/check"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "checking a checked item should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/checklist/0/check"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`already checked`)},
+		},
+		{
+			name:    "checking an unknown item should return 404",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/checklist/2/check"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 1 checklist item 2 not found`)},
+		},
+		{
+			name:    "task should return checked and unchecked items",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[{"name":"step 1","checkedTime":"%v"},{"name":"step 2","checkedTime":null}],"autoComplete":true}`, nowStr, nowStr))},
+		},
+		{
+			name:    "checking the last item should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/checklist/1/check"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "auto-complete task should be completed once all items are checked",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"","completedTime":"%v","createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[{"name":"step 1","checkedTime":"%v"},{"name":"step 2","checkedTime":"%v"}],"autoComplete":true}`, nowStr, nowStr, nowStr, nowStr))},
+		},
+		{
+			name:    "unchecking an item should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/checklist/1/uncheck"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "unchecking an unchecked item should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/checklist/1/uncheck"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`is not checked`)},
+		},
+		{
+			name:    "auto-complete task should be reopened once an item is unchecked",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[{"name":"step 1","checkedTime":"%v"},{"name":"step 2","checkedTime":null}],"autoComplete":true}`, nowStr, nowStr))},
+		},
+		{
+			name:    "new schedule with recurring task checklist should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour","atMinutes":[0],"tasks":[{"name":"rtask1","checklist":["step 1"],"autoComplete":true}]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "schedule should return recurring task checklist",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0],"paused":false,"tasks":[{"name":"rtask1","description":"","priority":"none","tags":[],"checklist":["step 1"],"autoComplete":true}]}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func taskActivity(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	u1 := user.New("test user for taskActivity")
+	apiMock.UserRepo.AddExternal(ctx, u1, "p1", "e1")
+	u1Perms := []auth.Permission{auth.PermReadTask, auth.PermUpsertTask, auth.PermDeleteTask}
+	u1Api := test.InjectClaims(test.MockClaims{Issuer: "p1", Subject: "e1", Permissions: u1Perms}, api)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "new task should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"task1"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "adding a comment should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/1/activity", body: `{"comment":"left a note"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":2}`)},
+		},
+		{
+			name:    "adding an empty comment should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/1/activity", body: `{"comment":" "}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`comment cannot be empty`)},
+		},
+		{
+			name:    "adding a comment to an unknown task should return 404",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/9999/activity", body: `{"comment":"left a note"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 9999 not found`)},
+		},
+		{
+			name:    "completing a task should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/complete"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "task activity should list changes and comments in order",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1/activity"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`[{"id":1,"type":"created","comment":"","createdTime":"%v","createdBy":"%v"},{"id":2,"type":"comment","comment":"left a note","createdTime":"%v","createdBy":"%v"},{"id":3,"type":"completed","comment":"","createdTime":"%v","createdBy":"%v"}]`, nowStr, u1.ID(), nowStr, u1.ID(), nowStr, u1.ID()))},
+		},
+		{
+			name:    "clearing completed tasks should return 200",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/clear"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"count":1,"message":"Cleared all completed tasks"}`)},
+		},
+		{
+			name:    "cleared task activity should still be listed",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1/activity"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`{"id":4,"type":"cleared","comment":"","createdTime":"%v","createdBy":"%v"}]`, nowStr, u1.ID()))},
+		},
+		{
+			name:    "adding a comment to a cleared task should return 404",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/1/activity", body: `{"comment":"left a note"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 1 not found`)},
+		},
+		{
+			name:    "unknown task activity should return 404",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/9999/activity"},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 9999 not found`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func shareWorkspace(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	perms := []auth.Permission{auth.PermReadTask, auth.PermUpsertTask, auth.PermReadWorkspace, auth.PermUpsertWorkspace}
+	u1, u1Api := apiMock.NewUserWithPerms("owner for shareWorkspace", "p1", "e1", perms)
+	u2, u2Api := apiMock.NewUserWithPerms("member for shareWorkspace", "p1", "e2", perms)
+	u3, u3Api := apiMock.NewUserWithPerms("outsider for shareWorkspace", "p1", "e3", perms)
+	w, _ := workspace.New("team", u1.ID())
+	apiMock.WorkspaceRepo.Add(ctx, w)
+	wPre := fmt.Sprintf("/api/v1/workspace/%v", w.ID())
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "new workspace should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/workspace/", body: `{"name":"other team"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyContains: test.Strp(`{"id":"`)},
+		},
+		{
+			name:    "new workspace with empty name should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/workspace/", body: `{"name":" "}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`workspace name cannot be empty`)},
+		},
+		{
+			name:    "owner inviting a member should return 204",
+			h:       u1Api,
+			args:    args{method: "POST", url: wPre + "/member", body: fmt.Sprintf(`{"userId":"%v","role":"member"}`, u2.ID())},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "owner inviting an existing member should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: wPre + "/member", body: fmt.Sprintf(`{"userId":"%v"}`, u2.ID())},
+			asserts: asserts{statusEquals: http.StatusBadRequest},
+		},
+		{
+			name:    "member inviting a user should return 403",
+			h:       u2Api,
+			args:    args{method: "POST", url: wPre + "/member", body: fmt.Sprintf(`{"userId":"%v"}`, u3.ID())},
+			asserts: asserts{statusEquals: http.StatusForbidden},
+		},
+		{
+			name:    "member should get workspace",
+			h:       u2Api,
+			args:    args{method: "GET", url: wPre},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":"%v","name":"team","createdTime":"%v","createdBy":"%v","members":[{"userId":"%v","role":"owner"},{"userId":"%v","role":"member"}]}`, w.ID(), nowStr, u1.ID(), u1.ID(), u2.ID()))},
+		},
+		{
+			name:    "member should only list workspaces they belong to",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/workspace/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`[{"id":"%v","name":"team"`, w.ID()))},
+		},
+		{
+			name:    "non-member getting workspace should return 404",
+			h:       u3Api,
+			args:    args{method: "GET", url: wPre},
+			asserts: asserts{statusEquals: http.StatusNotFound},
+		},
+		{
+			name:    "new task in workspace should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: fmt.Sprintf(`{"name":"shared task","workspaceId":"%v"}`, w.ID())},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "non-member adding task to workspace should return 400",
+			h:       u3Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: fmt.Sprintf(`{"name":"shared task","workspaceId":"%v"}`, w.ID())},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(fmt.Sprintf(`workspace ID %v not found`, w.ID()))},
+		},
+		{
+			name:    "member should get shared task",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`{"id":1,"workspaceId":"%v","name":"shared task"`, w.ID()))},
+		},
+		{
+			name:    "member completing shared task should return 204",
+			h:       u2Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/complete"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "non-member getting shared task should return 404",
+			h:       u3Api,
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func assignTasks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	_, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	perms := []auth.Permission{auth.PermReadTask, auth.PermUpsertTask, auth.PermReadSchedule, auth.PermUpsertSchedule}
+	u1, u1Api := apiMock.NewUserWithPerms("owner for assignTasks", "p1", "e1", perms)
+	u2, u2Api := apiMock.NewUserWithPerms("member for assignTasks", "p1", "e2", perms)
+	u3, _ := apiMock.NewUserWithPerms("outsider for assignTasks", "p1", "e3", perms)
+	w, _ := workspace.New("team", u1.ID())
+	w.AddMember(u1.ID(), u2.ID(), workspace.RoleMember)
+	apiMock.WorkspaceRepo.Add(ctx, w)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "new shared task should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: fmt.Sprintf(`{"name":"shared task","workspaceId":"%v"}`, w.ID())},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "assigning task to a workspace member should return 204",
+			h:       u1Api,
+			args:    args{method: "PUT", url: fmt.Sprintf("/api/v1/task/1/assignee/%v", u2.ID())},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "assigned task should include assignee",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`"workspaceId":"%v","assignee":"%v"`, w.ID(), u2.ID()))},
+		},
+		{
+			name:    "assignee should see the task in their assigned tasks",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/task/?assigned=true"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`{"1":{"id":1,`)},
+		},
+		{
+			name:    "other users should not see the task in their assigned tasks",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/task/?assigned=true"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{}`)},
+		},
+		{
+			name:    "assigning task to a non-member should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: fmt.Sprintf("/api/v1/task/1/assignee/%v", u3.ID())},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(fmt.Sprintf(`user %v cannot be assigned task ID 1`, u3.ID()))},
+		},
+		{
+			name:    "assigning task to an invalid user ID should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/task/1/assignee/abc"},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid user ID 'abc'`)},
+		},
+		{
+			name:    "assigning an unknown task should return 404",
+			h:       u1Api,
+			args:    args{method: "PUT", url: fmt.Sprintf("/api/v1/task/9999/assignee/%v", u2.ID())},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Task ID 9999 not found`)},
+		},
+		{
+			name:    "unassigning task should return 204",
+			h:       u2Api,
+			args:    args{method: "DELETE", url: "/api/v1/task/1/assignee"},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "unassigned task should not be in assigned tasks",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/task/?assigned=true"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{}`)},
+		},
+		{
+			name:    "new schedule with recurring task rotation should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: fmt.Sprintf(`{"frequency":"Hour","workspaceId":"%v","tasks":[{"name":"on-call","rotation":["%v","%v"],"rotationStrategy":"least-recent"}]}`, w.ID(), u1.ID(), u2.ID())},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "schedule should include recurring task rotation and next assignee",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`"rotation":["%v","%v"],"rotationStrategy":"least-recent","nextAssignee":"%v"`, u1.ID(), u2.ID(), u1.ID()))},
+		},
+		{
+			name:    "new schedule with invalid rotation strategy should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: fmt.Sprintf(`{"frequency":"Hour","tasks":[{"name":"on-call","rotation":["%v"],"rotationStrategy":"random"}]}`, u1.ID())},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`unknown rotation strategy 'random'`)},
+		},
+		{
+			name:    "new schedule with duplicate rotation users should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: fmt.Sprintf(`{"frequency":"Hour","tasks":[{"name":"on-call","rotation":["%v","%v"]}]}`, u1.ID(), u1.ID())},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`is in the rotation more than once`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func endpointPermissions(t *testing.T, apiMock test.MockAPI) {
+	allPerms := auth.GetDefaultRolePerms(user.RoleAdmin)
+	withoutPerm := func(perm auth.Permission) []auth.Permission {
+		ps := []auth.Permission{}
+		for _, p := range allPerms {
+			if p != perm {
+				ps = append(ps, p)
+			}
+		}
+		return ps
+	}
+
+	type args struct {
+		method string
+		url    string
+	}
+	tests := []struct {
+		name string
+		perm auth.Permission
+		args args
+	}{
+		{name: "add or update external user", perm: auth.PermUpsertUserSelf, args: args{"PUT", "/api/v1/user/external/p1/e0/addOrUpdate"}},
+		{name: "list tasks", perm: auth.PermReadTask, args: args{"GET", "/api/v1/task/"}},
+		{name: "get task", perm: auth.PermReadTask, args: args{"GET", "/api/v1/task/1"}},
+		{name: "add task", perm: auth.PermUpsertTask, args: args{"POST", "/api/v1/task/"}},
+		{name: "update task", perm: auth.PermUpsertTask, args: args{"PATCH", "/api/v1/task/1"}},
+		{name: "complete task", perm: auth.PermUpsertTask, args: args{"PUT", "/api/v1/task/1/complete"}},
+		{name: "uncomplete task", perm: auth.PermUpsertTask, args: args{"PUT", "/api/v1/task/1/uncomplete"}},
+		{name: "check checklist item", perm: auth.PermUpsertTask, args: args{"PUT", "/api/v1/task/1/checklist/1/check"}},
+		{name: "uncheck checklist item", perm: auth.PermUpsertTask, args: args{"PUT", "/api/v1/task/1/checklist/1/uncheck"}},
+		{name: "tag task", perm: auth.PermUpsertTask, args: args{"PUT", "/api/v1/task/1/tag/a"}},
+		{name: "untag task", perm: auth.PermUpsertTask, args: args{"DELETE", "/api/v1/task/1/tag/a"}},
+		{name: "assign task", perm: auth.PermUpsertTask, args: args{"PUT", "/api/v1/task/1/assignee/" + user.NewID().String()}},
+		{name: "unassign task", perm: auth.PermUpsertTask, args: args{"DELETE", "/api/v1/task/1/assignee"}},
+		{name: "list task activity", perm: auth.PermReadTask, args: args{"GET", "/api/v1/task/1/activity"}},
+		{name: "get task action", perm: auth.PermReadTask, args: args{"GET", "/api/v1/task/1/action"}},
+		{name: "get task command", perm: auth.PermReadTask, args: args{"GET", "/api/v1/task/1/command"}},
+		{name: "add task comment", perm: auth.PermUpsertTask, args: args{"POST", "/api/v1/task/1/activity"}},
+		{name: "clear task", perm: auth.PermDeleteTask, args: args{"DELETE", "/api/v1/task/1"}},
+		{name: "clear completed tasks", perm: auth.PermDeleteTask, args: args{"POST", "/api/v1/task/clear"}},
+		{name: "search", perm: auth.PermReadTask, args: args{"GET", "/api/v1/search/?q=a"}},
+		{name: "list tags", perm: auth.PermReadTask, args: args{"GET", "/api/v1/tag/"}},
+		{name: "rename tag", perm: auth.PermUpsertTask, args: args{"PATCH", "/api/v1/tag/a"}},
+		{name: "delete tag", perm: auth.PermUpsertTask, args: args{"DELETE", "/api/v1/tag/a"}},
+		{name: "list schedules", perm: auth.PermReadSchedule, args: args{"GET", "/api/v1/schedule/"}},
+		{name: "get schedule", perm: auth.PermReadSchedule, args: args{"GET", "/api/v1/schedule/1"}},
+		{name: "list schedule runs", perm: auth.PermReadSchedule, args: args{"GET", "/api/v1/schedule/1/runs"}},
+		{name: "remove schedule", perm: auth.PermDeleteSchedule, args: args{"DELETE", "/api/v1/schedule/1"}},
+		{name: "add schedule", perm: auth.PermUpsertSchedule, args: args{"POST", "/api/v1/schedule/"}},
+		{name: "pause schedule", perm: auth.PermUpsertSchedule, args: args{"PUT", "/api/v1/schedule/1/pause"}},
+		{name: "unpause schedule", perm: auth.PermUpsertSchedule, args: args{"PUT", "/api/v1/schedule/1/unpause"}},
+		{name: "set schedule chat URL", perm: auth.PermUpsertSchedule, args: args{"PUT", "/api/v1/schedule/1/chat"}},
+		{name: "add recurring task", perm: auth.PermUpsertSchedule, args: args{"POST", "/api/v1/schedule/1/task/"}},
+		{name: "list workspaces", perm: auth.PermReadWorkspace, args: args{"GET", "/api/v1/workspace/"}},
+		{name: "get workspace", perm: auth.PermReadWorkspace, args: args{"GET", "/api/v1/workspace/" + workspace.NewID().String()}},
+		{name: "add workspace", perm: auth.PermUpsertWorkspace, args: args{"POST", "/api/v1/workspace/"}},
+		{name: "invite workspace member", perm: auth.PermUpsertWorkspace, args: args{"POST", "/api/v1/workspace/" + workspace.NewID().String() + "/member"}},
+		{name: "list roles", perm: auth.PermManageRoles, args: args{"GET", "/api/v1/role/"}},
+		{name: "set role permissions", perm: auth.PermManageRoles, args: args{"PUT", "/api/v1/role/viewer"}},
+		{name: "set user role", perm: auth.PermManageRoles, args: args{"PUT", "/api/v1/role/viewer/user/" + user.NewID().String()}},
+		{name: "list tokens", perm: auth.PermManageTokens, args: args{"GET", "/api/v1/token/"}},
+		{name: "add token", perm: auth.PermManageTokens, args: args{"POST", "/api/v1/token/"}},
+		{name: "revoke token", perm: auth.PermManageTokens, args: args{"DELETE", "/api/v1/token/" + token.NewID().String()}},
+		{name: "list webhooks", perm: auth.PermManageWebhooks, args: args{"GET", "/api/v1/webhook/"}},
+		{name: "add webhook", perm: auth.PermManageWebhooks, args: args{"POST", "/api/v1/webhook/"}},
+		{name: "remove webhook", perm: auth.PermManageWebhooks, args: args{"DELETE", "/api/v1/webhook/" + webhook.NewID().String()}},
+		{name: "list webhook deliveries", perm: auth.PermManageWebhooks, args: args{"GET", "/api/v1/webhook/" + webhook.NewID().String() + "/delivery/"}},
+		{name: "get notification preferences", perm: auth.PermUpsertUserSelf, args: args{"GET", "/api/v1/notification/"}},
+		{name: "update notification preferences", perm: auth.PermUpsertUserSelf, args: args{"PUT", "/api/v1/notification/"}},
+		{name: "stream events", perm: auth.PermReadTask, args: args{"GET", "/api/v1/events"}},
+		{name: "change local password", perm: auth.PermUpsertUserSelf, args: args{"POST", "/api/v1/auth/local/password"}},
+		{name: "request local password reset", perm: auth.PermManageRoles, args: args{"POST", "/api/v1/auth/local/reset"}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name+" should require "+tt.perm.String(), func(t *testing.T) {
+			_, h := apiMock.NewUserWithRole("user for endpointPermissions", "https://p1/", fmt.Sprintf("e%d", i), user.RoleAdmin, withoutPerm(tt.perm))
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("status code = %v, want %v", rr.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func manageRoles(t *testing.T, apiMock test.MockAPI) {
+	allPerms := append(auth.GetDefaultUserPerms(), auth.PermManageRoles)
+	admin, adminApi := apiMock.NewUserWithRole("admin for manageRoles", "p1", "e1", user.RoleAdmin, allPerms)
+	member, memberApi := apiMock.NewUserWithRole("member for manageRoles", "p1", "e2", user.RoleMember, allPerms)
+	_, viewerApi := apiMock.NewUserWithRole("viewer for manageRoles", "p1", "e3", user.RoleViewer, allPerms)
+	listed, listedApi := apiMock.NewUserWithRole("listed admin for manageRoles", "p1", test.AdminUser, user.RoleMember, allPerms)
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "admin should list default role permissions",
+			h:       adminApi,
+			args:    args{method: "GET", url: "/api/v1/role/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`{"role":"viewer","permissions":["upsert:user-self","read:task","read:schedule","read:workspace"]}`)},
+		},
+		{
+			name:    "member should not be able to list roles",
+			h:       memberApi,
+			args:    args{method: "GET", url: "/api/v1/role/"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "viewer should be able to read tasks",
+			h:       viewerApi,
+			args:    args{method: "GET", url: "/api/v1/task/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{}`)},
+		},
+		{
+			name:    "viewer should not be able to add tasks",
+			h:       viewerApi,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"viewer task"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "setting viewer write permissions should return 204",
+			h:       adminApi,
+			args:    args{method: "PUT", url: "/api/v1/role/viewer", body: `{"permissions":["read:task","upsert:task"]}`},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "viewer should still not be able to add tasks with write permissions",
+			h:       viewerApi,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"viewer task"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "setting member permissions should return 204",
+			h:       adminApi,
+			args:    args{method: "PUT", url: "/api/v1/role/member", body: `{"permissions":["read:task"]}`},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "role list should include persisted permissions",
+			h:       adminApi,
+			args:    args{method: "GET", url: "/api/v1/role/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`{"role":"member","permissions":["read:task"]}`)},
+		},
+		{
+			name:    "member should be able to read tasks",
+			h:       memberApi,
+			args:    args{method: "GET", url: "/api/v1/task/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{}`)},
+		},
+		{
+			name:    "member should not be able to add tasks after losing permission",
+			h:       memberApi,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"member task"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "setting unknown permission should return 400",
+			h:       adminApi,
+			args:    args{method: "PUT", url: "/api/v1/role/member", body: `{"permissions":["fly:task"]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`unknown permission 'fly:task'`)},
+		},
+		{
+			name:    "setting permissions for unknown role should return 404",
+			h:       adminApi,
+			args:    args{method: "PUT", url: "/api/v1/role/guest", body: `{"permissions":["read:task"]}`},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(`Role 'guest' not found`)},
+		},
+		{
+			name:    "admin changing own role should return 403",
+			h:       adminApi,
+			args:    args{method: "PUT", url: fmt.Sprintf("/api/v1/role/viewer/user/%v", admin.ID())},
+			asserts: asserts{statusEquals: http.StatusForbidden},
+		},
+		{
+			name:    "changing unknown user's role should return 404",
+			h:       adminApi,
+			args:    args{method: "PUT", url: fmt.Sprintf("/api/v1/role/viewer/user/%v", user.NewID())},
+			asserts: asserts{statusEquals: http.StatusNotFound},
+		},
+		{
+			name:    "admin promoting member should return 204",
+			h:       adminApi,
+			args:    args{method: "PUT", url: fmt.Sprintf("/api/v1/role/admin/user/%v", member.ID())},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "promoted member should be able to list roles",
+			h:       memberApi,
+			args:    args{method: "GET", url: "/api/v1/role/"},
+			asserts: asserts{statusEquals: http.StatusOK},
+		},
+		{
+			name:    "listed admin should be granted the admin role when they sign in",
+			h:       listedApi,
+			args:    args{method: "GET", url: "/api/v1/role/"},
+			asserts: asserts{statusEquals: http.StatusOK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+	if got, _ := apiMock.UserRepo.Get(context.Background(), listed.ID()); got.Role() != user.RoleAdmin {
+		t.Errorf("listed admin role = %v, want %v", got.Role(), user.RoleAdmin)
+	}
+}
+
+func personalTokens(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	api := apiMock.API
+	u1, u1Api := apiMock.NewUserWithPerms("user 1 for personalTokens", "p1", "e1", auth.GetDefaultUserPerms())
+	_, u2Api := apiMock.NewUserWithPerms("user 2 for personalTokens", "p1", "e2", auth.GetDefaultUserPerms())
+	grant := usecase.TokenGrant{Known: []string{"read:task", "manage:tokens"}, Held: []string{"read:task", "manage:tokens"}}
+	readToken, readSecret, _ := usecase.AddToken(ctx, apiMock.TokenRepo, "read only", u1.ID(), []string{"read:task"}, grant, time.Time{})
+	_, manageSecret, _ := usecase.AddToken(ctx, apiMock.TokenRepo, "manage tokens", u1.ID(), []string{"read:task", "manage:tokens"}, grant, time.Time{})
+	expiredSecret := token.SecretPrefix + "expired"
+	apiMock.TokenRepo.Add(ctx, token.NewRaw(token.NewID(), "expired", u1.ID(), []string{"read:task"}, token.Hash(expiredSecret), time.Date(1999, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2000, 1, 1, 11, 0, 0, 0, time.UTC), time.Time{}))
+	withToken := func(secret string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+secret)
+			api.ServeHTTP(w, r)
+		})
+	}
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "new task should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"scripted task"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "new token should return 201 with its secret",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"ci","scopes":["read:task","upsert:task"],"expiresTime":"2000-02-01T12:00:00Z"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyContains: test.Strp(`"token":"stpat_`)},
+		},
+		{
+			name:    "new token with unknown scope should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"ci","scopes":["fly:task"]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`unknown permission 'fly:task'`)},
+		},
+		{
+			name:    "new token with a scope the user doesn't have should return 403",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"ci","scopes":["read:task","manage:roles"]}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`scope 'manage:roles' cannot be granted`)},
+		},
+		{
+			name:    "token should be able to create a token with its own scopes",
+			h:       withToken(manageSecret),
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"from token","scopes":["read:task"]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyContains: test.Strp(`"token":"stpat_`)},
+		},
+		{
+			name:    "token should not be able to create a token with a scope it doesn't have",
+			h:       withToken(manageSecret),
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"from token","scopes":["upsert:task"]}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`scope 'upsert:task' cannot be granted`)},
+		},
+		{
+			name:    "token should not be able to create a token that manages tokens",
+			h:       withToken(manageSecret),
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"from token","scopes":["manage:tokens"]}`},
+			asserts: asserts{statusEquals: http.StatusForbidden, bodyContains: test.Strp(`cannot be granted by a personal access token`)},
+		},
+		{
+			name:    "new token without a name should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"","scopes":["read:task"]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`token name cannot be empty`)},
+		},
+		{
+			name:    "new token that has already expired should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/token/", body: `{"name":"ci","scopes":["read:task"],"expiresTime":"2000-01-01T11:00:00Z"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`must be in the future`)},
+		},
+		{
+			name:    "token list should not include secrets",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/token/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`{"id":"%v","name":"read only","scopes":["read:task"],"createdTime":"%v","expiresTime":null,"revokedTime":null}`, readToken.ID(), nowStr))},
+		},
+		{
+			name:    "other users should not see the user's tokens",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/token/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`[]`)},
+		},
+		{
+			name:    "token should authenticate as its user",
+			h:       withToken(readSecret),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"name":"scripted task"`)},
+		},
+		{
+			name:    "token should be limited to its scopes",
+			h:       withToken(readSecret),
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"not allowed"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "unknown token should be unauthorized",
+			h:       withToken(token.SecretPrefix + "unknown"),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "expired token should be unauthorized",
+			h:       withToken(expiredSecret),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "revoking another user's token should return 404",
+			h:       u2Api,
+			args:    args{method: "DELETE", url: fmt.Sprintf("/api/v1/token/%v", readToken.ID())},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(fmt.Sprintf(`Token ID %v not found`, readToken.ID()))},
+		},
+		{
+			name:    "revoking token should return 204",
+			h:       u1Api,
+			args:    args{method: "DELETE", url: fmt.Sprintf("/api/v1/token/%v", readToken.ID())},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "revoked token should be unauthorized",
+			h:       withToken(readSecret),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "token list should include revoked time",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/token/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`"name":"read only","scopes":["read:task"],"createdTime":"%v","expiresTime":null,"revokedTime":"%v"}`, nowStr, nowStr))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func webhooks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	u1, u1Api := apiMock.NewUserWithPerms("user 1 for webhooks", "p1", "e1", auth.GetDefaultUserPerms())
+	_, u2Api := apiMock.NewUserWithPerms("user 2 for webhooks", "p1", "e2", auth.GetDefaultUserPerms())
+	_, u3Api := apiMock.NewUserWithPerms("user 3 for webhooks", "p1", "e3", auth.GetDefaultUserPerms())
+	wh, _ := usecase.AddWebhook(ctx, apiMock.WebhookRepo, "https://example.com/hook", []webhook.EventType{webhook.EventTaskCreated, webhook.EventTaskCompleted}, "s3cret", u1.ID())
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		runFunc func()
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "new webhook should return 201 with its secret",
+			h:       u3Api,
+			args:    args{method: "POST", url: "/api/v1/webhook/", body: `{"url":"https://example.com/other","events":["schedule.created"]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyContains: test.Strp(`"secret":"`)},
+		},
+		{
+			name:    "new webhook with unknown event type should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/webhook/", body: `{"url":"https://example.com/other","events":["task.exploded"]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`unknown event type 'task.exploded'`)},
+		},
+		{
+			name:    "new webhook with relative URL should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/webhook/", body: `{"url":"/hook","events":["task.created"]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`must be an absolute http or https URL`)},
+		},
+		{
+			name:    "new webhook without events should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/webhook/", body: `{"url":"https://example.com/other","events":[]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`at least one event type`)},
+		},
+		{
+			name:    "webhook list should not include secrets",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/webhook/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(fmt.Sprintf(`{"id":"%v","url":"https://example.com/hook","events":["task.created","task.completed"],"createdTime":"%v"}`, wh.ID(), nowStr))},
+		},
+		{
+			name:    "other users should not see the user's webhooks",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/webhook/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`[]`)},
+		},
+		{
+			name:    "new task should return 201",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"hooked task"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "publishing the new task's event should queue a delivery to subscribed webhooks",
+			h:       u1Api,
+			runFunc: apiMock.PublishEvents,
+			args:    args{method: "GET", url: fmt.Sprintf("/api/v1/webhook/%v/delivery/", wh.ID())},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"event":"task.created","status":"pending","attempts":0`)},
+		},
+		{
+			name:    "delivery payload should include the task",
+			h:       u1Api,
+			args:    args{method: "GET", url: fmt.Sprintf("/api/v1/webhook/%v/delivery/", wh.ID())},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"type":"task.created","time":"`)},
+		},
+		{
+			name:    "other users should not see the user's webhook deliveries",
+			h:       u2Api,
+			args:    args{method: "GET", url: fmt.Sprintf("/api/v1/webhook/%v/delivery/", wh.ID())},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(fmt.Sprintf(`Webhook ID %v not found`, wh.ID()))},
+		},
+		{
+			name:    "removing another user's webhook should return 404",
+			h:       u2Api,
+			args:    args{method: "DELETE", url: fmt.Sprintf("/api/v1/webhook/%v", wh.ID())},
+			asserts: asserts{statusEquals: http.StatusNotFound, bodyContains: test.Strp(fmt.Sprintf(`Webhook ID %v not found`, wh.ID()))},
+		},
+		{
+			name:    "removing webhook should return 204",
+			h:       u1Api,
+			args:    args{method: "DELETE", url: fmt.Sprintf("/api/v1/webhook/%v", wh.ID())},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "removed webhook should not be listed",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/webhook/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`[]`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.runFunc != nil {
+				tt.runFunc()
+			}
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func notificationPreferences(t *testing.T, apiMock test.MockAPI) {
+	nowStr, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+
+	_, u1Api := apiMock.NewUserWithPerms("user 1 for notificationPreferences", "p1", "e1", auth.GetDefaultUserPerms())
+	_, u2Api := apiMock.NewUserWithPerms("user 2 for notificationPreferences", "p1", "e2", auth.GetDefaultUserPerms())
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "preferences should be disabled by default",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/notification/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"email":"","taskGenerated":false,"digest":false,"digestHour":0,"lastDigestTime":null}`)},
+		},
+		{
+			name:    "enabling notifications without an email should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/notification/", body: `{"taskGenerated":true}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid notification preferences`)},
+		},
+		{
+			name:    "invalid email should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/notification/", body: `{"email":"Jane <jane@example.com>","taskGenerated":true}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid notification preferences`)},
+		},
+		{
+			name:    "invalid digest hour should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/notification/", body: `{"email":"jane@example.com","digest":true,"digestHour":24}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`invalid notification preferences`)},
+		},
+		{
+			name:    "invalid JSON should return 400",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/notification/", body: `{"digestHour":"nine"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`could not parse notification preferences`)},
+		},
+		{
+			name:    "updating preferences should return 200 with them",
+			h:       u1Api,
+			args:    args{method: "PUT", url: "/api/v1/notification/", body: `{"email":"jane@example.com","taskGenerated":true,"digest":true,"digestHour":9}`},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"email":"jane@example.com","taskGenerated":true,"digest":true,"digestHour":9,"lastDigestTime":"%v"}`, nowStr))},
+		},
+		{
+			name:    "updated preferences should be returned",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/notification/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`{"email":"jane@example.com","taskGenerated":true,"digest":true,"digestHour":9,`)},
+		},
+		{
+			name:    "other users should not see the user's preferences",
+			h:       u2Api,
+			args:    args{method: "GET", url: "/api/v1/notification/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`{"email":"","taskGenerated":false,`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+		})
+	}
+}
+
+func streamEvents(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	u1, u1Api := apiMock.NewUserWithPerms("user 1 for streamEvents", "p1", "e1", auth.GetDefaultUserPerms())
+	u2, _ := apiMock.NewUserWithPerms("user 2 for streamEvents", "p1", "e2", auth.GetDefaultUserPerms())
+	u3, u3Api := apiMock.NewUserWithPerm("user 3 for streamEvents, without schedule permissions", "p1", "e3", auth.PermReadTask)
+	apiMock.TaskRepo.Add(ctx, task.New("first streamed task", "", u1.ID()))
+	apiMock.TaskRepo.Add(ctx, task.New("other user's task", "", u2.ID()))
+	apiMock.TaskRepo.Add(ctx, task.New("second streamed task", "", u1.ID()))
+	f, _ := schedule.NewHourFrequency([]int{0})
+	apiMock.ScheduleRepo.Add(ctx, schedule.New(f, u1.ID()))
+	apiMock.TaskRepo.Add(ctx, task.New("task without schedule permissions", "", u3.ID()))
+	apiMock.ScheduleRepo.Add(ctx, schedule.New(f, u3.ID()))
+	pending, _ := apiMock.OutboxRepo.GetPending(ctx, time.Now(), 10)
+	if len(pending) != 6 {
+		t.Fatalf("pending events = %d, want 6", len(pending))
+	}
+	ids := []string{}
+	for _, ed := range pending {
+		ids = append(ids, ed.Event.ID().String())
+	}
+	apiMock.PublishEvents()
+
+	type args struct {
+		url         string
+		lastEventID string
+	}
+	type asserts struct {
+		statusEquals    int
+		bodyContains    []string
+		bodyNotContains []string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "new stream should only send the reconnection delay",
+			h:       u1Api,
+			args:    args{url: "/api/v1/events"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: []string{"retry: 3000\n\n"}, bodyNotContains: []string{"event: "}},
+		},
+		{
+			name: "resumed stream should send missed events with their task or schedule",
+			h:    u1Api,
+			args: args{url: "/api/v1/events", lastEventID: ids[0]},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: []string{
+				fmt.Sprintf("id: %v\nevent: task.created\ndata: {\"id\":\"%v\",\"type\":\"task.created\",\"occurredTime\":", ids[2], ids[2]),
+				`"taskId":3,"task":{"id":3,"name":"second streamed task"`,
+				fmt.Sprintf("id: %v\nevent: schedule.created\ndata: ", ids[3]),
+				`"scheduleId":1,"schedule":{"id":1,"frequency":"Hour"`,
+			}, bodyNotContains: []string{"first streamed task", "other user's task", "event: resync"}},
+		},
+		{
+			name:    "stream resumed with a query parameter should send missed events",
+			h:       u1Api,
+			args:    args{url: "/api/v1/events?lastEventId=" + ids[0]},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: []string{"second streamed task"}},
+		},
+		{
+			name:    "stream that can't be resumed should tell the client to resync",
+			h:       u1Api,
+			args:    args{url: "/api/v1/events", lastEventID: "unknown"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: []string{"event: resync\ndata: {}\n\n"}, bodyNotContains: []string{"streamed task"}},
+		},
+		{
+			name:    "stream without schedule permissions should not send schedule events",
+			h:       u3Api,
+			args:    args{url: "/api/v1/events", lastEventID: ids[0]},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: []string{"task without schedule permissions"}, bodyNotContains: []string{"schedule.created", "streamed task"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// streams stay open until the client disconnects
+			reqCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			req, err := http.NewRequest("GET", tt.args.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.args.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.args.lastEventID)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req.WithContext(reqCtx))
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+				t.Errorf("content type = %v, want text/event-stream", ct)
+			}
+			for _, s := range tt.asserts.bodyContains {
+				if !strings.Contains(rr.Body.String(), s) {
+					t.Errorf("response body = %v, should contain %v", rr.Body.String(), s)
+				}
+			}
+			for _, s := range tt.asserts.bodyNotContains {
+				if strings.Contains(rr.Body.String(), s) {
+					t.Errorf("response body = %v, should not contain %v", rr.Body.String(), s)
+				}
+			}
+		})
+	}
+
+	t.Run("open stream should send events as they're published", func(t *testing.T) {
+		s := httptest.NewServer(u1Api)
+		defer s.Close()
+		res, err := http.Get(s.URL + "/api/v1/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body := bufio.NewReader(res.Body)
+		if line, err := body.ReadString('\n'); err != nil || line != "retry: 3000\n" {
+			t.Fatalf("first line = %q, %v, want the reconnection delay", line, err)
+		}
+
+		id, _ := apiMock.TaskRepo.Add(ctx, task.New("live task", "", u1.ID()))
+		apiMock.PublishEvents()
+		want := fmt.Sprintf(`"taskId":%v,"task":{"id":%v,"name":"live task"`, id, id)
+		for {
+			line, err := body.ReadString('\n')
+			if err != nil {
+				t.Fatalf("error reading stream: %v", err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				if !strings.Contains(line, want) {
+					t.Errorf("event data = %v, should contain %v", line, want)
+				}
+				return
+			}
+		}
+	})
+}
+
+func localAuth(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	api := apiMock.API
+	_, u1Api := apiMock.NewUserWithPerms("user 1 for localAuth", "p1", "e1", auth.GetDefaultUserPerms())
+	_, adminApi := apiMock.NewUserWithRole("admin for localAuth", "p1", "e2", user.RoleAdmin, auth.GetDefaultRolePerms(user.RoleAdmin))
+	usecase.RegisterLocalUser(ctx, apiMock.UserRepo, apiMock.CredentialRepo, "jane", "password1", "Jane")
+	var firstSession, secondSession, resetToken string
+	withSession := func(session *string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+*session)
+			api.ServeHTTP(w, r)
+		})
+	}
+	storeField := func(field string, val *string) func(body []byte) error {
+		return func(body []byte) error {
+			var o map[string]interface{}
+			if err := json.Unmarshal(body, &o); err != nil {
+				return err
+			}
+			*val, _ = o[field].(string)
+			return nil
+		}
+	}
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals int
+		bodyEquals   *string
+		bodyContains *string
+	}
+	tests := []struct {
+		name    string
+		h       http.Handler
+		args    args
+		asserts asserts
+		store   func(body []byte) error
+	}{
+		{
+			name:    "registering a new user should return 201",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/register", body: `{"username":"john","password":"password1","displayname":"John"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyContains: test.Strp(`{"id":"`)},
+		},
+		{
+			name:    "registering a taken username should return 400",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/register", body: `{"username":"JANE","password":"password1","displayname":"Jane"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`username is already taken`)},
+		},
+		{
+			name:    "registering with a short password should return 400",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/register", body: `{"username":"jim","password":"short","displayname":"Jim"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`password must be at least 8 characters`)},
+		},
+		{
+			name:    "logging in with the wrong password should return 401",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/login", body: `{"username":"jane","password":"password2"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "logging in with an unknown username should return 401",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/login", body: `{"username":"nobody","password":"password1"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "logging in should return 200 with a session token",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/login", body: `{"username":"Jane","password":"password1"}`},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"expiresTime":"`)},
+			store:   storeField("token", &firstSession),
+		},
+		{
+			name:    "session token should authenticate as the local user",
+			h:       withSession(&firstSession),
+			args:    args{method: "POST", url: "/api/v1/task/", body: `{"name":"local task"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "tampered session token should be unauthorized",
+			h:       withSession(test.Strp("a.b.c")),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "session token should be limited to the user's role",
+			h:       withSession(&firstSession),
+			args:    args{method: "GET", url: "/api/v1/role/"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "changing password with the wrong current password should return 403",
+			h:       withSession(&firstSession),
+			args:    args{method: "POST", url: "/api/v1/auth/local/password", body: `{"currentPassword":"wrong password","newPassword":"password2"}`},
+			asserts: asserts{statusEquals: http.StatusForbidden},
+		},
+		{
+			name:    "changing password for a user from another provider should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/password", body: `{"currentPassword":"password1","newPassword":"password2"}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`only local users can change their password`)},
+		},
+		{
+			name:    "changing password should return 204",
+			h:       withSession(&firstSession),
+			args:    args{method: "POST", url: "/api/v1/auth/local/password", body: `{"currentPassword":"password1","newPassword":"password2"}`},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "sessions from before the password change should be unauthorized",
+			h:       withSession(&firstSession),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "logging in with the old password should return 401",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/login", body: `{"username":"jane","password":"password1"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "logging in with the new password should return 200",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/login", body: `{"username":"jane","password":"password2"}`},
+			asserts: asserts{statusEquals: http.StatusOK},
+			store:   storeField("token", &secondSession),
+		},
+		{
+			name:    "new session should be authorized",
+			h:       withSession(&secondSession),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyContains: test.Strp(`"name":"local task"`)},
+		},
+		{
+			name:    "non-admins should not be able to request a password reset",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/reset", body: `{"username":"jane"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "requesting a password reset for an unknown username should return 404",
+			h:       adminApi,
+			args:    args{method: "POST", url: "/api/v1/auth/local/reset", body: `{"username":"nobody"}`},
+			asserts: asserts{statusEquals: http.StatusNotFound},
+		},
+		{
+			name:    "requesting a password reset should return 201 with a reset token",
+			h:       adminApi,
+			args:    args{method: "POST", url: "/api/v1/auth/local/reset", body: `{"username":"jane"}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyContains: test.Strp(`{"resetToken":"`)},
+			store:   storeField("resetToken", &resetToken),
+		},
+		{
+			name:    "resetting password with the wrong reset token should return 401",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/reset/confirm", body: `{"username":"jane","resetToken":"wrong","password":"password3"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "resetting password should return 204",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/reset/confirm", body: `{"username":"jane","resetToken":"{resetToken}","password":"password3"}`},
+			asserts: asserts{statusEquals: http.StatusNoContent},
+		},
+		{
+			name:    "reset token should only be usable once",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/reset/confirm", body: `{"username":"jane","resetToken":"{resetToken}","password":"password4"}`},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "sessions from before the password reset should be unauthorized",
+			h:       withSession(&secondSession),
+			args:    args{method: "GET", url: "/api/v1/task/1"},
+			asserts: asserts{statusEquals: http.StatusUnauthorized},
+		},
+		{
+			name:    "logging in with the reset password should return 200",
+			h:       api,
+			args:    args{method: "POST", url: "/api/v1/auth/local/login", body: `{"username":"jane","password":"password3"}`},
+			asserts: asserts{statusEquals: http.StatusOK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Replace(tt.args.body, "{resetToken}", resetToken, -1)
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+			if tt.store != nil {
+				if err := tt.store(rr.Body.Bytes()); err != nil {
+					t.Errorf("error parsing response body %v: %v", rr.Body.String(), err)
+				}
+			}
+		})
+	}
+}
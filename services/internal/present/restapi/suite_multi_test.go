@@ -3,6 +3,7 @@
 package restapi_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -33,9 +34,12 @@ func suiteMulti(t *testing.T, tester test.Tester) {
 	addRecurringTasksToEmptySchedule(t, tester.NewAPI())
 	addRemoveListSchedule(t, tester.NewAPI())
 	schedulerCreatesTasks(t, tester.NewAPI())
+	schedulerCreatesDueTasks(t, tester.NewAPI())
 }
 
 func schedulerCreatesTasks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
 	_, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
 	defer reset()
 	checkTime := time.Date(2000, 1, 1, 12, 10, 0, 0, time.UTC)
@@ -78,18 +82,18 @@ func schedulerCreatesTasks(t *testing.T, apiMock test.MockAPI) {
 			name:    "get schedule ID 1 should return hourly schedule with 1 recurring tasks",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/1"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[5],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc"}]}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[5],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc","priority":"none","tags":[],"checklist":[],"autoComplete":false}]}`)},
 		},
 		{
 			name: "after scheduler run, 1 task should be returned",
 			h:    u1Api,
 			runFunc: func() {
-				usecase.CheckSchedules(apiMock.TaskRepo, apiMock.ScheduleRepo) // initial check when schedule is created
+				usecase.CheckSchedules(ctx, apiMock.TaskRepo, apiMock.ActivityRepo, apiMock.ScheduleRepo, apiMock.ScheduleRunRepo) // initial check when schedule is created
 				_, _ = test.SetStaticClock(checkTime)
-				usecase.CheckSchedules(apiMock.TaskRepo, apiMock.ScheduleRepo) // check after elapsed time to create tasks
+				usecase.CheckSchedules(ctx, apiMock.TaskRepo, apiMock.ActivityRepo, apiMock.ScheduleRepo, apiMock.ScheduleRunRepo) // check after elapsed time to create tasks
 			},
 			args:    args{method: "GET", url: "/api/v1/task/"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"rtask1","description":"rtask1 desc","completedTime":null,"createdTime":"%v"}}`, checkTimeStr))},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"rtask1","description":"rtask1 desc","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}}`, checkTimeStr))},
 		},
 	}
 	for _, tt := range tests {
@@ -175,13 +179,13 @@ func addListGetCompleteTasks(t *testing.T, apiMock test.MockAPI) {
 			name:    "should return 200 list with 3 tasks",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/task/"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"task1","description":"task1 description","completedTime":null,"createdTime":"%v"},"2":{"id":2,"name":"task2","description":"task2 description","completedTime":null,"createdTime":"%v"},"3":{"id":3,"name":"task3","description":"task3 description","completedTime":null,"createdTime":"%v"}}`, nowStr, nowStr, nowStr))},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"task1","description":"task1 description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false},"2":{"id":2,"name":"task2","description":"task2 description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false},"3":{"id":3,"name":"task3","description":"task3 description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}}`, nowStr, nowStr, nowStr))},
 		},
 		{
 			name:    "get task ID 1 should return incompleted task",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/task/1"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"task1 description","completedTime":null,"createdTime":"%v"}`, nowStr))},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"task1 description","completedTime":null,"createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}`, nowStr))},
 		},
 		{
 			name:    "complete ID 1 should return 204",
@@ -193,7 +197,7 @@ func addListGetCompleteTasks(t *testing.T, apiMock test.MockAPI) {
 			name:    "get task ID 1 should return completed task",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/task/1"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"task1 description","completedTime":"%v","createdTime":"%v"}`, nowStr, nowStr))},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"id":1,"name":"task1","description":"task1 description","completedTime":"%v","createdTime":"%v","dueTime":null,"priority":"none","overdue":false,"tags":[],"checklist":[],"autoComplete":false}`, nowStr, nowStr))},
 		},
 	}
 	for _, tt := range tests {
@@ -279,7 +283,7 @@ func addRemoveListSchedule(t *testing.T, apiMock test.MockAPI) {
 			name:    "get schedule ID 2 should return hourly schedule with 1 recurring tasks",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/2"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":2,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,15,30],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc"}]}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":2,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,15,30],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc","priority":"none","tags":[],"checklist":[],"autoComplete":false}]}`)},
 		},
 		{
 			name:    "get schedule ID 3 should return hourly schedule with no recurring tasks and with interval and offset",
@@ -303,7 +307,7 @@ func addRemoveListSchedule(t *testing.T, apiMock test.MockAPI) {
 			name:    "list return 200 list with 1 schedule with ID 2",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"2":{"id":2,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,15,30],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc"}]}}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"2":{"id":2,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,15,30],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc","priority":"none","tags":[],"checklist":[],"autoComplete":false}]}}`)},
 		},
 	}
 	for _, tt := range tests {
@@ -431,7 +435,7 @@ func addListGetSchedules(t *testing.T, apiMock test.MockAPI) {
 			name:    "get schedule ID 3 should return hourly schedule with 1 recurring tasks",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/3"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":3,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,30,59],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc"}]}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":3,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,30,59],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc","priority":"none","tags":[],"checklist":[],"autoComplete":false}]}`)},
 		},
 		{
 			name:    "get schedule ID 4 should return empty schedule with no recurring tasks and interval and offset",
@@ -461,7 +465,7 @@ func addListGetSchedules(t *testing.T, apiMock test.MockAPI) {
 			name:    "should return 200 list with 7 schedules",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"1":{"id":1,"frequency":"Hour","interval":1,"offset":0,"paused":false,"tasks":[]},"2":{"id":2,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,30],"paused":true,"tasks":[]},"3":{"id":3,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,30,59],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc"}]},"4":{"id":4,"frequency":"Hour","interval":2,"offset":1,"atMinutes":[0],"paused":false,"tasks":[]},"5":{"id":5,"frequency":"Day","interval":1,"offset":0,"atMinutes":[0,30],"atHours":[3,6],"paused":false,"tasks":[]},"6":{"id":6,"frequency":"Week","interval":1,"offset":0,"atMinutes":[0,30],"atHours":[3,6],"onDaysOfWeek":["Wednesday","Thursday"],"paused":false,"tasks":[]},"7":{"id":7,"frequency":"Month","interval":1,"offset":0,"atMinutes":[15],"atHours":[1],"onDaysOfMonth":[1,15,31],"paused":false,"tasks":[]}}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"1":{"id":1,"frequency":"Hour","interval":1,"offset":0,"paused":false,"tasks":[]},"2":{"id":2,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,30],"paused":true,"tasks":[]},"3":{"id":3,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[0,30,59],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc","priority":"none","tags":[],"checklist":[],"autoComplete":false}]},"4":{"id":4,"frequency":"Hour","interval":2,"offset":1,"atMinutes":[0],"paused":false,"tasks":[]},"5":{"id":5,"frequency":"Day","interval":1,"offset":0,"atMinutes":[0,30],"atHours":[3,6],"paused":false,"tasks":[]},"6":{"id":6,"frequency":"Week","interval":1,"offset":0,"atMinutes":[0,30],"atHours":[3,6],"onDaysOfWeek":["Wednesday","Thursday"],"paused":false,"tasks":[]},"7":{"id":7,"frequency":"Month","interval":1,"offset":0,"atMinutes":[15],"atHours":[1],"onDaysOfMonth":[1,15,31],"paused":false,"tasks":[]}}`)},
 		},
 	}
 	for _, tt := range tests {
@@ -541,17 +545,106 @@ func addRecurringTasksToEmptySchedule(t *testing.T, apiMock test.MockAPI) {
 			name:    "get schedule ID 1 should return schedule with 1 task",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/1"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"paused":false,"tasks":[{"name":"task1","description":"task1 description"}]}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"paused":false,"tasks":[{"name":"task1","description":"task1 description","priority":"none","tags":[],"checklist":[],"autoComplete":false}]}`)},
 		},
 		{
 			name:    "should return 200 list with 1 schedule with 1 task",
 			h:       u1Api,
 			args:    args{method: "GET", url: "/api/v1/schedule/"},
-			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"1":{"id":1,"frequency":"Hour","interval":1,"offset":0,"paused":false,"tasks":[{"name":"task1","description":"task1 description"}]}}`)},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"1":{"id":1,"frequency":"Hour","interval":1,"offset":0,"paused":false,"tasks":[{"name":"task1","description":"task1 description","priority":"none","tags":[],"checklist":[],"autoComplete":false}]}}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			tt.h.ServeHTTP(rr, req)
+			if rr.Code != tt.asserts.statusEquals {
+				t.Errorf("status code = %v, want %v", rr.Code, tt.asserts.statusEquals)
+			}
+			if tt.asserts.bodyEquals != nil && rr.Body.String() != *tt.asserts.bodyEquals {
+				t.Errorf("response body = %v, should equal %v", rr.Body.String(), *tt.asserts.bodyEquals)
+			}
+			if tt.asserts.bodyContains != nil && !strings.Contains(rr.Body.String(), *tt.asserts.bodyContains) {
+				t.Errorf("response body = %v, should contain %v", rr.Body.String(), *tt.asserts.bodyContains)
+			}
+			if tt.asserts.bodyNotEquals != nil && rr.Body.String() == *tt.asserts.bodyNotEquals {
+				t.Errorf("response body = %v, should not equal %v", rr.Body.String(), *tt.asserts.bodyNotEquals)
+			}
+			if tt.asserts.bodyNotContains != nil && strings.Contains(rr.Body.String(), *tt.asserts.bodyNotContains) {
+				t.Errorf("response body = %v, should not contain %v", rr.Body.String(), *tt.asserts.bodyNotContains)
+			}
+		})
+	}
+}
+
+func schedulerCreatesDueTasks(t *testing.T, apiMock test.MockAPI) {
+	ctx := context.Background()
+
+	_, reset := test.SetStaticClock(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer reset()
+	checkTime := time.Date(2000, 1, 1, 12, 10, 0, 0, time.UTC)
+	checkTimeStr := test.FormatTime(checkTime)
+
+	_, u1Api := apiMock.NewUserWithPerms("test user 1 for schedulerCreatesDueTasks", "p1", "e1", []auth.Permission{auth.PermReadSchedule, auth.PermUpsertSchedule, auth.PermReadTask, auth.PermUpsertTask})
+
+	type args struct {
+		method string
+		url    string
+		body   string
+	}
+	type asserts struct {
+		statusEquals    int
+		bodyEquals      *string
+		bodyContains    *string
+		bodyNotEquals   *string
+		bodyNotContains *string
+	}
+	tests := []struct {
+		name    string
+		runFunc func()
+		h       http.Handler
+		args    args
+		asserts asserts
+	}{
+		{
+			name:    "negative due offset should return 400",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour", "atMinutes":[5], "tasks": [{"name": "rtask1", "dueOffsetMinutes": -30}]}`},
+			asserts: asserts{statusEquals: http.StatusBadRequest, bodyContains: test.Strp(`cannot be negative`)},
+		},
+		{
+			name:    "new hourly schedule with prioritized recurring task due 30 minutes after each occurrence should return 201 and ID 1",
+			h:       u1Api,
+			args:    args{method: "POST", url: "/api/v1/schedule/", body: `{"frequency":"Hour", "atMinutes":[5], "tasks": [{"name": "rtask1", "description": "rtask1 desc", "priority": "high", "dueOffsetMinutes": 30}]}`},
+			asserts: asserts{statusEquals: http.StatusCreated, bodyEquals: test.Strp(`{"id":1}`)},
+		},
+		{
+			name:    "get schedule ID 1 should return recurring task with priority and due offset",
+			h:       u1Api,
+			args:    args{method: "GET", url: "/api/v1/schedule/1"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(`{"id":1,"frequency":"Hour","interval":1,"offset":0,"atMinutes":[5],"paused":false,"tasks":[{"name":"rtask1","description":"rtask1 desc","priority":"high","dueOffsetMinutes":30,"tags":[],"checklist":[],"autoComplete":false}]}`)},
+		},
+		{
+			name: "after scheduler run, task should be due 30 minutes after the occurrence with the recurring task priority",
+			h:    u1Api,
+			runFunc: func() {
+				usecase.CheckSchedules(ctx, apiMock.TaskRepo, apiMock.ActivityRepo, apiMock.ScheduleRepo, apiMock.ScheduleRunRepo) // initial check when schedule is created
+				_, _ = test.SetStaticClock(checkTime)
+				usecase.CheckSchedules(ctx, apiMock.TaskRepo, apiMock.ActivityRepo, apiMock.ScheduleRepo, apiMock.ScheduleRunRepo) // check after elapsed time to create tasks
+			},
+			args:    args{method: "GET", url: "/api/v1/task/"},
+			asserts: asserts{statusEquals: http.StatusOK, bodyEquals: test.Strp(fmt.Sprintf(`{"1":{"id":1,"name":"rtask1","description":"rtask1 desc","completedTime":null,"createdTime":"%v","dueTime":"2000-01-01T12:35:00Z","priority":"high","overdue":false,"tags":[],"checklist":[],"autoComplete":false}}`, checkTimeStr))},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.runFunc != nil {
+				tt.runFunc()
+			}
 			req, err := http.NewRequest(tt.args.method, tt.args.url, strings.NewReader(tt.args.body))
 			if err != nil {
 				t.Fatal(err)
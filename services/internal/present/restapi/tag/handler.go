@@ -0,0 +1,143 @@
+package tag
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/tag/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	TagList(tds []usecase.TagData) ([]byte, error)
+	TagChanged(count int) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	RenameTag(b io.Reader) (task.Tag, error)
+}
+
+// Handle adds tag handling endpoints
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/tag"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermReadTask, false, l, f, listTags(l, f, taskRepo, scheduleRepo)))
+	r.PATCH(pre+"/:tag", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, renameTag(l, f, p, taskRepo, scheduleRepo)))
+	r.DELETE(pre+"/:tag", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, deleteTag(l, f, taskRepo, scheduleRepo)))
+}
+
+// schedulesWithPerm returns the schedule repo if the user has the given schedule permission, otherwise nil so only tasks are included
+func schedulesWithPerm(w http.ResponseWriter, perm auth.Permission, scheduleRepo usecase.ScheduleRepo) usecase.ScheduleRepo {
+	if auth.HasPerm(w, perm) {
+		return scheduleRepo
+	}
+	return nil
+}
+
+func listTags(l Logger, f Formatter, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		tds, ucerr := usecase.ListTags(r.Context(), taskRepo, schedulesWithPerm(w, auth.PermReadSchedule, scheduleRepo), u.ID())
+		if ucerr != nil {
+			l.Errorf("error retrieving tag list: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't retrieve tags"), 500)
+			return
+		}
+
+		o, err := f.TagList(tds)
+		if err != nil {
+			l.Errorf("error encoding tag list: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding tag data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func renameTag(l Logger, f Formatter, p Parser, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		from, err := task.NewTag(ps.ByName("tag"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", err), 400)
+			return
+		}
+		to, err := p.RenameTag(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing renameTag data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse tag data: %v", err), 400)
+			return
+		}
+
+		u := auth.GetUser(w)
+		count, ucerr := usecase.RenameTag(r.Context(), taskRepo, schedulesWithPerm(w, auth.PermUpsertSchedule, scheduleRepo), u.ID(), from, to)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Tag '%v' not found", from), 404)
+				return
+			}
+			l.Errorf("error renaming tag: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error renaming tag"), 500)
+			return
+		}
+
+		o, err := f.TagChanged(count)
+		if err != nil {
+			f.WriteResponse(w, f.Error("Tag renamed, but there was an error formatting the response"), 200)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func deleteTag(l Logger, f Formatter, taskRepo usecase.TaskRepo, scheduleRepo usecase.ScheduleRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		tag, err := task.NewTag(ps.ByName("tag"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", err), 400)
+			return
+		}
+
+		u := auth.GetUser(w)
+		count, ucerr := usecase.DeleteTag(r.Context(), taskRepo, schedulesWithPerm(w, auth.PermUpsertSchedule, scheduleRepo), u.ID(), tag)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Tag '%v' not found", tag), 404)
+				return
+			}
+			l.Errorf("error deleting tag: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error deleting tag"), 500)
+			return
+		}
+
+		o, err := f.TagChanged(count)
+		if err != nil {
+			f.WriteResponse(w, f.Error("Tag deleted, but there was an error formatting the response"), 200)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
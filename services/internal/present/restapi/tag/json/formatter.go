@@ -0,0 +1,43 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outTag struct {
+	Name               task.Tag `json:"name"`
+	TaskCount          int      `json:"taskCount"`
+	RecurringTaskCount int      `json:"recurringTaskCount"`
+}
+
+type outTagChanged struct {
+	Count int `json:"count"`
+}
+
+// TagList formats a list of tags to JSON
+func (f *Formatter) TagList(tds []usecase.TagData) ([]byte, error) {
+	o := make([]outTag, len(tds))
+	for i, td := range tds {
+		o[i] = outTag{Name: td.Tag, TaskCount: td.TaskCount, RecurringTaskCount: td.RecurringTaskCount}
+	}
+	return json.Marshal(o)
+}
+
+// TagChanged formats the number of tasks and recurring tasks changed by a tag operation to JSON
+func (f *Formatter) TagChanged(count int) ([]byte, error) {
+	return json.Marshal(outTagChanged{Count: count})
+}
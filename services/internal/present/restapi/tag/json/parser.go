@@ -0,0 +1,30 @@
+package json
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// RenameTag parses renameTag request JSON data into the new tag
+func (p *Parser) RenameTag(b io.Reader) (task.Tag, error) {
+	var renameTag renameTag
+	if err := json.NewDecoder(b).Decode(&renameTag); err != nil {
+		return "", err
+	}
+	return task.NewTag(renameTag.Name)
+}
+
+type renameTag struct {
+	Name string `json:"name"`
+}
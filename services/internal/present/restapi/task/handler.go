@@ -1,15 +1,20 @@
 package task
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
 	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
 	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/task/json"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
@@ -18,6 +23,8 @@ import (
 // Logger interface needed for log messages
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
 }
 
 // Formatter defines the formatter interface for output responses
@@ -26,16 +33,24 @@ type Formatter interface {
 	TaskID(id usecase.TaskID) ([]byte, error)
 	Task(td *usecase.TaskData) ([]byte, error)
 	TaskMap(ts map[usecase.TaskID]*task.Task) ([]byte, error)
+	TaskList(tds []usecase.TaskData) ([]byte, error)
+	ActivityID(id usecase.ActivityID) ([]byte, error)
+	ActivityList(ads []usecase.ActivityData) ([]byte, error)
+	Action(c *action.Call) ([]byte, error)
+	Command(r *command.Run) ([]byte, error)
 	responseMapper.ResponseFormatter
 }
 
 // Parser defines the parser interface for parsing input requests
 type Parser interface {
 	AddTask(b io.Reader, uid user.ID) (*task.Task, error)
+	UpdateTask(b io.Reader) (usecase.TaskUpdate, error)
+	AddComment(b io.Reader) (string, error)
 }
 
 // Handle adds task handling endpoints
-func Handle(r *httprouter.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, taskRepo usecase.TaskRepo) {
+// Changes made through these endpoints are recorded in each task's activity history
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo, workspaceRepo usecase.WorkspaceRepo, actionRepo usecase.ActionRepo, commandRepo usecase.CommandRepo) {
 
 	p := mapper.NewParser()
 	f := mapper.NewFormatter(rf)
@@ -43,78 +58,150 @@ func Handle(r *httprouter.Router, prefix string, l Logger, rf responseMapper.Res
 	pre := prefix + "/task"
 	r.GET(pre+"/", auth.HRAuthorize(auth.PermReadTask, false, l, f, listTasks(l, f, taskRepo)))
 	r.GET(pre+"/:taskID", auth.HRAuthorize(auth.PermReadTask, false, l, f, getTask(l, f, taskRepo)))
-	r.POST(pre+"/", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, addTask(l, f, p, taskRepo)))
-	r.PUT(pre+"/:taskID/complete", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, completeTask(l, f, taskRepo)))
-	r.DELETE(pre+"/:taskID", auth.HRAuthorize(auth.PermDeleteTask, true, l, f, clearTask(l, f, taskRepo)))
-	r.POST(pre+"/clear", auth.HRAuthorize(auth.PermDeleteTask, true, l, f, clearCompletedTasks(l, f, taskRepo)))
+	r.POST(pre+"/", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, addTask(l, f, p, taskRepo, activityRepo, workspaceRepo)))
+	r.PATCH(pre+"/:taskID", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, updateTask(l, f, p, taskRepo, activityRepo)))
+	r.PUT(pre+"/:taskID/complete", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, completeTask(l, f, taskRepo, activityRepo)))
+	r.PUT(pre+"/:taskID/uncomplete", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, uncompleteTask(l, f, taskRepo, activityRepo)))
+	r.PUT(pre+"/:taskID/checklist/:item/check", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, checkTaskItem(l, f, taskRepo, activityRepo, true)))
+	r.PUT(pre+"/:taskID/checklist/:item/uncheck", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, checkTaskItem(l, f, taskRepo, activityRepo, false)))
+	r.PUT(pre+"/:taskID/tag/:tag", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, tagTask(l, f, taskRepo, activityRepo)))
+	r.DELETE(pre+"/:taskID/tag/:tag", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, untagTask(l, f, taskRepo, activityRepo)))
+	r.PUT(pre+"/:taskID/assignee/:userID", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, assignTask(l, f, taskRepo, activityRepo)))
+	r.DELETE(pre+"/:taskID/assignee", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, assignTask(l, f, taskRepo, activityRepo)))
+	r.GET(pre+"/:taskID/activity", auth.HRAuthorize(auth.PermReadTask, true, l, f, listTaskActivity(l, f, taskRepo, activityRepo)))
+	r.GET(pre+"/:taskID/action", auth.HRAuthorize(auth.PermReadTask, true, l, f, getTaskAction(l, f, taskRepo, actionRepo)))
+	r.GET(pre+"/:taskID/command", auth.HRAuthorize(auth.PermReadTask, true, l, f, getTaskCommand(l, f, taskRepo, commandRepo)))
+	r.POST(pre+"/:taskID/activity", auth.HRAuthorize(auth.PermUpsertTask, true, l, f, addTaskComment(l, f, p, taskRepo, activityRepo)))
+	r.DELETE(pre+"/:taskID", auth.HRAuthorize(auth.PermDeleteTask, true, l, f, clearTask(l, f, taskRepo, activityRepo)))
+	r.POST(pre+"/clear", auth.HRAuthorize(auth.PermDeleteTask, true, l, f, clearCompletedTasks(l, f, taskRepo, activityRepo)))
 }
 
+// listTasks lists tasks as a map keyed by task ID, or as an ordered list if the 'sort' query parameter is set
+// Tasks can be filtered to those having all 'tag' query parameters, and to those assigned to the user with 'assigned=true'
 func listTasks(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		query := r.URL.Query()
+		sortBy, err := parseTaskSort(query.Get("sort"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", err), 400)
+			return
+		}
+		tags, err := task.ParseTags(query["tag"])
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", err), 400)
+			return
+		}
+
 		u := auth.GetUser(w)
-		ts, ucerr := usecase.ListTasks(taskRepo, u.ID())
+		var ts map[usecase.TaskID]*task.Task
+		var ucerr usecase.Error
+		if query.Get("overdue") == "true" {
+			ts, ucerr = usecase.ListOverdueTasks(r.Context(), taskRepo, u.ID())
+		} else {
+			ts, ucerr = usecase.ListTasks(r.Context(), taskRepo, u.ID())
+		}
 		if ucerr != nil {
-			l.Printf("error retrieving task list: %v", ucerr)
+			l.Errorf("error retrieving task list: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error: couldn't retrieve tasks"), 500)
 			return
 		}
-		o, err := f.TaskMap(ts)
+		if len(tags) > 0 {
+			ts = usecase.FilterTasksByTags(ts, tags)
+		}
+		if query.Get("assigned") == "true" {
+			ts = usecase.FilterTasksByAssignee(ts, u.ID())
+		}
+		var o []byte
+		if sortBy != usecase.TaskSortNone {
+			o, err = f.TaskList(usecase.SortTasks(ts, sortBy))
+		} else {
+			o, err = f.TaskMap(ts)
+		}
 		if err != nil {
-			l.Printf("error encoding task map: %v", err)
+			l.Errorf("error encoding task map: %v", err)
 			f.WriteResponse(w, f.Error("Error encoding task data"), 500)
 		}
 		f.WriteResponse(w, o, 200)
 	}
 }
 
+func parseTaskSort(val string) (usecase.TaskSort, error) {
+	switch val {
+	case "":
+		return usecase.TaskSortNone, nil
+	case "due":
+		return usecase.TaskSortDue, nil
+	case "priority":
+		return usecase.TaskSortPriority, nil
+	case "created":
+		return usecase.TaskSortCreated, nil
+	}
+	return usecase.TaskSortNone, fmt.Errorf("invalid sort '%v', should be 'due', 'priority', or 'created'", val)
+}
+
 func getTask(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		taskIDInt, err := strconv.Atoi(params.ByName("taskID"))
 		if err != nil {
-			l.Printf("valid task ID required")
+			l.Warnf("valid task ID required")
 			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		id := usecase.TaskID(taskIDInt)
-		td, ucerr := usecase.GetTask(taskRepo, id, u.ID())
+		td, ucerr := usecase.GetTask(r.Context(), taskRepo, id, u.ID())
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error retrieving task ID %d: %v", id, ucerr)
+			l.Errorf("error retrieving task ID %d: %v", id, ucerr)
 			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve task ID %d", id), 500)
 			return
 		}
 
 		o, err := f.Task(td)
 		if err != nil {
-			l.Printf("error encoding task map: %v", err)
+			l.Errorf("error encoding task map: %v", err)
 			f.WriteResponse(w, f.Error("Error encoding task data"), 500)
 		}
 		f.WriteResponse(w, o, 200)
 	}
 }
 
-func addTask(l Logger, f Formatter, p Parser, taskRepo usecase.TaskRepo) httprouter.Handle {
+func addTask(l Logger, f Formatter, p Parser, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo, workspaceRepo usecase.WorkspaceRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
 		u := auth.GetUser(w)
 		if u.ID().IsEmpty() {
-			l.Printf("error retrieving required user from http.ResponseWriter: %v", w)
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
 			f.ErrUnauthorized(w)
 			return
 		}
-		t, ucerr := p.AddTask(r.Body, u.ID())
+		t, err := p.AddTask(r.Body, u.ID())
 		defer r.Body.Close()
-		if ucerr != nil {
-			l.Printf("error parsing addTask data: %v", ucerr)
-			f.WriteResponse(w, f.Errorf("Error: could not parse task data: %v", ucerr), 400)
+		if err != nil {
+			l.Warnf("error parsing addTask data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse task data: %v", err), 400)
 			return
 		}
-		td, ucerr := usecase.AddTask(taskRepo, t)
+		if ucerr := usecase.CheckWorkspaceMember(r.Context(), workspaceRepo, t.Workspace(), u.ID()); ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Error: workspace ID %v not found", t.Workspace()), 400)
+				return
+			}
+			l.Errorf("error checking task workspace: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not add task data"), 500)
+			return
+		}
+		td, ucerr := usecase.AddTask(r.Context(), taskRepo, activityRepo, t)
 		if ucerr != nil {
-			l.Printf("error adding task: %v", ucerr)
+			if ucerr.Code() == usecase.ErrInvalidData {
+				f.WriteResponse(w, f.Errorf("Error: invalid task data: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error adding task: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error: could not add task data"), 500)
 			return
 		}
@@ -127,29 +214,77 @@ func addTask(l Logger, f Formatter, p Parser, taskRepo usecase.TaskRepo) httprou
 	}
 }
 
-func completeTask(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.Handle {
+func updateTask(l Logger, f Formatter, p Parser, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
 		if err != nil {
-			l.Printf("valid task ID required")
+			l.Warnf("valid task ID required")
 			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		uid := u.ID()
 		if uid.IsEmpty() {
-			l.Printf("error retrieving required user from http.ResponseWriter: %v", w)
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
 			f.ErrUnauthorized(w)
 			return
 		}
+		tu, err := p.UpdateTask(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing updateTask data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse task data: %v", err), 400)
+			return
+		}
 		id := usecase.TaskID(taskIDInt)
-		ok, ucerr := usecase.CompleteTask(taskRepo, id, uid)
+		td, ucerr := usecase.UpdateTask(r.Context(), taskRepo, activityRepo, id, uid, tu)
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrRecordNotFound:
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: invalid task data: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error updating task: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error updating task"), 500)
+			return
+		}
+		o, err := f.Task(td)
+		if err != nil {
+			f.WriteResponse(w, f.Error("Task updated, but there was an error formatting the response"), 200)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func completeTask(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		ok, ucerr := usecase.CompleteTask(r.Context(), taskRepo, activityRepo, id, uid)
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error completing task: %v", ucerr)
+			l.Errorf("error completing task: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error completing task"), 500)
 			return
 		}
@@ -161,29 +296,233 @@ func completeTask(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.H
 	}
 }
 
-func clearTask(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.Handle {
+func uncompleteTask(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		ok, ucerr := usecase.UncompleteTask(r.Context(), taskRepo, activityRepo, id, uid)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			}
+			l.Errorf("error uncompleting task: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error uncompleting task"), 500)
+			return
+		}
+		if !ok {
+			f.WriteResponse(w, f.Errorf("Task %v is not completed", id), 400)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+// checkTaskItem checks or unchecks a task checklist item, given its zero-based position in the checklist
+func checkTaskItem(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo, checked bool) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		index, err := strconv.Atoi(ps.ByName("item"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid checklist item position required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		var ok bool
+		var ucerr usecase.Error
+		if checked {
+			ok, ucerr = usecase.CheckTaskItem(r.Context(), taskRepo, activityRepo, id, uid, index)
+		} else {
+			ok, ucerr = usecase.UncheckTaskItem(r.Context(), taskRepo, activityRepo, id, uid, index)
+		}
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Task ID %d checklist item %d not found", id, index), 404)
+				return
+			}
+			l.Errorf("error changing task checklist item: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error changing task checklist item"), 500)
+			return
+		}
+		if !ok {
+			if checked {
+				f.WriteResponse(w, f.Errorf("Task %v checklist item %d already checked", id, index), 400)
+			} else {
+				f.WriteResponse(w, f.Errorf("Task %v checklist item %d is not checked", id, index), 400)
+			}
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func tagTask(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		tag, err := task.NewTag(ps.ByName("tag"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", err), 400)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		_, ucerr := usecase.TagTask(r.Context(), taskRepo, activityRepo, id, uid, tag)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			}
+			l.Errorf("error tagging task: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error tagging task"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func untagTask(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		tag, err := task.NewTag(ps.ByName("tag"))
+		if err != nil {
+			f.WriteResponse(w, f.Errorf("Error: %v", err), 400)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		ok, ucerr := usecase.UntagTask(r.Context(), taskRepo, activityRepo, id, uid, tag)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			}
+			l.Errorf("error untagging task: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error untagging task"), 500)
+			return
+		}
+		if !ok {
+			f.WriteResponse(w, f.Errorf("Task %v does not have tag '%v'", id, tag), 404)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+// assignTask assigns a task to the user in the 'userID' path parameter, or unassigns it if there is none
+func assignTask(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		assignee := user.ID{}
+		if val := ps.ByName("userID"); val != "" {
+			if assignee, err = user.ParseID(val); err != nil {
+				f.WriteResponse(w, f.Errorf("Error: invalid user ID '%v'", val), 400)
+				return
+			}
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		if ucerr := usecase.AssignTask(r.Context(), taskRepo, activityRepo, id, uid, assignee); ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrRecordNotFound:
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: user %v cannot be assigned task ID %d", assignee, id), 400)
+				return
+			}
+			l.Errorf("error assigning task: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error assigning task"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func clearTask(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		taskIDInt, err := strconv.Atoi(params.ByName("taskID"))
 		if err != nil {
-			l.Printf("valid task ID required")
+			l.Warnf("valid task ID required")
 			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
 			return
 		}
 		u := auth.GetUser(w)
 		uid := u.ID()
 		if uid.IsEmpty() {
-			l.Printf("error retrieving required user from http.ResponseWriter: %v", w)
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
 			f.ErrUnauthorized(w)
 			return
 		}
 		id := usecase.TaskID(taskIDInt)
-		ok, ucerr := usecase.ClearTask(taskRepo, id, uid)
+		ok, ucerr := usecase.ClearTask(r.Context(), taskRepo, activityRepo, id, uid)
 		if ucerr != nil {
 			if ucerr.Code() == usecase.ErrRecordNotFound {
 				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
 				return
 			}
-			l.Printf("error clearing task: %v", ucerr)
+			l.Errorf("error clearing task: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error clearing task"), 500)
 			return
 		}
@@ -195,22 +534,23 @@ func clearTask(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.Hand
 	}
 }
 
-func clearCompletedTasks(l Logger, f Formatter, taskRepo usecase.TaskRepo) httprouter.Handle {
+func clearCompletedTasks(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
 		u := auth.GetUser(w)
 		uid := u.ID()
 		if uid.IsEmpty() {
-			l.Printf("error retrieving required user from http.ResponseWriter: %v", w)
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
 			f.ErrUnauthorized(w)
 			return
 		}
-		count, ucerr := usecase.ClearCompletedTasks(taskRepo, uid)
+		ids, ucerr := usecase.ClearCompletedTaskIDs(r.Context(), taskRepo, activityRepo, uid)
 		if ucerr != nil {
-			l.Printf("error clearing completed tasks: %v", ucerr)
+			l.Errorf("error clearing completed tasks: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error clearing completed tasks"), 500)
 			return
 		}
-		o, err := f.ClearedCompleted(count)
+		o, err := f.ClearedCompleted(len(ids))
 		if err != nil {
 			f.WriteResponse(w, f.Error("Completed tasks cleared, but there was an error formatting the response"), 200)
 			return
@@ -218,3 +558,163 @@ func clearCompletedTasks(l Logger, f Formatter, taskRepo usecase.TaskRepo) httpr
 		f.WriteResponse(w, o, 200)
 	}
 }
+
+func listTaskActivity(l Logger, f Formatter, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		ads, ucerr := usecase.ListTaskActivity(r.Context(), taskRepo, activityRepo, id, uid)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			}
+			l.Errorf("error retrieving task activity: %v", ucerr)
+			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve activity for task ID %d", id), 500)
+			return
+		}
+		o, err := f.ActivityList(ads)
+		if err != nil {
+			l.Errorf("error encoding task activity: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding task activity data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+// getTaskAction returns the HTTP action call made for a task generated by a recurring task with an action
+func getTaskAction(l Logger, f Formatter, taskRepo usecase.TaskRepo, actionRepo usecase.ActionRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		c, ucerr := usecase.GetTaskAction(r.Context(), taskRepo, actionRepo, id, uid)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("No action found for task ID %d", id), 404)
+				return
+			}
+			l.Errorf("error retrieving task action: %v", ucerr)
+			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve action for task ID %d", id), 500)
+			return
+		}
+		o, err := f.Action(c)
+		if err != nil {
+			l.Errorf("error encoding task action: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding task action data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+// getTaskCommand returns the command run for a task generated by a recurring task with a command
+func getTaskCommand(l Logger, f Formatter, taskRepo usecase.TaskRepo, commandRepo usecase.CommandRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		run, ucerr := usecase.GetTaskCommand(r.Context(), taskRepo, commandRepo, id, uid)
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("No command found for task ID %d", id), 404)
+				return
+			}
+			l.Errorf("error retrieving task command: %v", ucerr)
+			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve command for task ID %d", id), 500)
+			return
+		}
+		o, err := f.Command(run)
+		if err != nil {
+			l.Errorf("error encoding task command: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding task command data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func addTaskComment(l Logger, f Formatter, p Parser, taskRepo usecase.TaskRepo, activityRepo usecase.ActivityRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		taskIDInt, err := strconv.Atoi(ps.ByName("taskID"))
+		if err != nil {
+			l.Warnf("valid task ID required")
+			f.WriteResponse(w, f.Error("Error: valid task ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		uid := u.ID()
+		if uid.IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		comment, err := p.AddComment(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing addComment data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse comment data: %v", err), 400)
+			return
+		}
+		id := usecase.TaskID(taskIDInt)
+		ad, ucerr := usecase.AddTaskComment(r.Context(), taskRepo, activityRepo, id, uid, comment)
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrRecordNotFound:
+				f.WriteResponse(w, f.Errorf("Task ID %d not found", id), 404)
+				return
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: invalid comment data: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error adding task comment: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error adding task comment"), 500)
+			return
+		}
+		o, err := f.ActivityID(ad.ActivityID)
+		if err != nil {
+			f.WriteResponse(w, f.Error("Comment added, but there was an error formatting the response ID"), 201)
+			return
+		}
+		f.WriteResponse(w, o, 201)
+	}
+}
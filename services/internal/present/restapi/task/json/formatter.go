@@ -2,7 +2,11 @@ package json
 
 import (
 	"encoding/json"
+	"time"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
@@ -20,16 +24,71 @@ func NewFormatter(rf format.ResponseFormatter) *Formatter {
 
 type outTask struct {
 	ID            usecase.TaskID `json:"id"`
+	WorkspaceID   *string        `json:"workspaceId,omitempty"`
+	Assignee      *string        `json:"assignee,omitempty"`
 	Name          string         `json:"name"`
 	Description   string         `json:"description"`
 	CompletedTime format.Time    `json:"completedTime"`
 	CreatedTime   format.Time    `json:"createdTime"`
+	DueTime       format.Time    `json:"dueTime"`
+	Priority      string         `json:"priority"`
+	Overdue       bool           `json:"overdue"`
+	Tags          []task.Tag     `json:"tags"`
+	Checklist     []outItem      `json:"checklist"`
+	AutoComplete  bool           `json:"autoComplete"`
+}
+
+type outItem struct {
+	Name        string      `json:"name"`
+	CheckedTime format.Time `json:"checkedTime"`
 }
 
 type outTaskID struct {
 	ID usecase.TaskID `json:"id"`
 }
 
+type outActivity struct {
+	ID          usecase.ActivityID `json:"id"`
+	Type        string             `json:"type"`
+	Comment     string             `json:"comment"`
+	CreatedTime format.Time        `json:"createdTime"`
+	CreatedBy   string             `json:"createdBy"`
+}
+
+type outActivityID struct {
+	ID usecase.ActivityID `json:"id"`
+}
+
+type outAction struct {
+	ID              string      `json:"id"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	Status          string      `json:"status"`
+	Attempts        int         `json:"attempts"`
+	StatusCode      int         `json:"statusCode"`
+	Response        string      `json:"response"`
+	LatencyMs       int64       `json:"latencyMs"`
+	LastError       string      `json:"lastError"`
+	CreatedTime     format.Time `json:"createdTime"`
+	LastAttemptTime format.Time `json:"lastAttemptTime"`
+	NextAttemptTime format.Time `json:"nextAttemptTime"`
+}
+
+type outCommand struct {
+	ID           string      `json:"id"`
+	Program      string      `json:"program"`
+	Args         []string    `json:"args"`
+	Status       string      `json:"status"`
+	ExitCode     int         `json:"exitCode"`
+	Stdout       string      `json:"stdout"`
+	Stderr       string      `json:"stderr"`
+	LastError    string      `json:"lastError"`
+	DurationMs   int64       `json:"durationMs"`
+	CreatedTime  format.Time `json:"createdTime"`
+	StartedTime  format.Time `json:"startedTime"`
+	FinishedTime format.Time `json:"finishedTime"`
+}
+
 type outClearedCompleted struct {
 	Count   int    `json:"count"`
 	Message string `json:"message"`
@@ -59,13 +118,25 @@ func (f *Formatter) TaskID(id usecase.TaskID) ([]byte, error) {
 }
 
 func taskToOut(id usecase.TaskID, t *task.Task) *outTask {
-	return &outTask{
+	o := &outTask{
 		ID:            id,
+		WorkspaceID:   t.Workspace().StringPtr(),
+		Assignee:      t.Assignee().StringPtr(),
 		Name:          t.Name(),
 		Description:   t.Description(),
 		CompletedTime: format.Time(t.CompletedTime()),
 		CreatedTime:   format.Time(t.CreatedTime()),
+		DueTime:       format.Time(t.DueTime()),
+		Priority:      t.Priority().String(),
+		Overdue:       t.IsOverdue(clock.Now()),
+		Tags:          t.Tags(),
+		Checklist:     []outItem{},
+		AutoComplete:  t.AutoComplete(),
+	}
+	for _, item := range t.Checklist() {
+		o.Checklist = append(o.Checklist, outItem{Name: item.Name(), CheckedTime: format.Time(item.CheckedTime())})
 	}
+	return o
 }
 
 // Task formats a Task to JSON
@@ -82,3 +153,75 @@ func (f *Formatter) TaskMap(ts map[usecase.TaskID]*task.Task) ([]byte, error) {
 
 	return json.Marshal(o)
 }
+
+// TaskList formats an ordered list of Tasks to JSON
+func (f *Formatter) TaskList(tds []usecase.TaskData) ([]byte, error) {
+	o := make([]*outTask, len(tds))
+	for i, td := range tds {
+		o[i] = taskToOut(td.TaskID, td.Task)
+	}
+
+	return json.Marshal(o)
+}
+
+// ActivityID formats an ActivityID to JSON
+func (f *Formatter) ActivityID(id usecase.ActivityID) ([]byte, error) {
+	o := &outActivityID{
+		ID: id,
+	}
+	return json.Marshal(o)
+}
+
+// ActivityList formats an ordered list of task activity entries to JSON
+func (f *Formatter) ActivityList(ads []usecase.ActivityData) ([]byte, error) {
+	o := make([]*outActivity, len(ads))
+	for i, ad := range ads {
+		o[i] = &outActivity{
+			ID:          ad.ActivityID,
+			Type:        ad.Activity.Type().String(),
+			Comment:     ad.Activity.Comment(),
+			CreatedTime: format.Time(ad.Activity.CreatedTime()),
+			CreatedBy:   ad.Activity.CreatedBy().String(),
+		}
+	}
+
+	return json.Marshal(o)
+}
+
+// Action formats a task's HTTP action call, with the outcome of its latest attempt, to JSON
+func (f *Formatter) Action(c *action.Call) ([]byte, error) {
+	o := &outAction{
+		ID:              c.ID().String(),
+		Method:          c.Request().Method(),
+		URL:             c.Request().URL(),
+		Status:          c.Status().String(),
+		Attempts:        c.Attempts(),
+		StatusCode:      c.StatusCode(),
+		Response:        c.Response(),
+		LatencyMs:       int64(c.Latency() / time.Millisecond),
+		LastError:       c.LastError(),
+		CreatedTime:     format.Time(c.CreatedTime()),
+		LastAttemptTime: format.Time(c.LastAttemptTime()),
+		NextAttemptTime: format.Time(c.NextAttemptTime()),
+	}
+	return json.Marshal(o)
+}
+
+// Command formats a task's command run, with its outcome, to JSON
+func (f *Formatter) Command(r *command.Run) ([]byte, error) {
+	o := &outCommand{
+		ID:           r.ID().String(),
+		Program:      r.Program(),
+		Args:         r.Args(),
+		Status:       r.Status().String(),
+		ExitCode:     r.ExitCode(),
+		Stdout:       r.Stdout(),
+		Stderr:       r.Stderr(),
+		LastError:    r.LastError(),
+		DurationMs:   int64(r.Duration() / time.Millisecond),
+		CreatedTime:  format.Time(r.CreatedTime()),
+		StartedTime:  format.Time(r.StartedTime()),
+		FinishedTime: format.Time(r.FinishedTime()),
+	}
+	return json.Marshal(o)
+}
@@ -2,10 +2,15 @@ package json
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	parse "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 // Parser handles JSON parsing
@@ -24,14 +29,122 @@ func (p *Parser) AddTask(b io.Reader, uid user.ID) (*task.Task, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parseAddTask(&addTask, uid), nil
+	return parseAddTask(&addTask, uid)
 }
 
 type addTask struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	DueTime      *parse.Time `json:"dueTime"`
+	Priority     string      `json:"priority"`
+	Tags         []string    `json:"tags"`
+	Checklist    []string    `json:"checklist"`
+	AutoComplete bool        `json:"autoComplete"`
+	WorkspaceID  string      `json:"workspaceId"`
 }
 
-func parseAddTask(at *addTask, uid user.ID) *task.Task {
-	return task.New(at.Name, at.Description, uid)
+func parseAddTask(at *addTask, uid user.ID) (*task.Task, error) {
+	t := task.New(at.Name, at.Description, uid)
+	if at.DueTime != nil {
+		t.SetDueTime(time.Time(*at.DueTime))
+	}
+	p, err := task.ParsePriority(at.Priority)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.SetPriority(p); err != nil {
+		return nil, err
+	}
+	tags, err := task.ParseTags(at.Tags)
+	if err != nil {
+		return nil, err
+	}
+	t.SetTags(tags)
+	items, err := task.ParseChecklist(at.Checklist)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.SetChecklist(items); err != nil {
+		return nil, err
+	}
+	t.SetAutoComplete(at.AutoComplete)
+	if at.WorkspaceID != "" {
+		wid, err := workspace.ParseID(at.WorkspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace ID '%v'", at.WorkspaceID)
+		}
+		t.SetWorkspace(wid)
+	}
+	return t, nil
+}
+
+// UpdateTask parses updateTask request JSON data into the task fields to change
+func (p *Parser) UpdateTask(b io.Reader) (usecase.TaskUpdate, error) {
+	var updateTask updateTask
+	err := json.NewDecoder(b).Decode(&updateTask)
+	if err != nil {
+		return usecase.TaskUpdate{}, err
+	}
+	return parseUpdateTask(&updateTask)
+}
+
+type updateTask struct {
+	Name         *string         `json:"name"`
+	Description  *string         `json:"description"`
+	DueTime      json.RawMessage `json:"dueTime"`
+	Priority     *string         `json:"priority"`
+	Tags         *[]string       `json:"tags"`
+	Checklist    *[]string       `json:"checklist"`
+	AutoComplete *bool           `json:"autoComplete"`
+}
+
+func parseUpdateTask(ut *updateTask) (usecase.TaskUpdate, error) {
+	tu := usecase.TaskUpdate{Name: ut.Name, Description: ut.Description}
+
+	// An explicit null due time removes it, an omitted due time leaves it unchanged
+	if ut.DueTime != nil {
+		var due parse.Time
+		if err := json.Unmarshal(ut.DueTime, &due); err != nil {
+			return usecase.TaskUpdate{}, err
+		}
+		dueTime := time.Time(due)
+		tu.DueTime = &dueTime
+	}
+	if ut.Priority != nil {
+		p, err := task.ParsePriority(*ut.Priority)
+		if err != nil {
+			return usecase.TaskUpdate{}, err
+		}
+		tu.Priority = &p
+	}
+	if ut.Tags != nil {
+		tags, err := task.ParseTags(*ut.Tags)
+		if err != nil {
+			return usecase.TaskUpdate{}, err
+		}
+		tu.Tags = &tags
+	}
+	if ut.Checklist != nil {
+		items, err := task.ParseChecklist(*ut.Checklist)
+		if err != nil {
+			return usecase.TaskUpdate{}, err
+		}
+		tu.Checklist = &items
+	}
+	tu.AutoComplete = ut.AutoComplete
+	return tu, nil
+}
+
+// AddComment parses addComment request JSON data into the comment text
+func (p *Parser) AddComment(b io.Reader) (string, error) {
+	var addComment addComment
+	err := json.NewDecoder(b).Decode(&addComment)
+	if err != nil {
+		return "", err
+	}
+	return addComment.Comment, nil
+}
+
+type addComment struct {
+	Comment string `json:"comment"`
 }
@@ -1,9 +1,11 @@
 package test
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
@@ -16,12 +18,30 @@ type Tester interface {
 
 // MockAPI contain the API mock and repos used during setup
 type MockAPI struct {
-	API          http.Handler
-	UserRepo     usecase.UserRepo
-	TaskRepo     usecase.TaskRepo
-	ScheduleRepo usecase.ScheduleRepo
+	API              http.Handler
+	UserRepo         usecase.UserRepo
+	TaskRepo         usecase.TaskRepo
+	ScheduleRepo     usecase.ScheduleRepo
+	ActivityRepo     usecase.ActivityRepo
+	WorkspaceRepo    usecase.WorkspaceRepo
+	RoleRepo         usecase.RoleRepo
+	TokenRepo        usecase.TokenRepo
+	CredentialRepo   usecase.CredentialRepo
+	WebhookRepo      usecase.WebhookRepo
+	NotificationRepo usecase.NotificationRepo
+	OutboxRepo       usecase.OutboxRepo
+	ActionRepo       usecase.ActionRepo
+	CommandRepo      usecase.CommandRepo
+	ScheduleRunRepo  usecase.ScheduleRunRepo
+	Subscribers      []usecase.EventSubscriber
 }
 
+// LocalAuthSecret is the secret local session tokens are signed with in test APIs
+const LocalAuthSecret = "local-auth-test-secret-of-at-least-32-bytes"
+
+// AdminUser is the external user ID listed as an admin in test APIs, users with it are granted the admin role when they sign in
+const AdminUser = "listed-admin"
+
 // NewUserWithPerm creates and adds a new user and injects a mock permission claim for them in the returned http.Handler
 func (m *MockAPI) NewUserWithPerm(displayname string, provider string, externalID string, perm auth.Permission) (*user.User, http.Handler) {
 	return m.NewUserWithPerms(displayname, provider, externalID, []auth.Permission{perm})
@@ -30,11 +50,25 @@ func (m *MockAPI) NewUserWithPerm(displayname string, provider string, externalI
 // NewUserWithPerms creates and adds a new user and injects mock permission claims for them in the returned http.Handler
 func (m *MockAPI) NewUserWithPerms(displayname string, provider string, externalID string, perms []auth.Permission) (*user.User, http.Handler) {
 	u := user.New(displayname)
-	m.UserRepo.AddExternal(u, provider, externalID)
+	m.UserRepo.AddExternal(context.Background(), u, provider, externalID)
 	api := InjectClaims(MockClaims{Issuer: provider, Subject: externalID, Permissions: perms}, m.API)
 	return u, api
 }
 
+// NewUserWithRole creates and adds a new user with a role and injects mock permission claims for them in the returned http.Handler
+func (m *MockAPI) NewUserWithRole(displayname string, provider string, externalID string, role user.Role, perms []auth.Permission) (*user.User, http.Handler) {
+	u := user.New(displayname)
+	u.SetRole(role)
+	m.UserRepo.AddExternal(context.Background(), u, provider, externalID)
+	api := InjectClaims(MockClaims{Issuer: provider, Subject: externalID, Permissions: perms}, m.API)
+	return u, api
+}
+
+// PublishEvents publishes all pending events to the API's event subscribers, as the event bus would in the background
+func (m *MockAPI) PublishEvents() {
+	usecase.PublishEvents(context.Background(), m.OutboxRepo, m.Subscribers, webhook.RetryPolicy{MaxAttempts: 1})
+}
+
 // Strp returns a pointer to the passed-in string
 func Strp(str string) *string {
 	return &str
@@ -1,8 +1,10 @@
 package test
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
 )
 
@@ -16,12 +18,11 @@ type MockClaims struct {
 	Issuer      string
 	Subject     string
 	Permissions []auth.Permission
+	UserID      user.ID
 }
 
-type injectContext struct {
-	http.ResponseWriter
-	claims MockClaims
-}
+// claimsKey is the request context key mock claims are injected with
+type claimsKey struct{}
 
 // NewAuthMock returns a mock AuthMock struct
 func NewAuthMock(l auth.Logger) *AuthMock {
@@ -31,8 +32,8 @@ func NewAuthMock(l auth.Logger) *AuthMock {
 // Authenticate authenticates a request and calls the next handler
 func (a *AuthMock) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if ic, ok := w.(injectContext); ok {
-			next.ServeHTTP(auth.ResponseContext{ResponseWriter: w, Auth: auth.Context(ic.claims)}, r)
+		if claims, ok := r.Context().Value(claimsKey{}).(MockClaims); ok {
+			next.ServeHTTP(auth.ResponseContext{ResponseWriter: w, Auth: auth.Context(claims)}, r)
 			return
 		}
 		next.ServeHTTP(auth.ResponseContext{ResponseWriter: w, Auth: auth.Context{}}, r)
@@ -42,6 +43,6 @@ func (a *AuthMock) Authenticate(next http.Handler) http.Handler {
 // InjectClaims injects claims into the current request
 func InjectClaims(claims MockClaims, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		next.ServeHTTP(injectContext{w, claims}, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
 	})
 }
@@ -8,7 +8,31 @@ import (
 type loggerStub struct{}
 
 func (l *loggerStub) Printf(format string, v ...interface{}) {
+	l.log("LOG", format, v...)
+}
+
+func (l *loggerStub) Warnf(format string, v ...interface{}) {
+	l.log("WARN", format, v...)
+}
+
+func (l *loggerStub) Errorf(format string, v ...interface{}) {
+	l.log("ERROR", format, v...)
+}
+
+func (l *loggerStub) Debug(msg string, kv ...interface{}) {
+	l.log("DEBUG", "%v %v", msg, kv)
+}
+
+func (l *loggerStub) Info(msg string, kv ...interface{}) {
+	l.log("INFO", "%v %v", msg, kv)
+}
+
+func (l *loggerStub) Error(msg string, kv ...interface{}) {
+	l.log("ERROR", "%v %v", msg, kv)
+}
+
+func (l *loggerStub) log(level string, format string, v ...interface{}) {
 	if testing.Verbose() {
-		fmt.Printf(fmt.Sprintf("    LOG: %v\n", format), v...)
+		fmt.Printf(fmt.Sprintf("    %v: %v\n", level, format), v...)
 	}
 }
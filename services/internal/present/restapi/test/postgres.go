@@ -3,7 +3,12 @@ package test
 import (
 	"github.com/benjohns1/scheduled-tasks/services/internal/data/postgres"
 	pgtest "github.com/benjohns1/scheduled-tasks/services/internal/data/postgres/test"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/stream"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/webhook"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 type postgresTester struct {
@@ -30,11 +35,63 @@ func (m *postgresTester) NewAPI() MockAPI {
 	if err != nil {
 		panic(err)
 	}
+	activityRepo, err := postgres.NewActivityRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	workspaceRepo, err := postgres.NewWorkspaceRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	roleRepo, err := postgres.NewRoleRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	tokenRepo, err := postgres.NewTokenRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	credRepo, err := postgres.NewCredentialRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	webhookRepo, err := postgres.NewWebhookRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	notificationRepo, err := postgres.NewNotificationRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	outboxRepo, err := postgres.NewOutboxRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	actionRepo, err := postgres.NewActionRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	commandRepo, err := postgres.NewCommandRepo(conn)
+	if err != nil {
+		panic(err)
+	}
+	scheduleRunRepo, err := postgres.NewScheduleRunRepo(conn)
+	if err != nil {
+		panic(err)
+	}
 	l := &loggerStub{}
 	c := make(chan<- bool)
-	authMock := NewAuthMock(l)
-	api := restapi.New(l, authMock, c, userRepo, taskRepo, scheduleRepo)
-	return MockAPI{api, userRepo, taskRepo, scheduleRepo}
+	local, err := auth.NewLocal(l, credRepo, auth.LocalConfig{Secret: []byte(LocalAuthSecret)}, NewAuthMock(l))
+	if err != nil {
+		panic(err)
+	}
+	authMock := auth.NewPersonalToken(l, tokenRepo, local)
+	hc := health.Config{DBs: map[string]health.DB{"api": m.prevConn}, Schema: m.prevConn, LatestSchemaVersion: postgres.LatestSchemaVersion()}
+	// webhook deliveries are queued when events are published, but never sent, since the dispatcher isn't run
+	events := webhook.NewDispatcher(l, webhookRepo, workspaceRepo, taskRepo, scheduleRepo, webhook.Config{})
+	hub := stream.NewHub(l, taskRepo, scheduleRepo, workspaceRepo, stream.Config{})
+	api := restapi.New(l, authMock, c, userRepo, taskRepo, scheduleRepo, activityRepo, workspaceRepo, roleRepo, tokenRepo, credRepo, webhookRepo, notificationRepo, actionRepo, commandRepo, scheduleRunRepo, hub, local, auth.Admins{AdminUser}, restapi.Limits{}, nil, hc)
+	return MockAPI{api, userRepo, taskRepo, scheduleRepo, activityRepo, workspaceRepo, roleRepo, tokenRepo, credRepo, webhookRepo, notificationRepo, outboxRepo, actionRepo, commandRepo, scheduleRunRepo, []usecase.EventSubscriber{events, hub}}
 }
 
 func (m *postgresTester) Close() error {
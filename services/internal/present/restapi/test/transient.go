@@ -2,7 +2,12 @@ package test
 
 import (
 	"github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/stream"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/webhook"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/health"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 type transientTester struct{}
@@ -12,10 +17,34 @@ func (m *transientTester) NewAPI() MockAPI {
 	userRepo := transient.NewUserRepo()
 	taskRepo := transient.NewTaskRepo()
 	scheduleRepo := transient.NewScheduleRepo()
+	activityRepo := transient.NewActivityRepo()
+	workspaceRepo := transient.NewWorkspaceRepo()
+	roleRepo := transient.NewRoleRepo()
+	tokenRepo := transient.NewTokenRepo()
+	credRepo := transient.NewCredentialRepo()
+	webhookRepo := transient.NewWebhookRepo()
+	notificationRepo := transient.NewNotificationRepo()
+	outboxRepo := transient.NewOutboxRepo()
+	actionRepo := transient.NewActionRepo()
+	commandRepo := transient.NewCommandRepo()
+	scheduleRunRepo := transient.NewScheduleRunRepo()
+	taskRepo.SetWorkspaceRepo(workspaceRepo)
+	scheduleRepo.SetWorkspaceRepo(workspaceRepo)
+	taskRepo.SetOutboxRepo(outboxRepo)
+	scheduleRepo.SetOutboxRepo(outboxRepo)
+	taskRepo.SetActionRepo(actionRepo)
+	taskRepo.SetCommandRepo(commandRepo)
 	c := make(chan<- bool)
-	authMock := NewAuthMock(l)
-	api := restapi.New(l, authMock, c, userRepo, taskRepo, scheduleRepo)
-	return MockAPI{api, userRepo, taskRepo, scheduleRepo}
+	local, err := auth.NewLocal(l, credRepo, auth.LocalConfig{Secret: []byte(LocalAuthSecret)}, NewAuthMock(l))
+	if err != nil {
+		panic(err)
+	}
+	authMock := auth.NewPersonalToken(l, tokenRepo, local)
+	// webhook deliveries are queued when events are published, but never sent, since the dispatcher isn't run
+	events := webhook.NewDispatcher(l, webhookRepo, workspaceRepo, taskRepo, scheduleRepo, webhook.Config{})
+	hub := stream.NewHub(l, taskRepo, scheduleRepo, workspaceRepo, stream.Config{})
+	api := restapi.New(l, authMock, c, userRepo, taskRepo, scheduleRepo, activityRepo, workspaceRepo, roleRepo, tokenRepo, credRepo, webhookRepo, notificationRepo, actionRepo, commandRepo, scheduleRunRepo, hub, local, auth.Admins{AdminUser}, restapi.Limits{}, nil, health.Config{})
+	return MockAPI{api, userRepo, taskRepo, scheduleRepo, activityRepo, workspaceRepo, roleRepo, tokenRepo, credRepo, webhookRepo, notificationRepo, outboxRepo, actionRepo, commandRepo, scheduleRunRepo, []usecase.EventSubscriber{events, hub}}
 }
 
 func (m *transientTester) Close() error {
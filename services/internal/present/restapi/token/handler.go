@@ -0,0 +1,124 @@
+package token
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/token/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	NewToken(t *token.Token, secret string) ([]byte, error)
+	TokenList(ts []*token.Token) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	AddToken(b io.Reader) (mapper.AddToken, error)
+}
+
+// Handle adds personal access token handling endpoints
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, tokenRepo usecase.TokenRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/token"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermManageTokens, true, l, f, listTokens(l, f, tokenRepo)))
+	r.POST(pre+"/", auth.HRAuthorize(auth.PermManageTokens, true, l, f, addToken(l, f, p, tokenRepo)))
+	r.DELETE(pre+"/:tokenID", auth.HRAuthorize(auth.PermManageTokens, true, l, f, revokeToken(l, f, tokenRepo)))
+}
+
+func listTokens(l Logger, f Formatter, tokenRepo usecase.TokenRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		ts, ucerr := usecase.ListTokens(r.Context(), tokenRepo, u.ID())
+		if ucerr != nil {
+			l.Errorf("error retrieving token list: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't retrieve tokens"), 500)
+			return
+		}
+		o, err := f.TokenList(ts)
+		if err != nil {
+			l.Errorf("error encoding token list: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding token data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func addToken(l Logger, f Formatter, p Parser, tokenRepo usecase.TokenRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		at, err := p.AddToken(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing addToken data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse token data: %v", err), 400)
+			return
+		}
+		t, secret, ucerr := usecase.AddToken(r.Context(), tokenRepo, at.Name, u.ID(), at.Scopes, auth.GetTokenGrant(w), at.ExpiresTime)
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrInvalidData:
+				f.WriteResponse(w, f.Errorf("Error: invalid token data: %v", ucerr), 400)
+				return
+			case usecase.ErrForbidden:
+				f.WriteResponse(w, f.Errorf("Error: %v", ucerr), 403)
+				return
+			}
+			l.Errorf("error adding token: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not add token"), 500)
+			return
+		}
+		o, err := f.NewToken(t, secret)
+		if err != nil {
+			l.Errorf("error encoding new token: %v", err)
+			f.WriteResponse(w, f.Error("Token created, but there was an error formatting the response token"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 201)
+	}
+}
+
+func revokeToken(l Logger, f Formatter, tokenRepo usecase.TokenRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		id, err := token.ParseID(ps.ByName("tokenID"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid token ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		if ucerr := usecase.RevokeToken(r.Context(), tokenRepo, id, u.ID()); ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Token ID %v not found", id), 404)
+				return
+			}
+			l.Errorf("error revoking token: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error revoking token"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
@@ -0,0 +1,53 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outToken struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Scopes      []string    `json:"scopes"`
+	CreatedTime format.Time `json:"createdTime"`
+	ExpiresTime format.Time `json:"expiresTime"`
+	RevokedTime format.Time `json:"revokedTime"`
+}
+
+type outNewToken struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+// NewToken formats a new token's ID and secret to JSON
+func (f *Formatter) NewToken(t *token.Token, secret string) ([]byte, error) {
+	return json.Marshal(&outNewToken{ID: t.ID().String(), Token: secret})
+}
+
+// TokenList formats an ordered list of tokens to JSON, token secrets are never included
+func (f *Formatter) TokenList(ts []*token.Token) ([]byte, error) {
+	o := make([]*outToken, len(ts))
+	for i, t := range ts {
+		o[i] = &outToken{
+			ID:          t.ID().String(),
+			Name:        t.Name(),
+			Scopes:      append([]string{}, t.Scopes()...),
+			CreatedTime: format.Time(t.CreatedTime()),
+			ExpiresTime: format.Time(t.ExpiresTime()),
+			RevokedTime: format.Time(t.RevokedTime()),
+		}
+	}
+	return json.Marshal(o)
+}
@@ -0,0 +1,54 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	parse "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// AddToken is the parsed data for a new personal access token
+type AddToken struct {
+	Name        string
+	Scopes      []string
+	ExpiresTime time.Time
+}
+
+// AddToken parses addToken request JSON data
+func (p *Parser) AddToken(b io.Reader) (AddToken, error) {
+	var addToken addToken
+	if err := json.NewDecoder(b).Decode(&addToken); err != nil {
+		return AddToken{}, err
+	}
+	scopes := []string{}
+	for _, scope := range addToken.Scopes {
+		perm, ok := auth.ParsePermission(scope)
+		if !ok {
+			return AddToken{}, fmt.Errorf("unknown permission '%v'", scope)
+		}
+		scopes = append(scopes, perm.Scope())
+	}
+	t := AddToken{Name: addToken.Name, Scopes: scopes}
+	if addToken.ExpiresTime != nil {
+		t.ExpiresTime = time.Time(*addToken.ExpiresTime)
+	}
+	return t, nil
+}
+
+type addToken struct {
+	Name        string      `json:"name"`
+	Scopes      []string    `json:"scopes"`
+	ExpiresTime *parse.Time `json:"expiresTime"`
+}
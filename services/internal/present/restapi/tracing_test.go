@@ -0,0 +1,116 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceRequests(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(prevProvider)
+	defer otel.SetTextMapPropagator(prevPropagator)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		traceparent string
+		status      int
+		wantSpan    string
+		wantTraceID string
+		wantError   bool
+	}{
+		{
+			name:        "should continue an incoming trace",
+			method:      "GET",
+			path:        "/api/v1/task/5",
+			traceparent: "00-" + traceID + "-" + parentID + "-01",
+			status:      http.StatusOK,
+			wantSpan:    "GET /api/v1/task/:taskID",
+			wantTraceID: traceID,
+		},
+		{
+			name:     "should start a new trace without a traceparent header",
+			method:   "PUT",
+			path:     "/api/v1/task/5/tag/urgent",
+			status:   http.StatusNoContent,
+			wantSpan: "PUT /api/v1/task/:taskID/tag/:tag",
+		},
+		{
+			name:      "should mark server errors",
+			method:    "GET",
+			path:      "/api/v1/task/",
+			status:    http.StatusInternalServerError,
+			wantSpan:  "GET /api/v1/task/",
+			wantError: true,
+		},
+		{
+			name:   "should not trace health probes",
+			method: "GET",
+			path:   HealthPath,
+			status: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevEnded := len(sr.Ended())
+			var handlerSpan trace.SpanContext
+			h := traceRequests(newTestRouter(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerSpan = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(tt.status)
+			}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			ended := sr.Ended()[prevEnded:]
+			if tt.wantSpan == "" {
+				if len(ended) != 0 {
+					t.Errorf("traceRequests() recorded %d spans, want none", len(ended))
+				}
+				return
+			}
+			if len(ended) != 1 {
+				t.Fatalf("traceRequests() recorded %d spans, want 1", len(ended))
+			}
+			span := ended[0]
+			if span.Name() != tt.wantSpan {
+				t.Errorf("span name = %v, want %v", span.Name(), tt.wantSpan)
+			}
+			if span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span kind = %v, want %v", span.SpanKind(), trace.SpanKindServer)
+			}
+			if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("handler context span = %v, want %v", handlerSpan.SpanID(), span.SpanContext().SpanID())
+			}
+			if tt.wantTraceID != "" {
+				if got := span.SpanContext().TraceID().String(); got != tt.wantTraceID {
+					t.Errorf("trace ID = %v, want %v", got, tt.wantTraceID)
+				}
+				if got := span.Parent().SpanID().String(); got != parentID {
+					t.Errorf("parent span ID = %v, want %v", got, parentID)
+				}
+			} else if span.Parent().IsValid() {
+				t.Errorf("span has parent %v, want a new trace", span.Parent().SpanID())
+			}
+			if gotError := span.Status().Code == codes.Error; gotError != tt.wantError {
+				t.Errorf("span error status = %v, want %v", gotError, tt.wantError)
+			}
+		})
+	}
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
 	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
 	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
 	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/user/json"
 	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
@@ -15,6 +17,8 @@ import (
 // Logger interface needed for log messages
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
 }
 
 // Formatter defines the formatter interface for output responses
@@ -28,7 +32,7 @@ type Parser interface {
 }
 
 // Handle adds user handling endpoints
-func Handle(r *httprouter.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, userRepo usecase.UserRepo) {
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, userRepo usecase.UserRepo) {
 
 	p := mapper.NewParser()
 	f := mapper.NewFormatter(rf)
@@ -39,9 +43,10 @@ func Handle(r *httprouter.Router, prefix string, l Logger, rf responseMapper.Res
 
 func paramsMatchLoggedInUser(l Logger, f Formatter, next httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		userContext, ok := w.(auth.UserContext)
 		if !ok {
-			l.Printf("invalid authorization context from http.ResponseWriter: %v", w)
+			l.Errorf("invalid authorization context from http.ResponseWriter: %v", w)
 			f.WriteResponse(w, f.Error("Internal authorization error"), 500)
 			return
 		}
@@ -49,7 +54,7 @@ func paramsMatchLoggedInUser(l Logger, f Formatter, next httprouter.Handle) http
 		providerID := ps.ByName("providerID")
 		userID := ps.ByName("userID")
 		if auth.FormatProvider(providerID) != userContext.Auth.Issuer || userID != userContext.Auth.Subject {
-			l.Printf("external user credentials (%v, %v) do not match logged-in user: %v", providerID, userID, userContext)
+			l.Warnf("external user credentials (%v, %v) do not match logged-in user: %v", providerID, userID, userContext)
 			f.ErrUnauthorized(w)
 			return
 		}
@@ -60,11 +65,12 @@ func paramsMatchLoggedInUser(l Logger, f Formatter, next httprouter.Handle) http
 
 func addOrUpdateExternalUser(l Logger, p Parser, f Formatter, userRepo usecase.UserRepo) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
 		// Validate URL params
 		providerID := ps.ByName("providerID")
 		userID := ps.ByName("userID")
 		if providerID == "" || userID == "" {
-			l.Printf("valid provider and user IDs required")
+			l.Warnf("valid provider and user IDs required")
 			f.WriteResponse(w, f.Error("Error: valid provider and user IDs required"), 404)
 			return
 		}
@@ -73,14 +79,14 @@ func addOrUpdateExternalUser(l Logger, p Parser, f Formatter, userRepo usecase.U
 		userData, ucerr := p.AddOrUpdateUser(r.Body)
 		defer r.Body.Close()
 		if ucerr != nil {
-			l.Printf("error parsing AddOrUpdateUser data: %v", ucerr)
+			l.Warnf("error parsing AddOrUpdateUser data: %v", ucerr)
 			f.WriteResponse(w, f.Errorf("Error: could not parse user data: %v", ucerr), 400)
 			return
 		}
 
-		_, ucerr = usecase.AddOrUpdateExternalUser(userRepo, provider, userID, userData.DisplayName)
+		_, ucerr = usecase.AddOrUpdateExternalUser(r.Context(), userRepo, provider, userID, userData.DisplayName)
 		if ucerr != nil {
-			l.Printf("error adding or updating external user: %v", ucerr)
+			l.Errorf("error adding or updating external user: %v", ucerr)
 			f.WriteResponse(w, f.Error("Error adding or updating external user"), 500)
 			return
 		}
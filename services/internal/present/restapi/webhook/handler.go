@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/webhook/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	NewWebhook(wh *webhook.Webhook) ([]byte, error)
+	WebhookList(whs []*webhook.Webhook) ([]byte, error)
+	DeliveryList(ds []*webhook.Delivery) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	AddWebhook(b io.Reader) (mapper.AddWebhook, error)
+}
+
+// Handle adds webhook handling endpoints
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, webhookRepo usecase.WebhookRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/webhook"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermManageWebhooks, true, l, f, listWebhooks(l, f, webhookRepo)))
+	r.POST(pre+"/", auth.HRAuthorize(auth.PermManageWebhooks, true, l, f, addWebhook(l, f, p, webhookRepo)))
+	r.DELETE(pre+"/:webhookID", auth.HRAuthorize(auth.PermManageWebhooks, true, l, f, removeWebhook(l, f, webhookRepo)))
+	r.GET(pre+"/:webhookID/delivery/", auth.HRAuthorize(auth.PermManageWebhooks, true, l, f, listDeliveries(l, f, webhookRepo)))
+}
+
+func listWebhooks(l Logger, f Formatter, webhookRepo usecase.WebhookRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		whs, ucerr := usecase.ListWebhooks(r.Context(), webhookRepo, u.ID())
+		if ucerr != nil {
+			l.Errorf("error retrieving webhook list: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't retrieve webhooks"), 500)
+			return
+		}
+		o, err := f.WebhookList(whs)
+		if err != nil {
+			l.Errorf("error encoding webhook list: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding webhook data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func addWebhook(l Logger, f Formatter, p Parser, webhookRepo usecase.WebhookRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		aw, err := p.AddWebhook(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing addWebhook data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse webhook data: %v", err), 400)
+			return
+		}
+		wh, ucerr := usecase.AddWebhook(r.Context(), webhookRepo, aw.URL, aw.Events, aw.Secret, u.ID())
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrInvalidData {
+				f.WriteResponse(w, f.Errorf("Error: invalid webhook data: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error adding webhook: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not add webhook"), 500)
+			return
+		}
+		o, err := f.NewWebhook(wh)
+		if err != nil {
+			l.Errorf("error encoding new webhook: %v", err)
+			f.WriteResponse(w, f.Error("Webhook created, but there was an error formatting the response webhook"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 201)
+	}
+}
+
+func removeWebhook(l Logger, f Formatter, webhookRepo usecase.WebhookRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		id, err := webhook.ParseID(ps.ByName("webhookID"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid webhook ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		if ucerr := usecase.RemoveWebhook(r.Context(), webhookRepo, id, u.ID()); ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Webhook ID %v not found", id), 404)
+				return
+			}
+			l.Errorf("error removing webhook: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error removing webhook"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
+
+func listDeliveries(l Logger, f Formatter, webhookRepo usecase.WebhookRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		id, err := webhook.ParseID(ps.ByName("webhookID"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid webhook ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		ds, ucerr := usecase.ListWebhookDeliveries(r.Context(), webhookRepo, id, u.ID())
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Webhook ID %v not found", id), 404)
+				return
+			}
+			l.Errorf("error retrieving webhook deliveries: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't retrieve webhook deliveries"), 500)
+			return
+		}
+		o, err := f.DeliveryList(ds)
+		if err != nil {
+			l.Errorf("error encoding webhook delivery list: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding webhook delivery data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
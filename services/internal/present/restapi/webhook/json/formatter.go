@@ -0,0 +1,86 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outWebhook struct {
+	ID          string      `json:"id"`
+	URL         string      `json:"url"`
+	Events      []string    `json:"events"`
+	CreatedTime format.Time `json:"createdTime"`
+}
+
+type outNewWebhook struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type outDelivery struct {
+	ID              string          `json:"id"`
+	Event           string          `json:"event"`
+	Status          string          `json:"status"`
+	Attempts        int             `json:"attempts"`
+	StatusCode      int             `json:"statusCode"`
+	LastError       string          `json:"lastError"`
+	Payload         json.RawMessage `json:"payload"`
+	CreatedTime     format.Time     `json:"createdTime"`
+	LastAttemptTime format.Time     `json:"lastAttemptTime"`
+	NextAttemptTime format.Time     `json:"nextAttemptTime"`
+}
+
+// NewWebhook formats a new webhook's ID and signing secret to JSON
+func (f *Formatter) NewWebhook(wh *webhook.Webhook) ([]byte, error) {
+	return json.Marshal(&outNewWebhook{ID: wh.ID().String(), Secret: wh.Secret()})
+}
+
+// WebhookList formats an ordered list of webhooks to JSON, webhook secrets are never included
+func (f *Formatter) WebhookList(whs []*webhook.Webhook) ([]byte, error) {
+	o := make([]*outWebhook, len(whs))
+	for i, wh := range whs {
+		events := make([]string, len(wh.Events()))
+		for j, e := range wh.Events() {
+			events[j] = string(e)
+		}
+		o[i] = &outWebhook{
+			ID:          wh.ID().String(),
+			URL:         wh.URL(),
+			Events:      events,
+			CreatedTime: format.Time(wh.CreatedTime()),
+		}
+	}
+	return json.Marshal(o)
+}
+
+// DeliveryList formats an ordered list of webhook deliveries to JSON
+func (f *Formatter) DeliveryList(ds []*webhook.Delivery) ([]byte, error) {
+	o := make([]*outDelivery, len(ds))
+	for i, d := range ds {
+		o[i] = &outDelivery{
+			ID:              d.ID().String(),
+			Event:           string(d.Event()),
+			Status:          d.Status().String(),
+			Attempts:        d.Attempts(),
+			StatusCode:      d.StatusCode(),
+			LastError:       d.LastError(),
+			Payload:         json.RawMessage(d.Payload()),
+			CreatedTime:     format.Time(d.CreatedTime()),
+			LastAttemptTime: format.Time(d.LastAttemptTime()),
+			NextAttemptTime: format.Time(d.NextAttemptTime()),
+		}
+	}
+	return json.Marshal(o)
+}
@@ -0,0 +1,48 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// AddWebhook is the parsed data for a new webhook
+type AddWebhook struct {
+	URL    string
+	Events []webhook.EventType
+	Secret string
+}
+
+// AddWebhook parses addWebhook request JSON data
+func (p *Parser) AddWebhook(b io.Reader) (AddWebhook, error) {
+	var addWebhook addWebhook
+	if err := json.NewDecoder(b).Decode(&addWebhook); err != nil {
+		return AddWebhook{}, err
+	}
+	events := []webhook.EventType{}
+	for _, name := range addWebhook.Events {
+		e, ok := webhook.ParseEventType(name)
+		if !ok {
+			return AddWebhook{}, fmt.Errorf("unknown event type '%v'", name)
+		}
+		events = append(events, e)
+	}
+	return AddWebhook{URL: addWebhook.URL, Events: events, Secret: addWebhook.Secret}, nil
+}
+
+type addWebhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
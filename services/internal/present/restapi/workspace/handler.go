@@ -0,0 +1,177 @@
+package workspace
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	"github.com/benjohns1/scheduled-tasks/services/internal/infra/logging"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/auth"
+	"github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/httprouterwrap"
+	responseMapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+	mapper "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/workspace/json"
+	"github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+// Logger interface needed for log messages
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Formatter defines the formatter interface for output responses
+type Formatter interface {
+	WorkspaceID(id workspace.ID) ([]byte, error)
+	Workspace(w *workspace.Workspace) ([]byte, error)
+	WorkspaceList(ws []*workspace.Workspace) ([]byte, error)
+	responseMapper.ResponseFormatter
+}
+
+// Parser defines the parser interface for parsing input requests
+type Parser interface {
+	AddWorkspace(b io.Reader) (string, error)
+	InviteMember(b io.Reader) (user.ID, workspace.Role, error)
+}
+
+// Handle adds workspace handling endpoints
+func Handle(r *httprouterwrap.Router, prefix string, l Logger, rf responseMapper.ResponseFormatter, workspaceRepo usecase.WorkspaceRepo, userRepo usecase.UserRepo) {
+
+	p := mapper.NewParser()
+	f := mapper.NewFormatter(rf)
+
+	pre := prefix + "/workspace"
+	r.GET(pre+"/", auth.HRAuthorize(auth.PermReadWorkspace, true, l, f, listWorkspaces(l, f, workspaceRepo)))
+	r.GET(pre+"/:workspaceID", auth.HRAuthorize(auth.PermReadWorkspace, true, l, f, getWorkspace(l, f, workspaceRepo)))
+	r.POST(pre+"/", auth.HRAuthorize(auth.PermUpsertWorkspace, true, l, f, addWorkspace(l, f, p, workspaceRepo)))
+	r.POST(pre+"/:workspaceID/member", auth.HRAuthorize(auth.PermUpsertWorkspace, true, l, f, inviteMember(l, f, p, workspaceRepo, userRepo)))
+}
+
+func listWorkspaces(l Logger, f Formatter, workspaceRepo usecase.WorkspaceRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		ws, ucerr := usecase.ListWorkspaces(r.Context(), workspaceRepo, u.ID())
+		if ucerr != nil {
+			l.Errorf("error retrieving workspace list: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: couldn't retrieve workspaces"), 500)
+			return
+		}
+		o, err := f.WorkspaceList(ws)
+		if err != nil {
+			l.Errorf("error encoding workspace list: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding workspace data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func getWorkspace(l Logger, f Formatter, workspaceRepo usecase.WorkspaceRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		id, err := workspace.ParseID(ps.ByName("workspaceID"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid workspace ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		ws, ucerr := usecase.GetWorkspace(r.Context(), workspaceRepo, id, u.ID())
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrRecordNotFound {
+				f.WriteResponse(w, f.Errorf("Workspace ID %v not found", id), 404)
+				return
+			}
+			l.Errorf("error retrieving workspace ID %v: %v", id, ucerr)
+			f.WriteResponse(w, f.Errorf("Error: couldn't retrieve workspace ID %v", id), 500)
+			return
+		}
+		o, err := f.Workspace(ws)
+		if err != nil {
+			l.Errorf("error encoding workspace: %v", err)
+			f.WriteResponse(w, f.Error("Error encoding workspace data"), 500)
+			return
+		}
+		f.WriteResponse(w, o, 200)
+	}
+}
+
+func addWorkspace(l Logger, f Formatter, p Parser, workspaceRepo usecase.WorkspaceRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		l := logging.Request(r, l)
+		u := auth.GetUser(w)
+		if u.ID().IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		name, err := p.AddWorkspace(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing addWorkspace data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse workspace data: %v", err), 400)
+			return
+		}
+		ws, ucerr := usecase.AddWorkspace(r.Context(), workspaceRepo, name, u.ID())
+		if ucerr != nil {
+			if ucerr.Code() == usecase.ErrInvalidData {
+				f.WriteResponse(w, f.Errorf("Error: invalid workspace data: %v", ucerr), 400)
+				return
+			}
+			l.Errorf("error adding workspace: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error: could not add workspace"), 500)
+			return
+		}
+		o, err := f.WorkspaceID(ws.ID())
+		if err != nil {
+			f.WriteResponse(w, f.Error("Workspace created, but there was an error formatting the response workspace ID"), 201)
+			return
+		}
+		f.WriteResponse(w, o, 201)
+	}
+}
+
+func inviteMember(l Logger, f Formatter, p Parser, workspaceRepo usecase.WorkspaceRepo, userRepo usecase.UserRepo) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		l := logging.Request(r, l)
+		id, err := workspace.ParseID(ps.ByName("workspaceID"))
+		if err != nil {
+			f.WriteResponse(w, f.Error("Error: valid workspace ID required"), 404)
+			return
+		}
+		u := auth.GetUser(w)
+		if u.ID().IsEmpty() {
+			l.Errorf("error retrieving required user from http.ResponseWriter: %v", w)
+			f.ErrUnauthorized(w)
+			return
+		}
+		uid, role, err := p.InviteMember(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			l.Warnf("error parsing inviteMember data: %v", err)
+			f.WriteResponse(w, f.Errorf("Error: could not parse member data: %v", err), 400)
+			return
+		}
+		ucerr := usecase.InviteMember(r.Context(), workspaceRepo, userRepo, id, u.ID(), uid, role)
+		if ucerr != nil {
+			switch ucerr.Code() {
+			case usecase.ErrRecordNotFound:
+				f.WriteResponse(w, f.Errorf("Workspace ID %v or user ID %v not found", id, uid), 404)
+				return
+			case usecase.ErrForbidden:
+				f.WriteResponse(w, f.Errorf("Error: not allowed to invite a %v to workspace ID %v", role, id), 403)
+				return
+			case usecase.ErrDuplicateRecord:
+				f.WriteResponse(w, f.Errorf("User %v is already a member of workspace ID %v", uid, id), 400)
+				return
+			}
+			l.Errorf("error inviting workspace member: %v", ucerr)
+			f.WriteResponse(w, f.Error("Error inviting workspace member"), 500)
+			return
+		}
+		f.WriteEmpty(w, 204)
+	}
+}
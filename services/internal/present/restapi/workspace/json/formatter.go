@@ -0,0 +1,68 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	format "github.com/benjohns1/scheduled-tasks/services/internal/present/restapi/json"
+)
+
+// Formatter formats application data into JSON for output
+type Formatter struct {
+	format.ResponseFormatter
+}
+
+// NewFormatter creates a new Formatter instance
+func NewFormatter(rf format.ResponseFormatter) *Formatter {
+	return &Formatter{rf}
+}
+
+type outWorkspace struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	CreatedTime format.Time `json:"createdTime"`
+	CreatedBy   string      `json:"createdBy"`
+	Members     []outMember `json:"members"`
+}
+
+type outMember struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+}
+
+type outWorkspaceID struct {
+	ID string `json:"id"`
+}
+
+func workspaceToOut(w *workspace.Workspace) *outWorkspace {
+	o := &outWorkspace{
+		ID:          w.ID().String(),
+		Name:        w.Name(),
+		CreatedTime: format.Time(w.CreatedTime()),
+		CreatedBy:   w.CreatedBy().String(),
+		Members:     []outMember{},
+	}
+	for _, m := range w.Members() {
+		o.Members = append(o.Members, outMember{UserID: m.UserID().String(), Role: m.Role().String()})
+	}
+	return o
+}
+
+// WorkspaceID formats a workspace ID to JSON
+func (f *Formatter) WorkspaceID(id workspace.ID) ([]byte, error) {
+	return json.Marshal(&outWorkspaceID{ID: id.String()})
+}
+
+// Workspace formats a workspace to JSON
+func (f *Formatter) Workspace(w *workspace.Workspace) ([]byte, error) {
+	return json.Marshal(workspaceToOut(w))
+}
+
+// WorkspaceList formats an ordered list of workspaces to JSON
+func (f *Formatter) WorkspaceList(ws []*workspace.Workspace) ([]byte, error) {
+	o := make([]*outWorkspace, len(ws))
+	for i, w := range ws {
+		o[i] = workspaceToOut(w)
+	}
+	return json.Marshal(o)
+}
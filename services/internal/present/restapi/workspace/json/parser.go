@@ -0,0 +1,53 @@
+package json
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+)
+
+// Parser handles JSON parsing
+type Parser struct {
+}
+
+// NewParser creates a new Parser instance
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// AddWorkspace parses addWorkspace request JSON data into the new workspace name
+func (p *Parser) AddWorkspace(b io.Reader) (string, error) {
+	var addWorkspace addWorkspace
+	if err := json.NewDecoder(b).Decode(&addWorkspace); err != nil {
+		return "", err
+	}
+	return addWorkspace.Name, nil
+}
+
+type addWorkspace struct {
+	Name string `json:"name"`
+}
+
+// InviteMember parses inviteMember request JSON data into the invited user's ID and role
+func (p *Parser) InviteMember(b io.Reader) (user.ID, workspace.Role, error) {
+	var inviteMember inviteMember
+	if err := json.NewDecoder(b).Decode(&inviteMember); err != nil {
+		return user.ID{}, 0, err
+	}
+	uid, err := user.ParseID(inviteMember.UserID)
+	if err != nil {
+		return user.ID{}, 0, err
+	}
+	role, err := workspace.ParseRole(inviteMember.Role)
+	if err != nil {
+		return user.ID{}, 0, err
+	}
+	return uid, role, nil
+}
+
+type inviteMember struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// ActionCallData contains application-level HTTP action call info
+type ActionCallData struct {
+	TaskID TaskID
+	Call   *action.Call
+}
+
+// ActionRepo defines the HTTP action call repository interface required by use cases
+// calls are added by the task repo, along with the generated task they were queued for
+type ActionRepo interface {
+	GetForTask(context.Context, TaskID) (*action.Call, Error)
+	GetDue(ctx context.Context, before time.Time, limit int) ([]ActionCallData, Error)
+	NextAttemptTime(context.Context) (time.Time, Error)
+	Update(context.Context, *action.Call) Error
+}
+
+// ActionSender makes a call's HTTP request, returning the status code and body of the response
+// an error is only returned if no response was received
+type ActionSender interface {
+	Send(ctx context.Context, c *action.Call) (int, string, error)
+}
+
+// actionBatchSize is the maximum number of due HTTP action calls attempted in a single run
+const actionBatchSize = 100
+
+// GetTaskAction returns the HTTP action call queued for a generated task, with the outcome of its latest attempt
+func GetTaskAction(ctx context.Context, taskRepo TaskRepo, actionRepo ActionRepo, id TaskID, uid user.ID) (*action.Call, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetTaskAction")
+	defer span.End()
+
+	if _, ucerr := taskRepo.GetForUser(ctx, id, uid); ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	c, ucerr := actionRepo.GetForTask(ctx, id)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving action for task id %d", id)
+	}
+	return c, nil
+}
+
+// PerformActions attempts every HTTP action call that is due, recording the outcome of each attempt
+// failed attempts are retried according to the retry policy, tasks whose call succeeds are completed if their action completes them
+// returns when the next call is due, zero if none are pending
+func PerformActions(ctx context.Context, r ActionRepo, taskRepo TaskRepo, sender ActionSender, p webhook.RetryPolicy) (time.Time, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.PerformActions")
+	defer span.End()
+
+	now := clock.Now()
+	cs, ucerr := r.GetDue(ctx, now, actionBatchSize)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving due action calls")
+	}
+	for _, cd := range cs {
+		c := cd.Call
+		start := clock.Now()
+		code, body, err := sender.Send(ctx, c)
+		latency := clock.Now().Sub(start)
+		switch {
+		case err != nil:
+			c.Failed(0, "", latency, err.Error(), p)
+		case code < 200 || code >= 300:
+			c.Failed(code, body, latency, http.StatusText(code), p)
+		default:
+			c.Succeeded(code, body, latency)
+		}
+		if ucerr := r.Update(ctx, c); ucerr != nil {
+			return time.Time{}, ucerr.Prefix("error updating action call id %v", c.ID())
+		}
+		if c.Status() == action.StatusSucceeded && c.CompleteTask() {
+			if ucerr := completeGeneratedTask(ctx, taskRepo, cd.TaskID); ucerr != nil {
+				return time.Time{}, ucerr
+			}
+		}
+	}
+	if len(cs) == actionBatchSize {
+		return now, nil
+	}
+
+	next, ucerr := r.NextAttemptTime(ctx)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving next action call time")
+	}
+	return next, nil
+}
+
+// completeGeneratedTask completes a generated task whose action or command succeeded, unless it was already completed or cleared
+func completeGeneratedTask(ctx context.Context, taskRepo TaskRepo, id TaskID) Error {
+	t, ucerr := taskRepo.Get(ctx, id)
+	if ucerr != nil {
+		return ucerr.Prefix("error retrieving task id %v", id)
+	}
+	if !t.IsValid() {
+		return nil
+	}
+	completed, err := t.CompleteNow()
+	if err != nil {
+		return NewError(ErrUnknown, "error completing task id %v: %v", id, err)
+	}
+	if !completed {
+		return nil
+	}
+	if ucerr := taskRepo.Update(ctx, id, t); ucerr != nil {
+		return ucerr.Prefix("error updating task id %v", id)
+	}
+	return nil
+}
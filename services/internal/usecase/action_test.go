@@ -0,0 +1,122 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/action"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type actionSenderStub struct {
+	code int
+	body string
+	err  error
+	sent int
+}
+
+func (s *actionSenderStub) Send(ctx context.Context, c *action.Call) (int, string, error) {
+	s.sent++
+	return s.code, s.body, s.err
+}
+
+func TestGetTaskAction(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := data.NewTaskRepo()
+	actionRepo := data.NewActionRepo()
+	taskRepo.SetActionRepo(actionRepo)
+	uid := user.NewID()
+	tsk := task.New("warm cache", "", uid)
+	tsk.SetAction(action.NewCall(action.NewRequest("POST", "https://example.com/warm", nil, ""), 0, 0, false))
+	id, _ := taskRepo.Add(ctx, tsk)
+	manual, _ := taskRepo.Add(ctx, task.New("manual task", "", uid))
+
+	if c, ucerr := GetTaskAction(ctx, taskRepo, actionRepo, id, uid); ucerr != nil || c.Request().URL() != "https://example.com/warm" {
+		t.Errorf("GetTaskAction() = %v, %v, want the task's call", c, ucerr)
+	}
+	if _, ucerr := GetTaskAction(ctx, taskRepo, actionRepo, id, user.NewID()); ucerr == nil || ucerr.Code() != ErrRecordNotFound {
+		t.Errorf("GetTaskAction() for another user's task error = %v, want %v", ucerr, ErrRecordNotFound)
+	}
+	if _, ucerr := GetTaskAction(ctx, taskRepo, actionRepo, manual, uid); ucerr == nil || ucerr.Code() != ErrRecordNotFound {
+		t.Errorf("GetTaskAction() for a task without an action error = %v, want %v", ucerr, ErrRecordNotFound)
+	}
+}
+
+func TestPerformActions(t *testing.T) {
+	ctx := context.Background()
+	policy := webhook.RetryPolicy{MaxAttempts: 2, Delay: time.Minute}
+
+	tests := []struct {
+		name          string
+		completeTask  bool
+		sender        *actionSenderStub
+		wantStatus    action.Status
+		wantError     string
+		wantNext      bool
+		wantCompleted bool
+	}{
+		{
+			name:       "successful response should record the response and leave the task open",
+			sender:     &actionSenderStub{code: http.StatusOK, body: "warmed"},
+			wantStatus: action.StatusSucceeded,
+		},
+		{
+			name:          "successful response should complete the task if the action completes it",
+			completeTask:  true,
+			sender:        &actionSenderStub{code: http.StatusNoContent},
+			wantStatus:    action.StatusSucceeded,
+			wantCompleted: true,
+		},
+		{
+			name:         "error response should schedule a retry and leave the task open",
+			completeTask: true,
+			sender:       &actionSenderStub{code: http.StatusServiceUnavailable, body: "busy"},
+			wantStatus:   action.StatusPending,
+			wantError:    "Service Unavailable",
+			wantNext:     true,
+		},
+		{
+			name:       "no response should schedule a retry",
+			sender:     &actionSenderStub{err: errors.New("context deadline exceeded")},
+			wantStatus: action.StatusPending,
+			wantError:  "context deadline exceeded",
+			wantNext:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskRepo := data.NewTaskRepo()
+			r := data.NewActionRepo()
+			taskRepo.SetActionRepo(r)
+			tsk := task.New("warm cache", "", user.NewID())
+			tsk.SetAction(action.NewCall(action.NewRequest("POST", "https://example.com/warm", nil, ""), 0, 0, tt.completeTask))
+			id, _ := taskRepo.Add(ctx, tsk)
+
+			next, err := PerformActions(ctx, r, taskRepo, tt.sender, policy)
+			if err != nil {
+				t.Fatalf("PerformActions() error = %v", err)
+			}
+			if next.IsZero() == tt.wantNext {
+				t.Errorf("PerformActions() next = %v, want retry scheduled %v", next, tt.wantNext)
+			}
+			if tt.sender.sent != 1 {
+				t.Errorf("PerformActions() sent %v requests, want 1", tt.sender.sent)
+			}
+			c, _ := r.GetForTask(ctx, id)
+			if c.Status() != tt.wantStatus || c.LastError() != tt.wantError || c.StatusCode() != tt.sender.code || c.Response() != tt.sender.body {
+				t.Errorf("PerformActions() call = %v %q %v %q, want %v with error %q", c.Status(), c.LastError(), c.StatusCode(), c.Response(), tt.wantStatus, tt.wantError)
+			}
+			got, _ := taskRepo.Get(ctx, id)
+			if completed := !got.CompletedTime().IsZero(); completed != tt.wantCompleted {
+				t.Errorf("PerformActions() task completed = %v, want %v", completed, tt.wantCompleted)
+			}
+		})
+	}
+}
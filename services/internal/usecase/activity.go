@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// ActivityID is the persistent ID of a task activity entry
+type ActivityID int64
+
+// ActivityData contains application-level task activity info
+type ActivityData struct {
+	ActivityID ActivityID
+	TaskID     TaskID
+	Activity   *task.Activity
+}
+
+// ActivityRepo defines the task activity repository interface required by use cases
+// Activity entries are append-only, they cannot be changed or removed once added
+// The task use cases record each change they make, including the creation of tasks generated by the scheduler
+type ActivityRepo interface {
+	GetAllForTask(context.Context, TaskID) ([]ActivityData, Error)
+	Add(context.Context, TaskID, *task.Activity) (ActivityID, Error)
+}
+
+// ListTaskActivity returns a task's activity history, oldest first
+// The history of cleared tasks is still available
+func ListTaskActivity(ctx context.Context, taskRepo TaskRepo, activityRepo ActivityRepo, id TaskID, uid user.ID) ([]ActivityData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListTaskActivity")
+	defer span.End()
+
+	if _, ucerr := taskRepo.GetForUser(ctx, id, uid); ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	as, ucerr := activityRepo.GetAllForTask(ctx, id)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving activity for task id %d", id)
+	}
+	return as, nil
+}
+
+// AddTaskComment adds a user comment to a valid task's activity history
+func AddTaskComment(ctx context.Context, taskRepo TaskRepo, activityRepo ActivityRepo, id TaskID, uid user.ID, comment string) (*ActivityData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddTaskComment")
+	defer span.End()
+
+	t, ucerr := taskRepo.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving task id %d", id)
+	}
+	if !t.IsValid() {
+		return nil, NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+
+	a, err := task.NewComment(comment, uid)
+	if err != nil {
+		return nil, NewError(ErrInvalidData, "error creating comment for task id %d: %v", id, err)
+	}
+	aid, ucerr := activityRepo.Add(ctx, id, a)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error adding comment to task id %d", id)
+	}
+	return &ActivityData{ActivityID: aid, TaskID: id, Activity: a}, nil
+}
+
+// RecordTaskActivity appends an entry for a change made by a user to a task's activity history
+func RecordTaskActivity(ctx context.Context, r ActivityRepo, id TaskID, uid user.ID, activityType task.ActivityType) Error {
+	ctx, span := tracer.Start(ctx, "usecase.RecordTaskActivity")
+	defer span.End()
+
+	a, err := task.NewActivity(activityType, uid)
+	if err != nil {
+		return NewError(ErrInvalidData, "error creating activity for task id %d: %v", id, err)
+	}
+	if _, ucerr := r.Add(ctx, id, a); ucerr != nil {
+		return ucerr.Prefix("error recording %v activity for task id %d", activityType, id)
+	}
+	return nil
+}
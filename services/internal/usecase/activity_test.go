@@ -0,0 +1,166 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestAddTaskComment(t *testing.T) {
+	ctx := context.Background()
+
+	now := clock.Now()
+	taskRepo := data.NewTaskRepo()
+	activityRepo := data.NewActivityRepo()
+	uid1 := user.New("new user 1 for AddTaskComment").ID()
+	taskID, _ := taskRepo.Add(ctx, task.New("task1", "", uid1))
+	clearedTaskID, _ := taskRepo.Add(ctx, task.NewRaw("task2", "", now, now, now, uid1))
+	uid2 := user.New("new user 2 for AddTaskComment").ID()
+
+	type args struct {
+		id      TaskID
+		uid     user.ID
+		comment string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "comment should be added to task",
+			args:    args{taskID, uid1, "a comment"},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "empty comment should return an ErrInvalidData",
+			args:    args{taskID, uid1, ""},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "commenting on a cleared task should return an ErrRecordNotFound",
+			args:    args{clearedTaskID, uid1, "a comment"},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "commenting on a task created by another user should return an ErrRecordNotFound",
+			args:    args{taskID, uid2, "a comment"},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddTaskComment(ctx, taskRepo, activityRepo, tt.args.id, tt.args.uid, tt.args.comment)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("AddTaskComment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.TaskID != tt.args.id || got.Activity.Comment() != tt.args.comment || !got.Activity.CreatedBy().Equals(tt.args.uid)) {
+				t.Errorf("AddTaskComment() = %v, want comment %v on task %v by %v", got, tt.args.comment, tt.args.id, tt.args.uid)
+			}
+		})
+	}
+}
+
+func TestListTaskActivity(t *testing.T) {
+	ctx := context.Background()
+
+	now := clock.Now()
+	taskRepo := data.NewTaskRepo()
+	activityRepo := data.NewActivityRepo()
+	uid1 := user.New("new user 1 for ListTaskActivity").ID()
+	taskID, _ := taskRepo.Add(ctx, task.New("task1", "", uid1))
+	RecordTaskActivity(ctx, activityRepo, taskID, uid1, task.ActivityCreated)
+	AddTaskComment(ctx, taskRepo, activityRepo, taskID, uid1, "a comment")
+	clearedTaskID, _ := taskRepo.Add(ctx, task.NewRaw("task2", "", now, now, now, uid1))
+	RecordTaskActivity(ctx, activityRepo, clearedTaskID, uid1, task.ActivityCleared)
+	uid2 := user.New("new user 2 for ListTaskActivity").ID()
+
+	type args struct {
+		id  TaskID
+		uid user.ID
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantTypes []task.ActivityType
+		wantErr   ErrorCode
+	}{
+		{
+			name:      "task activity should be listed oldest first",
+			args:      args{taskID, uid1},
+			wantTypes: []task.ActivityType{task.ActivityCreated, task.ActivityComment},
+			wantErr:   ErrNone,
+		},
+		{
+			name:      "cleared task activity should be listed",
+			args:      args{clearedTaskID, uid1},
+			wantTypes: []task.ActivityType{task.ActivityCleared},
+			wantErr:   ErrNone,
+		},
+		{
+			name:    "listing activity of a task created by another user should return an ErrRecordNotFound",
+			args:    args{taskID, uid2},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListTaskActivity(ctx, taskRepo, activityRepo, tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ListTaskActivity() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.wantTypes) {
+				t.Errorf("ListTaskActivity() = %v, want types %v", got, tt.wantTypes)
+				return
+			}
+			for i, ad := range got {
+				if ad.Activity.Type() != tt.wantTypes[i] {
+					t.Errorf("ListTaskActivity() entry %d type = %v, want %v", i, ad.Activity.Type(), tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTaskActivityRecorded(t *testing.T) {
+	ctx := context.Background()
+
+	taskRepo := data.NewTaskRepo()
+	activityRepo := data.NewActivityRepo()
+	uid := user.New("new user for task activity").ID()
+
+	td, err := AddTask(ctx, taskRepo, activityRepo, task.New("task1", "", uid))
+	if err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	id := td.TaskID
+	name := "renamed task1"
+	UpdateTask(ctx, taskRepo, activityRepo, id, uid, TaskUpdate{Name: &name})
+	TagTask(ctx, taskRepo, activityRepo, id, uid, "ops")
+	TagTask(ctx, taskRepo, activityRepo, id, uid, "ops")
+	CompleteTask(ctx, taskRepo, activityRepo, id, uid)
+	CompleteTask(ctx, taskRepo, activityRepo, id, uid)
+	UncompleteTask(ctx, taskRepo, activityRepo, id, uid)
+	ClearTask(ctx, taskRepo, activityRepo, id, uid)
+
+	got, err := activityRepo.GetAllForTask(ctx, id)
+	if err != nil {
+		t.Fatalf("ActivityRepo.GetAllForTask() error = %v", err)
+	}
+	want := []task.ActivityType{task.ActivityCreated, task.ActivityEdited, task.ActivityEdited, task.ActivityCompleted, task.ActivityUncompleted, task.ActivityCleared}
+	if len(got) != len(want) {
+		t.Fatalf("recorded %v activity entries, want one for each change %v", len(got), want)
+	}
+	for i, ad := range got {
+		if ad.Activity.Type() != want[i] || ad.Activity.CreatedBy() != uid {
+			t.Errorf("activity entry %d = %v by %v, want %v by %v", i, ad.Activity.Type(), ad.Activity.CreatedBy(), want[i], uid)
+		}
+	}
+}
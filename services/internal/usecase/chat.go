@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// ChatRepo defines the chat message repository interface required by use cases
+// Add returns an ErrDuplicateRecord error if a message of the same kind was already queued for the task
+type ChatRepo interface {
+	Add(ctx context.Context, m *chat.Message, taskID TaskID, scheduleID ScheduleID) Error
+	GetDue(ctx context.Context, before time.Time, limit int) ([]*chat.Message, Error)
+	NextAttemptTime(context.Context) (time.Time, Error)
+	Update(context.Context, *chat.Message) Error
+}
+
+// ChatSender posts a message's payload to its incoming webhook URL, returning the HTTP status code of the response
+// an error is only returned if no response was received
+type ChatSender interface {
+	Send(ctx context.Context, m *chat.Message) (int, error)
+}
+
+// ChatFormatter formats a task, and the schedule that generated it, into an incoming webhook payload
+type ChatFormatter interface {
+	Format(kind chat.Kind, td TaskData, sd ScheduleData) ([]byte, error)
+}
+
+// chatBatchSize is the maximum number of due chat messages attempted in a single run
+const chatBatchSize = 100
+
+// SetScheduleChatURL sets the incoming webhook URL a schedule's generated and overdue tasks are posted to, an empty URL stops posting them
+func SetScheduleChatURL(ctx context.Context, r ScheduleRepo, id ScheduleID, url string, uid user.ID) Error {
+	ctx, span := tracer.Start(ctx, "usecase.SetScheduleChatURL")
+	defer span.End()
+
+	s, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return ucerr.Prefix("error retrieving schedule id %d", id)
+	}
+	if !s.IsValid() {
+		return NewError(ErrRecordNotFound, "schedule id %d not found", id)
+	}
+	if err := s.SetChatURL(url); err != nil {
+		return NewError(ErrInvalidData, "%v", err)
+	}
+	if ucerr := r.Update(ctx, id, s); ucerr != nil {
+		return ucerr.Prefix("error updating schedule id %d chat URL", id)
+	}
+	return nil
+}
+
+// PostGeneratedTask queues a generated task to be posted to its schedule's chat channel, returning whether it was queued
+// events other than a generated task are ignored, as are tasks whose schedule doesn't post to chat
+func PostGeneratedTask(ctx context.Context, r ChatRepo, taskRepo TaskRepo, scheduleRepo ScheduleRepo, f ChatFormatter, ed EventData) (bool, Error) {
+	if ed.Event.Type() != event.TaskGenerated {
+		return false, nil
+	}
+	ctx, span := tracer.Start(ctx, "usecase.PostGeneratedTask")
+	defer span.End()
+
+	t, ucerr := taskRepo.Get(ctx, ed.TaskID)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving task id %v", ed.TaskID)
+	}
+	sid := ScheduleID(t.Schedule())
+	if sid == 0 {
+		return false, nil
+	}
+	s, ucerr := scheduleRepo.Get(ctx, sid)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving schedule id %v", sid)
+	}
+	return queueChatMessage(ctx, r, f, chat.KindGenerated, TaskData{TaskID: ed.TaskID, Task: t}, ScheduleData{ScheduleID: sid, Schedule: s})
+}
+
+// PostOverdueTasks queues open tasks that became overdue after one time, up to and including another, to be posted to their schedule's chat channel
+// each task is only posted once, even if it's found again, returns the number of messages queued
+func PostOverdueTasks(ctx context.Context, r ChatRepo, taskRepo TaskRepo, scheduleRepo ScheduleRepo, f ChatFormatter, after time.Time, before time.Time) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.PostOverdueTasks")
+	defer span.End()
+
+	ts, ucerr := taskRepo.GetAllDue(ctx, after, before)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving overdue tasks")
+	}
+	queued := 0
+	for id, t := range ts {
+		sid := ScheduleID(t.Schedule())
+		if sid == 0 {
+			continue
+		}
+		s, ucerr := scheduleRepo.Get(ctx, sid)
+		if ucerr != nil {
+			return queued, ucerr.Prefix("error retrieving schedule id %v", sid)
+		}
+		ok, ucerr := queueChatMessage(ctx, r, f, chat.KindOverdue, TaskData{TaskID: id, Task: t}, ScheduleData{ScheduleID: sid, Schedule: s})
+		if ucerr != nil {
+			return queued, ucerr
+		}
+		if ok {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+func queueChatMessage(ctx context.Context, r ChatRepo, f ChatFormatter, kind chat.Kind, td TaskData, sd ScheduleData) (bool, Error) {
+	if sd.Schedule.ChatURL() == "" || !sd.Schedule.IsValid() {
+		return false, nil
+	}
+	payload, err := f.Format(kind, td, sd)
+	if err != nil {
+		return false, NewError(ErrUnknown, "error formatting %v chat message for task id %v: %v", kind, td.TaskID, err)
+	}
+	if ucerr := r.Add(ctx, chat.NewMessage(sd.Schedule.ChatURL(), kind, payload), td.TaskID, sd.ScheduleID); ucerr != nil {
+		if ucerr.Code() == ErrDuplicateRecord {
+			return false, nil
+		}
+		return false, ucerr.Prefix("error queueing %v chat message for task id %v", kind, td.TaskID)
+	}
+	return true, nil
+}
+
+// DeliverChatMessages attempts every chat message that is due, recording the outcome of each attempt
+// failed attempts are retried according to the retry policy
+// returns when the next message is due, zero if none are pending
+func DeliverChatMessages(ctx context.Context, r ChatRepo, sender ChatSender, p webhook.RetryPolicy) (time.Time, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.DeliverChatMessages")
+	defer span.End()
+
+	now := clock.Now()
+	ms, ucerr := r.GetDue(ctx, now, chatBatchSize)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving due chat messages")
+	}
+	for _, m := range ms {
+		code, err := sender.Send(ctx, m)
+		switch {
+		case err != nil:
+			m.Failed(0, err.Error(), p)
+		case code < 200 || code >= 300:
+			m.Failed(code, http.StatusText(code), p)
+		default:
+			m.Succeeded(code)
+		}
+		if ucerr := r.Update(ctx, m); ucerr != nil {
+			return time.Time{}, ucerr.Prefix("error updating chat message id %v", m.ID())
+		}
+	}
+	if len(ms) == chatBatchSize {
+		return now, nil
+	}
+
+	next, ucerr := r.NextAttemptTime(ctx)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving next chat message time")
+	}
+	return next, nil
+}
@@ -0,0 +1,251 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/chat"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type chatFormatterStub struct{}
+
+func (f *chatFormatterStub) Format(kind chat.Kind, td TaskData, sd ScheduleData) ([]byte, error) {
+	return []byte(`{"text":"` + string(kind) + " " + td.Task.Name() + `"}`), nil
+}
+
+type chatSenderStub struct {
+	code int
+	err  error
+	sent int
+}
+
+func (s *chatSenderStub) Send(ctx context.Context, m *chat.Message) (int, error) {
+	s.sent++
+	return s.code, s.err
+}
+
+func TestSetScheduleChatURL(t *testing.T) {
+	ctx := context.Background()
+	r := data.NewScheduleRepo()
+	uid := user.NewID()
+	sid, _ := r.Add(ctx, schedule.New(schedule.Frequency{}, uid))
+
+	tests := []struct {
+		name     string
+		id       ScheduleID
+		url      string
+		uid      user.ID
+		wantCode ErrorCode
+		wantURL  string
+	}{
+		{
+			name:    "should set the chat URL",
+			id:      sid,
+			url:     "https://hooks.slack.com/services/T0/B0/x",
+			uid:     uid,
+			wantURL: "https://hooks.slack.com/services/T0/B0/x",
+		},
+		{
+			name:     "invalid URL should return an ErrInvalidData error",
+			id:       sid,
+			url:      "hooks.slack.com",
+			uid:      uid,
+			wantCode: ErrInvalidData,
+			wantURL:  "https://hooks.slack.com/services/T0/B0/x",
+		},
+		{
+			name:     "another user's schedule should return an ErrRecordNotFound error",
+			id:       sid,
+			url:      "",
+			uid:      user.NewID(),
+			wantCode: ErrRecordNotFound,
+			wantURL:  "https://hooks.slack.com/services/T0/B0/x",
+		},
+		{
+			name:    "empty URL should stop posting to chat",
+			id:      sid,
+			url:     "",
+			uid:     uid,
+			wantURL: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetScheduleChatURL(ctx, r, tt.id, tt.url, tt.uid)
+			if (err != nil || tt.wantCode != 0) && (err == nil || err.Code() != tt.wantCode) {
+				t.Fatalf("SetScheduleChatURL() error = %v, want code %v", err, tt.wantCode)
+			}
+			if s, _ := r.Get(ctx, sid); s.ChatURL() != tt.wantURL {
+				t.Errorf("SetScheduleChatURL() chat URL = %v, want %v", s.ChatURL(), tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestPostGeneratedTask(t *testing.T) {
+	ctx := context.Background()
+	uid := user.NewID()
+
+	tests := []struct {
+		name     string
+		chatURL  string
+		manual   bool
+		event    event.Type
+		wantPost bool
+	}{
+		{
+			name:     "generated task should be posted to its schedule's chat channel",
+			chatURL:  "https://chat.example.com/hooks/1",
+			event:    event.TaskGenerated,
+			wantPost: true,
+		},
+		{
+			name:    "schedule without a chat URL should not post",
+			chatURL: "",
+			event:   event.TaskGenerated,
+		},
+		{
+			name:    "task added by a user should not post",
+			chatURL: "https://chat.example.com/hooks/1",
+			manual:  true,
+			event:   event.TaskGenerated,
+		},
+		{
+			name:    "other events should be ignored",
+			chatURL: "https://chat.example.com/hooks/1",
+			event:   event.TaskCompleted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := data.NewChatRepo()
+			taskRepo := data.NewTaskRepo()
+			scheduleRepo := data.NewScheduleRepo()
+			s := schedule.New(schedule.Frequency{}, uid)
+			s.SetChatURL(tt.chatURL)
+			sid, _ := scheduleRepo.Add(ctx, s)
+			tsk := task.New("water plants", "", uid)
+			if !tt.manual {
+				tsk.SetSchedule(int64(sid))
+			}
+			tid, _ := taskRepo.Add(ctx, tsk)
+
+			for i := 0; i < 2; i++ {
+				posted, err := PostGeneratedTask(ctx, r, taskRepo, scheduleRepo, &chatFormatterStub{}, EventData{Event: event.New(tt.event), TaskID: tid})
+				if err != nil {
+					t.Fatalf("PostGeneratedTask() error = %v", err)
+				}
+				if want := tt.wantPost && i == 0; posted != want {
+					t.Errorf("PostGeneratedTask() attempt %v = %v, want %v", i+1, posted, want)
+				}
+			}
+			ms, _ := r.GetAll(ctx)
+			if tt.wantPost && (len(ms) != 1 || ms[0].URL() != tt.chatURL || ms[0].Kind() != chat.KindGenerated || string(ms[0].Payload()) != `{"text":"generated water plants"}`) {
+				t.Errorf("PostGeneratedTask() queued %v, want a single generated message to %v", ms, tt.chatURL)
+			}
+		})
+	}
+}
+
+func TestPostOverdueTasks(t *testing.T) {
+	ctx := context.Background()
+	uid := user.NewID()
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r := data.NewChatRepo()
+	taskRepo := data.NewTaskRepo()
+	scheduleRepo := data.NewScheduleRepo()
+	s := schedule.New(schedule.Frequency{}, uid)
+	s.SetChatURL("https://chat.example.com/hooks/1")
+	sid, _ := scheduleRepo.Add(ctx, s)
+	quiet, _ := scheduleRepo.Add(ctx, schedule.New(schedule.Frequency{}, uid))
+	newTask := func(sid ScheduleID, due time.Time) {
+		tsk := task.New("water plants", "", uid)
+		tsk.SetSchedule(int64(sid))
+		tsk.SetDueTime(due)
+		taskRepo.Add(ctx, tsk)
+	}
+	newTask(sid, now.Add(-time.Minute))
+	newTask(sid, now.Add(time.Minute))
+	newTask(quiet, now.Add(-time.Minute))
+	newTask(0, now.Add(-time.Minute))
+
+	queued, err := PostOverdueTasks(ctx, r, taskRepo, scheduleRepo, &chatFormatterStub{}, now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatalf("PostOverdueTasks() error = %v", err)
+	}
+	if queued != 1 {
+		t.Errorf("PostOverdueTasks() queued %v messages, want 1", queued)
+	}
+	if queued, _ := PostOverdueTasks(ctx, r, taskRepo, scheduleRepo, &chatFormatterStub{}, now.Add(-time.Hour), now); queued != 0 {
+		t.Errorf("PostOverdueTasks() again queued %v messages, want 0", queued)
+	}
+	ms, _ := r.GetAll(ctx)
+	if len(ms) != 1 || ms[0].Kind() != chat.KindOverdue {
+		t.Errorf("PostOverdueTasks() queued %v, want a single overdue message", ms)
+	}
+}
+
+func TestDeliverChatMessages(t *testing.T) {
+	ctx := context.Background()
+	policy := webhook.RetryPolicy{MaxAttempts: 2, Delay: time.Minute}
+
+	tests := []struct {
+		name       string
+		sender     *chatSenderStub
+		wantStatus chat.Status
+		wantError  string
+		wantNext   bool
+	}{
+		{
+			name:       "successful response should complete the message",
+			sender:     &chatSenderStub{code: http.StatusOK},
+			wantStatus: chat.StatusSucceeded,
+		},
+		{
+			name:       "error response should schedule a retry",
+			sender:     &chatSenderStub{code: http.StatusBadRequest},
+			wantStatus: chat.StatusPending,
+			wantError:  "Bad Request",
+			wantNext:   true,
+		},
+		{
+			name:       "no response should schedule a retry",
+			sender:     &chatSenderStub{err: errors.New("connection refused")},
+			wantStatus: chat.StatusPending,
+			wantError:  "connection refused",
+			wantNext:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := data.NewChatRepo()
+			r.Add(ctx, chat.NewMessage("https://chat.example.com/hooks/1", chat.KindGenerated, []byte("{}")), 1, 1)
+
+			next, err := DeliverChatMessages(ctx, r, tt.sender, policy)
+			if err != nil {
+				t.Fatalf("DeliverChatMessages() error = %v", err)
+			}
+			if next.IsZero() == tt.wantNext {
+				t.Errorf("DeliverChatMessages() next = %v, want retry scheduled %v", next, tt.wantNext)
+			}
+			if tt.sender.sent != 1 {
+				t.Errorf("DeliverChatMessages() sent %v requests, want 1", tt.sender.sent)
+			}
+			ms, _ := r.GetAll(ctx)
+			if len(ms) != 1 || ms[0].Status() != tt.wantStatus || ms[0].LastError() != tt.wantError {
+				t.Errorf("DeliverChatMessages() messages = %+v, want 1 %v message with error %q", ms, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
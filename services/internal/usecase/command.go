@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// CommandRunData contains application-level command run info
+type CommandRunData struct {
+	TaskID TaskID
+	Run    *command.Run
+}
+
+// CommandRepo defines the command run repository interface required by use cases
+// runs are added by the task repo, along with the generated task they were queued for
+// Start saves a started run only if it's still pending, returning false if it isn't, so a run is only claimed by one instance
+type CommandRepo interface {
+	GetForTask(context.Context, TaskID) (*command.Run, Error)
+	GetPending(ctx context.Context, limit int) ([]CommandRunData, Error)
+	Start(context.Context, *command.Run) (bool, Error)
+	Update(context.Context, *command.Run) Error
+}
+
+// CommandRunner runs a run's command, returning its exit code and output
+// an error is returned if the command couldn't be started or didn't finish, along with any output it produced
+type CommandRunner interface {
+	Run(ctx context.Context, r *command.Run) (exitCode int, stdout string, stderr string, err error)
+}
+
+// commandBatchSize is the maximum number of pending command runs started in a single run
+const commandBatchSize = 10
+
+// GetTaskCommand returns the command run queued for a generated task, with its outcome
+func GetTaskCommand(ctx context.Context, taskRepo TaskRepo, commandRepo CommandRepo, id TaskID, uid user.ID) (*command.Run, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetTaskCommand")
+	defer span.End()
+
+	if _, ucerr := taskRepo.GetForUser(ctx, id, uid); ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	r, ucerr := commandRepo.GetForTask(ctx, id)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving command for task id %d", id)
+	}
+	return r, nil
+}
+
+// RunCommands runs pending commands one at a time, recording the outcome of each, and completes the tasks of those that succeed
+// each run is claimed as running before its command starts, so it isn't run again if the executor stops part way through,
+// and runs claimed by another instance first are skipped
+// returns the number of commands run, if it's the batch size there may be more pending
+func RunCommands(ctx context.Context, r CommandRepo, taskRepo TaskRepo, runner CommandRunner) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.RunCommands")
+	defer span.End()
+
+	rs, ucerr := r.GetPending(ctx, commandBatchSize)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving pending command runs")
+	}
+	count := 0
+	for _, rd := range rs {
+		run := rd.Run
+		run.Start()
+		claimed, ucerr := r.Start(ctx, run)
+		if ucerr != nil {
+			return count, ucerr.Prefix("error starting command run id %v", run.ID())
+		}
+		if !claimed {
+			continue
+		}
+		count++
+		code, stdout, stderr, err := runner.Run(ctx, run)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		}
+		run.Finish(code, stdout, stderr, reason)
+		if ucerr := r.Update(ctx, run); ucerr != nil {
+			return count, ucerr.Prefix("error updating command run id %v", run.ID())
+		}
+		if run.Status() == command.StatusSucceeded {
+			if ucerr := completeGeneratedTask(ctx, taskRepo, rd.TaskID); ucerr != nil {
+				return count, ucerr
+			}
+		}
+	}
+	return count, nil
+}
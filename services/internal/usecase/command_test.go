@@ -0,0 +1,139 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/command"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type commandRunnerStub struct {
+	exitCode int
+	stdout   string
+	stderr   string
+	err      error
+	statuses []command.Status
+}
+
+func (s *commandRunnerStub) Run(ctx context.Context, r *command.Run) (int, string, string, error) {
+	s.statuses = append(s.statuses, r.Status())
+	return s.exitCode, s.stdout, s.stderr, s.err
+}
+
+func TestGetTaskCommand(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := data.NewTaskRepo()
+	commandRepo := data.NewCommandRepo()
+	taskRepo.SetCommandRepo(commandRepo)
+	uid := user.NewID()
+	tsk := task.New("back up", "", uid)
+	tsk.SetCommand(command.NewRun("backup", nil, 0))
+	id, _ := taskRepo.Add(ctx, tsk)
+	manual, _ := taskRepo.Add(ctx, task.New("manual task", "", uid))
+
+	if r, ucerr := GetTaskCommand(ctx, taskRepo, commandRepo, id, uid); ucerr != nil || r.Program() != "backup" {
+		t.Errorf("GetTaskCommand() = %v, %v, want the task's run", r, ucerr)
+	}
+	if _, ucerr := GetTaskCommand(ctx, taskRepo, commandRepo, id, user.NewID()); ucerr == nil || ucerr.Code() != ErrRecordNotFound {
+		t.Errorf("GetTaskCommand() for another user's task error = %v, want %v", ucerr, ErrRecordNotFound)
+	}
+	if _, ucerr := GetTaskCommand(ctx, taskRepo, commandRepo, manual, uid); ucerr == nil || ucerr.Code() != ErrRecordNotFound {
+		t.Errorf("GetTaskCommand() for a task without a command error = %v, want %v", ucerr, ErrRecordNotFound)
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		runner        *commandRunnerStub
+		wantStatus    command.Status
+		wantError     string
+		wantCompleted bool
+	}{
+		{
+			name:          "zero exit code should record the output and complete the task",
+			runner:        &commandRunnerStub{stdout: "backed up 3 files"},
+			wantStatus:    command.StatusSucceeded,
+			wantCompleted: true,
+		},
+		{
+			name:       "non-zero exit code should fail and leave the task open",
+			runner:     &commandRunnerStub{exitCode: 1, stderr: "disk full"},
+			wantStatus: command.StatusFailed,
+		},
+		{
+			name:       "timeout should fail and leave the task open",
+			runner:     &commandRunnerStub{exitCode: -1, stdout: "backing up", err: errors.New("timed out after 1s")},
+			wantStatus: command.StatusFailed,
+			wantError:  "timed out after 1s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskRepo := data.NewTaskRepo()
+			r := data.NewCommandRepo()
+			taskRepo.SetCommandRepo(r)
+			tsk := task.New("back up", "", user.NewID())
+			tsk.SetCommand(command.NewRun("backup", nil, 0))
+			id, _ := taskRepo.Add(ctx, tsk)
+
+			count, err := RunCommands(ctx, r, taskRepo, tt.runner)
+			if err != nil {
+				t.Fatalf("RunCommands() error = %v", err)
+			}
+			if count != 1 || len(tt.runner.statuses) != 1 || tt.runner.statuses[0] != command.StatusRunning {
+				t.Errorf("RunCommands() ran %v commands with statuses %v, want 1 run that was started first", count, tt.runner.statuses)
+			}
+			run, _ := r.GetForTask(ctx, id)
+			if run.Status() != tt.wantStatus || run.ExitCode() != tt.runner.exitCode || run.Stdout() != tt.runner.stdout || run.Stderr() != tt.runner.stderr || run.LastError() != tt.wantError {
+				t.Errorf("RunCommands() run = %v %v %q %q %q, want %v with error %q", run.Status(), run.ExitCode(), run.Stdout(), run.Stderr(), run.LastError(), tt.wantStatus, tt.wantError)
+			}
+			got, _ := taskRepo.Get(ctx, id)
+			if completed := !got.CompletedTime().IsZero(); completed != tt.wantCompleted {
+				t.Errorf("RunCommands() task completed = %v, want %v", completed, tt.wantCompleted)
+			}
+			if count, _ := RunCommands(ctx, r, taskRepo, tt.runner); count != 0 {
+				t.Errorf("RunCommands() ran %v commands again, want each run only once", count)
+			}
+		})
+	}
+}
+
+type staleCommandRepo struct {
+	*data.CommandRepo
+	pending []CommandRunData
+}
+
+func (r staleCommandRepo) GetPending(ctx context.Context, limit int) ([]CommandRunData, Error) {
+	return r.pending, nil
+}
+
+func TestRunCommands_claimedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := data.NewTaskRepo()
+	r := data.NewCommandRepo()
+	taskRepo.SetCommandRepo(r)
+	tsk := task.New("back up", "", user.NewID())
+	tsk.SetCommand(command.NewRun("backup", nil, 0))
+	taskRepo.Add(ctx, tsk)
+	pending, _ := r.GetPending(ctx, 10)
+	other, _ := r.GetPending(ctx, 10)
+	other[0].Run.Start()
+	r.Start(ctx, other[0].Run)
+
+	runner := &commandRunnerStub{}
+	count, err := RunCommands(ctx, staleCommandRepo{r, pending}, taskRepo, runner)
+	if err != nil {
+		t.Fatalf("RunCommands() error = %v", err)
+	}
+	if count != 0 || len(runner.statuses) != 0 {
+		t.Errorf("RunCommands() ran %v commands, want the run started by another instance skipped", count)
+	}
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// ResetTokenDuration is how long a password reset token can be used for
+const ResetTokenDuration = 1 * time.Hour
+
+// CredentialRepo defines the local credential repository interface required by use cases
+type CredentialRepo interface {
+	Get(ctx context.Context, username string) (*credential.Credential, Error)
+	Add(context.Context, *credential.Credential) Error
+	Update(context.Context, *credential.Credential) Error
+}
+
+// RegisterLocalUser adds a new user mapped to the local provider, with a username and password
+func RegisterLocalUser(ctx context.Context, ur UserRepo, cr CredentialRepo, username string, password string, displayname string) (*user.User, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.RegisterLocalUser")
+	defer span.End()
+
+	username, err := credential.ParseUsername(username)
+	if err != nil {
+		return nil, NewError(ErrInvalidData, "error registering user: %v", err)
+	}
+	if _, ucerr := cr.Get(ctx, username); ucerr == nil {
+		return nil, NewError(ErrDuplicateRecord, "username %v is already taken", username)
+	} else if ucerr.Code() != ErrRecordNotFound {
+		return nil, ucerr.Prefix("error retrieving credential")
+	}
+
+	u := user.New(displayname)
+	c, err := credential.New(username, u.ID(), password)
+	if err != nil {
+		return nil, NewError(ErrInvalidData, "error registering user: %v", err)
+	}
+	if ucerr := ur.AddExternal(ctx, u, credential.ProviderID, username); ucerr != nil {
+		return nil, ucerr.Prefix("error adding local user")
+	}
+	if ucerr := cr.Add(ctx, c); ucerr != nil {
+		return nil, ucerr.Prefix("error adding credential")
+	}
+	return u, nil
+}
+
+// LoginLocalUser returns a local user's credential if the password matches
+// Returns an ErrForbidden error for both unknown usernames and wrong passwords
+func LoginLocalUser(ctx context.Context, cr CredentialRepo, username string, password string) (*credential.Credential, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.LoginLocalUser")
+	defer span.End()
+
+	c, ucerr := getLoginCredential(ctx, cr, username)
+	if ucerr != nil {
+		return nil, ucerr
+	}
+	if !c.CheckPassword(password) {
+		return nil, NewError(ErrForbidden, "invalid username or password")
+	}
+	return c, nil
+}
+
+// GetCredential returns a local user's credential given their username
+func GetCredential(ctx context.Context, cr CredentialRepo, username string) (*credential.Credential, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetCredential")
+	defer span.End()
+
+	parsed, err := credential.ParseUsername(username)
+	if err != nil {
+		return nil, NewError(ErrRecordNotFound, "no credential for username %v: %v", username, err)
+	}
+	return cr.Get(ctx, parsed)
+}
+
+// ChangeLocalPassword changes a local user's password, the current password must match
+func ChangeLocalPassword(ctx context.Context, cr CredentialRepo, username string, current string, password string) Error {
+	ctx, span := tracer.Start(ctx, "usecase.ChangeLocalPassword")
+	defer span.End()
+
+	c, ucerr := getLoginCredential(ctx, cr, username)
+	if ucerr != nil {
+		return ucerr
+	}
+	if err := credential.ValidatePassword(password); err != nil {
+		return NewError(ErrInvalidData, "error changing password: %v", err)
+	}
+	if err := c.ChangePassword(current, password); err != nil {
+		return NewError(ErrForbidden, "error changing password: %v", err)
+	}
+	if ucerr := cr.Update(ctx, c); ucerr != nil {
+		return ucerr.Prefix("error updating credential")
+	}
+	return nil
+}
+
+// RequestPasswordReset creates a password reset token for a local user, the token is only returned here
+func RequestPasswordReset(ctx context.Context, cr CredentialRepo, username string) (string, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.RequestPasswordReset")
+	defer span.End()
+
+	c, ucerr := GetCredential(ctx, cr, username)
+	if ucerr != nil {
+		return "", ucerr.Prefix("error retrieving credential")
+	}
+	token, err := c.NewResetToken(ResetTokenDuration)
+	if err != nil {
+		return "", NewError(ErrUnknown, "error creating reset token: %v", err)
+	}
+	if ucerr := cr.Update(ctx, c); ucerr != nil {
+		return "", ucerr.Prefix("error updating credential")
+	}
+	return token, nil
+}
+
+// ResetLocalPassword sets a new password for a local user using a password reset token
+// Returns an ErrForbidden error for unknown usernames and invalid or expired reset tokens
+func ResetLocalPassword(ctx context.Context, cr CredentialRepo, username string, token string, password string) Error {
+	ctx, span := tracer.Start(ctx, "usecase.ResetLocalPassword")
+	defer span.End()
+
+	c, ucerr := getLoginCredential(ctx, cr, username)
+	if ucerr != nil {
+		return ucerr
+	}
+	if err := credential.ValidatePassword(password); err != nil {
+		return NewError(ErrInvalidData, "error resetting password: %v", err)
+	}
+	if err := c.ResetPassword(token, password); err != nil {
+		return NewError(ErrForbidden, "error resetting password: %v", err)
+	}
+	if ucerr := cr.Update(ctx, c); ucerr != nil {
+		return ucerr.Prefix("error updating credential")
+	}
+	return nil
+}
+
+// getLoginCredential retrieves a credential, mapping an unknown username to an ErrForbidden error so usernames can't be probed
+func getLoginCredential(ctx context.Context, cr CredentialRepo, username string) (*credential.Credential, Error) {
+	c, ucerr := GetCredential(ctx, cr, username)
+	if ucerr != nil {
+		if ucerr.Code() == ErrRecordNotFound {
+			return nil, NewError(ErrForbidden, "invalid username or password")
+		}
+		return nil, ucerr.Prefix("error retrieving credential")
+	}
+	return c, nil
+}
@@ -0,0 +1,209 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/credential"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestRegisterLocalUser(t *testing.T) {
+	ctx := context.Background()
+
+	ur := data.NewUserRepo()
+	cr := data.NewCredentialRepo()
+	RegisterLocalUser(ctx, ur, cr, "jane", "password1", "Jane")
+
+	type args struct {
+		username string
+		password string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should register user mapped to the local provider",
+			args:    args{"John", "password1"},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "taken username should return an ErrDuplicateRecord",
+			args:    args{"JANE", "password1"},
+			wantErr: ErrDuplicateRecord,
+		},
+		{
+			name:    "invalid username should return an ErrInvalidData",
+			args:    args{"j", "password1"},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "invalid password should return an ErrInvalidData",
+			args:    args{"jim", "short"},
+			wantErr: ErrInvalidData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RegisterLocalUser(ctx, ur, cr, tt.args.username, tt.args.password, "display name")
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("RegisterLocalUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if u, _ := ur.GetExternal(ctx, credential.ProviderID, "john"); u != got {
+				t.Errorf("RegisterLocalUser() user = %v, want mapped user %v", got, u)
+			}
+		})
+	}
+}
+
+func TestLoginLocalUser(t *testing.T) {
+	ctx := context.Background()
+
+	ur := data.NewUserRepo()
+	cr := data.NewCredentialRepo()
+	RegisterLocalUser(ctx, ur, cr, "jane", "password1", "Jane")
+
+	type args struct {
+		username string
+		password string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "matching password should log in",
+			args:    args{"Jane", "password1"},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "wrong password should return an ErrForbidden",
+			args:    args{"jane", "password2"},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "unknown username should return an ErrForbidden",
+			args:    args{"john", "password1"},
+			wantErr: ErrForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoginLocalUser(ctx, cr, tt.args.username, tt.args.password)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("LoginLocalUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChangeLocalPassword(t *testing.T) {
+	ctx := context.Background()
+
+	ur := data.NewUserRepo()
+	cr := data.NewCredentialRepo()
+	RegisterLocalUser(ctx, ur, cr, "jane", "password1", "Jane")
+
+	type args struct {
+		current  string
+		password string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "wrong current password should return an ErrForbidden",
+			args:    args{"wrong password", "password2"},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "invalid new password should return an ErrInvalidData",
+			args:    args{"password1", "short"},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "matching current password should change password",
+			args:    args{"password1", "password2"},
+			wantErr: ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ChangeLocalPassword(ctx, cr, "jane", tt.args.current, tt.args.password)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ChangeLocalPassword() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if _, err := LoginLocalUser(ctx, cr, "jane", tt.args.password); (err == nil) != (tt.wantErr == ErrNone) {
+				t.Errorf("LoginLocalUser() with new password error = %v", err)
+			}
+		})
+	}
+}
+
+func TestResetLocalPassword(t *testing.T) {
+	ctx := context.Background()
+
+	ur := data.NewUserRepo()
+	cr := data.NewCredentialRepo()
+	RegisterLocalUser(ctx, ur, cr, "jane", "password1", "Jane")
+	resetToken, _ := RequestPasswordReset(ctx, cr, "jane")
+
+	type args struct {
+		username string
+		token    string
+		password string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "wrong reset token should return an ErrForbidden",
+			args:    args{"jane", "wrong", "password2"},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "unknown username should return an ErrForbidden",
+			args:    args{"john", resetToken, "password2"},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "invalid new password should return an ErrInvalidData",
+			args:    args{"jane", resetToken, "short"},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "valid reset token should reset password",
+			args:    args{"jane", resetToken, "password2"},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "used reset token should return an ErrForbidden",
+			args:    args{"jane", resetToken, "password3"},
+			wantErr: ErrForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResetLocalPassword(ctx, cr, tt.args.username, tt.args.token, tt.args.password)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ResetLocalPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := RequestPasswordReset(ctx, cr, "john"); err == nil || err.Code() != ErrRecordNotFound {
+		t.Errorf("RequestPasswordReset() error = %v, wantErr %v", err, ErrRecordNotFound)
+	}
+}
@@ -46,6 +46,9 @@ const (
 	ErrRecordNotFound
 	ErrDuplicateRecord
 	ErrInvalidID
+	ErrInvalidData
+	ErrForbidden
+	ErrQuotaExceeded
 )
 
 func (ec ErrorCode) String() string {
@@ -60,6 +63,12 @@ func (ec ErrorCode) String() string {
 		return "Duplicate record"
 	case ErrInvalidID:
 		return "Invalid ID"
+	case ErrInvalidData:
+		return "Invalid data"
+	case ErrForbidden:
+		return "Forbidden"
+	case ErrQuotaExceeded:
+		return "Quota exceeded"
 	}
 	return "[Invalid error code]"
 }
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// EventData contains a domain event along with the ID of the task or schedule that emitted it
+type EventData struct {
+	Event      *event.Event
+	TaskID     TaskID
+	ScheduleID ScheduleID
+}
+
+// OutboxRepo defines the event outbox repository interface required by use cases
+// events are added to the outbox by the task and schedule repos, along with the change that emitted them
+type OutboxRepo interface {
+	GetPending(ctx context.Context, before time.Time, limit int) ([]EventData, Error)
+	Update(context.Context, *event.Event) Error
+}
+
+// EventSubscriber handles published domain events
+// Name identifies the subscriber in each event's delivery record, so it must be unique and stay the same across restarts
+type EventSubscriber interface {
+	Name() string
+	HandleEvent(context.Context, EventData) error
+}
+
+// EventBatchSize is the maximum number of events published in a single run
+const EventBatchSize = 100
+
+// PublishEvents publishes due events from the outbox to every subscriber, oldest first, returning the number published
+// each subscriber that handles an event is recorded, so if any fail the event is only retried for those that failed,
+// waiting longer after each failed attempt as set by the retry policy, until it has been attempted MaxAttempts times
+func PublishEvents(ctx context.Context, r OutboxRepo, subscribers []EventSubscriber, p webhook.RetryPolicy) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.PublishEvents")
+	defer span.End()
+
+	es, ucerr := r.GetPending(ctx, clock.Now(), EventBatchSize)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving pending events")
+	}
+	published := 0
+	for _, ed := range es {
+		errs := []string{}
+		for _, s := range subscribers {
+			if ed.Event.IsDeliveredTo(s.Name()) {
+				continue
+			}
+			if err := s.HandleEvent(ctx, ed); err != nil {
+				errs = append(errs, fmt.Sprintf("%v: %v", s.Name(), err))
+				continue
+			}
+			ed.Event.Delivered(s.Name())
+		}
+		if len(errs) > 0 {
+			ed.Event.Failed(strings.Join(errs, "; "), p.MaxAttempts, p.Backoff(ed.Event.Attempts()+1))
+		} else {
+			ed.Event.Published()
+			published++
+		}
+		if ucerr := r.Update(ctx, ed.Event); ucerr != nil {
+			return published, ucerr.Prefix("error updating event id %v", ed.Event.ID())
+		}
+	}
+	return published, nil
+}
+
+// String returns a short description of the event and the task or schedule that emitted it, for logging
+func (ed EventData) String() string {
+	if ed.ScheduleID != 0 {
+		return fmt.Sprintf("%v schedule %v", ed.Event.Type(), ed.ScheduleID)
+	}
+	return fmt.Sprintf("%v task %v", ed.Event.Type(), ed.TaskID)
+}
@@ -0,0 +1,114 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type subscriberStub struct {
+	name     string
+	err      error
+	received []EventData
+}
+
+func (s *subscriberStub) Name() string {
+	return s.name
+}
+
+func (s *subscriberStub) HandleEvent(ctx context.Context, ed EventData) error {
+	s.received = append(s.received, ed)
+	return s.err
+}
+
+func TestPublishEvents(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		subscriberErr error
+		retryDelay    time.Duration
+		runs          int
+		wantPublished int
+		wantStatus    event.Status
+		wantAttempts  int
+		wantReceived  int
+		// wantDelivered is the number of events received by the subscriber that doesn't fail
+		wantDelivered int
+	}{
+		{
+			name:          "events should be published to every subscriber once",
+			runs:          2,
+			wantPublished: 2,
+			wantStatus:    event.StatusPublished,
+			wantAttempts:  0,
+			wantReceived:  2,
+			wantDelivered: 2,
+		},
+		{
+			name:          "failed events should only be retried for the failed subscriber until max attempts",
+			subscriberErr: errors.New("subscriber unavailable"),
+			runs:          3,
+			wantPublished: 0,
+			wantStatus:    event.StatusFailed,
+			wantAttempts:  2,
+			wantReceived:  4,
+			wantDelivered: 2,
+		},
+		{
+			name:          "failed events should not be retried until the retry delay has passed",
+			subscriberErr: errors.New("subscriber unavailable"),
+			retryDelay:    time.Hour,
+			runs:          3,
+			wantPublished: 0,
+			wantStatus:    event.StatusPending,
+			wantAttempts:  1,
+			wantReceived:  2,
+			wantDelivered: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := data.NewOutboxRepo()
+			taskRepo := data.NewTaskRepo()
+			taskRepo.SetOutboxRepo(outbox)
+			uid := user.NewID()
+			td, _ := AddTask(ctx, taskRepo, data.NewActivityRepo(), task.New("t1", "", uid))
+			CompleteTask(ctx, taskRepo, data.NewActivityRepo(), td.TaskID, uid)
+
+			s1 := &subscriberStub{name: "s1", err: tt.subscriberErr}
+			s2 := &subscriberStub{name: "s2"}
+			published := 0
+			for i := 0; i < tt.runs; i++ {
+				count, err := PublishEvents(ctx, outbox, []EventSubscriber{s1, s2}, webhook.RetryPolicy{MaxAttempts: 2, Delay: tt.retryDelay})
+				if err != nil {
+					t.Fatalf("PublishEvents() error = %v", err)
+				}
+				published += count
+			}
+			if published != tt.wantPublished {
+				t.Errorf("PublishEvents() published %v events, want %v", published, tt.wantPublished)
+			}
+			if len(s1.received) != tt.wantReceived || len(s2.received) != tt.wantDelivered {
+				t.Errorf("subscribers received %v and %v events, want %v and %v", len(s1.received), len(s2.received), tt.wantReceived, tt.wantDelivered)
+			}
+			if len(s2.received) > 0 && (s2.received[0].Event.Type() != event.TaskCreated || s2.received[0].TaskID != td.TaskID) {
+				t.Errorf("first event received = %v, want %v task %v", s2.received[0], event.TaskCreated, td.TaskID)
+			}
+			es, _ := outbox.GetAll(ctx)
+			for _, ed := range es {
+				if ed.Event.Status() != tt.wantStatus || ed.Event.Attempts() != tt.wantAttempts {
+					t.Errorf("event %v = %v after %v attempts, want %v after %v attempts", ed, ed.Event.Status(), ed.Event.Attempts(), tt.wantStatus, tt.wantAttempts)
+				}
+			}
+		})
+	}
+}
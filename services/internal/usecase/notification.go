@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+)
+
+// NotificationRepo defines the notification preferences and email queue repository interface required by use cases
+// AddEmail returns an ErrDuplicateRecord error if an email was already queued for the task
+type NotificationRepo interface {
+	Get(context.Context, user.ID) (*notification.Preferences, Error)
+	GetAllDigests(context.Context) ([]*notification.Preferences, Error)
+	Upsert(context.Context, *notification.Preferences) Error
+	AddEmail(ctx context.Context, e *notification.Email, taskID TaskID) Error
+	GetDueEmails(ctx context.Context, before time.Time, limit int) ([]*notification.Email, Error)
+	NextEmailAttemptTime(context.Context) (time.Time, Error)
+	UpdateEmail(context.Context, *notification.Email) Error
+}
+
+// NotificationSender sends digest emails to the address in a user's preferences, and queued emails to their recipient
+type NotificationSender interface {
+	SendDigest(ctx context.Context, p *notification.Preferences, d Digest) error
+	SendEmail(ctx context.Context, e *notification.Email) error
+}
+
+// NotificationFormatter renders a generated task into an email's subject and HTML body
+type NotificationFormatter interface {
+	FormatTaskGenerated(td TaskData) (subject string, html []byte, err error)
+}
+
+// emailBatchSize is the maximum number of due emails attempted in a single run
+const emailBatchSize = 100
+
+// Digest lists a user's open tasks, each ordered by due time then ID, with overdue tasks listed separately
+type Digest struct {
+	Time    time.Time
+	Overdue []TaskData
+	Open    []TaskData
+}
+
+// GetNotificationPreferences returns a user's notification preferences, all notifications are disabled if they haven't set any
+func GetNotificationPreferences(ctx context.Context, r NotificationRepo, uid user.ID) (*notification.Preferences, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetNotificationPreferences")
+	defer span.End()
+
+	p, ucerr := r.Get(ctx, uid)
+	if ucerr != nil {
+		if ucerr.Code() == ErrRecordNotFound {
+			return notification.NewRaw(uid, "", false, false, 0, time.Time{}), nil
+		}
+		return nil, ucerr.Prefix("error retrieving notification preferences")
+	}
+	return p, nil
+}
+
+// UpdateNotificationPreferences replaces a user's notification preferences
+// a digest already sent today isn't sent again, and the first digest is sent at the next digest hour
+func UpdateNotificationPreferences(ctx context.Context, r NotificationRepo, uid user.ID, email string, taskGenerated bool, digest bool, digestHour int) (*notification.Preferences, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.UpdateNotificationPreferences")
+	defer span.End()
+
+	p, err := notification.New(uid, email, taskGenerated, digest, digestHour)
+	if err != nil {
+		return nil, NewError(ErrInvalidData, "error creating notification preferences: %v", err)
+	}
+	prev, ucerr := GetNotificationPreferences(ctx, r, uid)
+	if ucerr != nil {
+		return nil, ucerr
+	}
+	lastDigest := prev.LastDigestTime()
+	if lastDigest.IsZero() {
+		lastDigest = clock.Now()
+	}
+	p.SetLastDigestTime(lastDigest)
+	if ucerr := r.Upsert(ctx, p); ucerr != nil {
+		return nil, ucerr.Prefix("error saving notification preferences")
+	}
+	return p, nil
+}
+
+// NotifyTaskGenerated queues an email of a generated task to its assignee, or the owner of its schedule if it isn't assigned, if they've enabled it
+// returns whether it was queued, events other than a generated task are ignored, and each task is only emailed once
+func NotifyTaskGenerated(ctx context.Context, r NotificationRepo, taskRepo TaskRepo, f NotificationFormatter, ed EventData) (bool, Error) {
+	if ed.Event.Type() != event.TaskGenerated {
+		return false, nil
+	}
+	ctx, span := tracer.Start(ctx, "usecase.NotifyTaskGenerated")
+	defer span.End()
+
+	t, ucerr := taskRepo.Get(ctx, ed.TaskID)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving task id %v", ed.TaskID)
+	}
+	uid := t.Assignee()
+	if uid.IsEmpty() {
+		uid = t.CreatedBy()
+	}
+	if uid.IsEmpty() {
+		return false, nil
+	}
+	p, ucerr := GetNotificationPreferences(ctx, r, uid)
+	if ucerr != nil {
+		return false, ucerr
+	}
+	if !p.TaskGenerated() {
+		return false, nil
+	}
+	subject, html, err := f.FormatTaskGenerated(TaskData{TaskID: ed.TaskID, Task: t})
+	if err != nil {
+		return false, NewError(ErrUnknown, "error formatting task id %v notification: %v", ed.TaskID, err)
+	}
+	if ucerr := r.AddEmail(ctx, notification.NewEmail(p.Email(), subject, html), ed.TaskID); ucerr != nil {
+		if ucerr.Code() == ErrDuplicateRecord {
+			return false, nil
+		}
+		return false, ucerr.Prefix("error queueing task id %v notification to user %v", ed.TaskID, uid)
+	}
+	return true, nil
+}
+
+// DeliverEmails attempts every queued email that is due, recording the outcome of each attempt
+// failed attempts are retried according to the retry policy
+// returns when the next email is due, zero if none are pending
+func DeliverEmails(ctx context.Context, r NotificationRepo, sender NotificationSender, p webhook.RetryPolicy) (time.Time, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.DeliverEmails")
+	defer span.End()
+
+	now := clock.Now()
+	es, ucerr := r.GetDueEmails(ctx, now, emailBatchSize)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving due emails")
+	}
+	for _, e := range es {
+		if err := sender.SendEmail(ctx, e); err != nil {
+			e.Failed(err.Error(), p)
+		} else {
+			e.Sent()
+		}
+		if ucerr := r.UpdateEmail(ctx, e); ucerr != nil {
+			return time.Time{}, ucerr.Prefix("error updating email id %v", e.ID())
+		}
+	}
+	if len(es) == emailBatchSize {
+		return now, nil
+	}
+
+	next, ucerr := r.NextEmailAttemptTime(ctx)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving next email time")
+	}
+	return next, nil
+}
+
+// SendDigests sends the daily digest of open tasks to every user it's due for, returning the number sent
+// users without open tasks aren't sent one, if sending fails for a user it's retried on the next run, and the last error is returned
+func SendDigests(ctx context.Context, r NotificationRepo, taskRepo TaskRepo, sender NotificationSender, now time.Time) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.SendDigests")
+	defer span.End()
+
+	ps, ucerr := r.GetAllDigests(ctx)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving digest preferences")
+	}
+	sent := 0
+	var lastErr Error
+	for _, p := range ps {
+		if !p.DigestDue(now) {
+			continue
+		}
+		d, ucerr := newDigest(ctx, taskRepo, p.UserID(), now)
+		if ucerr != nil {
+			lastErr = ucerr
+			continue
+		}
+		if len(d.Open)+len(d.Overdue) > 0 {
+			if err := sender.SendDigest(ctx, p, d); err != nil {
+				lastErr = NewError(ErrUnknown, "error sending digest to user %v: %v", p.UserID(), err)
+				continue
+			}
+			sent++
+		}
+		p.DigestSent(now)
+		if ucerr := r.Upsert(ctx, p); ucerr != nil {
+			lastErr = ucerr.Prefix("error saving digest time for user %v", p.UserID())
+		}
+	}
+	return sent, lastErr
+}
+
+func newDigest(ctx context.Context, taskRepo TaskRepo, uid user.ID, now time.Time) (Digest, Error) {
+	ts, ucerr := ListTasks(ctx, taskRepo, uid)
+	if ucerr != nil {
+		return Digest{}, ucerr.Prefix("error retrieving tasks for user %v", uid)
+	}
+	d := Digest{Time: now, Overdue: []TaskData{}, Open: []TaskData{}}
+	for id, t := range ts {
+		if !t.CompletedTime().IsZero() {
+			continue
+		}
+		if t.IsOverdue(now) {
+			d.Overdue = append(d.Overdue, TaskData{TaskID: id, Task: t})
+		} else {
+			d.Open = append(d.Open, TaskData{TaskID: id, Task: t})
+		}
+	}
+	sortByDueTime(d.Overdue)
+	sortByDueTime(d.Open)
+	return d, nil
+}
+
+// sortByDueTime orders tasks by due time, with tasks without one last, then by ID
+func sortByDueTime(tds []TaskData) {
+	sort.Slice(tds, func(i, j int) bool {
+		a, b := tds[i].Task.DueTime(), tds[j].Task.DueTime()
+		if !a.Equal(b) {
+			if a.IsZero() || b.IsZero() {
+				return b.IsZero()
+			}
+			return a.Before(b)
+		}
+		return tds[i].TaskID < tds[j].TaskID
+	})
+}
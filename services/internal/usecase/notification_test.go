@@ -0,0 +1,283 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/event"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/notification"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+type notificationSenderStub struct {
+	err     error
+	digests []Digest
+	emails  []*notification.Email
+	to      []string
+}
+
+func (s *notificationSenderStub) SendEmail(ctx context.Context, e *notification.Email) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.emails = append(s.emails, e)
+	s.to = append(s.to, e.To())
+	return nil
+}
+
+func (s *notificationSenderStub) SendDigest(ctx context.Context, p *notification.Preferences, d Digest) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.digests = append(s.digests, d)
+	s.to = append(s.to, p.Email())
+	return nil
+}
+
+type notificationFormatterStub struct {
+	err error
+}
+
+func (f *notificationFormatterStub) FormatTaskGenerated(td TaskData) (string, []byte, error) {
+	if f.err != nil {
+		return "", nil, f.err
+	}
+	return "New task: " + td.Task.Name(), []byte(fmt.Sprintf("<p>task %v</p>", td.TaskID)), nil
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	ctx := context.Background()
+	uid := user.NewID()
+	lastDigest := time.Date(2019, 1, 2, 8, 0, 0, 0, time.UTC)
+
+	r := data.NewNotificationRepo()
+	r.Upsert(ctx, notification.NewRaw(uid, "old@example.com", false, true, 8, lastDigest))
+
+	if _, err := UpdateNotificationPreferences(ctx, r, uid, "", true, false, 8); err == nil || err.Code() != ErrInvalidData {
+		t.Errorf("UpdateNotificationPreferences() without email error = %v, want code %v", err, ErrInvalidData)
+	}
+	p, err := UpdateNotificationPreferences(ctx, r, uid, "new@example.com", true, true, 9)
+	if err != nil {
+		t.Fatalf("UpdateNotificationPreferences() error = %v", err)
+	}
+	got, _ := GetNotificationPreferences(ctx, r, uid)
+	if got.Email() != "new@example.com" || got.DigestHour() != 9 || !got.TaskGenerated() {
+		t.Errorf("GetNotificationPreferences() = %+v, want updated preferences", got)
+	}
+	if !p.LastDigestTime().Equal(lastDigest) || !got.LastDigestTime().Equal(lastDigest) {
+		t.Errorf("LastDigestTime() = %v, want %v kept", got.LastDigestTime(), lastDigest)
+	}
+
+	newUser := user.NewID()
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(lastDigest))
+	p, err = UpdateNotificationPreferences(ctx, r, newUser, "first@example.com", false, true, 9)
+	if err != nil {
+		t.Fatalf("UpdateNotificationPreferences() error = %v", err)
+	}
+	if !p.LastDigestTime().Equal(lastDigest) {
+		t.Errorf("first LastDigestTime() = %v, want %v so the first digest waits for the digest hour", p.LastDigestTime(), lastDigest)
+	}
+
+	none, err := GetNotificationPreferences(ctx, r, user.NewID())
+	if err != nil {
+		t.Fatalf("GetNotificationPreferences() error = %v", err)
+	}
+	if none.TaskGenerated() || none.Digest() {
+		t.Errorf("GetNotificationPreferences() for a new user = %+v, want notifications disabled", none)
+	}
+}
+
+func TestNotifyTaskGenerated(t *testing.T) {
+	ctx := context.Background()
+	owner := user.NewID()
+	assignee := user.NewID()
+
+	tests := []struct {
+		name      string
+		eventType event.Type
+		assignee  user.ID
+		prefs     []*notification.Preferences
+		formatErr error
+		wantTo    []string
+		wantErr   bool
+	}{
+		{
+			name:      "should notify the task's assignee",
+			eventType: event.TaskGenerated,
+			assignee:  assignee,
+			prefs: []*notification.Preferences{
+				notification.NewRaw(owner, "owner@example.com", true, false, 0, time.Time{}),
+				notification.NewRaw(assignee, "assignee@example.com", true, false, 0, time.Time{}),
+			},
+			wantTo: []string{"assignee@example.com"},
+		},
+		{
+			name:      "should notify the task's creator if it isn't assigned",
+			eventType: event.TaskGenerated,
+			prefs:     []*notification.Preferences{notification.NewRaw(owner, "owner@example.com", true, false, 0, time.Time{})},
+			wantTo:    []string{"owner@example.com"},
+		},
+		{
+			name:      "should not notify a user that disabled it",
+			eventType: event.TaskGenerated,
+			prefs:     []*notification.Preferences{notification.NewRaw(owner, "owner@example.com", false, true, 0, time.Time{})},
+		},
+		{
+			name:      "should not notify a user without preferences",
+			eventType: event.TaskGenerated,
+		},
+		{
+			name:      "should ignore other events",
+			eventType: event.TaskCompleted,
+			prefs:     []*notification.Preferences{notification.NewRaw(owner, "owner@example.com", true, false, 0, time.Time{})},
+		},
+		{
+			name:      "should return formatting errors",
+			eventType: event.TaskGenerated,
+			prefs:     []*notification.Preferences{notification.NewRaw(owner, "owner@example.com", true, false, 0, time.Time{})},
+			formatErr: errors.New("template error"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := data.NewNotificationRepo()
+			for _, p := range tt.prefs {
+				r.Upsert(ctx, p)
+			}
+			taskRepo := data.NewTaskRepo()
+			tk := task.New("generated", "desc", owner)
+			tk.SetAssignee(tt.assignee)
+			td, _ := AddTask(ctx, taskRepo, data.NewActivityRepo(), tk)
+			f := &notificationFormatterStub{err: tt.formatErr}
+			ed := EventData{Event: event.New(tt.eventType), TaskID: td.TaskID}
+
+			queued, err := NotifyTaskGenerated(ctx, r, taskRepo, f, ed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NotifyTaskGenerated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if queued != (len(tt.wantTo) > 0) {
+				t.Errorf("NotifyTaskGenerated() queued = %v, want %v", queued, len(tt.wantTo) > 0)
+			}
+			if queued, err := NotifyTaskGenerated(ctx, r, taskRepo, f, ed); queued || (err != nil) != tt.wantErr {
+				t.Errorf("NotifyTaskGenerated() again = %v, %v, want each task emailed once", queued, err)
+			}
+			es, _ := r.GetAllEmails(ctx)
+			if len(es) != len(tt.wantTo) {
+				t.Fatalf("NotifyTaskGenerated() queued %v, want emails to %v", es, tt.wantTo)
+			}
+			for i, to := range tt.wantTo {
+				if es[i].To() != to || es[i].Subject() != "New task: generated" || string(es[i].HTML()) != fmt.Sprintf("<p>task %v</p>", td.TaskID) {
+					t.Errorf("NotifyTaskGenerated() queued %v %q %s, want task %v to %v", es[i].To(), es[i].Subject(), es[i].HTML(), td.TaskID, to)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverEmails(t *testing.T) {
+	ctx := context.Background()
+	policy := webhook.RetryPolicy{MaxAttempts: 2, Delay: time.Minute}
+
+	tests := []struct {
+		name       string
+		sender     *notificationSenderStub
+		wantStatus notification.Status
+		wantError  string
+		wantNext   bool
+	}{
+		{
+			name:       "sent email should be completed",
+			sender:     &notificationSenderStub{},
+			wantStatus: notification.StatusSent,
+		},
+		{
+			name:       "sending error should schedule a retry",
+			sender:     &notificationSenderStub{err: errors.New("smtp unavailable")},
+			wantStatus: notification.StatusPending,
+			wantError:  "smtp unavailable",
+			wantNext:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := data.NewNotificationRepo()
+			r.AddEmail(ctx, notification.NewEmail("user@example.com", "New task", []byte("<p>task</p>")), 1)
+
+			next, err := DeliverEmails(ctx, r, tt.sender, policy)
+			if err != nil {
+				t.Fatalf("DeliverEmails() error = %v", err)
+			}
+			if next.IsZero() == tt.wantNext {
+				t.Errorf("DeliverEmails() next = %v, want retry scheduled %v", next, tt.wantNext)
+			}
+			es, _ := r.GetAllEmails(ctx)
+			if len(es) != 1 || es[0].Status() != tt.wantStatus || es[0].LastError() != tt.wantError || es[0].Attempts() != 1 {
+				t.Errorf("DeliverEmails() emails = %+v, want 1 %v email with error %q", es, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestSendDigests(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2019, 1, 2, 9, 30, 0, 0, time.UTC)
+	uid := user.NewID()
+	noTasks := user.NewID()
+	later := user.NewID()
+
+	r := data.NewNotificationRepo()
+	r.Upsert(ctx, notification.NewRaw(uid, "user@example.com", false, true, 9, time.Time{}))
+	r.Upsert(ctx, notification.NewRaw(noTasks, "none@example.com", false, true, 9, time.Time{}))
+	r.Upsert(ctx, notification.NewRaw(later, "later@example.com", false, true, 10, now.Add(-time.Hour)))
+	taskRepo := data.NewTaskRepo()
+
+	overdue := task.New("overdue", "", uid)
+	overdue.SetDueTime(now.Add(-time.Hour))
+	dueSoon := task.New("due soon", "", uid)
+	dueSoon.SetDueTime(now.Add(time.Hour))
+	completed := task.New("completed", "", uid)
+	completed.CompleteNow()
+	overdueTD, _ := AddTask(ctx, taskRepo, data.NewActivityRepo(), overdue)
+	openTD, _ := AddTask(ctx, taskRepo, data.NewActivityRepo(), task.New("no due time", "", uid))
+	dueSoonTD, _ := AddTask(ctx, taskRepo, data.NewActivityRepo(), dueSoon)
+	AddTask(ctx, taskRepo, data.NewActivityRepo(), completed)
+	AddTask(ctx, taskRepo, data.NewActivityRepo(), task.New("later", "", later))
+
+	sender := &notificationSenderStub{}
+	count, err := SendDigests(ctx, r, taskRepo, sender, now)
+	if err != nil {
+		t.Fatalf("SendDigests() error = %v", err)
+	}
+	if count != 1 || len(sender.digests) != 1 || sender.to[0] != "user@example.com" {
+		t.Fatalf("SendDigests() sent %v digests to %v, want 1 to user@example.com", count, sender.to)
+	}
+	d := sender.digests[0]
+	if len(d.Overdue) != 1 || d.Overdue[0].TaskID != overdueTD.TaskID {
+		t.Errorf("digest overdue tasks = %v, want [%v]", d.Overdue, overdueTD.TaskID)
+	}
+	if len(d.Open) != 2 || d.Open[0].TaskID != dueSoonTD.TaskID || d.Open[1].TaskID != openTD.TaskID {
+		t.Errorf("digest open tasks = %v, want [%v %v]", d.Open, dueSoonTD.TaskID, openTD.TaskID)
+	}
+	for _, id := range []user.ID{uid, noTasks} {
+		p, _ := r.Get(ctx, id)
+		if !p.LastDigestTime().Equal(now) {
+			t.Errorf("user %v LastDigestTime() = %v, want %v", id, p.LastDigestTime(), now)
+		}
+	}
+
+	count, err = SendDigests(ctx, r, taskRepo, sender, now.Add(10*time.Minute))
+	if err != nil || count != 0 {
+		t.Errorf("SendDigests() again = %v, %v, want no digests sent twice in a day", count, err)
+	}
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// Quota limits how many active schedules and recurring tasks each user can own, zero values are unlimited
+type Quota struct {
+	MaxSchedules      int
+	MaxRecurringTasks int
+}
+
+// CheckScheduleQuota returns an ErrQuotaExceeded error if the user can't add another schedule with the given number of recurring tasks
+func CheckScheduleQuota(ctx context.Context, r ScheduleRepo, q Quota, uid user.ID, newTasks int) Error {
+	ctx, span := tracer.Start(ctx, "usecase.CheckScheduleQuota")
+	defer span.End()
+
+	if q.MaxSchedules <= 0 && (q.MaxRecurringTasks <= 0 || newTasks == 0) {
+		return nil
+	}
+	schedules, tasks, err := countOwned(ctx, r, uid)
+	if err != nil {
+		return err
+	}
+	if q.MaxSchedules > 0 && schedules >= q.MaxSchedules {
+		return NewError(ErrQuotaExceeded, "quota of %d active schedules reached", q.MaxSchedules)
+	}
+	if q.MaxRecurringTasks > 0 && tasks+newTasks > q.MaxRecurringTasks {
+		return NewError(ErrQuotaExceeded, "quota of %d recurring tasks exceeded", q.MaxRecurringTasks)
+	}
+	return nil
+}
+
+// CheckRecurringTaskQuota returns an ErrQuotaExceeded error if another recurring task can't be added to the schedule
+// recurring tasks count towards the quota of the user that owns the schedule
+func CheckRecurringTaskQuota(ctx context.Context, r ScheduleRepo, q Quota, id ScheduleID, uid user.ID) Error {
+	ctx, span := tracer.Start(ctx, "usecase.CheckRecurringTaskQuota")
+	defer span.End()
+
+	if q.MaxRecurringTasks <= 0 {
+		return nil
+	}
+	s, err := r.GetForUser(ctx, id, uid)
+	if err != nil {
+		return err.Prefix("error retrieving schedule id %v to check quota", id)
+	}
+	_, tasks, err := countOwned(ctx, r, s.CreatedBy())
+	if err != nil {
+		return err
+	}
+	if tasks >= q.MaxRecurringTasks {
+		return NewError(ErrQuotaExceeded, "quota of %d recurring tasks reached", q.MaxRecurringTasks)
+	}
+	return nil
+}
+
+// countOwned counts the active schedules a user created, and the recurring tasks in them
+func countOwned(ctx context.Context, r ScheduleRepo, uid user.ID) (schedules int, tasks int, err Error) {
+	ss, err := r.GetAllForUser(ctx, uid)
+	if err != nil {
+		return 0, 0, err.Prefix("error retrieving schedules to check quota")
+	}
+	for _, s := range ss {
+		if !s.IsValid() || !s.CreatedBy().Equals(uid) {
+			continue
+		}
+		schedules++
+		tasks += len(s.Tasks())
+	}
+	return schedules, tasks, nil
+}
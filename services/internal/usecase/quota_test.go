@@ -0,0 +1,135 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestCheckScheduleQuota(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	uid1 := user.NewID()
+	uid2 := user.NewID()
+	s := schedule.New(f, uid1)
+	s.AddTask(schedule.NewRecurringTask("task 1", ""))
+	r.Add(ctx, s)
+	removed := schedule.New(f, uid1)
+	removed.AddTask(schedule.NewRecurringTask("task 2", ""))
+	removed.Remove()
+	r.Add(ctx, removed)
+
+	type args struct {
+		q     Quota
+		uid   user.ID
+		tasks int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "unlimited quota should allow new schedules",
+			args:    args{Quota{}, uid1, 5},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "user below quota should be allowed a new schedule",
+			args:    args{Quota{MaxSchedules: 2}, uid1, 0},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "user at quota should return an ErrQuotaExceeded",
+			args:    args{Quota{MaxSchedules: 1}, uid1, 0},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:    "other users' schedules should not count towards quota",
+			args:    args{Quota{MaxSchedules: 1}, uid2, 0},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "new schedule's tasks within the recurring task quota should be allowed",
+			args:    args{Quota{MaxRecurringTasks: 3}, uid1, 2},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "new schedule's tasks beyond the recurring task quota should return an ErrQuotaExceeded",
+			args:    args{Quota{MaxRecurringTasks: 3}, uid1, 3},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:    "new schedule without tasks should be allowed at the recurring task quota",
+			args:    args{Quota{MaxRecurringTasks: 1}, uid1, 0},
+			wantErr: ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckScheduleQuota(ctx, r, tt.args.q, tt.args.uid, tt.args.tasks)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("CheckScheduleQuota() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRecurringTaskQuota(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	uid := user.NewID()
+	s1 := schedule.New(f, uid)
+	s1.AddTask(schedule.NewRecurringTask("task 1", ""))
+	sID1, _ := r.Add(ctx, s1)
+	s2 := schedule.New(f, uid)
+	s2.AddTask(schedule.NewRecurringTask("task 2", ""))
+	sID2, _ := r.Add(ctx, s2)
+
+	type args struct {
+		q  Quota
+		id ScheduleID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "unlimited quota should allow new recurring tasks",
+			args:    args{Quota{}, sID1},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "owner below quota should be allowed a new recurring task",
+			args:    args{Quota{MaxRecurringTasks: 3}, sID1},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "recurring tasks across all of the owner's schedules should count towards quota",
+			args:    args{Quota{MaxRecurringTasks: 2}, sID2},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:    "unknown schedule should return an ErrRecordNotFound",
+			args:    args{Quota{MaxRecurringTasks: 3}, 9999},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRecurringTaskQuota(ctx, r, tt.args.q, tt.args.id, uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("CheckRecurringTaskQuota() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
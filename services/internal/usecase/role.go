@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// RoleRepo defines the role permission repository interface required by use cases
+// Permissions are stored as a bitmask of the permission flags defined by the presentation layer
+type RoleRepo interface {
+	GetPermissions(context.Context, user.Role) (int64, Error)
+	SetPermissions(context.Context, user.Role, int64) Error
+}
+
+// GetRolePermissions returns the persisted permission bitmask for a role
+// Returns an ErrRecordNotFound error if the role's permissions have never been changed from its defaults
+func GetRolePermissions(ctx context.Context, r RoleRepo, role user.Role) (int64, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetRolePermissions")
+	defer span.End()
+
+	if !role.IsValid() {
+		return 0, NewError(ErrInvalidData, "invalid role %d", role)
+	}
+	perms, ucerr := r.GetPermissions(ctx, role)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving permissions for role %v", role)
+	}
+	return perms, nil
+}
+
+// SetRolePermissions persists the permission bitmask granted to a role
+func SetRolePermissions(ctx context.Context, r RoleRepo, role user.Role, perms int64) Error {
+	ctx, span := tracer.Start(ctx, "usecase.SetRolePermissions")
+	defer span.End()
+
+	if !role.IsValid() {
+		return NewError(ErrInvalidData, "invalid role %d", role)
+	}
+	if ucerr := r.SetPermissions(ctx, role, perms); ucerr != nil {
+		return ucerr.Prefix("error setting permissions for role %v", role)
+	}
+	return nil
+}
+
+// GrantAdminRole gives a user the admin role, so admins configured by the operator can bootstrap a new install
+func GrantAdminRole(ctx context.Context, r UserRepo, u *user.User) Error {
+	ctx, span := tracer.Start(ctx, "usecase.GrantAdminRole")
+	defer span.End()
+
+	if u.Role() == user.RoleAdmin {
+		return nil
+	}
+	if err := u.SetRole(user.RoleAdmin); err != nil {
+		return NewError(ErrInvalidData, "error setting role for user %v: %v", u.ID(), err)
+	}
+	if ucerr := r.Update(ctx, u); ucerr != nil {
+		return ucerr.Prefix("error updating user %v", u.ID())
+	}
+	return nil
+}
+
+// SetUserRole changes another user's role, users cannot change their own role
+func SetUserRole(ctx context.Context, r UserRepo, changedBy user.ID, id user.ID, role user.Role) Error {
+	ctx, span := tracer.Start(ctx, "usecase.SetUserRole")
+	defer span.End()
+
+	if changedBy.Equals(id) {
+		return NewError(ErrForbidden, "user %v cannot change their own role", id)
+	}
+	u, ucerr := r.Get(ctx, id)
+	if ucerr != nil {
+		return ucerr.Prefix("error retrieving user %v", id)
+	}
+	if err := u.SetRole(role); err != nil {
+		return NewError(ErrInvalidData, "error setting role for user %v: %v", id, err)
+	}
+	if ucerr := r.Update(ctx, u); ucerr != nil {
+		return ucerr.Prefix("error updating user %v", id)
+	}
+	return nil
+}
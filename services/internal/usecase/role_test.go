@@ -0,0 +1,125 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestSetRolePermissions(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewRoleRepo()
+
+	type args struct {
+		role  user.Role
+		perms int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should set role permissions",
+			args:    args{user.RoleViewer, 6},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "invalid role should return an ErrInvalidData",
+			args:    args{user.Role(0), 6},
+			wantErr: ErrInvalidData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetRolePermissions(ctx, r, tt.args.role, tt.args.perms)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("SetRolePermissions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if got, _ := GetRolePermissions(ctx, r, tt.args.role); got != tt.args.perms {
+					t.Errorf("GetRolePermissions() = %v, want %v", got, tt.args.perms)
+				}
+			}
+		})
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewUserRepo()
+	admin := user.New("admin for SetUserRole")
+	member := user.New("member for SetUserRole")
+	r.AddExternal(ctx, admin, "p", "admin")
+	r.AddExternal(ctx, member, "p", "member")
+
+	type args struct {
+		changedBy user.ID
+		id        user.ID
+		role      user.Role
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should change another user's role",
+			args:    args{admin.ID(), member.ID(), user.RoleViewer},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "changing own role should return an ErrForbidden",
+			args:    args{admin.ID(), admin.ID(), user.RoleViewer},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "unknown user should return an ErrRecordNotFound",
+			args:    args{admin.ID(), user.NewID(), user.RoleViewer},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "invalid role should return an ErrInvalidData",
+			args:    args{admin.ID(), member.ID(), user.Role(0)},
+			wantErr: ErrInvalidData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetUserRole(ctx, r, tt.args.changedBy, tt.args.id, tt.args.role)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("SetUserRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if got, _ := r.Get(ctx, tt.args.id); got.Role() != tt.args.role {
+					t.Errorf("SetUserRole() role = %v, want %v", got.Role(), tt.args.role)
+				}
+			}
+		})
+	}
+}
+
+func TestGrantAdminRole(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewUserRepo()
+	u := user.New("member")
+	r.AddExternal(ctx, u, "p1", "e1")
+
+	if err := GrantAdminRole(ctx, r, u); err != nil {
+		t.Fatalf("GrantAdminRole() error = %v", err)
+	}
+	if got, _ := r.Get(ctx, u.ID()); got.Role() != user.RoleAdmin {
+		t.Errorf("GrantAdminRole() role = %v, want %v", got.Role(), user.RoleAdmin)
+	}
+	if err := GrantAdminRole(ctx, r, user.New("unknown")); err == nil || err.Code() != ErrRecordNotFound {
+		t.Errorf("GrantAdminRole() of unknown user error = %v, want %v", err, ErrRecordNotFound)
+	}
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
@@ -16,18 +18,22 @@ type ScheduleData struct {
 
 // ScheduleRepo defines the task repository interface required by use cases
 type ScheduleRepo interface {
-	Get(ScheduleID) (*schedule.Schedule, Error)
-	GetForUser(ScheduleID, user.ID) (*schedule.Schedule, Error)
-	GetAll() (map[ScheduleID]*schedule.Schedule, Error)
-	GetAllForUser(user.ID) (map[ScheduleID]*schedule.Schedule, Error)
-	GetAllScheduled() (map[ScheduleID]*schedule.Schedule, Error)
-	Add(*schedule.Schedule) (ScheduleID, Error)
-	Update(ScheduleID, *schedule.Schedule) Error
+	Get(context.Context, ScheduleID) (*schedule.Schedule, Error)
+	GetForUser(context.Context, ScheduleID, user.ID) (*schedule.Schedule, Error)
+	GetAll(context.Context) (map[ScheduleID]*schedule.Schedule, Error)
+	GetAllForUser(context.Context, user.ID) (map[ScheduleID]*schedule.Schedule, Error)
+	GetAllScheduled(context.Context) (map[ScheduleID]*schedule.Schedule, Error)
+	Add(context.Context, *schedule.Schedule) (ScheduleID, Error)
+	Update(context.Context, ScheduleID, *schedule.Schedule) Error
+	SearchRecurringTasks(ctx context.Context, query string, uid user.ID) ([]SearchResult, Error)
 }
 
 // GetSchedule returns a single schedule
-func GetSchedule(r ScheduleRepo, id ScheduleID, uid user.ID) (*ScheduleData, Error) {
-	s, err := r.GetForUser(id, uid)
+func GetSchedule(ctx context.Context, r ScheduleRepo, id ScheduleID, uid user.ID) (*ScheduleData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetSchedule")
+	defer span.End()
+
+	s, err := r.GetForUser(ctx, id, uid)
 	if err != nil {
 		return nil, err.Prefix("error getting schedule id %v", id)
 	}
@@ -40,8 +46,11 @@ func GetSchedule(r ScheduleRepo, id ScheduleID, uid user.ID) (*ScheduleData, Err
 }
 
 // ListSchedules returns all schedules
-func ListSchedules(r ScheduleRepo, uid user.ID) (map[ScheduleID]*schedule.Schedule, Error) {
-	ss, err := r.GetAllForUser(uid)
+func ListSchedules(ctx context.Context, r ScheduleRepo, uid user.ID) (map[ScheduleID]*schedule.Schedule, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListSchedules")
+	defer span.End()
+
+	ss, err := r.GetAllForUser(ctx, uid)
 	if err != nil {
 		return nil, err.Prefix("error listing all schedules")
 	}
@@ -58,8 +67,11 @@ func ListSchedules(r ScheduleRepo, uid user.ID) (map[ScheduleID]*schedule.Schedu
 }
 
 // AddSchedule adds a new schedule
-func AddSchedule(r ScheduleRepo, s *schedule.Schedule, checkSchedule chan<- bool) (ScheduleID, Error) {
-	id, err := r.Add(s)
+func AddSchedule(ctx context.Context, r ScheduleRepo, s *schedule.Schedule, checkSchedule chan<- bool) (ScheduleID, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddSchedule")
+	defer span.End()
+
+	id, err := r.Add(ctx, s)
 	if err != nil {
 		return id, err.Prefix("error adding schedule")
 	}
@@ -71,9 +83,11 @@ func AddSchedule(r ScheduleRepo, s *schedule.Schedule, checkSchedule chan<- bool
 }
 
 // PauseSchedule pauses the schedule
-func PauseSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule chan<- bool) Error {
+func PauseSchedule(ctx context.Context, r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule chan<- bool) Error {
+	ctx, span := tracer.Start(ctx, "usecase.PauseSchedule")
+	defer span.End()
 
-	s, err := r.GetForUser(id, uid)
+	s, err := r.GetForUser(ctx, id, uid)
 	if err != nil {
 		return err.Prefix("error retrieving schedule id %d to pause", id)
 	}
@@ -84,7 +98,7 @@ func PauseSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule cha
 
 	s.Pause()
 
-	err = r.Update(id, s)
+	err = r.Update(ctx, id, s)
 	if err != nil {
 		return err.Prefix("error updating schedule id %d attempting to pause", id)
 	}
@@ -96,9 +110,11 @@ func PauseSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule cha
 }
 
 // UnpauseSchedule unpauses the schedule
-func UnpauseSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule chan<- bool) Error {
+func UnpauseSchedule(ctx context.Context, r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule chan<- bool) Error {
+	ctx, span := tracer.Start(ctx, "usecase.UnpauseSchedule")
+	defer span.End()
 
-	s, err := r.GetForUser(id, uid)
+	s, err := r.GetForUser(ctx, id, uid)
 	if err != nil {
 		return err.Prefix("error retrieving schedule id %d to unpause", id)
 	}
@@ -109,7 +125,7 @@ func UnpauseSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule c
 
 	s.Unpause()
 
-	err = r.Update(id, s)
+	err = r.Update(ctx, id, s)
 	if err != nil {
 		return err.Prefix("error updating schedule id %d attempting to unpause", id)
 	}
@@ -121,8 +137,11 @@ func UnpauseSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule c
 }
 
 // RemoveSchedule removes a schedule
-func RemoveSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule chan<- bool) Error {
-	s, ucErr := r.GetForUser(id, uid)
+func RemoveSchedule(ctx context.Context, r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule chan<- bool) Error {
+	ctx, span := tracer.Start(ctx, "usecase.RemoveSchedule")
+	defer span.End()
+
+	s, ucErr := r.GetForUser(ctx, id, uid)
 	if ucErr != nil {
 		return ucErr.Prefix("error retrieving schedule id %d to remove", id)
 	}
@@ -132,7 +151,7 @@ func RemoveSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule ch
 		return NewError(ErrUnknown, "error removing schedule id %d", id)
 	}
 
-	ucErr = r.Update(id, s)
+	ucErr = r.Update(ctx, id, s)
 	if err != nil {
 		return ucErr.Prefix("error attempting to remove schedule id %d", id)
 	}
@@ -144,9 +163,11 @@ func RemoveSchedule(r ScheduleRepo, id ScheduleID, uid user.ID, checkSchedule ch
 }
 
 // AddRecurringTask adds a new recurring task to the schedule
-func AddRecurringTask(r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule.RecurringTask) Error {
+func AddRecurringTask(ctx context.Context, r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule.RecurringTask) Error {
+	ctx, span := tracer.Start(ctx, "usecase.AddRecurringTask")
+	defer span.End()
 
-	s, err := r.GetForUser(id, uid)
+	s, err := r.GetForUser(ctx, id, uid)
 	if err != nil {
 		return err.Prefix("error retrieving schedule id %v to add recurring task", id)
 	}
@@ -155,7 +176,7 @@ func AddRecurringTask(r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule.Re
 		return NewError(ErrDuplicateRecord, "can't add recurring task: duplicate found for schedule id %v", id)
 	}
 
-	err = r.Update(id, s)
+	err = r.Update(ctx, id, s)
 	if err != nil {
 		return err.Prefix("error updating schedule id %d attempting to add recurring task", id)
 	}
@@ -164,8 +185,11 @@ func AddRecurringTask(r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule.Re
 }
 
 // RemoveRecurringTask removes the recurring task at the specified index from the schedule
-func RemoveRecurringTask(r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule.RecurringTask) Error {
-	s, err := r.GetForUser(id, uid)
+func RemoveRecurringTask(ctx context.Context, r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule.RecurringTask) Error {
+	ctx, span := tracer.Start(ctx, "usecase.RemoveRecurringTask")
+	defer span.End()
+
+	s, err := r.GetForUser(ctx, id, uid)
 	if err != nil {
 		return err.Prefix("error retrieving schedule id %v to remove recurring task", id)
 	}
@@ -174,7 +198,7 @@ func RemoveRecurringTask(r ScheduleRepo, id ScheduleID, uid user.ID, rt schedule
 		return NewError(ErrRecordNotFound, "can't remove recurring task from schedule id %v", id)
 	}
 
-	err = r.Update(id, s)
+	err = r.Update(ctx, id, s)
 	if err != nil {
 		return err.Prefix("error updating schedule id %d attempting to remove recurring task", id)
 	}
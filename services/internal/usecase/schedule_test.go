@@ -1,6 +1,7 @@
 package usecase_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -11,16 +12,18 @@ import (
 )
 
 func TestGetSchedule(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	hourFreq, _ := schedule.NewHourFrequency([]int{0})
 	hourSched := schedule.New(hourFreq, user.ID{})
-	hourSchedID, _ := r.Add(hourSched)
+	hourSchedID, _ := r.Add(ctx, hourSched)
 
 	r2 := data.NewScheduleRepo()
 	f, _ := schedule.NewHourFrequency([]int{0})
 	s1 := schedule.New(f, user.ID{})
 	s1.Remove()
-	sID1, _ := r2.Add(s1)
+	sID1, _ := r2.Add(ctx, s1)
 
 	type args struct {
 		r   ScheduleRepo
@@ -52,7 +55,7 @@ func TestGetSchedule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetSchedule(tt.args.r, tt.args.id, tt.args.uid)
+			got, err := GetSchedule(ctx, tt.args.r, tt.args.id, tt.args.uid)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetSchedule() got = %v, want %v", got, tt.want)
 			}
@@ -65,21 +68,23 @@ func TestGetSchedule(t *testing.T) {
 }
 
 func TestListSchedules(t *testing.T) {
+	ctx := context.Background()
+
 	r1 := data.NewScheduleRepo()
 
 	r2 := data.NewScheduleRepo()
 	hourFreq, _ := schedule.NewHourFrequency([]int{0})
 	hourSched := schedule.New(hourFreq, user.ID{})
-	hourSchedID1, _ := r2.Add(hourSched)
-	hourSchedID2, _ := r2.Add(hourSched)
+	hourSchedID1, _ := r2.Add(ctx, hourSched)
+	hourSchedID2, _ := r2.Add(ctx, hourSched)
 
 	r3 := data.NewScheduleRepo()
 	f, _ := schedule.NewHourFrequency([]int{0})
 	s1 := schedule.New(f, user.ID{})
 	s2 := schedule.New(f, user.ID{})
 	s2.Remove()
-	sID1, _ := r3.Add(s1)
-	r3.Add(s2)
+	sID1, _ := r3.Add(ctx, s1)
+	r3.Add(ctx, s2)
 
 	type args struct {
 		r   ScheduleRepo
@@ -112,7 +117,7 @@ func TestListSchedules(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ListSchedules(tt.args.r, tt.args.uid)
+			got, err := ListSchedules(ctx, tt.args.r, tt.args.uid)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ListSchedules() got = %v, want %v", got, tt.want)
 			}
@@ -125,6 +130,8 @@ func TestListSchedules(t *testing.T) {
 }
 
 func TestAddSchedule(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	hourFreq, _ := schedule.NewHourFrequency([]int{0})
 	hourSchedule := schedule.New(hourFreq, user.ID{})
@@ -149,7 +156,7 @@ func TestAddSchedule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := AddSchedule(tt.args.r, tt.args.s, c)
+			got, err := AddSchedule(ctx, tt.args.r, tt.args.s, c)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("AddSchedule() got = %v, want %v", got, tt.want)
 			}
@@ -162,13 +169,15 @@ func TestAddSchedule(t *testing.T) {
 }
 
 func TestPauseSchedule(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	f, _ := schedule.NewHourFrequency([]int{0})
 	s1 := schedule.New(f, user.ID{})
 	s2 := schedule.New(f, user.ID{})
 	s2.Remove()
-	sID1, _ := r.Add(s1)
-	sID2, _ := r.Add(s2)
+	sID1, _ := r.Add(ctx, s1)
+	sID2, _ := r.Add(ctx, s2)
 	c := make(chan<- bool)
 
 	type args struct {
@@ -199,7 +208,7 @@ func TestPauseSchedule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := PauseSchedule(tt.args.r, tt.args.id, tt.args.uid, c)
+			err := PauseSchedule(ctx, tt.args.r, tt.args.id, tt.args.uid, c)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("PauseSchedule() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -209,14 +218,16 @@ func TestPauseSchedule(t *testing.T) {
 }
 
 func TestUnpauseSchedule(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	f, _ := schedule.NewHourFrequency([]int{0})
 	s1 := schedule.New(f, user.ID{})
 	s2 := schedule.New(f, user.ID{})
 	s1.Pause()
 	s2.Remove()
-	sID1, _ := r.Add(s1)
-	sID2, _ := r.Add(s2)
+	sID1, _ := r.Add(ctx, s1)
+	sID2, _ := r.Add(ctx, s2)
 	c := make(chan<- bool)
 
 	type args struct {
@@ -247,7 +258,7 @@ func TestUnpauseSchedule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := UnpauseSchedule(tt.args.r, tt.args.id, tt.args.uid, c)
+			err := UnpauseSchedule(ctx, tt.args.r, tt.args.id, tt.args.uid, c)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("UnpauseSchedule() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -257,24 +268,26 @@ func TestUnpauseSchedule(t *testing.T) {
 }
 
 func TestRemoveSchedule(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	f, err := schedule.NewHourFrequency([]int{0})
 	if err != nil {
 		t.Fatal(err)
 	}
 	s := schedule.New(f, user.ID{})
-	sID, err := r.Add(s)
+	sID, err := r.Add(ctx, s)
 	if err != nil {
 		t.Fatal(err)
 	}
 	c := make(chan<- bool)
 
-	err = RemoveSchedule(r, sID, user.ID{}, c)
+	err = RemoveSchedule(ctx, r, sID, user.ID{}, c)
 	if err != nil {
 		t.Errorf("RemoveSchedule() error = %v, wantErr %v", err, nil)
 	}
 
-	retrievedS, ucErr := r.Get(sID)
+	retrievedS, ucErr := r.Get(ctx, sID)
 	if ucErr != nil {
 		t.Errorf("Get(%v) should have retrieved the removed schedule", sID)
 	}
@@ -284,10 +297,12 @@ func TestRemoveSchedule(t *testing.T) {
 }
 
 func TestAddRecurringTask(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	hourFreq, _ := schedule.NewHourFrequency([]int{0})
 	hourSched := schedule.New(hourFreq, user.ID{})
-	hourSchedID, _ := r.Add(hourSched)
+	hourSchedID, _ := r.Add(ctx, hourSched)
 	rt1 := schedule.NewRecurringTask("task 1", "")
 	rt2 := schedule.NewRecurringTask("task 2", "")
 
@@ -326,7 +341,7 @@ func TestAddRecurringTask(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := AddRecurringTask(tt.args.r, tt.args.id, tt.args.uid, tt.args.rt)
+			err := AddRecurringTask(ctx, tt.args.r, tt.args.id, tt.args.uid, tt.args.rt)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("AddRecurringTask() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -336,6 +351,8 @@ func TestAddRecurringTask(t *testing.T) {
 }
 
 func TestRemoveRecurringTask(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewScheduleRepo()
 	hourFreq, _ := schedule.NewHourFrequency([]int{0})
 	hourSched := schedule.New(hourFreq, user.ID{})
@@ -345,7 +362,7 @@ func TestRemoveRecurringTask(t *testing.T) {
 	rt3unknown := schedule.NewRecurringTask("unknown task", "")
 	hourSched.AddTask(rt1)
 	hourSched.AddTask(rt2)
-	hourSchedID, _ := r.Add(hourSched)
+	hourSchedID, _ := r.Add(ctx, hourSched)
 
 	type args struct {
 		r   ScheduleRepo
@@ -386,7 +403,7 @@ func TestRemoveRecurringTask(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := RemoveRecurringTask(tt.args.r, tt.args.id, tt.args.uid, tt.args.t)
+			err := RemoveRecurringTask(ctx, tt.args.r, tt.args.id, tt.args.uid, tt.args.t)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("RemoveRecurringTask() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1,57 +1,113 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 )
 
+// ScheduleCheck is the result of checking a single schedule
+type ScheduleCheck struct {
+	ScheduleID   ScheduleID
+	TasksCreated int
+	Next         time.Time
+	Err          error
+	// RecordErr is an error recording the schedule's runs or its generated tasks' activity, it doesn't fail the check, since its tasks have already been created
+	RecordErr error
+}
+
 // CheckSchedules checks all schedules, determines all recurrences that have occurred, and when the next run is needed
-func CheckSchedules(taskRepo TaskRepo, scheduleRepo ScheduleRepo) (time.Time, error) {
+// the result of each schedule checked is also returned, if checking a schedule fails the last result contains the error
+// a run is recorded for each of a schedule's occurrences, along with a run for the check that found them, so there's a history of what the scheduler did
+// checks without any occurrences or errors aren't recorded, so a schedule's history grows with the tasks it generates rather than how often it's checked
+func CheckSchedules(ctx context.Context, taskRepo TaskRepo, activityRepo ActivityRepo, scheduleRepo ScheduleRepo, runRepo ScheduleRunRepo) (time.Time, []ScheduleCheck, error) {
+	ctx, span := tracer.Start(ctx, "usecase.CheckSchedules")
+	defer span.End()
+
 	// Check all valid, unpaused schedules
-	schedules, err := scheduleRepo.GetAllScheduled()
+	schedules, err := scheduleRepo.GetAllScheduled(ctx)
 	if err != nil {
-		return time.Time{}, err
+		return time.Time{}, nil, err
 	}
 
 	now := clock.Now()
 	var next time.Time
+	checks := make([]ScheduleCheck, 0, len(schedules))
 	for id, sched := range schedules {
+		check := ScheduleCheck{ScheduleID: id}
+		var runs []*schedule.Run
+		record := func(err error) {
+			if err == nil && len(runs) == 0 {
+				return
+			}
+			runs = append(runs, schedule.NewCheckRun(now, check.TasksCreated, err))
+			for _, run := range runs {
+				if _, ucerr := runRepo.Add(ctx, id, run); ucerr != nil && check.RecordErr == nil {
+					check.RecordErr = ucerr.Prefix("error recording %v run for schedule id %v", run.Type(), id)
+				}
+			}
+		}
+		fail := func(err error) (time.Time, []ScheduleCheck, error) {
+			check.Err = err
+			record(err)
+			return time.Time{}, append(checks, check), err
+		}
+
 		// If the schedule has previously been checked, create tasks for any recurrences
 		if !sched.LastChecked().IsZero() {
 
 			times, err := sched.Times(sched.LastChecked(), now)
 			if err != nil {
-				return time.Time{}, fmt.Errorf("error retrieving times from schedule id %v: %v", id, err)
+				return fail(fmt.Errorf("error retrieving times from schedule id %v: %v", id, err))
 			}
 
 			// Create tasks for all scheduled recurrences
-			for _, rt := range sched.Tasks() {
-				for i := 0; i < len(times); i++ {
-					t := task.New(rt.Name(), rt.Description(), sched.CreatedBy())
-					_, err := taskRepo.Add(t)
+			for _, occurrence := range times {
+				created := 0
+				for i := range sched.Tasks() {
+					t, err := sched.NewTask(i, occurrence)
 					if err != nil {
-						return time.Time{}, fmt.Errorf("error adding task to repo: %v", err)
+						err = fmt.Errorf("error creating task from schedule id %v: %v", id, err)
+						runs = append(runs, schedule.NewOccurrenceRun(occurrence, created, err))
+						return fail(err)
+					}
+					t.SetSchedule(int64(id))
+					tid, ucerr := taskRepo.Add(ctx, t)
+					if ucerr != nil {
+						err = fmt.Errorf("error adding task to repo: %v", ucerr)
+						runs = append(runs, schedule.NewOccurrenceRun(occurrence, created, err))
+						return fail(err)
+					}
+					created++
+					check.TasksCreated++
+					if ucerr := RecordTaskActivity(ctx, activityRepo, tid, t.CreatedBy(), task.ActivityCreated); ucerr != nil && check.RecordErr == nil {
+						check.RecordErr = ucerr.Prefix("error recording activity for task id %v generated by schedule id %v", tid, id)
 					}
 				}
+				runs = append(runs, schedule.NewOccurrenceRun(occurrence, created, nil))
 			}
 		}
 
 		// Set the last checked time for the schedule
 		sched.Check(now)
-		scheduleRepo.Update(id, sched)
+		scheduleRepo.Update(ctx, id, sched)
 
 		// Get the next runtime and store the nearest upcoming time as the next time to run scheduler
 		n, err := sched.NextTime(now)
 		if err != nil {
-			return time.Time{}, fmt.Errorf("error getting next schedule time for id %v: %v", id, err)
+			return fail(fmt.Errorf("error getting next schedule time for id %v: %v", id, err))
 		}
+		check.Next = n
+		record(nil)
+		checks = append(checks, check)
 		if next.IsZero() || n.Before(next) {
 			next = n
 		}
 	}
 
-	return next, nil
+	return next, checks, nil
 }
@@ -1,15 +1,22 @@
 package usecase_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
 	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 func TestCheckSchedules(t *testing.T) {
+	ctx := context.Background()
+
 	type args struct {
 		taskRepo     TaskRepo
 		scheduleRepo ScheduleRepo
@@ -32,7 +39,7 @@ func TestCheckSchedules(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := CheckSchedules(tt.args.taskRepo, tt.args.scheduleRepo)
+			got, _, err := CheckSchedules(ctx, tt.args.taskRepo, data.NewActivityRepo(), tt.args.scheduleRepo, data.NewScheduleRunRepo())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CheckSchedules() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -43,3 +50,238 @@ func TestCheckSchedules(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckSchedules_rotation(t *testing.T) {
+	ctx := context.Background()
+
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(time.Date(2000, 1, 1, 15, 30, 0, 0, time.UTC)))
+
+	taskRepo := data.NewTaskRepo()
+	scheduleRepo := data.NewScheduleRepo()
+	u1, u2 := user.NewID(), user.NewID()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	rt, _ := schedule.NewRecurringTask("on-call", "").WithRotation([]user.ID{u1, u2}, schedule.RotationRoundRobin)
+	s := schedule.NewRaw(f, false, time.Date(2000, 1, 1, 12, 30, 0, 0, time.UTC), []schedule.RecurringTask{rt}, time.Time{}, u1)
+	scheduleRepo.Add(ctx, s)
+
+	_, checks, err := CheckSchedules(ctx, taskRepo, data.NewActivityRepo(), scheduleRepo, data.NewScheduleRunRepo())
+	if err != nil {
+		t.Fatalf("CheckSchedules() error = %v", err)
+	}
+	if len(checks) != 1 || checks[0].TasksCreated != 3 {
+		t.Errorf("CheckSchedules() checks = %+v, want 1 schedule with 3 tasks created", checks)
+	}
+
+	// Occurrences at 13:00, 14:00 and 15:00 are assigned in turn
+	want := []user.ID{u1, u2, u1}
+	for i, uid := range want {
+		got, ucerr := taskRepo.Get(ctx, TaskID(i+1))
+		if ucerr != nil {
+			t.Fatalf("task %d not created: %v", i+1, ucerr)
+		}
+		if !got.Assignee().Equals(uid) {
+			t.Errorf("CheckSchedules() task %d assignee = %v, want %v", i+1, got.Assignee(), uid)
+		}
+	}
+	if next, _ := s.Tasks()[0].NextAssignee(); !next.Equals(u2) {
+		t.Errorf("CheckSchedules() next assignee = %v, want %v", next, u2)
+	}
+}
+
+func TestCheckSchedules_action(t *testing.T) {
+	ctx := context.Background()
+
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(time.Date(2000, 1, 1, 14, 30, 0, 0, time.UTC)))
+
+	taskRepo := data.NewTaskRepo()
+	actionRepo := data.NewActionRepo()
+	taskRepo.SetActionRepo(actionRepo)
+	scheduleRepo := data.NewScheduleRepo()
+	uid := user.NewID()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	a, _ := schedule.NewHTTPAction("POST", "https://example.com/warm", nil, `{"at":"{{.Occurrence}}"}`, 0, 0, true)
+	rt := schedule.NewRecurringTask("warm cache", "").WithAction(a)
+	scheduleRepo.Add(ctx, schedule.NewRaw(f, false, time.Date(2000, 1, 1, 12, 30, 0, 0, time.UTC), []schedule.RecurringTask{rt}, time.Time{}, uid))
+
+	if _, _, err := CheckSchedules(ctx, taskRepo, data.NewActivityRepo(), scheduleRepo, data.NewScheduleRunRepo()); err != nil {
+		t.Fatalf("CheckSchedules() error = %v", err)
+	}
+
+	// Occurrences at 13:00 and 14:00 each queue a call for their task
+	for i, want := range []string{`{"at":"2000-01-01T13:00:00Z"}`, `{"at":"2000-01-01T14:00:00Z"}`} {
+		c, ucerr := actionRepo.GetForTask(ctx, TaskID(i+1))
+		if ucerr != nil {
+			t.Fatalf("CheckSchedules() didn't queue a call for task %d: %v", i+1, ucerr)
+		}
+		if c.Request().Body() != want {
+			t.Errorf("CheckSchedules() task %d call body = %v, want %v", i+1, c.Request().Body(), want)
+		}
+	}
+}
+
+// failingTaskRepo fails to add tasks, to test how failed checks are handled
+type failingTaskRepo struct {
+	TaskRepo
+}
+
+func (r *failingTaskRepo) Add(ctx context.Context, t *task.Task) (TaskID, Error) {
+	return 0, NewError(ErrUnknown, "DB unavailable")
+}
+
+// failingActivityRepo fails to add activity, to test that it doesn't fail the check
+type failingActivityRepo struct {
+	ActivityRepo
+}
+
+func (r *failingActivityRepo) Add(ctx context.Context, id TaskID, a *task.Activity) (ActivityID, Error) {
+	return 0, NewError(ErrUnknown, "DB unavailable")
+}
+
+func TestCheckSchedules_runs(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2000, 1, 1, 14, 30, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(now))
+
+	f, _ := schedule.NewHourFrequency([]int{0})
+	lastChecked := time.Date(2000, 1, 1, 12, 30, 0, 0, time.UTC)
+	tasks := []schedule.RecurringTask{schedule.NewRecurringTask("t1", ""), schedule.NewRecurringTask("t2", "")}
+	type wantRun struct {
+		runType      schedule.RunType
+		scheduled    time.Time
+		tasksCreated int
+		failed       bool
+	}
+	tests := []struct {
+		name        string
+		taskRepo    TaskRepo
+		lastChecked time.Time
+		wantErr     bool
+		want        []wantRun
+	}{
+		{
+			name:     "should record each occurrence and the check, newest first",
+			taskRepo: data.NewTaskRepo(),
+			want: []wantRun{
+				{runType: schedule.RunTypeCheck, scheduled: now, tasksCreated: 4},
+				{runType: schedule.RunTypeOccurrence, scheduled: time.Date(2000, 1, 1, 14, 0, 0, 0, time.UTC), tasksCreated: 2},
+				{runType: schedule.RunTypeOccurrence, scheduled: time.Date(2000, 1, 1, 13, 0, 0, 0, time.UTC), tasksCreated: 2},
+			},
+		},
+		{
+			name:     "should record the failed occurrence and check",
+			taskRepo: &failingTaskRepo{data.NewTaskRepo()},
+			wantErr:  true,
+			want: []wantRun{
+				{runType: schedule.RunTypeCheck, scheduled: now, failed: true},
+				{runType: schedule.RunTypeOccurrence, scheduled: time.Date(2000, 1, 1, 13, 0, 0, 0, time.UTC), failed: true},
+			},
+		},
+		{
+			name:        "should not record a check without any occurrences",
+			taskRepo:    data.NewTaskRepo(),
+			lastChecked: time.Date(2000, 1, 1, 14, 15, 0, 0, time.UTC),
+			want:        []wantRun{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduleRepo := data.NewScheduleRepo()
+			runRepo := data.NewScheduleRunRepo()
+			checked := lastChecked
+			if !tt.lastChecked.IsZero() {
+				checked = tt.lastChecked
+			}
+			id, _ := scheduleRepo.Add(ctx, schedule.NewRaw(f, false, checked, tasks, time.Time{}, user.NewID()))
+
+			_, checks, err := CheckSchedules(ctx, tt.taskRepo, data.NewActivityRepo(), scheduleRepo, runRepo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckSchedules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(checks) != 1 || checks[0].RecordErr != nil {
+				t.Fatalf("CheckSchedules() checks = %+v, want 1 check with its runs recorded", checks)
+			}
+
+			got, total, ucerr := runRepo.GetForSchedule(ctx, id, Page{Limit: 10})
+			if ucerr != nil {
+				t.Fatalf("ScheduleRunRepo.GetForSchedule() error = %v", ucerr)
+			}
+			if total != len(tt.want) || len(got) != len(tt.want) {
+				t.Fatalf("CheckSchedules() recorded %v runs, want %v", total, len(tt.want))
+			}
+			for i, w := range tt.want {
+				run := got[i].Run
+				if run.Type() != w.runType || !run.ScheduledTime().Equal(w.scheduled) || run.TasksCreated() != w.tasksCreated || run.Failed() != w.failed || !run.ProcessedTime().Equal(now) {
+					t.Errorf("CheckSchedules() run %d = %v %v %v tasks, error %q, want %+v", i, run.Type(), run.ScheduledTime(), run.TasksCreated(), run.Err(), w)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckSchedules_activity(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2000, 1, 1, 14, 30, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(now))
+
+	taskRepo := data.NewTaskRepo()
+	activityRepo := data.NewActivityRepo()
+	scheduleRepo := data.NewScheduleRepo()
+	uid := user.NewID()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	scheduleRepo.Add(ctx, schedule.NewRaw(f, false, time.Date(2000, 1, 1, 13, 30, 0, 0, time.UTC), []schedule.RecurringTask{schedule.NewRecurringTask("t1", "")}, time.Time{}, uid))
+
+	if _, _, err := CheckSchedules(ctx, taskRepo, activityRepo, scheduleRepo, data.NewScheduleRunRepo()); err != nil {
+		t.Fatalf("CheckSchedules() error = %v", err)
+	}
+	tasks, _ := taskRepo.GetAll(ctx)
+	if len(tasks) != 1 {
+		t.Fatalf("CheckSchedules() created %v tasks, want 1", len(tasks))
+	}
+	for id := range tasks {
+		got, _ := activityRepo.GetAllForTask(ctx, id)
+		if len(got) != 1 || got[0].Activity.Type() != task.ActivityCreated || got[0].Activity.CreatedBy() != uid {
+			t.Errorf("generated task activity = %+v, want a single created entry by the schedule's user", got)
+		}
+	}
+}
+
+func TestCheckSchedules_activityError(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2000, 1, 1, 14, 30, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	defer clock.Set(prevClock)
+	clock.Set(clock.NewStaticMock(now))
+
+	taskRepo := data.NewTaskRepo()
+	scheduleRepo := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	id, _ := scheduleRepo.Add(ctx, schedule.NewRaw(f, false, time.Date(2000, 1, 1, 13, 30, 0, 0, time.UTC), []schedule.RecurringTask{schedule.NewRecurringTask("t1", "")}, time.Time{}, user.NewID()))
+	activityRepo := &failingActivityRepo{data.NewActivityRepo()}
+
+	_, checks, err := CheckSchedules(ctx, taskRepo, activityRepo, scheduleRepo, data.NewScheduleRunRepo())
+	if err != nil {
+		t.Fatalf("CheckSchedules() error = %v, want activity errors not to fail the check", err)
+	}
+	if len(checks) != 1 || checks[0].RecordErr == nil || checks[0].TasksCreated != 1 {
+		t.Errorf("CheckSchedules() checks = %+v, want 1 task created with the activity error recorded", checks)
+	}
+	if s, _ := scheduleRepo.Get(ctx, id); !s.LastChecked().Equal(now) {
+		t.Errorf("CheckSchedules() schedule last checked = %v, want %v", s.LastChecked(), now)
+	}
+
+	CheckSchedules(ctx, taskRepo, activityRepo, scheduleRepo, data.NewScheduleRunRepo())
+	if tasks, _ := taskRepo.GetAll(ctx); len(tasks) != 1 {
+		t.Errorf("CheckSchedules() again created %v tasks in total, want the occurrence's task only created once", len(tasks))
+	}
+}
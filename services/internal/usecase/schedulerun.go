@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// ScheduleRunID is the persistent ID of a schedule run
+type ScheduleRunID int64
+
+// ScheduleRunData contains application-level schedule run info
+type ScheduleRunData struct {
+	ScheduleRunID ScheduleRunID
+	ScheduleID    ScheduleID
+	Run           *schedule.Run
+}
+
+// DefaultPageLimit is the number of records returned in a page if no limit is given
+const DefaultPageLimit = 50
+
+// MaxPageLimit is the most records that can be returned in a single page
+const MaxPageLimit = 500
+
+// Page selects a range of records from a list, skipping the first Offset records and returning up to Limit of the rest
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// NewPage validates a page range, a zero limit is replaced with the default
+func NewPage(limit int, offset int) (Page, Error) {
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return Page{}, NewError(ErrInvalidData, "page limit must be between 1 and %d", MaxPageLimit)
+	}
+	if offset < 0 {
+		return Page{}, NewError(ErrInvalidData, "page offset can't be negative")
+	}
+	return Page{Limit: limit, Offset: offset}, nil
+}
+
+// ScheduleRunRepo defines the schedule run repository interface required by use cases
+// Runs are append-only, they cannot be changed or removed once added
+type ScheduleRunRepo interface {
+	Add(context.Context, ScheduleID, *schedule.Run) (ScheduleRunID, Error)
+	GetForSchedule(context.Context, ScheduleID, Page) (runs []ScheduleRunData, total int, ucerr Error)
+}
+
+// ListScheduleRuns returns a page of a schedule's run history, newest first, along with the total number of runs
+// The history of removed schedules is still available
+func ListScheduleRuns(ctx context.Context, scheduleRepo ScheduleRepo, runRepo ScheduleRunRepo, id ScheduleID, uid user.ID, p Page) ([]ScheduleRunData, int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListScheduleRuns")
+	defer span.End()
+
+	if _, ucerr := scheduleRepo.GetForUser(ctx, id, uid); ucerr != nil {
+		return nil, 0, ucerr.Prefix("error retrieving schedule id %d", id)
+	}
+
+	rs, total, ucerr := runRepo.GetForSchedule(ctx, id, p)
+	if ucerr != nil {
+		return nil, 0, ucerr.Prefix("error retrieving runs for schedule id %d", id)
+	}
+	return rs, total, nil
+}
@@ -0,0 +1,108 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestNewPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		offset  int
+		want    Page
+		wantErr ErrorCode
+	}{
+		{name: "zero limit should use the default", want: Page{Limit: DefaultPageLimit}, wantErr: ErrNone},
+		{name: "valid range should be returned", limit: 10, offset: 20, want: Page{Limit: 10, Offset: 20}, wantErr: ErrNone},
+		{name: "negative limit should return an ErrInvalidData", limit: -1, wantErr: ErrInvalidData},
+		{name: "limit over the maximum should return an ErrInvalidData", limit: MaxPageLimit + 1, wantErr: ErrInvalidData},
+		{name: "negative offset should return an ErrInvalidData", offset: -1, wantErr: ErrInvalidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPage(tt.limit, tt.offset)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("NewPage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NewPage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListScheduleRuns(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	scheduleRepo := data.NewScheduleRepo()
+	runRepo := data.NewScheduleRunRepo()
+	uid1 := user.NewID()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	id, _ := scheduleRepo.Add(ctx, schedule.New(f, uid1))
+	runRepo.Add(ctx, id, schedule.NewCheckRun(now, 0, nil))
+	runRepo.Add(ctx, id, schedule.NewCheckRun(now.Add(time.Hour), 1, nil))
+	removed := schedule.New(f, uid1)
+	removed.Remove()
+	removedID, _ := scheduleRepo.Add(ctx, removed)
+	runRepo.Add(ctx, removedID, schedule.NewCheckRun(now, 0, nil))
+	uid2 := user.NewID()
+
+	type args struct {
+		id  ScheduleID
+		uid user.ID
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantTimes []time.Time
+		wantTotal int
+		wantErr   ErrorCode
+	}{
+		{
+			name:      "schedule runs should be listed newest first",
+			args:      args{id, uid1},
+			wantTimes: []time.Time{now.Add(time.Hour), now},
+			wantTotal: 2,
+			wantErr:   ErrNone,
+		},
+		{
+			name:      "removed schedule runs should be listed",
+			args:      args{removedID, uid1},
+			wantTimes: []time.Time{now},
+			wantTotal: 1,
+			wantErr:   ErrNone,
+		},
+		{
+			name:    "listing runs of a schedule created by another user should return an ErrRecordNotFound",
+			args:    args{id, uid2},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := ListScheduleRuns(ctx, scheduleRepo, runRepo, tt.args.id, tt.args.uid, Page{Limit: 10})
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ListScheduleRuns() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.wantTimes) || total != tt.wantTotal {
+				t.Errorf("ListScheduleRuns() = %v, %v, want times %v, total %v", got, total, tt.wantTimes, tt.wantTotal)
+				return
+			}
+			for i, rd := range got {
+				if !rd.Run.ScheduledTime().Equal(tt.wantTimes[i]) {
+					t.Errorf("ListScheduleRuns() run %d scheduled time = %v, want %v", i, rd.Run.ScheduledTime(), tt.wantTimes[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// Highlight markers wrapped around matching terms in search result highlights
+const (
+	HighlightStart = "<b>"
+	HighlightStop  = "</b>"
+)
+
+// Match markers repos wrap around matching terms, replaced by the highlight markers once the rest of the text is HTML escaped
+// they're private use characters, so they aren't expected in task text
+const (
+	MatchStart = "\uE000"
+	MatchStop  = "\uE001"
+)
+
+// MaxSearchResults is the maximum number of matches returned by a single search
+const MaxSearchResults = 100
+
+// SearchResultType identifies which kind of entity a search result refers to
+type SearchResultType uint8
+
+// Search result types
+const (
+	SearchResultTask SearchResultType = iota + 1
+	SearchResultRecurringTask
+)
+
+func (t SearchResultType) String() string {
+	switch t {
+	case SearchResultTask:
+		return "task"
+	case SearchResultRecurringTask:
+		return "recurringTask"
+	}
+	return "[Invalid search result type]"
+}
+
+// SearchResult is a single ranked search match
+type SearchResult struct {
+	Type                 SearchResultType
+	TaskID               TaskID
+	ScheduleID           ScheduleID
+	Name                 string
+	Description          string
+	NameHighlight        string
+	DescriptionHighlight string
+	Rank                 float64
+}
+
+// SearchTasks searches the names and descriptions of a user's valid (uncleared) tasks
+func SearchTasks(ctx context.Context, r TaskRepo, query string, uid user.ID) ([]SearchResult, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.SearchTasks")
+	defer span.End()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []SearchResult{}, nil
+	}
+	rs, err := r.Search(ctx, query, uid)
+	if err != nil {
+		return nil, err.Prefix("error searching tasks")
+	}
+	return rankResults(highlightResults(rs)), nil
+}
+
+// SearchRecurringTasks searches the names and descriptions of recurring tasks in a user's valid (unremoved) schedules
+func SearchRecurringTasks(ctx context.Context, r ScheduleRepo, query string, uid user.ID) ([]SearchResult, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.SearchRecurringTasks")
+	defer span.End()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []SearchResult{}, nil
+	}
+	rs, err := r.SearchRecurringTasks(ctx, query, uid)
+	if err != nil {
+		return nil, err.Prefix("error searching recurring tasks")
+	}
+	return rankResults(highlightResults(rs)), nil
+}
+
+// Search searches both tasks and recurring tasks, returning all matches ordered by rank
+func Search(ctx context.Context, taskRepo TaskRepo, scheduleRepo ScheduleRepo, query string, uid user.ID) ([]SearchResult, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.Search")
+	defer span.End()
+
+	ts, err := SearchTasks(ctx, taskRepo, query, uid)
+	if err != nil {
+		return nil, err
+	}
+	rts, err := SearchRecurringTasks(ctx, scheduleRepo, query, uid)
+	if err != nil {
+		return nil, err
+	}
+	return rankResults(append(ts, rts...)), nil
+}
+
+// highlightResults converts the match markers in results' highlights into HTML highlights
+func highlightResults(rs []SearchResult) []SearchResult {
+	for i := range rs {
+		rs[i].NameHighlight = highlight(rs[i].NameHighlight)
+		rs[i].DescriptionHighlight = highlight(rs[i].DescriptionHighlight)
+	}
+	return rs
+}
+
+// highlight HTML escapes text and replaces its match markers with highlight markers
+// unbalanced markers are dropped, so the highlight markers are always well-formed
+func highlight(s string) string {
+	var b strings.Builder
+	open := false
+	for s != "" {
+		i := strings.IndexAny(s, MatchStart+MatchStop)
+		if i < 0 {
+			b.WriteString(html.EscapeString(s))
+			break
+		}
+		b.WriteString(html.EscapeString(s[:i]))
+		switch {
+		case strings.HasPrefix(s[i:], MatchStart) && !open:
+			b.WriteString(HighlightStart)
+			open = true
+		case strings.HasPrefix(s[i:], MatchStop) && open:
+			b.WriteString(HighlightStop)
+			open = false
+		}
+		s = s[i+len(MatchStart):]
+	}
+	if open {
+		b.WriteString(HighlightStop)
+	}
+	return b.String()
+}
+
+// rankResults sorts results by descending rank and truncates them to MaxSearchResults
+func rankResults(rs []SearchResult) []SearchResult {
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].Rank != rs[j].Rank {
+			return rs[i].Rank > rs[j].Rank
+		}
+		if rs[i].Type != rs[j].Type {
+			return rs[i].Type < rs[j].Type
+		}
+		if rs[i].ScheduleID != rs[j].ScheduleID {
+			return rs[i].ScheduleID < rs[j].ScheduleID
+		}
+		return rs[i].TaskID < rs[j].TaskID
+	})
+	if len(rs) > MaxSearchResults {
+		rs = rs[:MaxSearchResults]
+	}
+	return rs
+}
@@ -0,0 +1,168 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestSearchTasks(t *testing.T) {
+	ctx := context.Background()
+
+	uid1 := user.New("test user 1 for SearchTasks").ID()
+	uid2 := user.New("test user 2 for SearchTasks").ID()
+
+	r := data.NewTaskRepo()
+	t1ID, _ := r.Add(ctx, task.New("Renew certificates", "rotate the TLS certs", uid1))
+	t2ID, _ := r.Add(ctx, task.New("Water plants", "don't forget the certificate of the ficus", uid1))
+	cleared := task.New("Cleared certificate task", "", uid1)
+	cleared.Clear()
+	r.Add(ctx, cleared)
+	r.Add(ctx, task.New("Other user's certificate", "", uid2))
+	t3ID, _ := r.Add(ctx, task.New("<script>alert(1)</script> & <b>bold</b>", "", uid1))
+
+	type args struct {
+		r     TaskRepo
+		query string
+		uid   user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []SearchResult
+		wantErr ErrorCode
+	}{
+		{
+			name: "empty query should return no results",
+			args: args{r, "   ", uid1},
+			want: []SearchResult{},
+		},
+		{
+			name: "query with no matches should return no results",
+			args: args{r, "nothing matches", uid1},
+			want: []SearchResult{},
+		},
+		{
+			name: "should rank name matches above description matches, excluding cleared and other users' tasks",
+			args: args{r, "Certificate", uid1},
+			want: []SearchResult{
+				{
+					Type:                 SearchResultTask,
+					TaskID:               t1ID,
+					Name:                 "Renew certificates",
+					Description:          "rotate the TLS certs",
+					NameHighlight:        "Renew <b>certificate</b>s",
+					DescriptionHighlight: "rotate the TLS certs",
+					Rank:                 1,
+				},
+				{
+					Type:                 SearchResultTask,
+					TaskID:               t2ID,
+					Name:                 "Water plants",
+					Description:          "don't forget the certificate of the ficus",
+					NameHighlight:        "Water plants",
+					DescriptionHighlight: "don&#39;t forget the <b>certificate</b> of the ficus",
+					Rank:                 0.5,
+				},
+			},
+		},
+		{
+			name: "should escape HTML in highlights",
+			args: args{r, "script", uid1},
+			want: []SearchResult{
+				{
+					Type:          SearchResultTask,
+					TaskID:        t3ID,
+					Name:          "<script>alert(1)</script> & <b>bold</b>",
+					NameHighlight: "&lt;<b>script</b>&gt;alert(1)&lt;/<b>script</b>&gt; &amp; &lt;b&gt;bold&lt;/b&gt;",
+					Rank:          1,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SearchTasks(ctx, tt.args.r, tt.args.query, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("SearchTasks() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+
+	uid := user.New("test user for Search").ID()
+
+	taskRepo := data.NewTaskRepo()
+	tID, _ := taskRepo.Add(ctx, task.New("deploy notes", "", uid))
+
+	scheduleRepo := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, uid)
+	s.AddTask(schedule.NewRecurringTask("Weekly deploy", "deploy the release branch"))
+	sID, _ := scheduleRepo.Add(ctx, s)
+	removed := schedule.New(f, uid)
+	removed.AddTask(schedule.NewRecurringTask("deploy from removed schedule", ""))
+	removed.Remove()
+	scheduleRepo.Add(ctx, removed)
+
+	type args struct {
+		taskRepo     TaskRepo
+		scheduleRepo ScheduleRepo
+		query        string
+		uid          user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []SearchResult
+		wantErr ErrorCode
+	}{
+		{
+			name: "should merge task and recurring task results by rank",
+			args: args{taskRepo, scheduleRepo, "deploy", uid},
+			want: []SearchResult{
+				{
+					Type:                 SearchResultRecurringTask,
+					ScheduleID:           sID,
+					Name:                 "Weekly deploy",
+					Description:          "deploy the release branch",
+					NameHighlight:        "Weekly <b>deploy</b>",
+					DescriptionHighlight: "<b>deploy</b> the release branch",
+					Rank:                 1.5,
+				},
+				{
+					Type:          SearchResultTask,
+					TaskID:        tID,
+					Name:          "deploy notes",
+					NameHighlight: "<b>deploy</b> notes",
+					Rank:          1,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Search(ctx, tt.args.taskRepo, tt.args.scheduleRepo, tt.args.query, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
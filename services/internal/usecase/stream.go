@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+)
+
+// StreamEvent is a published event along with the task or schedule that emitted it, as of when it was published, and the users who can see it
+type StreamEvent struct {
+	EventData
+	Task     *task.Task
+	Schedule *schedule.Schedule
+	Audience []user.ID
+}
+
+// IsTaskEvent returns whether the event was emitted by a task, rather than a schedule
+func (se StreamEvent) IsTaskEvent() bool {
+	return se.ScheduleID == 0
+}
+
+// VisibleTo returns whether a user can see the task or schedule that emitted the event
+func (se StreamEvent) VisibleTo(uid user.ID) bool {
+	for _, a := range se.Audience {
+		if a.Equals(uid) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewStreamEvent retrieves the task or schedule that emitted a published event, and the users who can see it
+// a task or schedule in a workspace can be seen by the workspace's members, otherwise only by the user who created it
+func NewStreamEvent(ctx context.Context, taskRepo TaskRepo, scheduleRepo ScheduleRepo, workspaceRepo WorkspaceRepo, ed EventData) (StreamEvent, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.NewStreamEvent")
+	defer span.End()
+
+	se := StreamEvent{EventData: ed}
+	var owner user.ID
+	var wsID workspace.ID
+	if ed.ScheduleID != 0 {
+		s, ucerr := scheduleRepo.Get(ctx, ed.ScheduleID)
+		if ucerr != nil {
+			return se, ucerr.Prefix("error retrieving schedule id %v", ed.ScheduleID)
+		}
+		se.Schedule, owner, wsID = s, s.CreatedBy(), s.Workspace()
+	} else {
+		t, ucerr := taskRepo.Get(ctx, ed.TaskID)
+		if ucerr != nil {
+			return se, ucerr.Prefix("error retrieving task id %v", ed.TaskID)
+		}
+		se.Task, owner, wsID = t, t.CreatedBy(), t.Workspace()
+	}
+
+	if wsID.IsEmpty() {
+		se.Audience = []user.ID{owner}
+		return se, nil
+	}
+	ws, ucerr := workspaceRepo.Get(ctx, wsID)
+	if ucerr != nil {
+		return se, ucerr.Prefix("error retrieving workspace id %v", wsID)
+	}
+	for _, m := range ws.Members() {
+		se.Audience = append(se.Audience, m.UserID())
+	}
+	return se, nil
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// TagData contains a tag and the number of tasks and recurring tasks it is used by
+type TagData struct {
+	Tag                task.Tag
+	TaskCount          int
+	RecurringTaskCount int
+}
+
+// ListTags returns all tags used by a user's valid tasks, sorted by tag
+// If scheduleRepo is not nil, tags used by recurring tasks in the user's valid schedules are included
+func ListTags(ctx context.Context, taskRepo TaskRepo, scheduleRepo ScheduleRepo, uid user.ID) ([]TagData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListTags")
+	defer span.End()
+
+	ts, ucerr := ListTasks(ctx, taskRepo, uid)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error listing tags")
+	}
+
+	counts := map[task.Tag]*TagData{}
+	count := func(tag task.Tag) *TagData {
+		if _, ok := counts[tag]; !ok {
+			counts[tag] = &TagData{Tag: tag}
+		}
+		return counts[tag]
+	}
+	for _, t := range ts {
+		for _, tag := range t.Tags() {
+			count(tag).TaskCount++
+		}
+	}
+
+	if scheduleRepo != nil {
+		ss, ucerr := ListSchedules(ctx, scheduleRepo, uid)
+		if ucerr != nil {
+			return nil, ucerr.Prefix("error listing tags")
+		}
+		for _, s := range ss {
+			for _, rt := range s.Tasks() {
+				for _, tag := range rt.Tags() {
+					count(tag).RecurringTaskCount++
+				}
+			}
+		}
+	}
+
+	list := make([]TagData, 0, len(counts))
+	for _, td := range counts {
+		list = append(list, *td)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tag < list[j].Tag })
+	return list, nil
+}
+
+// TagTask adds a tag to an existing task, returns false if the task already had the tag
+func TagTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, tag task.Tag) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.TagTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	if !t.IsValid() {
+		return false, NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+
+	if !t.AddTag(tag) {
+		return false, nil
+	}
+
+	ucerr = r.Update(ctx, id, t)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error updating task id %d", id)
+	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityEdited); ucerr != nil {
+		return false, ucerr
+	}
+	return true, nil
+}
+
+// UntagTask removes a tag from an existing task, returns false if the task didn't have the tag
+func UntagTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, tag task.Tag) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.UntagTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	if !t.IsValid() {
+		return false, NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+
+	if !t.RemoveTag(tag) {
+		return false, nil
+	}
+
+	ucerr = r.Update(ctx, id, t)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error updating task id %d", id)
+	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityEdited); ucerr != nil {
+		return false, ucerr
+	}
+	return true, nil
+}
+
+// RenameTag renames a tag on all of a user's valid tasks, returning the number of tasks and recurring tasks changed
+// If scheduleRepo is not nil, the tag is also renamed on recurring tasks in the user's valid schedules
+func RenameTag(ctx context.Context, taskRepo TaskRepo, scheduleRepo ScheduleRepo, uid user.ID, from task.Tag, to task.Tag) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.RenameTag")
+	defer span.End()
+
+	return replaceTag(ctx, taskRepo, scheduleRepo, uid, from, to)
+}
+
+// DeleteTag removes a tag from all of a user's valid tasks, returning the number of tasks and recurring tasks changed
+// If scheduleRepo is not nil, the tag is also removed from recurring tasks in the user's valid schedules
+func DeleteTag(ctx context.Context, taskRepo TaskRepo, scheduleRepo ScheduleRepo, uid user.ID, tag task.Tag) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.DeleteTag")
+	defer span.End()
+
+	return replaceTag(ctx, taskRepo, scheduleRepo, uid, tag, "")
+}
+
+func replaceTag(ctx context.Context, taskRepo TaskRepo, scheduleRepo ScheduleRepo, uid user.ID, from task.Tag, to task.Tag) (int, Error) {
+	ts, ucerr := ListTasks(ctx, taskRepo, uid)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving tasks for tag '%v'", from)
+	}
+
+	count := 0
+	for id, t := range ts {
+		if !t.ReplaceTag(from, to) {
+			continue
+		}
+		if ucerr := taskRepo.Update(ctx, id, t); ucerr != nil {
+			return count, ucerr.Prefix("error updating task id %d", id)
+		}
+		count++
+	}
+
+	if scheduleRepo != nil {
+		ss, ucerr := ListSchedules(ctx, scheduleRepo, uid)
+		if ucerr != nil {
+			return count, ucerr.Prefix("error retrieving schedules for tag '%v'", from)
+		}
+		for id, s := range ss {
+			replaced := s.ReplaceTag(from, to)
+			if replaced == 0 {
+				continue
+			}
+			if ucerr := scheduleRepo.Update(ctx, id, s); ucerr != nil {
+				return count, ucerr.Prefix("error updating schedule id %d", id)
+			}
+			count += replaced
+		}
+	}
+
+	if count == 0 {
+		return 0, NewError(ErrRecordNotFound, "tag '%v' not found", from)
+	}
+	return count, nil
+}
+
+// FilterTasksByTags returns the tasks that have all of the given tags
+func FilterTasksByTags(ts map[TaskID]*task.Task, tags []task.Tag) map[TaskID]*task.Task {
+	list := make(map[TaskID]*task.Task)
+	for id, t := range ts {
+		if t.HasTags(tags...) {
+			list[id] = t
+		}
+	}
+	return list
+}
+
+// FilterSchedulesByTags returns the schedules that have at least one recurring task with all of the given tags
+func FilterSchedulesByTags(ss map[ScheduleID]*schedule.Schedule, tags []task.Tag) map[ScheduleID]*schedule.Schedule {
+	list := make(map[ScheduleID]*schedule.Schedule)
+	for id, s := range ss {
+		if s.HasTaskWithTags(tags...) {
+			list[id] = s
+		}
+	}
+	return list
+}
@@ -0,0 +1,248 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/schedule"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func newTaggedTask(name string, uid user.ID, tags ...task.Tag) *task.Task {
+	t := task.New(name, "", uid)
+	t.SetTags(tags)
+	return t
+}
+
+func TestListTags(t *testing.T) {
+	ctx := context.Background()
+
+	uid := user.New("test user ListTags").ID()
+	taskRepo := data.NewTaskRepo()
+	taskRepo.Add(ctx, newTaggedTask("task1", uid, "infra", "ops"))
+	taskRepo.Add(ctx, newTaggedTask("task2", uid, "infra"))
+	taskRepo.Add(ctx, newTaggedTask("other user task", user.ID{}, "billing"))
+	scheduleRepo := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, uid)
+	s.AddTask(schedule.NewRecurringTask("rtask1", "").WithTags([]task.Tag{"ops", "weekly"}))
+	scheduleRepo.Add(ctx, s)
+
+	type args struct {
+		taskRepo     TaskRepo
+		scheduleRepo ScheduleRepo
+		uid          user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []TagData
+		wantErr ErrorCode
+	}{
+		{
+			name: "should return sorted tags used by tasks and recurring tasks",
+			args: args{taskRepo, scheduleRepo, uid},
+			want: []TagData{
+				{Tag: "infra", TaskCount: 2},
+				{Tag: "ops", TaskCount: 1, RecurringTaskCount: 1},
+				{Tag: "weekly", RecurringTaskCount: 1},
+			},
+			wantErr: ErrNone,
+		},
+		{
+			name: "should only return tags used by tasks if schedule repo is nil",
+			args: args{taskRepo, nil, uid},
+			want: []TagData{
+				{Tag: "infra", TaskCount: 2},
+				{Tag: "ops", TaskCount: 1},
+			},
+			wantErr: ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListTags(ctx, tt.args.taskRepo, tt.args.scheduleRepo, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ListTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagTask(t *testing.T) {
+	ctx := context.Background()
+
+	uid := user.New("test user TagTask").ID()
+	taskRepo := data.NewTaskRepo()
+	id1, _ := taskRepo.Add(ctx, newTaggedTask("task1", uid, "infra"))
+
+	type args struct {
+		r   TaskRepo
+		id  TaskID
+		uid user.ID
+		tag task.Tag
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should add new tag",
+			args:    args{taskRepo, id1, uid, "ops"},
+			want:    true,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "should not add existing tag",
+			args:    args{taskRepo, id1, uid, "infra"},
+			want:    false,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "should return not found error for other user's task",
+			args:    args{taskRepo, id1, user.New("other user").ID(), "ops"},
+			want:    false,
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TagTask(ctx, tt.args.r, data.NewActivityRepo(), tt.args.id, tt.args.uid, tt.args.tag)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("TagTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TagTask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	ctx := context.Background()
+
+	uid := user.New("test user RenameTag").ID()
+	taskRepo := data.NewTaskRepo()
+	id1, _ := taskRepo.Add(ctx, newTaggedTask("task1", uid, "ops"))
+	taskRepo.Add(ctx, newTaggedTask("task2", uid, "billing"))
+	scheduleRepo := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, uid)
+	s.AddTask(schedule.NewRecurringTask("rtask1", "").WithTags([]task.Tag{"ops"}))
+	sid, _ := scheduleRepo.Add(ctx, s)
+
+	type args struct {
+		from task.Tag
+		to   task.Tag
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should rename tag on 1 task and 1 recurring task",
+			args:    args{"ops", "infra"},
+			want:    2,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "should return not found error for unused tag",
+			args:    args{"ops", "infra"},
+			want:    0,
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenameTag(ctx, taskRepo, scheduleRepo, uid, tt.args.from, tt.args.to)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("RenameTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenameTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t1, _ := taskRepo.Get(ctx, id1)
+	if !t1.HasTags("infra") || t1.HasTags("ops") {
+		t.Errorf("RenameTag() task tags = %v, want [infra]", t1.Tags())
+	}
+	s1, _ := scheduleRepo.Get(ctx, sid)
+	if !s1.HasTaskWithTags("infra") || s1.HasTaskWithTags("ops") {
+		t.Errorf("RenameTag() recurring task tags = %v, want [infra]", s1.Tasks()[0].Tags())
+	}
+}
+
+func TestDeleteTag(t *testing.T) {
+	ctx := context.Background()
+
+	uid := user.New("test user DeleteTag").ID()
+	taskRepo := data.NewTaskRepo()
+	taskRepo.Add(ctx, newTaggedTask("task1", uid, "ops", "infra"))
+	taskRepo.Add(ctx, newTaggedTask("task2", uid, "ops"))
+	scheduleRepo := data.NewScheduleRepo()
+	f, _ := schedule.NewHourFrequency([]int{0})
+	s := schedule.New(f, uid)
+	s.AddTask(schedule.NewRecurringTask("rtask1", "").WithTags([]task.Tag{"ops"}))
+	scheduleRepo.Add(ctx, s)
+
+	got, err := DeleteTag(ctx, taskRepo, nil, uid, "ops")
+	if err != nil {
+		t.Errorf("DeleteTag() error = %v, wantErr %v", err, ErrNone)
+	}
+	if got != 2 {
+		t.Errorf("DeleteTag() = %v, want %v", got, 2)
+	}
+	tds, _ := ListTags(ctx, taskRepo, scheduleRepo, uid)
+	want := []TagData{{Tag: "infra", TaskCount: 1}, {Tag: "ops", RecurringTaskCount: 1}}
+	if !reflect.DeepEqual(tds, want) {
+		t.Errorf("ListTags() after DeleteTag() = %v, want %v", tds, want)
+	}
+}
+
+func TestFilterTasksByTags(t *testing.T) {
+	task1 := newTaggedTask("task1", user.ID{}, "infra", "ops")
+	task2 := newTaggedTask("task2", user.ID{}, "infra")
+	ts := map[TaskID]*task.Task{1: task1, 2: task2, 3: task.New("task3", "", user.ID{})}
+
+	tests := []struct {
+		name string
+		tags []task.Tag
+		want map[TaskID]*task.Task
+	}{
+		{
+			name: "should return tasks with a single tag",
+			tags: []task.Tag{"infra"},
+			want: map[TaskID]*task.Task{1: task1, 2: task2},
+		},
+		{
+			name: "should return tasks with all tags",
+			tags: []task.Tag{"infra", "ops"},
+			want: map[TaskID]*task.Task{1: task1},
+		},
+		{
+			name: "should return no tasks for unused tag",
+			tags: []task.Tag{"billing"},
+			want: map[TaskID]*task.Task{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterTasksByTags(ts, tt.tags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterTasksByTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package usecase
 
 import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
@@ -15,17 +20,24 @@ type TaskData struct {
 }
 
 // TaskRepo defines the task repository interface required by use cases
+// GetAllDue returns open tasks, not completed or cleared, due after one time, up to and including another
 type TaskRepo interface {
-	GetForUser(TaskID, user.ID) (*task.Task, Error)
-	GetAll() (map[TaskID]*task.Task, Error)
-	GetAllForUser(user.ID) (map[TaskID]*task.Task, Error)
-	Add(*task.Task) (TaskID, Error)
-	Update(TaskID, *task.Task) Error
+	Get(context.Context, TaskID) (*task.Task, Error)
+	GetForUser(context.Context, TaskID, user.ID) (*task.Task, Error)
+	GetAll(context.Context) (map[TaskID]*task.Task, Error)
+	GetAllForUser(context.Context, user.ID) (map[TaskID]*task.Task, Error)
+	GetAllDue(ctx context.Context, after time.Time, before time.Time) (map[TaskID]*task.Task, Error)
+	Add(context.Context, *task.Task) (TaskID, Error)
+	Update(context.Context, TaskID, *task.Task) Error
+	Search(ctx context.Context, query string, uid user.ID) ([]SearchResult, Error)
 }
 
 // GetTask gets a single task
-func GetTask(r TaskRepo, id TaskID, uid user.ID) (*TaskData, Error) {
-	t, ucerr := r.GetForUser(id, uid)
+func GetTask(ctx context.Context, r TaskRepo, id TaskID, uid user.ID) (*TaskData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
 	if ucerr != nil {
 		return nil, ucerr.Prefix("error retrieving task id %d", id)
 	}
@@ -35,19 +47,53 @@ func GetTask(r TaskRepo, id TaskID, uid user.ID) (*TaskData, Error) {
 	return &TaskData{TaskID: id, Task: t}, nil
 }
 
-// AddTask creates and adds a new task to the list
-func AddTask(r TaskRepo, t *task.Task) (*TaskData, Error) {
-	id, err := r.Add(t)
+// TaskUpdate contains changes to a task's editable fields, nil fields are left unchanged
+type TaskUpdate struct {
+	Name         *string
+	Description  *string
+	DueTime      *time.Time
+	Priority     *task.Priority
+	Tags         *[]task.Tag
+	Checklist    *[]task.ChecklistItem
+	AutoComplete *bool
+}
+
+// TaskSort defines the order tasks are listed in
+type TaskSort uint8
+
+// Task sort orders
+const (
+	TaskSortNone TaskSort = iota
+	TaskSortDue
+	TaskSortPriority
+	TaskSortCreated
+)
+
+// AddTask creates and adds a new task to the list, and records its creation in the task's activity history
+func AddTask(ctx context.Context, r TaskRepo, a ActivityRepo, t *task.Task) (*TaskData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddTask")
+	defer span.End()
+
+	if err := t.Validate(); err != nil {
+		return nil, NewError(ErrInvalidData, "invalid task: %v", err)
+	}
+	id, err := r.Add(ctx, t)
 	if err != nil {
 		return nil, NewError(ErrUnknown, "error adding task: %v", err)
 	}
+	if ucerr := RecordTaskActivity(ctx, a, id, t.CreatedBy(), task.ActivityCreated); ucerr != nil {
+		return nil, ucerr
+	}
 	taskData := &TaskData{TaskID: id, Task: t}
 	return taskData, nil
 }
 
 // CompleteTask completes an existing task
-func CompleteTask(r TaskRepo, id TaskID, uid user.ID) (bool, Error) {
-	t, ucerr := r.GetForUser(id, uid)
+func CompleteTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.CompleteTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
 	if ucerr != nil {
 		return false, ucerr.Prefix("error retrieving task id %d", id)
 	}
@@ -64,16 +110,197 @@ func CompleteTask(r TaskRepo, id TaskID, uid user.ID) (bool, Error) {
 		return false, nil
 	}
 
-	ucerr = r.Update(id, t)
+	ucerr = r.Update(ctx, id, t)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error updating task id %d", id)
+	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityCompleted); ucerr != nil {
+		return false, ucerr
+	}
+	return true, nil
+}
+
+// UpdateTask changes any of the editable fields of an existing task
+func UpdateTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, tu TaskUpdate) (*TaskData, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.UpdateTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	if !t.IsValid() {
+		return nil, NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+
+	if tu.Name != nil {
+		if err := t.Rename(*tu.Name); err != nil {
+			return nil, NewError(ErrInvalidData, "error renaming task id %d: %v", id, err)
+		}
+	}
+	if tu.Description != nil {
+		if err := t.Redescribe(*tu.Description); err != nil {
+			return nil, NewError(ErrInvalidData, "error changing description of task id %d: %v", id, err)
+		}
+	}
+	if tu.DueTime != nil {
+		t.SetDueTime(*tu.DueTime)
+	}
+	if tu.Priority != nil {
+		if err := t.SetPriority(*tu.Priority); err != nil {
+			return nil, NewError(ErrInvalidData, "error changing priority of task id %d: %v", id, err)
+		}
+	}
+	if tu.Tags != nil {
+		t.SetTags(*tu.Tags)
+	}
+	if tu.Checklist != nil {
+		if err := t.SetChecklist(*tu.Checklist); err != nil {
+			return nil, NewError(ErrInvalidData, "error changing checklist of task id %d: %v", id, err)
+		}
+	}
+	if tu.AutoComplete != nil {
+		t.SetAutoComplete(*tu.AutoComplete)
+	}
+
+	ucerr = r.Update(ctx, id, t)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error updating task id %d", id)
+	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityEdited); ucerr != nil {
+		return nil, ucerr
+	}
+	return &TaskData{TaskID: id, Task: t}, nil
+}
+
+// UncompleteTask reopens a completed task
+func UncompleteTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.UncompleteTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	if !t.IsValid() {
+		return false, NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+
+	ok, err := t.Uncomplete()
+	if err != nil {
+		return false, NewError(ErrUnknown, "error uncompleting task id %d: %v", id, err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	ucerr = r.Update(ctx, id, t)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error updating task id %d", id)
+	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityUncompleted); ucerr != nil {
+		return false, ucerr
+	}
+	return true, nil
+}
+
+// AssignTask assigns a valid task to a user who can access it, an empty assignee ID unassigns the task
+func AssignTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, assignee user.ID) Error {
+	ctx, span := tracer.Start(ctx, "usecase.AssignTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	if !t.IsValid() {
+		return NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+
+	if !assignee.IsEmpty() {
+		if _, ucerr := r.GetForUser(ctx, id, assignee); ucerr != nil {
+			if ucerr.Code() == ErrRecordNotFound {
+				return NewError(ErrInvalidData, "user %v cannot access task id %d", assignee, id)
+			}
+			return ucerr.Prefix("error checking assignee access to task id %d", id)
+		}
+	}
+
+	t.SetAssignee(assignee)
+	ucerr = r.Update(ctx, id, t)
+	if ucerr != nil {
+		return ucerr.Prefix("error updating task id %d", id)
+	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityEdited); ucerr != nil {
+		return ucerr
+	}
+	return nil
+}
+
+// CheckTaskItem checks an item on a task's checklist, returns false if the item was already checked
+// The task is completed if it auto-completes and all of its items are checked
+func CheckTaskItem(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, index int) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.CheckTaskItem")
+	defer span.End()
+
+	return setTaskItemChecked(ctx, r, a, id, uid, index, true)
+}
+
+// UncheckTaskItem unchecks an item on a task's checklist, returns false if the item was not checked
+// The task is reopened if it auto-completes and was completed
+func UncheckTaskItem(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, index int) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.UncheckTaskItem")
+	defer span.End()
+
+	return setTaskItemChecked(ctx, r, a, id, uid, index, false)
+}
+
+func setTaskItemChecked(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID, index int, checked bool) (bool, Error) {
+	t, ucerr := r.GetForUser(ctx, id, uid)
+	if ucerr != nil {
+		return false, ucerr.Prefix("error retrieving task id %d", id)
+	}
+
+	if !t.IsValid() {
+		return false, NewError(ErrRecordNotFound, "task id %d not found", id)
+	}
+	if index < 0 || index >= len(t.Checklist()) {
+		return false, NewError(ErrRecordNotFound, "checklist item %d not found for task id %d", index, id)
+	}
+
+	var ok bool
+	var err error
+	if checked {
+		ok, err = t.CheckItem(index)
+	} else {
+		ok, err = t.UncheckItem(index)
+	}
+	if err != nil {
+		return false, NewError(ErrUnknown, "error changing checklist item %d of task id %d: %v", index, id, err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	ucerr = r.Update(ctx, id, t)
 	if ucerr != nil {
 		return false, ucerr.Prefix("error updating task id %d", id)
 	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityEdited); ucerr != nil {
+		return false, ucerr
+	}
 	return true, nil
 }
 
 // ClearTask clears (removes) a single task, regardless of whether it has been completed
-func ClearTask(r TaskRepo, id TaskID, uid user.ID) (bool, Error) {
-	t, ucerr := r.GetForUser(id, uid)
+func ClearTask(ctx context.Context, r TaskRepo, a ActivityRepo, id TaskID, uid user.ID) (bool, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ClearTask")
+	defer span.End()
+
+	t, ucerr := r.GetForUser(ctx, id, uid)
 	if ucerr != nil {
 		if ucerr.Code() == ErrRecordNotFound {
 			return false, ucerr.Prefix("task id %d not found", id)
@@ -90,42 +317,63 @@ func ClearTask(r TaskRepo, id TaskID, uid user.ID) (bool, Error) {
 		return false, NewError(ErrUnknown, "error clearing task id %d: %v", id, err)
 	}
 
-	ucerr = r.Update(id, t)
+	ucerr = r.Update(ctx, id, t)
 	if ucerr != nil {
 		return false, ucerr.Prefix("error updating task id %d", id)
 	}
+	if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityCleared); ucerr != nil {
+		return false, ucerr
+	}
 	return true, nil
 }
 
 // ClearCompletedTasks clears all completed tasks, returning the number completed and an error
-func ClearCompletedTasks(r TaskRepo, uid user.ID) (int, Error) {
-	ts, ucerr := r.GetAllForUser(uid)
+func ClearCompletedTasks(ctx context.Context, r TaskRepo, a ActivityRepo, uid user.ID) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ClearCompletedTasks")
+	defer span.End()
+
+	ids, ucerr := ClearCompletedTaskIDs(ctx, r, a, uid)
+	return len(ids), ucerr
+}
+
+// ClearCompletedTaskIDs clears all completed tasks, returning the IDs of the tasks cleared and an error
+func ClearCompletedTaskIDs(ctx context.Context, r TaskRepo, a ActivityRepo, uid user.ID) ([]TaskID, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ClearCompletedTaskIDs")
+	defer span.End()
+
+	ts, ucerr := r.GetAllForUser(ctx, uid)
 	if ucerr != nil {
-		return 0, ucerr.Prefix("error retrieving tasks to clear")
+		return nil, ucerr.Prefix("error retrieving tasks to clear")
 	}
 
-	count := 0
+	ids := []TaskID{}
 	for id, t := range ts {
 		if t.CompletedTime().IsZero() || !t.IsValid() {
 			continue
 		}
 		err := t.ClearCompleted()
 		if err != nil {
-			return count, NewError(ErrUnknown, "error clearing completed tasks: %v", err)
+			return ids, NewError(ErrUnknown, "error clearing completed tasks: %v", err)
 		}
-		ucerr = r.Update(id, t)
+		ucerr = r.Update(ctx, id, t)
 		if ucerr != nil {
-			return count, ucerr
+			return ids, ucerr
+		}
+		ids = append(ids, id)
+		if ucerr := RecordTaskActivity(ctx, a, id, uid, task.ActivityCleared); ucerr != nil {
+			return ids, ucerr
 		}
-		count++
 	}
 
-	return count, nil
+	return ids, nil
 }
 
 // ListTasks returns all valid (uncleared) tasks
-func ListTasks(r TaskRepo, uid user.ID) (map[TaskID]*task.Task, Error) {
-	all, ucerr := r.GetAllForUser(uid)
+func ListTasks(ctx context.Context, r TaskRepo, uid user.ID) (map[TaskID]*task.Task, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListTasks")
+	defer span.End()
+
+	all, ucerr := r.GetAllForUser(ctx, uid)
 	if ucerr != nil {
 		return nil, ucerr.Prefix("error retrieving tasks")
 	}
@@ -140,3 +388,79 @@ func ListTasks(r TaskRepo, uid user.ID) (map[TaskID]*task.Task, Error) {
 
 	return list, nil
 }
+
+// ListOverdueTasks returns all valid tasks that are incomplete and past their due time
+func ListOverdueTasks(ctx context.Context, r TaskRepo, uid user.ID) (map[TaskID]*task.Task, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListOverdueTasks")
+	defer span.End()
+
+	all, ucerr := ListTasks(ctx, r, uid)
+	if ucerr != nil {
+		return nil, ucerr
+	}
+
+	now := clock.Now()
+	list := make(map[TaskID]*task.Task)
+	for id, t := range all {
+		if t.IsOverdue(now) {
+			list[id] = t
+		}
+	}
+
+	return list, nil
+}
+
+// SortTasks returns a list of tasks in the given order, ties are ordered by task ID
+func SortTasks(ts map[TaskID]*task.Task, by TaskSort) []TaskData {
+	list := make([]TaskData, 0, len(ts))
+	for id, t := range ts {
+		list = append(list, TaskData{TaskID: id, Task: t})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].Task, list[j].Task
+		switch by {
+		case TaskSortDue:
+			if less, ok := dueLess(a, b); ok {
+				return less
+			}
+		case TaskSortPriority:
+			if a.Priority() != b.Priority() {
+				return a.Priority() > b.Priority()
+			}
+			if less, ok := dueLess(a, b); ok {
+				return less
+			}
+		case TaskSortCreated:
+			if !a.CreatedTime().Equal(b.CreatedTime()) {
+				return a.CreatedTime().Before(b.CreatedTime())
+			}
+		}
+		return list[i].TaskID < list[j].TaskID
+	})
+
+	return list
+}
+
+// dueLess compares tasks by due time, with tasks that have no due time last; ok is false if due times are equal
+func dueLess(a *task.Task, b *task.Task) (less bool, ok bool) {
+	ad, bd := a.DueTime(), b.DueTime()
+	if ad.Equal(bd) {
+		return false, false
+	}
+	if ad.IsZero() || bd.IsZero() {
+		return bd.IsZero(), true
+	}
+	return ad.Before(bd), true
+}
+
+// FilterTasksByAssignee returns the tasks assigned to the given user
+func FilterTasksByAssignee(ts map[TaskID]*task.Task, assignee user.ID) map[TaskID]*task.Task {
+	list := make(map[TaskID]*task.Task)
+	for id, t := range ts {
+		if t.Assignee().Equals(assignee) {
+			list[id] = t
+		}
+	}
+	return list
+}
@@ -1,34 +1,39 @@
 package usecase_test
 
 import (
+	"context"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
 	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
 	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
 )
 
 func TestGetTask(t *testing.T) {
+	ctx := context.Background()
+
 	now := clock.Now()
 
 	userRepo := data.NewUserRepo()
 	u1 := user.New("test user for GetTask")
 	uid1 := u1.ID()
-	userRepo.AddExternal(u1, "p1", "e1")
+	userRepo.AddExternal(ctx, u1, "p1", "e1")
 	u2 := user.New("test user 2 for GetTask")
 	uid2 := u2.ID()
-	userRepo.AddExternal(u2, "p1", "e2")
+	userRepo.AddExternal(ctx, u2, "p1", "e2")
 
 	taskRepo := data.NewTaskRepo()
 	task1 := task.New("task1", "", uid1)
-	taskID, _ := taskRepo.Add(task1)
+	taskID, _ := taskRepo.Add(ctx, task1)
 	task2 := task.NewRaw("task2", "", now, time.Time{}, now, uid1)
-	completedTaskID, _ := taskRepo.Add(task2)
-	clearedTaskID, _ := taskRepo.Add(task.NewRaw("task3", "", now, now, now, uid1))
+	completedTaskID, _ := taskRepo.Add(ctx, task2)
+	clearedTaskID, _ := taskRepo.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
 
 	type args struct {
 		r   TaskRepo
@@ -74,7 +79,7 @@ func TestGetTask(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetTask(tt.args.r, tt.args.id, tt.args.uid)
+			got, err := GetTask(ctx, tt.args.r, tt.args.id, tt.args.uid)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("GetTask() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -87,9 +92,12 @@ func TestGetTask(t *testing.T) {
 }
 
 func TestAddTask(t *testing.T) {
+	ctx := context.Background()
+
 	taskRepo := data.NewTaskRepo()
 	emptyTask := task.New("", "", user.ID{})
 	basicTask := task.New("task with data", "task description", user.ID{})
+	longNameTask := task.New(strings.Repeat("n", task.MaxNameLength+1), "", user.ID{})
 
 	type args struct {
 		r TaskRepo
@@ -113,14 +121,23 @@ func TestAddTask(t *testing.T) {
 			want:    basicTask,
 			wantErr: false,
 		},
+		{
+			name:    "add task with a name that is too long should be invalid",
+			args:    args{r: taskRepo, t: longNameTask},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := AddTask(tt.args.r, tt.args.t)
+			got, err := AddTask(ctx, tt.args.r, data.NewActivityRepo(), tt.args.t)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("AddTask() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			if !reflect.DeepEqual(got.Task, tt.want) {
 				t.Errorf("AddTask() = %v, want %v", got.Task, tt.want)
 			}
@@ -129,17 +146,19 @@ func TestAddTask(t *testing.T) {
 }
 
 func TestCompleteTask(t *testing.T) {
+	ctx := context.Background()
+
 	now := clock.Now()
 	r := data.NewTaskRepo()
 	u1 := user.New("new user 1 for CompleteTask")
 	uid1 := u1.ID()
-	taskID, _ := r.Add(task.New("task1", "", uid1))
-	completedTaskID, _ := r.Add(task.NewRaw("task2", "", now, time.Time{}, now, uid1))
-	clearedTaskID, _ := r.Add(task.NewRaw("task3", "", now, now, now, uid1))
+	taskID, _ := r.Add(ctx, task.New("task1", "", uid1))
+	completedTaskID, _ := r.Add(ctx, task.NewRaw("task2", "", now, time.Time{}, now, uid1))
+	clearedTaskID, _ := r.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
 
 	u2 := user.New("new user 2 for CompleteTask")
 	uid2 := u2.ID()
-	u2t1, _ := r.Add(task.New("u2t1", "", uid2))
+	u2t1, _ := r.Add(ctx, task.New("u2t1", "", uid2))
 
 	type args struct {
 		r   TaskRepo
@@ -185,7 +204,7 @@ func TestCompleteTask(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := CompleteTask(tt.args.r, tt.args.id, tt.args.uid)
+			got, err := CompleteTask(ctx, tt.args.r, data.NewActivityRepo(), tt.args.id, tt.args.uid)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("CompleteTask() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -197,18 +216,20 @@ func TestCompleteTask(t *testing.T) {
 }
 
 func TestClearTask(t *testing.T) {
+	ctx := context.Background()
+
 	now := clock.Now()
 
 	u1 := user.New("new user 1 for CompleteTask")
 	uid1 := u1.ID()
 	r := data.NewTaskRepo()
-	taskID, _ := r.Add(task.New("task1", "", uid1))
-	completedTaskID, _ := r.Add(task.NewRaw("task2", "", now, time.Time{}, now, uid1))
-	clearedTaskID, _ := r.Add(task.NewRaw("task3", "", now, now, now, uid1))
+	taskID, _ := r.Add(ctx, task.New("task1", "", uid1))
+	completedTaskID, _ := r.Add(ctx, task.NewRaw("task2", "", now, time.Time{}, now, uid1))
+	clearedTaskID, _ := r.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
 
 	u2 := user.New("new user 2 for CompleteTask")
 	uid2 := u2.ID()
-	u2t1, _ := r.Add(task.New("u2t1", "", uid2))
+	u2t1, _ := r.Add(ctx, task.New("u2t1", "", uid2))
 
 	type args struct {
 		r   TaskRepo
@@ -254,7 +275,7 @@ func TestClearTask(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ClearTask(tt.args.r, tt.args.id, tt.args.uid)
+			got, err := ClearTask(ctx, tt.args.r, data.NewActivityRepo(), tt.args.id, tt.args.uid)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("ClearTask() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -267,6 +288,8 @@ func TestClearTask(t *testing.T) {
 }
 
 func TestClearCompletedTasks(t *testing.T) {
+	ctx := context.Background()
+
 	now := clock.Now()
 	emptyRepo := data.NewTaskRepo()
 
@@ -274,13 +297,13 @@ func TestClearCompletedTasks(t *testing.T) {
 	uid1 := u1.ID()
 
 	singleCompletedTaskRepo := data.NewTaskRepo()
-	singleCompletedTaskRepo.Add(task.New("task1", "", uid1))
-	singleCompletedTaskRepo.Add(task.NewRaw("task2", "", now, time.Time{}, now, uid1))
-	singleCompletedTaskRepo.Add(task.NewRaw("task3", "", now, now, now, uid1))
+	singleCompletedTaskRepo.Add(ctx, task.New("task1", "", uid1))
+	singleCompletedTaskRepo.Add(ctx, task.NewRaw("task2", "", now, time.Time{}, now, uid1))
+	singleCompletedTaskRepo.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
 
 	thousandCompletedTasksRepo := data.NewTaskRepo()
 	for i := 0; i < 1000; i++ {
-		_, err := thousandCompletedTasksRepo.Add(task.NewRaw("", "", now, time.Time{}, now, uid1))
+		_, err := thousandCompletedTasksRepo.Add(ctx, task.NewRaw("", "", now, time.Time{}, now, uid1))
 		if err != nil {
 			t.Errorf("error setting up task repo: %v", err)
 		}
@@ -293,13 +316,13 @@ func TestClearCompletedTasks(t *testing.T) {
 	uid3 := u3.ID()
 	u1t1 := task.New("mixed u1t1", "", uid1)
 	u1t1.CompleteNow()
-	mixedRepo.Add(u1t1)
+	mixedRepo.Add(ctx, u1t1)
 	u1t2 := task.New("mixed u1t2", "", uid1)
 	u1t2.CompleteNow()
-	mixedRepo.Add(u1t2)
+	mixedRepo.Add(ctx, u1t2)
 	u2t1 := task.New("mixed u2t1", "", uid2)
 	u2t1.CompleteNow()
-	mixedRepo.Add(u2t1)
+	mixedRepo.Add(ctx, u2t1)
 
 	type args struct {
 		r   TaskRepo
@@ -344,7 +367,7 @@ func TestClearCompletedTasks(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotCount, err := ClearCompletedTasks(tt.args.r, tt.args.uid)
+			gotCount, err := ClearCompletedTasks(ctx, tt.args.r, data.NewActivityRepo(), tt.args.uid)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ClearCompletedTasks() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -357,17 +380,19 @@ func TestClearCompletedTasks(t *testing.T) {
 }
 
 func TestListTasks(t *testing.T) {
+	ctx := context.Background()
+
 	now := clock.Now()
 	userRepo := data.NewUserRepo()
 	u1 := user.New("test user ListTasks")
 	uid1 := u1.ID()
-	userRepo.AddExternal(u1, "p1", "e1")
+	userRepo.AddExternal(ctx, u1, "p1", "e1")
 	taskRepo := data.NewTaskRepo()
 	task1 := task.New("task1", "", uid1)
-	id1, _ := taskRepo.Add(task1)
+	id1, _ := taskRepo.Add(ctx, task1)
 	task2 := task.NewRaw("task2", "", now, time.Time{}, now, uid1)
-	id2, _ := taskRepo.Add(task2)
-	taskRepo.Add(task.NewRaw("task3", "", now, now, now, uid1))
+	id2, _ := taskRepo.Add(ctx, task2)
+	taskRepo.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
 
 	type args struct {
 		r   TaskRepo
@@ -391,7 +416,7 @@ func TestListTasks(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ListTasks(tt.args.r, tt.args.uid)
+			got, err := ListTasks(ctx, tt.args.r, tt.args.uid)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("ListTasks() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -401,3 +426,421 @@ func TestListTasks(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateTask(t *testing.T) {
+	ctx := context.Background()
+
+	now := clock.Now()
+	r := data.NewTaskRepo()
+	uid1 := user.New("new user 1 for UpdateTask").ID()
+	taskID, _ := r.Add(ctx, task.New("task1", "desc1", uid1))
+	completedTaskID, _ := r.Add(ctx, task.NewRaw("task2", "desc2", now, time.Time{}, now, uid1))
+	clearedTaskID, _ := r.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
+	uid2 := user.New("new user 2 for UpdateTask").ID()
+	u2t1, _ := r.Add(ctx, task.New("u2t1", "", uid2))
+
+	type args struct {
+		r   TaskRepo
+		id  TaskID
+		uid user.ID
+		tu  TaskUpdate
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantName string
+		wantDesc string
+		wantErr  ErrorCode
+	}{
+		{
+			name:     "task name should be changed",
+			args:     args{r, taskID, uid1, TaskUpdate{Name: strp("renamed")}},
+			wantName: "renamed",
+			wantDesc: "desc1",
+			wantErr:  ErrNone,
+		},
+		{
+			name:     "completed task name and description should be changed",
+			args:     args{r, completedTaskID, uid1, TaskUpdate{Name: strp("renamed 2"), Description: strp("")}},
+			wantName: "renamed 2",
+			wantDesc: "",
+			wantErr:  ErrNone,
+		},
+		{
+			name:    "description that is too long should return an ErrInvalidData",
+			args:    args{r, taskID, uid1, TaskUpdate{Description: strp(strings.Repeat("d", task.MaxDescriptionLength+1))}},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "updating a cleared task should return an ErrRecordNotFound",
+			args:    args{r, clearedTaskID, uid1, TaskUpdate{Name: strp("renamed")}},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "updating a task created by another user should return an ErrRecordNotFound",
+			args:    args{r, u2t1, uid1, TaskUpdate{Name: strp("renamed")}},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UpdateTask(ctx, tt.args.r, data.NewActivityRepo(), tt.args.id, tt.args.uid, tt.args.tu)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("UpdateTask() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Task.Name() != tt.wantName || got.Task.Description() != tt.wantDesc {
+				t.Errorf("UpdateTask() = (%v, %v), want (%v, %v)", got.Task.Name(), got.Task.Description(), tt.wantName, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestUncompleteTask(t *testing.T) {
+	ctx := context.Background()
+
+	now := clock.Now()
+	r := data.NewTaskRepo()
+	uid1 := user.New("new user 1 for UncompleteTask").ID()
+	taskID, _ := r.Add(ctx, task.New("task1", "", uid1))
+	completedTaskID, _ := r.Add(ctx, task.NewRaw("task2", "", now, time.Time{}, now, uid1))
+	clearedTaskID, _ := r.Add(ctx, task.NewRaw("task3", "", now, now, now, uid1))
+	uid2 := user.New("new user 2 for UncompleteTask").ID()
+	u2t1, _ := r.Add(ctx, task.NewRaw("u2t1", "", now, time.Time{}, now, uid2))
+
+	type args struct {
+		r   TaskRepo
+		id  TaskID
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr ErrorCode
+	}{
+		{
+			name:    "completed task should be uncompleted",
+			args:    args{r, completedTaskID, uid1},
+			want:    true,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "incomplete task should not be uncompleted",
+			args:    args{r, taskID, uid1},
+			want:    false,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "uncompleting a cleared task should return an ErrRecordNotFound",
+			args:    args{r, clearedTaskID, uid1},
+			want:    false,
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "uncompleting a task created by another user should return an ErrRecordNotFound",
+			args:    args{r, u2t1, uid1},
+			want:    false,
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UncompleteTask(ctx, tt.args.r, data.NewActivityRepo(), tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("UncompleteTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UncompleteTask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func strp(s string) *string {
+	return &s
+}
+
+func TestListOverdueTasks(t *testing.T) {
+	ctx := context.Background()
+
+	now := clock.Now()
+	uid := user.New("test user ListOverdueTasks").ID()
+	taskRepo := data.NewTaskRepo()
+	overdue := task.New("overdue", "", uid)
+	overdue.SetDueTime(now.Add(-time.Hour))
+	id1, _ := taskRepo.Add(ctx, overdue)
+	upcoming := task.New("upcoming", "", uid)
+	upcoming.SetDueTime(now.Add(time.Hour))
+	taskRepo.Add(ctx, upcoming)
+	completed := task.NewRaw("completed", "", now, time.Time{}, now, uid)
+	completed.SetDueTime(now.Add(-time.Hour))
+	taskRepo.Add(ctx, completed)
+	taskRepo.Add(ctx, task.New("no due time", "", uid))
+
+	type args struct {
+		r   TaskRepo
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[TaskID]*task.Task
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should only return incomplete tasks past their due time",
+			args:    args{taskRepo, uid},
+			want:    map[TaskID]*task.Task{id1: overdue},
+			wantErr: ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListOverdueTasks(ctx, tt.args.r, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("ListOverdueTasks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListOverdueTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortTasks(t *testing.T) {
+	now := clock.Now()
+	uid := user.New("test user SortTasks").ID()
+	dueLater := task.NewRaw("due later", "", time.Time{}, time.Time{}, now, uid)
+	dueLater.SetDueTime(now.Add(2 * time.Hour))
+	dueLater.SetPriority(task.PriorityHigh)
+	dueSooner := task.NewRaw("due sooner", "", time.Time{}, time.Time{}, now.Add(time.Minute), uid)
+	dueSooner.SetDueTime(now.Add(time.Hour))
+	dueSooner.SetPriority(task.PriorityLow)
+	noDue := task.NewRaw("no due time", "", time.Time{}, time.Time{}, now.Add(-time.Minute), uid)
+	noDue.SetPriority(task.PriorityHigh)
+	ts := map[TaskID]*task.Task{1: dueLater, 2: dueSooner, 3: noDue}
+
+	type args struct {
+		ts map[TaskID]*task.Task
+		by TaskSort
+	}
+	tests := []struct {
+		name string
+		args args
+		want []TaskID
+	}{
+		{
+			name: "no sort should order by ID",
+			args: args{ts, TaskSortNone},
+			want: []TaskID{1, 2, 3},
+		},
+		{
+			name: "due sort should order by due time with no due time last",
+			args: args{ts, TaskSortDue},
+			want: []TaskID{2, 1, 3},
+		},
+		{
+			name: "priority sort should order by highest priority then due time",
+			args: args{ts, TaskSortPriority},
+			want: []TaskID{1, 3, 2},
+		},
+		{
+			name: "created sort should order by created time",
+			args: args{ts, TaskSortCreated},
+			want: []TaskID{3, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []TaskID{}
+			for _, td := range SortTasks(tt.args.ts, tt.args.by) {
+				got = append(got, td.TaskID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTaskItem(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewTaskRepo()
+	uid := user.New("new user for CheckTaskItem").ID()
+	items, _ := task.ParseChecklist([]string{"item 1", "item 2"})
+	manual := task.New("manual", "", uid)
+	manual.SetChecklist(items)
+	manualID, _ := r.Add(ctx, manual)
+	auto := task.New("auto", "", uid)
+	auto.SetChecklist(items[:1])
+	auto.SetAutoComplete(true)
+	autoID, _ := r.Add(ctx, auto)
+
+	type args struct {
+		r     TaskRepo
+		id    TaskID
+		uid   user.ID
+		index int
+	}
+	tests := []struct {
+		name          string
+		args          args
+		want          bool
+		wantErr       ErrorCode
+		wantCompleted bool
+	}{
+		{
+			name:          "unchecked item should be checked",
+			args:          args{r, manualID, uid, 0},
+			want:          true,
+			wantErr:       ErrNone,
+			wantCompleted: false,
+		},
+		{
+			name:          "checked item should not be checked again",
+			args:          args{r, manualID, uid, 0},
+			want:          false,
+			wantErr:       ErrNone,
+			wantCompleted: false,
+		},
+		{
+			name:          "unknown item should return an ErrRecordNotFound",
+			args:          args{r, manualID, uid, 2},
+			want:          false,
+			wantErr:       ErrRecordNotFound,
+			wantCompleted: false,
+		},
+		{
+			name:          "checking the last item of an auto-complete task should complete it",
+			args:          args{r, autoID, uid, 0},
+			want:          true,
+			wantErr:       ErrNone,
+			wantCompleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckTaskItem(ctx, tt.args.r, data.NewActivityRepo(), tt.args.id, tt.args.uid, tt.args.index)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("CheckTaskItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CheckTaskItem() = %v, want %v", got, tt.want)
+			}
+			tk, _ := tt.args.r.GetForUser(ctx, tt.args.id, tt.args.uid)
+			if completed := !tk.CompletedTime().IsZero(); completed != tt.wantCompleted {
+				t.Errorf("CheckTaskItem() completed = %v, want %v", completed, tt.wantCompleted)
+			}
+		})
+	}
+
+	if ok, err := UncheckTaskItem(ctx, r, data.NewActivityRepo(), autoID, uid, 0); !ok || err != nil {
+		t.Errorf("UncheckTaskItem() = %v, %v, want true, nil", ok, err)
+	}
+	if tk, _ := r.GetForUser(ctx, autoID, uid); !tk.CompletedTime().IsZero() {
+		t.Errorf("UncheckTaskItem() should reopen auto-complete task, completed = %v", tk.CompletedTime())
+	}
+}
+
+func TestAssignTask(t *testing.T) {
+	ctx := context.Background()
+
+	now := clock.Now()
+	workspaceRepo := data.NewWorkspaceRepo()
+	r := data.NewTaskRepo()
+	r.SetWorkspaceRepo(workspaceRepo)
+	uid1 := user.New("new user 1 for AssignTask").ID()
+	uid2 := user.New("new user 2 for AssignTask").ID()
+	uid3 := user.New("new user 3 for AssignTask").ID()
+	w, _ := workspace.New("team", uid1)
+	w.AddMember(uid1, uid2, workspace.RoleMember)
+	workspaceRepo.Add(ctx, w)
+	shared := task.New("shared", "", uid1)
+	shared.SetWorkspace(w.ID())
+	sharedID, _ := r.Add(ctx, shared)
+	personalID, _ := r.Add(ctx, task.New("personal", "", uid1))
+	clearedTaskID, _ := r.Add(ctx, task.NewRaw("cleared", "", now, now, now, uid1))
+
+	type args struct {
+		id       TaskID
+		uid      user.ID
+		assignee user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "shared task should be assigned to a workspace member",
+			args:    args{sharedID, uid1, uid2},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "workspace member should be able to unassign a shared task",
+			args:    args{sharedID, uid2, user.ID{}},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "assigning a shared task to a non-member should return an ErrInvalidData",
+			args:    args{sharedID, uid1, uid3},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "assigning a personal task to another user should return an ErrInvalidData",
+			args:    args{personalID, uid1, uid2},
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "personal task should be assigned to its creator",
+			args:    args{personalID, uid1, uid1},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "assigning a cleared task should return an ErrRecordNotFound",
+			args:    args{clearedTaskID, uid1, uid1},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "assigning a task the user cannot access should return an ErrRecordNotFound",
+			args:    args{personalID, uid3, uid3},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AssignTask(ctx, r, data.NewActivityRepo(), tt.args.id, tt.args.uid, tt.args.assignee)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("AssignTask() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				got, _ := r.Get(ctx, tt.args.id)
+				if !got.Assignee().Equals(tt.args.assignee) {
+					t.Errorf("AssignTask() assignee = %v, want %v", got.Assignee(), tt.args.assignee)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterTasksByAssignee(t *testing.T) {
+	uid1 := user.New("new user 1 for FilterTasksByAssignee").ID()
+	uid2 := user.New("new user 2 for FilterTasksByAssignee").ID()
+	t1 := task.New("task1", "", uid1)
+	t1.SetAssignee(uid1)
+	t2 := task.New("task2", "", uid1)
+	t2.SetAssignee(uid2)
+	t3 := task.New("task3", "", uid1)
+	ts := map[TaskID]*task.Task{1: t1, 2: t2, 3: t3}
+
+	got := FilterTasksByAssignee(ts, uid1)
+	if len(got) != 1 || got[1] != t1 {
+		t.Errorf("FilterTasksByAssignee() = %v, want only task 1", got)
+	}
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+)
+
+// TokenRepo defines the personal access token repository interface required by use cases
+type TokenRepo interface {
+	Get(context.Context, token.ID) (*token.Token, Error)
+	GetByHash(ctx context.Context, hash string) (*token.Token, Error)
+	GetAllForUser(context.Context, user.ID) ([]*token.Token, Error)
+	Add(context.Context, *token.Token) Error
+	Update(context.Context, *token.Token) Error
+}
+
+// TokenGrant describes the scopes a user may give a new personal access token
+type TokenGrant struct {
+	// Known lists every scope a token can have
+	Known []string
+	// Held lists the scopes the user currently has, a token can't be given any others
+	Held []string
+	// ViaToken is set if the user authenticated with a personal access token, which can't create tokens with the token.ManageScope
+	ViaToken bool
+}
+
+// AddToken creates a new personal access token for the user, the token's secret is only returned here
+// each scope must be known and allowed by the grant, otherwise an ErrInvalidData or ErrForbidden error is returned
+func AddToken(ctx context.Context, r TokenRepo, name string, uid user.ID, scopes []string, g TokenGrant, expires time.Time) (*token.Token, string, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddToken")
+	defer span.End()
+
+	for _, s := range scopes {
+		if !containsString(g.Known, s) {
+			return nil, "", NewError(ErrInvalidData, "unknown scope '%v'", s)
+		}
+		if !containsString(g.Held, s) {
+			return nil, "", NewError(ErrForbidden, "scope '%v' cannot be granted, the user doesn't have it", s)
+		}
+		if g.ViaToken && s == token.ManageScope {
+			return nil, "", NewError(ErrForbidden, "scope '%v' cannot be granted by a personal access token", s)
+		}
+	}
+	t, secret, err := token.New(name, uid, scopes, expires)
+	if err != nil {
+		return nil, "", NewError(ErrInvalidData, "error creating token: %v", err)
+	}
+	if ucerr := r.Add(ctx, t); ucerr != nil {
+		return nil, "", ucerr.Prefix("error adding token")
+	}
+	return t, secret, nil
+}
+
+// ListTokens returns all of a user's personal access tokens, newest first
+func ListTokens(ctx context.Context, r TokenRepo, uid user.ID) ([]*token.Token, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListTokens")
+	defer span.End()
+
+	ts, ucerr := r.GetAllForUser(ctx, uid)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving tokens")
+	}
+	sort.SliceStable(ts, func(i, j int) bool { return ts[i].CreatedTime().After(ts[j].CreatedTime()) })
+	return ts, nil
+}
+
+// RevokeToken revokes one of the user's personal access tokens
+func RevokeToken(ctx context.Context, r TokenRepo, id token.ID, uid user.ID) Error {
+	ctx, span := tracer.Start(ctx, "usecase.RevokeToken")
+	defer span.End()
+
+	t, ucerr := r.Get(ctx, id)
+	if ucerr != nil {
+		return ucerr.Prefix("error retrieving token id %v", id)
+	}
+	if !t.UserID().Equals(uid) {
+		return NewError(ErrRecordNotFound, "token id %v not found", id)
+	}
+	t.Revoke()
+	if ucerr := r.Update(ctx, t); ucerr != nil {
+		return ucerr.Prefix("error revoking token id %v", id)
+	}
+	return nil
+}
+
+// AuthenticateToken returns the active personal access token matching a secret
+// Returns an ErrRecordNotFound error for unknown, revoked and expired tokens
+func AuthenticateToken(ctx context.Context, r TokenRepo, secret string) (*token.Token, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AuthenticateToken")
+	defer span.End()
+
+	t, ucerr := r.GetByHash(ctx, token.Hash(secret))
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving token")
+	}
+	if !t.IsActive() {
+		return nil, NewError(ErrRecordNotFound, "token id %v has been revoked or has expired", t.ID())
+	}
+	return t, nil
+}
+
+func containsString(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/token"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+var readGrant = TokenGrant{Known: []string{"read:task"}, Held: []string{"read:task"}}
+
+func TestAddToken(t *testing.T) {
+	ctx := context.Background()
+	grant := TokenGrant{Known: []string{"read:task", "upsert:task", token.ManageScope}, Held: []string{"read:task", token.ManageScope}}
+	viaToken := grant
+	viaToken.ViaToken = true
+
+	tests := []struct {
+		name    string
+		scopes  []string
+		grant   TokenGrant
+		wantErr ErrorCode
+	}{
+		{
+			name:    "should add a token with scopes the user has",
+			scopes:  []string{"read:task", token.ManageScope},
+			grant:   grant,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "unknown scope should return an ErrInvalidData",
+			scopes:  []string{"fly:task"},
+			grant:   grant,
+			wantErr: ErrInvalidData,
+		},
+		{
+			name:    "scope the user doesn't have should return an ErrForbidden",
+			scopes:  []string{"read:task", "upsert:task"},
+			grant:   grant,
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "token should be able to add a token with its other scopes",
+			scopes:  []string{"read:task"},
+			grant:   viaToken,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "token adding a token that manages tokens should return an ErrForbidden",
+			scopes:  []string{token.ManageScope},
+			grant:   viaToken,
+			wantErr: ErrForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := data.NewTokenRepo()
+			uid := user.NewID()
+			_, _, err := AddToken(ctx, r, "ci", uid, tt.scopes, tt.grant, time.Time{})
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("AddToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			wantStored := 0
+			if err == nil {
+				wantStored = 1
+			}
+			if ts, _ := r.GetAllForUser(ctx, uid); len(ts) != wantStored {
+				t.Errorf("AddToken() stored %v tokens, want %v", len(ts), wantStored)
+			}
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewTokenRepo()
+	uid1 := user.NewID()
+	uid2 := user.NewID()
+	tok, _, _ := AddToken(ctx, r, "ci", uid1, []string{"read:task"}, readGrant, time.Time{})
+
+	type args struct {
+		id  token.ID
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "other users should not be able to revoke the token",
+			args:    args{tok.ID(), uid2},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "unknown token should return an ErrRecordNotFound",
+			args:    args{token.NewID(), uid1},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "token owner should be able to revoke the token",
+			args:    args{tok.ID(), uid1},
+			wantErr: ErrNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RevokeToken(ctx, r, tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("RevokeToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && tok.IsActive() {
+				t.Errorf("RevokeToken() token should not be active")
+			}
+		})
+	}
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	prevClock := clock.Get()
+	clock.Set(clock.NewStaticMock(now))
+	defer clock.Set(prevClock)
+
+	r := data.NewTokenRepo()
+	uid := user.NewID()
+	active, activeSecret, _ := AddToken(ctx, r, "active", uid, []string{"read:task"}, readGrant, now.Add(time.Hour))
+	revoked, revokedSecret, _ := AddToken(ctx, r, "revoked", uid, []string{"read:task"}, readGrant, time.Time{})
+	RevokeToken(ctx, r, revoked.ID(), uid)
+	expiredSecret := token.SecretPrefix + "expired"
+	r.Add(ctx, token.NewRaw(token.NewID(), "expired", uid, nil, token.Hash(expiredSecret), now.Add(-2*time.Hour), now.Add(-time.Hour), time.Time{}))
+
+	tests := []struct {
+		name    string
+		secret  string
+		want    *token.Token
+		wantErr ErrorCode
+	}{
+		{
+			name:    "active token should be authenticated",
+			secret:  activeSecret,
+			want:    active,
+			wantErr: ErrNone,
+		},
+		{
+			name:    "revoked token should return an ErrRecordNotFound",
+			secret:  revokedSecret,
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "expired token should return an ErrRecordNotFound",
+			secret:  expiredSecret,
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "unknown secret should return an ErrRecordNotFound",
+			secret:  token.SecretPrefix + "unknown",
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AuthenticateToken(ctx, r, tt.secret)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("AuthenticateToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("AuthenticateToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package usecase
+
+import "go.opentelemetry.io/otel"
+
+// tracer records a span for each use case, as a child of the span in the context it's called with
+var tracer = otel.Tracer("github.com/benjohns1/scheduled-tasks/services/internal/usecase")
@@ -1,31 +1,48 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
 )
 
 // UserRepo defines the user repository interface required by use cases
 type UserRepo interface {
-	AddExternal(u *user.User, providerID string, externalID string) Error
-	Update(*user.User) Error
-	GetExternal(providerID string, externalID string) (*user.User, Error)
+	Get(context.Context, user.ID) (*user.User, Error)
+	AddExternal(ctx context.Context, u *user.User, providerID string, externalID string) Error
+	Update(context.Context, *user.User) Error
+	GetExternal(ctx context.Context, providerID string, externalID string) (*user.User, Error)
+}
+
+// GetUser returns a user given their ID
+func GetUser(ctx context.Context, r UserRepo, id user.ID) (*user.User, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetUser")
+	defer span.End()
+
+	return r.Get(ctx, id)
 }
 
 // GetExternalUser looks up a user by an external provider's ID, then returns it
-func GetExternalUser(r UserRepo, providerID string, externalID string) (*user.User, Error) {
-	return r.GetExternal(providerID, externalID)
+func GetExternalUser(ctx context.Context, r UserRepo, providerID string, externalID string) (*user.User, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetExternalUser")
+	defer span.End()
+
+	return r.GetExternal(ctx, providerID, externalID)
 }
 
 // AddOrUpdateExternalUser looks up a user by an external provider's ID, then either adds them or updates their displayname if needed
-func AddOrUpdateExternalUser(r UserRepo, providerID string, externalID string, displayname string) (*user.User, Error) {
-	u, err := r.GetExternal(providerID, externalID)
+func AddOrUpdateExternalUser(ctx context.Context, r UserRepo, providerID string, externalID string, displayname string) (*user.User, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddOrUpdateExternalUser")
+	defer span.End()
+
+	u, err := r.GetExternal(ctx, providerID, externalID)
 	if err != nil {
 		if err.Code() != ErrRecordNotFound {
 			return nil, err
 		}
 
 		u = user.New(displayname)
-		if err := r.AddExternal(u, providerID, externalID); err != nil {
+		if err := r.AddExternal(ctx, u, providerID, externalID); err != nil {
 			return nil, err.Prefix("error adding external user: ")
 		}
 		return u, nil
@@ -33,7 +50,7 @@ func AddOrUpdateExternalUser(r UserRepo, providerID string, externalID string, d
 
 	if u.DisplayName() != displayname {
 		u.UpdateDisplayName(displayname)
-		if err := r.Update(u); err != nil {
+		if err := r.Update(ctx, u); err != nil {
 			return nil, err.Prefix("error updating user: ")
 		}
 	}
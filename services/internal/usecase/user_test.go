@@ -1,6 +1,7 @@
 package usecase_test
 
 import (
+	"context"
 	"testing"
 
 	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
@@ -8,6 +9,8 @@ import (
 )
 
 func TestAddOrUpdateExternalUser(t *testing.T) {
+	ctx := context.Background()
+
 	r := data.NewUserRepo()
 
 	type args struct {
@@ -40,7 +43,7 @@ func TestAddOrUpdateExternalUser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := AddOrUpdateExternalUser(tt.args.r, tt.args.providerID, tt.args.externalID, tt.args.displayname)
+			got, err := AddOrUpdateExternalUser(ctx, tt.args.r, tt.args.providerID, tt.args.externalID, tt.args.displayname)
 			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
 				t.Errorf("AddOrUpdateExternalUser() error = %v, wantErr %v", err, tt.wantErr)
 				return
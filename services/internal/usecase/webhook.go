@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/clock"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+)
+
+// WebhookRepo defines the webhook and webhook delivery repository interface required by use cases
+type WebhookRepo interface {
+	Get(context.Context, webhook.ID) (*webhook.Webhook, Error)
+	GetAllForUser(context.Context, user.ID) ([]*webhook.Webhook, Error)
+	GetSubscribed(ctx context.Context, e webhook.EventType, uids []user.ID) ([]*webhook.Webhook, Error)
+	Add(context.Context, *webhook.Webhook) Error
+	Update(context.Context, *webhook.Webhook) Error
+	GetDeliveries(ctx context.Context, id webhook.ID, limit int) ([]*webhook.Delivery, Error)
+	GetDueDeliveries(ctx context.Context, before time.Time, limit int) ([]*webhook.Delivery, Error)
+	NextDeliveryTime(context.Context) (time.Time, Error)
+	AddDeliveries(context.Context, []*webhook.Delivery) Error
+	UpdateDelivery(context.Context, *webhook.Delivery) Error
+}
+
+// WebhookSender sends a delivery's payload to its webhook, returning the HTTP status code of the response
+// an error is only returned if no response was received
+type WebhookSender interface {
+	Send(ctx context.Context, w *webhook.Webhook, d *webhook.Delivery) (int, error)
+}
+
+// MaxWebhookDeliveries is the maximum number of deliveries listed for a webhook
+const MaxWebhookDeliveries = 100
+
+// deliveryBatchSize is the maximum number of due deliveries attempted in a single run
+const deliveryBatchSize = 100
+
+// AddWebhook subscribes a URL to a user's task and schedule events
+func AddWebhook(ctx context.Context, r WebhookRepo, url string, events []webhook.EventType, secret string, uid user.ID) (*webhook.Webhook, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddWebhook")
+	defer span.End()
+
+	w, err := webhook.New(url, events, secret, uid)
+	if err != nil {
+		return nil, NewError(ErrInvalidData, "error creating webhook: %v", err)
+	}
+	if ucerr := r.Add(ctx, w); ucerr != nil {
+		return nil, ucerr.Prefix("error adding webhook")
+	}
+	return w, nil
+}
+
+// ListWebhooks returns all of a user's active webhooks, newest first
+func ListWebhooks(ctx context.Context, r WebhookRepo, uid user.ID) ([]*webhook.Webhook, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListWebhooks")
+	defer span.End()
+
+	ws, ucerr := r.GetAllForUser(ctx, uid)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving webhooks")
+	}
+	active := []*webhook.Webhook{}
+	for _, w := range ws {
+		if w.IsActive() {
+			active = append(active, w)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].CreatedTime().After(active[j].CreatedTime()) })
+	return active, nil
+}
+
+// RemoveWebhook removes one of the user's webhooks, its pending deliveries are cancelled
+func RemoveWebhook(ctx context.Context, r WebhookRepo, id webhook.ID, uid user.ID) Error {
+	ctx, span := tracer.Start(ctx, "usecase.RemoveWebhook")
+	defer span.End()
+
+	w, ucerr := getUserWebhook(ctx, r, id, uid)
+	if ucerr != nil {
+		return ucerr
+	}
+	w.Remove()
+	if ucerr := r.Update(ctx, w); ucerr != nil {
+		return ucerr.Prefix("error removing webhook id %v", id)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the most recent deliveries to one of the user's webhooks, newest first
+func ListWebhookDeliveries(ctx context.Context, r WebhookRepo, id webhook.ID, uid user.ID) ([]*webhook.Delivery, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListWebhookDeliveries")
+	defer span.End()
+
+	if _, ucerr := getUserWebhook(ctx, r, id, uid); ucerr != nil {
+		return nil, ucerr
+	}
+	ds, ucerr := r.GetDeliveries(ctx, id, MaxWebhookDeliveries)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving deliveries for webhook id %v", id)
+	}
+	return ds, nil
+}
+
+func getUserWebhook(ctx context.Context, r WebhookRepo, id webhook.ID, uid user.ID) (*webhook.Webhook, Error) {
+	w, ucerr := r.Get(ctx, id)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving webhook id %v", id)
+	}
+	if !w.UserID().Equals(uid) || !w.IsActive() {
+		return nil, NewError(ErrRecordNotFound, "webhook id %v not found", id)
+	}
+	return w, nil
+}
+
+// QueueWebhookDeliveries queues an event payload for delivery to every webhook subscribed to the event
+// webhooks belonging to the owner of the changed task or schedule are notified, along with those of all members of the workspace it is shared with
+// returns the number of deliveries queued
+func QueueWebhookDeliveries(ctx context.Context, r WebhookRepo, workspaceRepo WorkspaceRepo, e webhook.EventType, payload []byte, owner user.ID, wsID workspace.ID) (int, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.QueueWebhookDeliveries")
+	defer span.End()
+
+	uids := []user.ID{owner}
+	if !wsID.IsEmpty() {
+		ws, ucerr := workspaceRepo.Get(ctx, wsID)
+		if ucerr != nil {
+			return 0, ucerr.Prefix("error retrieving workspace id %v", wsID)
+		}
+		for _, m := range ws.Members() {
+			if !m.UserID().Equals(owner) {
+				uids = append(uids, m.UserID())
+			}
+		}
+	}
+
+	ws, ucerr := r.GetSubscribed(ctx, e, uids)
+	if ucerr != nil {
+		return 0, ucerr.Prefix("error retrieving webhooks subscribed to %v", e)
+	}
+	if len(ws) == 0 {
+		return 0, nil
+	}
+	ds := make([]*webhook.Delivery, 0, len(ws))
+	for _, w := range ws {
+		ds = append(ds, webhook.NewDelivery(w.ID(), e, payload))
+	}
+	if ucerr := r.AddDeliveries(ctx, ds); ucerr != nil {
+		return 0, ucerr.Prefix("error queueing %v deliveries", e)
+	}
+	return len(ds), nil
+}
+
+// DeliverWebhooks attempts every delivery that is due, recording the outcome of each attempt
+// failed attempts are retried according to the retry policy, deliveries to removed webhooks are cancelled
+// returns when the next delivery is due, zero if none are pending
+func DeliverWebhooks(ctx context.Context, r WebhookRepo, sender WebhookSender, p webhook.RetryPolicy) (time.Time, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.DeliverWebhooks")
+	defer span.End()
+
+	now := clock.Now()
+	ds, ucerr := r.GetDueDeliveries(ctx, now, deliveryBatchSize)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving due deliveries")
+	}
+	for _, d := range ds {
+		w, ucerr := r.Get(ctx, d.WebhookID())
+		switch {
+		case ucerr != nil && ucerr.Code() != ErrRecordNotFound:
+			return time.Time{}, ucerr.Prefix("error retrieving webhook id %v", d.WebhookID())
+		case ucerr != nil || !w.IsActive():
+			d.Cancel("webhook has been removed")
+		default:
+			code, err := sender.Send(ctx, w, d)
+			switch {
+			case err != nil:
+				d.Failed(0, err.Error(), p)
+			case code < 200 || code >= 300:
+				d.Failed(code, http.StatusText(code), p)
+			default:
+				d.Succeeded(code)
+			}
+		}
+		if ucerr := r.UpdateDelivery(ctx, d); ucerr != nil {
+			return time.Time{}, ucerr.Prefix("error updating delivery id %v", d.ID())
+		}
+	}
+	if len(ds) == deliveryBatchSize {
+		return now, nil
+	}
+
+	next, ucerr := r.NextDeliveryTime(ctx)
+	if ucerr != nil {
+		return time.Time{}, ucerr.Prefix("error retrieving next delivery time")
+	}
+	return next, nil
+}
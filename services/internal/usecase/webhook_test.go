@@ -0,0 +1,192 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/webhook"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestRemoveWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewWebhookRepo()
+	uid1 := user.NewID()
+	uid2 := user.NewID()
+	w, _ := AddWebhook(ctx, r, "https://example.com/hook", []webhook.EventType{webhook.EventTaskCreated}, "", uid1)
+
+	type args struct {
+		id  webhook.ID
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "other users should not be able to remove the webhook",
+			args:    args{w.ID(), uid2},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "unknown webhook should return an ErrRecordNotFound",
+			args:    args{webhook.NewID(), uid1},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "webhook owner should be able to remove the webhook",
+			args:    args{w.ID(), uid1},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "removed webhook should return an ErrRecordNotFound",
+			args:    args{w.ID(), uid1},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RemoveWebhook(ctx, r, tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("RemoveWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if ws, _ := ListWebhooks(ctx, r, uid1); len(ws) != 0 {
+		t.Errorf("ListWebhooks() = %v, want removed webhook to be hidden", ws)
+	}
+}
+
+func TestQueueWebhookDeliveries(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewWebhookRepo()
+	workspaceRepo := data.NewWorkspaceRepo()
+	owner, member, outsider := user.NewID(), user.NewID(), user.NewID()
+	ws, _ := workspace.New("ops", owner)
+	ws.AddMember(owner, member, workspace.RoleMember)
+	workspaceRepo.Add(ctx, ws)
+	events := []webhook.EventType{webhook.EventTaskCreated}
+	AddWebhook(ctx, r, "https://example.com/owner", events, "", owner)
+	AddWebhook(ctx, r, "https://example.com/member", events, "", member)
+	AddWebhook(ctx, r, "https://example.com/outsider", events, "", outsider)
+	AddWebhook(ctx, r, "https://example.com/cleared", []webhook.EventType{webhook.EventTaskCleared}, "", owner)
+
+	tests := []struct {
+		name      string
+		workspace workspace.ID
+		want      int
+	}{
+		{
+			name: "should only deliver to the owner's webhooks",
+			want: 1,
+		},
+		{
+			name:      "should deliver to webhooks of all workspace members",
+			workspace: ws.ID(),
+			want:      2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QueueWebhookDeliveries(ctx, r, workspaceRepo, webhook.EventTaskCreated, []byte("{}"), owner, tt.workspace)
+			if err != nil {
+				t.Fatalf("QueueWebhookDeliveries() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("QueueWebhookDeliveries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type senderStub struct {
+	code int
+	err  error
+	sent int
+}
+
+func (s *senderStub) Send(ctx context.Context, w *webhook.Webhook, d *webhook.Delivery) (int, error) {
+	s.sent++
+	return s.code, s.err
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	ctx := context.Background()
+	policy := webhook.RetryPolicy{MaxAttempts: 3, Delay: time.Minute}
+
+	tests := []struct {
+		name       string
+		sender     *senderStub
+		remove     bool
+		wantSent   int
+		wantStatus webhook.DeliveryStatus
+		wantError  string
+		wantNext   bool
+	}{
+		{
+			name:       "successful response should complete the delivery",
+			sender:     &senderStub{code: http.StatusOK},
+			wantSent:   1,
+			wantStatus: webhook.DeliverySucceeded,
+		},
+		{
+			name:       "error response should schedule a retry",
+			sender:     &senderStub{code: http.StatusNotFound},
+			wantSent:   1,
+			wantStatus: webhook.DeliveryPending,
+			wantError:  "Not Found",
+			wantNext:   true,
+		},
+		{
+			name:       "no response should schedule a retry",
+			sender:     &senderStub{err: errors.New("connection refused")},
+			wantSent:   1,
+			wantStatus: webhook.DeliveryPending,
+			wantError:  "connection refused",
+			wantNext:   true,
+		},
+		{
+			name:       "deliveries to removed webhooks should be cancelled",
+			sender:     &senderStub{code: http.StatusOK},
+			remove:     true,
+			wantSent:   0,
+			wantStatus: webhook.DeliveryFailed,
+			wantError:  "webhook has been removed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := data.NewWebhookRepo()
+			uid := user.NewID()
+			w, _ := AddWebhook(ctx, r, "https://example.com/hook", []webhook.EventType{webhook.EventTaskCreated}, "", uid)
+			QueueWebhookDeliveries(ctx, r, nil, webhook.EventTaskCreated, []byte("{}"), uid, workspace.ID{})
+			if tt.remove {
+				RemoveWebhook(ctx, r, w.ID(), uid)
+			}
+
+			next, err := DeliverWebhooks(ctx, r, tt.sender, policy)
+			if err != nil {
+				t.Fatalf("DeliverWebhooks() error = %v", err)
+			}
+			if next.IsZero() == tt.wantNext {
+				t.Errorf("DeliverWebhooks() next = %v, want retry scheduled %v", next, tt.wantNext)
+			}
+			if tt.sender.sent != tt.wantSent {
+				t.Errorf("DeliverWebhooks() sent %v requests, want %v", tt.sender.sent, tt.wantSent)
+			}
+			ds, _ := r.GetDeliveries(ctx, w.ID(), 10)
+			if len(ds) != 1 || ds[0].Status() != tt.wantStatus || ds[0].LastError() != tt.wantError {
+				t.Errorf("DeliverWebhooks() deliveries = %+v, want 1 %v delivery with error %q", ds, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+)
+
+// WorkspaceRepo defines the workspace repository interface required by use cases
+type WorkspaceRepo interface {
+	Get(context.Context, workspace.ID) (*workspace.Workspace, Error)
+	GetAllForUser(context.Context, user.ID) ([]*workspace.Workspace, Error)
+	Add(context.Context, *workspace.Workspace) Error
+	Update(context.Context, *workspace.Workspace) Error
+}
+
+// AddWorkspace creates a new workspace owned by the user
+func AddWorkspace(ctx context.Context, r WorkspaceRepo, name string, uid user.ID) (*workspace.Workspace, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.AddWorkspace")
+	defer span.End()
+
+	w, err := workspace.New(name, uid)
+	if err != nil {
+		return nil, NewError(ErrInvalidData, "error creating workspace: %v", err)
+	}
+	if ucerr := r.Add(ctx, w); ucerr != nil {
+		return nil, ucerr.Prefix("error adding workspace")
+	}
+	return w, nil
+}
+
+// GetWorkspace gets a single workspace the user is a member of
+func GetWorkspace(ctx context.Context, r WorkspaceRepo, id workspace.ID, uid user.ID) (*workspace.Workspace, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.GetWorkspace")
+	defer span.End()
+
+	w, ucerr := r.Get(ctx, id)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving workspace id %v", id)
+	}
+	if !w.IsMember(uid) {
+		return nil, NewError(ErrRecordNotFound, "workspace id %v not found", id)
+	}
+	return w, nil
+}
+
+// ListWorkspaces returns all workspaces the user is a member of, sorted by name
+func ListWorkspaces(ctx context.Context, r WorkspaceRepo, uid user.ID) ([]*workspace.Workspace, Error) {
+	ctx, span := tracer.Start(ctx, "usecase.ListWorkspaces")
+	defer span.End()
+
+	ws, ucerr := r.GetAllForUser(ctx, uid)
+	if ucerr != nil {
+		return nil, ucerr.Prefix("error retrieving workspaces")
+	}
+	sort.SliceStable(ws, func(i, j int) bool { return ws[i].Name() < ws[j].Name() })
+	return ws, nil
+}
+
+// InviteMember adds an existing user to a workspace, the inviting user must be a workspace owner or admin
+func InviteMember(ctx context.Context, r WorkspaceRepo, userRepo UserRepo, id workspace.ID, invitedBy user.ID, uid user.ID, role workspace.Role) Error {
+	ctx, span := tracer.Start(ctx, "usecase.InviteMember")
+	defer span.End()
+
+	w, ucerr := GetWorkspace(ctx, r, id, invitedBy)
+	if ucerr != nil {
+		return ucerr
+	}
+	if inviterRole, _ := w.Role(invitedBy); !inviterRole.CanManageMembers() || role > inviterRole {
+		return NewError(ErrForbidden, "user %v cannot invite a %v to workspace id %v", invitedBy, role, id)
+	}
+	if w.IsMember(uid) {
+		return NewError(ErrDuplicateRecord, "user %v is already a member of workspace id %v", uid, id)
+	}
+	if _, ucerr := userRepo.Get(ctx, uid); ucerr != nil {
+		return ucerr.Prefix("error retrieving invited user %v", uid)
+	}
+	if err := w.AddMember(invitedBy, uid, role); err != nil {
+		return NewError(ErrInvalidData, "error inviting user %v to workspace id %v: %v", uid, id, err)
+	}
+	if ucerr := r.Update(ctx, w); ucerr != nil {
+		return ucerr.Prefix("error updating workspace id %v", id)
+	}
+	return nil
+}
+
+// CheckWorkspaceMember returns an ErrRecordNotFound error unless the user is a member of the workspace
+// An empty workspace ID is always allowed, it refers to the user's own tasks and schedules
+func CheckWorkspaceMember(ctx context.Context, r WorkspaceRepo, id workspace.ID, uid user.ID) Error {
+	ctx, span := tracer.Start(ctx, "usecase.CheckWorkspaceMember")
+	defer span.End()
+
+	if id.IsEmpty() {
+		return nil
+	}
+	_, ucerr := GetWorkspace(ctx, r, id, uid)
+	return ucerr
+}
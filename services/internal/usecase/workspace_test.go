@@ -0,0 +1,221 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/task"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/user"
+	"github.com/benjohns1/scheduled-tasks/services/internal/core/workspace"
+	data "github.com/benjohns1/scheduled-tasks/services/internal/data/transient"
+	. "github.com/benjohns1/scheduled-tasks/services/internal/usecase"
+)
+
+func TestAddWorkspace(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewWorkspaceRepo()
+	uid := user.New("new user for AddWorkspace").ID()
+
+	type args struct {
+		name string
+		uid  user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "workspace should be added",
+			args:    args{"team", uid},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "empty workspace name should return an ErrInvalidData",
+			args:    args{"", uid},
+			wantErr: ErrInvalidData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddWorkspace(ctx, r, tt.args.name, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("AddWorkspace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !got.IsMember(tt.args.uid) {
+				t.Errorf("AddWorkspace() = %v, want user %v to be a member", got, tt.args.uid)
+			}
+		})
+	}
+}
+
+func TestGetWorkspace(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewWorkspaceRepo()
+	uid1 := user.New("new user 1 for GetWorkspace").ID()
+	uid2 := user.New("new user 2 for GetWorkspace").ID()
+	w, _ := AddWorkspace(ctx, r, "team", uid1)
+
+	type args struct {
+		id  workspace.ID
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "member should get workspace",
+			args:    args{w.ID(), uid1},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "non-member should get an ErrRecordNotFound",
+			args:    args{w.ID(), uid2},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "unknown workspace should return an ErrRecordNotFound",
+			args:    args{workspace.NewID(), uid1},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetWorkspace(ctx, r, tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("GetWorkspace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !got.ID().Equals(tt.args.id) {
+				t.Errorf("GetWorkspace() = %v, want ID %v", got.ID(), tt.args.id)
+			}
+		})
+	}
+}
+
+func TestInviteMember(t *testing.T) {
+	ctx := context.Background()
+
+	r := data.NewWorkspaceRepo()
+	userRepo := data.NewUserRepo()
+	newUser := func(name string) user.ID {
+		u := user.New(name)
+		userRepo.AddExternal(ctx, u, "p", name)
+		return u.ID()
+	}
+	owner := newUser("owner for InviteMember")
+	member := newUser("member for InviteMember")
+	invitee := newUser("invitee for InviteMember")
+	w, _ := AddWorkspace(ctx, r, "team", owner)
+	InviteMember(ctx, r, userRepo, w.ID(), owner, member, workspace.RoleMember)
+
+	type args struct {
+		id        workspace.ID
+		invitedBy user.ID
+		uid       user.ID
+		role      workspace.Role
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "member should not be able to invite users",
+			args:    args{w.ID(), member, invitee, workspace.RoleMember},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "non-member inviting users should get an ErrRecordNotFound",
+			args:    args{w.ID(), invitee, invitee, workspace.RoleMember},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "inviting an unknown user should return an ErrRecordNotFound",
+			args:    args{w.ID(), owner, user.NewID(), workspace.RoleMember},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "owner should be able to invite an admin",
+			args:    args{w.ID(), owner, invitee, workspace.RoleAdmin},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "inviting an existing member should return an ErrDuplicateRecord",
+			args:    args{w.ID(), owner, member, workspace.RoleMember},
+			wantErr: ErrDuplicateRecord,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := InviteMember(ctx, r, userRepo, tt.args.id, tt.args.invitedBy, tt.args.uid, tt.args.role)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("InviteMember() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if role, ok := w.Role(tt.args.uid); !ok || role != tt.args.role {
+					t.Errorf("InviteMember() role = %v, %v, want %v, true", role, ok, tt.args.role)
+				}
+			}
+		})
+	}
+}
+
+func TestGetTask_workspace(t *testing.T) {
+	ctx := context.Background()
+
+	workspaceRepo := data.NewWorkspaceRepo()
+	userRepo := data.NewUserRepo()
+	taskRepo := data.NewTaskRepo()
+	taskRepo.SetWorkspaceRepo(workspaceRepo)
+	owner := user.New("owner for shared GetTask")
+	member := user.New("member for shared GetTask")
+	outsider := user.New("outsider for shared GetTask")
+	userRepo.AddExternal(ctx, member, "p", "member")
+	w, _ := AddWorkspace(ctx, workspaceRepo, "team", owner.ID())
+	InviteMember(ctx, workspaceRepo, userRepo, w.ID(), owner.ID(), member.ID(), workspace.RoleMember)
+	shared := task.New("shared", "", owner.ID())
+	shared.SetWorkspace(w.ID())
+	sharedID, _ := taskRepo.Add(ctx, shared)
+	personalID, _ := taskRepo.Add(ctx, task.New("personal", "", owner.ID()))
+
+	type args struct {
+		id  TaskID
+		uid user.ID
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr ErrorCode
+	}{
+		{
+			name:    "workspace member should get shared task",
+			args:    args{sharedID, member.ID()},
+			wantErr: ErrNone,
+		},
+		{
+			name:    "non-member should not get shared task",
+			args:    args{sharedID, outsider.ID()},
+			wantErr: ErrRecordNotFound,
+		},
+		{
+			name:    "workspace member should not get another member's personal task",
+			args:    args{personalID, member.ID()},
+			wantErr: ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GetTask(ctx, taskRepo, tt.args.id, tt.args.uid)
+			if ((err == nil) != (tt.wantErr == ErrNone)) || ((err != nil) && (tt.wantErr != err.Code())) {
+				t.Errorf("GetTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}